@@ -2,7 +2,9 @@ package configs
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -12,27 +14,89 @@ import (
 
 // Config holds the application configuration values.
 type Config struct {
-	ServerHost          string
-	ServerPort          string
-	ServerMode          string
-	DatabaseHost        string
-	DatabasePort        string
-	DatabaseUser        string
-	DatabasePassword    string
-	DatabaseName        string
-	DatabaseURL         string
-	DevUserEmail        string
-	DevUserName         string
-	DevUserPassword     string
-	LogLevel            string
-	JWTSecret           string
-	JWTLifetime         time.Duration
-	MySQLRootPassword   string
-	CORSOrigins         []string
-	NumberOfCrawlers    int // Number of concurrent crawlers
-	MaxConcurrentCrawls int
-	CrawlTimeout        time.Duration
-	UserAgent           string
+	ServerHost           string
+	ServerPort           string
+	ServerMode           string
+	DatabaseHost         string
+	DatabasePort         string
+	DatabaseUser         string
+	DatabasePassword     string
+	DatabaseName         string
+	DatabaseURL          string
+	DeadlockRetries      int // Retries for writes hitting a MySQL deadlock or lock wait timeout
+	DevUserEmail         string
+	DevUserName          string
+	DevUserPassword      string
+	DevLogAuthTokens     bool   // Development only: log password reset and email verification tokens, which cannot be emailed yet
+	LogLevel             string // debug, info, warn or error
+	LogFormat            string // Request log format: json or text
+	LogHTTPBodies        bool   // Log request/response bodies; only honoured when LogLevel is "debug"
+	LogHTTPBodyMaxBytes  int
+	JWTSecret            string
+	JWTLifetime          time.Duration
+	JWTRefreshLifetime   time.Duration // Lifetime of refresh tokens handed out at login
+	PasswordResetTTL     time.Duration // How long a password reset token can be redeemed
+	EmailVerifyTTL       time.Duration // How long an email verification token can be redeemed
+	EmailVerifyResend    time.Duration // Minimum time between verification tokens sent to one user
+	RequireVerifiedEmail bool          // URL routes refuse users whose email is not verified
+	UsernameMatchCase    bool          // "Alice" and "alice" may both register; needs a case-sensitive users.username collation
+	APIKeyMaxLifetime    time.Duration // Longest lifetime of a new API key, and the lifetime of keys created without one (0 means no limit)
+	APIKeyRequireExpiry  bool          // Refuse API keys created without an expiry
+	MySQLRootPassword    string
+	CORSOrigins          []string
+	SlowRequestThreshold time.Duration // Requests slower than this are logged as warnings (0 disables)
+	HTTPReadTimeout      time.Duration
+	HTTPWriteTimeout     time.Duration // Not applied to streaming (SSE) responses
+	SSEHeartbeat         time.Duration // Interval between SSE keep-alive comments (0 disables)
+	SSEMaxLifetime       time.Duration // SSE connections are closed after this long (0 means no limit)
+	ResultsMaxLinks      int           // Links inlined in GET /urls/{id}/results (0 means no limit)
+	StreamMaxConns       int           // Concurrent SSE and WebSocket connections (0 means no limit)
+	StreamMaxConnsUser   int           // Concurrent SSE and WebSocket connections per user (0 means no limit)
+	NumberOfCrawlers     int           // Number of concurrent crawlers
+	MaxConcurrentCrawls  int
+	CrawlTimeout         time.Duration
+	CrawlHTTPTimeout     time.Duration // Bounds each page request; the crawl timeout bounds the whole analysis
+	LinkCheckConcurrency int           // Links of a page probed at once for broken-link detection
+	CrawlRetryAttempts   int           // Attempts per crawl, including the first (1 disables retries)
+	CrawlRetryDelay      time.Duration // Wait before the first retry; doubled for each further one
+	RespectRobots        bool          // Skip URLs the site's robots.txt disallows for UserAgent
+	RobotsCacheTTL       time.Duration // How long a fetched robots.txt is reused per host
+	HostConcurrency      int           // Crawls of one host running at once across all workers (0 disables); URLs may override
+	QueueCapacity        int           // URLs waiting to be crawled before starts are refused (0 means the queue size)
+	CrawlQuotaPerUser    int           // URLs a user may have queued or running at once (0 means no limit)
+	// CrawlQuotaByRole overrides CrawlQuotaPerUser per role; admins default to 0.
+	CrawlQuotaByRole     map[string]int
+	WorkerIdleTimeout    time.Duration // Idle workers exit after this long, down to MinCrawlers (0 disables)
+	MinCrawlers          int           // Workers kept however idle the pool is
+	ReservedCrawlers     int           // Workers kept for URLs of at least ReservedMinPriority (0 disables)
+	ReservedMinPriority  int           // Lowest priority the reserved workers take
+	SlowCrawlThreshold   time.Duration // URLs whose last crawl took longer are re-queued at lower priority (0 disables)
+	SlowCrawlPenalty     int           // Priority levels subtracted for such URLs
+	WatchdogInterval     time.Duration // Time between checks for URLs stuck in running (0 disables)
+	StuckCrawlAfter      time.Duration // URLs running for longer than this are considered stuck
+	StuckCrawlAction     string        // Status stuck URLs are reset to: queued (retried) or error
+	TrashRetention       time.Duration // Deleted URLs are purged for good after this long (0 keeps them)
+	TrashPurgeInterval   time.Duration // Time between purges of expired deleted URLs
+	UserAgent            string
+	UnknownContentPolicy string // How non-HTML responses are handled: skip, parse or metadata
+	MaxRedirects         int
+	MaxIdleConnsPerHost  int    // Idle keep-alive connections kept per crawled host
+	ExternalLinkBase     string // Host links are classified against: original or final (after redirects)
+	EgressMode           string // "denylist" or "allowlist"
+	EgressHosts          []string
+	HTTPSOnly            bool     // Reject http:// URLs at create time and refuse to fetch them
+	InsecureTLSHosts     []string // Hosts crawled without TLS certificate verification (self-signed targets)
+	StoreRawHTML         bool     // Keep a copy of every crawled page body in the blob store
+	BlobBackend          string   // Blob store backend; only "fs" is built in
+	BlobDir              string
+	TitleFallback        []string // Title sources tried, in order, for pages without <title>: h1, og_title, url_path
+	AutoTagHost          bool     // Tag every created URL with its host name
+	CrawlOnCreate        bool     // Queue new URLs for crawling right away; ?crawl= overrides it per request
+	LinkStripParams      []string // Query parameter patterns removed from links, e.g. "utm_*"
+	LinkLowercaseHost    bool
+	LinkStripSlash       bool
+	LinkStripFragment    bool
+	MetricsEnabled       bool // Serve Prometheus metrics at /metrics, and as JSON to admins, and instrument crawls and requests
 }
 
 // Load reads configuration exclusively from environment variables (optionally .env file).
@@ -73,8 +137,32 @@ func Load() (*Config, error) {
 		cfg.DatabaseName,
 	)
 
+	retries, err := strconv.Atoi(getEnv("DB_DEADLOCK_RETRIES", "3"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_DEADLOCK_RETRIES: %w", err)
+	}
+	cfg.DeadlockRetries = retries
+
 	// Logging & Auth
 	cfg.LogLevel = getEnv("LOG_LEVEL", "info")
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.LogLevel)); err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVEL: %q", cfg.LogLevel)
+	}
+	cfg.LogFormat = getEnv("LOG_FORMAT", "json")
+	if cfg.LogFormat != "json" && cfg.LogFormat != "text" {
+		return nil, fmt.Errorf("invalid LOG_FORMAT: %q", cfg.LogFormat)
+	}
+	logBodies, err := strconv.ParseBool(getEnv("LOG_HTTP_BODIES", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOG_HTTP_BODIES: %w", err)
+	}
+	cfg.LogHTTPBodies = logBodies
+	bodyMax, err := strconv.Atoi(getEnv("LOG_HTTP_BODY_MAX_BYTES", "2048"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOG_HTTP_BODY_MAX_BYTES: %w", err)
+	}
+	cfg.LogHTTPBodyMaxBytes = bodyMax
 	cfg.JWTSecret = os.Getenv("JWT_SECRET")
 	if cfg.JWTSecret == "" {
 		return nil, fmt.Errorf("missing JWT_SECRET environment variable")
@@ -86,6 +174,126 @@ func Load() (*Config, error) {
 	}
 	cfg.JWTLifetime = d
 
+	refreshLifetime, err := time.ParseDuration(getEnv("JWT_REFRESH_LIFETIME", "168h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_REFRESH_LIFETIME: %w", err)
+	}
+	if refreshLifetime <= 0 {
+		return nil, fmt.Errorf("invalid JWT_REFRESH_LIFETIME: %s", refreshLifetime)
+	}
+	cfg.JWTRefreshLifetime = refreshLifetime
+
+	resetTTL, err := time.ParseDuration(getEnv("PASSWORD_RESET_TTL", "30m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PASSWORD_RESET_TTL: %w", err)
+	}
+	if resetTTL <= 0 {
+		return nil, fmt.Errorf("invalid PASSWORD_RESET_TTL: %s", resetTTL)
+	}
+	cfg.PasswordResetTTL = resetTTL
+
+	logTokens, err := strconv.ParseBool(getEnv("DEV_LOG_AUTH_TOKENS", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DEV_LOG_AUTH_TOKENS: %w", err)
+	}
+	cfg.DevLogAuthTokens = logTokens
+
+	verifyTTL, err := time.ParseDuration(getEnv("EMAIL_VERIFICATION_TTL", "48h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid EMAIL_VERIFICATION_TTL: %w", err)
+	}
+	if verifyTTL <= 0 {
+		return nil, fmt.Errorf("invalid EMAIL_VERIFICATION_TTL: %s", verifyTTL)
+	}
+	cfg.EmailVerifyTTL = verifyTTL
+
+	verifyResend, err := time.ParseDuration(getEnv("EMAIL_VERIFICATION_RESEND_INTERVAL", "1m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid EMAIL_VERIFICATION_RESEND_INTERVAL: %w", err)
+	}
+	if verifyResend < 0 {
+		return nil, fmt.Errorf("invalid EMAIL_VERIFICATION_RESEND_INTERVAL: %s", verifyResend)
+	}
+	cfg.EmailVerifyResend = verifyResend
+
+	requireVerified, err := strconv.ParseBool(getEnv("REQUIRE_EMAIL_VERIFICATION", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REQUIRE_EMAIL_VERIFICATION: %w", err)
+	}
+	cfg.RequireVerifiedEmail = requireVerified
+
+	usernameCase, err := strconv.ParseBool(getEnv("USERNAME_CASE_SENSITIVE", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid USERNAME_CASE_SENSITIVE: %w", err)
+	}
+	cfg.UsernameMatchCase = usernameCase
+
+	keyLifetime, err := time.ParseDuration(getEnv("API_KEY_MAX_LIFETIME", "0s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API_KEY_MAX_LIFETIME: %w", err)
+	}
+	if keyLifetime < 0 {
+		return nil, fmt.Errorf("invalid API_KEY_MAX_LIFETIME: %s", keyLifetime)
+	}
+	cfg.APIKeyMaxLifetime = keyLifetime
+
+	requireExpiry, err := strconv.ParseBool(getEnv("API_KEY_REQUIRE_EXPIRY", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid API_KEY_REQUIRE_EXPIRY: %w", err)
+	}
+	cfg.APIKeyRequireExpiry = requireExpiry
+
+	slowStr := getEnv("SLOW_REQUEST_THRESHOLD", "1s")
+	slow, err := time.ParseDuration(slowStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SLOW_REQUEST_THRESHOLD: %w", err)
+	}
+	cfg.SlowRequestThreshold = slow
+
+	maxLinks, err := strconv.Atoi(getEnv("RESULTS_MAX_LINKS", "1000"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RESULTS_MAX_LINKS: %w", err)
+	}
+	if maxLinks < 0 {
+		return nil, fmt.Errorf("invalid RESULTS_MAX_LINKS: %d", maxLinks)
+	}
+	cfg.ResultsMaxLinks = maxLinks
+
+	for _, n := range []struct {
+		key string
+		def string
+		dst *int
+	}{
+		{"STREAM_MAX_CONNECTIONS", "500", &cfg.StreamMaxConns},
+		{"STREAM_MAX_CONNECTIONS_PER_USER", "5", &cfg.StreamMaxConnsUser},
+	} {
+		v, err := strconv.Atoi(getEnv(n.key, n.def))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", n.key, err)
+		}
+		if v < 0 {
+			return nil, fmt.Errorf("invalid %s: %d", n.key, v)
+		}
+		*n.dst = v
+	}
+
+	for _, d := range []struct {
+		key string
+		def string
+		dst *time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", "15s", &cfg.HTTPReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", "30s", &cfg.HTTPWriteTimeout},
+		{"SSE_HEARTBEAT_INTERVAL", "15s", &cfg.SSEHeartbeat},
+		{"SSE_MAX_LIFETIME", "30m", &cfg.SSEMaxLifetime},
+	} {
+		v, err := time.ParseDuration(getEnv(d.key, d.def))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", d.key, err)
+		}
+		*d.dst = v
+	}
+
 	// CORS
 	origins := getEnv("CORS_ORIGINS", "")
 	if origins != "" {
@@ -107,9 +315,283 @@ func Load() (*Config, error) {
 	}
 	cfg.CrawlTimeout = time.Duration(ts) * time.Second
 
+	httpTimeout, err := time.ParseDuration(getEnv("CRAWL_HTTP_TIMEOUT", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_HTTP_TIMEOUT: %w", err)
+	}
+	cfg.CrawlHTTPTimeout = httpTimeout
+
+	linkConc, err := strconv.Atoi(getEnv("CRAWL_LINK_CHECK_CONCURRENCY", "12"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_LINK_CHECK_CONCURRENCY: %w", err)
+	}
+	if linkConc < 1 {
+		return nil, fmt.Errorf("invalid CRAWL_LINK_CHECK_CONCURRENCY: %d", linkConc)
+	}
+	cfg.LinkCheckConcurrency = linkConc
+
+	retryAttempts, err := strconv.Atoi(getEnv("CRAWL_RETRY_ATTEMPTS", "1"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_RETRY_ATTEMPTS: %w", err)
+	}
+	if retryAttempts < 1 {
+		return nil, fmt.Errorf("invalid CRAWL_RETRY_ATTEMPTS: %d", retryAttempts)
+	}
+	cfg.CrawlRetryAttempts = retryAttempts
+	retryDelay, err := time.ParseDuration(getEnv("CRAWL_RETRY_BASE_DELAY", "2s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_RETRY_BASE_DELAY: %w", err)
+	}
+	cfg.CrawlRetryDelay = retryDelay
+
+	hostConc, err := strconv.Atoi(getEnv("CRAWL_MAX_PER_HOST", "4"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_MAX_PER_HOST: %w", err)
+	}
+	if hostConc < 0 {
+		return nil, fmt.Errorf("invalid CRAWL_MAX_PER_HOST: %d", hostConc)
+	}
+	cfg.HostConcurrency = hostConc
+
+	queueCap, err := strconv.Atoi(getEnv("CRAWL_QUEUE_CAPACITY", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_QUEUE_CAPACITY: %w", err)
+	}
+	if queueCap < 0 {
+		return nil, fmt.Errorf("invalid CRAWL_QUEUE_CAPACITY: %d", queueCap)
+	}
+	cfg.QueueCapacity = queueCap
+
+	quota, err := strconv.Atoi(getEnv("CRAWL_QUOTA_PER_USER", "100"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_QUOTA_PER_USER: %w", err)
+	}
+	if quota < 0 {
+		return nil, fmt.Errorf("invalid CRAWL_QUOTA_PER_USER: %d", quota)
+	}
+	cfg.CrawlQuotaPerUser = quota
+	cfg.CrawlQuotaByRole = map[string]int{"admin": 0}
+	if overrides := getEnv("CRAWL_QUOTA_ROLE_OVERRIDES", ""); overrides != "" {
+		for _, pair := range strings.Split(overrides, ",") {
+			role, n, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			switch role {
+			case "admin", "crawler", "worker", "user":
+			default:
+				ok = false
+			}
+			v, err := strconv.Atoi(n)
+			if !ok || err != nil || v < 0 {
+				return nil, fmt.Errorf("invalid CRAWL_QUOTA_ROLE_OVERRIDES entry: %q", pair)
+			}
+			cfg.CrawlQuotaByRole[role] = v
+		}
+	}
+
+	idleTimeout, err := time.ParseDuration(getEnv("CRAWL_WORKER_IDLE_TIMEOUT", "0s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_WORKER_IDLE_TIMEOUT: %w", err)
+	}
+	if idleTimeout < 0 {
+		return nil, fmt.Errorf("invalid CRAWL_WORKER_IDLE_TIMEOUT: %s", idleTimeout)
+	}
+	cfg.WorkerIdleTimeout = idleTimeout
+
+	minCrawlers, err := strconv.Atoi(getEnv("MIN_CRAWLERS", "1"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MIN_CRAWLERS: %w", err)
+	}
+	if minCrawlers < 1 {
+		return nil, fmt.Errorf("invalid MIN_CRAWLERS: %d", minCrawlers)
+	}
+	cfg.MinCrawlers = minCrawlers
+
+	reserved, err := strconv.Atoi(getEnv("CRAWL_RESERVED_WORKERS", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_RESERVED_WORKERS: %w", err)
+	}
+	if reserved < 0 || (reserved > 0 && reserved >= cfg.NumberOfCrawlers) {
+		return nil, fmt.Errorf("invalid CRAWL_RESERVED_WORKERS: %d (must leave at least one of %d workers)", reserved, cfg.NumberOfCrawlers)
+	}
+	cfg.ReservedCrawlers = reserved
+	reservedMin, err := strconv.Atoi(getEnv("CRAWL_RESERVED_MIN_PRIORITY", "8"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_RESERVED_MIN_PRIORITY: %w", err)
+	}
+	if reservedMin < 1 || reservedMin > 10 {
+		return nil, fmt.Errorf("invalid CRAWL_RESERVED_MIN_PRIORITY: %d", reservedMin)
+	}
+	cfg.ReservedMinPriority = reservedMin
+
+	slowCrawl, err := time.ParseDuration(getEnv("CRAWL_SLOW_THRESHOLD", "0s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_SLOW_THRESHOLD: %w", err)
+	}
+	cfg.SlowCrawlThreshold = slowCrawl
+	slowPenalty, err := strconv.Atoi(getEnv("CRAWL_SLOW_PRIORITY_PENALTY", "3"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_SLOW_PRIORITY_PENALTY: %w", err)
+	}
+	cfg.SlowCrawlPenalty = slowPenalty
+
+	watchdogInterval, err := time.ParseDuration(getEnv("CRAWL_WATCHDOG_INTERVAL", "1m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_WATCHDOG_INTERVAL: %w", err)
+	}
+	if watchdogInterval < 0 {
+		return nil, fmt.Errorf("invalid CRAWL_WATCHDOG_INTERVAL: %s", watchdogInterval)
+	}
+	cfg.WatchdogInterval = watchdogInterval
+	stuckAfter, err := time.ParseDuration(getEnv("CRAWL_STUCK_AFTER", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_STUCK_AFTER: %w", err)
+	}
+	if stuckAfter <= 0 {
+		return nil, fmt.Errorf("invalid CRAWL_STUCK_AFTER: %s", stuckAfter)
+	}
+	cfg.StuckCrawlAfter = stuckAfter
+	cfg.StuckCrawlAction = getEnv("CRAWL_STUCK_ACTION", "queued")
+	if cfg.StuckCrawlAction != "queued" && cfg.StuckCrawlAction != "error" {
+		return nil, fmt.Errorf("invalid CRAWL_STUCK_ACTION: %q", cfg.StuckCrawlAction)
+	}
+
+	trashRetention, err := time.ParseDuration(getEnv("URL_TRASH_RETENTION", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL_TRASH_RETENTION: %w", err)
+	}
+	if trashRetention < 0 {
+		return nil, fmt.Errorf("invalid URL_TRASH_RETENTION: %s", trashRetention)
+	}
+	cfg.TrashRetention = trashRetention
+	purgeInterval, err := time.ParseDuration(getEnv("URL_TRASH_PURGE_INTERVAL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL_TRASH_PURGE_INTERVAL: %w", err)
+	}
+	if purgeInterval <= 0 {
+		return nil, fmt.Errorf("invalid URL_TRASH_PURGE_INTERVAL: %s", purgeInterval)
+	}
+	cfg.TrashPurgeInterval = purgeInterval
+
+	cfg.UnknownContentPolicy = getEnv("UNKNOWN_CONTENT_POLICY", "parse")
+	switch cfg.UnknownContentPolicy {
+	case "skip", "parse", "metadata":
+	default:
+		return nil, fmt.Errorf("invalid UNKNOWN_CONTENT_POLICY: %q", cfg.UnknownContentPolicy)
+	}
+
+	maxRedirects, err := strconv.Atoi(getEnv("MAX_REDIRECTS", "10"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAX_REDIRECTS: %w", err)
+	}
+	cfg.MaxRedirects = maxRedirects
+
+	maxIdlePerHost, err := strconv.Atoi(getEnv("CRAWL_MAX_IDLE_CONNS_PER_HOST", "16"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_MAX_IDLE_CONNS_PER_HOST: %w", err)
+	}
+	cfg.MaxIdleConnsPerHost = maxIdlePerHost
+
+	cfg.ExternalLinkBase = getEnv("EXTERNAL_LINK_BASE", "original")
+	if cfg.ExternalLinkBase != "original" && cfg.ExternalLinkBase != "final" {
+		return nil, fmt.Errorf("invalid EXTERNAL_LINK_BASE: %q", cfg.ExternalLinkBase)
+	}
+
+	if sources := getEnv("TITLE_FALLBACK", ""); sources != "" {
+		for _, s := range strings.Split(sources, ",") {
+			s = strings.TrimSpace(s)
+			switch s {
+			case "h1", "og_title", "url_path":
+			default:
+				return nil, fmt.Errorf("invalid TITLE_FALLBACK source: %q", s)
+			}
+			cfg.TitleFallback = append(cfg.TitleFallback, s)
+		}
+	}
+
+	cfg.EgressMode = getEnv("CRAWL_EGRESS_MODE", "denylist")
+	if cfg.EgressMode != "denylist" && cfg.EgressMode != "allowlist" {
+		return nil, fmt.Errorf("invalid CRAWL_EGRESS_MODE: %q", cfg.EgressMode)
+	}
+	if hosts := getEnv("CRAWL_EGRESS_HOSTS", ""); hosts != "" {
+		cfg.EgressHosts = strings.Split(hosts, ",")
+	}
+	httpsOnly, err := strconv.ParseBool(getEnv("CRAWL_HTTPS_ONLY", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_HTTPS_ONLY: %w", err)
+	}
+	cfg.HTTPSOnly = httpsOnly
+	if hosts := getEnv("CRAWL_INSECURE_TLS_HOSTS", ""); hosts != "" {
+		cfg.InsecureTLSHosts = strings.Split(hosts, ",")
+	}
+
+	// Link normalization
+	if params := getEnv("LINK_STRIP_PARAMS", ""); params != "" {
+		for _, p := range strings.Split(params, ",") {
+			p = strings.TrimSpace(p)
+			if _, err := path.Match(p, ""); err != nil || p == "" {
+				return nil, fmt.Errorf("invalid LINK_STRIP_PARAMS pattern: %q", p)
+			}
+			cfg.LinkStripParams = append(cfg.LinkStripParams, p)
+		}
+	}
+	for _, flag := range []struct {
+		key string
+		dst *bool
+	}{
+		{"LINK_LOWERCASE_HOST", &cfg.LinkLowercaseHost},
+		{"LINK_STRIP_TRAILING_SLASH", &cfg.LinkStripSlash},
+		{"LINK_STRIP_FRAGMENT", &cfg.LinkStripFragment},
+	} {
+		v, err := strconv.ParseBool(getEnv(flag.key, "false"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", flag.key, err)
+		}
+		*flag.dst = v
+	}
+
+	// Blob storage
+	storeRaw, err := strconv.ParseBool(getEnv("STORE_RAW_HTML", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STORE_RAW_HTML: %w", err)
+	}
+	cfg.StoreRawHTML = storeRaw
+	cfg.BlobBackend = getEnv("BLOB_BACKEND", "fs")
+	if cfg.BlobBackend != "fs" {
+		return nil, fmt.Errorf("invalid BLOB_BACKEND: %q", cfg.BlobBackend)
+	}
+	cfg.BlobDir = getEnv("BLOB_DIR", "data/blobs")
+
 	// User agent
 	cfg.UserAgent = getEnv("USER_AGENT", "LinkAgent-Bot/1.0")
 
+	respectRobots, err := strconv.ParseBool(getEnv("CRAWL_RESPECT_ROBOTS", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_RESPECT_ROBOTS: %w", err)
+	}
+	cfg.RespectRobots = respectRobots
+	robotsTTL, err := time.ParseDuration(getEnv("CRAWL_ROBOTS_CACHE_TTL", "1h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_ROBOTS_CACHE_TTL: %w", err)
+	}
+	cfg.RobotsCacheTTL = robotsTTL
+
+	autoTagHost, err := strconv.ParseBool(getEnv("AUTO_TAG_HOST", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTO_TAG_HOST: %w", err)
+	}
+	cfg.AutoTagHost = autoTagHost
+
+	crawlOnCreate, err := strconv.ParseBool(getEnv("CRAWL_ON_CREATE", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CRAWL_ON_CREATE: %w", err)
+	}
+	cfg.CrawlOnCreate = crawlOnCreate
+
+	metricsEnabled, err := strconv.ParseBool(getEnv("METRICS_ENABLED", "true"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid METRICS_ENABLED: %w", err)
+	}
+	cfg.MetricsEnabled = metricsEnabled
+
 	return cfg, nil
 }
 
@@ -13,4 +13,9 @@ type Analyzer interface {
 }
 
 // New creates a new HTML analyzer instance.
-func New() Analyzer { return NewHTMLAnalyzer() }
+func New() Analyzer { return NewHTMLAnalyzer(Options{}) }
+
+// Namer is implemented by analyzers that report a name for crawl provenance.
+type Namer interface {
+	Name() string
+}
@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+// HeadAnalyzer is implemented by analyzers that can check a URL without
+// fetching its content, for URLs crawled with model.CrawlMethodHeadOnly.
+type HeadAnalyzer interface {
+	AnalyzeHead(ctx context.Context, u *url.URL) (*model.AnalysisResult, error)
+}
+
+// AnalyzeHead requests u with HEAD and records only the response status,
+// content type and redirects; the page is not parsed and its links are not
+// checked. Servers that do not allow HEAD are asked with GET instead, whose
+// body is discarded unread.
+func (a *htmlAnalyzer) AnalyzeHead(ctx context.Context, u *url.URL) (*model.AnalysisResult, error) {
+	if err := a.egress.Check(u); err != nil {
+		return nil, err
+	}
+	client, skipVerify := a.clientFor(u)
+	resp, err := a.do(ctx, client, http.MethodHead, u)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = a.do(ctx, client, http.MethodGet, u)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+
+	return &model.AnalysisResult{
+		HTMLVersion:      HTMLVersionUnknown,
+		ContentType:      resp.Header.Get("Content-Type"),
+		StatusCode:       resp.StatusCode,
+		RedirectChain:    redirectChain(resp),
+		FinalURL:         resp.Request.URL.String(),
+		TLSVerifySkipped: skipVerify,
+	}, nil
+}
+
+func (a *htmlAnalyzer) do(ctx context.Context, client *http.Client, method string, u *url.URL) (*http.Response, error) {
+	req, _ := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	req.Header.Set("User-Agent", a.userAgent)
+	return client.Do(req)
+}
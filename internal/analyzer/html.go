@@ -1,30 +1,190 @@
 package analyzer
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"golang.org/x/net/html"
 
+	"github.com/fuzumoe/linkTorch-api/internal/egress"
 	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/storage"
 )
 
+// ContentPolicy decides how responses that are not HTML are handled.
+type ContentPolicy string
+
+const (
+	ContentPolicySkip     ContentPolicy = "skip"     // abort with ErrUnsupportedContent
+	ContentPolicyParse    ContentPolicy = "parse"    // parse the body as HTML anyway
+	ContentPolicyMetadata ContentPolicy = "metadata" // record the content type only
+)
+
+// LinkBase selects which host links are compared against when deciding
+// whether they are external.
+type LinkBase string
+
+const (
+	LinkBaseOriginal LinkBase = "original" // host of the URL that was submitted
+	LinkBaseFinal    LinkBase = "final"    // host the request was redirected to
+)
+
+// TitleSource names where a page's title was taken from.
+type TitleSource string
+
+const (
+	TitleSourceTitle     TitleSource = "title"    // the <title> element
+	TitleSourceH1        TitleSource = "h1"       // the first <h1>
+	TitleSourceOpenGraph TitleSource = "og_title" // the og:title meta property
+	TitleSourceURLPath   TitleSource = "url_path" // the last segment of the URL path
+)
+
+// ErrUnsupportedContent is returned when a non-HTML response is skipped.
+var ErrUnsupportedContent = errors.New("unsupported content type")
+
+// HTTPStatusError is returned when the page itself answers with a server error.
+type HTTPStatusError struct {
+	StatusCode int
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("server responded with status %d", e.StatusCode)
+}
+
+// Defaults used when the corresponding Options field is zero.
+const (
+	DefaultTimeout              = 10 * time.Second
+	DefaultUserAgent            = "linkTorch-Bot/1.0"
+	DefaultLinkCheckConcurrency = 12
+)
+
+// Options configures an HTML analyzer. Zero values fall back to defaults.
+type Options struct {
+	// Timeout bounds each page request from dial to the end of the body
+	// (default DefaultTimeout). The crawl timeout still bounds the whole analysis.
+	Timeout time.Duration
+	// UserAgent is sent with page fetches and link checks (default DefaultUserAgent).
+	UserAgent     string
+	ContentPolicy ContentPolicy
+	// Egress restricts the hosts that may be fetched, including redirects and link checks.
+	Egress *egress.Policy
+	// MaxRedirects caps how many redirects are followed and recorded (default 10).
+	MaxRedirects int
+	// Normalize canonicalizes extracted links before they are deduplicated.
+	Normalize NormalizeRules
+	// LinkBase picks the host used to classify links as external (default original).
+	LinkBase LinkBase
+	// InsecureTLSHosts are hosts (and their subdomains) crawled without TLS
+	// certificate verification, e.g. internal targets with self-signed certs.
+	InsecureTLSHosts []string
+	// RawHTML, when set, receives the body of every parsed page; the result
+	// keeps only its key.
+	RawHTML storage.BlobStore
+	// Transport tunes the connection pool shared by page fetches and link checks.
+	Transport TransportOptions
+	// LinkCheckConcurrency caps how many links of a page are probed at once
+	// (default DefaultLinkCheckConcurrency).
+	LinkCheckConcurrency int
+	// TitleFallback lists, in order, where to look for a title when the page
+	// has no <title>. By default none is tried and such pages stay untitled.
+	TitleFallback []TitleSource
+}
+
 // HTMLAnalyzer analyzes HTML documents for various metrics.
 type htmlAnalyzer struct {
-	client *http.Client
-	check  *linkChecker
+	client    *http.Client
+	check     *linkChecker
+	policy    ContentPolicy
+	egress    *egress.Policy
+	normalize NormalizeRules
+	linkBase  LinkBase
+	insecure  insecureHosts
+	// insecureClient is only set when insecure hosts are configured.
+	insecureClient *http.Client
+	rawHTML        storage.BlobStore
+	userAgent      string
+	titleFrom      []TitleSource // fallback chain for pages without <title>
 }
 
-// NewHTMLAnalyzer creates a new HTML analyzer with default settings.
-func NewHTMLAnalyzer() *htmlAnalyzer {
-	return &htmlAnalyzer{
-		client: &http.Client{Timeout: 10 * time.Second},
-		check:  newLinkChecker(12, 5*time.Second),
+// NewHTMLAnalyzer creates a new HTML analyzer with the given options.
+func NewHTMLAnalyzer(opts Options) *htmlAnalyzer {
+	policy := opts.ContentPolicy
+	if policy == "" {
+		policy = ContentPolicyParse
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	linkConc := opts.LinkCheckConcurrency
+	if linkConc <= 0 {
+		linkConc = DefaultLinkCheckConcurrency
+	}
+	maxRedirects := opts.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = 10
+	}
+	checkRedirect := func(req *http.Request, via []*http.Request) error {
+		if err := opts.Egress.Check(req.URL); err != nil {
+			return err
+		}
+		if len(via) > maxRedirects {
+			// Keep the last redirect response so the chain can still be recorded.
+			return http.ErrUseLastResponse
+		}
+		return nil
 	}
+	// One transport serves every request of this analyzer, so all workers
+	// share a single connection pool.
+	transport := newTransport(opts.Transport)
+	check := newLinkCheckerWithTransport(linkConc, 5*time.Second, transport)
+	check.egress = opts.Egress
+	check.userAgent = userAgent
+	a := &htmlAnalyzer{
+		client: &http.Client{
+			Timeout:       timeout,
+			Transport:     transport,
+			CheckRedirect: checkRedirect,
+		},
+		check:     check,
+		policy:    policy,
+		egress:    opts.Egress,
+		normalize: opts.Normalize,
+		linkBase:  opts.LinkBase,
+		rawHTML:   opts.RawHTML,
+		userAgent: userAgent,
+		titleFrom: opts.TitleFallback,
+	}
+	if len(opts.InsecureTLSHosts) > 0 {
+		a.insecure = insecureHosts(opts.InsecureTLSHosts)
+		insecureTransport := a.insecure.transport(transport)
+		a.insecureClient = &http.Client{
+			Timeout:       timeout,
+			Transport:     insecureTransport,
+			CheckRedirect: checkRedirect,
+		}
+		check.insecure = a.insecure
+		check.insecureClient = &http.Client{
+			Timeout:   check.timeout,
+			Transport: insecureTransport,
+		}
+	}
+	return a
 }
 
 // Analyze fetches the HTML document from the URL and extracts various metrics.
@@ -32,23 +192,84 @@ func (a *htmlAnalyzer) Analyze(
 	ctx context.Context,
 	u *url.URL,
 ) (*model.AnalysisResult, []model.Link, error) {
+	if err := a.egress.Check(u); err != nil {
+		return nil, nil, err
+	}
+	client, skipVerify := a.clientFor(u)
 	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-	resp, err := a.client.Do(req)
+	req.Header.Set("User-Agent", a.userAgent)
+	start := time.Now()
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return nil, nil, &HTTPStatusError{StatusCode: resp.StatusCode}
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !isHTML(contentType) {
+		switch a.policy {
+		case ContentPolicySkip:
+			return nil, nil, ErrUnsupportedContent
+		case ContentPolicyMetadata:
+			return &model.AnalysisResult{
+				HTMLVersion:      HTMLVersionUnknown,
+				ContentType:      contentType,
+				StatusCode:       resp.StatusCode,
+				ResponseTimeMs:   int(time.Since(start).Milliseconds()),
+				RedirectChain:    redirectChain(resp),
+				FinalURL:         resp.Request.URL.String(),
+				TLSVerifySkipped: skipVerify,
+			}, nil, nil
+		}
+	}
+
+	// The body is read into a pooled buffer so busy workers do not allocate
+	// a fresh one for every page.
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return nil, nil, err
+	}
+	// The fetch ends here; storing and parsing the page and checking its
+	// links are not part of the response time.
+	elapsed := time.Since(start)
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	var rawKey string
+	if a.rawHTML != nil {
+		rawKey = rawHTMLKey(u)
+		if err := a.rawHTML.Put(ctx, rawKey, bytes.NewReader(buf.Bytes())); err != nil {
+			// Losing the raw copy should not fail the analysis itself.
+			log.Printf("[analyzer] storing raw HTML of %s: %v", u, err)
+			rawKey = ""
+		}
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(buf.Bytes()))
 	if err != nil {
 		return nil, nil, err
 	}
 
 	res := &model.AnalysisResult{
-		HTMLVersion:  detectHTMLVersion(doc),
-		Title:        strings.TrimSpace(doc.Find("title").First().Text()),
-		HasLoginForm: doc.Find("form input[type='password']").Length() > 0,
+		HTMLVersion:      detectHTMLVersion(doc),
+		ContentType:      contentType,
+		StatusCode:       resp.StatusCode,
+		ResponseTimeMs:   int(elapsed.Milliseconds()),
+		PageSizeBytes:    buf.Len(),
+		MetaDescription:  metaDescription(doc),
+		Language:         pageLanguage(doc, resp.Header),
+		HasLoginForm:     doc.Find("form input[type='password']").Length() > 0,
+		RedirectChain:    redirectChain(resp),
+		TLSVerifySkipped: skipVerify,
+		RawHTMLKey:       rawKey,
 	}
+	title, source := a.title(doc, u)
+	res.Title, res.TitleSource = title, string(source)
+	res.FinalURL = resp.Request.URL.String()
+	res.CanonicalURL = canonicalURL(doc, resp.Request.URL)
+	res.CanonicalMismatch = res.CanonicalURL != "" && !sameURL(res.CanonicalURL, res.FinalURL)
 
 	// headings
 	doc.Find("h1,h2,h3,h4,h5,h6").Each(func(_ int, s *goquery.Selection) {
@@ -68,22 +289,41 @@ func (a *htmlAnalyzer) Analyze(
 		}
 	})
 
-	seen := make(map[string]struct{})
+	// images; alt="" counts as missing too
+	doc.Find("img").Each(func(_ int, s *goquery.Selection) {
+		res.ImageCount++
+		if alt, _ := s.Attr("alt"); strings.TrimSpace(alt) == "" {
+			res.ImagesMissingAlt++
+		}
+	})
+
+	// Relative links are resolved against the same base so they stay internal.
+	base := u
+	if a.linkBase == LinkBaseFinal && resp.Request != nil {
+		base = resp.Request.URL
+	}
+	// Links are kept once per target, under the href they first appeared
+	// with, counting how often the page links to each.
+	normalize := a.normalize
+	seen := make(map[string]int)
 	var links []model.Link
 	doc.Find("a[href]").Each(func(_ int, a *goquery.Selection) {
 		href, _ := a.Attr("href")
-		abs := resolve(u, href)
+		abs := normalize.apply(resolve(base, href))
 		if abs == "" {
 			return
 		}
-		if _, ok := seen[abs]; ok {
+		key := linkIdentity.apply(abs)
+		if i, ok := seen[key]; ok {
+			links[i].OccurrenceCount++
 			return
 		}
-		seen[abs] = struct{}{}
+		seen[key] = len(links)
 
 		lnk := model.Link{
-			Href:       abs,
-			IsExternal: !sameHost(u, abs),
+			Href:            abs,
+			IsExternal:      !sameHost(base, abs),
+			OccurrenceCount: 1,
 		}
 		links = append(links, lnk)
 	})
@@ -102,16 +342,227 @@ func (a *htmlAnalyzer) Analyze(
 	return res, links, nil
 }
 
-// detectHTMLVersion checks the doctype of the HTML document to determine its version.
+// clientFor returns the client to fetch u with and whether it skips TLS
+// certificate verification.
+func (a *htmlAnalyzer) clientFor(u *url.URL) (*http.Client, bool) {
+	if u.Scheme == "https" && a.insecure.match(u.Hostname()) {
+		return a.insecureClient, true
+	}
+	return a.client, false
+}
+
+// title returns the page's <title>, or else the first non-empty candidate of
+// the configured fallback chain, together with where it was found.
+func (a *htmlAnalyzer) title(doc *goquery.Document, u *url.URL) (string, TitleSource) {
+	if t := strings.TrimSpace(doc.Find("title").First().Text()); t != "" {
+		return t, TitleSourceTitle
+	}
+	for _, source := range a.titleFrom {
+		var t string
+		switch source {
+		case TitleSourceH1:
+			t = doc.Find("h1").First().Text()
+		case TitleSourceOpenGraph:
+			t, _ = doc.Find(`meta[property="og:title"]`).First().Attr("content")
+		case TitleSourceURLPath:
+			t = path.Base(strings.TrimSuffix(u.Path, "/"))
+			if t == "." || t == "/" {
+				t = ""
+			}
+		}
+		if t = strings.Join(strings.Fields(t), " "); t != "" {
+			return t, source
+		}
+	}
+	return "", ""
+}
+
+// HTML versions reported in AnalysisResult.HTMLVersion.
+const (
+	HTMLVersion5                   = "HTML 5"
+	HTMLVersion401Strict           = "HTML 4.01 Strict"
+	HTMLVersion401Transitional     = "HTML 4.01 Transitional"
+	HTMLVersion401Frameset         = "HTML 4.01 Frameset"
+	HTMLVersionXHTML10Strict       = "XHTML 1.0 Strict"
+	HTMLVersionXHTML10Transitional = "XHTML 1.0 Transitional"
+	HTMLVersionXHTML10Frameset     = "XHTML 1.0 Frameset"
+	HTMLVersionXHTML11             = "XHTML 1.1"
+	HTMLVersionUnknown             = "unknown"
+)
+
+// html5Elements only exist since HTML5; their presence suggests an HTML5
+// page that was served without a doctype.
+const html5Elements = "article, aside, audio, canvas, figure, footer, header, main, nav, section, time, video, meta[charset]"
+
+// DetectHTMLVersion classifies a doctype declaration such as
+// `<!DOCTYPE html PUBLIC "-//W3C//DTD HTML 4.01//EN" "...">`. The leading
+// "<!DOCTYPE" and trailing ">" are optional. Anything it does not recognize,
+// including an empty string, is reported as unknown.
+func DetectHTMLVersion(doctype string) string {
+	d := strings.ToLower(strings.TrimSpace(doctype))
+	d = strings.TrimPrefix(d, "<!doctype")
+	d = strings.TrimSpace(strings.TrimSuffix(d, ">"))
+
+	variant := func(strict, transitional, frameset string) string {
+		switch {
+		case strings.Contains(d, "transitional"):
+			return transitional
+		case strings.Contains(d, "frameset"):
+			return frameset
+		}
+		return strict
+	}
+	switch {
+	case strings.Contains(d, "xhtml 1.1"):
+		return HTMLVersionXHTML11
+	case strings.Contains(d, "xhtml 1.0"):
+		return variant(HTMLVersionXHTML10Strict, HTMLVersionXHTML10Transitional, HTMLVersionXHTML10Frameset)
+	case strings.Contains(d, "html 4.01"):
+		return variant(HTMLVersion401Strict, HTMLVersion401Transitional, HTMLVersion401Frameset)
+	case d == "html" || strings.HasPrefix(d, "html system"):
+		// The HTML5 doctype, optionally with the "about:legacy-compat" system id.
+		return HTMLVersion5
+	}
+	return HTMLVersionUnknown
+}
+
+// metaDescription returns the content of the page's
+// <meta name="description">, with runs of whitespace collapsed.
+func metaDescription(doc *goquery.Document) string {
+	var desc string
+	doc.Find("meta[name]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if name, _ := s.Attr("name"); !strings.EqualFold(strings.TrimSpace(name), "description") {
+			return true
+		}
+		content, _ := s.Attr("content")
+		desc = strings.Join(strings.Fields(content), " ")
+		return false
+	})
+	return desc
+}
+
+// linkIdentity decides which links of a page point at the same target,
+// regardless of the normalization configured for the stored hrefs.
+var linkIdentity = NormalizeRules{LowercaseHost: true, StripFragment: true}
+
+// canonicalURL returns the href of the page's <link rel="canonical">,
+// resolved against the URL the page was served from.
+func canonicalURL(doc *goquery.Document, final *url.URL) string {
+	var canonical string
+	doc.Find("link[rel][href]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		rel, _ := s.Attr("rel")
+		for _, r := range strings.Fields(rel) {
+			if strings.EqualFold(r, "canonical") {
+				href, _ := s.Attr("href")
+				canonical = resolve(final, href)
+				return false
+			}
+		}
+		return true
+	})
+	return canonical
+}
+
+// sameURL reports whether a and b point at the same page, ignoring host
+// case, fragments and trailing slashes.
+func sameURL(a, b string) bool {
+	rules := NormalizeRules{LowercaseHost: true, StripFragment: true, StripTrailingSlash: true}
+	return rules.apply(a) == rules.apply(b)
+}
+
+// maxLanguageLen matches the size of the language column.
+const maxLanguageLen = 35
+
+// pageLanguage returns the lang attribute of the <html> element, or else the
+// first language listed in the Content-Language header.
+func pageLanguage(doc *goquery.Document, header http.Header) string {
+	lang, _ := doc.Find("html").First().Attr("lang")
+	lang = strings.TrimSpace(lang)
+	if lang == "" {
+		first, _, _ := strings.Cut(header.Get("Content-Language"), ",")
+		lang = strings.TrimSpace(first)
+	}
+	if len(lang) > maxLanguageLen {
+		lang = lang[:maxLanguageLen]
+	}
+	return lang
+}
+
+// detectHTMLVersion classifies the document by its doctype or, without one,
+// by whether it uses HTML5-only elements.
 func detectHTMLVersion(doc *goquery.Document) string {
-	if n := doc.Nodes[0].FirstChild; n != nil && n.Type == html.DoctypeNode {
-		d := strings.ToLower(strings.TrimSpace(n.Data))
-		if strings.HasPrefix(d, "html") {
-			return "HTML 5"
+	for n := doc.Nodes[0].FirstChild; n != nil; n = n.NextSibling {
+		if n.Type == html.DoctypeNode {
+			return DetectHTMLVersion(doctypeDeclaration(n))
+		}
+	}
+	if doc.Find(html5Elements).Length() > 0 {
+		return HTMLVersion5
+	}
+	return HTMLVersionUnknown
+}
+
+// doctypeDeclaration rebuilds the text of a doctype node; the parser keeps
+// the public and system identifiers as attributes.
+func doctypeDeclaration(n *html.Node) string {
+	var public, system string
+	for _, a := range n.Attr {
+		switch a.Key {
+		case "public":
+			public = a.Val
+		case "system":
+			system = a.Val
 		}
-		return d
 	}
-	return "unknown"
+	d := n.Data
+	switch {
+	case public != "":
+		d += ` PUBLIC "` + public + `"`
+		if system != "" {
+			d += ` "` + system + `"`
+		}
+	case system != "":
+		d += ` SYSTEM "` + system + `"`
+	}
+	return d
+}
+
+// redirectChain returns the hops that led to resp, oldest first, or nil
+// when the request was not redirected.
+func redirectChain(resp *http.Response) model.RedirectChain {
+	var chain model.RedirectChain
+	for r := resp; r != nil && r.Request != nil; r = r.Request.Response {
+		chain = append(chain, model.RedirectHop{
+			URL:        r.Request.URL.String(),
+			StatusCode: r.StatusCode,
+		})
+	}
+	if len(chain) < 2 {
+		return nil
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// rawHTMLKey returns a unique blob key for a copy of u's body, grouped by URL.
+func rawHTMLKey(u *url.URL) string {
+	sum := sha256.Sum256([]byte(u.String()))
+	return fmt.Sprintf("raw/%x/%d.html", sum[:8], time.Now().UnixNano())
+}
+
+// isHTML reports whether a Content-Type header denotes an HTML document.
+// A missing header is treated as HTML.
+func isHTML(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
 }
 
 // resolve resolves a relative URL against a base URL.
@@ -128,3 +579,6 @@ func sameHost(a *url.URL, raw string) bool {
 	b, err := url.Parse(raw)
 	return err == nil && a.Hostname() == b.Hostname()
 }
+
+// Name implements Namer.
+func (a *htmlAnalyzer) Name() string { return "html" }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/temoto/robotstxt"
 
+	"github.com/fuzumoe/linkTorch-api/internal/egress"
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 )
 
@@ -20,14 +21,25 @@ type linkChecker struct {
 	conc    int
 	timeout time.Duration
 	client  *http.Client
+	egress  *egress.Policy
+	// userAgent, when set, is sent with every check.
+	userAgent string
+
+	insecure       insecureHosts
+	insecureClient *http.Client
 }
 
 // newLinkChecker creates a new link checker with the specified concurrency and timeout.
 func newLinkChecker(conc int, timeout time.Duration) *linkChecker {
+	return newLinkCheckerWithTransport(conc, timeout, http.DefaultTransport)
+}
+
+// newLinkCheckerWithTransport creates a link checker whose requests go through rt.
+func newLinkCheckerWithTransport(conc int, timeout time.Duration, rt http.RoundTripper) *linkChecker {
 	return &linkChecker{
 		conc:    conc,
 		timeout: timeout,
-		client:  &http.Client{Timeout: timeout},
+		client:  &http.Client{Timeout: timeout, Transport: rt},
 	}
 }
 
@@ -36,12 +48,21 @@ func NewLinkChecker(conc int, timeout time.Duration) *linkChecker {
 	return newLinkChecker(conc, timeout)
 }
 
-// run checks the status of links in the provided analysis result.
+// run checks the status of links in the provided analysis result using at
+// most lc.conc concurrent requests. Once ctx is done no further links are
+// probed; those left keep StatusCode 0.
 func (lc *linkChecker) run(ctx context.Context, links []model.Link) []model.Link {
 	in := make(chan *model.Link)
 	var wg sync.WaitGroup
 
-	for i := 0; i < lc.conc; i++ {
+	workers := lc.conc
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(links) {
+		workers = len(links)
+	}
+	for i := 0; i < workers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -52,10 +73,14 @@ func (lc *linkChecker) run(ctx context.Context, links []model.Link) []model.Link
 	}
 
 	go func() {
+		defer close(in)
 		for i := range links {
-			in <- &links[i]
+			select {
+			case in <- &links[i]:
+			case <-ctx.Done():
+				return
+			}
 		}
-		close(in)
 	}()
 
 	wg.Wait()
@@ -67,23 +92,34 @@ func (lc *linkChecker) Run(ctx context.Context, links []model.Link) []model.Link
 	return lc.run(ctx, links)
 }
 
-// head performs a HEAD request to check the link status, respecting robots.txt rules.
+// head performs a HEAD request to check the link status, respecting robots.txt
+// rules. Servers that do not support HEAD are asked again with GET.
 func (lc *linkChecker) head(ctx context.Context, raw string) int {
 	u, _ := url.Parse(raw)
-	if !robotsAllowed(lc.client, u) {
+	if u == nil || ctx.Err() != nil || lc.egress.Check(u) != nil {
+		return 0
+	}
+	client := lc.client
+	if u.Scheme == "https" && lc.insecure.match(u.Hostname()) {
+		client = lc.insecureClient
+	}
+	if !robotsAllowed(ctx, client, u) {
 		return http.StatusForbidden
 	}
 
 	req, _ := http.NewRequestWithContext(ctx, http.MethodHead, raw, nil)
-	resp, err := lc.client.Do(req)
+	if lc.userAgent != "" {
+		req.Header.Set("User-Agent", lc.userAgent)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return 0
 	}
 	resp.Body.Close()
 
-	if resp.StatusCode == http.StatusMethodNotAllowed {
+	if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented {
 		req.Method = http.MethodGet
-		resp2, err := lc.client.Do(req)
+		resp2, err := client.Do(req)
 		if err != nil {
 			return 0
 		}
@@ -94,7 +130,7 @@ func (lc *linkChecker) head(ctx context.Context, raw string) int {
 }
 
 // robotsAllowed checks if the link is allowed by robots.txt rules.
-func robotsAllowed(c *http.Client, u *url.URL) bool {
+func robotsAllowed(ctx context.Context, c *http.Client, u *url.URL) bool {
 	if u.Host == "" {
 		return true
 	}
@@ -105,9 +141,16 @@ func robotsAllowed(c *http.Client, u *url.URL) bool {
 		return val.(*robotstxt.RobotsData).TestAgent(u.Path, "*")
 	}
 
-	resp, err := c.Get(u.Scheme + "://" + u.Host + "/robots.txt")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.Scheme+"://"+u.Host+"/robots.txt", nil)
 	if err != nil {
-		robots.Store(u.Host, nil)
+		return true
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		// A cancelled crawl says nothing about the host, so it is not cached.
+		if ctx.Err() == nil {
+			robots.Store(u.Host, nil)
+		}
 		return true
 	}
 	defer resp.Body.Close()
@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// NormalizeRules controls how extracted links are canonicalized, so that
+// links considered equal are counted and stored once. The zero value keeps
+// links as resolved.
+type NormalizeRules struct {
+	StripParams        []string // query parameter names to drop; path.Match patterns such as "utm_*"
+	LowercaseHost      bool
+	StripTrailingSlash bool
+	StripFragment      bool
+}
+
+// apply returns raw normalized according to the rules.
+func (n NormalizeRules) apply(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	if n.LowercaseHost {
+		u.Host = strings.ToLower(u.Host)
+	}
+	if n.StripFragment {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+	if n.StripTrailingSlash {
+		u.Path = strings.TrimRight(u.Path, "/")
+		u.RawPath = strings.TrimRight(u.RawPath, "/")
+	}
+	if len(n.StripParams) > 0 && u.RawQuery != "" {
+		q := u.Query()
+		removed := false
+		for name := range q {
+			if n.stripParam(name) {
+				q.Del(name)
+				removed = true
+			}
+		}
+		// Only re-encode when needed; Encode sorts the remaining parameters.
+		if removed {
+			u.RawQuery = q.Encode()
+		}
+	}
+	return u.String()
+}
+
+// stripParam reports whether the query parameter name matches a strip pattern.
+func (n NormalizeRules) stripParam(name string) bool {
+	for _, pattern := range n.StripParams {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TransportOptions tunes the HTTP transport shared by page fetches and link
+// checks. Zero values fall back to defaults sized for the link checker's
+// concurrency, so checking many links on one host reuses connections instead
+// of redialing.
+type TransportOptions struct {
+	MaxIdleConns          int           // default 100
+	MaxIdleConnsPerHost   int           // default 16
+	IdleConnTimeout       time.Duration // default 90s
+	DialTimeout           time.Duration // default 10s; bounds the TCP connect
+	ResponseHeaderTimeout time.Duration // default 10s; bounds the wait for response headers once the request is sent
+}
+
+// newTransport returns the transport every client of one analyzer shares.
+func newTransport(opts TransportOptions) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = 100
+	if opts.MaxIdleConns > 0 {
+		t.MaxIdleConns = opts.MaxIdleConns
+	}
+	t.MaxIdleConnsPerHost = 16
+	if opts.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	dialTimeout := 10 * time.Second
+	if opts.DialTimeout > 0 {
+		dialTimeout = opts.DialTimeout
+	}
+	t.DialContext = (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	t.ResponseHeaderTimeout = 10 * time.Second
+	if opts.ResponseHeaderTimeout > 0 {
+		t.ResponseHeaderTimeout = opts.ResponseHeaderTimeout
+	}
+	return t
+}
+
+// maxPooledBuffer keeps unusually large pages from pinning memory in the pool.
+const maxPooledBuffer = 4 << 20
+
+// bodyBuffers holds reusable buffers for reading page bodies.
+var bodyBuffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+func getBuffer() *bytes.Buffer {
+	buf := bodyBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	bodyBuffers.Put(buf)
+}
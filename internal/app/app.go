@@ -16,12 +16,15 @@ import (
 	"github.com/fuzumoe/linkTorch-api/configs"
 	"github.com/fuzumoe/linkTorch-api/internal/analyzer"
 	"github.com/fuzumoe/linkTorch-api/internal/crawler"
+	"github.com/fuzumoe/linkTorch-api/internal/egress"
 	"github.com/fuzumoe/linkTorch-api/internal/handler"
+	"github.com/fuzumoe/linkTorch-api/internal/metrics"
 	"github.com/fuzumoe/linkTorch-api/internal/middleware"
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
 	"github.com/fuzumoe/linkTorch-api/internal/server"
 	"github.com/fuzumoe/linkTorch-api/internal/service"
+	"github.com/fuzumoe/linkTorch-api/internal/storage"
 )
 
 var (
@@ -52,26 +55,149 @@ func Run() error {
 
 	userRepo := repository.NewUserRepo(db)
 	authRepo := repository.NewTokenRepo(db)
-	urlRepo := repository.NewURLRepo(db)
+	urlRepo := repository.NewURLRepoWithOptions(db, repository.URLRepoOptions{
+		DeadlockRetry: repository.DeadlockRetry{
+			Attempts: cfg.DeadlockRetries,
+			Backoff:  repository.DefaultDeadlockRetry.Backoff,
+		},
+	})
+	linkRepo := repository.NewLinkRepo(db)
+	analysisRepo := repository.NewAnalysisResultRepo(db)
+	statsRepo := repository.NewStatsRepo(db)
+	notificationRepo := repository.NewNotificationRepo(db)
+	apiKeyRepo := repository.NewAPIKeyRepo(db)
 
-	healthSvc := service.NewHealthService(db, "LinkTorch API")
-	userSvc := service.NewUserService(userRepo)
-	authSVC := service.NewAuthService(
+	authSVC := service.NewAuthServiceWithOptions(
 		userRepo,
 		authRepo,
 		cfg.JWTSecret,
 		cfg.JWTLifetime,
+		service.AuthServiceOptions{RefreshLifetime: cfg.JWTRefreshLifetime},
 	)
 
-	htmlAnalyzer := analyzer.NewHTMLAnalyzer()
-	crawlerPool := crawler.New(urlRepo, htmlAnalyzer, cfg.NumberOfCrawlers, cfg.MaxConcurrentCrawls, cfg.CrawlTimeout)
+	var rawHTML storage.BlobStore
+	if cfg.StoreRawHTML {
+		rawHTML, err = storage.NewFileStore(cfg.BlobDir)
+		if err != nil {
+			return fmt.Errorf("blob store init error: %w", err)
+		}
+	}
 
-	urlSvc := service.NewURLService(urlRepo, crawlerPool)
+	egressPolicy := egress.NewPolicyWithHTTPSOnly(egress.Mode(cfg.EgressMode), cfg.EgressHosts, cfg.HTTPSOnly)
+	titleFallback := make([]analyzer.TitleSource, len(cfg.TitleFallback))
+	for i, source := range cfg.TitleFallback {
+		titleFallback[i] = analyzer.TitleSource(source)
+	}
+	htmlAnalyzer := analyzer.NewHTMLAnalyzer(analyzer.Options{
+		Timeout:          cfg.CrawlHTTPTimeout,
+		UserAgent:        cfg.UserAgent,
+		ContentPolicy:    analyzer.ContentPolicy(cfg.UnknownContentPolicy),
+		Egress:           egressPolicy,
+		MaxRedirects:     cfg.MaxRedirects,
+		LinkBase:         analyzer.LinkBase(cfg.ExternalLinkBase),
+		InsecureTLSHosts: cfg.InsecureTLSHosts,
+		RawHTML:          rawHTML,
+		Transport:        analyzer.TransportOptions{MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost},
+		TitleFallback:    titleFallback,
+		Normalize: analyzer.NormalizeRules{
+			StripParams:        cfg.LinkStripParams,
+			LowercaseHost:      cfg.LinkLowercaseHost,
+			StripTrailingSlash: cfg.LinkStripSlash,
+			StripFragment:      cfg.LinkStripFragment,
+		},
+		LinkCheckConcurrency: cfg.LinkCheckConcurrency,
+	})
+	if len(cfg.InsecureTLSHosts) > 0 {
+		log.Printf("[WARN] TLS certificate verification is disabled when crawling: %v", cfg.InsecureTLSHosts)
+	}
+	var robots *crawler.RobotsChecker
+	if cfg.RespectRobots {
+		robots = crawler.NewRobotsChecker(cfg.UserAgent, cfg.RobotsCacheTTL, egressPolicy)
+	}
+	var (
+		crawlerPool   crawler.Pool
+		promMetrics   *metrics.Metrics
+		crawlObserver crawler.CrawlObserver
+	)
+	if cfg.MetricsEnabled {
+		promMetrics = metrics.New(func() int { return crawlerPool.QueueDepth() })
+		crawlObserver = promMetrics
+	}
+	crawlLogs := crawler.NewLogHub()
+	crawlerPool = crawler.NewWithOptions(urlRepo, htmlAnalyzer, cfg.NumberOfCrawlers, cfg.MaxConcurrentCrawls, cfg.CrawlTimeout, crawler.Options{
+		Retry: crawler.RetryPolicy{
+			MaxAttempts: cfg.CrawlRetryAttempts,
+			BaseDelay:   cfg.CrawlRetryDelay,
+		},
+		Robots:        robots,
+		Hosts:         crawler.NewHostLimiter(cfg.HostConcurrency),
+		QueueCapacity: cfg.QueueCapacity,
+		Idle: crawler.IdleScaling{
+			Timeout:    cfg.WorkerIdleTimeout,
+			MinWorkers: cfg.MinCrawlers,
+		},
+		Observer: crawlObserver,
+		Reserve: crawler.ReservedWorkers{
+			Workers:     cfg.ReservedCrawlers,
+			MinPriority: cfg.ReservedMinPriority,
+		},
+		Logs: crawlLogs,
+	})
+	healthSvc := service.NewHealthServiceWithCrawler(db, "LinkTorch API", crawlerPool)
+
+	crawlQuota := service.CrawlQuota{PerUser: cfg.CrawlQuotaPerUser, ByRole: map[model.UserRole]int{}}
+	for role, n := range cfg.CrawlQuotaByRole {
+		crawlQuota.ByRole[model.UserRole(role)] = n
+	}
+	urlSvc := service.NewURLServiceWithOptions(urlRepo, crawlerPool, egressPolicy, service.URLServiceOptions{
+		SlowCrawl: service.SlowCrawlPolicy{
+			Threshold: cfg.SlowCrawlThreshold,
+			Penalty:   cfg.SlowCrawlPenalty,
+		},
+		TagHost:       cfg.AutoTagHost,
+		Quota:         crawlQuota,
+		Users:         userRepo,
+		CrawlOnCreate: cfg.CrawlOnCreate,
+		RawHTML:       rawHTML,
+	})
+	userSvc := service.NewUserService(userRepo, service.UserServiceOptions{
+		UsernameCaseSensitive: cfg.UsernameMatchCase,
+		RawHTML:               rawHTML,
+		Tokens:                authRepo,
+		JWTSecret:             cfg.JWTSecret,
+		ResetTTL:              cfg.PasswordResetTTL,
+		EmailVerification: &service.EmailVerification{
+			TTL:            cfg.EmailVerifyTTL,
+			ResendInterval: cfg.EmailVerifyResend,
+		},
+	})
+	linkSvc := service.NewLinkService(linkRepo)
+	analysisSvc := service.NewAnalysisService(analysisRepo, rawHTML)
+	statsSvc := service.NewStatsService(statsRepo, crawlerPool)
+	notificationSvc := service.NewNotificationService(notificationRepo)
+	apiKeySvc := service.NewAPIKeyServiceWithOptions(apiKeyRepo, userRepo, service.APIKeyServiceOptions{
+		MaxLifetime:   cfg.APIKeyMaxLifetime,
+		RequireExpiry: cfg.APIKeyRequireExpiry,
+	})
+	exportSvc := service.NewExportService(userRepo, urlRepo, analysisRepo, linkRepo, notificationRepo)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	go crawlerPool.Start(ctx)
+	if cfg.WatchdogInterval > 0 {
+		if maxCrawl := cfg.CrawlTimeout * time.Duration(cfg.CrawlRetryAttempts); cfg.StuckCrawlAfter <= maxCrawl {
+			log.Printf("[WARN] CRAWL_STUCK_AFTER (%s) does not exceed the longest crawl (%s); slow crawls may be reset while running", cfg.StuckCrawlAfter, maxCrawl)
+		}
+		go crawler.NewWatchdog(urlRepo, crawlerPool, crawler.WatchdogOptions{
+			Interval:   cfg.WatchdogInterval,
+			StuckAfter: cfg.StuckCrawlAfter,
+			ResetTo:    cfg.StuckCrawlAction,
+		}).Run(ctx)
+	}
+	if cfg.TrashRetention > 0 {
+		go service.NewTrashPurger(urlSvc, cfg.TrashPurgeInterval, cfg.TrashRetention).Run(ctx)
+	}
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
@@ -126,18 +252,66 @@ func Run() error {
 		}
 	}
 
-	dualAuthMiddleware := middleware.AuthMiddleware(authSVC)
+	dualAuthMiddleware := middleware.AuthMiddlewareWithOptions(authSVC, middleware.AuthOptions{APIKeys: apiKeySvc})
 
 	healthH := handler.NewHealthHandler(healthSvc)
-	authH := handler.NewAuthHandler(authSVC, userSvc)
-	urlH := handler.NewURLHandler(urlSvc)
-	userH := handler.NewUserHandler(userSvc)
+	// Nothing can email reset or verification tokens yet. They are only
+	// logged when DEV_LOG_AUTH_TOKENS asks for it, since anyone reading the
+	// log could take over or verify the account; otherwise both are disabled.
+	var sendReset handler.PasswordResetSender
+	var sendVerification handler.EmailVerificationSender
+	if cfg.DevLogAuthTokens {
+		log.Printf("[WARN] DEV_LOG_AUTH_TOKENS is set: password reset and email verification tokens are written to the log")
+		sendReset = func(email, token string) error {
+			log.Printf("[DEBUG] password reset token for %s: %s", email, token)
+			return nil
+		}
+		sendVerification = func(email, token string) error {
+			log.Printf("[DEBUG] email verification token for %s: %s", email, token)
+			return nil
+		}
+	}
+	if cfg.RequireVerifiedEmail && sendVerification == nil {
+		log.Printf("[WARN] REQUIRE_EMAIL_VERIFICATION is set but verification tokens cannot be delivered; unverified users cannot use URL routes")
+	}
+	authH := handler.NewAuthHandlerWithPasswordReset(authSVC, userSvc, sendReset)
+	streamOpts := handler.StreamOptions{
+		Heartbeat:   cfg.SSEHeartbeat,
+		MaxLifetime: cfg.SSEMaxLifetime,
+		Limiter:     handler.NewStreamLimiter(cfg.StreamMaxConns, cfg.StreamMaxConnsUser),
+	}
+	urlH := handler.NewURLHandlerWithOptions(urlSvc, handler.URLHandlerOptions{
+		MaxResultLinks: cfg.ResultsMaxLinks,
+		Stream:         streamOpts,
+	})
+	userH := handler.NewUserHandlerWithOptions(userSvc, handler.UserHandlerOptions{
+		Export:           exportSvc,
+		SendVerification: sendVerification,
+	})
+	linkH := handler.NewLinkHandler(linkSvc)
+	crawlLogH := handler.NewCrawlLogHandler(crawlLogs, streamOpts)
+	analysisH := handler.NewAnalysisHandler(analysisSvc)
+	statsH := handler.NewStatsHandler(statsSvc)
+	notificationH := handler.NewNotificationHandler(notificationSvc)
+	apiKeyH := handler.NewAPIKeyHandler(apiKeySvc)
 
 	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.Use(middleware.RequestLogger(middleware.NewLogger(os.Stdout, cfg.LogLevel, cfg.LogFormat)))
+	if promMetrics != nil {
+		router.Use(middleware.RequestMetrics(promMetrics))
+		router.GET("/metrics", gin.WrapH(promMetrics.Handler()))
+	}
+	// The long-poll may rightly take up to its timeout.
+	router.Use(middleware.SlowRequestLogger(cfg.SlowRequestThreshold, "/api/v1/urls/:id/wait"))
+	router.Use(middleware.BodyLogger(cfg.LogHTTPBodies && cfg.LogLevel == "debug", cfg.LogHTTPBodyMaxBytes))
 	publicRegs := []server.RouteRegistrar{
 		RouteRegistrarFunc(func(rg *gin.RouterGroup) {
 			authH.RegisterPublicRoutes(rg)
 		}),
+		RouteRegistrarFunc(func(rg *gin.RouterGroup) {
+			userH.RegisterPublicRoutes(rg)
+		}),
 		healthH,
 	}
 	protectedRegs := []server.RouteRegistrar{
@@ -145,11 +319,35 @@ func Run() error {
 			authH.RegisterProtectedRoutes(rg)
 		}),
 		RouteRegistrarFunc(func(rg *gin.RouterGroup) {
-			urlH.RegisterProtectedRoutes(rg)
+			urlH.RegisterProtectedRoutes(rg.Group("", middleware.RequireVerifiedEmail(userSvc, cfg.RequireVerifiedEmail)))
 		}),
 		RouteRegistrarFunc(func(rg *gin.RouterGroup) {
 			userH.RegisterProtectedRoutes(rg)
 		}),
+		RouteRegistrarFunc(func(rg *gin.RouterGroup) {
+			linkH.RegisterProtectedRoutes(rg)
+		}),
+		RouteRegistrarFunc(func(rg *gin.RouterGroup) {
+			crawlLogH.RegisterProtectedRoutes(rg)
+		}),
+		RouteRegistrarFunc(func(rg *gin.RouterGroup) {
+			analysisH.RegisterProtectedRoutes(rg)
+		}),
+		RouteRegistrarFunc(func(rg *gin.RouterGroup) {
+			statsH.RegisterProtectedRoutes(rg)
+		}),
+		RouteRegistrarFunc(func(rg *gin.RouterGroup) {
+			notificationH.RegisterProtectedRoutes(rg)
+		}),
+		RouteRegistrarFunc(func(rg *gin.RouterGroup) {
+			apiKeyH.RegisterProtectedRoutes(rg)
+		}),
+	}
+	if promMetrics != nil {
+		metricsH := handler.NewMetricsHandler(promMetrics)
+		protectedRegs = append(protectedRegs, RouteRegistrarFunc(func(rg *gin.RouterGroup) {
+			metricsH.RegisterProtectedRoutes(rg)
+		}))
 	}
 	server.RegisterRoutes(
 		router,
@@ -161,8 +359,10 @@ func Run() error {
 
 	addr := fmt.Sprintf("%s:%s", cfg.ServerHost, cfg.ServerPort)
 	srv := &http.Server{
-		Addr:    addr,
-		Handler: router,
+		Addr:         addr,
+		Handler:      router,
+		ReadTimeout:  cfg.HTTPReadTimeout,
+		WriteTimeout: cfg.HTTPWriteTimeout,
 	}
 
 	go func() {
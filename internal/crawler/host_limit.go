@@ -0,0 +1,81 @@
+package crawler
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// hostCapacity is the weight of one host's semaphore. A crawl allowed n
+// concurrent requests to the host takes hostCapacity/n of it, so URLs with
+// different caps still share a single semaphore per host.
+const hostCapacity = 1 << 30
+
+// HostLimiter caps how many crawls of the same host run at once across all
+// workers of a pool. Semaphores are created on first use and dropped once a
+// host has no crawls in flight.
+type HostLimiter struct {
+	limit int // default cap; 0 leaves hosts without an override unlimited
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlot
+}
+
+type hostSlot struct {
+	sem   *semaphore.Weighted
+	users int
+}
+
+// NewHostLimiter creates a limiter allowing limit concurrent crawls per host
+// unless a URL overrides it.
+func NewHostLimiter(limit int) *HostLimiter {
+	return &HostLimiter{limit: max(limit, 0), hosts: make(map[string]*hostSlot)}
+}
+
+// Acquire blocks until a crawl of host may start or ctx is done. override,
+// when positive, replaces the default cap for this crawl. The returned
+// release must be called once the crawl has finished.
+func (l *HostLimiter) Acquire(ctx context.Context, host string, override int) (func(), error) {
+	limit := override
+	if limit <= 0 && l != nil {
+		limit = l.limit
+	}
+	if l == nil || limit <= 0 || host == "" {
+		return func() {}, nil
+	}
+	host = strings.ToLower(host)
+	weight := int64(hostCapacity / limit)
+
+	l.mu.Lock()
+	slot, ok := l.hosts[host]
+	if !ok {
+		slot = &hostSlot{sem: semaphore.NewWeighted(hostCapacity)}
+		l.hosts[host] = slot
+	}
+	slot.users++
+	l.mu.Unlock()
+
+	if err := slot.sem.Acquire(ctx, weight); err != nil {
+		l.leave(host, slot)
+		return nil, err
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			slot.sem.Release(weight)
+			l.leave(host, slot)
+		})
+	}, nil
+}
+
+// leave drops the host's semaphore once nobody holds or waits for it.
+func (l *HostLimiter) leave(host string, slot *hostSlot) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slot.users--
+	if slot.users == 0 {
+		delete(l.hosts, host)
+	}
+}
@@ -0,0 +1,63 @@
+package crawler
+
+import (
+	"sync"
+	"time"
+)
+
+// LogLine is a single crawl log message for one URL.
+type LogLine struct {
+	URLID   uint      `json:"url_id"`
+	Worker  int       `json:"worker"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// LogHub fans crawl log lines out to the subscribers of a URL.
+type LogHub struct {
+	mu   sync.Mutex
+	subs map[uint]map[chan LogLine]struct{}
+}
+
+// NewLogHub creates an empty log hub.
+func NewLogHub() *LogHub {
+	return &LogHub{subs: make(map[uint]map[chan LogLine]struct{})}
+}
+
+// Subscribe returns a channel receiving log lines for urlID and a function
+// that ends the subscription.
+func (h *LogHub) Subscribe(urlID uint) (<-chan LogLine, func()) {
+	ch := make(chan LogLine, 64)
+
+	h.mu.Lock()
+	if h.subs[urlID] == nil {
+		h.subs[urlID] = make(map[chan LogLine]struct{})
+	}
+	h.subs[urlID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subs[urlID], ch)
+			if len(h.subs[urlID]) == 0 {
+				delete(h.subs, urlID)
+			}
+		})
+	}
+}
+
+// Publish delivers line to the subscribers of its URL. Lines are dropped for
+// subscribers that are not keeping up, so crawling never blocks on a reader.
+func (h *LogHub) Publish(line LogLine) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[line.URLID] {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
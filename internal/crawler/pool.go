@@ -2,24 +2,96 @@ package crawler
 
 import (
 	"context"
+	"errors"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fuzumoe/linkTorch-api/internal/analyzer"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
 )
 
+// ErrQueueFull is returned when a URL is enqueued while the pool's queue is at
+// capacity.
+var ErrQueueFull = errors.New("crawl queue is full")
+
+// ErrPoolStopped is returned when a URL is enqueued after the pool shut down.
+var ErrPoolStopped = errors.New("crawler pool is shut down")
+
+// ErrNotQueued is returned when reprioritizing a URL that is not waiting in
+// the queue, e.g. because a worker already took it.
+var ErrNotQueued = errors.New("url is not waiting in the crawl queue")
+
 type Pool interface {
 	Start(ctx context.Context)
-	Enqueue(id uint)
-	EnqueueWithPriority(id uint, priority int)
+	Enqueue(id uint) error
+	EnqueueWithPriority(id uint, priority int) error
+	EnqueueWithRequestID(id uint, priority int, requestID string) error
+	Reprioritize(id uint, priority int) error
+	Snapshot(limit int) QueueSnapshot
 	Shutdown()
 	GetResults() <-chan CrawlResult
 	AdjustWorkers(cmd ControlCommand)
+	QueueDepth() int
+	Workers() int
+	Pause()
+	Resume()
+	Paused() bool
+	Running() bool
+	Stats() PoolStats
+}
+
+// Options configures the optional behaviour of a pool. The zero value is a
+// plain pool: no retries, no robots.txt check, uncapped hosts, a queue
+// bounded by the buffer size, no idle scaling and no reserved workers.
+type Options struct {
+	Retry RetryPolicy
+	// Robots, when set, makes workers skip URLs it disallows.
+	Robots *RobotsChecker
+	// Hosts, when set, caps how many workers crawl the same host at once.
+	Hosts *HostLimiter
+	// QueueCapacity is how many URLs may wait before Enqueue fails with
+	// ErrQueueFull. With 0 the buffer size bounds it.
+	QueueCapacity int
+	Idle          IdleScaling
+	// Observer is told about the start and end of each crawl.
+	Observer CrawlObserver
+	Reserve  ReservedWorkers
+	// Logs, when set, receives the log lines of every crawl.
+	Logs *LogHub
+}
+
+// IdleScaling shrinks an idle pool: a worker that waited Timeout for a task
+// exits as long as more than MinWorkers are left. A zero Timeout disables it.
+type IdleScaling struct {
+	Timeout    time.Duration
+	MinWorkers int
+}
+
+// CrawlObserver is told about every crawl the workers of a pool run, e.g. to
+// export metrics. Its methods are called from the workers' goroutines.
+type CrawlObserver interface {
+	CrawlStarted()
+	CrawlFinished(status string, elapsed time.Duration)
+}
+
+// ReservedWorkers sets Workers of a pool's workers aside for tasks of at least
+// MinPriority, so a backlog of lower-priority URLs cannot hold them up. High
+// tasks go to an idle reserved worker first and to the other workers once all
+// reserved ones are busy. A MinPriority of 0 means above the default priority.
+// At least one worker is left for the other URLs.
+type ReservedWorkers struct {
+	Workers     int
+	MinPriority int
 }
 
 func New(repo repository.URLRepository, a analyzer.Analyzer, workers, buf int, crawlTimeout time.Duration) Pool {
+	return NewWithOptions(repo, a, workers, buf, crawlTimeout, Options{})
+}
+
+// NewWithOptions creates a pool configured by opts.
+func NewWithOptions(repo repository.URLRepository, a analyzer.Analyzer, workers, buf int, crawlTimeout time.Duration, opts Options) Pool {
 	if workers <= 0 {
 		workers = 4
 	}
@@ -32,51 +104,85 @@ func New(repo repository.URLRepository, a analyzer.Analyzer, workers, buf int, c
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	capacity := opts.QueueCapacity
+	if capacity <= 0 {
+		capacity = buf
+	}
+
+	queue := newTaskQueue()
+	reserve := opts.Reserve
+	if reserve.Workers > 0 {
+		if reserve.Workers >= workers {
+			log.Printf("[crawler] reserving %d workers instead of %d to leave one for other URLs", workers-1, reserve.Workers)
+			reserve.Workers = workers - 1
+		}
+		if reserve.MinPriority <= 0 {
+			reserve.MinPriority = defaultPriority + 1
+		}
+		if reserve.Workers > 0 {
+			queue.reserveMin = reserve.MinPriority
+		}
+	}
+	reserve.Workers = max(reserve.Workers, 0)
+
 	return &pool{
-		repo:           repo,
-		analyzer:       a,
-		workers:        workers,
-		tasks:          make(chan uint, buf),
-		highPriority:   make(chan uint, buf/4),
-		normalPriority: make(chan uint, buf/2),
-		lowPriority:    make(chan uint, buf/4),
-		results:        make(chan CrawlResult, buf),
-		controlChan:    make(chan ControlCommand, 10),
-		ctx:            ctx,
-		cancel:         cancel,
-		crawlTimeout:   crawlTimeout,
+		repo:         repo,
+		analyzer:     a,
+		workers:      workers,
+		queue:        queue,
+		results:      make(chan CrawlResult, buf),
+		controlChan:  make(chan ControlCommand, 10),
+		ctx:          ctx,
+		cancel:       cancel,
+		crawlTimeout: crawlTimeout,
+		retry:        opts.Retry,
+		robots:       opts.Robots,
+		hosts:        opts.Hosts,
+		capacity:     capacity,
+		idle:         IdleScaling{Timeout: opts.Idle.Timeout, MinWorkers: max(opts.Idle.MinWorkers, 1)},
+		stats:        newPoolCounters(),
+		observer:     opts.Observer,
+		reserve:      reserve,
+		logs:         opts.Logs,
 	}
 }
 
 type pool struct {
-	repo           repository.URLRepository
-	analyzer       analyzer.Analyzer
-	workers        int
-	tasks          chan uint
-	highPriority   chan uint
-	normalPriority chan uint
-	lowPriority    chan uint
-	results        chan CrawlResult
-	controlChan    chan ControlCommand
-	ctx            context.Context
-	cancel         context.CancelFunc
-	wg             sync.WaitGroup
-	crawlTimeout   time.Duration
+	repo         repository.URLRepository
+	analyzer     analyzer.Analyzer
+	workersMu    sync.Mutex
+	workers      int
+	queue        *taskQueue
+	results      chan CrawlResult
+	controlChan  chan ControlCommand
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	crawlTimeout time.Duration
+	retry        RetryPolicy
+	robots       *RobotsChecker
+	hosts        *HostLimiter
+	capacity     int // URLs allowed to wait in queue
+	idle         IdleScaling
+	stats        *poolCounters
+	observer     CrawlObserver
+	reserve      ReservedWorkers
+	logs         *LogHub
+	running      atomic.Bool
+	shutdownOnce sync.Once
 }
 
 func (p *pool) Start(ctx context.Context) {
 	childCtx, cancel := context.WithCancel(ctx)
 	p.ctx = childCtx
 	defer cancel()
+	p.running.Store(true)
 
+	p.workersMu.Lock()
 	for i := 0; i < p.workers; i++ {
-		w := newWorker(i+1, p.ctx, p.repo, p.analyzer, p.crawlTimeout, p.results)
-		p.wg.Add(1)
-		go func() {
-			defer p.wg.Done()
-			w.runWithPriority(p.highPriority, p.normalPriority, p.lowPriority)
-		}()
+		p.spawn(i + 1)
 	}
+	p.workersMu.Unlock()
 
 	go func() {
 		for {
@@ -87,41 +193,20 @@ func (p *pool) Start(ctx context.Context) {
 				switch cmd.Action {
 				case "add":
 					log.Printf("[crawler] adding %d new workers", cmd.Count)
+					p.workersMu.Lock()
 					for i := 0; i < cmd.Count; i++ {
-						w := newWorker(p.workers+i+1, p.ctx, p.repo, p.analyzer, p.crawlTimeout, p.results)
-						p.wg.Add(1)
-						go func() {
-							defer p.wg.Done()
-							w.runWithPriority(p.highPriority, p.normalPriority, p.lowPriority)
-						}()
+						p.spawn(p.workers + i + 1)
 					}
 					p.workers += cmd.Count
+					p.workersMu.Unlock()
 				case "remove":
-					toRemove := min(cmd.Count, p.workers-1)
+					p.workersMu.Lock()
+					toRemove := min(cmd.Count, p.workers-1-p.reserve.Workers)
 					if toRemove > 0 {
 						log.Printf("[crawler] removing %d workers", toRemove)
 						p.workers = p.workers - toRemove
 					}
-				}
-			}
-		}
-	}()
-
-	go func() {
-		for {
-			select {
-			case <-p.ctx.Done():
-				return
-			case id, ok := <-p.tasks:
-				if !ok {
-					return
-				}
-				select {
-				case <-p.ctx.Done():
-					return
-				case p.normalPriority <- id:
-				default:
-					log.Printf("[crawler] normal priority queue full – dropping id=%d", id)
+					p.workersMu.Unlock()
 				}
 			}
 		}
@@ -131,33 +216,71 @@ func (p *pool) Start(ctx context.Context) {
 	p.Shutdown()
 }
 
-func (p *pool) Enqueue(id uint) {
-	select {
-	case <-p.ctx.Done():
-	case p.normalPriority <- id:
-	default:
-		log.Printf("[crawler] queue full – dropping id=%d", id)
+// spawn starts worker id. The first reserve.Workers workers are the reserved
+// ones, which never retire. The caller holds workersMu.
+func (p *pool) spawn(id int) {
+	w := NewWorkerWithOptions(id, p.ctx, p.repo, p.analyzer, p.crawlTimeout, p.results, WorkerOptions{
+		Retry:  p.retry,
+		Robots: p.robots,
+		Hosts:  p.hosts,
+		Logs:   p.logs,
+	})
+	w.stats = p.stats
+	w.observer = p.observer
+	w.reserved = id <= p.reserve.Workers
+	if p.idle.Timeout > 0 && !w.reserved {
+		w.idleTimeout = p.idle.Timeout
+		w.retire = p.retire
 	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		w.runQueue(p.queue)
+	}()
 }
 
-func (p *pool) EnqueueWithPriority(id uint, priority int) {
-	var targetQueue chan uint
-
-	switch {
-	case priority > 7:
-		targetQueue = p.highPriority
-	case priority < 3:
-		targetQueue = p.lowPriority
-	default:
-		targetQueue = p.normalPriority
+// retire lets an idle worker exit unless the pool is down to its minimum of
+// unreserved workers.
+func (p *pool) retire() bool {
+	p.workersMu.Lock()
+	defer p.workersMu.Unlock()
+	if p.workers <= p.idle.MinWorkers+p.reserve.Workers {
+		return false
 	}
+	p.workers--
+	return true
+}
 
-	select {
-	case <-p.ctx.Done():
-	case targetQueue <- id:
-	default:
-		log.Printf("[crawler] priority queue %d full – dropping id=%d", priority, id)
+func (p *pool) Enqueue(id uint) error {
+	return p.EnqueueWithPriority(id, defaultPriority)
+}
+
+// EnqueueWithPriority queues id behind every waiting URL of the same or a
+// higher priority. It fails with ErrQueueFull when the pool is at capacity
+// and with ErrPoolStopped after Shutdown.
+func (p *pool) EnqueueWithPriority(id uint, priority int) error {
+	return p.EnqueueWithRequestID(id, priority, "")
+}
+
+// EnqueueWithRequestID queues id like EnqueueWithPriority, tagging its crawl's
+// log lines and result with requestID.
+func (p *pool) EnqueueWithRequestID(id uint, priority int, requestID string) error {
+	return p.queue.push(id, priority, requestID, p.capacity)
+}
+
+// Reprioritize moves id, while it waits for a worker, to priority without
+// queueing it again. It fails with ErrNotQueued once a worker took it.
+func (p *pool) Reprioritize(id uint, priority int) error {
+	if !p.queue.reprioritize(id, priority) {
+		return ErrNotQueued
 	}
+	return nil
+}
+
+// Snapshot lists up to limit of the URLs waiting for a worker, in the order
+// they will be taken.
+func (p *pool) Snapshot(limit int) QueueSnapshot {
+	return p.queue.snapshot(limit)
 }
 
 func (p *pool) GetResults() <-chan CrawlResult {
@@ -173,13 +296,65 @@ func (p *pool) AdjustWorkers(cmd ControlCommand) {
 	}
 }
 
+// QueueDepth returns how many URL ids are waiting for a worker.
+func (p *pool) QueueDepth() int {
+	return p.queue.len()
+}
+
+// Pause stops workers from taking URLs off the queue. Crawls already running
+// finish, and Enqueue keeps accepting URLs until Resume.
+func (p *pool) Pause() {
+	if p.queue.setPaused(true) {
+		log.Printf("[crawler] paused with %d queued URLs", p.queue.len())
+	}
+}
+
+// Resume lets workers take URLs off the queue again.
+func (p *pool) Resume() {
+	if p.queue.setPaused(false) {
+		log.Printf("[crawler] resumed with %d queued URLs", p.queue.len())
+	}
+}
+
+// Paused reports whether the pool is paused.
+func (p *pool) Paused() bool {
+	return p.queue.isPaused()
+}
+
+// Stats returns the pool's worker and queue figures with the totals of the
+// crawls its workers finished.
+func (p *pool) Stats() PoolStats {
+	stats := p.stats.snapshot()
+	stats.Workers = p.Workers()
+	stats.QueueLength = p.queue.len()
+	stats.Paused = p.queue.isPaused()
+	return stats
+}
+
+// Running reports whether the pool was started and has not shut down since.
+func (p *pool) Running() bool {
+	return p.running.Load()
+}
+
+// Workers returns the current number of workers.
+func (p *pool) Workers() int {
+	p.workersMu.Lock()
+	defer p.workersMu.Unlock()
+	return p.workers
+}
+
+// Shutdown stops the workers once their current crawl ends, whether or not the
+// pool is paused. URLs still waiting in the queue are abandoned and keep their
+// queued status.
 func (p *pool) Shutdown() {
-	p.cancel()
-	p.wg.Wait()
-	close(p.tasks)
-	close(p.highPriority)
-	close(p.normalPriority)
-	close(p.lowPriority)
-	close(p.results)
-	close(p.controlChan)
+	p.shutdownOnce.Do(func() {
+		p.running.Store(false)
+		p.cancel()
+		if dropped := p.queue.close(); dropped > 0 {
+			log.Printf("[crawler] shutting down with %d queued URLs left", dropped)
+		}
+		p.wg.Wait()
+		close(p.results)
+		close(p.controlChan)
+	})
 }
@@ -0,0 +1,250 @@
+package crawler
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultPriority is the priority of URLs enqueued without one.
+const defaultPriority = 5
+
+type task struct {
+	id         uint
+	priority   int
+	seq        uint64 // enqueue order, keeping equal priorities first in first out
+	requestID  string // ID of the API request that queued the URL, if any
+	enqueuedAt time.Time
+}
+
+// MaxQueueSnapshot caps how many tasks a queue snapshot lists.
+const MaxQueueSnapshot = 500
+
+// QueuedTask is a URL waiting in a pool's queue.
+type QueuedTask struct {
+	URLID      uint      `json:"url_id"`
+	Priority   int       `json:"priority"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+}
+
+// QueueSnapshot lists the head of a pool's queue in the order workers take it.
+type QueueSnapshot struct {
+	Total int          `json:"total"` // URLs waiting, including those not listed
+	Tasks []QueuedTask `json:"tasks"`
+}
+
+// taskHeap orders tasks by descending priority, then by enqueue order.
+type taskHeap []task
+
+func (h taskHeap) Len() int { return len(h) }
+func (h taskHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h taskHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *taskHeap) Push(x any)   { *h = append(*h, x.(task)) }
+func (h *taskHeap) Pop() any {
+	old := *h
+	t := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return t
+}
+
+// popResult tells why taskQueue.pop returned.
+type popResult int
+
+const (
+	popped popResult = iota
+	popClosed
+	popIdle
+)
+
+// taskQueue is the pool's queue of URL ids waiting for a worker. Higher
+// priorities are taken first; workers block on it while it is empty or
+// paused.
+type taskQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	tasks  taskHeap
+	seq    uint64
+	closed bool
+	paused bool
+	// reserveMin, when set, is the lowest priority of the tasks reserved
+	// workers take. Unreserved workers leave such tasks to the idleReserved
+	// reserved workers waiting in pop, if any.
+	reserveMin   int
+	idleReserved int
+}
+
+func newTaskQueue() *taskQueue {
+	q := &taskQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds id, failing with ErrPoolStopped once the queue is closed and with
+// ErrQueueFull when limit ids are already waiting; limit 0 means no limit.
+// requestID travels with id to the worker that crawls it.
+func (q *taskQueue) push(id uint, priority int, requestID string, limit int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrPoolStopped
+	}
+	if limit > 0 && len(q.tasks) >= limit {
+		return ErrQueueFull
+	}
+	q.seq++
+	heap.Push(&q.tasks, task{id: id, priority: priority, seq: q.seq, requestID: requestID, enqueuedAt: time.Now()})
+	if q.reserveMin > 0 {
+		// A signalled worker may not be allowed to take the task.
+		q.cond.Broadcast()
+	} else {
+		q.cond.Signal()
+	}
+	return nil
+}
+
+// pop takes the next task, waiting for one while the queue is empty or
+// paused. With a positive idle it gives up with popIdle after waiting that
+// long without a task to take; a paused queue is never idle. A reserved
+// worker only takes tasks of at least the queue's reserveMin priority.
+func (q *taskQueue) pop(idle time.Duration, reserved bool) (task, popResult) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if reserved {
+		q.idleReserved++
+		defer func() { q.idleReserved-- }()
+	}
+	var deadline time.Time
+	if idle > 0 {
+		deadline = time.Now().Add(idle)
+		// Cond has no timed wait; wake every waiter so this one can see
+		// its deadline has passed.
+		timer := time.AfterFunc(idle, func() {
+			q.mu.Lock()
+			q.cond.Broadcast()
+			q.mu.Unlock()
+		})
+		defer timer.Stop()
+	}
+	for !q.takes(reserved) && !q.closed {
+		if idle > 0 && !q.paused && !time.Now().Before(deadline) {
+			return task{}, popIdle
+		}
+		q.cond.Wait()
+	}
+	if q.closed {
+		return task{}, popClosed
+	}
+	t := heap.Pop(&q.tasks).(task)
+	if reserved && len(q.tasks) > 0 {
+		// Unreserved workers may have been holding back for this one.
+		q.cond.Broadcast()
+	}
+	return t, popped
+}
+
+// takes reports whether a worker, reserved or not, may take the task at the
+// head of the queue. The caller holds mu.
+func (q *taskQueue) takes(reserved bool) bool {
+	if len(q.tasks) == 0 || q.paused {
+		return false
+	}
+	if q.reserveMin <= 0 {
+		return true
+	}
+	high := q.tasks[0].priority >= q.reserveMin
+	if reserved {
+		return high
+	}
+	return !high || q.idleReserved == 0
+}
+
+// reprioritize moves the waiting task for id to priority, keeping its place
+// among the tasks of that priority by enqueue order. It reports whether id
+// was waiting.
+func (q *taskQueue) reprioritize(id uint, priority int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.tasks {
+		if q.tasks[i].id != id {
+			continue
+		}
+		q.tasks[i].priority = priority
+		heap.Fix(&q.tasks, i)
+		if q.reserveMin > 0 {
+			// The task may have moved into or out of the reserved range.
+			q.cond.Broadcast()
+		}
+		return true
+	}
+	return false
+}
+
+// snapshot returns the first limit waiting tasks in pop order, limit being
+// capped at MaxQueueSnapshot. Reserved workers may take high-priority tasks
+// out of this order.
+func (q *taskQueue) snapshot(limit int) QueueSnapshot {
+	q.mu.Lock()
+	tasks := make(taskHeap, len(q.tasks))
+	copy(tasks, q.tasks)
+	q.mu.Unlock()
+
+	if limit <= 0 || limit > MaxQueueSnapshot {
+		limit = MaxQueueSnapshot
+	}
+	sort.Sort(tasks)
+	snap := QueueSnapshot{Total: len(tasks), Tasks: make([]QueuedTask, 0, min(limit, len(tasks)))}
+	for _, t := range tasks[:min(limit, len(tasks))] {
+		snap.Tasks = append(snap.Tasks, QueuedTask{URLID: t.id, Priority: t.priority, EnqueuedAt: t.enqueuedAt})
+	}
+	return snap
+}
+
+// setPaused stops or resumes handing out ids. Pushes are accepted either way.
+// It reports whether the state changed.
+func (q *taskQueue) setPaused(paused bool) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.paused == paused {
+		return false
+	}
+	q.paused = paused
+	if !paused {
+		q.cond.Broadcast()
+	}
+	return true
+}
+
+// isPaused reports whether pop is holding ids back.
+func (q *taskQueue) isPaused() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.paused
+}
+
+// len returns the number of waiting ids.
+func (q *taskQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.tasks)
+}
+
+// close wakes every waiting worker and drops the ids still queued, returning
+// how many there were. Later pushes are refused.
+func (q *taskQueue) close() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return 0
+	}
+	q.closed = true
+	dropped := len(q.tasks)
+	q.tasks = nil
+	q.cond.Broadcast()
+	return dropped
+}
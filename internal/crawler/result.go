@@ -8,12 +8,44 @@ import (
 
 type CrawlResult struct {
 	URLID     uint
+	UserID    uint // owner of the URL, once it has been looked up
 	URL       string
 	Status    string
 	Error     error
 	LinkCount int
 	Duration  time.Duration `json:"duration" swaggertype:"integer" format:"int64" example:"1500000000"` // Duration in nanoseconds
 	Links     []model.Link
+	Attempt   int    // 1 for the first try; failed attempts that will be retried are reported as running
+	RequestID string // the API request that queued the crawl; empty when none did
+}
+
+// CrawlEvent is the JSON form of a CrawlResult streamed to clients.
+type CrawlEvent struct {
+	URLID      uint   `json:"url_id"`
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	LinkCount  int    `json:"link_count"`
+	DurationMs int64  `json:"duration_ms"`
+	Attempt    int    `json:"attempt"`
+	RequestID  string `json:"request_id,omitempty"`
+}
+
+// Event converts r to its streamed form.
+func (r CrawlResult) Event() CrawlEvent {
+	ev := CrawlEvent{
+		URLID:      r.URLID,
+		URL:        r.URL,
+		Status:     r.Status,
+		LinkCount:  r.LinkCount,
+		DurationMs: r.Duration.Milliseconds(),
+		Attempt:    r.Attempt,
+		RequestID:  r.RequestID,
+	}
+	if r.Error != nil {
+		ev.Error = r.Error.Error()
+	}
+	return ev
 }
 
 type PriorityTask struct {
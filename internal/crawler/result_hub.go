@@ -0,0 +1,63 @@
+package crawler
+
+import "sync"
+
+// ResultHub fans the results of a pool out to any number of subscribers.
+type ResultHub struct {
+	mu     sync.Mutex
+	subs   map[chan CrawlResult]struct{}
+	closed bool
+}
+
+// NewResultHub creates a hub without subscribers. Call Run to feed it.
+func NewResultHub() *ResultHub {
+	return &ResultHub{subs: make(map[chan CrawlResult]struct{})}
+}
+
+// Run forwards every result of src to the current subscribers until src is
+// closed, then closes all subscriptions. Results are dropped for subscribers
+// that are not keeping up, so a slow reader never holds up the others.
+func (h *ResultHub) Run(src <-chan CrawlResult) {
+	for r := range src {
+		h.mu.Lock()
+		for ch := range h.subs {
+			select {
+			case ch <- r:
+			default:
+			}
+		}
+		h.mu.Unlock()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for ch := range h.subs {
+		close(ch)
+		delete(h.subs, ch)
+	}
+}
+
+// Subscribe returns a channel receiving every result published from now on
+// and a function that ends the subscription. The channel is closed when the
+// hub's source is.
+func (h *ResultHub) Subscribe() (<-chan CrawlResult, func()) {
+	ch := make(chan CrawlResult, 64)
+
+	h.mu.Lock()
+	if h.closed {
+		close(ch)
+	} else {
+		h.subs[ch] = struct{}{}
+	}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subs, ch)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fuzumoe/linkTorch-api/internal/analyzer"
+	"github.com/fuzumoe/linkTorch-api/internal/egress"
+)
+
+// RetryPolicy controls how often a failed crawl is re-attempted. The n-th
+// retry waits BaseDelay * 2^(n-1). The zero value crawls each URL once.
+type RetryPolicy struct {
+	MaxAttempts int // total attempts, including the first
+	BaseDelay   time.Duration
+}
+
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// delay returns the wait before the attempt following the given one.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	return p.BaseDelay << (attempt - 1)
+}
+
+// retryable reports whether a failed analysis may succeed if tried again.
+// Cancellation, skipped content and hosts the egress policy rejects are final;
+// network failures and server errors are not.
+func retryable(err error) bool {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, analyzer.ErrUnsupportedContent):
+		return false
+	case errors.Is(err, egress.ErrHostNotAllowed), errors.Is(err, egress.ErrHTTPSRequired):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,136 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/temoto/robotstxt"
+
+	"github.com/fuzumoe/linkTorch-api/internal/egress"
+)
+
+// ErrDisallowedByRobots is reported for URLs the site's robots.txt excludes us from.
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+// maxRobotsSize is how much of a robots.txt is read; RFC 9309 asks crawlers
+// to parse at least the first 500 KiB.
+const maxRobotsSize = 500 << 10
+
+// robotsAllowAll stands in for a robots.txt that does not exist or cannot be parsed.
+var robotsAllowAll, _ = robotstxt.FromString("")
+
+// DefaultRobotsTTL is how long a parsed robots.txt is reused when no TTL is given.
+const DefaultRobotsTTL = time.Hour
+
+// RobotsChecker decides whether a URL may be crawled according to its host's
+// robots.txt. Parsed files are cached per scheme and host for ttl.
+type RobotsChecker struct {
+	userAgent string
+	ttl       time.Duration
+	client    *http.Client
+	egress    *egress.Policy
+
+	mu    sync.Mutex
+	cache map[string]robotsEntry
+}
+
+type robotsEntry struct {
+	data    *robotstxt.RobotsData
+	expires time.Time
+}
+
+// NewRobotsChecker creates a checker that identifies itself as userAgent.
+// robots.txt is only fetched from hosts the egress policy allows.
+func NewRobotsChecker(userAgent string, ttl time.Duration, policy *egress.Policy) *RobotsChecker {
+	if ttl <= 0 {
+		ttl = DefaultRobotsTTL
+	}
+	return &RobotsChecker{
+		userAgent: userAgent,
+		ttl:       ttl,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return errors.New("too many robots.txt redirects")
+				}
+				return policy.Check(req.URL)
+			},
+		},
+		egress: policy,
+		cache:  make(map[string]robotsEntry),
+	}
+}
+
+// Allowed reports whether u may be fetched. When robots.txt cannot be
+// retrieved the error is returned along with true, so callers can crawl
+// anyway and let the page fetch surface the real problem. Hosts the egress
+// policy rejects are reported as allowed; the analyzer refuses them itself.
+func (c *RobotsChecker) Allowed(ctx context.Context, u *url.URL) (bool, error) {
+	if c == nil || c.egress.Check(u) != nil {
+		return true, nil
+	}
+	key := strings.ToLower(u.Scheme + "://" + u.Host)
+
+	c.mu.Lock()
+	entry, ok := c.cache[key]
+	c.mu.Unlock()
+	if !ok || time.Now().After(entry.expires) {
+		data, err := c.fetch(ctx, u)
+		if err != nil {
+			return true, err
+		}
+		entry = robotsEntry{data: data, expires: time.Now().Add(c.ttl)}
+		c.mu.Lock()
+		c.cache[key] = entry
+		c.mu.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return entry.data.TestAgent(path, c.userAgent), nil
+}
+
+// fetch downloads and parses robots.txt for u's host. A missing file (any
+// 4xx) or one that does not parse allows everything; server errors are
+// returned so nothing is cached.
+func (c *RobotsChecker) fetch(ctx context.Context, u *url.URL) (*robotstxt.RobotsData, error) {
+	robotsURL := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch robots.txt: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return nil, fmt.Errorf("fetch robots.txt: status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+	if err != nil {
+		return nil, fmt.Errorf("read robots.txt: %w", err)
+	}
+	data, err := robotstxt.FromStatusAndBytes(resp.StatusCode, body)
+	if err != nil {
+		return robotsAllowAll, nil
+	}
+	return data, nil
+}
@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+// PoolStats is a snapshot of a pool's health.
+type PoolStats struct {
+	Workers       int   `json:"workers"`        // workers the pool runs
+	ActiveWorkers int   `json:"active_workers"` // workers crawling right now
+	QueueLength   int   `json:"queue_length"`
+	Paused        bool  `json:"paused"`
+	Processed     int64 `json:"processed"` // crawls finished since the pool was created, whatever their outcome
+	Errored       int64 `json:"errored"`   // finished crawls that ended in error
+	AvgDurationMs int64 `json:"avg_duration_ms"`
+	// ByStatus counts finished crawls by the status they ended with.
+	ByStatus map[string]int64 `json:"by_status"`
+}
+
+// crawlStatuses are the statuses a crawl can end with. One that failed before
+// its URL could be marked running is reported, and counted, as running.
+var crawlStatuses = []string{
+	model.StatusDone, model.StatusError, model.StatusStopped, model.StatusSkipped, model.StatusRunning,
+}
+
+// poolCounters are updated by the workers of a pool as they crawl. They are
+// atomic so that reading stats never waits for a worker.
+type poolCounters struct {
+	active    atomic.Int64
+	processed atomic.Int64
+	totalNs   atomic.Int64
+	byStatus  map[string]*atomic.Int64 // keyed by crawlStatuses; never written after creation
+}
+
+func newPoolCounters() *poolCounters {
+	c := &poolCounters{byStatus: make(map[string]*atomic.Int64, len(crawlStatuses))}
+	for _, s := range crawlStatuses {
+		c.byStatus[s] = new(atomic.Int64)
+	}
+	return c
+}
+
+// begin records a worker starting a crawl.
+func (c *poolCounters) begin() {
+	c.active.Add(1)
+}
+
+// finish records a crawl that began at start ending with status.
+func (c *poolCounters) finish(status string, start time.Time) {
+	c.active.Add(-1)
+	c.processed.Add(1)
+	c.totalNs.Add(int64(time.Since(start)))
+	if n, ok := c.byStatus[status]; ok {
+		n.Add(1)
+	}
+}
+
+// snapshot fills the counter-based fields of PoolStats.
+func (c *poolCounters) snapshot() PoolStats {
+	stats := PoolStats{
+		ActiveWorkers: int(c.active.Load()),
+		Processed:     c.processed.Load(),
+		ByStatus:      make(map[string]int64, len(c.byStatus)),
+	}
+	for s, n := range c.byStatus {
+		stats.ByStatus[s] = n.Load()
+	}
+	stats.Errored = stats.ByStatus[model.StatusError]
+	if stats.Processed > 0 {
+		stats.AvgDurationMs = time.Duration(c.totalNs.Load() / stats.Processed).Milliseconds()
+	}
+	return stats
+}
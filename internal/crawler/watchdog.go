@@ -0,0 +1,72 @@
+package crawler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+)
+
+// WatchdogOptions configures a Watchdog.
+type WatchdogOptions struct {
+	Interval   time.Duration // Time between sweeps
+	StuckAfter time.Duration // URLs running for longer than this are considered stuck
+	// ResetTo is the status stuck URLs are moved to: model.StatusQueued
+	// re-queues them for another crawl, model.StatusError gives up on them.
+	ResetTo string
+}
+
+// Watchdog recovers URLs left running by a worker that died mid-crawl.
+type Watchdog struct {
+	repo repository.URLRepository
+	pool Pool
+	opts WatchdogOptions
+}
+
+// NewWatchdog creates a watchdog that resets stuck URLs in repo and, when
+// opts.ResetTo is model.StatusQueued, hands them back to pool.
+func NewWatchdog(repo repository.URLRepository, pool Pool, opts WatchdogOptions) *Watchdog {
+	if opts.ResetTo == "" {
+		opts.ResetTo = model.StatusQueued
+	}
+	return &Watchdog{repo: repo, pool: pool, opts: opts}
+}
+
+// Run sweeps every opts.Interval until ctx is done.
+func (w *Watchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.Sweep(); err != nil {
+				log.Printf("[crawler] watchdog: %v", err)
+			}
+		}
+	}
+}
+
+// Sweep resets the URLs that have been running for longer than
+// opts.StuckAfter and returns their IDs. A re-queued URL the pool does not
+// accept is marked as failed.
+func (w *Watchdog) Sweep() ([]uint, error) {
+	ids, err := w.repo.ResetStuck(time.Now().Add(-w.opts.StuckAfter), w.opts.ResetTo)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		log.Printf("[crawler] url %d: running for over %s, reset to %s", id, w.opts.StuckAfter, w.opts.ResetTo)
+		if w.opts.ResetTo != model.StatusQueued {
+			continue
+		}
+		if err := w.pool.Enqueue(id); err != nil {
+			log.Printf("[crawler] url %d: re-queue failed: %v", id, err)
+			_ = w.repo.UpdateStatus(id, model.StatusError)
+		}
+	}
+	return ids, nil
+}
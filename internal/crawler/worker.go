@@ -3,9 +3,12 @@ package crawler
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"runtime/debug"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 
 	"github.com/fuzumoe/linkTorch-api/internal/analyzer"
@@ -20,9 +23,38 @@ type worker struct {
 	analyzer     analyzer.Analyzer
 	crawlTimeout time.Duration
 	results      chan<- CrawlResult
+	retry        RetryPolicy
+	robots       *RobotsChecker // nil skips the robots.txt check
+	hosts        *HostLimiter   // nil leaves hosts uncapped
+	logs         *LogHub        // nil when nobody follows the crawl logs
+	// idleTimeout, when set, makes the worker ask retire whether it may exit
+	// after waiting that long for a task.
+	idleTimeout time.Duration
+	retire      func() bool
+	stats       *poolCounters // nil when the worker does not belong to a pool
+	observer    CrawlObserver // nil when nothing watches the pool's crawls
+	reserved    bool          // only takes the queue's high-priority tasks
 }
 
-func newWorker(id int, ctx context.Context, r repository.URLRepository, a analyzer.Analyzer, crawlTimeout time.Duration, results chan<- CrawlResult) *worker {
+// WorkerOptions configures the optional behaviour of a worker. The zero value
+// crawls once, skips the robots.txt check, leaves hosts uncapped and only
+// writes log lines to the standard logger.
+type WorkerOptions struct {
+	Retry RetryPolicy
+	// Robots, when set, is consulted before a page is fetched.
+	Robots *RobotsChecker
+	// Hosts, when set, makes each crawl wait for a free slot of its host.
+	Hosts *HostLimiter
+	// Logs, when set, receives every log line of a crawl.
+	Logs *LogHub
+}
+
+func NewWorker(id int, ctx context.Context, r repository.URLRepository, a analyzer.Analyzer, crawlTimeout time.Duration, results chan<- CrawlResult) *worker {
+	return NewWorkerWithOptions(id, ctx, r, a, crawlTimeout, results, WorkerOptions{})
+}
+
+// NewWorkerWithOptions creates a worker configured by opts.
+func NewWorkerWithOptions(id int, ctx context.Context, r repository.URLRepository, a analyzer.Analyzer, crawlTimeout time.Duration, results chan<- CrawlResult, opts WorkerOptions) *worker {
 	return &worker{
 		id:           id,
 		ctx:          ctx,
@@ -30,13 +62,13 @@ func newWorker(id int, ctx context.Context, r repository.URLRepository, a analyz
 		analyzer:     a,
 		crawlTimeout: crawlTimeout,
 		results:      results,
+		retry:        opts.Retry,
+		robots:       opts.Robots,
+		hosts:        opts.Hosts,
+		logs:         opts.Logs,
 	}
 }
 
-func NewWorker(id int, ctx context.Context, r repository.URLRepository, a analyzer.Analyzer, crawlTimeout time.Duration, results chan<- CrawlResult) *worker {
-	return newWorker(id, ctx, r, a, crawlTimeout, results)
-}
-
 func (w *worker) run(tasks <-chan uint) {
 	for {
 		select {
@@ -49,59 +81,35 @@ func (w *worker) run(tasks <-chan uint) {
 			if id == 0 {
 				continue
 			}
-			w.process(id)
+			w.process(id, "")
 		}
 	}
 }
 
-func (w *worker) runWithPriority(high, normal, low <-chan uint) {
+// runQueue processes ids from q until it is closed or, when idle workers
+// are retired, the worker has waited idleTimeout and may exit.
+func (w *worker) runQueue(q *taskQueue) {
+	var idle time.Duration
+	if w.idleTimeout > 0 && w.retire != nil {
+		idle = w.idleTimeout
+	}
 	for {
-
-		if w.ctx.Done() != nil {
-			select {
-			case <-w.ctx.Done():
-				return
-			default:
+		t, res := q.pop(idle, w.reserved)
+		switch res {
+		case popClosed:
+			return
+		case popIdle:
+			if w.retire() {
+				log.Printf("[crawler:%d] idle for %s – exiting", w.id, w.idleTimeout)
 				return
 			}
+			continue
 		}
-
-		select {
-		case <-w.ctx.Done():
+		if w.ctx.Err() != nil {
 			return
-
-		case id, ok := <-high:
-			if !ok {
-				continue
-			}
-			if id == 0 {
-				continue
-			}
-			w.process(id)
-
-		default:
-			select {
-			case <-w.ctx.Done():
-				return
-			case id, ok := <-normal:
-				if !ok {
-					continue
-				}
-				if id == 0 {
-					continue
-				}
-				w.process(id)
-			case id, ok := <-low:
-				if !ok {
-					continue
-				}
-				if id == 0 {
-					continue
-				}
-				w.process(id)
-			default:
-				time.Sleep(50 * time.Millisecond)
-			}
+		}
+		if t.id != 0 {
+			w.process(t.id, t.requestID)
 		}
 	}
 }
@@ -110,28 +118,60 @@ func (w *worker) Run(tasks <-chan uint) {
 	w.run(tasks)
 }
 
-func (w *worker) process(id uint) {
+// process crawls URL id. requestID, when set, is the API request that queued
+// it; it is added to every log line and to the results.
+func (w *worker) process(id uint, requestID string) {
+	logPrefix := fmt.Sprintf("[crawler:%d] id=%d", w.id, id)
+	if requestID != "" {
+		logPrefix += " request_id=" + requestID
+	}
 	logf := func(fmtStr string, v ...any) {
-		log.Printf("[crawler:%d] id=%d – "+fmtStr, append([]any{id}, v...)...)
+		msg := fmt.Sprintf(fmtStr, v...)
+		log.Printf("%s – %s", logPrefix, msg)
+		if w.logs != nil {
+			w.logs.Publish(LogLine{URLID: id, Worker: w.id, Time: time.Now(), Message: msg})
+		}
 	}
 
 	start := time.Now()
 	result := CrawlResult{
-		URLID:    id,
-		Status:   model.StatusRunning,
-		Duration: 0,
+		URLID:     id,
+		Status:    model.StatusRunning,
+		Duration:  0,
+		RequestID: requestID,
 	}
 
-	defer func() {
-		result.Duration = time.Since(start)
+	emit := func(r CrawlResult) {
+		r.Duration = time.Since(start)
 		if w.results != nil {
 			select {
 			case <-w.ctx.Done():
-			case w.results <- result:
+			case w.results <- r:
 			default:
 				logf("results channel full - dropping result")
 			}
 		}
+	}
+	defer func() { emit(result) }()
+
+	if w.stats != nil {
+		w.stats.begin()
+		defer func() { w.stats.finish(result.Status, start) }()
+	}
+	if w.observer != nil {
+		w.observer.CrawlStarted()
+		defer func() { w.observer.CrawlFinished(result.Status, time.Since(start)) }()
+	}
+
+	// A panic in the analyzer must not take the worker down with it.
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("analyzer panic: %v", r)
+			logf("%v\n%s", err, debug.Stack())
+			setErr(w.repo, id, err)
+			result.Status = model.StatusError
+			result.Error = err
+		}
 	}()
 
 	if err := w.repo.UpdateStatus(id, model.StatusRunning); err != nil {
@@ -150,6 +190,7 @@ func (w *worker) process(id uint) {
 	}
 
 	result.URL = rec.OriginalURL
+	result.UserID = rec.UserID
 
 	if rec.Status == model.StatusStopped {
 		logf("aborting analysis because status is 'stopped'")
@@ -157,10 +198,41 @@ func (w *worker) process(id uint) {
 		return
 	}
 
-	timeoutCtx, cancel := context.WithTimeout(w.ctx, w.crawlTimeout)
-	defer cancel()
+	if !w.robotsAllowed(rec, logf) {
+		_ = w.repo.UpdateStatus(id, model.StatusSkipped)
+		logf("skipped: %v", ErrDisallowedByRobots)
+		result.Status = model.StatusSkipped
+		result.Error = ErrDisallowedByRobots
+		return
+	}
 
-	res, links, err := w.analyzer.Analyze(timeoutCtx, rec.URL())
+	logf("analyzing %s", rec.OriginalURL)
+	var (
+		res   *model.AnalysisResult
+		links []model.Link
+		prov  = model.Provenance{WorkerID: w.id, StartedAt: start}
+	)
+	for attempt := 1; ; attempt++ {
+		result.Attempt = attempt
+		prov.Attempts = attempt
+		res, links, err = w.analyze(rec, &prov)
+		if err == nil || attempt >= w.retry.attempts() || !retryable(err) {
+			break
+		}
+		delay := w.retry.delay(attempt)
+		logf("attempt %d failed: %v; retrying in %s", attempt, err, delay)
+		emit(CrawlResult{URLID: id, UserID: rec.UserID, URL: rec.OriginalURL, Status: model.StatusRunning, Error: err, Attempt: attempt, RequestID: requestID})
+		timer := time.NewTimer(delay)
+		select {
+		case <-w.ctx.Done():
+			timer.Stop()
+			err = w.ctx.Err()
+		case <-timer.C:
+		}
+		if w.ctx.Err() != nil {
+			break
+		}
+	}
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
 			_ = w.repo.UpdateStatus(id, model.StatusStopped)
@@ -169,6 +241,23 @@ func (w *worker) process(id uint) {
 			result.Error = err
 			return
 		}
+		if errors.Is(err, analyzer.ErrUnsupportedContent) {
+			_ = w.repo.UpdateStatus(id, model.StatusSkipped)
+			logf("skipped: %v", err)
+			result.Status = model.StatusSkipped
+			result.Error = err
+			return
+		}
+		// A server error is still an answer: keep its status code as a
+		// result, so the URL can be found by it.
+		var statusErr *analyzer.HTTPStatusError
+		if errors.As(err, &statusErr) {
+			failed := &model.AnalysisResult{HTMLVersion: analyzer.HTMLVersionUnknown, StatusCode: statusErr.StatusCode}
+			finishRun(failed, &prov, start)
+			if err := w.repo.SaveResults(id, failed, nil); err != nil {
+				logf("save failed crawl: %v", err)
+			}
+		}
 		setErr(w.repo, id, err)
 		logf("analyze: %v", err)
 		result.Status = model.StatusError
@@ -178,6 +267,7 @@ func (w *worker) process(id uint) {
 
 	result.LinkCount = len(links)
 	result.Links = links
+	finishRun(res, &prov, start)
 
 	if err := w.repo.SaveResults(id, res, links); err != nil {
 		setErr(w.repo, id, err)
@@ -202,6 +292,73 @@ func (w *worker) process(id uint) {
 	logf("done in %s (links=%d)", time.Since(start).Truncate(time.Millisecond), len(links))
 }
 
+// robotsAllowed checks the URL against its site's robots.txt. A robots.txt
+// that cannot be fetched does not block the crawl.
+func (w *worker) robotsAllowed(rec *model.URL, logf func(string, ...any)) bool {
+	if w.robots == nil {
+		return true
+	}
+	timeoutCtx, cancel := context.WithTimeout(w.ctx, w.crawlTimeout)
+	defer cancel()
+	allowed, err := w.robots.Allowed(timeoutCtx, rec.URL())
+	if err != nil {
+		logf("robots.txt unavailable, crawling anyway: %v", err)
+	}
+	return allowed
+}
+
+// analyze runs one crawl attempt under the worker's crawl timeout. The
+// attempt holds one of its host's slots, so time spent waiting for one counts
+// towards the timeout. Head-only URLs are fully crawled if the analyzer
+// cannot check them with HEAD. The analyzer, render mode and timings of the
+// attempt are recorded in prov.
+func (w *worker) analyze(rec *model.URL, prov *model.Provenance) (*model.AnalysisResult, []model.Link, error) {
+	timeoutCtx, cancel := context.WithTimeout(w.ctx, w.crawlTimeout)
+	defer cancel()
+	u := rec.URL()
+	var host string
+	if u != nil {
+		host = u.Hostname()
+	}
+	waitStart := time.Now()
+	release, err := w.hosts.Acquire(timeoutCtx, host, rec.HostLimit)
+	prov.HostWaitMs = time.Since(waitStart).Milliseconds()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	prov.Analyzer = analyzerName(w.analyzer)
+	analyzeStart := time.Now()
+	defer func() { prov.AnalyzeMs = time.Since(analyzeStart).Milliseconds() }()
+	if head, ok := w.analyzer.(analyzer.HeadAnalyzer); ok && rec.CrawlMethod == model.CrawlMethodHeadOnly {
+		prov.RenderMode = model.RenderModeHead
+		res, err := head.AnalyzeHead(timeoutCtx, u)
+		return res, nil, err
+	}
+	prov.RenderMode = model.RenderModeFull
+	return w.analyzer.Analyze(timeoutCtx, u)
+}
+
+// finishRun stamps res with the run's duration, provenance and a fresh run ID.
+func finishRun(res *model.AnalysisResult, prov *model.Provenance, start time.Time) {
+	prov.FinishedAt = time.Now()
+	durationMs := prov.FinishedAt.Sub(start).Milliseconds()
+	prov.TotalMs = durationMs
+	res.CrawlDurationMs = &durationMs
+	res.Provenance = prov
+	runID := uuid.NewString()
+	res.RunID = &runID
+}
+
+// analyzerName is the analyzer's own name, or its Go type if it has none.
+func analyzerName(a analyzer.Analyzer) string {
+	if n, ok := a.(analyzer.Namer); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", a)
+}
+
 func setErr(repo repository.URLRepository, id uint, err error) {
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		_ = repo.UpdateStatus(id, model.StatusError)
@@ -0,0 +1,85 @@
+package egress
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Mode selects how the host list of a Policy is interpreted.
+type Mode string
+
+const (
+	ModeDenylist  Mode = "denylist"  // every host except the listed ones may be crawled
+	ModeAllowlist Mode = "allowlist" // only the listed hosts may be crawled
+)
+
+// ErrHostNotAllowed is returned when a URL's host is rejected by the policy.
+var ErrHostNotAllowed = errors.New("host not allowed")
+
+// ErrHTTPSRequired is returned for plain-HTTP URLs by an HTTPS-only policy.
+var ErrHTTPSRequired = errors.New("https required")
+
+// Policy restricts which hosts the crawler may contact.
+// A nil Policy allows every host.
+type Policy struct {
+	mode      Mode
+	hosts     []string
+	httpsOnly bool
+}
+
+// NewPolicy creates a policy for the given mode. Each host also matches its subdomains.
+func NewPolicy(mode Mode, hosts []string) *Policy {
+	p := &Policy{mode: mode}
+	for _, h := range hosts {
+		h = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(h)), ".")
+		if h != "" {
+			p.hosts = append(p.hosts, h)
+		}
+	}
+	return p
+}
+
+// NewPolicyWithHTTPSOnly creates a policy like NewPolicy that, when httpsOnly
+// is set, also rejects every URL whose scheme is not https.
+func NewPolicyWithHTTPSOnly(mode Mode, hosts []string, httpsOnly bool) *Policy {
+	p := NewPolicy(mode, hosts)
+	p.httpsOnly = httpsOnly
+	return p
+}
+
+// Check returns an error wrapping ErrHTTPSRequired or ErrHostNotAllowed if u
+// may not be crawled.
+func (p *Policy) Check(u *url.URL) error {
+	if p == nil {
+		return nil
+	}
+	if p.httpsOnly && !strings.EqualFold(u.Scheme, "https") {
+		return fmt.Errorf("%w: %q URLs are not crawled in HTTPS-only mode", ErrHTTPSRequired, u.Scheme)
+	}
+	host := strings.ToLower(u.Hostname())
+	listed := p.matches(host)
+
+	switch p.mode {
+	case ModeAllowlist:
+		if !listed {
+			return fmt.Errorf("%w: %q is not in the crawl allowlist", ErrHostNotAllowed, host)
+		}
+	default:
+		if listed {
+			return fmt.Errorf("%w: %q is blocked by the crawl denylist", ErrHostNotAllowed, host)
+		}
+	}
+	return nil
+}
+
+// matches reports whether host equals, or is a subdomain of, a listed host.
+func (p *Policy) matches(host string) bool {
+	for _, h := range p.hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/service"
+	"github.com/fuzumoe/linkTorch-api/internal/storage"
+)
+
+type AnalysisHandler struct {
+	analysisService service.AnalysisService
+}
+
+func NewAnalysisHandler(analysisService service.AnalysisService) *AnalysisHandler {
+	return &AnalysisHandler{analysisService: analysisService}
+}
+
+// @Summary HTML version distribution of the caller's URLs
+// @Description Counts the caller's URLs by the HTML version detected in their latest analysis.
+// @Tags    analysis
+// @Produce json
+// @Success 200 {array} model.HTMLVersionCountDTO "Counts per HTML version"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /users/me/html-versions [get]
+func (h *AnalysisHandler) HTMLVersions(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	counts, err := h.analysisService.HTMLVersionDistribution(uidAny.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, counts)
+}
+
+// @Summary The caller's most recently crawled URLs
+// @Description Lists the caller's URLs ordered by when their latest analysis finished, newest first.
+// @Tags    analysis
+// @Produce json
+// @Param   limit query int false "max entries (capped at 50)" default(10)
+// @Success 200 {array} model.RecentCrawlDTO
+// @Failure 400 {object} map[string]string "error"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /users/me/recent [get]
+func (h *AnalysisHandler) Recent(c *gin.Context) {
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = n
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	recent, err := h.analysisService.Recent(uidAny.(uint), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, recent)
+}
+
+// @Summary Crawl duration statistics for a URL
+// @Description Min, max and average crawl duration over the URL's history, plus the latest one, in milliseconds.
+// @Tags    analysis
+// @Produce json
+// @Param   id path int true "URL ID"
+// @Success 200 {object} model.CrawlTimingsDTO
+// @Failure 400 {object} map[string]string "error"
+// @Failure 404 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/{id}/timings [get]
+func (h *AnalysisHandler) Timings(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	timings, err := h.analysisService.Timings(uint(id), uidAny.(uint))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, timings)
+}
+
+// @Summary Crawl response-time percentiles for a URL
+// @Description Nearest-rank p50, p90 and p99 of the URL's page response times across its history, in milliseconds. Crawls from before response times were recorded count with their crawl duration.
+// @Tags    analysis
+// @Produce json
+// @Param   id path int true "URL ID"
+// @Success 200 {object} model.ResponseTimesDTO
+// @Failure 400 {object} map[string]string "error"
+// @Failure 404 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/{id}/response-times [get]
+func (h *AnalysisHandler) ResponseTimes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	rt, err := h.analysisService.ResponseTimes(uint(id), uidAny.(uint))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, rt)
+}
+
+// @Summary Raw HTML of a URL's latest crawl
+// @Description Returns the page body as fetched by the most recent crawl that kept a raw copy.
+// @Tags    analysis
+// @Produce html
+// @Param   id path int true "URL ID"
+// @Success 200 {string} string "raw HTML"
+// @Failure 400 {object} map[string]string "error"
+// @Failure 404 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/{id}/raw-html [get]
+func (h *AnalysisHandler) RawHTML(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	body, err := h.analysisService.RawHTML(c.Request.Context(), uint(id), uidAny.(uint))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, storage.ErrBlobNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "raw HTML not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+	c.DataFromReader(http.StatusOK, -1, "text/html; charset=utf-8", body, nil)
+}
+
+// @Summary Provenance of a URL's latest result
+// @Description Reports which worker produced the latest result, after how many attempts, with which analyzer and render mode, and how long each step took.
+// @Tags    analysis
+// @Produce json
+// @Param   id path int true "URL ID"
+// @Success 200 {object} model.ProvenanceDTO
+// @Failure 400 {object} map[string]string "error"
+// @Failure 404 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/{id}/results/provenance [get]
+func (h *AnalysisHandler) Provenance(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	prov, err := h.analysisService.Provenance(uint(id), uidAny.(uint))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "provenance not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, prov)
+}
+
+func (h *AnalysisHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
+	rg.GET("/users/me/html-versions", h.HTMLVersions)
+	rg.GET("/users/me/recent", h.Recent)
+	rg.GET("/urls/:id/timings", h.Timings)
+	rg.GET("/urls/:id/response-times", h.ResponseTimes)
+	rg.GET("/urls/:id/raw-html", h.RawHTML)
+	rg.GET("/urls/:id/results/provenance", h.Provenance)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/service"
+)
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// @Summary Create an API key
+// @Description Creates a key that authenticates requests in the X-API-Key header. The key is in the response only this once.
+// @Description An expires_at beyond the server's maximum key lifetime is refused; without one the key gets the maximum lifetime, or never expires if there is none.
+// @Tags    api-keys
+// @Accept  json
+// @Produce json
+// @Param   input body model.CreateAPIKeyInput true "Key name and optional expiry"
+// @Success 201 {object} model.CreatedAPIKeyDTO
+// @Failure 400 {object} map[string]string "error"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /api-keys [post]
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var input model.CreateAPIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	key, err := h.apiKeyService.Create(uidAny.(uint), &input)
+	if err != nil {
+		if errors.Is(err, service.ErrAPIKeyExpiry) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create API key"})
+		return
+	}
+	c.JSON(http.StatusCreated, key)
+}
+
+// @Summary List the caller's API keys
+// @Description Lists all keys of the caller, revoked and expired ones included, without the keys themselves.
+// @Tags    api-keys
+// @Produce json
+// @Success 200 {array} model.APIKeyDTO
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /api-keys [get]
+func (h *APIKeyHandler) List(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	keys, err := h.apiKeyService.List(uidAny.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list API keys"})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// @Summary Revoke an API key
+// @Description The key stops authenticating requests at once. It stays in the list, marked revoked.
+// @Tags    api-keys
+// @Produce json
+// @Param   id path int true "API key ID"
+// @Success 204 "Revoked"
+// @Failure 400 {object} map[string]string "error"
+// @Failure 404 {object} map[string]string "error"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /api-keys/{id} [delete]
+func (h *APIKeyHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(uidAny.(uint), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke API key"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *APIKeyHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
+	rg.POST("/api-keys", h.Create)
+	rg.GET("/api-keys", h.List)
+	rg.DELETE("/api-keys/:id", h.Delete)
+}
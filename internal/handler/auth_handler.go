@@ -2,17 +2,25 @@ package handler
 
 import (
 	"encoding/base64"
+	"errors"
+	"log"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/service"
 )
 
+// PasswordResetSender delivers a password reset token to the owner of email.
+type PasswordResetSender func(email, token string) error
+
 type AuthHandler struct {
 	authService service.AuthService
 	userService service.UserService
+	// sendReset delivers reset tokens; nil if nothing can deliver them.
+	sendReset PasswordResetSender
 }
 
 func NewAuthHandler(authService service.AuthService, userService service.UserService) *AuthHandler {
@@ -22,11 +30,25 @@ func NewAuthHandler(authService service.AuthService, userService service.UserSer
 	}
 }
 
+// NewAuthHandlerWithPasswordReset creates an auth handler that hands
+// password reset tokens to send.
+func NewAuthHandlerWithPasswordReset(authService service.AuthService, userService service.UserService, send PasswordResetSender) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+		userService: userService,
+		sendReset:   send,
+	}
+}
+
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 // LoginBasic godoc
 // @Summary      Login via Basic Auth header and generate JWT token
 // @Description  Authenticates a user using Basic Authorization header and returns a JWT token
@@ -34,7 +56,7 @@ type LoginRequest struct {
 // @Tags         auth
 // @Produce      json
 // @Param        Authorization header string true "Basic base64(email:password)"
-// @Success      200 {object} map[string]interface{} "JWT token generated"
+// @Success      200 {object} map[string]interface{} "JWT access and refresh tokens generated"
 // @Failure      400 {object} map[string]interface{} "Invalid request or login error"
 // @Failure      401 {object} map[string]interface{} "Authentication failed"
 // @Router       /login/basic [post]
@@ -65,25 +87,25 @@ func (h *AuthHandler) LoginBasic(c *gin.Context) {
 		return
 	}
 
-	token, err := h.authService.Generate(userDTO.ID)
+	token, refresh, err := h.authService.GeneratePair(userDTO.ID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refresh})
 }
 
 // LoginJWT godoc
 // @Summary      Login via JSON payload and generate JWT token
 // @Description  Authenticates a user using email and password provided in JSON and returns a JWT token
 // @Description  Example request: {"email": "user@example.com", "password": "userpassword"}
-// @Description  Example response: {"token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."}
+// @Description  Example response: {"token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...", "refresh_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."}
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        loginRequest  body      LoginRequest  true  "Login request payload"
-// @Success      200           {object}  map[string]interface{} "JWT token generated"
+// @Success      200           {object}  map[string]interface{} "JWT access and refresh tokens generated"
 // @Failure      400           {object}  map[string]interface{} "Invalid request or login error"
 // @Failure      401           {object}  map[string]interface{} "Authentication failed"
 // @Router       /login/jwt [post]
@@ -100,13 +122,120 @@ func (h *AuthHandler) LoginJWT(c *gin.Context) {
 		return
 	}
 
-	token, err := h.authService.Generate(userDTO.ID)
+	token, refresh, err := h.authService.GeneratePair(userDTO.ID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refresh})
+}
+
+// Refresh godoc
+// @Summary      Exchange a refresh token for new access and refresh tokens
+// @Description  Issues a new JWT access token and refresh token for a refresh token obtained at login or from an earlier refresh
+// @Description  Each refresh token works once; presenting it again revokes all of the user's refresh tokens
+// @Description  Example request: {"refresh_token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."}
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        refreshRequest  body      RefreshRequest  true  "Refresh request payload"
+// @Success      200             {object}  map[string]interface{} "JWT access and refresh tokens generated"
+// @Failure      400             {object}  map[string]interface{} "Invalid request"
+// @Failure      401             {object}  map[string]interface{} "Refresh token invalid, expired, revoked or reused"
+// @Failure      500             {object}  map[string]interface{} "Internal server error"
+// @Router       /refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid refresh request"})
+		return
+	}
+
+	token, refresh, err := h.authService.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrTokenReused) {
+			log.Printf("[WARN] refresh token reused from %s; all refresh tokens of its user revoked", c.ClientIP())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token reused"})
+			return
+		}
+		if errors.Is(err, service.ErrTokenInvalid) || errors.Is(err, service.ErrTokenExpired) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "refresh_token": refresh})
+}
+
+// RequestPasswordReset godoc
+// @Summary      Request a password reset token
+// @Description  Sends a single-use password reset token to the email if it belongs to a user
+// @Description  The response is the same whether or not the email is registered
+// @Description  Example request: {"email": "user@example.com"}
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.PasswordResetRequestInput  true  "Email of the account"
+// @Success      200      {object}  map[string]interface{} "Reset requested"
+// @Failure      400      {object}  map[string]interface{} "Invalid request"
+// @Failure      500      {object}  map[string]interface{} "Internal server error"
+// @Router       /password/reset/request [post]
+func (h *AuthHandler) RequestPasswordReset(c *gin.Context) {
+	var req model.PasswordResetRequestInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid password reset request"})
+		return
+	}
+
+	token, err := h.userService.RequestPasswordReset(req.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to request password reset"})
+		return
+	}
+	if token != "" && h.sendReset != nil {
+		// A delivery failure is only logged; reporting it would reveal
+		// that the email is registered.
+		if err := h.sendReset(req.Email, token); err != nil {
+			log.Printf("[WARN] failed to send password reset token: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "if the email is registered, a password reset token has been sent"})
+}
+
+// ConfirmPasswordReset godoc
+// @Summary      Set a new password with a password reset token
+// @Description  Sets the password of the user the token was sent to and revokes all of their tokens
+// @Description  Each reset token works once
+// @Description  Example request: {"token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9...", "password": "newpassword"}
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.PasswordResetInput  true  "Reset token and new password"
+// @Success      200      {object}  map[string]interface{} "Password updated"
+// @Failure      400      {object}  map[string]interface{} "Invalid request, or token invalid, expired or used"
+// @Failure      500      {object}  map[string]interface{} "Internal server error"
+// @Router       /password/reset/confirm [post]
+func (h *AuthHandler) ConfirmPasswordReset(c *gin.Context) {
+	var req model.PasswordResetInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid password reset request"})
+		return
+	}
+
+	if err := h.userService.ResetPassword(req.Token, req.Password); err != nil {
+		if errors.Is(err, service.ErrResetTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired reset token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password updated"})
 }
 
 // Logout godoc
@@ -174,6 +303,9 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 func (h *AuthHandler) RegisterPublicRoutes(rg *gin.RouterGroup) {
 	rg.POST("/login/basic", h.LoginBasic)
 	rg.POST("/login/jwt", h.LoginJWT)
+	rg.POST("/refresh", h.Refresh)
+	rg.POST("/password/reset/request", h.RequestPasswordReset)
+	rg.POST("/password/reset/confirm", h.ConfirmPasswordReset)
 }
 
 func (h *AuthHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
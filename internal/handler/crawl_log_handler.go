@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fuzumoe/linkTorch-api/internal/crawler"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+// StreamOptions tunes long-lived streaming (SSE and WebSocket) responses.
+type StreamOptions struct {
+	Heartbeat   time.Duration  // interval between keep-alive comments; 0 disables them
+	MaxLifetime time.Duration  // the stream is closed after this long; 0 means no limit
+	Limiter     *StreamLimiter // caps open streams; nil means no limit
+}
+
+type CrawlLogHandler struct {
+	hub  *crawler.LogHub
+	opts StreamOptions
+}
+
+func NewCrawlLogHandler(hub *crawler.LogHub, opts StreamOptions) *CrawlLogHandler {
+	return &CrawlLogHandler{hub: hub, opts: opts}
+}
+
+// isAdmin reports whether the authenticated caller has the admin role.
+// The auth middleware stores the role as model.UserRole, older code as a string.
+func isAdmin(c *gin.Context) bool {
+	roleAny, _ := c.Get("user_role")
+	switch role := roleAny.(type) {
+	case model.UserRole:
+		return role == model.RoleAdmin
+	case string:
+		return role == string(model.RoleAdmin)
+	}
+	return false
+}
+
+// @Summary Stream crawl log lines for a URL (admin only)
+// @Description Server-sent events with one "log" event per line logged while the URL is crawled.
+// @Tags    admin
+// @Produce text/event-stream
+// @Param   id path int true "URL ID"
+// @Success 200 {object} crawler.LogLine "log event"
+// @Failure 400 {object} map[string]string "error"
+// @Failure 403 {object} map[string]string "error"
+// @Failure 429 {object} map[string]string "too many open streams"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /admin/urls/{id}/logs [get]
+func (h *CrawlLogHandler) Stream(c *gin.Context) {
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+	v, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	release, ok := acquireStream(c, h.opts)
+	if !ok {
+		return
+	}
+	defer release()
+
+	lines, unsubscribe := h.hub.Subscribe(uint(v))
+	defer unsubscribe()
+
+	// The server write timeout is meant for ordinary requests; lift it for
+	// this stream, whose length is bounded by MaxLifetime instead.
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	var heartbeat <-chan time.Time
+	if h.opts.Heartbeat > 0 {
+		ticker := time.NewTicker(h.opts.Heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+	var expired <-chan time.Time
+	if h.opts.MaxLifetime > 0 {
+		timer := time.NewTimer(h.opts.MaxLifetime)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("subscribed", gin.H{"url_id": v})
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expired:
+			return
+		case <-heartbeat:
+			_, _ = c.Writer.WriteString(": keep-alive\n\n")
+			c.Writer.Flush()
+		case line := <-lines:
+			c.SSEvent("log", line)
+			c.Writer.Flush()
+		}
+	}
+}
+
+func (h *CrawlLogHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
+	rg.GET("/admin/urls/:id/logs", h.Stream)
+}
@@ -49,15 +49,70 @@ func (h *HealthHandler) Health(c *gin.Context) {
 	if !stat.Healthy {
 		code = http.StatusServiceUnavailable
 	}
-	c.JSON(code, gin.H{
+	body := gin.H{
 		"service":  stat.Service,
 		"status":   "ok",
 		"database": stat.Database,
 		"checked":  stat.Checked.Format(time.RFC3339),
+	}
+	if stat.Crawler != "" {
+		body["crawler"] = stat.Crawler
+	}
+	c.JSON(code, body)
+}
+
+// Live godoc
+// @Summary      Liveness probe
+// @Description  Answers as long as the process serves requests; dependencies are not checked
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  map[string]interface{} "Process is up"
+// @Router       /health/live [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "alive"})
+}
+
+// Ready godoc
+// @Summary      Readiness probe
+// @Description  Checks that the database answers and the crawler pool is running; down lists the subsystems that are not
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  map[string]interface{} "Ready to serve traffic"
+// @Failure      503  {object}  map[string]interface{} "A dependency is down"
+// @Router       /health/ready [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	stat := h.healthService.Check()
+	checks := gin.H{"database": stat.Database}
+	down := []string{}
+	if stat.Database != "healthy" {
+		down = append(down, "database")
+	}
+	if stat.Crawler != "" {
+		checks["crawler"] = stat.Crawler
+		if stat.Crawler != "running" {
+			down = append(down, "crawler")
+		}
+	}
+
+	if !stat.Healthy {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":  "not ready",
+			"checks":  checks,
+			"down":    down,
+			"checked": stat.Checked.Format(time.RFC3339),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "ready",
+		"checks":  checks,
+		"checked": stat.Checked.Format(time.RFC3339),
 	})
 }
 
 func (h *HealthHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.GET("/status", h.Home)
 	rg.GET("/health", h.Health)
+	rg.GET("/health/live", h.Live)
+	rg.GET("/health/ready", h.Ready)
 }
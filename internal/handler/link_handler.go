@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+	"github.com/fuzumoe/linkTorch-api/internal/service"
+)
+
+type LinkHandler struct {
+	linkService service.LinkService
+}
+
+func NewLinkHandler(linkService service.LinkService) *LinkHandler {
+	return &LinkHandler{linkService: linkService}
+}
+
+func (h *LinkHandler) paginationFromQuery(c *gin.Context) repository.Pagination {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	return repository.Pagination{Page: page, PageSize: size}
+}
+
+// optionalBoolQuery parses a boolean query parameter, returning nil when it is absent.
+func optionalBoolQuery(c *gin.Context, name string) (*bool, bool) {
+	raw, exists := c.GetQuery(name)
+	if !exists || raw == "" {
+		return nil, true
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name + " value"})
+		return nil, false
+	}
+	return &v, true
+}
+
+// @Summary List links across all of the caller's URLs (paginated)
+// @Tags    links
+// @Produce json
+// @Param   page         query int    false "page" default(1) example(1)
+// @Param   page_size    query int    false "page_size" default(10) example(10)
+// @Param   url_id       query int    false "Only links of this URL"
+// @Param   is_external  query bool   false "Only external (true) or internal (false) links"
+// @Param   broken       query bool   false "Only broken (true) or working (false) links"
+// @Param   search       query string false "Substring to match against the link href"
+// @Param   href_pattern query string false "LIKE pattern matched against the whole href, % and _ being wildcards" example(%/old/%)
+// @Param   status_class query string false "Only links of this status class: 2xx, 3xx, 4xx, 5xx or unreachable" example(4xx)
+// @Success 200 {object} model.PaginatedResponse[model.UserLinkDTO] "Paginated link list"
+// @Failure 400 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /users/me/links [get]
+func (h *LinkHandler) ListUserLinks(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := uidAny.(uint)
+
+	var urlID *uint
+	if raw := c.Query("url_id"); raw != "" {
+		v, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid url_id value"})
+			return
+		}
+		id := uint(v)
+		urlID = &id
+	}
+	isExternal, ok := optionalBoolQuery(c, "is_external")
+	if !ok {
+		return
+	}
+	broken, ok := optionalBoolQuery(c, "broken")
+	if !ok {
+		return
+	}
+	filter := repository.LinkFilter{
+		URLID:       urlID,
+		IsExternal:  isExternal,
+		Broken:      broken,
+		Search:      c.Query("search"),
+		HrefPattern: c.Query("href_pattern"),
+		StatusClass: c.Query("status_class"),
+	}
+	if err := filter.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	paginatedResult, err := h.linkService.ListByUser(userID, filter, h.paginationFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, paginatedResult)
+}
+
+// @Summary Count a URL's links by HTTP status class
+// @Description Compact companion to the broken-link listing: counts of 2xx, 3xx, 4xx, 5xx and unreachable links.
+// @Tags    links
+// @Produce json
+// @Param   id path int true "URL ID"
+// @Success 200 {object} model.LinkStatusSummaryDTO
+// @Failure 400 {object} map[string]string "error"
+// @Failure 404 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/{id}/link-status-summary [get]
+func (h *LinkHandler) StatusSummary(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	summary, err := h.linkService.StatusSummary(uint(id), uidAny.(uint))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// @Summary Links added and removed by the latest crawl
+// @Description Compares the links of a URL's two latest crawls by href. After a single crawl all its links are listed as added.
+// @Tags    links
+// @Produce json
+// @Param   id path int true "URL ID"
+// @Success 200 {object} model.LinkChangesDTO
+// @Failure 400 {object} map[string]string "error"
+// @Failure 404 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/{id}/links/changes [get]
+func (h *LinkHandler) Changes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	changes, err := h.linkService.Changes(uint(id), uidAny.(uint))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
+// @Summary Platform-wide impact of an external domain (admin only)
+// @Description Counts the external links, across all users, to the domain or its subdomains, the URLs holding them and their owners, with a page of those URLs. Useful when a popular third-party site breaks.
+// @Tags    admin
+// @Produce json
+// @Param   domain    path  string true  "domain, e.g. cdn.example.com"
+// @Param   page      query int    false "page" default(1) example(1)
+// @Param   page_size query int    false "page_size" default(10) example(10)
+// @Success 200 {object} model.DomainImpactDTO
+// @Failure 400 {object} map[string]string "error"
+// @Failure 403 {object} map[string]string "error"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /admin/link-domains/{domain}/impact [get]
+func (h *LinkHandler) DomainImpact(c *gin.Context) {
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+	domain := strings.TrimSpace(c.Param("domain"))
+	if domain == "" || strings.ContainsAny(domain, "/:?#@ ") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid domain"})
+		return
+	}
+
+	impact, err := h.linkService.DomainImpact(domain, h.paginationFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, impact)
+}
+
+// @Summary External domains with the most broken links (admin only)
+// @Description Ranks the hosts of broken (4xx or 5xx) external links across all users by their number of broken links, to spot widespread third-party outages.
+// @Tags    admin
+// @Produce json
+// @Param   limit query int false "max domains (capped at 100)" default(10)
+// @Success 200 {array} model.BrokenDomainDTO
+// @Failure 400 {object} map[string]string "error"
+// @Failure 403 {object} map[string]string "error"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /admin/top-broken-domains [get]
+func (h *LinkHandler) TopBrokenDomains(c *gin.Context) {
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = n
+	}
+
+	domains, err := h.linkService.TopBrokenDomains(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, domains)
+}
+
+func (h *LinkHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
+	rg.GET("/users/me/links", h.ListUserLinks)
+	rg.GET("/urls/:id/link-status-summary", h.StatusSummary)
+	rg.GET("/urls/:id/links/changes", h.Changes)
+	rg.GET("/admin/link-domains/:domain/impact", h.DomainImpact)
+	rg.GET("/admin/top-broken-domains", h.TopBrokenDomains)
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fuzumoe/linkTorch-api/internal/metrics"
+)
+
+type MetricsHandler struct {
+	metrics *metrics.Metrics
+}
+
+func NewMetricsHandler(m *metrics.Metrics) *MetricsHandler {
+	return &MetricsHandler{metrics: m}
+}
+
+// @Summary Current metric values as JSON (admin only)
+// @Description Request counts by route, crawl counts and durations, and the crawl queue depth, read from the metrics served at /metrics. For monitoring without a Prometheus server.
+// @Tags    admin
+// @Produce json
+// @Success 200 {object} metrics.Snapshot
+// @Failure 403 {object} map[string]string "error"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /admin/metrics/snapshot [get]
+func (h *MetricsHandler) Snapshot(c *gin.Context) {
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+
+	snap, err := h.metrics.Snapshot()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, snap)
+}
+
+func (h *MetricsHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
+	rg.GET("/admin/metrics/snapshot", h.Snapshot)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/service"
+)
+
+type NotificationHandler struct {
+	notificationService service.NotificationService
+}
+
+func NewNotificationHandler(notificationService service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// @Summary Get the caller's notification preferences
+// @Description Returns the stored preferences, or the defaults if the caller never changed them.
+// @Tags    users
+// @Produce json
+// @Success 200 {object} model.NotificationPrefs
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /users/me/notifications [get]
+func (h *NotificationHandler) Get(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	prefs, err := h.notificationService.Get(uidAny.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+// @Summary Update the caller's notification preferences
+// @Description Changes only the fields present in the body.
+// @Tags    users
+// @Accept  json
+// @Produce json
+// @Param   input body model.UpdateNotificationPrefsInput true "Preferences to change"
+// @Success 200 {object} model.NotificationPrefs
+// @Failure 400 {object} map[string]string "error"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /users/me/notifications [put]
+func (h *NotificationHandler) Update(c *gin.Context) {
+	var input model.UpdateNotificationPrefsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	prefs, err := h.notificationService.Update(uidAny.(uint), &input)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, prefs)
+}
+
+func (h *NotificationHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
+	rg.GET("/users/me/notifications", h.Get)
+	rg.PUT("/users/me/notifications", h.Update)
+}
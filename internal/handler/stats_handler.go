@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fuzumoe/linkTorch-api/internal/service"
+)
+
+type StatsHandler struct {
+	statsService service.StatsService
+}
+
+func NewStatsHandler(statsService service.StatsService) *StatsHandler {
+	return &StatsHandler{statsService: statsService}
+}
+
+// @Summary System-wide statistics (admin only)
+// @Description Total users and URLs, crawls in the last 24h, current crawl queue depth and URL error rate.
+// @Tags    admin
+// @Produce json
+// @Success 200 {object} model.SystemStatsDTO
+// @Failure 403 {object} map[string]string "error"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /admin/stats [get]
+func (h *StatsHandler) System(c *gin.Context) {
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+
+	stats, err := h.statsService.System()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// @Summary Estimated time to drain the crawl queue
+// @Description Queue depth, worker count and the average duration of recent crawls, with the resulting estimate in seconds. eta_seconds is null while URLs are queued but no crawl has been timed yet.
+// @Tags    crawler
+// @Produce json
+// @Success 200 {object} model.CrawlETADTO
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /crawler/eta [get]
+func (h *StatsHandler) ETA(c *gin.Context) {
+	eta, err := h.statsService.ETA()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, eta)
+}
+
+func (h *StatsHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
+	rg.GET("/admin/stats", h.System)
+	rg.GET("/crawler/eta", h.ETA)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreamLimiter caps the number of open SSE and WebSocket connections, in
+// total and per user. One limiter is shared by all streaming endpoints.
+type StreamLimiter struct {
+	maxTotal   int64 // 0 means no limit
+	maxPerUser int64 // 0 means no limit
+	total      atomic.Int64
+	perUser    sync.Map // uint -> *atomic.Int64
+}
+
+// NewStreamLimiter creates a limiter allowing maxTotal connections in all
+// and maxPerUser per user; zero lifts either limit.
+func NewStreamLimiter(maxTotal, maxPerUser int) *StreamLimiter {
+	return &StreamLimiter{maxTotal: int64(maxTotal), maxPerUser: int64(maxPerUser)}
+}
+
+// Acquire takes a connection slot for userID. It reports false when a limit
+// is reached; otherwise release must be called once the connection closes.
+func (l *StreamLimiter) Acquire(userID uint) (release func(), ok bool) {
+	if !take(&l.total, l.maxTotal) {
+		return nil, false
+	}
+	counter, _ := l.perUser.LoadOrStore(userID, new(atomic.Int64))
+	user := counter.(*atomic.Int64)
+	if !take(user, l.maxPerUser) {
+		l.total.Add(-1)
+		return nil, false
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			user.Add(-1)
+			l.total.Add(-1)
+		})
+	}, true
+}
+
+// Open returns the number of connections currently holding a slot.
+func (l *StreamLimiter) Open() int {
+	return int(l.total.Load())
+}
+
+// take increments n unless that would exceed limit, which 0 disables.
+func take(n *atomic.Int64, limit int64) bool {
+	if n.Add(1) > limit && limit > 0 {
+		n.Add(-1)
+		return false
+	}
+	return true
+}
+
+// acquireStream takes a slot of the caller in opts.Limiter, answering 429
+// itself when none is left. Without a limiter every stream is allowed.
+func acquireStream(c *gin.Context, opts StreamOptions) (release func(), ok bool) {
+	if opts.Limiter == nil {
+		return func() {}, true
+	}
+	uidAny, _ := c.Get("user_id")
+	userID, _ := uidAny.(uint)
+	release, ok = opts.Limiter.Acquire(userID)
+	if !ok {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many open streams"})
+		return nil, false
+	}
+	return release, true
+}
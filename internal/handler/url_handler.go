@@ -1,12 +1,22 @@
 package handler
 
 import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
 
+	"github.com/fuzumoe/linkTorch-api/internal/crawler"
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
 	"github.com/fuzumoe/linkTorch-api/internal/service"
@@ -14,12 +24,35 @@ import (
 
 type URLHandler struct {
 	urlService service.URLService
+	// maxResultLinks caps the links inlined in a results response (0 means no limit).
+	maxResultLinks int
+	stream         StreamOptions
+
+	resultsOnce sync.Once
+	results     *crawler.ResultHub
 }
 
 func NewURLHandler(urlService service.URLService) *URLHandler {
 	return &URLHandler{urlService: urlService}
 }
 
+// URLHandlerOptions configures the optional behaviour of a URL handler. The
+// zero value inlines every link in results responses and streams crawl
+// results without keep-alives or limits.
+type URLHandlerOptions struct {
+	// MaxResultLinks caps the links inlined in a results response; 0
+	// disables the limit.
+	MaxResultLinks int
+	// Stream configures keep-alives, the maximum lifetime and the connection
+	// limit of the crawl result streams.
+	Stream StreamOptions
+}
+
+// NewURLHandlerWithOptions creates a URL handler configured by opts.
+func NewURLHandlerWithOptions(urlService service.URLService, opts URLHandlerOptions) *URLHandler {
+	return &URLHandler{urlService: urlService, maxResultLinks: opts.MaxResultLinks, stream: opts.Stream}
+}
+
 func (h *URLHandler) parseUintParam(c *gin.Context, name string) (uint, bool) {
 	v, err := strconv.ParseUint(c.Param(name), 10, 64)
 	if err != nil {
@@ -36,11 +69,13 @@ func (h *URLHandler) paginationFromQuery(c *gin.Context) repository.Pagination {
 }
 
 // @Summary Create URL row
+// @Description With crawl=true the URL is also queued for crawling; without the parameter the server's default applies. A URL that could not be queued is still created and crawl_error says why.
 // @Tags    urls
 // @Accept  json
 // @Produce json
-// @Param   input body model.URLCreateRequestDTO true "URL to crawl"
-// @Success 201 {object} map[string]uint "{id}"
+// @Param   input body  model.URLCreateRequestDTO true "URL to crawl"
+// @Param   crawl query bool false "Queue the URL for crawling right away"
+// @Success 201 {object} model.URLCreatedDTO
 // @Failure 400 {object} map[string]string "error"
 // @Security JWTAuth
 // @Security BasicAuth
@@ -58,25 +93,116 @@ func (h *URLHandler) Create(c *gin.Context) {
 		return
 	}
 
+	crawl, ok := optionalBoolQuery(c, "crawl")
+	if !ok {
+		return
+	}
+
 	inputDTO := &model.CreateURLInputDTO{
 		UserID:      uidAny.(uint),
 		OriginalURL: requestDTO.OriginalURL,
+		CrawlMethod: requestDTO.CrawlMethod,
+		Crawl:       crawl,
 	}
 
-	id, err := h.urlService.Create(inputDTO)
+	created, err := h.urlService.CreateWithCrawl(inputDTO)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"id": id})
+	c.JSON(http.StatusCreated, created)
+}
+
+// @Summary Create URLs in bulk
+// @Description Creates up to 500 URLs in one transaction. Each entry is validated on its own, so a bad URL is reported without aborting the others. Repeats of an earlier entry are not created again and get its id. Responds 201 when at least one URL was created and 400 otherwise, with a result per entry either way.
+// @Tags    urls
+// @Accept  json
+// @Produce json
+// @Param   input body []model.URLCreateRequestDTO true "URLs to crawl"
+// @Success 201 {array} model.URLBulkCreateResultDTO
+// @Failure 400 {array} model.URLBulkCreateResultDTO
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/bulk [post]
+func (h *URLHandler) CreateBulk(c *gin.Context) {
+	// Entries are validated one by one below; binding the slice would reject
+	// the whole request for a single bad URL.
+	var req []model.URLCreateRequestDTO
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	if len(req) == 0 || len(req) > model.MaxBulkCreateURLs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("between 1 and %d urls are required", model.MaxBulkCreateURLs)})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := uidAny.(uint)
+
+	results := make([]model.URLBulkCreateResultDTO, len(req))
+	var inputs []*model.CreateURLInputDTO
+	var positions []int // index in req of each input
+	seen := make(map[string]bool, len(req))
+	for i, item := range req {
+		results[i] = model.URLBulkCreateResultDTO{Index: i, OriginalURL: item.OriginalURL}
+		if err := binding.Validator.ValidateStruct(&item); err != nil {
+			results[i].Error = "invalid url"
+			continue
+		}
+		results[i].Duplicate = seen[item.OriginalURL]
+		seen[item.OriginalURL] = true
+		inputs = append(inputs, &model.CreateURLInputDTO{UserID: userID, OriginalURL: item.OriginalURL, CrawlMethod: item.CrawlMethod})
+		positions = append(positions, i)
+	}
+
+	var ids []uint
+	var rejected map[int]error
+	if len(inputs) > 0 {
+		var err error
+		ids, err = h.urlService.CreateBulk(inputs)
+		var bulkErr *service.BulkCreateError
+		switch {
+		case errors.As(err, &bulkErr):
+			rejected = bulkErr.Failed
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	status := http.StatusBadRequest
+	for n, i := range positions {
+		if err, ok := rejected[n]; ok {
+			results[i].Error = err.Error()
+			results[i].Duplicate = false
+			continue
+		}
+		results[i].ID = ids[n]
+		if ids[n] != 0 {
+			status = http.StatusCreated
+		}
+	}
+	c.JSON(status, results)
 }
 
 // @Summary List URLs (paginated)
+// @Description With a cursor parameter (empty for the first page) the URLs come newest first as a model.CursorResponse, and page and sort are not used; pass next_cursor back for the following page.
 // @Tags    urls
 // @Produce json
-// @Param   page      query int false "page" default(1) example(1)
-// @Param   page_size query int false "page_size" default(10) example(10)
+// @Param   page      query int    false "page" default(1) example(1)
+// @Param   page_size query int    false "page_size" default(10) example(10)
+// @Param   status    query string false "Only URLs in this status" Enums(queued, running, done, error, stopped, skipped)
+// @Param   search    query string false "Substring to match against the original URL"
+// @Param   status_code query int false "Only URLs whose latest analysis got this HTTP status code" example(500)
+// @Param   sort      query string false "Sort key, prefixed with - for descending: id, created_at, updated_at, original_url or status" example(-created_at)
+// @Param   cursor    query string false "Cursor from a previous page's next_cursor; switches to cursor pagination"
 // @Success 200 {object} model.PaginatedResponse[model.URLDTO] "Paginated URL list"
+// @Failure 400 {object} map[string]string "error"
 // @Security JWTAuth
 // @Security BasicAuth
 // @Router  /urls [get]
@@ -88,7 +214,96 @@ func (h *URLHandler) List(c *gin.Context) {
 	}
 	userID := uidAny.(uint)
 
-	paginatedResult, err := h.urlService.List(userID, h.paginationFromQuery(c))
+	filter := repository.URLFilter{
+		Status: c.Query("status"),
+		Search: c.Query("search"),
+		Sort:   c.Query("sort"),
+	}
+	if raw := c.Query("status_code"); raw != "" {
+		code, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status_code"})
+			return
+		}
+		filter.StatusCode = code
+	}
+	if err := filter.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if after, ok := c.GetQuery("cursor"); ok {
+		if filter.Sort != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort cannot be combined with cursor"})
+			return
+		}
+		cursor := repository.Cursor{After: after, Size: h.paginationFromQuery(c).PageSize}
+		page, err := h.urlService.ListCursor(userID, filter, cursor)
+		if err != nil {
+			if errors.Is(err, repository.ErrInvalidCursor) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, page)
+		return
+	}
+
+	paginatedResult, err := h.urlService.List(userID, filter, h.paginationFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, paginatedResult)
+}
+
+// @Summary List URLs never crawled successfully (paginated)
+// @Description The caller's URLs without any analysis result: queued, failed, stopped or skipped ones.
+// @Tags    urls
+// @Produce json
+// @Param   page      query int false "page" default(1) example(1)
+// @Param   page_size query int false "page_size" default(10) example(10)
+// @Success 200 {object} model.PaginatedResponse[model.URLDTO] "Paginated URL list"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /users/me/urls/uncrawled [get]
+func (h *URLHandler) ListUncrawled(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	paginatedResult, err := h.urlService.ListUncrawled(uidAny.(uint), h.paginationFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, paginatedResult)
+}
+
+// @Summary List the caller's deleted URLs (paginated)
+// @Description Deleted URLs keep their analysis results and links and can be brought back with POST /urls/{id}/restore. Most recently deleted first.
+// @Tags    urls
+// @Produce json
+// @Param   page      query int false "page" default(1) example(1)
+// @Param   page_size query int false "page_size" default(10) example(10)
+// @Success 200 {object} model.PaginatedResponse[model.URLDTO] "Paginated URL list"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/trash [get]
+func (h *URLHandler) ListTrash(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	paginatedResult, err := h.urlService.ListDeleted(uidAny.(uint), h.paginationFromQuery(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -165,12 +380,82 @@ func (h *URLHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "deleted"})
 }
 
+// @Summary Restore a deleted URL
+// @Description Undoes DELETE /urls/{id} for one of the caller's URLs, with its analysis results and links.
+// @Tags    urls
+// @Produce json
+// @Param   id path int true "URL ID"
+// @Success 200 {object} map[string]string "restored"
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 404 {object} map[string]string "no such deleted URL"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/{id}/restore [post]
+func (h *URLHandler) Restore(c *gin.Context) {
+	id, ok := h.parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.urlService.Restore(id, uidAny.(uint)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "deleted URL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "restored"})
+}
+
+// @Summary Permanently delete a URL (admin only)
+// @Description Removes the URL, deleted or not, with its analysis results, links and tags. It cannot be restored afterwards.
+// @Tags    urls
+// @Produce json
+// @Param   id path int true "URL ID"
+// @Success 200 {object} map[string]string "purged"
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 403 {object} map[string]string "forbidden"
+// @Failure 404 {object} map[string]string "not found"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/{id}/purge [delete]
+func (h *URLHandler) Purge(c *gin.Context) {
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+	id, ok := h.parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.urlService.Purge(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "purged"})
+}
+
 // @Summary Start crawl
 // @Tags    urls
 // @Produce json
 // @Param   id path int true "URL ID"
 // @Param   priority query int false "Priority (1-10, default 5)" default(5)
+// @Param   X-Request-ID header string false "ID tagging the crawl's log lines and results; generated when absent"
 // @Success 202 {object} map[string]string "queued"
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 429 {object} map[string]string "too many of the caller's URLs queued or running"
+// @Failure 503 {object} map[string]string "crawl queue full; see Retry-After"
 // @Security JWTAuth
 // @Security BasicAuth
 // @Router  /urls/{id}/start [patch]
@@ -187,20 +472,83 @@ func (h *URLHandler) Start(c *gin.Context) {
 	}
 
 	if priorityStr != "5" {
-		if err := h.urlService.StartWithPriority(id, priority); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := h.urlService.StartWithPriority(id, priority, c.GetString("request_id")); err != nil {
+			startError(c, err)
 			return
 		}
 		c.JSON(http.StatusAccepted, gin.H{"status": model.StatusQueued, "priority": priority})
 	} else {
-		if err := h.urlService.Start(id); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		if err := h.urlService.Start(id, c.GetString("request_id")); err != nil {
+			startError(c, err)
 			return
 		}
 		c.JSON(http.StatusAccepted, gin.H{"status": model.StatusQueued})
 	}
 }
 
+// @Summary Change the priority of a queued URL
+// @Description Moves one of the caller's queued URLs to another priority in the crawl queue without queueing it again.
+// @Tags    urls
+// @Accept  json
+// @Produce json
+// @Param   id path int true "URL ID"
+// @Param   input body model.URLPriorityInput true "new priority (1-10)"
+// @Success 200 {object} map[string]string "reprioritized"
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 404 {object} map[string]string "not found"
+// @Failure 409 {object} map[string]string "URL is running or done"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/{id}/priority [patch]
+func (h *URLHandler) Reprioritize(c *gin.Context) {
+	id, ok := h.parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var in model.URLPriorityInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "priority must be an integer from 1 to 10"})
+		return
+	}
+	if err := h.urlService.Reprioritize(id, uidAny.(uint), in.Priority); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+		case errors.Is(err, service.ErrURLNotQueued):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": model.StatusQueued, "priority": in.Priority})
+}
+
+// queueFullRetryAfter is the Retry-After sent when the crawl queue is full.
+const queueFullRetryAfter = 30 * time.Second
+
+// startError reports a failed crawl start. A full queue is a temporary
+// condition the client should retry later; an exhausted crawl quota frees up
+// as the user's crawls finish.
+func startError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrCrawlQuotaExceeded) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, crawler.ErrQueueFull) {
+		c.Header("Retry-After", strconv.Itoa(int(queueFullRetryAfter.Seconds())))
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
 // @Summary Stop crawl
 // @Tags    urls
 // @Produce json
@@ -222,12 +570,15 @@ func (h *URLHandler) Stop(c *gin.Context) {
 }
 
 // @Summary Latest analysis snapshot + links
+// @Description Links beyond the configured limit are left out; truncated is then set and links_url points at the paginated link listing.
 // @Tags    urls
 // @Produce json
 // @Param   id path int true "URL ID"
+// @Param   Accept-Version header int false "schema version the client understands"
 // @Success 200 {object} model.URLResultsDTO
 // @Failure 404 {object} map[string]string "not found"
 // @Failure 400 {object} map[string]string "bad request"
+// @Failure 406 {object} map[string]string "unsupported schema version"
 // @Security JWTAuth
 // @Security BasicAuth
 // @Router  /urls/{id}/results [get]
@@ -236,6 +587,12 @@ func (h *URLHandler) Results(c *gin.Context) {
 	if !ok {
 		return
 	}
+	// Only one shape exists so far; clients asking for any other are refused
+	// rather than handed a payload they may misread.
+	if v := c.GetHeader("Accept-Version"); v != "" && v != strconv.Itoa(model.ResultsSchemaVersion) {
+		c.JSON(http.StatusNotAcceptable, gin.H{"error": "unsupported schema version"})
+		return
+	}
 
 	url, analysisResults, links, err := h.urlService.ResultsWithDetails(id)
 	if err != nil {
@@ -249,71 +606,964 @@ func (h *URLHandler) Results(c *gin.Context) {
 	}
 
 	dto := &model.URLResultsDTO{
+		SchemaVersion:   model.ResultsSchemaVersion,
 		URL:             url.ToDTO(),
 		AnalysisResults: analysisResults,
 		Links:           links,
+		LinksTotal:      len(links),
+	}
+	if h.maxResultLinks > 0 && len(links) > h.maxResultLinks {
+		dto.Links = links[:h.maxResultLinks]
+		dto.Truncated = true
+		dto.LinksURL = fmt.Sprintf("/api/v1/users/me/links?url_id=%d", id)
 	}
 
 	c.JSON(http.StatusOK, dto)
 }
 
-// @Summary Adjust crawler workers
-// @Tags    crawler
-// @Produce json
-// @Param   action query string true "Action (add or remove)" Enums(add, remove)
-// @Param   count query int true "Number of workers to add/remove"
-// @Success 200 {object} map[string]string "adjusted"
+// csvFlushRows is how many CSV rows are written between flushes to the client.
+const csvFlushRows = 500
+
+// @Summary Download a URL's links as CSV
+// @Description Streams one row per link (href, is_external, status_code). When the URL has been analyzed, a last row `summary,<internal>,<external>,<broken>` holds the link counts of its latest analysis.
+// @Tags    urls
+// @Produce text/csv
+// @Param   id path int true "URL ID"
+// @Success 200 {file} file "CSV of the URL's links"
 // @Failure 400 {object} map[string]string "bad request"
+// @Failure 404 {object} map[string]string "not found"
 // @Security JWTAuth
 // @Security BasicAuth
-// @Router  /crawler/workers [patch]
-func (h *URLHandler) AdjustWorkers(c *gin.Context) {
-	action := c.Query("action")
-	countStr := c.Query("count")
-
-	if action != "add" && action != "remove" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be 'add' or 'remove'"})
+// @Router  /urls/{id}/results.csv [get]
+func (h *URLHandler) ResultsCSV(c *gin.Context) {
+	id, ok := h.parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	count, err := strconv.Atoi(countStr)
-	if err != nil || count <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "count must be a positive integer"})
+	url, analysisResults, links, err := h.urlService.ResultsWithDetails(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if url == nil || url.ID == 0 || (url.UserID != uidAny.(uint) && !isAdmin(c)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
 		return
 	}
 
-	if err := h.urlService.AdjustCrawlerWorkers(action, count); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="url-%d-results.csv"`, id))
+	c.Status(http.StatusOK)
+
+	// Rows go out as they are written; once the header is sent an error
+	// can only cut the file short.
+	w := csv.NewWriter(c.Writer)
+	flush := func() bool {
+		w.Flush()
+		c.Writer.Flush()
+		return w.Error() == nil
+	}
+	if err := w.Write([]string{"href", "is_external", "status_code"}); err != nil {
 		return
 	}
+	for i, l := range links {
+		if err := w.Write([]string{l.Href, strconv.FormatBool(l.IsExternal), strconv.Itoa(l.StatusCode)}); err != nil {
+			return
+		}
+		if (i+1)%csvFlushRows == 0 && !flush() {
+			return
+		}
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Successfully %s %d workers", action+"ed", count)})
+	var latest *model.AnalysisResult
+	for _, ar := range analysisResults {
+		if ar != nil && (latest == nil || ar.ID > latest.ID) {
+			latest = ar
+		}
+	}
+	if latest != nil {
+		_ = w.Write([]string{
+			"summary",
+			strconv.Itoa(latest.InternalLinkCount),
+			strconv.Itoa(latest.ExternalLinkCount),
+			strconv.Itoa(latest.BrokenLinkCount),
+		})
+	}
+	flush()
 }
 
-// @Summary Get recent crawl results
-// @Tags    crawler
-// @Produce json
-// @Success 200 {array} crawler.CrawlResult "array of recent crawl results"
+// @Summary Download a PDF report for a URL
+// @Description Summarizes the URL's latest analysis (title, counts and broken links) as a PDF for sharing.
+// @Tags    urls
+// @Produce application/pdf
+// @Param   id path int true "URL ID"
+// @Success 200 {file} file "PDF report"
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 404 {object} map[string]string "not found"
 // @Security JWTAuth
 // @Security BasicAuth
-// @Router  /crawler/results [get]
-func (h *URLHandler) GetCrawlResults(c *gin.Context) {
+// @Router  /urls/{id}/report.pdf [get]
+func (h *URLHandler) ReportPDF(c *gin.Context) {
+	id, ok := h.parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "This endpoint would stream real-time crawl results. In a production implementation, consider using WebSockets or Server-Sent Events.",
-		"note":    "The enhanced crawler now supports real-time result streaming via channels. This HTTP endpoint is just a placeholder.",
-	})
+	rep, err := h.urlService.Report(id, uidAny.(uint))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	pdf, err := rep.PDF()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="url-%d-report.pdf"`, id))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// @Summary Merge a duplicate URL into another
+// @Description Moves the URL's analysis results and links onto the target URL and deletes it. Both URLs must belong to the caller.
+// @Tags    urls
+// @Produce json
+// @Param   id path int true "URL ID to merge away"
+// @Param   into query int true "URL ID to merge into"
+// @Success 200 {object} map[string]string "merged"
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 403 {object} map[string]string "forbidden"
+// @Failure 404 {object} map[string]string "not found"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/{id}/merge [post]
+func (h *URLHandler) Merge(c *gin.Context) {
+	id, ok := h.parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	into, err := strconv.ParseUint(c.Query("into"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid into"})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.urlService.Merge(id, uint(into), uidAny.(uint)); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+		case errors.Is(err, service.ErrURLNotOwned):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "merged"})
+}
+
+// @Summary Clone a URL's configuration
+// @Description Creates a new URL for the caller at original_url that inherits the crawl configuration of URL {id}. Results are not copied.
+// @Tags    urls
+// @Accept  json
+// @Produce json
+// @Param   id path int true "URL ID to copy the configuration from"
+// @Param   input body model.URLCreateRequestDTO true "URL to crawl"
+// @Success 201 {object} map[string]uint "{id}"
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 403 {object} map[string]string "forbidden"
+// @Failure 404 {object} map[string]string "not found"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/{id}/clone [post]
+func (h *URLHandler) Clone(c *gin.Context) {
+	id, ok := h.parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	var req model.URLCreateRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	newID, err := h.urlService.Clone(id, uidAny.(uint), req.OriginalURL)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+		case errors.Is(err, service.ErrURLNotOwned):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": newID})
+}
+
+// @Summary Validate URLs without creating them
+// @Description Checks a batch of URLs before an import. Each entry reports its normalized form, whether it is valid (and why not), and whether the caller already has it. Nothing is stored.
+// @Tags    urls
+// @Accept  json
+// @Produce json
+// @Param   input body model.ValidateURLsRequestDTO true "URLs to validate"
+// @Success 200 {array} model.URLValidationDTO
+// @Failure 400 {object} map[string]string "bad request"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/validate [post]
+func (h *URLHandler) Validate(c *gin.Context) {
+	var req model.ValidateURLsRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	verdicts, err := h.urlService.Validate(uidAny.(uint), req.URLs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, verdicts)
+}
+
+// @Summary Export the caller's URLs
+// @Description Downloads the caller's URLs as a versioned document that POST /urls/import reads back.
+// @Tags    urls
+// @Produce json
+// @Param   Accept-Version header int false "schema version the client understands"
+// @Success 200 {object} model.URLExportDocument
+// @Failure 406 {object} map[string]string "unsupported schema version"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/export [get]
+func (h *URLHandler) ExportURLs(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	if v := c.GetHeader("Accept-Version"); v != "" && v != strconv.Itoa(model.URLExportSchemaVersion) {
+		c.JSON(http.StatusNotAcceptable, gin.H{"error": "unsupported schema version"})
+		return
+	}
+	userID := uidAny.(uint)
+
+	doc, err := h.urlService.ExportURLs(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="linktorch-urls-%d.json"`, userID))
+	c.JSON(http.StatusOK, doc)
+}
+
+// @Summary Import URLs
+// @Description Creates the URLs of a document from GET /urls/export. URLs the caller already has are skipped, and the result lists entries that were rejected. Documents of a schema version this server does not read are refused.
+// @Tags    urls
+// @Accept  json
+// @Produce json
+// @Param   input body model.URLExportDocument true "Exported URL document"
+// @Success 200 {object} model.URLImportResultDTO
+// @Failure 400 {object} map[string]string "invalid payload or unsupported schema version"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/import [post]
+func (h *URLHandler) ImportURLs(c *gin.Context) {
+	var doc model.URLExportDocument
+	if err := json.NewDecoder(c.Request.Body).Decode(&doc); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	if len(doc.URLs) > model.MaxImportURLs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d urls can be imported at once", model.MaxImportURLs)})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	res, err := h.urlService.ImportURLs(uidAny.(uint), &doc)
+	if errors.Is(err, service.ErrUnsupportedSchemaVersion) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// @Summary Report on the URLs of a tag (paginated)
+// @Description Lists the caller's URLs tagged with {tag}, each with its status and the title and broken link count of its latest analysis.
+// @Tags    urls
+// @Produce json
+// @Param   tag       path  string true  "Tag name"
+// @Param   page      query int    false "page" default(1) example(1)
+// @Param   page_size query int    false "page_size" default(10) example(10)
+// @Success 200 {object} model.PaginatedResponse[model.TagReportItemDTO] "Paginated tag report"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /tags/{tag}/report [get]
+func (h *URLHandler) TagReport(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	report, err := h.urlService.TagReport(uidAny.(uint), c.Param("tag"), h.paginationFromQuery(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// @Summary Summarize the URLs of a tag
+// @Description Counts the caller's URLs tagged with {tag} in total and per status, and adds up the broken links found by their latest analyses.
+// @Tags    urls
+// @Produce json
+// @Param   tag path string true "Tag name"
+// @Success 200 {object} model.TagSummaryDTO
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /tags/{tag}/summary [get]
+func (h *URLHandler) TagSummary(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	summary, err := h.urlService.TagSummary(uidAny.(uint), c.Param("tag"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// @Summary Re-crawl the URLs of a tag
+// @Description Queues a new crawl of each of the caller's URLs tagged with {tag}. URLs that could not be queued are skipped and listed in the response.
+// @Tags    urls
+// @Produce json
+// @Param   tag      path  string true  "Tag name"
+// @Param   priority query int    false "Priority (1-10); without it each URL is queued like a plain start"
+// @Success 200 {object} model.BulkURLActionResultDTO
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /tags/{tag}/recrawl [post]
+func (h *URLHandler) RecrawlTag(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	priority := 0
+	if raw := c.Query("priority"); raw != "" {
+		p, err := strconv.Atoi(raw)
+		if err != nil || p < 1 || p > 10 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "priority must be an integer from 1 to 10"})
+			return
+		}
+		priority = p
+	}
+
+	summary, err := h.urlService.RecrawlTag(uidAny.(uint), c.Param("tag"), priority)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// @Summary Delete all errored URLs
+// @Description Soft-deletes every URL of the caller currently in error status and returns how many were deleted. Admins may pass user_id to clean up another user's URLs.
+// @Tags    urls
+// @Produce json
+// @Param   user_id query int false "owner whose URLs are cleaned up (admin only)"
+// @Success 200 {object} map[string]int "deleted count"
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 403 {object} map[string]string "forbidden"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/cleanup-errored [post]
+func (h *URLHandler) CleanupErrored(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := uidAny.(uint)
+	if raw := c.Query("user_id"); raw != "" {
+		if !isAdmin(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only admins can clean up other users' URLs"})
+			return
+		}
+		owner, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || owner == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id value"})
+			return
+		}
+		userID = uint(owner)
+	}
+
+	deleted, err := h.urlService.DeleteErrored(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
+
+// @Summary Delete URLs in bulk
+// @Description Soft-deletes the listed URLs of the caller. IDs that do not exist or belong to someone else are skipped and listed in the response.
+// @Tags    urls
+// @Accept  json
+// @Produce json
+// @Param   input body model.BulkURLActionRequestDTO true "URL IDs"
+// @Success 200 {object} model.BulkURLActionResultDTO
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls [delete]
+func (h *URLHandler) DeleteBulk(c *gin.Context) {
+	h.bulkAction(c, h.urlService.DeleteBulk)
+}
+
+// @Summary Re-crawl URLs in bulk
+// @Description Queues a new crawl of each listed URL of the caller. IDs that do not exist, belong to someone else or could not be queued are skipped and listed in the response.
+// @Tags    urls
+// @Accept  json
+// @Produce json
+// @Param   input body model.BulkURLActionRequestDTO true "URL IDs"
+// @Success 200 {object} model.BulkURLActionResultDTO
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/recrawl [patch]
+func (h *URLHandler) RecrawlBulk(c *gin.Context) {
+	h.bulkAction(c, h.urlService.StartBulk)
+}
+
+// bulkAction runs action on the ids of the request body for the caller.
+func (h *URLHandler) bulkAction(c *gin.Context, action func(userID uint, ids []uint) (*model.BulkURLActionResultDTO, error)) {
+	var req model.BulkURLActionRequestDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	summary, err := action(uidAny.(uint), req.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// @Summary Adjust crawler workers
+// @Tags    crawler
+// @Produce json
+// @Param   action query string true "Action (add or remove)" Enums(add, remove)
+// @Param   count query int true "Number of workers to add/remove"
+// @Success 200 {object} map[string]string "adjusted"
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 403 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /crawler/workers [patch]
+func (h *URLHandler) AdjustWorkers(c *gin.Context) {
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+	action := c.Query("action")
+	countStr := c.Query("count")
+
+	if action != "add" && action != "remove" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be 'add' or 'remove'"})
+		return
+	}
+
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "count must be a positive integer"})
+		return
+	}
+
+	if err := h.urlService.AdjustCrawlerWorkers(action, count); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Successfully %s %d workers", action+"ed", count)})
+}
+
+// @Summary Pause the crawler
+// @Description Workers finish the crawl they are on and then take no new URLs until the crawler is resumed. URLs started meanwhile stay queued.
+// @Tags    crawler
+// @Produce json
+// @Success 200 {object} model.CrawlerStatusDTO
+// @Failure 403 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /crawler/pause [patch]
+func (h *URLHandler) PauseCrawler(c *gin.Context) {
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+	h.urlService.PauseCrawler()
+	c.JSON(http.StatusOK, h.urlService.CrawlerStatus())
+}
+
+// @Summary Resume the crawler
+// @Tags    crawler
+// @Produce json
+// @Success 200 {object} model.CrawlerStatusDTO
+// @Failure 403 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /crawler/resume [patch]
+func (h *URLHandler) ResumeCrawler(c *gin.Context) {
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+	h.urlService.ResumeCrawler()
+	c.JSON(http.StatusOK, h.urlService.CrawlerStatus())
+}
+
+// @Summary Crawler status
+// @Description Whether the crawler is paused, with its queue depth and worker count.
+// @Tags    crawler
+// @Produce json
+// @Success 200 {object} model.CrawlerStatusDTO
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /crawler/status [get]
+func (h *URLHandler) CrawlerStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.urlService.CrawlerStatus())
+}
+
+// @Summary Crawler pool metrics
+// @Description Active workers, queue length and the totals of the crawls finished since the server started: how many, how many errored, their average duration and their count per final status.
+// @Tags    crawler
+// @Produce json
+// @Success 200 {object} crawler.PoolStats
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /crawler/stats [get]
+func (h *URLHandler) CrawlerStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.urlService.CrawlerStats())
+}
+
+// defaultQueueSnapshot is how many queued URLs GET /admin/crawler/queue lists
+// without a limit.
+const defaultQueueSnapshot = 100
+
+// @Summary Crawl queue contents (admin only)
+// @Description The URLs waiting for a crawler worker with their priority and enqueue time, in the order workers will take them.
+// @Tags    crawler
+// @Produce json
+// @Param   limit query int false "How many queued URLs to list (max 500)" default(100)
+// @Success 200 {object} crawler.QueueSnapshot
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 403 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /admin/crawler/queue [get]
+func (h *URLHandler) CrawlerQueue(c *gin.Context) {
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "admin access required"})
+		return
+	}
+	limit := defaultQueueSnapshot
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > crawler.MaxQueueSnapshot {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", crawler.MaxQueueSnapshot)})
+			return
+		}
+		limit = n
+	}
+	c.JSON(http.StatusOK, h.urlService.CrawlerQueue(limit))
+}
+
+// resultHub starts fanning the service's crawl results out on first use, so
+// every stream receives all results instead of competing for them.
+func (h *URLHandler) resultHub() *crawler.ResultHub {
+	h.resultsOnce.Do(func() {
+		h.results = crawler.NewResultHub()
+		go h.results.Run(h.urlService.GetCrawlResults())
+	})
+	return h.results
+}
+
+// resultFilter reports which crawl results the caller may see: admins see
+// all of them, everyone else only those of their own URLs.
+func resultFilter(c *gin.Context) (func(crawler.CrawlResult) bool, bool) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		return nil, false
+	}
+	if isAdmin(c) {
+		return func(crawler.CrawlResult) bool { return true }, true
+	}
+	userID := uidAny.(uint)
+	return func(r crawler.CrawlResult) bool { return r.UserID == userID }, true
+}
+
+// @Summary Stream crawl results
+// @Description Server-sent events with one "result" event per crawl result as workers report them. Admins receive the results of all URLs, other users those of their own.
+// @Tags    crawler
+// @Produce text/event-stream
+// @Success 200 {object} crawler.CrawlEvent "result event"
+// @Failure 401 {object} map[string]string "unauthorized"
+// @Failure 429 {object} map[string]string "too many open streams"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /crawler/results [get]
+func (h *URLHandler) GetCrawlResults(c *gin.Context) {
+	visible, ok := resultFilter(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	release, ok := acquireStream(c, h.stream)
+	if !ok {
+		return
+	}
+	defer release()
+	results, unsubscribe := h.resultHub().Subscribe()
+	defer unsubscribe()
+
+	// Like the log stream, this one is bounded by MaxLifetime rather than
+	// the server write timeout.
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	var heartbeat <-chan time.Time
+	if h.stream.Heartbeat > 0 {
+		ticker := time.NewTicker(h.stream.Heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+	var expired <-chan time.Time
+	if h.stream.MaxLifetime > 0 {
+		timer := time.NewTimer(h.stream.MaxLifetime)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expired:
+			return
+		case <-heartbeat:
+			_, _ = c.Writer.WriteString(": keep-alive\n\n")
+			c.Writer.Flush()
+		case res, ok := <-results:
+			if !ok {
+				return
+			}
+			if !visible(res) {
+				continue
+			}
+			c.SSEvent("result", res.Event())
+			c.Writer.Flush()
+		}
+	}
+}
+
+// Defaults for the crawl result WebSocket.
+const (
+	wsPingInterval = 30 * time.Second
+	wsWriteTimeout = 10 * time.Second
+)
+
+// @Summary Crawl results over WebSocket
+// @Description Upgrades to a WebSocket that receives one JSON text frame per crawl result. Admins receive the results of all URLs, other users those of their own. The server pings the client periodically; the socket is dropped once a write fails.
+// @Tags    crawler
+// @Success 101 {object} crawler.CrawlEvent "result frame"
+// @Failure 401 {object} map[string]string "unauthorized"
+// @Failure 429 {object} map[string]string "too many open streams"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /crawler/ws [get]
+func (h *URLHandler) CrawlResultsWS(c *gin.Context) {
+	visible, ok := resultFilter(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	// The slot is held until the socket closes; ServeHTTP below returns
+	// only then.
+	release, ok := acquireStream(c, h.stream)
+	if !ok {
+		return
+	}
+	defer release()
+	results, unsubscribe := h.resultHub().Subscribe()
+	defer unsubscribe()
+
+	ping := h.stream.Heartbeat
+	if ping <= 0 {
+		ping = wsPingInterval
+	}
+
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+		// Deadlines the HTTP server set for the request would otherwise
+		// end the socket; only writes are bounded from here on.
+		_ = ws.SetDeadline(time.Time{})
+
+		// Clients send nothing but control frames, which the reader answers
+		// itself; reading only tells us when the socket goes away.
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			var msg []byte
+			for websocket.Message.Receive(ws, &msg) == nil {
+			}
+		}()
+
+		ticker := time.NewTicker(ping)
+		defer ticker.Stop()
+		var expired <-chan time.Time
+		if h.stream.MaxLifetime > 0 {
+			timer := time.NewTimer(h.stream.MaxLifetime)
+			defer timer.Stop()
+			expired = timer.C
+		}
+
+		for {
+			select {
+			case <-closed:
+				return
+			case <-expired:
+				return
+			case <-ticker.C:
+				_ = ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				ws.PayloadType = websocket.PingFrame
+				_, err := ws.Write(nil)
+				ws.PayloadType = websocket.TextFrame
+				if err != nil {
+					return
+				}
+			case res, ok := <-results:
+				if !ok {
+					return
+				}
+				if !visible(res) {
+					continue
+				}
+				_ = ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				if err := websocket.JSON.Send(ws, res.Event()); err != nil {
+					return
+				}
+			}
+		}
+	}}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// Bounds of the timeout of a crawl completion long-poll.
+const (
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 2 * time.Minute
+)
+
+// @Summary Wait for a crawl to finish
+// @Description Long-polls until URL {id} reaches a terminal status (done, error, stopped or skipped) or the timeout elapses, then returns its status. timed_out tells the two apart. Meant for clients that cannot use the result stream.
+// @Tags    urls
+// @Produce json
+// @Param   id      path  int    true  "URL ID"
+// @Param   timeout query string false "how long to wait, e.g. 30s or 30 (seconds); at most 2m" default(30s)
+// @Success 200 {object} model.URLWaitDTO
+// @Failure 400 {object} map[string]string "bad request"
+// @Failure 403 {object} map[string]string "forbidden"
+// @Failure 404 {object} map[string]string "not found"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /urls/{id}/wait [get]
+func (h *URLHandler) Wait(c *gin.Context) {
+	id, ok := h.parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	timeout, err := parseWaitTimeout(c.Query("timeout"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	// Subscribe before reading the status so a crawl finishing in between
+	// is not missed.
+	results, unsubscribe := h.resultHub().Subscribe()
+	defer unsubscribe()
+
+	dto, err := h.urlService.Get(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+		return
+	}
+	if dto.UserID != uidAny.(uint) && !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": service.ErrURLNotOwned.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+	status := dto.Status
+	for !model.IsTerminalStatus(status) {
+		select {
+		case <-ctx.Done():
+			c.JSON(http.StatusOK, model.URLWaitDTO{ID: id, Status: status, TimedOut: true})
+			return
+		case res, ok := <-results:
+			if !ok {
+				results = nil // hub closed; wait out the timeout
+				continue
+			}
+			if res.URLID != id || !model.IsTerminalStatus(res.Status) {
+				continue
+			}
+			if dto, err = h.urlService.Get(id); err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+				return
+			}
+			status = dto.Status
+			if !model.IsTerminalStatus(status) {
+				status = res.Status
+			}
+		}
+	}
+	c.JSON(http.StatusOK, model.URLWaitDTO{ID: id, Status: status})
+}
+
+// parseWaitTimeout reads the timeout of a long-poll, given as a duration
+// ("45s") or a number of seconds, capped at maxWaitTimeout.
+func parseWaitTimeout(raw string) (time.Duration, error) {
+	if raw == "" {
+		return defaultWaitTimeout, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		secs, convErr := strconv.Atoi(raw)
+		if convErr != nil {
+			return 0, errors.New("invalid timeout")
+		}
+		d = time.Duration(secs) * time.Second
+	}
+	if d <= 0 {
+		return 0, errors.New("timeout must be positive")
+	}
+	return min(d, maxWaitTimeout), nil
 }
 
 func (h *URLHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
 	rg.POST("/urls", h.Create)
+	rg.POST("/urls/bulk", h.CreateBulk)
+	rg.POST("/urls/validate", h.Validate)
+	rg.GET("/urls/export", h.ExportURLs)
+	rg.POST("/urls/import", h.ImportURLs)
+	rg.POST("/urls/cleanup-errored", h.CleanupErrored)
 	rg.GET("/urls", h.List)
+	rg.DELETE("/urls", h.DeleteBulk)
+	rg.PATCH("/urls/recrawl", h.RecrawlBulk)
+	rg.GET("/users/me/urls/uncrawled", h.ListUncrawled)
+	rg.GET("/tags/:tag/report", h.TagReport)
+	rg.GET("/tags/:tag/summary", h.TagSummary)
+	rg.POST("/tags/:tag/recrawl", h.RecrawlTag)
+	rg.GET("/urls/trash", h.ListTrash)
 	rg.GET("/urls/:id", h.Get)
 	rg.PUT("/urls/:id", h.Update)
 	rg.DELETE("/urls/:id", h.Delete)
+	rg.POST("/urls/:id/restore", h.Restore)
+	rg.DELETE("/urls/:id/purge", h.Purge)
 	rg.PATCH("/urls/:id/start", h.Start)
+	rg.PATCH("/urls/:id/priority", h.Reprioritize)
 	rg.PATCH("/urls/:id/stop", h.Stop)
 	rg.GET("/urls/:id/results", h.Results)
+	rg.GET("/urls/:id/wait", h.Wait)
+	rg.GET("/urls/:id/results.csv", h.ResultsCSV)
+	rg.GET("/urls/:id/report.pdf", h.ReportPDF)
+	rg.POST("/urls/:id/merge", h.Merge)
+	rg.POST("/urls/:id/clone", h.Clone)
 	rg.PATCH("/crawler/workers", h.AdjustWorkers)
+	rg.PATCH("/crawler/pause", h.PauseCrawler)
+	rg.PATCH("/crawler/resume", h.ResumeCrawler)
+	rg.GET("/crawler/status", h.CrawlerStatus)
+	rg.GET("/crawler/stats", h.CrawlerStats)
+	rg.GET("/admin/crawler/queue", h.CrawlerQueue)
 	rg.GET("/crawler/results", h.GetCrawlResults)
+	rg.GET("/crawler/ws", h.CrawlResultsWS)
 }
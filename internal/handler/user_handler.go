@@ -1,18 +1,29 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
 	"github.com/fuzumoe/linkTorch-api/internal/service"
 )
 
+// EmailVerificationSender delivers an email verification token to email.
+type EmailVerificationSender func(email, token string) error
+
 type UserHandler struct {
-	userService service.UserService
+	userService   service.UserService
+	exportService service.ExportService
+	// sendVerification delivers verification tokens; nil if email
+	// verification is disabled.
+	sendVerification EmailVerificationSender
 }
 
 func NewUserHandler(userService service.UserService) *UserHandler {
@@ -21,6 +32,25 @@ func NewUserHandler(userService service.UserService) *UserHandler {
 	}
 }
 
+// UserHandlerOptions configures the optional routes of a user handler. The
+// zero value serves neither account exports nor email verification.
+type UserHandlerOptions struct {
+	// Export serves account exports at /users/me/export.
+	Export service.ExportService
+	// SendVerification sends new users an email verification token and
+	// enables /verify-email.
+	SendVerification EmailVerificationSender
+}
+
+// NewUserHandlerWithOptions creates a user handler configured by opts.
+func NewUserHandlerWithOptions(userService service.UserService, opts UserHandlerOptions) *UserHandler {
+	return &UserHandler{
+		userService:      userService,
+		exportService:    opts.Export,
+		sendVerification: opts.SendVerification,
+	}
+}
+
 func (h *UserHandler) parseUintParam(c *gin.Context, name string) (uint, bool) {
 	v, err := strconv.ParseUint(c.Param(name), 10, 64)
 	if err != nil {
@@ -43,6 +73,7 @@ func (h *UserHandler) paginationFromQuery(c *gin.Context) repository.Pagination
 // @Param   input body model.CreateUserInput true "User to create"
 // @Success 201 {object} map[string]uint "{id}"
 // @Failure 400 {object} map[string]string "error"
+// @Failure 409 {object} map[string]string "username taken"
 // @Failure 500 {object} map[string]string "error"
 // @Security JWTAuth
 // @Security BasicAuth
@@ -56,13 +87,105 @@ func (h *UserHandler) Create(c *gin.Context) {
 
 	userID, err := h.userService.Register(&input)
 	if err != nil {
+		if errors.Is(err, service.ErrUsernameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
 		return
 	}
+	if h.sendVerification != nil {
+		// The user exists either way; they can ask for a new token at
+		// /verify-email/resend if this one does not arrive.
+		if err := h.sendVerificationToken(userID.ID, userID.Email); err != nil {
+			log.Printf("[WARN] failed to send email verification token: %v", err)
+		}
+	}
 
 	c.JSON(http.StatusCreated, gin.H{"id": userID})
 }
 
+// sendVerificationToken issues a verification token for the user and hands
+// it to sendVerification.
+func (h *UserHandler) sendVerificationToken(userID uint, email string) error {
+	token, err := h.userService.RequestEmailVerification(userID)
+	if err != nil {
+		return err
+	}
+	return h.sendVerification(email, token)
+}
+
+// VerifyEmail godoc
+// @Summary      Verify an email address
+// @Description  Marks the email of the user the token was sent to as verified
+// @Description  Example request: {"token": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."}
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        request  body      model.VerifyEmailInput  true  "Verification token"
+// @Success      200      {object}  map[string]interface{} "Email verified"
+// @Failure      400      {object}  map[string]interface{} "Invalid request, or token invalid or expired"
+// @Failure      500      {object}  map[string]interface{} "Internal server error"
+// @Router       /verify-email [post]
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	var req model.VerifyEmailInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "verification token required"})
+		return
+	}
+
+	if err := h.userService.VerifyEmail(req.Token); err != nil {
+		if errors.Is(err, service.ErrVerificationTokenInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or expired verification token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+// ResendVerification godoc
+// @Summary      Resend the email verification token
+// @Description  Sends a new verification token to the authenticated user's email
+// @Tags         users
+// @Produce      json
+// @Success      200      {object}  map[string]interface{} "Token sent"
+// @Failure      401      {object}  map[string]interface{} "Unauthorized"
+// @Failure      409      {object}  map[string]interface{} "Email already verified"
+// @Failure      429      {object}  map[string]interface{} "Requested too often"
+// @Failure      500      {object}  map[string]interface{} "Internal server error"
+// @Security     JWTAuth
+// @Security     BasicAuth
+// @Router       /verify-email/resend [post]
+func (h *UserHandler) ResendVerification(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := uidAny.(uint)
+
+	user, err := h.userService.Get(userID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	err = h.sendVerificationToken(userID, user.Email)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": "verification token sent"})
+	case errors.Is(err, service.ErrEmailAlreadyVerified):
+		c.JSON(http.StatusConflict, gin.H{"error": "email already verified"})
+	case errors.Is(err, service.ErrVerificationRateLimited):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "verification token requested too often"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send verification token"})
+	}
+}
+
 // @Summary Get Authenticated User
 // @Tags    users
 // @Produce json
@@ -89,6 +212,80 @@ func (h *UserHandler) Me(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// @Summary Export Authenticated User's Data
+// @Description Downloads a zip archive with the user's profile, notification settings, URLs, tags, analysis results and links, one JSON file each. Its manifest.json gives the archive's schema_version and lists the other files.
+// @Tags    users
+// @Produce application/zip
+// @Success 200 {file} file "Account export archive"
+// @Failure 401 {object} map[string]string "error"
+// @Failure 404 {object} map[string]string "error"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /users/me/export [get]
+func (h *UserHandler) Export(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	h.export(c, uidAny.(uint))
+}
+
+// @Summary Export a User's Data
+// @Description Downloads the same archive as /users/me/export for the given user. Only that user and admins may export it.
+// @Tags    users
+// @Produce application/zip
+// @Param   id path int true "User ID"
+// @Success 200 {file} file "Account export archive"
+// @Failure 400 {object} map[string]string "error"
+// @Failure 401 {object} map[string]string "error"
+// @Failure 403 {object} map[string]string "error"
+// @Failure 404 {object} map[string]string "error"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /users/{id}/export [get]
+func (h *UserHandler) ExportUser(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id, ok := h.parseUintParam(c, "id")
+	if !ok {
+		return
+	}
+	if id != uidAny.(uint) && !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+	h.export(c, id)
+}
+
+// export streams the account archive of userID.
+func (h *UserHandler) export(c *gin.Context, userID uint) {
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="linktorch-export-%d.zip"`, userID))
+	err := h.exportService.Export(userID, c.Writer)
+	if err == nil {
+		return
+	}
+	if c.Writer.Written() {
+		// The archive is already streaming; it is left without its
+		// directory, so clients see it as corrupt.
+		_ = c.Error(err)
+		return
+	}
+	c.Header("Content-Type", "")
+	c.Header("Content-Disposition", "")
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export account data"})
+}
+
 // @Summary Search Users
 // @Tags    users
 // @Produce json
@@ -133,6 +330,7 @@ func (h *UserHandler) Get(c *gin.Context) {
 // @Success 200 {object} model.UserDTO
 // @Failure 400 {object} map[string]string "error"
 // @Failure 404 {object} map[string]string "error"
+// @Failure 409 {object} map[string]string "username taken"
 // @Failure 500 {object} map[string]string "error"
 // @Security JWTAuth
 // @Security BasicAuth
@@ -172,6 +370,10 @@ func (h *UserHandler) Update(c *gin.Context) {
 
 	user, err := h.userService.Update(id, &input)
 	if err != nil {
+		if errors.Is(err, service.ErrUsernameTaken) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
 		return
 	}
@@ -211,9 +413,64 @@ func (h *UserHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// @Summary Permanently Delete Own Account
+// @Description Erases the authenticated user and all their URLs, results, links, tags and settings, and revokes their tokens. The current password must be given to confirm.
+// @Tags    users
+// @Accept  json
+// @Produce json
+// @Param   input body model.DeleteAccountInput true "Password confirmation"
+// @Success 204 "Account deleted"
+// @Failure 400 {object} map[string]string "error"
+// @Failure 401 {object} map[string]string "error"
+// @Failure 404 {object} map[string]string "error"
+// @Failure 500 {object} map[string]string "error"
+// @Security JWTAuth
+// @Security BasicAuth
+// @Router  /users/me/account [delete]
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	uidAny, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID := uidAny.(uint)
+
+	var input model.DeleteAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "password confirmation required"})
+		return
+	}
+
+	err := h.userService.DeleteAccount(userID, input.Password)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, service.ErrWrongPassword):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "wrong password"})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete account"})
+	}
+}
+
+func (h *UserHandler) RegisterPublicRoutes(rg *gin.RouterGroup) {
+	if h.sendVerification != nil {
+		rg.POST("/verify-email", h.VerifyEmail)
+	}
+}
+
 func (h *UserHandler) RegisterProtectedRoutes(rg *gin.RouterGroup) {
 	rg.POST("/users", h.Create)
 	rg.GET("/users/me", h.Me)
+	rg.DELETE("/users/me/account", h.DeleteAccount)
+	if h.exportService != nil {
+		rg.GET("/users/me/export", h.Export)
+		rg.GET("/users/:id/export", h.ExportUser)
+	}
+	if h.sendVerification != nil {
+		rg.POST("/verify-email/resend", h.ResendVerification)
+	}
 	rg.GET("/users/search", h.Get)
 	rg.GET("/users/:id", h.Get)
 	rg.PUT("/users/:id", h.Update)
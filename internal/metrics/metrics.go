@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+// Namespace prefixes the name of every metric.
+const Namespace = "linktorch"
+
+// Metrics holds the Prometheus collectors of the API in a registry of their
+// own, so that several instances can coexist in tests.
+type Metrics struct {
+	registry        *prometheus.Registry
+	crawlsStarted   prometheus.Counter
+	crawlsCompleted prometheus.Counter
+	crawlsFailed    prometheus.Counter
+	crawlDuration   *prometheus.HistogramVec
+	httpDuration    *prometheus.HistogramVec
+	queueDepth      func() int
+}
+
+// New registers the crawl, HTTP and runtime metrics. queueDepth is read on
+// every scrape for the queue depth gauge; nil leaves the gauge out.
+func New(queueDepth func() int) *Metrics {
+	m := &Metrics{
+		registry:   prometheus.NewRegistry(),
+		queueDepth: queueDepth,
+		crawlsStarted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "crawls_started_total",
+			Help:      "Crawls taken off the queue by a worker.",
+		}),
+		crawlsCompleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "crawls_completed_total",
+			Help:      "Crawls that finished with the URL analysed.",
+		}),
+		crawlsFailed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Name:      "crawls_failed_total",
+			Help:      "Crawls that finished in error.",
+		}),
+		crawlDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "crawl_duration_seconds",
+			Help:      "Time from a worker taking a URL to its crawl ending, by final status.",
+			Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+		}, []string{"status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by method, route and response status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+	}
+	m.registry.MustRegister(
+		m.crawlsStarted, m.crawlsCompleted, m.crawlsFailed, m.crawlDuration, m.httpDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	if queueDepth != nil {
+		m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Name:      "crawl_queue_depth",
+			Help:      "URLs waiting for a crawler worker.",
+		}, func() float64 { return float64(queueDepth()) }))
+	}
+	return m
+}
+
+// CrawlStarted counts a crawl a worker has begun.
+func (m *Metrics) CrawlStarted() {
+	m.crawlsStarted.Inc()
+}
+
+// CrawlFinished records the duration of a crawl ending with status. Crawls
+// ending done count as completed and those ending in error as failed.
+func (m *Metrics) CrawlFinished(status string, elapsed time.Duration) {
+	switch status {
+	case model.StatusDone:
+		m.crawlsCompleted.Inc()
+	case model.StatusError:
+		m.crawlsFailed.Inc()
+	}
+	m.crawlDuration.WithLabelValues(status).Observe(elapsed.Seconds())
+}
+
+// ObserveRequest records the latency of a request served by route.
+func (m *Metrics) ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	m.httpDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(elapsed.Seconds())
+}
+
+// Handler serves the registered metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Snapshot is the current value of the crawl and HTTP metrics, for
+// monitoring without a Prometheus server.
+type Snapshot struct {
+	Requests   RequestsSnapshot `json:"requests"`
+	Crawls     CrawlsSnapshot   `json:"crawls"`
+	QueueDepth *int             `json:"queue_depth"` // Nil when the queue depth is not tracked
+}
+
+// RequestsSnapshot counts the HTTP requests served since start-up.
+type RequestsSnapshot struct {
+	Total   uint64          `json:"total"`
+	ByRoute []RouteRequests `json:"by_route"`
+}
+
+// RouteRequests counts the requests of one method, route and status.
+type RouteRequests struct {
+	Method     string  `json:"method"`
+	Route      string  `json:"route"`
+	Status     int     `json:"status"`
+	Count      uint64  `json:"count"`
+	AvgSeconds float64 `json:"avg_seconds"`
+}
+
+// CrawlsSnapshot counts the crawls run since start-up.
+type CrawlsSnapshot struct {
+	Started   uint64 `json:"started"`
+	Completed uint64 `json:"completed"`
+	Failed    uint64 `json:"failed"`
+	// ByStatus holds the number and average duration of finished crawls
+	// by final status.
+	ByStatus map[string]CrawlDurations `json:"by_status"`
+}
+
+// CrawlDurations summarises the durations of crawls ending with one status.
+type CrawlDurations struct {
+	Count      uint64  `json:"count"`
+	AvgSeconds float64 `json:"avg_seconds"`
+}
+
+// Snapshot reads the current value of the crawl and HTTP metrics.
+func (m *Metrics) Snapshot() (*Snapshot, error) {
+	families, err := m.registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for _, f := range families {
+		byName[f.GetName()] = f
+	}
+	counter := func(name string) uint64 {
+		f := byName[Namespace+"_"+name]
+		if f == nil || len(f.GetMetric()) == 0 {
+			return 0
+		}
+		return uint64(f.GetMetric()[0].GetCounter().GetValue())
+	}
+
+	snap := &Snapshot{
+		Requests: RequestsSnapshot{ByRoute: []RouteRequests{}},
+		Crawls: CrawlsSnapshot{
+			Started:   counter("crawls_started_total"),
+			Completed: counter("crawls_completed_total"),
+			Failed:    counter("crawls_failed_total"),
+			ByStatus:  map[string]CrawlDurations{},
+		},
+	}
+	if f := byName[Namespace+"_http_request_duration_seconds"]; f != nil {
+		for _, metric := range f.GetMetric() {
+			labels := labelValues(metric)
+			status, _ := strconv.Atoi(labels["status"])
+			h := metric.GetHistogram()
+			snap.Requests.Total += h.GetSampleCount()
+			snap.Requests.ByRoute = append(snap.Requests.ByRoute, RouteRequests{
+				Method:     labels["method"],
+				Route:      labels["route"],
+				Status:     status,
+				Count:      h.GetSampleCount(),
+				AvgSeconds: average(h),
+			})
+		}
+	}
+	if f := byName[Namespace+"_crawl_duration_seconds"]; f != nil {
+		for _, metric := range f.GetMetric() {
+			h := metric.GetHistogram()
+			snap.Crawls.ByStatus[labelValues(metric)["status"]] = CrawlDurations{
+				Count:      h.GetSampleCount(),
+				AvgSeconds: average(h),
+			}
+		}
+	}
+	if m.queueDepth != nil {
+		depth := m.queueDepth()
+		snap.QueueDepth = &depth
+	}
+	return snap, nil
+}
+
+func labelValues(metric *dto.Metric) map[string]string {
+	labels := make(map[string]string, len(metric.GetLabel()))
+	for _, l := range metric.GetLabel() {
+		labels[l.GetName()] = l.GetValue()
+	}
+	return labels
+}
+
+func average(h *dto.Histogram) float64 {
+	if h.GetSampleCount() == 0 {
+		return 0
+	}
+	return h.GetSampleSum() / float64(h.GetSampleCount())
+}
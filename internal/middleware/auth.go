@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"encoding/base64"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -10,8 +11,37 @@ import (
 	"github.com/fuzumoe/linkTorch-api/internal/service"
 )
 
+// AuthOptions configures the optional credentials AuthMiddleware accepts.
+type AuthOptions struct {
+	// APIKeys, when set, accepts an API key in the X-API-Key header, which
+	// takes precedence over Authorization.
+	APIKeys service.APIKeyService
+}
+
 func AuthMiddleware(authService service.AuthService) gin.HandlerFunc {
+	return AuthMiddlewareWithOptions(authService, AuthOptions{})
+}
+
+// AuthMiddlewareWithOptions is AuthMiddleware configured by opts.
+func AuthMiddlewareWithOptions(authService service.AuthService, opts AuthOptions) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader("X-API-Key"); key != "" && opts.APIKeys != nil {
+			user, err := opts.APIKeys.Authenticate(key)
+			if errors.Is(err, service.ErrAPIKeyExpired) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key expired"})
+				return
+			}
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
+				return
+			}
+			c.Set("user_id", user.ID)
+			c.Set("user_email", user.Email)
+			c.Set("user_role", user.Role)
+			c.Next()
+			return
+		}
+
 		auth := c.GetHeader("Authorization")
 		if auth == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "authorization header missing"})
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// sensitiveJSON matches string values of JSON keys that look like
+	// credentials, including the plaintext "key" of a newly created API key.
+	sensitiveJSON = regexp.MustCompile(`(?i)("(?:[^"]*(?:password|token|secret)[^"]*|(?:api_)?key)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// sensitiveForm matches values of form fields that look like credentials.
+	sensitiveForm = regexp.MustCompile(`(?i)((?:^|&)[^=&]*(?:password|token|secret)[^=&]*=)[^&]*`)
+	// sensitiveHeaders are logged with their value replaced.
+	sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-API-Key"}
+)
+
+// bodyCapture tees everything written to the response into buf.
+type bodyCapture struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *bodyCapture) Write(b []byte) (int, error) {
+	if !w.streaming() {
+		w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCapture) WriteString(s string) (int, error) {
+	if !w.streaming() {
+		w.buf.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *bodyCapture) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// streaming reports whether the response is an event stream, which is never buffered.
+func (w *bodyCapture) streaming() bool {
+	return strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+}
+
+// BodyLogger logs request and response bodies for debugging. It does nothing
+// unless enabled. Credentials are redacted and each body is cut to maxBytes.
+func BodyLogger(enabled bool, maxBytes int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled {
+			c.Next()
+			return
+		}
+
+		var reqBody []byte
+		if c.Request.Body != nil {
+			reqBody, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(reqBody))
+		}
+		log.Printf("[DEBUG] --> %s %s headers=%v body=%s",
+			c.Request.Method, c.Request.URL.RequestURI(),
+			redactHeaders(c.Request.Header),
+			redactBody(reqBody, c.ContentType(), maxBytes))
+
+		capture := &bodyCapture{ResponseWriter: c.Writer}
+		c.Writer = capture
+		c.Next()
+
+		if capture.streaming() {
+			return
+		}
+		respType := capture.Header().Get("Content-Type")
+		log.Printf("[DEBUG] <-- %s %s status=%d body=%s",
+			c.Request.Method, c.Request.URL.RequestURI(), capture.Status(),
+			redactBody(capture.buf.Bytes(), respType, maxBytes))
+	}
+}
+
+// redactHeaders returns a copy of h with credential headers masked.
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+// redactBody masks credentials in body and truncates the result to maxBytes.
+func redactBody(body []byte, contentType string, maxBytes int) string {
+	s := sensitiveJSON.ReplaceAllString(string(body), `$1"`+redacted+`"`)
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		s = sensitiveForm.ReplaceAllString(s, "${1}"+redacted)
+	}
+	if maxBytes > 0 && len(s) > maxBytes {
+		s = s[:maxBytes] + "...(truncated)"
+	}
+	return s
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestObserver records the latency of served requests.
+type RequestObserver interface {
+	ObserveRequest(method, route string, status int, elapsed time.Duration)
+}
+
+// unmatchedRoute labels requests that matched no route, so that arbitrary
+// paths do not each become a metric series.
+const unmatchedRoute = "unmatched"
+
+// RequestMetrics reports every request to obs under its route template,
+// e.g. /api/v1/urls/:id.
+func RequestMetrics(obs RequestObserver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		obs.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID correlating a request with its log lines and
+// the crawls it queues.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength caps incoming request IDs; longer ones are replaced.
+const maxRequestIDLength = 128
+
+// RequestID gives every request an ID: the one in its X-Request-ID header,
+// or a new UUID. The ID is stored in the context as "request_id" and echoed
+// in the response header.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+		c.Set("request_id", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLogger writes one structured log record per request to logger with
+// its method, path, status, latency, user_id and the request_id set by
+// RequestID, which must run first. Server errors are logged at
+// error level, client errors at warn and the rest at info; the query, client
+// IP and user agent are added when logger is enabled for debug.
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("request_id", c.GetString("request_id")),
+		}
+		if userID, ok := c.Get("user_id"); ok {
+			attrs = append(attrs, slog.Any("user_id", userID))
+		}
+		ctx := c.Request.Context()
+		if logger.Enabled(ctx, slog.LevelDebug) {
+			attrs = append(attrs,
+				slog.String("query", c.Request.URL.RawQuery),
+				slog.String("client_ip", c.ClientIP()),
+				slog.String("user_agent", c.Request.UserAgent()),
+			)
+		}
+		logger.LogAttrs(ctx, requestLogLevel(status), "request", attrs...)
+	}
+}
+
+// requestLogLevel is the level a response with status is logged at.
+func requestLogLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
+
+// NewLogger builds a logger for RequestLogger writing records of level and
+// above to w, as JSON lines when format is "json" and as key=value text
+// otherwise. An unknown level means info.
+func NewLogger(w io.Writer, level, format string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SlowRequestLogger logs a warning for every request that takes longer than threshold.
+// A threshold of zero or less disables the check. Requests that are meant to
+// stay open are not timed: WebSocket upgrades, event streams and the routes
+// in longLived, e.g. long-polls, given as registered ("/api/v1/urls/:id/wait").
+func SlowRequestLogger(threshold time.Duration, longLived ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(longLived))
+	for _, route := range longLived {
+		skip[route] = true
+	}
+	return func(c *gin.Context) {
+		if threshold <= 0 || skip[c.FullPath()] || c.IsWebsocket() {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+		elapsed := time.Since(start)
+		if elapsed < threshold {
+			return
+		}
+		if strings.HasPrefix(c.Writer.Header().Get("Content-Type"), "text/event-stream") {
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		userID, _ := c.Get("user_id")
+		log.Printf("[WARN] slow request: %s %s took %s (threshold %s, status %d, user_id=%v)",
+			c.Request.Method, route, elapsed.Truncate(time.Millisecond), threshold, c.Writer.Status(), userID)
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/fuzumoe/linkTorch-api/internal/service"
+)
+
+// RequireVerifiedEmail rejects users who have not verified their email with
+// 403. It must run after AuthMiddleware. When enabled is false every request
+// passes.
+func RequireVerifiedEmail(userService service.UserService, enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled {
+			c.Next()
+			return
+		}
+		userID, ok := c.Get("user_id")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		user, err := userService.Get(userID.(uint))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		if !user.EmailVerified {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "email not verified"})
+			return
+		}
+		c.Next()
+	}
+}
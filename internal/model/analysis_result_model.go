@@ -1,6 +1,9 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -11,17 +14,35 @@ type AnalysisResult struct {
 	ID                uint           `gorm:"primaryKey;autoIncrement" json:"id"`
 	URLID             uint           `gorm:"not null;index" json:"url_id"`
 	HTMLVersion       string         `gorm:"size:50;not null" json:"html_version"`
+	ContentType       string         `gorm:"size:255" json:"content_type"`
+	StatusCode        int            `json:"status_code"`      // HTTP status of the final response; 0 for results saved before it was recorded
+	ResponseTimeMs    int            `json:"response_time_ms"` // Fetching the page, redirects and body included
+	PageSizeBytes     int            `json:"page_size_bytes"`  // Size of the fetched body
 	Title             string         `gorm:"type:text" json:"title"`
+	TitleSource       string         `gorm:"size:20" json:"title_source,omitempty"` // Where Title came from: title, h1, og_title or url_path
+	MetaDescription   string         `gorm:"type:text" json:"meta_description"`
+	Language          string         `gorm:"size:35" json:"language"` // From <html lang>, else the Content-Language header
 	H1Count           int            `json:"h1_count"`
 	H2Count           int            `json:"h2_count"`
 	H3Count           int            `json:"h3_count"`
 	H4Count           int            `json:"h4_count"`
 	H5Count           int            `json:"h5_count"`
 	H6Count           int            `json:"h6_count"`
+	ImageCount        int            `json:"image_count"`
+	ImagesMissingAlt  int            `json:"images_missing_alt"` // <img> tags without alt text, empty or blank alt included
 	HasLoginForm      bool           `json:"has_login_form"`
 	InternalLinkCount int            `json:"internal_link_count"`
 	ExternalLinkCount int            `json:"external_link_count"`
 	BrokenLinkCount   int            `json:"broken_link_count"`
+	RedirectChain     RedirectChain  `gorm:"type:json" json:"redirect_chain,omitempty"`
+	FinalURL          string         `gorm:"type:text" json:"final_url"`     // URL the crawl ended on after following redirects
+	CanonicalURL      string         `gorm:"type:text" json:"canonical_url"` // From <link rel="canonical">, resolved against FinalURL
+	CanonicalMismatch bool           `json:"canonical_mismatch"`             // CanonicalURL is set and points elsewhere than FinalURL
+	CrawlDurationMs   *int64         `json:"crawl_duration_ms,omitempty"`
+	TLSVerifySkipped  bool           `json:"tls_verify_skipped"`
+	RawHTMLKey        string         `gorm:"size:255" json:"-"`
+	RunID             *string        `gorm:"size:36;uniqueIndex" json:"-"` // Set by the crawler; saving the same run again overwrites it
+	Provenance        *Provenance    `gorm:"serializer:json;type:json" json:"-"`
 	CreatedAt         time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt         time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
@@ -29,19 +50,136 @@ type AnalysisResult struct {
 
 // AnalysisResultDTO is used for sending analysis results in responses.
 type AnalysisResultDTO struct {
-	ID           uint      `json:"id"`
-	URLID        uint      `json:"url_id"`
-	HTMLVersion  string    `json:"html_version"`
-	Title        string    `json:"title"`
-	H1Count      int       `json:"h1_count"`
-	H2Count      int       `json:"h2_count"`
-	H3Count      int       `json:"h3_count"`
-	H4Count      int       `json:"h4_count"`
-	H5Count      int       `json:"h5_count"`
-	H6Count      int       `json:"h6_count"`
-	HasLoginForm bool      `json:"has_login_form"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                uint          `json:"id"`
+	URLID             uint          `json:"url_id"`
+	HTMLVersion       string        `json:"html_version"`
+	ContentType       string        `json:"content_type"`
+	StatusCode        int           `json:"status_code"`
+	ResponseTimeMs    int           `json:"response_time_ms"`
+	PageSizeBytes     int           `json:"page_size_bytes"`
+	Title             string        `json:"title"`
+	TitleSource       string        `json:"title_source,omitempty"`
+	MetaDescription   string        `json:"meta_description"`
+	Language          string        `json:"language"`
+	H1Count           int           `json:"h1_count"`
+	H2Count           int           `json:"h2_count"`
+	H3Count           int           `json:"h3_count"`
+	H4Count           int           `json:"h4_count"`
+	H5Count           int           `json:"h5_count"`
+	H6Count           int           `json:"h6_count"`
+	ImageCount        int           `json:"image_count"`
+	ImagesMissingAlt  int           `json:"images_missing_alt"`
+	HasLoginForm      bool          `json:"has_login_form"`
+	RedirectChain     RedirectChain `json:"redirect_chain,omitempty"`
+	FinalURL          string        `json:"final_url"`
+	CanonicalURL      string        `json:"canonical_url"`
+	CanonicalMismatch bool          `json:"canonical_mismatch"`
+	CrawlDurationMs   *int64        `json:"crawl_duration_ms,omitempty"`
+	TLSVerifySkipped  bool          `json:"tls_verify_skipped"`
+	CreatedAt         time.Time     `json:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+}
+
+// HTMLVersionCountDTO is the number of URLs whose latest analysis detected an HTML version.
+type HTMLVersionCountDTO struct {
+	HTMLVersion string `json:"html_version"`
+	Count       int    `json:"count"`
+}
+
+// RecentCrawlDTO is one of a user's URLs with the time its latest analysis finished.
+type RecentCrawlDTO struct {
+	URLID         uint      `json:"url_id"`
+	OriginalURL   string    `json:"original_url"`
+	Status        string    `json:"status"`
+	LastCrawledAt time.Time `json:"last_crawled_at"`
+}
+
+// CrawlTimingsDTO summarizes how long a URL's crawls took, in milliseconds.
+// The durations are nil when no timed crawl exists yet.
+type CrawlTimingsDTO struct {
+	URLID    uint     `json:"url_id"`
+	Runs     int      `json:"runs"`
+	MinMs    *int64   `json:"min_ms"`
+	MaxMs    *int64   `json:"max_ms"`
+	AvgMs    *float64 `json:"avg_ms"`
+	LatestMs *int64   `json:"latest_ms"`
+}
+
+// ResponseTimesDTO holds nearest-rank percentiles of a URL's page response
+// times, in milliseconds. Crawls saved before response times were recorded
+// count with their crawl duration. The percentiles are nil when no timed
+// crawl exists yet.
+type ResponseTimesDTO struct {
+	URLID uint   `json:"url_id"`
+	Runs  int    `json:"runs"`
+	P50Ms *int64 `json:"p50_ms"`
+	P90Ms *int64 `json:"p90_ms"`
+	P99Ms *int64 `json:"p99_ms"`
+}
+
+// Render modes recorded in Provenance.
+const (
+	RenderModeFull = "full" // the page was fetched and parsed
+	RenderModeHead = "head" // only the response headers were checked
+)
+
+// Provenance records how the crawler produced an analysis result.
+type Provenance struct {
+	WorkerID   int       `json:"worker_id"`
+	Attempts   int       `json:"attempts"`
+	Analyzer   string    `json:"analyzer"`
+	RenderMode string    `json:"render_mode"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	HostWaitMs int64     `json:"host_wait_ms"` // waiting for a per-host slot in the final attempt
+	AnalyzeMs  int64     `json:"analyze_ms"`   // the analyzer call of the final attempt
+	TotalMs    int64     `json:"total_ms"`     // the whole run, retries included
+}
+
+// ProvenanceDTO is the provenance of a URL's latest analysis result.
+type ProvenanceDTO struct {
+	URLID    uint      `json:"url_id"`
+	ResultID uint      `json:"result_id"`
+	RunID    string    `json:"run_id,omitempty"`
+	SavedAt  time.Time `json:"saved_at"`
+	Provenance
+}
+
+// RedirectHop is a single response observed while following redirects.
+type RedirectHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+}
+
+// RedirectChain is the ordered list of hops, ending with the final response.
+// It is stored as a JSON column.
+type RedirectChain []RedirectHop
+
+// Value implements driver.Valuer.
+func (c RedirectChain) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner.
+func (c *RedirectChain) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	default:
+		return fmt.Errorf("cannot scan %T into RedirectChain", src)
+	}
 }
 
 // TableName returns the name of the table for AnalysisResult.
@@ -66,19 +204,34 @@ type CreateAnalysisResultInput struct {
 // ToDTO converts an AnalysisResult model to AnalysisResultDTO.
 func (r *AnalysisResult) ToDTO() *AnalysisResultDTO {
 	return &AnalysisResultDTO{
-		ID:           r.ID,
-		URLID:        r.URLID,
-		HTMLVersion:  r.HTMLVersion,
-		Title:        r.Title,
-		H1Count:      r.H1Count,
-		H2Count:      r.H2Count,
-		H3Count:      r.H3Count,
-		H4Count:      r.H4Count,
-		H5Count:      r.H5Count,
-		H6Count:      r.H6Count,
-		HasLoginForm: r.HasLoginForm,
-		CreatedAt:    r.CreatedAt,
-		UpdatedAt:    r.UpdatedAt,
+		ID:                r.ID,
+		URLID:             r.URLID,
+		HTMLVersion:       r.HTMLVersion,
+		ContentType:       r.ContentType,
+		StatusCode:        r.StatusCode,
+		ResponseTimeMs:    r.ResponseTimeMs,
+		PageSizeBytes:     r.PageSizeBytes,
+		Title:             r.Title,
+		TitleSource:       r.TitleSource,
+		MetaDescription:   r.MetaDescription,
+		Language:          r.Language,
+		H1Count:           r.H1Count,
+		H2Count:           r.H2Count,
+		H3Count:           r.H3Count,
+		H4Count:           r.H4Count,
+		H5Count:           r.H5Count,
+		H6Count:           r.H6Count,
+		ImageCount:        r.ImageCount,
+		ImagesMissingAlt:  r.ImagesMissingAlt,
+		HasLoginForm:      r.HasLoginForm,
+		RedirectChain:     r.RedirectChain,
+		FinalURL:          r.FinalURL,
+		CanonicalURL:      r.CanonicalURL,
+		CanonicalMismatch: r.CanonicalMismatch,
+		CrawlDurationMs:   r.CrawlDurationMs,
+		TLSVerifySkipped:  r.TLSVerifySkipped,
+		CreatedAt:         r.CreatedAt,
+		UpdatedAt:         r.UpdatedAt,
 	}
 }
 
@@ -0,0 +1,67 @@
+package model
+
+import "time"
+
+// APIKey is a long-lived credential a user hands to scripts instead of a
+// JWT. Only a hash of the key is stored; the plaintext is shown once, when
+// the key is created.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	KeyHash    string     `gorm:"type:char(64);uniqueIndex;not null" json:"-"` // Hex SHA-256 of the key
+	Prefix     string     `gorm:"type:varchar(16);not null" json:"prefix"`     // Start of the key, so users can tell keys apart
+	LastUsedAt *time.Time `json:"last_used_at"`                                // Nil until the key authenticates a request
+	Revoked    bool       `gorm:"not null;default:false" json:"revoked"`       // Revoked keys never authenticate
+	ExpiresAt  *time.Time `json:"expires_at"`                                  // Nil for keys that never expire
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName overrides GORM’s default table name.
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ExpiredAt reports whether the key has expired by t.
+func (k *APIKey) ExpiredAt(t time.Time) bool {
+	return k.ExpiresAt != nil && !t.Before(*k.ExpiresAt)
+}
+
+// APIKeyDTO describes a key without any secret.
+type APIKeyDTO struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	Revoked    bool       `json:"revoked"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Expired    bool       `json:"expired"` // Expired keys no longer authenticate
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (k *APIKey) ToDTO() *APIKeyDTO {
+	return &APIKeyDTO{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		LastUsedAt: k.LastUsedAt,
+		Revoked:    k.Revoked,
+		ExpiresAt:  k.ExpiresAt,
+		Expired:    k.ExpiredAt(time.Now()),
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+// CreatedAPIKeyDTO is returned once, when a key is created; Key is the
+// plaintext that cannot be retrieved again.
+type CreatedAPIKeyDTO struct {
+	APIKeyDTO
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyInput names a new API key. Without ExpiresAt the key never
+// expires, unless the server requires an expiry.
+type CreateAPIKeyInput struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
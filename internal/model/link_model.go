@@ -6,27 +6,94 @@ import (
 	"gorm.io/gorm"
 )
 
-// Link represents a hyperlink found on a URL's page.
+// Link represents a hyperlink found on a URL's page. AnalysisResultID is the
+// crawl that found it; links saved before links were scoped to crawls have none.
 type Link struct {
-	ID         uint           `gorm:"primaryKey;autoIncrement" json:"id"`
-	URLID      uint           `gorm:"not null;index" json:"url_id"`
-	Href       string         `gorm:"type:text;not null" json:"href"`
-	IsExternal bool           `json:"is_external"`
-	StatusCode int            `json:"status_code"`
-	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt  time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+	ID               uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	URLID            uint           `gorm:"not null;index" json:"url_id"`
+	AnalysisResultID *uint          `gorm:"index" json:"analysis_result_id,omitempty"`
+	Href             string         `gorm:"type:text;not null" json:"href"`
+	IsExternal       bool           `json:"is_external"`
+	StatusCode       int            `json:"status_code"`
+	OccurrenceCount  int            `json:"occurrence_count"` // Times the page links to Href; 0 for links saved before it was counted
+	CreatedAt        time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 // LinkDTO is a data transfer object for Link responses
 type LinkDTO struct {
-	ID         uint      `json:"id"`
-	URLID      uint      `json:"url_id"`
-	Href       string    `json:"href"`
-	IsExternal bool      `json:"is_external"`
-	StatusCode int       `json:"status_code"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID              uint      `json:"id"`
+	URLID           uint      `json:"url_id"`
+	Href            string    `json:"href"`
+	IsExternal      bool      `json:"is_external"`
+	StatusCode      int       `json:"status_code"`
+	OccurrenceCount int       `json:"occurrence_count"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// UserLinkDTO is a link enriched with its owning URL for the cross-URL link explorer.
+type UserLinkDTO struct {
+	LinkDTO
+	OriginalURL string `json:"original_url"`
+	URLTitle    string `json:"url_title"`
+}
+
+// LinkStatusSummaryDTO counts a URL's links by HTTP status class. Links that
+// could not be reached (status 0 or outside 2xx-5xx) count as unreachable.
+type LinkStatusSummaryDTO struct {
+	URLID       uint `json:"url_id"`
+	Status2xx   int  `json:"2xx"`
+	Status3xx   int  `json:"3xx"`
+	Status4xx   int  `json:"4xx"`
+	Status5xx   int  `json:"5xx"`
+	Unreachable int  `json:"unreachable"`
+	Total       int  `json:"total"`
+}
+
+// LinkRun is the set of hrefs found by one crawl.
+type LinkRun struct {
+	AnalysisResultID uint
+	Hrefs            []string
+}
+
+// LinkChangesDTO lists the links that appeared or disappeared between the
+// two latest crawls of a URL. With a single crawl every link counts as added.
+type LinkChangesDTO struct {
+	URLID         uint     `json:"url_id"`
+	CurrentRunID  *uint    `json:"current_run_id"`
+	PreviousRunID *uint    `json:"previous_run_id"`
+	Added         []string `json:"added"`
+	Removed       []string `json:"removed"`
+}
+
+// DomainImpactURLDTO is a URL with links to the domain being inspected.
+type DomainImpactURLDTO struct {
+	URLID       uint   `json:"url_id"`
+	UserID      uint   `json:"user_id"`
+	OriginalURL string `json:"original_url"`
+	Links       int    `json:"links"`
+}
+
+// DomainImpactDTO sums up, across all users, the external links to a domain
+// and its subdomains. AffectedURLs is one page of the URLs holding them,
+// those with the most links first.
+type DomainImpactDTO struct {
+	Domain       string                                `json:"domain"`
+	Links        int                                   `json:"links"`
+	URLs         int                                   `json:"urls"`
+	Users        int                                   `json:"users"`
+	AffectedURLs PaginatedResponse[DomainImpactURLDTO] `json:"affected_urls"`
+}
+
+// BrokenDomainDTO counts, across all users, the broken external links to one
+// host and the URLs and users they belong to.
+type BrokenDomainDTO struct {
+	Domain      string `json:"domain"`
+	BrokenLinks int    `json:"broken_links"`
+	URLs        int    `json:"urls"`
+	Users       int    `json:"users"`
 }
 
 // TableName returns the name of the table for Link.
@@ -45,13 +112,14 @@ type CreateLinkInput struct {
 // ToDTO transforms a Link model into a LinkDTO for responses.
 func (l *Link) ToDTO() *LinkDTO {
 	return &LinkDTO{
-		ID:         l.ID,
-		URLID:      l.URLID,
-		Href:       l.Href,
-		IsExternal: l.IsExternal,
-		StatusCode: l.StatusCode,
-		CreatedAt:  l.CreatedAt,
-		UpdatedAt:  l.UpdatedAt,
+		ID:              l.ID,
+		URLID:           l.URLID,
+		Href:            l.Href,
+		IsExternal:      l.IsExternal,
+		StatusCode:      l.StatusCode,
+		OccurrenceCount: l.OccurrenceCount,
+		CreatedAt:       l.CreatedAt,
+		UpdatedAt:       l.UpdatedAt,
 	}
 }
 
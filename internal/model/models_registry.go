@@ -4,7 +4,11 @@ package model
 var AllModels = []interface{}{
 	&User{},
 	&URL{},
+	&URLTag{},
 	&AnalysisResult{},
 	&Link{},
 	&BlacklistedToken{},
+	&IssuedToken{},
+	&NotificationPrefs{},
+	&APIKey{},
 }
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// Digest frequencies.
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// Alert channels.
+const (
+	AlertChannelEmail   = "email"
+	AlertChannelWebhook = "webhook"
+)
+
+// NotificationPrefs holds a user's notification settings. Users without a
+// row get DefaultNotificationPrefs.
+type NotificationPrefs struct {
+	UserID          uint      `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	DigestFrequency string    `gorm:"type:varchar(16);not null;default:'off'" json:"digest_frequency"`
+	AlertChannels   []string  `gorm:"serializer:json;type:varchar(255)" json:"alert_channels"`
+	WebhookEnabled  bool      `gorm:"not null;default:false" json:"webhook_enabled"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (NotificationPrefs) TableName() string {
+	return "notification_prefs"
+}
+
+// DefaultNotificationPrefs returns the settings of a user who never changed them.
+func DefaultNotificationPrefs(userID uint) *NotificationPrefs {
+	return &NotificationPrefs{
+		UserID:          userID,
+		DigestFrequency: DigestOff,
+		AlertChannels:   []string{AlertChannelEmail},
+	}
+}
+
+// UpdateNotificationPrefsInput changes only the fields that are set.
+type UpdateNotificationPrefsInput struct {
+	DigestFrequency *string   `json:"digest_frequency,omitempty" binding:"omitempty,oneof=off daily weekly"`
+	AlertChannels   *[]string `json:"alert_channels,omitempty" binding:"omitempty,max=2,dive,oneof=email webhook"`
+	WebhookEnabled  *bool     `json:"webhook_enabled,omitempty"`
+}
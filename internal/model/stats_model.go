@@ -0,0 +1,31 @@
+package model
+
+// SystemStatsDTO is the operator dashboard summary across all users.
+type SystemStatsDTO struct {
+	TotalUsers  int `json:"total_users"`
+	TotalURLs   int `json:"total_urls"`
+	Crawls24h   int `json:"crawls_last_24h"`
+	QueueDepth  int `json:"queue_depth"`
+	CrawledURLs int `json:"crawled_urls"`
+	ErroredURLs int `json:"errored_urls"`
+	// ErrorRate is ErroredURLs / CrawledURLs, or 0 when nothing was crawled yet.
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// CrawlETADTO estimates how long the current crawl queue takes to drain.
+type CrawlETADTO struct {
+	QueueDepth int   `json:"queue_depth"`
+	Workers    int   `json:"workers"`
+	AvgCrawlMs int64 `json:"avg_crawl_ms"`
+	Samples    int   `json:"samples"` // recent timed crawls the average is based on
+	// ETASeconds is nil while the queue is not empty but no crawl has been timed yet.
+	ETASeconds *int64 `json:"eta_seconds"`
+}
+
+// CrawlerStatusDTO reports whether the crawler pool is paused and how much
+// work it holds.
+type CrawlerStatusDTO struct {
+	Paused     bool `json:"paused"`
+	QueueDepth int  `json:"queue_depth"`
+	Workers    int  `json:"workers"`
+}
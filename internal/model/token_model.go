@@ -43,6 +43,22 @@ func (b *BlacklistedToken) ToDTO() *BlacklistedTokenDTO {
 	}
 }
 
+// IssuedToken records a JWT handed to a user, so all of a user's
+// outstanding tokens, or those of one subject, can be revoked at once.
+type IssuedToken struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	JTI       string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"jti"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	Subject   string    `gorm:"type:varchar(32);not null" json:"subject"` // access_token or refresh_token
+	ExpiresAt time.Time `gorm:"index;not null" json:"expires_at"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName overrides GORM’s default table name.
+func (IssuedToken) TableName() string {
+	return "issued_tokens"
+}
+
 // FromJTI constructs a BlacklistedToken from a jti string and expiration time.
 func FromJTI(jti string, exp time.Time) *BlacklistedToken {
 	return &BlacklistedToken{
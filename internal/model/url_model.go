@@ -13,16 +13,41 @@ const (
 	StatusDone    = "done"
 	StatusError   = "error"
 	StatusStopped = "stopped"
+	StatusSkipped = "skipped"
 )
 
+// Crawl methods of a URL.
+const (
+	CrawlMethodFull     = "full"      // fetch and parse the page and check its links
+	CrawlMethodHeadOnly = "head_only" // only record status and response time, via HEAD
+)
+
+// IsCrawlMethod reports whether method is a known crawl method.
+func IsCrawlMethod(method string) bool {
+	return method == CrawlMethodFull || method == CrawlMethodHeadOnly
+}
+
+// IsTerminalStatus reports whether a URL in status is no longer waiting for
+// or undergoing a crawl.
+func IsTerminalStatus(status string) bool {
+	switch status {
+	case StatusDone, StatusError, StatusStopped, StatusSkipped:
+		return true
+	}
+	return false
+}
+
 // URL represents a URL to be analyzed and its processing status.
 type URL struct {
 	ID              uint             `gorm:"primaryKey;autoIncrement" json:"id"`
 	UserID          uint             `gorm:"not null;index" json:"user_id"`
 	OriginalURL     string           `gorm:"type:varchar(191);uniqueIndex;not null" json:"original_url"`
-	Status          string           `gorm:"type:enum('queued','running','done','error','stopped');default:'queued';not null" json:"status"`
+	Status          string           `gorm:"type:enum('queued','running','done','error','stopped','skipped');default:'queued';not null" json:"status"`
+	HostLimit       int              `gorm:"not null;default:0" json:"host_limit"`
+	CrawlMethod     string           `gorm:"type:varchar(16);not null;default:'full'" json:"crawl_method"`
 	AnalysisResults []AnalysisResult `gorm:"foreignKey:URLID"`
 	Links           []Link           `gorm:"foreignKey:URLID"`
+	Tags            []URLTag         `gorm:"foreignKey:URLID" json:"tags,omitempty"`
 	CreatedAt       time.Time        `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt       time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt       gorm.DeletedAt   `gorm:"index" json:"-"`
@@ -47,12 +72,23 @@ type PaginatedResponse[T any] struct {
 	Pagination PaginationMetaDTO `json:"pagination"`
 }
 
+// CursorResponse is one page of a cursor listing. NextCursor, passed back as
+// the cursor query parameter, fetches the following page; it is empty on the
+// last one.
+type CursorResponse[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
 // URLDTO is the data transfer object for URL.
 type URLDTO struct {
 	ID          uint      `json:"id"`
 	UserID      uint      `json:"user_id"`
 	OriginalURL string    `json:"original_url"`
 	Status      string    `json:"status" binding:"omitempty,oneof=queued running done error"`
+	HostLimit   int       `json:"host_limit"`
+	CrawlMethod string    `json:"crawl_method"`
+	Tags        []string  `json:"tags,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -61,26 +97,132 @@ type URLDTO struct {
 type CreateURLInputDTO struct {
 	UserID      uint   `json:"user_id" binding:"required"`
 	OriginalURL string `json:"original_url" binding:"required,url"`
+	CrawlMethod string `json:"crawl_method" binding:"omitempty,oneof=full head_only"`
+	// Crawl queues the URL for crawling once it is created; nil leaves it to
+	// the service's default.
+	Crawl *bool `json:"-"`
 }
 type URLCreateRequestDTO struct {
 	OriginalURL string `json:"original_url" binding:"required,url" example:"https://example.com"`
+	CrawlMethod string `json:"crawl_method,omitempty" binding:"omitempty,oneof=full head_only" example:"full"`
+}
+
+// URLCreatedDTO answers a URL creation. Enqueued is set when the URL was also
+// queued for crawling; CrawlError says why a requested crawl was not queued.
+type URLCreatedDTO struct {
+	ID         uint   `json:"id"`
+	Enqueued   bool   `json:"enqueued"`
+	CrawlError string `json:"crawl_error,omitempty"`
+}
+
+// URLWaitDTO answers a long-poll for a URL's crawl to finish. TimedOut is set
+// when the wait ended before the URL reached a terminal status.
+type URLWaitDTO struct {
+	ID       uint   `json:"id"`
+	Status   string `json:"status"`
+	TimedOut bool   `json:"timed_out"`
+}
+
+// MaxBulkCreateURLs caps the number of URLs of a single bulk create request.
+const MaxBulkCreateURLs = 500
+
+// URLBulkCreateResultDTO is the outcome of one entry of a bulk create. ID is
+// set for created entries and for repeats of an earlier entry of the batch,
+// which are flagged as Duplicate instead of being created twice.
+type URLBulkCreateResultDTO struct {
+	Index       int    `json:"index"`
+	OriginalURL string `json:"original_url"`
+	ID          uint   `json:"id,omitempty"`
+	Duplicate   bool   `json:"duplicate"`
+	Error       string `json:"error,omitempty"`
+}
+
+// BulkURLActionRequestDTO names the URLs a bulk action applies to.
+type BulkURLActionRequestDTO struct {
+	IDs []uint `json:"ids" binding:"required,min=1,max=500"`
+}
+
+// BulkURLActionResultDTO summarizes a bulk action. Skipped lists the ids that
+// were left alone because they do not exist or belong to someone else.
+type BulkURLActionResultDTO struct {
+	Succeeded int    `json:"succeeded"`
+	Skipped   []uint `json:"skipped"`
+}
+
+// ValidateURLsRequestDTO is a batch of URLs to check before importing them.
+type ValidateURLsRequestDTO struct {
+	URLs []string `json:"urls" binding:"required,min=1,max=500"`
 }
 
+// URLValidationDTO is the verdict for a single URL of a validation batch.
+type URLValidationDTO struct {
+	Input      string `json:"input"`
+	Normalized string `json:"normalized,omitempty"`
+	Valid      bool   `json:"valid"`
+	Reason     string `json:"reason,omitempty"`
+	// Duplicate is set when the caller already has the URL or it appeared
+	// earlier in the same batch.
+	Duplicate bool `json:"duplicate"`
+}
+
+// ResultsSchemaVersion is the shape version of URLResultsDTO. Bump it when a
+// field is renamed, removed or changes meaning.
+const ResultsSchemaVersion = 1
+
+// URLResultsDTO is the latest analysis snapshot of a URL with its links.
+// Links may be cut to a server-side limit; Truncated is then set and LinksURL
+// points at the paginated listing of all of them.
 type URLResultsDTO struct {
+	SchemaVersion   int               `json:"schema_version"`
 	URL             *URLDTO           `json:"url"`
 	AnalysisResults []*AnalysisResult `json:"analysis_results"`
 	Links           []*Link           `json:"links"`
+	LinksTotal      int               `json:"links_total"`
+	Truncated       bool              `json:"truncated"`
+	LinksURL        string            `json:"links_url,omitempty"`
+}
+
+// URLExportSchemaVersion is the shape version of URLExportDocument. Bump it
+// when a field is renamed, removed or changes meaning; imports of any other
+// version are refused.
+const URLExportSchemaVersion = 1
+
+// MaxImportURLs caps the number of URLs of a single import.
+const MaxImportURLs = 5000
+
+// URLExportDocument is a user's URL list as served by GET /urls/export and
+// read back by POST /urls/import.
+type URLExportDocument struct {
+	SchemaVersion int                   `json:"schema_version"`
+	ExportedAt    time.Time             `json:"exported_at"`
+	URLs          []URLCreateRequestDTO `json:"urls"`
+}
+
+// URLImportResultDTO sums up an import. URLs the user already has, and
+// repeats within the document, are skipped; Failed lists the rejected
+// entries by their index in the document.
+type URLImportResultDTO struct {
+	Imported int                      `json:"imported"`
+	Skipped  int                      `json:"skipped"`
+	Failed   []URLBulkCreateResultDTO `json:"failed,omitempty"`
 }
 
 // AnalysisResult represents a snapshot of the analysis
 
 // ToDTO converts a URL model to a URLDTO.
 func (u *URL) ToDTO() *URLDTO {
+	var tags []string
+	for _, t := range u.Tags {
+		tags = append(tags, t.Name)
+	}
 	return &URLDTO{
 		ID:          u.ID,
 		UserID:      u.UserID,
 		OriginalURL: u.OriginalURL,
 		Status:      u.Status,
+		HostLimit:   u.HostLimit,
+		CrawlMethod: u.CrawlMethod,
+		Tags:        tags,
 		CreatedAt:   u.CreatedAt,
 		UpdatedAt:   u.UpdatedAt,
 	}
@@ -89,10 +231,36 @@ func (u *URL) ToDTO() *URLDTO {
 // FromCreateInput maps CreateURLInput to a URL model.
 func URLFromCreateInput(input *CreateURLInputDTO) *URL {
 	now := time.Now()
+	method := input.CrawlMethod
+	if method == "" {
+		method = CrawlMethodFull
+	}
 	return &URL{
 		UserID:      input.UserID,
 		OriginalURL: input.OriginalURL,
 		Status:      StatusQueued,
+		CrawlMethod: method,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// CloneFor returns a new queued URL for userID at originalURL that inherits
+// u's crawl configuration but none of its results. Per-URL crawl settings
+// must be copied here when they are added to URL.
+func (u *URL) CloneFor(userID uint, originalURL string) *URL {
+	now := time.Now()
+	var tags []URLTag
+	for _, t := range u.Tags {
+		tags = append(tags, URLTag{Name: t.Name})
+	}
+	return &URL{
+		UserID:      userID,
+		OriginalURL: originalURL,
+		Status:      StatusQueued,
+		HostLimit:   u.HostLimit,
+		CrawlMethod: u.CrawlMethod,
+		Tags:        tags,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
@@ -101,6 +269,13 @@ func URLFromCreateInput(input *CreateURLInputDTO) *URL {
 type UpdateURLInput struct {
 	OriginalURL string `json:"original_url" binding:"omitempty,url"`
 	Status      string `json:"status"        binding:"omitempty,oneof=queued running done error"`
+	HostLimit   *int   `json:"host_limit"    binding:"omitempty,min=0"`
+	CrawlMethod string `json:"crawl_method"  binding:"omitempty,oneof=full head_only"`
+}
+
+// URLPriorityInput moves a queued URL to another crawl priority.
+type URLPriorityInput struct {
+	Priority int `json:"priority" binding:"required,min=1,max=10"`
 }
 
 func (u *URL) URL() *url.URL {
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// URLTag is a label attached to a URL so URLs can be grouped.
+type URLTag struct {
+	ID    uint   `gorm:"primaryKey;autoIncrement" json:"-"`
+	URLID uint   `gorm:"not null;uniqueIndex:idx_url_tag" json:"-"`
+	Name  string `gorm:"type:varchar(191);not null;uniqueIndex:idx_url_tag" json:"name"`
+}
+
+// TableName returns the name of the table for URLTag.
+func (URLTag) TableName() string {
+	return "url_tags"
+}
+
+// TagReportItemDTO is one URL of a tag report, summarized by its latest
+// analysis. Title and BrokenLinkCount are zero until the URL is analyzed.
+type TagReportItemDTO struct {
+	URLID           uint       `json:"url_id"`
+	OriginalURL     string     `json:"original_url"`
+	Status          string     `json:"status"`
+	Title           string     `json:"title"`
+	BrokenLinkCount int        `json:"broken_link_count"`
+	AnalyzedAt      *time.Time `json:"analyzed_at,omitempty"`
+}
+
+// TagSummaryDTO rolls up a user's URLs of one tag. BrokenLinks adds up the
+// broken links found by each URL's latest analysis; ByStatus counts the URLs
+// per crawl status.
+type TagSummaryDTO struct {
+	Tag         string         `json:"tag"`
+	TotalURLs   int            `json:"total_urls"`
+	BrokenLinks int            `json:"broken_links"`
+	ByStatus    map[string]int `json:"by_status"`
+}
@@ -16,24 +16,26 @@ const (
 )
 
 type User struct {
-	ID        uint           `gorm:"primaryKey;autoIncrement" json:"id"`
-	Username  string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"username"`
-	Email     string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
-	Password  string         `gorm:"type:varchar(255);not null" json:"-"`
-	Role      UserRole       `gorm:"type:varchar(50);not null;default:'user'" json:"role"`
-	URLs      []URL          `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"urls,omitempty"`
-	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	ID            uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	Username      string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"username"`
+	Email         string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
+	Password      string         `gorm:"type:varchar(255);not null" json:"-"`
+	Role          UserRole       `gorm:"type:varchar(50);not null;default:'user'" json:"role"`
+	EmailVerified bool           `gorm:"not null;default:false" json:"email_verified"` // Set once the user proves they own Email
+	URLs          []URL          `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"urls,omitempty"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 type UserDTO struct {
-	ID        uint      `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Role      UserRole  `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            uint      `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	Role          UserRole  `json:"role"`
+	EmailVerified bool      `json:"email_verified"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 func (User) TableName() string {
@@ -54,14 +56,36 @@ type UpdateUserInput struct {
 	Role     *UserRole `json:"role,omitempty"`
 }
 
+// DeleteAccountInput confirms a request to erase one's own account.
+type DeleteAccountInput struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// VerifyEmailInput confirms an email address with a verification token.
+type VerifyEmailInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// PasswordResetRequestInput asks for a password reset token for an email.
+type PasswordResetRequestInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// PasswordResetInput sets a new password with a password reset token.
+type PasswordResetInput struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
 func (u *User) ToDTO() *UserDTO {
 	return &UserDTO{
-		ID:        u.ID,
-		Username:  u.Username,
-		Email:     u.Email,
-		Role:      u.Role,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:            u.ID,
+		Username:      u.Username,
+		Email:         u.Email,
+		Role:          u.Role,
+		EmailVerified: u.EmailVerified,
+		CreatedAt:     u.CreatedAt,
+		UpdatedAt:     u.UpdatedAt,
 	}
 }
 
@@ -0,0 +1,90 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// margin is the page margin in points on A4 pages.
+const margin = 50.0
+
+// Font styles of the Helvetica core font, so nothing needs to be embedded.
+const (
+	fontRegular = ""
+	fontBold    = "B"
+)
+
+// document lays text out top to bottom on A4 pages and starts a new page
+// when one is full.
+type document struct {
+	pdf *gofpdf.Fpdf
+	// encode converts UTF-8 to the code page of the core fonts; characters
+	// they cannot show become '?'.
+	encode func(string) string
+}
+
+func newDocument() *document {
+	pdf := gofpdf.New("P", "pt", "A4", "")
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin)
+	pdf.AddPage()
+	return &document{pdf: pdf, encode: pdf.UnicodeTranslatorFromDescriptor("")}
+}
+
+// line writes one line of text at the horizontal offset x from the left margin.
+func (d *document) line(style string, size, x float64, text string) {
+	d.lineAt(style, size, []float64{x}, []string{text})
+}
+
+// lineAt writes several cells on the same line, one per offset.
+func (d *document) lineAt(style string, size float64, xs []float64, cells []string) {
+	lead := size * 1.4
+	// Break before the line so its cells stay on the same page.
+	if _, pageHeight := d.pdf.GetPageSize(); d.pdf.GetY()+lead > pageHeight-margin {
+		d.pdf.AddPage()
+	}
+	d.pdf.SetFont("Helvetica", style, size)
+	y := d.pdf.GetY()
+	for i, text := range cells {
+		d.pdf.SetXY(margin+xs[i], y)
+		d.pdf.CellFormat(0, lead, d.encode(singleLine(text)), "", 0, "L", false, 0, "")
+	}
+	d.pdf.Ln(lead)
+}
+
+// gap adds vertical space.
+func (d *document) gap(points float64) {
+	d.pdf.Ln(points)
+}
+
+// bytes serializes the document.
+func (d *document) bytes() ([]byte, error) {
+	var out bytes.Buffer
+	if err := d.pdf.Output(&out); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// singleLine replaces control characters, which would break the line, with
+// spaces.
+func singleLine(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// truncate shortens s to at most n runes, marking the cut with "...".
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
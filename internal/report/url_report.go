@@ -0,0 +1,78 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+// MaxBrokenLinks bounds the broken-link table so generation stays fast for
+// pages with huge link counts. The total is still reported.
+const MaxBrokenLinks = 500
+
+// URLReport is the content of the shareable report for one URL.
+type URLReport struct {
+	URL    string
+	Status string
+	// Analysis is the latest analysis, or nil if the URL was never crawled.
+	Analysis    *model.AnalysisResult
+	BrokenLinks []model.Link // at most MaxBrokenLinks
+	BrokenTotal int
+	GeneratedAt time.Time
+}
+
+// PDF renders the report.
+func (r *URLReport) PDF() ([]byte, error) {
+	d := newDocument()
+	d.line(fontBold, 18, 0, "LinkTorch URL report")
+	d.gap(6)
+	d.line(fontRegular, 10, 0, truncate(r.URL, 90))
+	d.line(fontRegular, 10, 0, "Status: "+r.Status)
+	d.line(fontRegular, 10, 0, "Generated: "+r.GeneratedAt.UTC().Format(time.RFC1123))
+	d.gap(12)
+
+	a := r.Analysis
+	if a == nil {
+		d.line(fontRegular, 11, 0, "No analysis is available for this URL yet.")
+		return d.bytes()
+	}
+
+	d.line(fontBold, 13, 0, "Latest analysis")
+	d.gap(4)
+	loginForm := "no"
+	if a.HasLoginForm {
+		loginForm = "yes"
+	}
+	rows := [][2]string{
+		{"Title", truncate(a.Title, 70)},
+		{"Analyzed", a.CreatedAt.UTC().Format(time.RFC1123)},
+		{"HTML version", a.HTMLVersion},
+		{"Headings", fmt.Sprintf("h1 %d, h2 %d, h3 %d, h4 %d, h5 %d, h6 %d",
+			a.H1Count, a.H2Count, a.H3Count, a.H4Count, a.H5Count, a.H6Count)},
+		{"Internal links", fmt.Sprint(a.InternalLinkCount)},
+		{"External links", fmt.Sprint(a.ExternalLinkCount)},
+		{"Broken links", fmt.Sprint(a.BrokenLinkCount)},
+		{"Login form", loginForm},
+	}
+	for _, row := range rows {
+		d.lineAt(fontRegular, 10, []float64{0, 110}, []string{row[0], row[1]})
+	}
+	d.gap(12)
+
+	d.line(fontBold, 13, 0, "Broken links")
+	d.gap(4)
+	if len(r.BrokenLinks) == 0 {
+		d.line(fontRegular, 10, 0, "None found.")
+		return d.bytes()
+	}
+	d.lineAt(fontBold, 9, []float64{0, 50}, []string{"Status", "URL"})
+	for _, l := range r.BrokenLinks {
+		d.lineAt(fontRegular, 9, []float64{0, 50}, []string{fmt.Sprint(l.StatusCode), truncate(l.Href, 95)})
+	}
+	if more := r.BrokenTotal - len(r.BrokenLinks); more > 0 {
+		d.gap(4)
+		d.line(fontRegular, 9, 0, fmt.Sprintf("... and %d more", more))
+	}
+	return d.bytes()
+}
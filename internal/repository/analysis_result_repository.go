@@ -9,6 +9,12 @@ import (
 type AnalysisResultRepository interface {
 	Create(res *model.AnalysisResult, links []model.Link) error
 	ListByURL(urlID uint, p Pagination) ([]model.AnalysisResult, error)
+	HTMLVersionCounts(userID uint) ([]model.HTMLVersionCountDTO, error)
+	Timings(urlID, userID uint) (*model.CrawlTimingsDTO, error)
+	LatestRawHTMLKey(urlID, userID uint) (string, error)
+	RecentByUser(userID uint, limit int) ([]model.RecentCrawlDTO, error)
+	ResponseTimes(urlID, userID uint) ([]int64, error)
+	LatestProvenance(urlID, userID uint) (*model.ProvenanceDTO, error)
 }
 
 type analysisResultRepo struct{ db *gorm.DB }
@@ -24,6 +30,7 @@ func (r *analysisResultRepo) Create(res *model.AnalysisResult, links []model.Lin
 		}
 		for i := range links {
 			links[i].URLID = res.URLID
+			links[i].AnalysisResultID = &res.ID
 		}
 		return tx.CreateInBatches(&links, 500).Error
 	})
@@ -39,3 +46,135 @@ func (r *analysisResultRepo) ListByURL(urlID uint, p Pagination) ([]model.Analys
 		Find(&results).Error
 	return results, err
 }
+
+// HTMLVersionCounts groups the user's URLs by the HTML version of their latest analysis.
+func (r *analysisResultRepo) HTMLVersionCounts(userID uint) ([]model.HTMLVersionCountDTO, error) {
+	var counts []model.HTMLVersionCountDTO
+	latest := r.db.Model(&model.AnalysisResult{}).Select("MAX(id)").Group("url_id")
+	err := r.db.Model(&model.AnalysisResult{}).
+		Select("analysis_results.html_version, COUNT(*) AS count").
+		Joins("JOIN urls ON urls.id = analysis_results.url_id AND urls.deleted_at IS NULL").
+		Where("urls.user_id = ? AND analysis_results.id IN (?)", userID, latest).
+		Group("analysis_results.html_version").
+		Order("count DESC, analysis_results.html_version").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// Timings aggregates the crawl durations recorded for one of the user's URLs.
+// It returns gorm.ErrRecordNotFound if the URL does not belong to the user.
+func (r *analysisResultRepo) Timings(urlID, userID uint) (*model.CrawlTimingsDTO, error) {
+	var t model.CrawlTimingsDTO
+	res := r.db.Table("urls").
+		Select("urls.id AS url_id, COUNT(ar.crawl_duration_ms) AS runs, "+
+			"MIN(ar.crawl_duration_ms) AS min_ms, MAX(ar.crawl_duration_ms) AS max_ms, "+
+			"AVG(ar.crawl_duration_ms) AS avg_ms").
+		Joins("LEFT JOIN analysis_results ar ON ar.url_id = urls.id AND ar.deleted_at IS NULL").
+		Where("urls.id = ? AND urls.user_id = ? AND urls.deleted_at IS NULL", urlID, userID).
+		Group("urls.id").
+		Scan(&t)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var latest []int64
+	if err := r.db.Model(&model.AnalysisResult{}).
+		Where("url_id = ? AND crawl_duration_ms IS NOT NULL", urlID).
+		Order("id DESC").
+		Limit(1).
+		Pluck("crawl_duration_ms", &latest).Error; err != nil {
+		return nil, err
+	}
+	if len(latest) > 0 {
+		t.LatestMs = &latest[0]
+	}
+	return &t, nil
+}
+
+// ResponseTimes returns the recorded page response times of one of the
+// user's URLs. Results saved before response times were recorded fall back
+// to their crawl duration. It returns gorm.ErrRecordNotFound when the user
+// does not own the URL.
+func (r *analysisResultRepo) ResponseTimes(urlID, userID uint) ([]int64, error) {
+	var owned int64
+	if err := r.db.Model(&model.URL{}).
+		Where("id = ? AND user_id = ?", urlID, userID).
+		Count(&owned).Error; err != nil {
+		return nil, err
+	}
+	if owned == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var times []int64
+	err := r.db.Model(&model.AnalysisResult{}).
+		Where("url_id = ? AND (response_time_ms > 0 OR crawl_duration_ms IS NOT NULL)", urlID).
+		Pluck("CASE WHEN response_time_ms > 0 THEN response_time_ms ELSE crawl_duration_ms END", &times).Error
+	return times, err
+}
+
+// LatestRawHTMLKey returns the blob key of the newest stored raw body for one
+// of the user's URLs, or gorm.ErrRecordNotFound if there is none.
+func (r *analysisResultRepo) LatestRawHTMLKey(urlID, userID uint) (string, error) {
+	var keys []string
+	err := r.db.Model(&model.AnalysisResult{}).
+		Joins("JOIN urls ON urls.id = analysis_results.url_id AND urls.deleted_at IS NULL").
+		Where("urls.id = ? AND urls.user_id = ? AND analysis_results.raw_html_key <> ''", urlID, userID).
+		Order("analysis_results.id DESC").
+		Limit(1).
+		Pluck("analysis_results.raw_html_key", &keys).Error
+	if err != nil {
+		return "", err
+	}
+	if len(keys) == 0 {
+		return "", gorm.ErrRecordNotFound
+	}
+	return keys[0], nil
+}
+
+// LatestProvenance returns how the newest result of one of the user's URLs
+// was produced, or gorm.ErrRecordNotFound if it has no recorded provenance.
+func (r *analysisResultRepo) LatestProvenance(urlID, userID uint) (*model.ProvenanceDTO, error) {
+	var res model.AnalysisResult
+	err := r.db.Model(&model.AnalysisResult{}).
+		Select("analysis_results.id, analysis_results.url_id, analysis_results.run_id, "+
+			"analysis_results.provenance, analysis_results.created_at").
+		Joins("JOIN urls ON urls.id = analysis_results.url_id AND urls.deleted_at IS NULL").
+		Where("urls.id = ? AND urls.user_id = ?", urlID, userID).
+		Order("analysis_results.id DESC").
+		Take(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	if res.Provenance == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	dto := &model.ProvenanceDTO{
+		URLID:      res.URLID,
+		ResultID:   res.ID,
+		SavedAt:    res.CreatedAt,
+		Provenance: *res.Provenance,
+	}
+	if res.RunID != nil {
+		dto.RunID = *res.RunID
+	}
+	return dto, nil
+}
+
+// RecentByUser returns the user's crawled URLs, most recently analyzed first.
+// URLs that were never analyzed are left out.
+func (r *analysisResultRepo) RecentByUser(userID uint, limit int) ([]model.RecentCrawlDTO, error) {
+	var recent []model.RecentCrawlDTO
+	err := r.db.Model(&model.AnalysisResult{}).
+		Select("urls.id AS url_id, urls.original_url, urls.status, MAX(analysis_results.created_at) AS last_crawled_at").
+		Joins("JOIN urls ON urls.id = analysis_results.url_id AND urls.deleted_at IS NULL").
+		Where("urls.user_id = ?", userID).
+		Group("urls.id, urls.original_url, urls.status").
+		Order("last_crawled_at DESC, urls.id DESC").
+		Limit(limit).
+		Scan(&recent).Error
+	return recent, err
+}
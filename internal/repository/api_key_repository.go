@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+type APIKeyRepository interface {
+	Create(key *model.APIKey) error
+	FindActiveByHash(hash string) (*model.APIKey, error)
+	ListByUser(userID uint) ([]model.APIKey, error)
+	Revoke(id, userID uint) error
+	TouchLastUsed(id uint, at time.Time) error
+}
+
+type apiKeyRepo struct{ db *gorm.DB }
+
+func NewAPIKeyRepo(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepo{db: db}
+}
+
+func (r *apiKeyRepo) Create(key *model.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// FindActiveByHash returns gorm.ErrRecordNotFound unless an unrevoked key has the hash.
+func (r *apiKeyRepo) FindActiveByHash(hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := r.db.Where("key_hash = ? AND revoked = ?", hash, false).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByUser returns the user's keys, revoked ones included, newest first.
+func (r *apiKeyRepo) ListByUser(userID uint) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	if err := r.db.Where("user_id = ?", userID).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Revoke marks the key revoked. It returns gorm.ErrRecordNotFound when the
+// user has no key with the id; revoking a key twice is not an error.
+func (r *apiKeyRepo) Revoke(id, userID uint) error {
+	var key model.APIKey
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&key).Error; err != nil {
+		return err
+	}
+	return r.db.Model(&key).Update("revoked", true).Error
+}
+
+func (r *apiKeyRepo) TouchLastUsed(id uint, at time.Time) error {
+	return r.db.Model(&model.APIKey{ID: id}).Update("last_used_at", at).Error
+}
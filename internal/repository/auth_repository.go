@@ -1,15 +1,28 @@
 package repository
 
 import (
+	"errors"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 )
 
+// mysqlErrDupEntry is MySQL's error number for a duplicate unique key.
+const mysqlErrDupEntry = 1062
+
+// ErrRefreshTokenUsed is returned by RotateRefresh when the refresh token
+// being replaced was already blacklisted, e.g. by a concurrent rotation.
+var ErrRefreshTokenUsed = errors.New("refresh token already used")
+
+// ErrTokenUsed is returned by Consume when the token was already blacklisted.
+var ErrTokenUsed = errors.New("token already used")
+
 type TokenRepo struct {
 	db *gorm.DB
 }
@@ -24,6 +37,10 @@ type TokenRepository interface {
 	Add(token *model.BlacklistedToken) error
 	IsBlacklisted(jti string) (bool, error)
 	RemoveExpired() error
+	AddIssued(tokens ...*model.IssuedToken) error
+	RotateRefresh(oldJTI string, oldExpiresAt time.Time, issued ...*model.IssuedToken) error
+	Consume(jti string, expiresAt time.Time) error
+	RevokeUserTokens(userID uint, subject string) (int, error)
 }
 
 func (r *TokenRepo) Add(token *model.BlacklistedToken) error {
@@ -65,8 +82,92 @@ func (r *TokenRepo) IsBlacklisted(jti string) (bool, error) {
 
 func (r *TokenRepo) RemoveExpired() error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		result := tx.Where("expires_at < ?", time.Now()).
-			Delete(&model.BlacklistedToken{})
-		return result.Error
+		now := time.Now()
+		if err := tx.Where("expires_at < ?", now).Delete(&model.BlacklistedToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("expires_at < ?", now).Delete(&model.IssuedToken{}).Error
+	})
+}
+
+// AddIssued records tokens handed to their users.
+func (r *TokenRepo) AddIssued(tokens ...*model.IssuedToken) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	return r.db.Create(&tokens).Error
+}
+
+// RotateRefresh blacklists the refresh token oldJTI and records the tokens
+// issued in its place. The blacklist insert fails on a duplicate JTI, so of
+// two rotations of the same token only one succeeds; the other gets
+// ErrRefreshTokenUsed.
+func (r *TokenRepo) RotateRefresh(oldJTI string, oldExpiresAt time.Time, issued ...*model.IssuedToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := consume(tx, oldJTI, oldExpiresAt)
+		if errors.Is(err, ErrTokenUsed) {
+			return ErrRefreshTokenUsed
+		}
+		if err != nil {
+			return err
+		}
+		if len(issued) == 0 {
+			return nil
+		}
+		return tx.Create(&issued).Error
 	})
 }
+
+// Consume blacklists the single-use token jti. Like RotateRefresh, only one
+// of two concurrent calls succeeds; the other, and any later one, gets
+// ErrTokenUsed.
+func (r *TokenRepo) Consume(jti string, expiresAt time.Time) error {
+	return consume(r.db, jti, expiresAt)
+}
+
+// consume blacklists jti with a plain insert, mapping a duplicate key to ErrTokenUsed.
+func consume(db *gorm.DB, jti string, expiresAt time.Time) error {
+	err := db.Create(model.FromJTI(jti, expiresAt)).Error
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) && myErr.Number == mysqlErrDupEntry {
+		return ErrTokenUsed
+	}
+	return err
+}
+
+// RevokeUserTokens blacklists every unexpired token of userID with the
+// given subject, or of any subject if it is empty, and returns how many
+// there were, including ones already blacklisted.
+func (r *TokenRepo) RevokeUserTokens(userID uint, subject string) (int, error) {
+	var revoked int
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		revoked, err = revokeIssued(tx, userID, subject)
+		return err
+	})
+	return revoked, err
+}
+
+// revokeIssued is RevokeUserTokens within the transaction tx.
+func revokeIssued(tx *gorm.DB, userID uint, subject string) (int, error) {
+	q := tx.Where("user_id = ? AND expires_at > ?", userID, time.Now())
+	if subject != "" {
+		q = q.Where("subject = ?", subject)
+	}
+	var tokens []model.IssuedToken
+	if err := q.Find(&tokens).Error; err != nil {
+		return 0, err
+	}
+	if len(tokens) == 0 {
+		return 0, nil
+	}
+
+	blacklist := make([]*model.BlacklistedToken, len(tokens))
+	for i, t := range tokens {
+		blacklist[i] = model.FromJTI(t.JTI, t.ExpiresAt)
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&blacklist).Error; err != nil {
+		return 0, err
+	}
+	return len(tokens), nil
+}
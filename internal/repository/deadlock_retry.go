@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL error numbers that indicate lock contention rather than a real failure.
+const (
+	mysqlErrLockWaitTimeout = 1205
+	mysqlErrDeadlock        = 1213
+)
+
+// DeadlockRetry controls how writes are retried when MySQL reports a
+// deadlock or lock wait timeout.
+type DeadlockRetry struct {
+	Attempts int           // retries after the first try; 0 disables retrying
+	Backoff  time.Duration // delay before the first retry, doubled on each further retry
+}
+
+// DefaultDeadlockRetry is used by NewURLRepo.
+var DefaultDeadlockRetry = DeadlockRetry{Attempts: 3, Backoff: 50 * time.Millisecond}
+
+// isLockContention reports whether err is a MySQL deadlock or lock wait timeout.
+func isLockContention(err error) bool {
+	var myErr *mysql.MySQLError
+	if !errors.As(err, &myErr) {
+		return false
+	}
+	return myErr.Number == mysqlErrDeadlock || myErr.Number == mysqlErrLockWaitTimeout
+}
+
+// run calls fn, retrying it while it fails with lock contention.
+func (d DeadlockRetry) run(fn func() error) error {
+	backoff := d.Backoff
+	err := fn()
+	for i := 0; i < d.Attempts && isLockContention(err); i++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		err = fn()
+	}
+	return err
+}
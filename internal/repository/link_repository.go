@@ -2,6 +2,9 @@ package repository
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 
 	"gorm.io/gorm"
 
@@ -14,6 +17,70 @@ type LinkRepository interface {
 	CountByURL(urlID uint) (int, error)
 	Update(link *model.Link) error
 	Delete(link *model.Link) error
+	ListByUser(userID uint, f LinkFilter, p Pagination) ([]model.UserLinkDTO, error)
+	CountByUser(userID uint, f LinkFilter) (int, error)
+	StatusSummary(urlID, userID uint) (*model.LinkStatusSummaryDTO, error)
+	LatestRuns(urlID, userID uint, n int) ([]model.LinkRun, error)
+	DomainImpact(domain string) (*model.DomainImpactDTO, error)
+	ListDomainImpact(domain string, p Pagination) ([]model.DomainImpactURLDTO, error)
+	TopBrokenDomains(limit int) ([]model.BrokenDomainDTO, error)
+}
+
+// LinkFilter narrows the cross-URL link listing; nil and empty fields are not
+// applied.
+type LinkFilter struct {
+	URLID      *uint
+	IsExternal *bool
+	Broken     *bool
+	Search     string
+	// HrefPattern is a LIKE pattern matched against the whole href, so "%"
+	// and "_" act as wildcards.
+	HrefPattern string
+	// StatusClass is a key of linkStatusClasses, the same buckets
+	// StatusSummary counts.
+	StatusClass string
+}
+
+// MaxHrefPatternLength caps the length of LinkFilter.HrefPattern.
+const MaxHrefPatternLength = 200
+
+// hrefPatternChars are the characters allowed in LinkFilter.HrefPattern: those
+// that may appear in a URL, including "%" and "_" as LIKE wildcards. The LIKE
+// escape character "\" is not among them.
+var hrefPatternChars = regexp.MustCompile(`^[A-Za-z0-9\-._~:/?#\[\]@!$&'()*+,;=%]+$`)
+
+// linkStatusClasses maps the accepted LinkFilter.StatusClass values to their
+// conditions. Classes are only ever mapped through it, never put into SQL as
+// given.
+var linkStatusClasses = map[string]string{
+	"2xx":         "links.status_code BETWEEN 200 AND 299",
+	"3xx":         "links.status_code BETWEEN 300 AND 399",
+	"4xx":         "links.status_code BETWEEN 400 AND 499",
+	"5xx":         "links.status_code BETWEEN 500 AND 599",
+	"unreachable": "links.status_code NOT BETWEEN 200 AND 599",
+}
+
+// ErrInvalidLinkFilter is returned for a LinkFilter with an unknown status
+// class or a malformed href pattern.
+var ErrInvalidLinkFilter = errors.New("invalid link filter")
+
+// Validate reports whether f only uses known status classes and an href
+// pattern of allowed characters and length.
+func (f LinkFilter) Validate() error {
+	if f.StatusClass != "" {
+		if _, ok := linkStatusClasses[f.StatusClass]; !ok {
+			return fmt.Errorf("%w: unknown status class %q", ErrInvalidLinkFilter, f.StatusClass)
+		}
+	}
+	if f.HrefPattern != "" {
+		if len(f.HrefPattern) > MaxHrefPatternLength {
+			return fmt.Errorf("%w: href pattern longer than %d characters", ErrInvalidLinkFilter, MaxHrefPatternLength)
+		}
+		if !hrefPatternChars.MatchString(f.HrefPattern) {
+			return fmt.Errorf("%w: href pattern contains characters not allowed in a URL", ErrInvalidLinkFilter)
+		}
+	}
+	return nil
 }
 
 type linkRepo struct {
@@ -58,3 +125,230 @@ func (r *linkRepo) Delete(link *model.Link) error {
 	}
 	return nil
 }
+
+// userLinks selects the links of userID's URLs matching f.
+func (r *linkRepo) userLinks(userID uint, f LinkFilter) (*gorm.DB, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	q := r.db.Model(&model.Link{}).
+		Joins("JOIN urls ON urls.id = links.url_id AND urls.deleted_at IS NULL").
+		Where("urls.user_id = ?", userID)
+	if f.URLID != nil {
+		q = q.Where("links.url_id = ?", *f.URLID)
+	}
+	if f.IsExternal != nil {
+		q = q.Where("links.is_external = ?", *f.IsExternal)
+	}
+	if f.Broken != nil {
+		if *f.Broken {
+			q = q.Where("links.status_code BETWEEN 400 AND 599")
+		} else {
+			q = q.Where("links.status_code NOT BETWEEN 400 AND 599")
+		}
+	}
+	if f.Search != "" {
+		q = q.Where("links.href LIKE ?", "%"+f.Search+"%")
+	}
+	if f.HrefPattern != "" {
+		q = q.Where("links.href LIKE ?", f.HrefPattern)
+	}
+	if f.StatusClass != "" {
+		q = q.Where(linkStatusClasses[f.StatusClass])
+	}
+	return q, nil
+}
+
+func (r *linkRepo) ListByUser(userID uint, f LinkFilter, p Pagination) ([]model.UserLinkDTO, error) {
+	q, err := r.userLinks(userID, f)
+	if err != nil {
+		return nil, err
+	}
+	var links []model.UserLinkDTO
+	err = q.
+		Select(`links.id, links.url_id, links.href, links.is_external, links.status_code, links.occurrence_count,
+			links.created_at, links.updated_at, urls.original_url,
+			COALESCE((SELECT ar.title FROM analysis_results ar
+			 WHERE ar.url_id = links.url_id AND ar.deleted_at IS NULL
+			 ORDER BY ar.created_at DESC LIMIT 1), '') AS url_title`).
+		Order("links.id").
+		Limit(p.Limit()).
+		Offset(p.Offset()).
+		Scan(&links).Error
+	return links, err
+}
+
+func (r *linkRepo) CountByUser(userID uint, f LinkFilter) (int, error) {
+	q, err := r.userLinks(userID, f)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	err = q.Count(&count).Error
+	return int(count), err
+}
+
+// StatusSummary counts the links of a URL owned by userID by status class.
+// It returns gorm.ErrRecordNotFound when the URL does not belong to the user.
+func (r *linkRepo) StatusSummary(urlID, userID uint) (*model.LinkStatusSummaryDTO, error) {
+	var owned int64
+	if err := r.db.Model(&model.URL{}).
+		Where("id = ? AND user_id = ?", urlID, userID).
+		Count(&owned).Error; err != nil {
+		return nil, err
+	}
+	if owned == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var rows []struct {
+		Class string
+		Count int
+	}
+	err := r.db.Model(&model.Link{}).
+		Select(`CASE
+			WHEN status_code BETWEEN 200 AND 299 THEN '2xx'
+			WHEN status_code BETWEEN 300 AND 399 THEN '3xx'
+			WHEN status_code BETWEEN 400 AND 499 THEN '4xx'
+			WHEN status_code BETWEEN 500 AND 599 THEN '5xx'
+			ELSE 'unreachable'
+		END AS class, COUNT(*) AS count`).
+		Where("url_id = ?", urlID).
+		Group("class").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &model.LinkStatusSummaryDTO{URLID: urlID}
+	for _, row := range rows {
+		switch row.Class {
+		case "2xx":
+			summary.Status2xx = row.Count
+		case "3xx":
+			summary.Status3xx = row.Count
+		case "4xx":
+			summary.Status4xx = row.Count
+		case "5xx":
+			summary.Status5xx = row.Count
+		default:
+			summary.Unreachable += row.Count
+		}
+		summary.Total += row.Count
+	}
+	return summary, nil
+}
+
+// LatestRuns returns the hrefs found by each of the n latest crawls of a URL
+// owned by userID, newest first. It returns gorm.ErrRecordNotFound when the
+// URL does not belong to the user.
+func (r *linkRepo) LatestRuns(urlID, userID uint, n int) ([]model.LinkRun, error) {
+	var owned int64
+	if err := r.db.Model(&model.URL{}).
+		Where("id = ? AND user_id = ?", urlID, userID).
+		Count(&owned).Error; err != nil {
+		return nil, err
+	}
+	if owned == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var runIDs []uint
+	if err := r.db.Model(&model.AnalysisResult{}).
+		Where("url_id = ?", urlID).
+		Order("id DESC").
+		Limit(n).
+		Pluck("id", &runIDs).Error; err != nil {
+		return nil, err
+	}
+	runs := make([]model.LinkRun, len(runIDs))
+	if len(runIDs) == 0 {
+		return runs, nil
+	}
+
+	var rows []struct {
+		AnalysisResultID uint
+		Href             string
+	}
+	if err := r.db.Model(&model.Link{}).
+		Select("analysis_result_id, href").
+		Where("analysis_result_id IN ?", runIDs).
+		Order("id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	index := make(map[uint]int, len(runIDs))
+	for i, id := range runIDs {
+		runs[i].AnalysisResultID = id
+		index[id] = i
+	}
+	for _, row := range rows {
+		i := index[row.AnalysisResultID]
+		runs[i].Hrefs = append(runs[i].Hrefs, row.Href)
+	}
+	return runs, nil
+}
+
+// linkHost extracts the lower-cased host of links.href, dropping the scheme,
+// path, query, fragment and port.
+const linkHost = `LOWER(SUBSTRING_INDEX(SUBSTRING_INDEX(SUBSTRING_INDEX(SUBSTRING_INDEX(
+	SUBSTRING_INDEX(links.href, '://', -1), '/', 1), '?', 1), '#', 1), ':', 1))`
+
+// likeEscaper escapes the LIKE wildcards and MySQL's default escape
+// character, so a value is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// domainLinks selects the external links, of any user, to domain or one of
+// its subdomains.
+func (r *linkRepo) domainLinks(domain string) *gorm.DB {
+	return r.db.Model(&model.Link{}).
+		Joins("JOIN urls ON urls.id = links.url_id AND urls.deleted_at IS NULL").
+		Where("links.is_external = ?", true).
+		Where(linkHost+" = ? OR "+linkHost+" LIKE ?", domain, "%."+likeEscaper.Replace(domain))
+}
+
+// DomainImpact counts the links to domain and the URLs and users they belong
+// to. AffectedURLs is left for the caller to fill.
+func (r *linkRepo) DomainImpact(domain string) (*model.DomainImpactDTO, error) {
+	var totals struct {
+		Links int `gorm:"column:links"`
+		URLs  int `gorm:"column:urls"`
+		Users int `gorm:"column:users"`
+	}
+	err := r.domainLinks(domain).
+		Select("COUNT(*) AS links, COUNT(DISTINCT links.url_id) AS urls, COUNT(DISTINCT urls.user_id) AS users").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	return &model.DomainImpactDTO{Domain: domain, Links: totals.Links, URLs: totals.URLs, Users: totals.Users}, nil
+}
+
+// ListDomainImpact pages through the URLs linking to domain, most links first.
+func (r *linkRepo) ListDomainImpact(domain string, p Pagination) ([]model.DomainImpactURLDTO, error) {
+	var urls []model.DomainImpactURLDTO
+	err := r.domainLinks(domain).
+		Select("links.url_id, urls.user_id, urls.original_url, COUNT(*) AS links").
+		Group("links.url_id, urls.user_id, urls.original_url").
+		Order("links DESC, links.url_id").
+		Limit(p.Limit()).
+		Offset(p.Offset()).
+		Scan(&urls).Error
+	return urls, err
+}
+
+// TopBrokenDomains ranks the hosts of broken (4xx or 5xx) external links, of
+// any user, by how many such links point at them.
+func (r *linkRepo) TopBrokenDomains(limit int) ([]model.BrokenDomainDTO, error) {
+	var domains []model.BrokenDomainDTO
+	err := r.db.Model(&model.Link{}).
+		Joins("JOIN urls ON urls.id = links.url_id AND urls.deleted_at IS NULL").
+		Where("links.is_external = ?", true).
+		Where("links.status_code BETWEEN 400 AND 599").
+		Select(linkHost + " AS domain, COUNT(*) AS broken_links, COUNT(DISTINCT links.url_id) AS urls, COUNT(DISTINCT urls.user_id) AS users").
+		Group("domain").
+		Order("broken_links DESC, domain").
+		Limit(limit).
+		Scan(&domains).Error
+	return domains, err
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+type NotificationRepository interface {
+	FindByUser(userID uint) (*model.NotificationPrefs, error)
+	Save(prefs *model.NotificationPrefs) error
+}
+
+type notificationRepo struct{ db *gorm.DB }
+
+func NewNotificationRepo(db *gorm.DB) NotificationRepository {
+	return &notificationRepo{db: db}
+}
+
+// FindByUser returns gorm.ErrRecordNotFound when the user has no stored preferences.
+func (r *notificationRepo) FindByUser(userID uint) (*model.NotificationPrefs, error) {
+	var prefs model.NotificationPrefs
+	if err := r.db.Where("user_id = ?", userID).First(&prefs).Error; err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// Save inserts the preferences or replaces the user's existing row.
+func (r *notificationRepo) Save(prefs *model.NotificationPrefs) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(prefs).Error
+}
@@ -1,5 +1,13 @@
 package repository
 
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type Pagination struct {
 	Page     int
 	PageSize int
@@ -18,3 +26,54 @@ func (p Pagination) Limit() int {
 	}
 	return p.PageSize
 }
+
+// ErrInvalidCursor is returned for a cursor token that was not issued by a
+// cursor listing.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor pages through a listing by position rather than by offset, so deep
+// pages cost as little as the first. After is the opaque token returned with
+// the previous page; empty starts from the beginning.
+type Cursor struct {
+	After string
+	Size  int
+}
+
+func (c Cursor) Limit() int {
+	if c.Size <= 0 {
+		return 10
+	}
+	return c.Size
+}
+
+// cursorKey is the position a cursor token encodes: the creation time and id
+// of the last row of a page.
+type cursorKey struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+func encodeCursor(k cursorKey) string {
+	raw := strconv.FormatInt(k.CreatedAt.UnixNano(), 10) + ":" + strconv.FormatUint(uint64(k.ID), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(token string) (cursorKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursorKey{}, ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return cursorKey{}, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return cursorKey{}, ErrInvalidCursor
+	}
+	i, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || i == 0 {
+		return cursorKey{}, ErrInvalidCursor
+	}
+	return cursorKey{CreatedAt: time.Unix(0, n).UTC(), ID: uint(i)}, nil
+}
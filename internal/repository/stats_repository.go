@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+type StatsRepository interface {
+	System(since time.Time) (*model.SystemStatsDTO, error)
+	RecentCrawlDuration(limit int) (time.Duration, int, error)
+}
+
+type statsRepo struct{ db *gorm.DB }
+
+func NewStatsRepo(db *gorm.DB) StatsRepository {
+	return &statsRepo{db: db}
+}
+
+// System counts users, URLs and the analyses created since the given time.
+// Crawled URLs are those in a terminal status (done, error or skipped).
+func (r *statsRepo) System(since time.Time) (*model.SystemStatsDTO, error) {
+	var users, crawls int64
+	if err := r.db.Model(&model.User{}).Count(&users).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Model(&model.AnalysisResult{}).
+		Where("created_at >= ?", since).
+		Count(&crawls).Error; err != nil {
+		return nil, err
+	}
+
+	var urls struct {
+		Total   int
+		Crawled int
+		Errored int
+	}
+	err := r.db.Model(&model.URL{}).
+		Select(`COUNT(*) AS total,
+			COALESCE(SUM(CASE WHEN status IN (?, ?, ?) THEN 1 ELSE 0 END), 0) AS crawled,
+			COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS errored`,
+			model.StatusDone, model.StatusError, model.StatusSkipped, model.StatusError).
+		Scan(&urls).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.SystemStatsDTO{
+		TotalUsers:  int(users),
+		TotalURLs:   urls.Total,
+		Crawls24h:   int(crawls),
+		CrawledURLs: urls.Crawled,
+		ErroredURLs: urls.Errored,
+	}, nil
+}
+
+// RecentCrawlDuration averages the duration of the last limit timed crawls
+// and reports how many crawls the average covers.
+func (r *statsRepo) RecentCrawlDuration(limit int) (time.Duration, int, error) {
+	recent := r.db.Model(&model.AnalysisResult{}).
+		Select("crawl_duration_ms").
+		Where("crawl_duration_ms IS NOT NULL").
+		Order("id DESC").
+		Limit(limit)
+
+	var row struct {
+		AvgMs   float64
+		Samples int
+	}
+	err := r.db.Table("(?) AS recent", recent).
+		Select("COALESCE(AVG(crawl_duration_ms), 0) AS avg_ms, COUNT(*) AS samples").
+		Scan(&row).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	return time.Duration(row.AvgMs * float64(time.Millisecond)), row.Samples, nil
+}
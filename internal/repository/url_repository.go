@@ -4,47 +4,181 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 )
 
 type URLRepository interface {
 	Create(u *model.URL) error
+	CreateBatch(urls []*model.URL) error
 	FindByID(id uint) (*model.URL, error)
-	CountByUser(userID uint) (int, error)
-	ListByUser(userID uint, p Pagination) ([]model.URL, error)
+	CountByUser(userID uint, f URLFilter) (int, error)
+	CountByUserAndStatus(userID uint, statuses ...string) (int, error)
+	ListByUser(userID uint, f URLFilter, p Pagination) ([]model.URL, error)
+	ListByUserCursor(userID uint, f URLFilter, c Cursor) ([]model.URL, string, error)
 	Update(u *model.URL) error
 	Delete(id uint) error
 	UpdateStatus(id uint, status string) error
+	ResetStuck(runningBefore time.Time, status string) ([]uint, error)
 	SaveResults(id uint, res *model.AnalysisResult, links []model.Link) error
 	Results(id uint) (*model.URL, error)
 	ResultsWithDetails(id uint) (*model.URL, []*model.AnalysisResult, []*model.Link, error)
+	Merge(srcID, dstID uint) error
+	FindExisting(userID uint, urls []string) ([]string, error)
+	ListUncrawled(userID uint, p Pagination) ([]model.URL, error)
+	CountUncrawled(userID uint) (int, error)
+	DeleteErrored(userID uint) (int, error)
+	FindOwnedIDs(userID uint, ids []uint) ([]uint, error)
+	DeleteByIDs(ids []uint) (int, error)
+	ListTagReport(userID uint, tag string, p Pagination) ([]model.TagReportItemDTO, error)
+	CountByTag(userID uint, tag string) (int, error)
+	TagSummary(userID uint, tag string) (*model.TagSummaryDTO, error)
+	FindIDsByTag(userID uint, tag string) ([]uint, error)
+	FindDeleted(id uint) (*model.URL, error)
+	ListDeletedByUser(userID uint, p Pagination) ([]model.URL, error)
+	CountDeletedByUser(userID uint) (int, error)
+	Restore(id uint) error
+	Purge(id uint) ([]string, error)
+	PurgeOlderThan(d time.Duration) (int, []string, error)
+}
+
+// URLFilter narrows and orders the URL listing; empty fields are not applied.
+type URLFilter struct {
+	Status string
+	Search string // substring of the original URL
+	// StatusCode keeps URLs whose latest analysis result got this HTTP
+	// status; zero does not filter.
+	StatusCode int
+	// Sort names a column of urlSortColumns, prefixed with "-" for
+	// descending order. Without it rows come in the database's order.
+	Sort string
+}
+
+// urlSortColumns are the columns the URL listing may be sorted by. Sort keys
+// are only ever mapped through it, never put into SQL as given.
+var urlSortColumns = map[string]string{
+	"id":           "id",
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+	"original_url": "original_url",
+	"status":       "status",
+}
+
+// ErrInvalidURLFilter is returned for a URLFilter with an unknown status or
+// sort key.
+var ErrInvalidURLFilter = errors.New("invalid url filter")
+
+// Validate reports whether f only uses known statuses, valid HTTP status
+// codes and known sort keys.
+func (f URLFilter) Validate() error {
+	switch f.Status {
+	case "", model.StatusQueued, model.StatusRunning, model.StatusDone,
+		model.StatusError, model.StatusStopped, model.StatusSkipped:
+	default:
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidURLFilter, f.Status)
+	}
+	if f.StatusCode != 0 && (f.StatusCode < 100 || f.StatusCode > 599) {
+		return fmt.Errorf("%w: status code %d out of range", ErrInvalidURLFilter, f.StatusCode)
+	}
+	if f.Sort != "" {
+		if _, ok := urlSortColumns[strings.TrimPrefix(f.Sort, "-")]; !ok {
+			return fmt.Errorf("%w: cannot sort by %q", ErrInvalidURLFilter, f.Sort)
+		}
+	}
+	return nil
 }
 
 type urlRepo struct {
-	db *gorm.DB
+	db    *gorm.DB
+	retry DeadlockRetry
+}
+
+// URLRepoOptions configures a URL repository. NewURLRepo uses
+// DefaultDeadlockRetry; the zero value never retries.
+type URLRepoOptions struct {
+	// DeadlockRetry controls how status updates and result saves are retried
+	// when MySQL reports lock contention.
+	DeadlockRetry DeadlockRetry
 }
 
 func NewURLRepo(db *gorm.DB) URLRepository {
-	return &urlRepo{db: db}
+	return NewURLRepoWithOptions(db, URLRepoOptions{DeadlockRetry: DefaultDeadlockRetry})
+}
+
+// NewURLRepoWithOptions creates a URL repository configured by opts.
+func NewURLRepoWithOptions(db *gorm.DB, opts URLRepoOptions) URLRepository {
+	return &urlRepo{db: db, retry: opts.DeadlockRetry}
 }
 
-func (r *urlRepo) CountByUser(userID uint) (int, error) {
+func (r *urlRepo) CountByUser(userID uint, f URLFilter) (int, error) {
+	q, err := r.userURLs(userID, f)
+	if err != nil {
+		return 0, err
+	}
 	var count int64
-	result := r.db.Model(&model.URL{}).Where("user_id = ?", userID).Count(&count)
+	result := q.Count(&count)
 	return int(count), result.Error
 }
+
+// CountByUserAndStatus counts the URLs of userID whose status is one of
+// statuses; without statuses every URL of the user is counted.
+func (r *urlRepo) CountByUserAndStatus(userID uint, statuses ...string) (int, error) {
+	q := r.db.Model(&model.URL{}).Where("user_id = ?", userID)
+	if len(statuses) > 0 {
+		q = q.Where("status IN ?", statuses)
+	}
+	var count int64
+	err := q.Count(&count).Error
+	return int(count), err
+}
+
+// userURLs selects the URLs of userID matching f, in the order f asks for.
+func (r *urlRepo) userURLs(userID uint, f URLFilter) (*gorm.DB, error) {
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	q := r.db.Model(&model.URL{}).Where("user_id = ?", userID)
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+	if f.Search != "" {
+		q = q.Where("original_url LIKE ?", "%"+f.Search+"%")
+	}
+	if f.StatusCode != 0 {
+		// URLs never analysed have no latest result and drop out.
+		q = q.Joins(`JOIN analysis_results latest_result ON latest_result.id = (
+			SELECT MAX(ar.id) FROM analysis_results ar
+			 WHERE ar.url_id = urls.id AND ar.deleted_at IS NULL)`).
+			Where("latest_result.status_code = ?", f.StatusCode)
+	}
+	return q, nil
+}
 func (r *urlRepo) Create(u *model.URL) error {
 	return r.db.Create(u).Error
 }
 
+// CreateBatch inserts urls in a single transaction; either all of them are
+// created or none is.
+func (r *urlRepo) CreateBatch(urls []*model.URL) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(urls, 500).Error
+	})
+}
+
 func (r *urlRepo) FindByID(id uint) (*model.URL, error) {
 	var u model.URL
 	if err := r.db.
 		Preload("AnalysisResults").
 		Preload("Links").
+		Preload("Tags").
 		First(&u, id).
 		Error; err != nil {
 		return nil, err
@@ -52,16 +186,64 @@ func (r *urlRepo) FindByID(id uint) (*model.URL, error) {
 	return &u, nil
 }
 
-func (r *urlRepo) ListByUser(userID uint, p Pagination) ([]model.URL, error) {
+func (r *urlRepo) ListByUser(userID uint, f URLFilter, p Pagination) ([]model.URL, error) {
+	q, err := r.userURLs(userID, f)
+	if err != nil {
+		return nil, err
+	}
+	if f.Sort != "" {
+		column := urlSortColumns[strings.TrimPrefix(f.Sort, "-")]
+		q = q.Order(clause.OrderByColumn{
+			Column: clause.Column{Table: clause.CurrentTable, Name: column},
+			Desc:   strings.HasPrefix(f.Sort, "-"),
+		})
+	}
 	var urls []model.URL
-	err := r.db.
-		Where("user_id = ?", userID).
+	err = q.
+		Preload("Tags").
 		Limit(p.Limit()).
 		Offset(p.Offset()).
 		Find(&urls).Error
 	return urls, err
 }
 
+// ListByUserCursor returns the user's URLs after c, newest first, with the
+// token of the next page; it is empty on the last page. f.Sort cannot be
+// used, since the cursor fixes the order.
+func (r *urlRepo) ListByUserCursor(userID uint, f URLFilter, c Cursor) ([]model.URL, string, error) {
+	if f.Sort != "" {
+		return nil, "", fmt.Errorf("%w: cannot sort a cursor listing", ErrInvalidURLFilter)
+	}
+	q, err := r.userURLs(userID, f)
+	if err != nil {
+		return nil, "", err
+	}
+	if c.After != "" {
+		after, err := decodeCursor(c.After)
+		if err != nil {
+			return nil, "", err
+		}
+		q = q.Where("urls.created_at < ? OR (urls.created_at = ? AND urls.id < ?)", after.CreatedAt, after.CreatedAt, after.ID)
+	}
+
+	var urls []model.URL
+	// One row more than asked for tells whether another page follows.
+	err = q.
+		Preload("Tags").
+		Order("urls.created_at DESC, urls.id DESC").
+		Limit(c.Limit() + 1).
+		Find(&urls).Error
+	if err != nil {
+		return nil, "", err
+	}
+	if len(urls) <= c.Limit() {
+		return urls, "", nil
+	}
+	urls = urls[:c.Limit()]
+	last := urls[len(urls)-1]
+	return urls, encodeCursor(cursorKey{CreatedAt: last.CreatedAt, ID: last.ID}), nil
+}
+
 func (r *urlRepo) Update(u *model.URL) error {
 	return r.db.Save(u).Error
 }
@@ -75,25 +257,200 @@ func (r *urlRepo) Delete(id uint) error {
 }
 
 func (r *urlRepo) UpdateStatus(id uint, status string) error {
-	return r.db.
-		Model(&model.URL{}).
-		Where("id = ?", id).
-		Update("status", status).Error
+	return r.retry.run(func() error {
+		return r.db.
+			Model(&model.URL{}).
+			Where("id = ?", id).
+			Update("status", status).Error
+	})
+}
+
+// ResetStuck moves every URL that has been running since before
+// runningBefore to status and returns their IDs. The rows are locked while
+// they are reset, so a crawl finishing at the same time is not overwritten.
+func (r *urlRepo) ResetStuck(runningBefore time.Time, status string) ([]uint, error) {
+	var ids []uint
+	err := r.retry.run(func() error {
+		ids = nil
+		return r.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&model.URL{}).
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("status = ? AND updated_at < ?", model.StatusRunning, runningBefore).
+				Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			if len(ids) == 0 {
+				return nil
+			}
+			return tx.Model(&model.URL{}).
+				Where("id IN ?", ids).
+				Update("status", status).Error
+		})
+	})
+	return ids, err
 }
 
 func (r *urlRepo) SaveResults(id uint, res *model.AnalysisResult, links []model.Link) error {
+	return r.retry.run(func() error {
+		return r.db.Transaction(func(tx *gorm.DB) error {
+			// IDs may have been assigned by an attempt that was rolled back.
+			res.ID = 0
+			res.URLID = id
+			if res.RunID != nil {
+				if err := saveRun(tx, res); err != nil {
+					return err
+				}
+			} else if err := tx.Create(res).Error; err != nil {
+				return err
+			}
+			for i := range links {
+				links[i].ID = 0
+				links[i].URLID = id
+				links[i].AnalysisResultID = &res.ID
+			}
+			return tx.CreateInBatches(&links, 500).Error
+		})
+	})
+}
+
+// saveRun inserts res or, when a result with the same run ID exists, overwrites
+// it and drops the links saved with it, so saving a run twice leaves one set of rows.
+func saveRun(tx *gorm.DB, res *model.AnalysisResult) error {
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "run_id"}},
+		UpdateAll: true,
+	}).Create(res).Error; err != nil {
+		return err
+	}
+	// MySQL does not report the ID of a row updated by ON DUPLICATE KEY UPDATE.
+	var stored model.AnalysisResult
+	if err := tx.Unscoped().Select("id").Where("run_id = ?", *res.RunID).First(&stored).Error; err != nil {
+		return err
+	}
+	res.ID = stored.ID
+	return tx.Unscoped().Where("analysis_result_id = ?", res.ID).Delete(&model.Link{}).Error
+}
+
+// Merge moves the analysis results and links of srcID onto dstID and deletes
+// srcID, all in one transaction.
+func (r *urlRepo) Merge(srcID, dstID uint) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		res.URLID = id
-		if err := tx.Create(res).Error; err != nil {
+		if err := tx.Model(&model.AnalysisResult{}).
+			Where("url_id = ?", srcID).
+			Update("url_id", dstID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Link{}).
+			Where("url_id = ?", srcID).
+			Update("url_id", dstID).Error; err != nil {
 			return err
 		}
-		for i := range links {
-			links[i].URLID = id
+		res := tx.Delete(&model.URL{}, srcID)
+		if res.Error != nil {
+			return res.Error
 		}
-		return tx.CreateInBatches(&links, 500).Error
+		if res.RowsAffected == 0 {
+			return errors.New("url not found")
+		}
+		return nil
 	})
 }
 
+// FindExisting returns those of urls the user has already added.
+func (r *urlRepo) FindExisting(userID uint, urls []string) ([]string, error) {
+	var existing []string
+	if len(urls) == 0 {
+		return existing, nil
+	}
+	err := r.db.Model(&model.URL{}).
+		Where("user_id = ? AND original_url IN ?", userID, urls).
+		Pluck("original_url", &existing).Error
+	return existing, err
+}
+
+// FindOwnedIDs returns those of ids that exist and belong to userID.
+func (r *urlRepo) FindOwnedIDs(userID uint, ids []uint) ([]uint, error) {
+	var owned []uint
+	if len(ids) == 0 {
+		return owned, nil
+	}
+	err := r.db.Model(&model.URL{}).
+		Where("user_id = ? AND id IN ?", userID, ids).
+		Pluck("id", &owned).Error
+	return owned, err
+}
+
+// taggedURLs selects the URLs of userID tagged with tag.
+func (r *urlRepo) taggedURLs(userID uint, tag string) *gorm.DB {
+	return r.db.Model(&model.URL{}).
+		Joins("JOIN url_tags ON url_tags.url_id = urls.id AND url_tags.name = ?", tag).
+		Where("urls.user_id = ?", userID)
+}
+
+// latestAnalysisJoin joins each URL's latest analysis as ar, if it has one.
+const latestAnalysisJoin = `LEFT JOIN analysis_results ar ON ar.id = (
+			SELECT MAX(latest.id) FROM analysis_results latest
+			 WHERE latest.url_id = urls.id AND latest.deleted_at IS NULL)`
+
+// ListTagReport returns a page of the user's URLs tagged with tag, each with
+// a summary of its latest analysis, in one query.
+func (r *urlRepo) ListTagReport(userID uint, tag string, p Pagination) ([]model.TagReportItemDTO, error) {
+	var items []model.TagReportItemDTO
+	err := r.taggedURLs(userID, tag).
+		Joins(latestAnalysisJoin).
+		Select(`urls.id AS url_id, urls.original_url, urls.status,
+			COALESCE(ar.title, '') AS title,
+			COALESCE(ar.broken_link_count, 0) AS broken_link_count,
+			ar.created_at AS analyzed_at`).
+		Order("urls.id").
+		Limit(p.Limit()).
+		Offset(p.Offset()).
+		Scan(&items).Error
+	return items, err
+}
+
+func (r *urlRepo) CountByTag(userID uint, tag string) (int, error) {
+	var count int64
+	err := r.taggedURLs(userID, tag).Count(&count).Error
+	return int(count), err
+}
+
+// FindIDsByTag returns the IDs of the user's URLs tagged with tag.
+func (r *urlRepo) FindIDsByTag(userID uint, tag string) ([]uint, error) {
+	var ids []uint
+	err := r.taggedURLs(userID, tag).
+		Order("urls.id").
+		Pluck("urls.id", &ids).Error
+	return ids, err
+}
+
+// TagSummary counts the user's URLs tagged with tag per status and adds up
+// the broken links of their latest analyses, in one query.
+func (r *urlRepo) TagSummary(userID uint, tag string) (*model.TagSummaryDTO, error) {
+	var rows []struct {
+		Status      string
+		URLs        int
+		BrokenLinks int
+	}
+	err := r.taggedURLs(userID, tag).
+		Joins(latestAnalysisJoin).
+		Select(`urls.status AS status, COUNT(*) AS urls,
+			COALESCE(SUM(ar.broken_link_count), 0) AS broken_links`).
+		Group("urls.status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &model.TagSummaryDTO{Tag: tag, ByStatus: make(map[string]int, len(rows))}
+	for _, row := range rows {
+		summary.TotalURLs += row.URLs
+		summary.BrokenLinks += row.BrokenLinks
+		summary.ByStatus[row.Status] = row.URLs
+	}
+	return summary, nil
+}
+
 func (r *urlRepo) Results(id uint) (*model.URL, error) {
 	var u model.URL
 	err := r.db.
@@ -124,17 +481,31 @@ func (r *urlRepo) ResultsWithDetails(id uint) (*model.URL, []*model.AnalysisResu
                    'id',                  ar.id,
                    'url_id',              ar.url_id,
                    'html_version',        ar.html_version,
+                   'content_type',        ar.content_type,
+                   'status_code',         ar.status_code,
+                   'response_time_ms',    ar.response_time_ms,
+                   'page_size_bytes',     ar.page_size_bytes,
                    'title',               ar.title,
+                   'meta_description',    ar.meta_description,
+                   'language',            ar.language,
                    'h1_count',            ar.h1_count,
                    'h2_count',            ar.h2_count,
                    'h3_count',            ar.h3_count,
                    'h4_count',            ar.h4_count,
                    'h5_count',            ar.h5_count,
                    'h6_count',            ar.h6_count,
+                   'image_count',         ar.image_count,
+                   'images_missing_alt',  ar.images_missing_alt,
                    'has_login_form',      IF(ar.has_login_form = 1, CAST('true' AS JSON), CAST('false' AS JSON)),
                    'internal_link_count', ar.internal_link_count,
                    'external_link_count', ar.external_link_count,
                    'broken_link_count',   ar.broken_link_count,
+                   'redirect_chain',      ar.redirect_chain,
+                   'final_url',           ar.final_url,
+                   'canonical_url',       ar.canonical_url,
+                   'canonical_mismatch',  IF(ar.canonical_mismatch = 1, CAST('true' AS JSON), CAST('false' AS JSON)),
+                   'crawl_duration_ms',   ar.crawl_duration_ms,
+                   'tls_verify_skipped',  IF(ar.tls_verify_skipped = 1, CAST('true' AS JSON), CAST('false' AS JSON)),
                    'created_at',          DATE_FORMAT(ar.created_at, '%Y-%m-%dT%H:%i:%s.%fZ'),
                    'updated_at',          DATE_FORMAT(ar.updated_at, '%Y-%m-%dT%H:%i:%s.%fZ')
                  )
@@ -151,7 +522,8 @@ func (r *urlRepo) ResultsWithDetails(id uint) (*model.URL, []*model.AnalysisResu
                    'url_id',      l.url_id,
                    'href',        l.href,
                    'is_external', IF(l.is_external = 1, CAST('true' AS JSON), CAST('false' AS JSON)),
-                   'status_code', l.status_code
+                   'status_code', l.status_code,
+                   'occurrence_count', l.occurrence_count
                  )
                )
         FROM   links l
@@ -177,3 +549,175 @@ WHERE u.id = ?`
 
 	return &result.URL, result.AnalysisResults, result.Links, nil
 }
+
+// uncrawled selects the user's URLs that have no analysis result. Results are
+// only saved for successful crawls, so these never succeeded.
+func (r *urlRepo) uncrawled(userID uint) *gorm.DB {
+	return r.db.Model(&model.URL{}).
+		Where("user_id = ?", userID).
+		Where(`NOT EXISTS (SELECT 1 FROM analysis_results ar
+			WHERE ar.url_id = urls.id AND ar.deleted_at IS NULL)`)
+}
+
+func (r *urlRepo) ListUncrawled(userID uint, p Pagination) ([]model.URL, error) {
+	var urls []model.URL
+	err := r.uncrawled(userID).
+		Order("urls.id").
+		Limit(p.Limit()).
+		Offset(p.Offset()).
+		Find(&urls).Error
+	return urls, err
+}
+
+func (r *urlRepo) CountUncrawled(userID uint) (int, error) {
+	var count int64
+	err := r.uncrawled(userID).Count(&count).Error
+	return int(count), err
+}
+
+// deleted selects the user's soft-deleted URLs.
+func (r *urlRepo) deleted(userID uint) *gorm.DB {
+	return r.db.Unscoped().Model(&model.URL{}).
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+}
+
+// FindDeleted returns URL id if it is soft-deleted; live URLs are not found.
+func (r *urlRepo) FindDeleted(id uint) (*model.URL, error) {
+	var u model.URL
+	if err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&u).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// ListDeletedByUser pages through the user's soft-deleted URLs, most
+// recently deleted first.
+func (r *urlRepo) ListDeletedByUser(userID uint, p Pagination) ([]model.URL, error) {
+	var urls []model.URL
+	err := r.deleted(userID).
+		Order("deleted_at DESC, id").
+		Limit(p.Limit()).
+		Offset(p.Offset()).
+		Find(&urls).Error
+	return urls, err
+}
+
+func (r *urlRepo) CountDeletedByUser(userID uint) (int, error) {
+	var count int64
+	err := r.deleted(userID).Count(&count).Error
+	return int(count), err
+}
+
+// Restore undoes the soft delete of URL id, bringing back its analysis
+// results and links with it. It fails with gorm.ErrRecordNotFound unless id
+// is deleted.
+func (r *urlRepo) Restore(id uint) error {
+	res := r.db.Unscoped().Model(&model.URL{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Purge permanently deletes URL id, deleted or not, with its links, analysis
+// results and tags. It returns the blob keys of the purged results' stored
+// raw HTML, which the caller removes from the blob store.
+func (r *urlRepo) Purge(id uint) ([]string, error) {
+	var rawHTMLKeys []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		n, keys, err := purgeURLs(tx, []uint{id})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		rawHTMLKeys = keys
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rawHTMLKeys, nil
+}
+
+// PurgeOlderThan permanently deletes the URLs soft-deleted more than d ago,
+// like Purge, and returns how many there were and their raw HTML blob keys.
+func (r *urlRepo) PurgeOlderThan(d time.Duration) (int, []string, error) {
+	var purged int64
+	var rawHTMLKeys []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Unscoped().Model(&model.URL{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", time.Now().Add(-d)).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		var err error
+		purged, rawHTMLKeys, err = purgeURLs(tx, ids)
+		return err
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return int(purged), rawHTMLKeys, nil
+}
+
+// purgeURLs hard-deletes the URLs ids with everything stored for them and
+// returns how many URLs it removed along with the blob keys of their
+// results' raw HTML. The caller holds a transaction.
+func purgeURLs(tx *gorm.DB, ids []uint) (int64, []string, error) {
+	if len(ids) == 0 {
+		return 0, nil, nil
+	}
+	var rawHTMLKeys []string
+	if err := tx.Unscoped().Model(&model.AnalysisResult{}).
+		Where("url_id IN ? AND raw_html_key <> ''", ids).
+		Pluck("raw_html_key", &rawHTMLKeys).Error; err != nil {
+		return 0, nil, err
+	}
+	if err := tx.Unscoped().Where("url_id IN ?", ids).Delete(&model.Link{}).Error; err != nil {
+		return 0, nil, err
+	}
+	if err := tx.Unscoped().Where("url_id IN ?", ids).Delete(&model.AnalysisResult{}).Error; err != nil {
+		return 0, nil, err
+	}
+	if err := tx.Where("url_id IN ?", ids).Delete(&model.URLTag{}).Error; err != nil {
+		return 0, nil, err
+	}
+	res := tx.Unscoped().Delete(&model.URL{}, ids)
+	if res.Error != nil {
+		return 0, nil, res.Error
+	}
+	return res.RowsAffected, rawHTMLKeys, nil
+}
+
+// DeleteByIDs soft-deletes the URLs with the given ids and returns how many
+// were deleted.
+func (r *urlRepo) DeleteByIDs(ids []uint) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	res := r.db.Delete(&model.URL{}, ids)
+	return int(res.RowsAffected), res.Error
+}
+
+// DeleteErrored soft-deletes all of the user's URLs in error status, in one
+// transaction, and returns how many were deleted.
+func (r *urlRepo) DeleteErrored(userID uint) (int, error) {
+	var deleted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("user_id = ? AND status = ?", userID, model.StatusError).Delete(&model.URL{})
+		deleted = res.RowsAffected
+		return res.Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(deleted), nil
+}
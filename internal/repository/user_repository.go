@@ -12,10 +12,25 @@ import (
 type UserRepository interface {
 	Create(u *model.User) error
 	Update(id uint, u *model.User) error
+	SetEmailVerified(id uint, verified bool) error
 	FindByID(id uint) (*model.User, error)
 	FindByEmail(email string) (*model.User, error)
+	UsernameTaken(username string, excludeID uint, ignoreCase bool) (bool, error)
 	Search(email, role, username string, p Pagination) ([]model.User, error)
 	Delete(id uint) error
+	Erase(id uint) (*AccountErasure, error)
+}
+
+// AccountErasure describes what Erase removed.
+type AccountErasure struct {
+	URLs            int64
+	AnalysisResults int64
+	Links           int64
+	Tags            int64
+	TokensRevoked   int
+	// RawHTMLKeys are the blob keys of the erased analysis results' stored
+	// bodies; the blobs themselves are not in the database.
+	RawHTMLKeys []string
 }
 
 // userRepo is the GORM implementation of UserRepository.
@@ -36,6 +51,12 @@ func (r *userRepo) Update(id uint, u *model.User) error {
 	return r.db.Model(&model.User{ID: id}).Updates(u).Error
 }
 
+// SetEmailVerified stores whether the user's email is verified. Unlike
+// Update it also writes false.
+func (r *userRepo) SetEmailVerified(id uint, verified bool) error {
+	return r.db.Model(&model.User{ID: id}).Update("email_verified", verified).Error
+}
+
 func (r *userRepo) FindByID(id uint) (*model.User, error) {
 	var u model.User
 	if err := r.db.First(&u, id).Error; err != nil {
@@ -52,6 +73,23 @@ func (r *userRepo) FindByEmail(email string) (*model.User, error) {
 	return &u, nil
 }
 
+// UsernameTaken reports whether another user (any ID but excludeID) already
+// has the username. Soft-deleted users count too, since the unique index
+// still holds their rows.
+func (r *userRepo) UsernameTaken(username string, excludeID uint, ignoreCase bool) (bool, error) {
+	query := r.db.Unscoped().Model(&model.User{}).Where("id <> ?", excludeID)
+	if ignoreCase {
+		query = query.Where("LOWER(username) = LOWER(?)", username)
+	} else {
+		query = query.Where("username = ? COLLATE utf8mb4_bin", username)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
 func (r *userRepo) Search(email, role, username string, p Pagination) ([]model.User, error) {
 	var users []model.User
 	query := r.db
@@ -80,3 +118,73 @@ func (r *userRepo) Delete(id uint) error {
 	}
 	return res.Error
 }
+
+// Erase permanently deletes the user with everything stored for them in
+// one transaction: URLs with their tags, analysis results and links, and
+// notification settings. Their issued tokens are blacklisted before the
+// records of them are dropped, so none of them authenticates afterwards.
+func (r *userRepo) Erase(id uint) (*AccountErasure, error) {
+	erased := &AccountErasure{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		urlIDs := tx.Unscoped().Model(&model.URL{}).Select("id").Where("user_id = ?", id)
+
+		if err := tx.Unscoped().Model(&model.AnalysisResult{}).
+			Where("url_id IN (?) AND raw_html_key <> ''", urlIDs).
+			Pluck("raw_html_key", &erased.RawHTMLKeys).Error; err != nil {
+			return err
+		}
+
+		res := tx.Unscoped().Where("url_id IN (?)", urlIDs).Delete(&model.Link{})
+		if res.Error != nil {
+			return res.Error
+		}
+		erased.Links = res.RowsAffected
+
+		res = tx.Unscoped().Where("url_id IN (?)", urlIDs).Delete(&model.AnalysisResult{})
+		if res.Error != nil {
+			return res.Error
+		}
+		erased.AnalysisResults = res.RowsAffected
+
+		res = tx.Where("url_id IN (?)", urlIDs).Delete(&model.URLTag{})
+		if res.Error != nil {
+			return res.Error
+		}
+		erased.Tags = res.RowsAffected
+
+		res = tx.Unscoped().Where("user_id = ?", id).Delete(&model.URL{})
+		if res.Error != nil {
+			return res.Error
+		}
+		erased.URLs = res.RowsAffected
+
+		if err := tx.Where("user_id = ?", id).Delete(&model.NotificationPrefs{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&model.APIKey{}).Error; err != nil {
+			return err
+		}
+
+		revoked, err := revokeIssued(tx, id, "")
+		if err != nil {
+			return err
+		}
+		erased.TokensRevoked = revoked
+		if err := tx.Where("user_id = ?", id).Delete(&model.IssuedToken{}).Error; err != nil {
+			return err
+		}
+
+		res = tx.Unscoped().Delete(&model.User{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return erased, nil
+}
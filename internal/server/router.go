@@ -20,7 +20,7 @@ func RegisterRoutes(
 	protectedRegs []RouteRegistrar,
 ) {
 
-	r.Use(gin.Logger(), gin.Recovery())
+	r.Use(gin.Recovery())
 
 	public := r.Group("/api/v1")
 	for _, reg := range publicRegs {
@@ -1,21 +1,41 @@
 package service
 
 import (
+	"context"
+	"io"
+	"sort"
+
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
+	"github.com/fuzumoe/linkTorch-api/internal/storage"
 )
 
 type AnalysisService interface {
 	Record(res *model.AnalysisResult, links []model.Link) error
 	List(urlID uint, p repository.Pagination) ([]*model.AnalysisResultDTO, error)
+	HTMLVersionDistribution(userID uint) ([]model.HTMLVersionCountDTO, error)
+	Timings(urlID, userID uint) (*model.CrawlTimingsDTO, error)
+	RawHTML(ctx context.Context, urlID, userID uint) (io.ReadCloser, error)
+	Recent(userID uint, limit int) ([]model.RecentCrawlDTO, error)
+	ResponseTimes(urlID, userID uint) (*model.ResponseTimesDTO, error)
+	Provenance(urlID, userID uint) (*model.ProvenanceDTO, error)
 }
 
+// Bounds for the number of entries Recent returns.
+const (
+	DefaultRecentLimit = 10
+	MaxRecentLimit     = 50
+)
+
 type analysisService struct {
-	repo repository.AnalysisResultRepository
+	repo  repository.AnalysisResultRepository
+	blobs storage.BlobStore
 }
 
-func NewAnalysisService(r repository.AnalysisResultRepository) AnalysisService {
-	return &analysisService{repo: r}
+// NewAnalysisService creates an analysis service. blobs holds raw page bodies;
+// when it is nil no raw HTML is available.
+func NewAnalysisService(r repository.AnalysisResultRepository, blobs storage.BlobStore) AnalysisService {
+	return &analysisService{repo: r, blobs: blobs}
 }
 
 func (s *analysisService) Record(res *model.AnalysisResult, links []model.Link) error {
@@ -33,3 +53,81 @@ func (s *analysisService) List(urlID uint, p repository.Pagination) ([]*model.An
 	}
 	return dtos, nil
 }
+
+func (s *analysisService) HTMLVersionDistribution(userID uint) ([]model.HTMLVersionCountDTO, error) {
+	counts, err := s.repo.HTMLVersionCounts(userID)
+	if err != nil {
+		return nil, err
+	}
+	if counts == nil {
+		counts = []model.HTMLVersionCountDTO{}
+	}
+	return counts, nil
+}
+
+func (s *analysisService) Timings(urlID, userID uint) (*model.CrawlTimingsDTO, error) {
+	return s.repo.Timings(urlID, userID)
+}
+
+// Provenance describes how the URL's latest result was produced.
+func (s *analysisService) Provenance(urlID, userID uint) (*model.ProvenanceDTO, error) {
+	return s.repo.LatestProvenance(urlID, userID)
+}
+
+// RawHTML opens the newest stored raw body of one of the user's URLs.
+func (s *analysisService) RawHTML(ctx context.Context, urlID, userID uint) (io.ReadCloser, error) {
+	if s.blobs == nil {
+		return nil, storage.ErrBlobNotFound
+	}
+	key, err := s.repo.LatestRawHTMLKey(urlID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.blobs.Get(ctx, key)
+}
+
+// Recent lists the user's most recently crawled URLs. A limit outside
+// 1..MaxRecentLimit is replaced by the default or the cap.
+func (s *analysisService) Recent(userID uint, limit int) ([]model.RecentCrawlDTO, error) {
+	switch {
+	case limit <= 0:
+		limit = DefaultRecentLimit
+	case limit > MaxRecentLimit:
+		limit = MaxRecentLimit
+	}
+	recent, err := s.repo.RecentByUser(userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if recent == nil {
+		recent = []model.RecentCrawlDTO{}
+	}
+	return recent, nil
+}
+
+// ResponseTimes computes percentiles over every timed crawl of the URL.
+func (s *analysisService) ResponseTimes(urlID, userID uint) (*model.ResponseTimesDTO, error) {
+	times, err := s.repo.ResponseTimes(urlID, userID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	rt := &model.ResponseTimesDTO{URLID: urlID, Runs: len(times)}
+	if len(times) > 0 {
+		rt.P50Ms = percentile(times, 50)
+		rt.P90Ms = percentile(times, 90)
+		rt.P99Ms = percentile(times, 99)
+	}
+	return rt, nil
+}
+
+// percentile returns the nearest-rank p-th percentile of the sorted, non-empty values.
+func percentile(sorted []int64, p int) *int64 {
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	v := sorted[rank-1]
+	return &v
+}
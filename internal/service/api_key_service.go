@@ -0,0 +1,169 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+)
+
+// apiKeyPrefix starts every API key, so leaked keys are easy to spot.
+const apiKeyPrefix = "ltk_"
+
+// apiKeyPrefixLen is how much of a key is stored in clear to identify it.
+const apiKeyPrefixLen = len(apiKeyPrefix) + 8
+
+// ErrAPIKeyInvalid is returned for an API key that is unknown or revoked,
+// or whose user no longer exists.
+var ErrAPIKeyInvalid = errors.New("invalid API key")
+
+// ErrAPIKeyExpired is returned for an API key past its expiry.
+var ErrAPIKeyExpired = errors.New("API key expired")
+
+// ErrAPIKeyExpiry is wrapped by the errors Create returns for an expiry the
+// policy does not allow.
+var ErrAPIKeyExpiry = errors.New("invalid API key expiry")
+
+// APIKeyService manages users' API keys and authenticates requests made
+// with them.
+type APIKeyService interface {
+	Create(userID uint, input *model.CreateAPIKeyInput) (*model.CreatedAPIKeyDTO, error)
+	List(userID uint) ([]*model.APIKeyDTO, error)
+	Revoke(userID, id uint) error
+	Authenticate(key string) (*model.UserDTO, error)
+}
+
+// APIKeyServiceOptions sets the expiry policy for new keys.
+type APIKeyServiceOptions struct {
+	MaxLifetime   time.Duration // Longest lifetime a key may be created with (0 means no limit)
+	RequireExpiry bool          // Refuse keys created without an expiry
+}
+
+type apiKeyService struct {
+	repo     repository.APIKeyRepository
+	userRepo repository.UserRepository
+	opts     APIKeyServiceOptions
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository, userRepo repository.UserRepository) APIKeyService {
+	return NewAPIKeyServiceWithOptions(repo, userRepo, APIKeyServiceOptions{})
+}
+
+// NewAPIKeyServiceWithOptions is NewAPIKeyService with an expiry policy.
+func NewAPIKeyServiceWithOptions(repo repository.APIKeyRepository, userRepo repository.UserRepository, opts APIKeyServiceOptions) APIKeyService {
+	return &apiKeyService{repo: repo, userRepo: userRepo, opts: opts}
+}
+
+// expiry applies the expiry policy to the expiry requested for a key created
+// at now. Without one, the key lives for MaxLifetime, or for ever when there
+// is no limit.
+func (s *apiKeyService) expiry(requested *time.Time, now time.Time) (*time.Time, error) {
+	if requested == nil {
+		if s.opts.RequireExpiry {
+			return nil, fmt.Errorf("%w: expires_at is required", ErrAPIKeyExpiry)
+		}
+		if s.opts.MaxLifetime > 0 {
+			at := now.Add(s.opts.MaxLifetime)
+			return &at, nil
+		}
+		return nil, nil
+	}
+	if !requested.After(now) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrAPIKeyExpiry)
+	}
+	if s.opts.MaxLifetime > 0 && requested.After(now.Add(s.opts.MaxLifetime)) {
+		return nil, fmt.Errorf("%w: keys may live at most %s", ErrAPIKeyExpiry, s.opts.MaxLifetime)
+	}
+	return requested, nil
+}
+
+// hashAPIKey returns the hex SHA-256 of key. Keys are random, so a fast
+// unsalted hash is enough to make the stored value useless to an attacker.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create returns an error wrapping ErrAPIKeyExpiry if the policy does not
+// allow input.ExpiresAt.
+func (s *apiKeyService) Create(userID uint, input *model.CreateAPIKeyInput) (*model.CreatedAPIKeyDTO, error) {
+	expiresAt, err := s.expiry(input.ExpiresAt, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	plain := apiKeyPrefix + hex.EncodeToString(secret)
+
+	key := &model.APIKey{
+		UserID:    userID,
+		Name:      input.Name,
+		KeyHash:   hashAPIKey(plain),
+		Prefix:    plain[:apiKeyPrefixLen],
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.Create(key); err != nil {
+		return nil, err
+	}
+	return &model.CreatedAPIKeyDTO{APIKeyDTO: *key.ToDTO(), Key: plain}, nil
+}
+
+func (s *apiKeyService) List(userID uint) ([]*model.APIKeyDTO, error) {
+	keys, err := s.repo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	dtos := make([]*model.APIKeyDTO, len(keys))
+	for i := range keys {
+		dtos[i] = keys[i].ToDTO()
+	}
+	return dtos, nil
+}
+
+// Revoke returns gorm.ErrRecordNotFound if the user has no key with the id.
+func (s *apiKeyService) Revoke(userID, id uint) error {
+	return s.repo.Revoke(id, userID)
+}
+
+// Authenticate returns the owner of an active key and records that the key
+// was used. Expired keys give ErrAPIKeyExpired.
+func (s *apiKeyService) Authenticate(key string) (*model.UserDTO, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, ErrAPIKeyInvalid
+	}
+	k, err := s.repo.FindActiveByHash(hashAPIKey(key))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if k.ExpiredAt(time.Now()) {
+		return nil, ErrAPIKeyExpired
+	}
+	user, err := s.userRepo.FindByID(k.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIKeyInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A failed update only loses the timestamp, so the request goes on.
+	if err := s.repo.TouchLastUsed(k.ID, time.Now()); err != nil {
+		log.Printf("[WARN] failed to record use of API key %d: %v", k.ID, err)
+	}
+	return user.ToDTO(), nil
+}
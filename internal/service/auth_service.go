@@ -17,8 +17,23 @@ var (
 	ErrTokenExpired       = errors.New("token is expired")
 	ErrTokenBlacklistFail = errors.New("failed to blacklist token")
 	ErrBlacklistCheckFail = errors.New("failed to check token blacklist")
+	// ErrTokenReused means a refresh token was presented after it had been
+	// rotated or revoked. All of the user's refresh tokens are revoked then.
+	ErrTokenReused = errors.New("refresh token reused")
 )
 
+// Token subjects tell access tokens apart from refresh, password reset and
+// email verification tokens.
+const (
+	accessTokenSubject       = "access_token"
+	refreshTokenSubject      = "refresh_token"
+	passwordResetSubject     = "password_reset"
+	emailVerificationSubject = "email_verification"
+)
+
+// defaultRefreshLifetime applies when AuthServiceOptions.RefreshLifetime is zero.
+const defaultRefreshLifetime = 7 * 24 * time.Hour
+
 // Claims defines the JWT claims.
 type Claims struct {
 	jwt.RegisteredClaims
@@ -33,6 +48,8 @@ type AuthService interface {
 	IsTokenRevoked(tokenID string) (bool, error)
 	FindUserById(userID uint) (*model.UserDTO, error)
 	Generate(userID uint) (string, error)
+	GeneratePair(userID uint) (access, refresh string, err error)
+	Refresh(refreshToken string) (access, refresh string, err error)
 	Invalidate(tokenID string) error
 	CleanupExpired() error
 }
@@ -42,14 +59,35 @@ type authService struct {
 	tokenRepo   repository.TokenRepository
 	jwtSecret   string
 	jwtLifetime time.Duration
+	refreshLife time.Duration
+	// legacyUntil ends the window in which access tokens issued before
+	// tokens carried a subject are still accepted.
+	legacyUntil time.Time
+}
+
+// AuthServiceOptions configures the optional behaviour of an auth service.
+type AuthServiceOptions struct {
+	// RefreshLifetime is how long refresh tokens stay valid; 0 means
+	// defaultRefreshLifetime.
+	RefreshLifetime time.Duration
 }
 
 func NewAuthService(userRepo repository.UserRepository, tokenRepo repository.TokenRepository, jwtSecret string, jwtLifetime time.Duration) AuthService {
+	return NewAuthServiceWithOptions(userRepo, tokenRepo, jwtSecret, jwtLifetime, AuthServiceOptions{})
+}
+
+// NewAuthServiceWithOptions creates an auth service configured by opts.
+func NewAuthServiceWithOptions(userRepo repository.UserRepository, tokenRepo repository.TokenRepository, jwtSecret string, jwtLifetime time.Duration, opts AuthServiceOptions) AuthService {
+	if opts.RefreshLifetime <= 0 {
+		opts.RefreshLifetime = defaultRefreshLifetime
+	}
 	return &authService{
 		userRepo:    userRepo,
 		tokenRepo:   tokenRepo,
 		jwtSecret:   jwtSecret,
 		jwtLifetime: jwtLifetime,
+		refreshLife: opts.RefreshLifetime,
+		legacyUntil: time.Now().Add(jwtLifetime),
 	}
 }
 
@@ -65,19 +103,27 @@ func (a *authService) AuthenticateBasic(email, password string) (*model.UserDTO,
 }
 
 func (a *authService) Validate(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(a.jwtSecret), nil
-	})
+	claims, err := a.parse(tokenString)
 	if err != nil {
-		if errors.Is(err, jwt.ErrTokenExpired) {
-			return nil, ErrTokenExpired
-		}
+		return nil, err
+	}
+	// Refresh and password reset tokens never authenticate requests. Tokens
+	// without a subject were issued as access tokens by earlier releases and
+	// are honoured until the last of them has expired.
+	switch {
+	case claims.Subject == accessTokenSubject:
+	case claims.Subject == "" && time.Now().Before(a.legacyUntil):
+	default:
 		return nil, ErrTokenInvalid
 	}
+	return claims, nil
+}
 
-	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
-		return nil, ErrTokenInvalid
+// parse verifies the signature, expiry and blacklist entry of a token of any subject.
+func (a *authService) parse(tokenString string) (*Claims, error) {
+	claims, err := a.verify(tokenString)
+	if err != nil {
+		return nil, err
 	}
 
 	revoked, err := a.IsTokenRevoked(claims.ID)
@@ -91,6 +137,30 @@ func (a *authService) Validate(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// verify checks a token's signature and expiry but not the blacklist.
+func (a *authService) verify(tokenString string) (*Claims, error) {
+	return verifyToken(a.jwtSecret, tokenString)
+}
+
+// verifyToken checks the signature and expiry of a token signed with secret.
+func verifyToken(secret, tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	})
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrTokenInvalid
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, ErrTokenInvalid
+	}
+	return claims, nil
+}
+
 func (a *authService) IsTokenRevoked(tokenID string) (bool, error) {
 	// If token ID is empty, it can't be in the blacklist.
 	if tokenID == "" {
@@ -119,36 +189,137 @@ func (a *authService) Generate(userID uint) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	access, claims, err := a.sign(userID, user, accessTokenSubject, a.jwtLifetime)
+	if err != nil {
+		return "", err
+	}
+	if err := a.tokenRepo.AddIssued(issuedRecord(claims)); err != nil {
+		return "", err
+	}
+	return access, nil
+}
+
+func (a *authService) GeneratePair(userID uint) (string, string, error) {
+	user, err := a.userRepo.FindByID(userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	access, accessClaims, err := a.sign(userID, user, accessTokenSubject, a.jwtLifetime)
+	if err != nil {
+		return "", "", err
+	}
+	refresh, refreshClaims, err := a.sign(userID, user, refreshTokenSubject, a.refreshLife)
+	if err != nil {
+		return "", "", err
+	}
+	if err := a.tokenRepo.AddIssued(issuedRecord(accessClaims), issuedRecord(refreshClaims)); err != nil {
+		return "", "", err
+	}
 
-	expirationTime := time.Now().Add(a.jwtLifetime)
+	return access, refresh, nil
+}
+
+// Refresh rotates refreshToken: it is blacklisted and a new access and
+// refresh token are returned. A refresh token that is presented again
+// afterwards is treated as stolen, and all of the user's refresh tokens
+// are revoked.
+func (a *authService) Refresh(refreshToken string) (string, string, error) {
+	claims, err := a.verify(refreshToken)
+	if err != nil {
+		return "", "", err
+	}
+	if claims.Subject != refreshTokenSubject {
+		return "", "", ErrTokenInvalid
+	}
+	revoked, err := a.IsTokenRevoked(claims.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if revoked {
+		return "", "", a.revokeReused(claims.UserID)
+	}
+
+	// Reload the user so the new tokens carry the current email and role.
+	user, err := a.userRepo.FindByID(claims.UserID)
+	if err != nil {
+		return "", "", err
+	}
+	access, accessClaims, err := a.sign(claims.UserID, user, accessTokenSubject, a.jwtLifetime)
+	if err != nil {
+		return "", "", err
+	}
+	refresh, refreshClaims, err := a.sign(claims.UserID, user, refreshTokenSubject, a.refreshLife)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = a.tokenRepo.RotateRefresh(claims.ID, claims.ExpiresAt.Time, issuedRecord(accessClaims), issuedRecord(refreshClaims))
+	if errors.Is(err, repository.ErrRefreshTokenUsed) {
+		return "", "", a.revokeReused(claims.UserID)
+	}
+	if err != nil {
+		return "", "", ErrTokenBlacklistFail
+	}
+
+	return access, refresh, nil
+}
+
+// revokeReused revokes every refresh token of userID after one was reused.
+func (a *authService) revokeReused(userID uint) error {
+	if _, err := a.tokenRepo.RevokeUserTokens(userID, refreshTokenSubject); err != nil {
+		return ErrTokenBlacklistFail
+	}
+	return ErrTokenReused
+}
+
+func issuedRecord(claims *Claims) *model.IssuedToken {
+	return &model.IssuedToken{
+		JTI:       claims.ID,
+		UserID:    claims.UserID,
+		Subject:   claims.Subject,
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+}
+
+func (a *authService) sign(userID uint, user *model.User, subject string, lifetime time.Duration) (string, *Claims, error) {
+	now := time.Now()
 	claims := &Claims{
 		UserID: userID,
 		Email:  user.Email,
 		Role:   user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(lifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
 			ID:        generateTokenID(),
+			Subject:   subject,
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(a.jwtSecret))
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return tokenString, nil
+	return tokenString, claims, nil
 }
+
 func (a *authService) Invalidate(tokenID string) error {
 
 	if tokenID == "" {
 		return ErrTokenInvalid
 	}
 
+	// The ID alone does not say which kind of token it was, so keep the
+	// entry until even a refresh token issued now would have expired.
+	lifetime := a.jwtLifetime
+	if a.refreshLife > lifetime {
+		lifetime = a.refreshLife
+	}
 	blacklistedToken := &model.BlacklistedToken{
 		JTI:       tokenID,
-		ExpiresAt: time.Now().Add(a.jwtLifetime),
+		ExpiresAt: time.Now().Add(lifetime),
 	}
 
 	err := a.tokenRepo.Add(blacklistedToken)
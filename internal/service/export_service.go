@@ -0,0 +1,236 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+)
+
+// exportPageSize is how many rows the export reads at a time, so large
+// accounts are streamed rather than loaded at once.
+const exportPageSize = 200
+
+// ExportSchemaVersion is the shape version of the account export archive,
+// recorded in its manifest. Bump it when a file or field is renamed, removed
+// or changes meaning.
+const ExportSchemaVersion = 1
+
+// Files of the account export archive.
+const (
+	ExportManifestFile        = "manifest.json"
+	ExportProfileFile         = "profile.json"
+	ExportNotificationsFile   = "notifications.json"
+	ExportURLsFile            = "urls.json"
+	ExportTagsFile            = "tags.json"
+	ExportAnalysisResultsFile = "analysis_results.json"
+	ExportLinksFile           = "links.json"
+)
+
+// exportSections are the data files of the archive, in the order written.
+var exportSections = []string{
+	ExportProfileFile,
+	ExportNotificationsFile,
+	ExportURLsFile,
+	ExportTagsFile,
+	ExportAnalysisResultsFile,
+	ExportLinksFile,
+}
+
+// ExportManifest is the top-level document of an account export archive. It
+// says which schema version the archive follows and which files it holds.
+type ExportManifest struct {
+	SchemaVersion int       `json:"schema_version"`
+	UserID        uint      `json:"user_id"`
+	ExportedAt    time.Time `json:"exported_at"`
+	Files         []string  `json:"files"`
+}
+
+// ExportService writes everything stored for a user as a zip archive, for
+// data portability and data subject access requests.
+type ExportService interface {
+	Export(userID uint, w io.Writer) error
+}
+
+type exportService struct {
+	userRepo         repository.UserRepository
+	urlRepo          repository.URLRepository
+	analysisRepo     repository.AnalysisResultRepository
+	linkRepo         repository.LinkRepository
+	notificationRepo repository.NotificationRepository
+}
+
+func NewExportService(
+	userRepo repository.UserRepository,
+	urlRepo repository.URLRepository,
+	analysisRepo repository.AnalysisResultRepository,
+	linkRepo repository.LinkRepository,
+	notificationRepo repository.NotificationRepository,
+) ExportService {
+	return &exportService{
+		userRepo:         userRepo,
+		urlRepo:          urlRepo,
+		analysisRepo:     analysisRepo,
+		linkRepo:         linkRepo,
+		notificationRepo: notificationRepo,
+	}
+}
+
+// Export writes a manifest and one JSON file per section. Nothing is written
+// if the user cannot be found, so callers may still report that error.
+func (s *exportService) Export(userID uint, w io.Writer) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	prefs, err := s.notificationRepo.FindByUser(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		prefs, err = model.DefaultNotificationPrefs(userID), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+	manifest := ExportManifest{
+		SchemaVersion: ExportSchemaVersion,
+		UserID:        userID,
+		ExportedAt:    time.Now().UTC(),
+		Files:         exportSections,
+	}
+	if err := writeJSONFile(zw, ExportManifestFile, manifest); err != nil {
+		return err
+	}
+	// The profile goes through the DTO so the password hash never leaves.
+	if err := writeJSONFile(zw, ExportProfileFile, user.ToDTO()); err != nil {
+		return err
+	}
+	if err := writeJSONFile(zw, ExportNotificationsFile, prefs); err != nil {
+		return err
+	}
+
+	tags := map[string][]uint{}
+	if err := s.writeURLSection(zw, ExportURLsFile, userID, func(enc *jsonArray, u *model.URL) error {
+		for _, t := range u.Tags {
+			tags[t.Name] = append(tags[t.Name], u.ID)
+		}
+		return enc.add(u.ToDTO())
+	}); err != nil {
+		return err
+	}
+	if err := writeJSONFile(zw, ExportTagsFile, tags); err != nil {
+		return err
+	}
+
+	if err := s.writeURLSection(zw, ExportAnalysisResultsFile, userID, func(enc *jsonArray, u *model.URL) error {
+		for p := (repository.Pagination{Page: 1, PageSize: exportPageSize}); ; p.Page++ {
+			results, err := s.analysisRepo.ListByURL(u.ID, p)
+			if err != nil {
+				return err
+			}
+			for i := range results {
+				if err := enc.add(&results[i]); err != nil {
+					return err
+				}
+			}
+			if len(results) < exportPageSize {
+				return nil
+			}
+		}
+	}); err != nil {
+		return err
+	}
+
+	if err := s.writeURLSection(zw, ExportLinksFile, userID, func(enc *jsonArray, u *model.URL) error {
+		for p := (repository.Pagination{Page: 1, PageSize: exportPageSize}); ; p.Page++ {
+			links, err := s.linkRepo.ListByURL(u.ID, p)
+			if err != nil {
+				return err
+			}
+			for i := range links {
+				if err := enc.add(&links[i]); err != nil {
+					return err
+				}
+			}
+			if len(links) < exportPageSize {
+				return nil
+			}
+		}
+	}); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// writeURLSection writes name as a JSON array, calling each for every URL
+// of the user in id order.
+func (s *exportService) writeURLSection(zw *zip.Writer, name string, userID uint, each func(*jsonArray, *model.URL) error) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := &jsonArray{w: f}
+	filter := repository.URLFilter{Sort: "id"}
+	for p := (repository.Pagination{Page: 1, PageSize: exportPageSize}); ; p.Page++ {
+		urls, err := s.urlRepo.ListByUser(userID, filter, p)
+		if err != nil {
+			return err
+		}
+		for i := range urls {
+			if err := each(enc, &urls[i]); err != nil {
+				return err
+			}
+		}
+		if len(urls) < exportPageSize {
+			break
+		}
+	}
+	return enc.close()
+}
+
+func writeJSONFile(zw *zip.Writer, name string, v any) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(f).Encode(v)
+}
+
+// jsonArray writes a JSON array one element at a time.
+type jsonArray struct {
+	w io.Writer
+	n int
+}
+
+func (a *jsonArray) add(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sep := ","
+	if a.n == 0 {
+		sep = "["
+	}
+	a.n++
+	if _, err := io.WriteString(a.w, sep); err != nil {
+		return err
+	}
+	_, err = a.w.Write(b)
+	return err
+}
+
+func (a *jsonArray) close() error {
+	end := "]\n"
+	if a.n == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(a.w, end)
+	return err
+}
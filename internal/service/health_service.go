@@ -1,14 +1,21 @@
 package service
 
 import (
+	"context"
 	"time"
 
 	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/crawler"
 )
 
+// healthPingTimeout bounds how long a health check waits for the database.
+const healthPingTimeout = 2 * time.Second
+
 type HealthStatus struct {
 	Service  string
 	Database string
+	Crawler  string // empty when the service does not watch a crawler pool
 	Healthy  bool
 	Checked  time.Time
 }
@@ -20,6 +27,7 @@ type healthService struct {
 	db    *gorm.DB
 	name  string
 	probe func() (string, bool)
+	pool  crawler.Pool
 }
 
 func NewHealthService(db *gorm.DB, name string) HealthService {
@@ -34,7 +42,9 @@ func NewHealthService(db *gorm.DB, name string) HealthService {
 			if err != nil {
 				return "unhealthy", false
 			}
-			if pingErr := sqlDB.Ping(); pingErr != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), healthPingTimeout)
+			defer cancel()
+			if pingErr := sqlDB.PingContext(ctx); pingErr != nil {
 				return "unhealthy", false
 			}
 			return "healthy", true
@@ -42,12 +52,28 @@ func NewHealthService(db *gorm.DB, name string) HealthService {
 	}
 }
 
+// NewHealthServiceWithCrawler creates a health service that also reports
+// whether pool is running; a pool not started or shut down is unhealthy.
+func NewHealthServiceWithCrawler(db *gorm.DB, name string, pool crawler.Pool) HealthService {
+	hs := NewHealthService(db, name).(*healthService)
+	hs.pool = pool
+	return hs
+}
+
 func (h *healthService) Check() *HealthStatus {
 	dbStatus, ok := h.probe()
-	return &HealthStatus{
+	status := &HealthStatus{
 		Service:  h.name,
 		Database: dbStatus,
 		Healthy:  ok,
 		Checked:  time.Now().UTC(),
 	}
+	if h.pool != nil {
+		status.Crawler = "running"
+		if !h.pool.Running() {
+			status.Crawler = "stopped"
+			status.Healthy = false
+		}
+	}
+	return status
 }
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
 )
@@ -11,8 +14,19 @@ type LinkService interface {
 	ListByURL(urlID uint, p repository.Pagination) (*model.PaginatedResponse[model.LinkDTO], error)
 	Update(link *model.Link) error
 	Delete(link *model.Link) error
+	ListByUser(userID uint, f repository.LinkFilter, p repository.Pagination) (*model.PaginatedResponse[model.UserLinkDTO], error)
+	StatusSummary(urlID, userID uint) (*model.LinkStatusSummaryDTO, error)
+	Changes(urlID, userID uint) (*model.LinkChangesDTO, error)
+	DomainImpact(domain string, p repository.Pagination) (*model.DomainImpactDTO, error)
+	TopBrokenDomains(limit int) ([]model.BrokenDomainDTO, error)
 }
 
+// Bounds for the number of domains TopBrokenDomains returns.
+const (
+	DefaultTopBrokenDomains = 10
+	MaxTopBrokenDomains     = 100
+)
+
 type linkService struct {
 	repo repository.LinkRepository
 }
@@ -53,15 +67,43 @@ func (s *linkService) ListByURL(urlID uint, p repository.Pagination) (*model.Pag
 	}, nil
 }
 
+func (s *linkService) ListByUser(userID uint, f repository.LinkFilter, p repository.Pagination) (*model.PaginatedResponse[model.UserLinkDTO], error) {
+	links, err := s.repo.ListByUser(userID, f, p)
+	if err != nil {
+		return nil, err
+	}
+
+	totalCount, err := s.repo.CountByUser(userID, f)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := totalCount / p.Limit()
+	if totalCount%p.Limit() > 0 {
+		totalPages++
+	}
+
+	return &model.PaginatedResponse[model.UserLinkDTO]{
+		Data: links,
+		Pagination: model.PaginationMetaDTO{
+			Page:       p.Page,
+			PageSize:   p.Limit(),
+			TotalItems: totalCount,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
 func mapLinkToDTO(link *model.Link) model.LinkDTO {
 	return model.LinkDTO{
-		ID:         link.ID,
-		URLID:      link.URLID,
-		Href:       link.Href,
-		IsExternal: link.IsExternal,
-		StatusCode: link.StatusCode,
-		CreatedAt:  link.CreatedAt,
-		UpdatedAt:  link.UpdatedAt,
+		ID:              link.ID,
+		URLID:           link.URLID,
+		Href:            link.Href,
+		IsExternal:      link.IsExternal,
+		StatusCode:      link.StatusCode,
+		OccurrenceCount: link.OccurrenceCount,
+		CreatedAt:       link.CreatedAt,
+		UpdatedAt:       link.UpdatedAt,
 	}
 }
 func (s *linkService) Add(link *model.Link) error {
@@ -87,3 +129,99 @@ func (s *linkService) Update(link *model.Link) error {
 func (s *linkService) Delete(link *model.Link) error {
 	return s.repo.Delete(link)
 }
+
+func (s *linkService) StatusSummary(urlID, userID uint) (*model.LinkStatusSummaryDTO, error) {
+	return s.repo.StatusSummary(urlID, userID)
+}
+
+// Changes compares the links of the two latest crawls of a URL by href.
+func (s *linkService) Changes(urlID, userID uint) (*model.LinkChangesDTO, error) {
+	runs, err := s.repo.LatestRuns(urlID, userID, 2)
+	if err != nil {
+		return nil, err
+	}
+	changes := &model.LinkChangesDTO{URLID: urlID, Added: []string{}, Removed: []string{}}
+	if len(runs) == 0 {
+		return changes, nil
+	}
+
+	current := runs[0]
+	changes.CurrentRunID = &current.AnalysisResultID
+	var previous model.LinkRun
+	if len(runs) > 1 {
+		previous = runs[1]
+		changes.PreviousRunID = &previous.AnalysisResultID
+	}
+	changes.Added = hrefDiff(current.Hrefs, previous.Hrefs)
+	changes.Removed = hrefDiff(previous.Hrefs, current.Hrefs)
+	return changes, nil
+}
+
+// hrefDiff returns the distinct hrefs of a that are not in b, sorted.
+func hrefDiff(a, b []string) []string {
+	exclude := make(map[string]bool, len(b))
+	for _, h := range b {
+		exclude[h] = true
+	}
+	out := []string{}
+	for _, h := range a {
+		if !exclude[h] {
+			out = append(out, h)
+			exclude[h] = true
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// DomainImpact reports how many links, URLs and users across the platform
+// point at domain, with one page of the affected URLs.
+func (s *linkService) DomainImpact(domain string, p repository.Pagination) (*model.DomainImpactDTO, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	impact, err := s.repo.DomainImpact(domain)
+	if err != nil {
+		return nil, err
+	}
+	urls, err := s.repo.ListDomainImpact(domain, p)
+	if err != nil {
+		return nil, err
+	}
+	if urls == nil {
+		urls = []model.DomainImpactURLDTO{}
+	}
+
+	totalPages := impact.URLs / p.Limit()
+	if impact.URLs%p.Limit() > 0 {
+		totalPages++
+	}
+	impact.AffectedURLs = model.PaginatedResponse[model.DomainImpactURLDTO]{
+		Data: urls,
+		Pagination: model.PaginationMetaDTO{
+			Page:       p.Page,
+			PageSize:   p.Limit(),
+			TotalItems: impact.URLs,
+			TotalPages: totalPages,
+		},
+	}
+	return impact, nil
+}
+
+// TopBrokenDomains lists the external hosts with the most broken links across
+// the platform. A limit outside 1..MaxTopBrokenDomains is replaced by the
+// default or the cap.
+func (s *linkService) TopBrokenDomains(limit int) ([]model.BrokenDomainDTO, error) {
+	switch {
+	case limit <= 0:
+		limit = DefaultTopBrokenDomains
+	case limit > MaxTopBrokenDomains:
+		limit = MaxTopBrokenDomains
+	}
+	domains, err := s.repo.TopBrokenDomains(limit)
+	if err != nil {
+		return nil, err
+	}
+	if domains == nil {
+		domains = []model.BrokenDomainDTO{}
+	}
+	return domains, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+)
+
+// NotificationService reads and changes users' notification preferences.
+// Jobs that notify users should look preferences up through Get so that
+// users who never saved any get the defaults.
+type NotificationService interface {
+	Get(userID uint) (*model.NotificationPrefs, error)
+	Update(userID uint, input *model.UpdateNotificationPrefsInput) (*model.NotificationPrefs, error)
+}
+
+type notificationService struct {
+	repo repository.NotificationRepository
+}
+
+func NewNotificationService(r repository.NotificationRepository) NotificationService {
+	return &notificationService{repo: r}
+}
+
+func (s *notificationService) Get(userID uint) (*model.NotificationPrefs, error) {
+	prefs, err := s.repo.FindByUser(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.DefaultNotificationPrefs(userID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if prefs.AlertChannels == nil {
+		prefs.AlertChannels = []string{}
+	}
+	return prefs, nil
+}
+
+func (s *notificationService) Update(userID uint, input *model.UpdateNotificationPrefsInput) (*model.NotificationPrefs, error) {
+	prefs, err := s.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if input.DigestFrequency != nil {
+		prefs.DigestFrequency = *input.DigestFrequency
+	}
+	if input.AlertChannels != nil {
+		prefs.AlertChannels = dedupe(*input.AlertChannels)
+	}
+	if input.WebhookEnabled != nil {
+		prefs.WebhookEnabled = *input.WebhookEnabled
+	}
+	if err := s.repo.Save(prefs); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// dedupe drops repeated values, keeping the first occurrence of each.
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := []string{}
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
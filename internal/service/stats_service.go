@@ -0,0 +1,63 @@
+package service
+
+import (
+	"time"
+
+	"github.com/fuzumoe/linkTorch-api/internal/crawler"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+)
+
+type StatsService interface {
+	System() (*model.SystemStatsDTO, error)
+	ETA() (*model.CrawlETADTO, error)
+}
+
+// etaSamples is how many recent crawls the ETA's average duration is based on.
+const etaSamples = 100
+
+type statsService struct {
+	repo     repository.StatsRepository
+	crawlers crawler.Pool
+}
+
+func NewStatsService(r repository.StatsRepository, p crawler.Pool) StatsService {
+	return &statsService{repo: r, crawlers: p}
+}
+
+func (s *statsService) System() (*model.SystemStatsDTO, error) {
+	stats, err := s.repo.System(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	stats.QueueDepth = s.crawlers.QueueDepth()
+	if stats.CrawledURLs > 0 {
+		stats.ErrorRate = float64(stats.ErroredURLs) / float64(stats.CrawledURLs)
+	}
+	return stats, nil
+}
+
+// ETA estimates when the queue is drained: the workers take the queued URLs
+// in rounds, each as long as an average recent crawl. Crawls already running
+// are not counted.
+func (s *statsService) ETA() (*model.CrawlETADTO, error) {
+	avg, samples, err := s.repo.RecentCrawlDuration(etaSamples)
+	if err != nil {
+		return nil, err
+	}
+	eta := &model.CrawlETADTO{
+		QueueDepth: s.crawlers.QueueDepth(),
+		Workers:    s.crawlers.Workers(),
+		AvgCrawlMs: avg.Milliseconds(),
+		Samples:    samples,
+	}
+	if eta.QueueDepth > 0 && samples == 0 {
+		return eta, nil
+	}
+	workers := max(eta.Workers, 1)
+	rounds := (eta.QueueDepth + workers - 1) / workers
+	drain := time.Duration(rounds) * avg
+	seconds := int64((drain + time.Second - 1) / time.Second)
+	eta.ETASeconds = &seconds
+	return eta, nil
+}
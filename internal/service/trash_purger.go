@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// TrashPurger permanently deletes URLs that have stayed deleted for longer
+// than a retention window, so soft-deleted rows do not pile up forever.
+type TrashPurger struct {
+	urls      URLService
+	interval  time.Duration
+	retention time.Duration
+}
+
+// NewTrashPurger creates a purger that, every interval, removes the URLs
+// deleted more than retention ago.
+func NewTrashPurger(urls URLService, interval, retention time.Duration) *TrashPurger {
+	return &TrashPurger{urls: urls, interval: interval, retention: retention}
+}
+
+// Run purges every interval until ctx is done.
+func (p *TrashPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.Sweep()
+		}
+	}
+}
+
+// Sweep purges once and returns how many URLs were removed.
+func (p *TrashPurger) Sweep() int {
+	n, err := p.urls.PurgeDeleted(p.retention)
+	if err != nil {
+		log.Printf("[trash] purge failed: %v", err)
+		return 0
+	}
+	if n > 0 {
+		log.Printf("[trash] purged %d URLs deleted over %s ago", n, p.retention)
+	}
+	return n
+}
@@ -1,32 +1,147 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
 
 	"github.com/fuzumoe/linkTorch-api/internal/crawler"
+	"github.com/fuzumoe/linkTorch-api/internal/egress"
 	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/report"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
+	"github.com/fuzumoe/linkTorch-api/internal/storage"
 )
 
 type URLService interface {
 	Create(input *model.CreateURLInputDTO) (uint, error)
+	CreateWithCrawl(input *model.CreateURLInputDTO) (*model.URLCreatedDTO, error)
+	CreateBulk(inputs []*model.CreateURLInputDTO) ([]uint, error)
 	Get(id uint) (*model.URLDTO, error)
-	List(userID uint, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error)
+	List(userID uint, f repository.URLFilter, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error)
 	Update(id uint, input *model.UpdateURLInput) error
 	Delete(id uint) error
-	Start(id uint) error
-	StartWithPriority(id uint, priority int) error
+	Start(id uint, requestID string) error
+	StartWithPriority(id uint, priority int, requestID string) error
+	Reprioritize(id, userID uint, priority int) error
 	Stop(id uint) error
 	Results(id uint) (*model.URLDTO, error)
 	ResultsWithDetails(id uint) (*model.URL, []*model.AnalysisResult, []*model.Link, error)
 	GetCrawlResults() <-chan crawler.CrawlResult
 	AdjustCrawlerWorkers(action string, count int) error
+	PauseCrawler()
+	ResumeCrawler()
+	CrawlerStatus() *model.CrawlerStatusDTO
+	CrawlerStats() crawler.PoolStats
+	CrawlerQueue(limit int) crawler.QueueSnapshot
+	Merge(id, intoID, userID uint) error
+	Validate(userID uint, urls []string) ([]model.URLValidationDTO, error)
+	Report(id, userID uint) (*report.URLReport, error)
+	ListUncrawled(userID uint, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error)
+	DeleteErrored(userID uint) (int, error)
+	Clone(id, userID uint, originalURL string) (uint, error)
+	DeleteBulk(userID uint, ids []uint) (*model.BulkURLActionResultDTO, error)
+	StartBulk(userID uint, ids []uint) (*model.BulkURLActionResultDTO, error)
+	TagReport(userID uint, tag string, p repository.Pagination) (*model.PaginatedResponse[model.TagReportItemDTO], error)
+	TagSummary(userID uint, tag string) (*model.TagSummaryDTO, error)
+	RecrawlTag(userID uint, tag string, priority int) (*model.BulkURLActionResultDTO, error)
+	ListCursor(userID uint, f repository.URLFilter, c repository.Cursor) (*model.CursorResponse[model.URLDTO], error)
+	ListDeleted(userID uint, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error)
+	Restore(id, userID uint) error
+	Purge(id uint) error
+	PurgeDeleted(olderThan time.Duration) (int, error)
+	ExportURLs(userID uint) (*model.URLExportDocument, error)
+	ImportURLs(userID uint, doc *model.URLExportDocument) (*model.URLImportResultDTO, error)
+}
+
+// ErrURLNotOwned is returned when a user acts on a URL that belongs to someone else.
+var ErrURLNotOwned = errors.New("url does not belong to user")
+
+var errInvalidCrawlMethod = errors.New("invalid crawl_method value")
+
+// ErrCrawlQuotaExceeded is returned when starting a crawl would put more of a
+// user's URLs in flight than their CrawlQuota allows.
+var ErrCrawlQuotaExceeded = errors.New("crawl quota exceeded")
+
+// ErrURLNotQueued is returned when reprioritizing a URL that is not waiting
+// for a crawler worker.
+var ErrURLNotQueued = errors.New("url is not queued")
+
+// ErrUnsupportedSchemaVersion is returned when importing a document whose
+// schema version this server does not read.
+var ErrUnsupportedSchemaVersion = errors.New("unsupported schema version")
+
+// BulkCreateError lists the inputs of a bulk create that were rejected, keyed
+// by their index. Every other input was created.
+type BulkCreateError struct {
+	Failed map[int]error
+}
+
+func (e *BulkCreateError) Error() string {
+	return fmt.Sprintf("%d url(s) rejected", len(e.Failed))
+}
+
+// SlowCrawlPolicy lowers the queue priority of URLs whose previous crawl was
+// slow, so they do not hold up quick pages. A zero Threshold disables it.
+type SlowCrawlPolicy struct {
+	Threshold time.Duration
+	Penalty   int // subtracted from the requested priority, which stays at least 1
+}
+
+// CrawlQuota caps how many of a user's URLs may be queued or running at once.
+// Zero means no limit.
+type CrawlQuota struct {
+	PerUser int
+	ByRole  map[model.UserRole]int // overrides PerUser for users with the role
+}
+
+// limit returns the quota that applies to role.
+func (q CrawlQuota) limit(role model.UserRole) int {
+	if n, ok := q.ByRole[role]; ok {
+		return n
+	}
+	return q.PerUser
+}
+
+// enabled reports whether any user can be limited.
+func (q CrawlQuota) enabled() bool {
+	if q.PerUser > 0 {
+		return true
+	}
+	for _, n := range q.ByRole {
+		if n > 0 {
+			return true
+		}
+	}
+	return false
 }
 
+// DefaultPriority is the priority of a crawl started without one.
+const DefaultPriority = 5
+
 type urlService struct {
-	repo     repository.URLRepository
-	crawlers crawler.Pool
+	repo      repository.URLRepository
+	crawlers  crawler.Pool
+	egress    *egress.Policy
+	slowCrawl SlowCrawlPolicy
+	// tagHost tags every created URL with its host name.
+	tagHost bool
+	users   repository.UserRepository // looks up roles for the crawl quota
+	quota   CrawlQuota
+	// quotaMu makes checking the quota and queueing a URL one step.
+	quotaMu sync.Mutex
+	// crawlOnCreate queues created URLs unless the input says otherwise.
+	crawlOnCreate bool
+	// rawHTML holds crawled bodies, removed when URLs are purged; nil if not stored.
+	rawHTML storage.BlobStore
 }
 
 func (s *urlService) Update(id uint, in *model.UpdateURLInput) error {
@@ -37,37 +152,176 @@ func (s *urlService) Update(id uint, in *model.UpdateURLInput) error {
 
 	if in.OriginalURL != "" {
 		u.OriginalURL = in.OriginalURL
+		if parsed := u.URL(); parsed != nil {
+			if err := s.egress.Check(parsed); err != nil {
+				return err
+			}
+		}
 	}
 	if in.Status != "" {
 		switch in.Status {
 		case model.StatusQueued, model.StatusRunning,
-			model.StatusDone, model.StatusError, model.StatusStopped:
+			model.StatusDone, model.StatusError, model.StatusStopped,
+			model.StatusSkipped:
 			u.Status = in.Status
 		default:
 			return errors.New("invalid status value")
 		}
 	}
+	if in.HostLimit != nil {
+		if *in.HostLimit < 0 {
+			return errors.New("invalid host_limit value")
+		}
+		u.HostLimit = *in.HostLimit
+	}
+	if in.CrawlMethod != "" {
+		if !model.IsCrawlMethod(in.CrawlMethod) {
+			return errInvalidCrawlMethod
+		}
+		u.CrawlMethod = in.CrawlMethod
+	}
 	return s.repo.Update(u)
 }
 
-func NewURLService(r repository.URLRepository, p crawler.Pool) URLService {
-	return &urlService{repo: r, crawlers: p}
+// NewURLService creates a URL service. A nil egress policy allows every host.
+func NewURLService(r repository.URLRepository, p crawler.Pool, e *egress.Policy) URLService {
+	return &urlService{repo: r, crawlers: p, egress: e}
 }
 
-func (s *urlService) Start(id uint) error {
+// URLServiceOptions configures the optional behaviour of a URL service. The
+// zero value changes nothing.
+type URLServiceOptions struct {
+	// SlowCrawl de-prioritizes URLs whose previous crawl was slow.
+	SlowCrawl SlowCrawlPolicy
+	// TagHost tags every created URL with its host, e.g. "example.com".
+	TagHost bool
+	// Quota refuses to start a crawl when the URL's owner already has that
+	// many URLs in flight. Users looks up the owners' roles for it.
+	Quota CrawlQuota
+	Users repository.UserRepository
+	// CrawlOnCreate queues every created URL for crawling unless the input
+	// opts out.
+	CrawlOnCreate bool
+	// RawHTML is where crawled bodies are stored, so purging a URL removes
+	// them too.
+	RawHTML storage.BlobStore
+}
 
-	_, err := s.repo.FindByID(id)
+// NewURLServiceWithOptions creates a URL service configured by opts. A nil
+// egress policy allows every host.
+func NewURLServiceWithOptions(r repository.URLRepository, p crawler.Pool, e *egress.Policy, opts URLServiceOptions) URLService {
+	return &urlService{
+		repo:          r,
+		crawlers:      p,
+		egress:        e,
+		slowCrawl:     opts.SlowCrawl,
+		tagHost:       opts.TagHost,
+		users:         opts.Users,
+		quota:         opts.Quota,
+		crawlOnCreate: opts.CrawlOnCreate,
+		rawHTML:       opts.RawHTML,
+	}
+}
+
+// Start queues URL id for crawling. requestID, when set, identifies the API
+// request asking for it and is carried by the queued task into the crawl's
+// logs and result.
+func (s *urlService) Start(id uint, requestID string) error {
+
+	u, err := s.repo.FindByID(id)
 	if err != nil {
 		return fmt.Errorf("cannot start crawling: %w", err)
 	}
+	return s.start(u, requestID)
+}
+
+// start queues u at the default priority, lowered if its last crawl was slow.
+func (s *urlService) start(u *model.URL, requestID string) error {
+	if s.quota.enabled() {
+		s.quotaMu.Lock()
+		defer s.quotaMu.Unlock()
+		if err := s.checkQuota(u); err != nil {
+			return err
+		}
+	}
+	id := u.ID
+	priority := s.effectivePriority(u, DefaultPriority)
+	if requestID != "" {
+		return s.queue(id, u.Status, func() error { return s.crawlers.EnqueueWithRequestID(id, priority, requestID) })
+	}
+	if priority != DefaultPriority {
+		return s.queue(id, u.Status, func() error { return s.crawlers.EnqueueWithPriority(id, priority) })
+	}
+	return s.queue(id, u.Status, func() error { return s.crawlers.Enqueue(id) })
+}
 
+// checkQuota fails with ErrCrawlQuotaExceeded if queueing u would exceed its
+// owner's crawl quota. Restarting a URL that is already in flight adds nothing.
+func (s *urlService) checkQuota(u *model.URL) error {
+	if u.Status == model.StatusQueued || u.Status == model.StatusRunning {
+		return nil
+	}
+	owner, err := s.users.FindByID(u.UserID)
+	if err != nil {
+		return fmt.Errorf("cannot start crawling: %w", err)
+	}
+	limit := s.quota.limit(owner.Role)
+	if limit <= 0 {
+		return nil
+	}
+	inFlight, err := s.repo.CountByUserAndStatus(u.UserID, model.StatusQueued, model.StatusRunning)
+	if err != nil {
+		return fmt.Errorf("cannot start crawling: %w", err)
+	}
+	if inFlight >= limit {
+		return fmt.Errorf("%w: %d of %d crawls already queued or running", ErrCrawlQuotaExceeded, inFlight, limit)
+	}
+	return nil
+}
+
+// queue marks URL id queued and hands it to the crawler pool with enqueue. If
+// the pool rejects it, e.g. with crawler.ErrQueueFull, the status goes back
+// to prev.
+func (s *urlService) queue(id uint, prev string, enqueue func() error) error {
 	if err := s.repo.UpdateStatus(id, model.StatusQueued); err != nil {
 		return err
 	}
-	s.crawlers.Enqueue(id)
+	if err := enqueue(); err != nil {
+		if restoreErr := s.repo.UpdateStatus(id, prev); restoreErr != nil {
+			log.Printf("[crawler] url %d: restoring status %q: %v", id, prev, restoreErr)
+		}
+		return err
+	}
 	return nil
 }
 
+// effectivePriority applies the slow crawl policy to the requested priority,
+// based on the duration of the URL's latest timed crawl. Adjustments are logged.
+func (s *urlService) effectivePriority(u *model.URL, priority int) int {
+	if s.slowCrawl.Threshold <= 0 || s.slowCrawl.Penalty <= 0 {
+		return priority
+	}
+	var last *model.AnalysisResult
+	for i := range u.AnalysisResults {
+		r := &u.AnalysisResults[i]
+		if r.CrawlDurationMs != nil && (last == nil || r.ID > last.ID) {
+			last = r
+		}
+	}
+	if last == nil {
+		return priority
+	}
+	took := time.Duration(*last.CrawlDurationMs) * time.Millisecond
+	if took <= s.slowCrawl.Threshold {
+		return priority
+	}
+	adjusted := max(priority-s.slowCrawl.Penalty, 1)
+	if adjusted != priority {
+		log.Printf("[crawler] url %d: previous crawl took %s, priority %d -> %d", u.ID, took, priority, adjusted)
+	}
+	return adjusted
+}
+
 func (s *urlService) Stop(id uint) error {
 
 	_, err := s.repo.FindByID(id)
@@ -96,11 +350,180 @@ func (s *urlService) ResultsWithDetails(id uint) (*model.URL, []*model.AnalysisR
 }
 
 func (s *urlService) Create(input *model.CreateURLInputDTO) (uint, error) {
-	u := model.URLFromCreateInput(input)
-	if err := s.repo.Create(u); err != nil {
+	created, err := s.CreateWithCrawl(input)
+	if err != nil {
 		return 0, err
 	}
-	return u.ID, nil
+	return created.ID, nil
+}
+
+// CreateWithCrawl creates a URL and queues it for crawling when input.Crawl,
+// or without it the service's crawl-on-create default, asks for that. A URL
+// the crawler does not take, e.g. because the queue is full, is still
+// created; CrawlError then says why it was not queued.
+func (s *urlService) CreateWithCrawl(input *model.CreateURLInputDTO) (*model.URLCreatedDTO, error) {
+	u, err := s.newURL(input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(u); err != nil {
+		return nil, err
+	}
+	created := &model.URLCreatedDTO{ID: u.ID}
+	crawl := s.crawlOnCreate
+	if input.Crawl != nil {
+		crawl = *input.Crawl
+	}
+	if !crawl {
+		return created, nil
+	}
+	if err := s.start(u, ""); err != nil {
+		created.CrawlError = err.Error()
+		return created, nil
+	}
+	created.Enqueued = true
+	return created, nil
+}
+
+// CreateBulk creates the valid inputs in a single transaction and returns
+// their ids in input order. Repeats of the same URL for the same user are
+// created once and share its id. Rejected inputs get id 0 and are reported
+// through a *BulkCreateError; any other error means nothing was created.
+func (s *urlService) CreateBulk(inputs []*model.CreateURLInputDTO) ([]uint, error) {
+	type key struct {
+		userID uint
+		url    string
+	}
+	ids := make([]uint, len(inputs))
+	failed := make(map[int]error)
+	first := make(map[key]int)
+	var batch []*model.URL
+	for i, in := range inputs {
+		k := key{in.UserID, in.OriginalURL}
+		if _, ok := first[k]; ok {
+			continue
+		}
+		u, err := s.newURL(in)
+		if err != nil {
+			failed[i] = err
+			continue
+		}
+		first[k] = len(batch)
+		batch = append(batch, u)
+	}
+
+	if err := s.repo.CreateBatch(batch); err != nil {
+		return nil, err
+	}
+	for i, in := range inputs {
+		if n, ok := first[key{in.UserID, in.OriginalURL}]; ok {
+			ids[i] = batch[n].ID
+		}
+	}
+	if len(failed) > 0 {
+		return ids, &BulkCreateError{Failed: failed}
+	}
+	return ids, nil
+}
+
+// ExportURLs returns the user's URLs, oldest first, as a document that
+// ImportURLs accepts.
+func (s *urlService) ExportURLs(userID uint) (*model.URLExportDocument, error) {
+	doc := &model.URLExportDocument{
+		SchemaVersion: model.URLExportSchemaVersion,
+		ExportedAt:    time.Now().UTC(),
+		URLs:          []model.URLCreateRequestDTO{},
+	}
+	filter := repository.URLFilter{Sort: "id"}
+	for p := (repository.Pagination{Page: 1, PageSize: exportPageSize}); ; p.Page++ {
+		urls, err := s.repo.ListByUser(userID, filter, p)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range urls {
+			doc.URLs = append(doc.URLs, model.URLCreateRequestDTO{OriginalURL: u.OriginalURL, CrawlMethod: u.CrawlMethod})
+		}
+		if len(urls) < exportPageSize {
+			return doc, nil
+		}
+	}
+}
+
+// ImportURLs creates the URLs of doc for userID in one transaction. URLs the
+// user already has are skipped, so importing the same document twice is
+// harmless. Documents of another schema version are refused with
+// ErrUnsupportedSchemaVersion.
+func (s *urlService) ImportURLs(userID uint, doc *model.URLExportDocument) (*model.URLImportResultDTO, error) {
+	if doc.SchemaVersion != model.URLExportSchemaVersion {
+		return nil, ErrUnsupportedSchemaVersion
+	}
+	raw := make([]string, len(doc.URLs))
+	for i, e := range doc.URLs {
+		raw[i] = e.OriginalURL
+	}
+	existing, err := s.repo.FindExisting(userID, raw)
+	if err != nil {
+		return nil, err
+	}
+	have := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		have[e] = true
+	}
+
+	res := &model.URLImportResultDTO{}
+	var inputs []*model.CreateURLInputDTO
+	var positions []int // index in doc.URLs of each input
+	for i, e := range doc.URLs {
+		if _, err := normalizeURL(e.OriginalURL); err != nil {
+			res.Failed = append(res.Failed, model.URLBulkCreateResultDTO{Index: i, OriginalURL: e.OriginalURL, Error: err.Error()})
+			continue
+		}
+		if have[e.OriginalURL] {
+			res.Skipped++
+			continue
+		}
+		have[e.OriginalURL] = true
+		inputs = append(inputs, &model.CreateURLInputDTO{UserID: userID, OriginalURL: e.OriginalURL, CrawlMethod: e.CrawlMethod})
+		positions = append(positions, i)
+	}
+	if len(inputs) == 0 {
+		return res, nil
+	}
+
+	_, err = s.CreateBulk(inputs)
+	var bulkErr *BulkCreateError
+	if err != nil && !errors.As(err, &bulkErr) {
+		return nil, err
+	}
+	for n, i := range positions {
+		if bulkErr != nil {
+			if err, ok := bulkErr.Failed[n]; ok {
+				res.Failed = append(res.Failed, model.URLBulkCreateResultDTO{Index: i, OriginalURL: doc.URLs[i].OriginalURL, Error: err.Error()})
+				continue
+			}
+		}
+		res.Imported++
+	}
+	sort.Slice(res.Failed, func(a, b int) bool { return res.Failed[a].Index < res.Failed[b].Index })
+	return res, nil
+}
+
+// newURL builds the row for input after checking it against the egress
+// policy, tagging it with its host when enabled.
+func (s *urlService) newURL(input *model.CreateURLInputDTO) (*model.URL, error) {
+	u := model.URLFromCreateInput(input)
+	if !model.IsCrawlMethod(u.CrawlMethod) {
+		return nil, errInvalidCrawlMethod
+	}
+	if parsed := u.URL(); parsed != nil {
+		if err := s.egress.Check(parsed); err != nil {
+			return nil, err
+		}
+		if host := strings.ToLower(parsed.Hostname()); s.tagHost && host != "" {
+			u.Tags = append(u.Tags, model.URLTag{Name: host})
+		}
+	}
+	return u, nil
 }
 
 func (s *urlService) Get(id uint) (*model.URLDTO, error) {
@@ -114,13 +537,13 @@ func mapURLToDTO(url *model.URL) *model.URLDTO {
 	return url.ToDTO()
 }
 
-func (s *urlService) List(userID uint, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error) {
-	urls, err := s.repo.ListByUser(userID, p)
+func (s *urlService) List(userID uint, f repository.URLFilter, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error) {
+	urls, err := s.repo.ListByUser(userID, f, p)
 	if err != nil {
 		return nil, err
 	}
 
-	totalCount, err := s.repo.CountByUser(userID)
+	totalCount, err := s.repo.CountByUser(userID, f)
 	if err != nil {
 		return nil, err
 	}
@@ -146,22 +569,65 @@ func (s *urlService) List(userID uint, p repository.Pagination) (*model.Paginate
 	}, nil
 }
 
+// ListCursor returns a page of the user's URLs after c.After, newest first.
+// Unlike List it does not count the matching URLs.
+func (s *urlService) ListCursor(userID uint, f repository.URLFilter, c repository.Cursor) (*model.CursorResponse[model.URLDTO], error) {
+	urls, next, err := s.repo.ListByUserCursor(userID, f, c)
+	if err != nil {
+		return nil, err
+	}
+	dtos := make([]model.URLDTO, len(urls))
+	for i, u := range urls {
+		dtos[i] = *mapURLToDTO(&u)
+	}
+	return &model.CursorResponse[model.URLDTO]{Data: dtos, NextCursor: next}, nil
+}
+
 func (s *urlService) Delete(id uint) error {
 	return s.repo.Delete(id)
 }
 
-func (s *urlService) StartWithPriority(id uint, priority int) error {
+// StartWithPriority queues URL id like Start, at priority.
+func (s *urlService) StartWithPriority(id uint, priority int, requestID string) error {
 
-	_, err := s.repo.FindByID(id)
+	u, err := s.repo.FindByID(id)
 	if err != nil {
 		return fmt.Errorf("cannot start crawling: %w", err)
 	}
 
-	if err := s.repo.UpdateStatus(id, model.StatusQueued); err != nil {
+	if s.quota.enabled() {
+		s.quotaMu.Lock()
+		defer s.quotaMu.Unlock()
+		if err := s.checkQuota(u); err != nil {
+			return err
+		}
+	}
+	priority = s.effectivePriority(u, priority)
+	if requestID != "" {
+		return s.queue(id, u.Status, func() error { return s.crawlers.EnqueueWithRequestID(id, priority, requestID) })
+	}
+	return s.queue(id, u.Status, func() error { return s.crawlers.EnqueueWithPriority(id, priority) })
+}
+
+// Reprioritize moves one of the user's queued URLs to priority in the crawl
+// queue without queueing it again. URLs of other users are reported as not
+// found; URLs no longer waiting for a worker fail with ErrURLNotQueued.
+func (s *urlService) Reprioritize(id, userID uint, priority int) error {
+	u, err := s.repo.FindByID(id)
+	if err != nil {
 		return err
 	}
-	s.crawlers.EnqueueWithPriority(id, priority)
-	return nil
+	if u.UserID != userID {
+		return gorm.ErrRecordNotFound
+	}
+	if u.Status != model.StatusQueued {
+		return fmt.Errorf("%w: status is %s", ErrURLNotQueued, u.Status)
+	}
+	err = s.crawlers.Reprioritize(id, s.effectivePriority(u, priority))
+	if errors.Is(err, crawler.ErrNotQueued) {
+		return fmt.Errorf("%w: a worker already took it", ErrURLNotQueued)
+	}
+	return err
 }
 
 func (s *urlService) GetCrawlResults() <-chan crawler.CrawlResult {
@@ -184,3 +650,422 @@ func (s *urlService) AdjustCrawlerWorkers(action string, count int) error {
 
 	return nil
 }
+
+// PauseCrawler stops the workers from starting new crawls; URLs started while
+// paused wait in the queue.
+func (s *urlService) PauseCrawler() {
+	s.crawlers.Pause()
+}
+
+func (s *urlService) ResumeCrawler() {
+	s.crawlers.Resume()
+}
+
+func (s *urlService) CrawlerStatus() *model.CrawlerStatusDTO {
+	return &model.CrawlerStatusDTO{
+		Paused:     s.crawlers.Paused(),
+		QueueDepth: s.crawlers.QueueDepth(),
+		Workers:    s.crawlers.Workers(),
+	}
+}
+
+func (s *urlService) CrawlerStats() crawler.PoolStats {
+	return s.crawlers.Stats()
+}
+
+// CrawlerQueue lists up to limit of the URLs waiting in the crawl queue, in
+// the order workers will take them.
+func (s *urlService) CrawlerQueue(limit int) crawler.QueueSnapshot {
+	return s.crawlers.Snapshot(limit)
+}
+
+// Merge folds the history of URL id into URL intoID and removes id. Both URLs
+// must belong to userID.
+func (s *urlService) Merge(id, intoID, userID uint) error {
+	if id == intoID {
+		return errors.New("cannot merge a URL into itself")
+	}
+	src, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	dst, err := s.repo.FindByID(intoID)
+	if err != nil {
+		return err
+	}
+	if src.UserID != userID || dst.UserID != userID {
+		return ErrURLNotOwned
+	}
+	return s.repo.Merge(id, intoID)
+}
+
+// Clone creates a URL at originalURL for userID with the crawl configuration
+// of the user's URL id.
+func (s *urlService) Clone(id, userID uint, originalURL string) (uint, error) {
+	src, err := s.repo.FindByID(id)
+	if err != nil {
+		return 0, err
+	}
+	if src.UserID != userID {
+		return 0, ErrURLNotOwned
+	}
+	u := src.CloneFor(userID, originalURL)
+	if parsed := u.URL(); parsed != nil {
+		if err := s.egress.Check(parsed); err != nil {
+			return 0, err
+		}
+	}
+	if err := s.repo.Create(u); err != nil {
+		return 0, err
+	}
+	return u.ID, nil
+}
+
+// Validate reports, without creating anything, whether each URL would be
+// accepted and whether the user already has it.
+func (s *urlService) Validate(userID uint, urls []string) ([]model.URLValidationDTO, error) {
+	out := make([]model.URLValidationDTO, len(urls))
+	var lookup []string
+	for i, raw := range urls {
+		out[i].Input = raw
+		normalized, err := normalizeURL(raw)
+		if err == nil {
+			if parsed, _ := url.Parse(normalized); parsed != nil {
+				err = s.egress.Check(parsed)
+			}
+		}
+		if err != nil {
+			out[i].Reason = err.Error()
+			continue
+		}
+		out[i].Valid = true
+		out[i].Normalized = normalized
+		lookup = append(lookup, normalized, strings.TrimSpace(raw))
+	}
+
+	existing, err := s.repo.FindExisting(userID, lookup)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		seen[e] = true
+	}
+	for i := range out {
+		if !out[i].Valid {
+			continue
+		}
+		out[i].Duplicate = seen[out[i].Normalized] || seen[strings.TrimSpace(out[i].Input)]
+		seen[out[i].Normalized] = true
+	}
+	return out, nil
+}
+
+// normalizeURL returns the canonical form of an absolute http(s) URL: scheme
+// and host are lowercased and the fragment is dropped.
+func normalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", errors.New("empty url")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", errors.New("malformed url")
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", errors.New("scheme must be http or https")
+	}
+	if u.Host == "" {
+		return "", errors.New("missing host")
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String(), nil
+}
+
+// Report gathers the latest analysis of one of the user's URLs and the broken
+// links found by that crawl. URLs of other users are reported as not found.
+func (s *urlService) Report(id, userID uint) (*report.URLReport, error) {
+	u, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if u.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	r := &report.URLReport{
+		URL:         u.OriginalURL,
+		Status:      u.Status,
+		GeneratedAt: time.Now(),
+	}
+	for i := range u.AnalysisResults {
+		if r.Analysis == nil || u.AnalysisResults[i].ID > r.Analysis.ID {
+			r.Analysis = &u.AnalysisResults[i]
+		}
+	}
+	if r.Analysis == nil {
+		return r, nil
+	}
+
+	// Links accumulate over crawls; those saved with the latest analysis
+	// were created together with it.
+	for _, l := range u.Links {
+		if l.CreatedAt.Before(r.Analysis.CreatedAt) || l.StatusCode < 400 || l.StatusCode >= 600 {
+			continue
+		}
+		r.BrokenTotal++
+		if len(r.BrokenLinks) < report.MaxBrokenLinks {
+			r.BrokenLinks = append(r.BrokenLinks, l)
+		}
+	}
+	return r, nil
+}
+
+// TagSummary rolls up the user's URLs tagged with tag.
+func (s *urlService) TagSummary(userID uint, tag string) (*model.TagSummaryDTO, error) {
+	return s.repo.TagSummary(userID, tag)
+}
+
+// TagReport returns a page of the user's URLs tagged with tag, summarized by
+// their latest analysis.
+func (s *urlService) TagReport(userID uint, tag string, p repository.Pagination) (*model.PaginatedResponse[model.TagReportItemDTO], error) {
+	items, err := s.repo.ListTagReport(userID, tag, p)
+	if err != nil {
+		return nil, err
+	}
+
+	totalCount, err := s.repo.CountByTag(userID, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := totalCount / p.Limit()
+	if totalCount%p.Limit() > 0 {
+		totalPages++
+	}
+
+	if items == nil {
+		items = []model.TagReportItemDTO{}
+	}
+	return &model.PaginatedResponse[model.TagReportItemDTO]{
+		Data: items,
+		Pagination: model.PaginationMetaDTO{
+			Page:       p.Page,
+			PageSize:   p.Limit(),
+			TotalItems: totalCount,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// ListUncrawled pages through the user's URLs that were never crawled successfully.
+func (s *urlService) ListUncrawled(userID uint, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error) {
+	urls, err := s.repo.ListUncrawled(userID, p)
+	if err != nil {
+		return nil, err
+	}
+
+	totalCount, err := s.repo.CountUncrawled(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := totalCount / p.Limit()
+	if totalCount%p.Limit() > 0 {
+		totalPages++
+	}
+
+	dtos := make([]model.URLDTO, len(urls))
+	for i, u := range urls {
+		dtos[i] = *mapURLToDTO(&u)
+	}
+
+	return &model.PaginatedResponse[model.URLDTO]{
+		Data: dtos,
+		Pagination: model.PaginationMetaDTO{
+			Page:       p.Page,
+			PageSize:   p.Limit(),
+			TotalItems: totalCount,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// ListDeleted pages through the user's deleted URLs that can still be restored.
+func (s *urlService) ListDeleted(userID uint, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error) {
+	urls, err := s.repo.ListDeletedByUser(userID, p)
+	if err != nil {
+		return nil, err
+	}
+
+	totalCount, err := s.repo.CountDeletedByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := totalCount / p.Limit()
+	if totalCount%p.Limit() > 0 {
+		totalPages++
+	}
+
+	dtos := make([]model.URLDTO, len(urls))
+	for i, u := range urls {
+		dtos[i] = *mapURLToDTO(&u)
+	}
+
+	return &model.PaginatedResponse[model.URLDTO]{
+		Data: dtos,
+		Pagination: model.PaginationMetaDTO{
+			Page:       p.Page,
+			PageSize:   p.Limit(),
+			TotalItems: totalCount,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// Restore brings back one of the user's deleted URLs. Deleted URLs of other
+// users are reported as not found, like live ones.
+func (s *urlService) Restore(id, userID uint) error {
+	u, err := s.repo.FindDeleted(id)
+	if err != nil {
+		return err
+	}
+	if u.UserID != userID {
+		return gorm.ErrRecordNotFound
+	}
+	return s.repo.Restore(id)
+}
+
+// Purge permanently deletes URL id with its results, links and stored raw
+// HTML. It is meant for admins; handlers check the caller's role.
+func (s *urlService) Purge(id uint) error {
+	keys, err := s.repo.Purge(id)
+	if err != nil {
+		return err
+	}
+	s.deleteRawHTML(keys)
+	return nil
+}
+
+// PurgeDeleted permanently deletes the URLs that were deleted more than
+// olderThan ago and can no longer be restored afterwards.
+func (s *urlService) PurgeDeleted(olderThan time.Duration) (int, error) {
+	n, keys, err := s.repo.PurgeOlderThan(olderThan)
+	if err != nil {
+		return 0, err
+	}
+	s.deleteRawHTML(keys)
+	return n, nil
+}
+
+// deleteRawHTML removes the raw HTML bodies keys of purged URLs from the
+// blob store. The rows are already gone, so failures are only logged.
+func (s *urlService) deleteRawHTML(keys []string) {
+	if s.rawHTML == nil {
+		return
+	}
+	failed := 0
+	for _, key := range keys {
+		err := s.rawHTML.Delete(context.Background(), key)
+		if err != nil && !errors.Is(err, storage.ErrBlobNotFound) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		log.Printf("[WARN] purge: %d of %d raw HTML bodies could not be deleted", failed, len(keys))
+	}
+}
+
+// DeleteErrored removes every URL of the user whose last crawl failed.
+func (s *urlService) DeleteErrored(userID uint) (int, error) {
+	return s.repo.DeleteErrored(userID)
+}
+
+// DeleteBulk deletes those of ids that belong to userID; the others are
+// reported as skipped.
+func (s *urlService) DeleteBulk(userID uint, ids []uint) (*model.BulkURLActionResultDTO, error) {
+	owned, skipped, err := s.splitOwned(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := s.repo.DeleteByIDs(owned)
+	if err != nil {
+		return nil, err
+	}
+	return &model.BulkURLActionResultDTO{Succeeded: deleted, Skipped: skipped}, nil
+}
+
+// StartBulk queues a new crawl of each of ids that belongs to userID; the
+// others, and any that could not be queued, are reported as skipped.
+func (s *urlService) StartBulk(userID uint, ids []uint) (*model.BulkURLActionResultDTO, error) {
+	owned, skipped, err := s.splitOwned(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := &model.BulkURLActionResultDTO{Skipped: skipped}
+	for _, id := range owned {
+		if err := s.Start(id, ""); err != nil {
+			log.Printf("[crawler] url %d: bulk start failed: %v", id, err)
+			out.Skipped = append(out.Skipped, id)
+			continue
+		}
+		out.Succeeded++
+	}
+	return out, nil
+}
+
+// RecrawlTag queues a new crawl of each of the user's URLs tagged with tag,
+// at priority or, when it is 0, like Start. URLs that could not be queued are
+// reported as skipped.
+func (s *urlService) RecrawlTag(userID uint, tag string, priority int) (*model.BulkURLActionResultDTO, error) {
+	ids, err := s.repo.FindIDsByTag(userID, tag)
+	if err != nil {
+		return nil, err
+	}
+	out := &model.BulkURLActionResultDTO{Skipped: []uint{}}
+	for _, id := range ids {
+		if priority == 0 {
+			err = s.Start(id, "")
+		} else {
+			err = s.StartWithPriority(id, priority, "")
+		}
+		if err != nil {
+			log.Printf("[crawler] url %d: tag %q recrawl failed: %v", id, tag, err)
+			out.Skipped = append(out.Skipped, id)
+			continue
+		}
+		out.Succeeded++
+	}
+	return out, nil
+}
+
+// splitOwned separates the distinct ids userID owns from the others, keeping
+// the order they were given in.
+func (s *urlService) splitOwned(userID uint, ids []uint) (owned, skipped []uint, err error) {
+	found, err := s.repo.FindOwnedIDs(userID, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	isOwned := make(map[uint]bool, len(found))
+	for _, id := range found {
+		isOwned[id] = true
+	}
+	seen := make(map[uint]bool, len(ids))
+	skipped = []uint{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if isOwned[id] {
+			owned = append(owned, id)
+		} else {
+			skipped = append(skipped, id)
+		}
+	}
+	return owned, skipped, nil
+}
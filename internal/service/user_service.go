@@ -1,12 +1,20 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
+	"github.com/fuzumoe/linkTorch-api/internal/storage"
 )
 
 type UserService interface {
@@ -16,14 +24,117 @@ type UserService interface {
 	Get(id uint) (*model.UserDTO, error)
 	Search(searchTerm, searchField, sortDirection string, p repository.Pagination) ([]*model.UserDTO, error)
 	Delete(id uint) error
+	DeleteAccount(id uint, password string) error
+	RequestPasswordReset(email string) (token string, err error)
+	ResetPassword(token, newPassword string) error
+	RequestEmailVerification(id uint) (token string, err error)
+	VerifyEmail(token string) error
+}
+
+// ErrUsernameTaken is returned when another user already has the username.
+var ErrUsernameTaken = errors.New("username taken")
+
+// ErrWrongPassword is returned when a confirmation password does not match.
+var ErrWrongPassword = errors.New("wrong password")
+
+// ErrResetTokenInvalid is returned for a password reset token that is
+// malformed, expired or already used.
+var ErrResetTokenInvalid = errors.New("invalid password reset token")
+
+// ErrPasswordResetDisabled is returned by a user service created without
+// UserServiceOptions.Tokens.
+var ErrPasswordResetDisabled = errors.New("password reset not configured")
+
+// ErrVerificationTokenInvalid is returned for an email verification token
+// that is malformed, expired or issued for an email the user no longer has.
+var ErrVerificationTokenInvalid = errors.New("invalid email verification token")
+
+// ErrEmailAlreadyVerified is returned when a verification token is requested
+// for a user whose email is already verified.
+var ErrEmailAlreadyVerified = errors.New("email already verified")
+
+// ErrVerificationRateLimited is returned when a user asks for another
+// verification token before EmailVerification.ResendInterval has passed.
+var ErrVerificationRateLimited = errors.New("email verification requested too often")
+
+// ErrEmailVerificationDisabled is returned by a user service created without
+// UserServiceOptions.EmailVerification.
+var ErrEmailVerificationDisabled = errors.New("email verification not configured")
+
+// EmailVerification configures the tokens that prove a user owns their email.
+type EmailVerification struct {
+	// TTL is how long a verification token can be redeemed.
+	TTL time.Duration
+	// ResendInterval is the minimum time between two tokens for one user;
+	// zero disables the limit.
+	ResendInterval time.Duration
 }
 
 type userService struct {
 	repo repository.UserRepository
+	// usernameCaseSensitive treats "Alice" and "alice" as different usernames.
+	usernameCaseSensitive bool
+	// rawHTML holds crawled bodies, removed with the account; nil if not stored.
+	rawHTML storage.BlobStore
+	// tokenRepo, jwtSecret and resetTTL sign and redeem password reset
+	// tokens; tokenRepo is nil if password reset is disabled.
+	tokenRepo repository.TokenRepository
+	jwtSecret string
+	resetTTL  time.Duration
+	// verify is nil if email verification is disabled; verifySent holds
+	// when each user was last sent a verification token.
+	verify     *EmailVerification
+	verifyMu   sync.Mutex
+	verifySent map[uint]time.Time
+}
+
+// UserServiceOptions configures the optional behaviour of a user service.
+// The zero value compares usernames case-insensitively and leaves password
+// reset and email verification disabled.
+type UserServiceOptions struct {
+	// UsernameCaseSensitive treats "Alice" and "alice" as different usernames.
+	UsernameCaseSensitive bool
+	// RawHTML is where crawled bodies are stored, so erasing an account
+	// removes them too.
+	RawHTML storage.BlobStore
+	// Tokens enables password reset; reset tokens are signed with JWTSecret
+	// and valid for ResetTTL.
+	Tokens    repository.TokenRepository
+	JWTSecret string
+	ResetTTL  time.Duration
+	// EmailVerification, when set, enables email verification tokens, which
+	// are signed with JWTSecret too.
+	EmailVerification *EmailVerification
 }
 
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{repo: repo}
+// NewUserService creates a user service configured by opts.
+func NewUserService(repo repository.UserRepository, opts UserServiceOptions) UserService {
+	s := &userService{
+		repo:                  repo,
+		usernameCaseSensitive: opts.UsernameCaseSensitive,
+		rawHTML:               opts.RawHTML,
+		tokenRepo:             opts.Tokens,
+		jwtSecret:             opts.JWTSecret,
+		resetTTL:              opts.ResetTTL,
+	}
+	if opts.EmailVerification != nil {
+		verify := *opts.EmailVerification
+		s.verify = &verify
+		s.verifySent = make(map[uint]time.Time)
+	}
+	return s
+}
+
+// checkUsername returns ErrUsernameTaken if a user other than id has the username.
+func (s *userService) checkUsername(username string, id uint) error {
+	taken, err := s.repo.UsernameTaken(username, id, !s.usernameCaseSensitive)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrUsernameTaken
+	}
+	return nil
 }
 
 func (s *userService) Register(input *model.CreateUserInput) (*model.UserDTO, error) {
@@ -31,6 +142,9 @@ func (s *userService) Register(input *model.CreateUserInput) (*model.UserDTO, er
 	if existing, _ := s.repo.FindByEmail(input.Email); existing != nil {
 		return nil, errors.New("email already in use")
 	}
+	if err := s.checkUsername(input.Username, 0); err != nil {
+		return nil, err
+	}
 
 	hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -53,11 +167,16 @@ func (s *userService) Update(id uint, input *model.UpdateUserInput) (*model.User
 	if err != nil {
 		return nil, err
 	}
-	if input.Username != nil {
+	if input.Username != nil && *input.Username != u.Username {
+		if err := s.checkUsername(*input.Username, id); err != nil {
+			return nil, err
+		}
 		u.Username = *input.Username
 	}
-	if input.Email != nil {
+	emailChanged := false
+	if input.Email != nil && *input.Email != u.Email {
 		u.Email = *input.Email
+		emailChanged = true
 	}
 	if input.Password != nil {
 		hash, err := bcrypt.GenerateFromPassword([]byte(*input.Password), bcrypt.DefaultCost)
@@ -72,6 +191,13 @@ func (s *userService) Update(id uint, input *model.UpdateUserInput) (*model.User
 	if err := s.repo.Update(id, u); err != nil {
 		return nil, err
 	}
+	// A new address has to be verified again.
+	if emailChanged && u.EmailVerified {
+		if err := s.repo.SetEmailVerified(id, false); err != nil {
+			return nil, err
+		}
+		u.EmailVerified = false
+	}
 	return u.ToDTO(), nil
 }
 
@@ -109,3 +235,176 @@ func (s *userService) Search(searchTerm, searchField, sortDirection string, p re
 func (s *userService) Delete(id uint) error {
 	return s.repo.Delete(id)
 }
+
+// DeleteAccount permanently erases the user and all their data once
+// password confirms it is them. The audit log line carries no identifier
+// of the erased user.
+func (s *userService) DeleteAccount(id uint, password string) error {
+	u, err := s.repo.FindByID(id)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) != nil {
+		return ErrWrongPassword
+	}
+
+	erased, err := s.repo.Erase(id)
+	if err != nil {
+		return err
+	}
+
+	blobsLeft := 0
+	if s.rawHTML != nil {
+		for _, key := range erased.RawHTMLKeys {
+			err := s.rawHTML.Delete(context.Background(), key)
+			if err != nil && !errors.Is(err, storage.ErrBlobNotFound) {
+				blobsLeft++
+			}
+		}
+	}
+	log.Printf("[AUDIT] account erased: urls=%d analysis_results=%d links=%d tags=%d tokens_revoked=%d raw_html=%d raw_html_failed=%d",
+		erased.URLs, erased.AnalysisResults, erased.Links, erased.Tags, erased.TokensRevoked, len(erased.RawHTMLKeys), blobsLeft)
+	return nil
+}
+
+// RequestPasswordReset returns a single-use token that lets the owner of
+// email choose a new password. For an unknown email it returns an empty
+// token and no error, so callers cannot tell which emails are registered.
+func (s *userService) RequestPasswordReset(email string) (string, error) {
+	if s.tokenRepo == nil {
+		return "", ErrPasswordResetDisabled
+	}
+	u, err := s.repo.FindByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		UserID: u.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.resetTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        generateTokenID(),
+			Subject:   passwordResetSubject,
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return "", err
+	}
+	// Recorded so that a later reset or account erasure revokes it.
+	if err := s.tokenRepo.AddIssued(issuedRecord(claims)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ResetPassword sets a new password for the user a reset token was issued
+// to. The token is used up even if setting the password then fails. All
+// other tokens of the user, including their sessions, are revoked.
+func (s *userService) ResetPassword(token, newPassword string) error {
+	if s.tokenRepo == nil {
+		return ErrPasswordResetDisabled
+	}
+	claims, err := verifyToken(s.jwtSecret, token)
+	if err != nil || claims.Subject != passwordResetSubject {
+		return ErrResetTokenInvalid
+	}
+	err = s.tokenRepo.Consume(claims.ID, claims.ExpiresAt.Time)
+	if errors.Is(err, repository.ErrTokenUsed) {
+		return ErrResetTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	u, err := s.repo.FindByID(claims.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrResetTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.Password = string(hash)
+	if err := s.repo.Update(u.ID, u); err != nil {
+		return err
+	}
+
+	_, err = s.tokenRepo.RevokeUserTokens(u.ID, "")
+	return err
+}
+
+// RequestEmailVerification returns a token that proves the user owns their
+// current email once redeemed with VerifyEmail. A user can get a new token
+// at most once per EmailVerification.ResendInterval.
+func (s *userService) RequestEmailVerification(id uint) (string, error) {
+	if s.verify == nil {
+		return "", ErrEmailVerificationDisabled
+	}
+	u, err := s.repo.FindByID(id)
+	if err != nil {
+		return "", err
+	}
+	if u.EmailVerified {
+		return "", ErrEmailAlreadyVerified
+	}
+
+	now := time.Now()
+	s.verifyMu.Lock()
+	last, sent := s.verifySent[id]
+	if sent && now.Sub(last) < s.verify.ResendInterval {
+		s.verifyMu.Unlock()
+		return "", ErrVerificationRateLimited
+	}
+	s.verifySent[id] = now
+	s.verifyMu.Unlock()
+
+	// The token names the email it verifies, so it stops working once the
+	// user changes their email.
+	claims := &Claims{
+		UserID: u.ID,
+		Email:  u.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.verify.TTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        generateTokenID(),
+			Subject:   emailVerificationSubject,
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.jwtSecret))
+}
+
+// VerifyEmail marks the user a verification token was issued to as having
+// verified their email. Redeeming a token again is harmless.
+func (s *userService) VerifyEmail(token string) error {
+	if s.verify == nil {
+		return ErrEmailVerificationDisabled
+	}
+	claims, err := verifyToken(s.jwtSecret, token)
+	if err != nil || claims.Subject != emailVerificationSubject {
+		return ErrVerificationTokenInvalid
+	}
+
+	u, err := s.repo.FindByID(claims.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrVerificationTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(u.Email, claims.Email) {
+		return ErrVerificationTokenInvalid
+	}
+	if u.EmailVerified {
+		return nil
+	}
+	return s.repo.SetEmailVerified(u.ID, true)
+}
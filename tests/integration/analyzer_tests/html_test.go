@@ -44,7 +44,7 @@ func TestHTMLAnalyzer_Integration(t *testing.T) {
 	baseURL, err := url.Parse(ts.URL)
 	require.NoError(t, err)
 
-	analyzerInstance := analyzer.NewHTMLAnalyzer()
+	analyzerInstance := analyzer.NewHTMLAnalyzer(analyzer.Options{})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -48,16 +48,22 @@ func (d *dummyCrawlerPool) Start(ctx context.Context) {
 	}
 }
 
-func (d *dummyCrawlerPool) Enqueue(id uint) {
+func (d *dummyCrawlerPool) Enqueue(id uint) error {
 	if d.EnqueueFunc != nil {
 		d.EnqueueFunc(id)
 	}
+	return nil
 }
 
-func (d *dummyCrawlerPool) EnqueueWithPriority(id uint, priority int) {
+func (d *dummyCrawlerPool) EnqueueWithPriority(id uint, priority int) error {
 	if d.EnqueuePriorityFunc != nil {
 		d.EnqueuePriorityFunc(id, priority)
 	}
+	return nil
+}
+
+func (d *dummyCrawlerPool) EnqueueWithRequestID(id uint, priority int, requestID string) error {
+	return d.EnqueueWithPriority(id, priority)
 }
 
 func (d *dummyCrawlerPool) Shutdown() {
@@ -79,6 +85,32 @@ func (d *dummyCrawlerPool) AdjustWorkers(cmd crawler.ControlCommand) {
 	}
 }
 
+func (d *dummyCrawlerPool) Reprioritize(id uint, priority int) error {
+	return nil
+}
+
+func (d *dummyCrawlerPool) Snapshot(limit int) crawler.QueueSnapshot {
+	return crawler.QueueSnapshot{}
+}
+
+func (d *dummyCrawlerPool) QueueDepth() int {
+	return 0
+}
+
+func (d *dummyCrawlerPool) Workers() int {
+	return 1
+}
+
+func (d *dummyCrawlerPool) Pause()       {}
+func (d *dummyCrawlerPool) Resume()      {}
+func (d *dummyCrawlerPool) Paused() bool { return false }
+
+func (d *dummyCrawlerPool) Running() bool { return true }
+
+func (d *dummyCrawlerPool) Stats() crawler.PoolStats {
+	return crawler.PoolStats{}
+}
+
 func TestAppRun_Integration(t *testing.T) {
 	utils.SetupTest(t)
 	defer utils.CleanTestData(t)
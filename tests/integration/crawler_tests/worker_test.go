@@ -3,6 +3,8 @@ package crawler_test
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/fuzumoe/linkTorch-api/internal/analyzer"
 	"github.com/fuzumoe/linkTorch-api/internal/crawler"
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
@@ -210,3 +213,36 @@ func TestWorkerIntegration(t *testing.T) {
 		})
 	})
 }
+
+func TestWorkerIntegration_ServerErrorStatusCode(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	user := model.User{Username: "status_worker", Email: "status_worker@example.com", Password: "password123"}
+	require.NoError(t, db.Create(&user).Error)
+	failing := model.URL{UserID: user.ID, OriginalURL: ts.URL + "/down", Status: model.StatusQueued}
+	require.NoError(t, db.Create(&failing).Error)
+
+	urlRepo := repository.NewURLRepo(db)
+	worker := crawler.NewWorker(1, context.Background(), urlRepo, analyzer.NewHTMLAnalyzer(analyzer.Options{}), 5*time.Second, nil)
+	tasks := make(chan uint, 1)
+	tasks <- failing.ID
+	close(tasks)
+	worker.Run(tasks)
+
+	var updated model.URL
+	require.NoError(t, db.First(&updated, failing.ID).Error)
+	assert.Equal(t, model.StatusError, updated.Status)
+
+	// The crawl failed, yet the URL is found by the status it answered with.
+	filter := repository.URLFilter{StatusCode: http.StatusInternalServerError}
+	urls, err := urlRepo.ListByUser(user.ID, filter, repository.Pagination{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	assert.Equal(t, failing.ID, urls[0].ID)
+}
@@ -29,7 +29,7 @@ func TestAuthHandler(t *testing.T) {
 	userRepo := repository.NewUserRepo(db)
 	tokenRepo := repository.NewTokenRepo(db)
 	authSvc := service.NewAuthService(userRepo, tokenRepo, "test-secret", time.Hour)
-	userSvc := service.NewUserService(userRepo)
+	userSvc := service.NewUserService(userRepo, service.UserServiceOptions{})
 
 	authHandler := handler.NewAuthHandler(authSvc, userSvc)
 
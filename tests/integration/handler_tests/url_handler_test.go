@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -15,6 +16,7 @@ import (
 	"github.com/fuzumoe/linkTorch-api/internal/crawler"
 	"github.com/fuzumoe/linkTorch-api/internal/handler"
 	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/report"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
 )
 
@@ -27,6 +29,14 @@ func (m *MockURLService) Create(input *model.CreateURLInputDTO) (uint, error) {
 	return args.Get(0).(uint), args.Error(1)
 }
 
+func (m *MockURLService) CreateWithCrawl(input *model.CreateURLInputDTO) (*model.URLCreatedDTO, error) {
+	args := m.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.URLCreatedDTO), args.Error(1)
+}
+
 func (m *MockURLService) Get(id uint) (*model.URLDTO, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
@@ -35,8 +45,8 @@ func (m *MockURLService) Get(id uint) (*model.URLDTO, error) {
 	return args.Get(0).(*model.URLDTO), args.Error(1)
 }
 
-func (m *MockURLService) List(userID uint, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error) {
-	args := m.Called(userID, p)
+func (m *MockURLService) List(userID uint, f repository.URLFilter, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error) {
+	args := m.Called(userID, f, p)
 	return args.Get(0).(*model.PaginatedResponse[model.URLDTO]), args.Error(1)
 }
 
@@ -50,7 +60,7 @@ func (m *MockURLService) Delete(id uint) error {
 	return args.Error(0)
 }
 
-func (m *MockURLService) Start(id uint) error {
+func (m *MockURLService) Start(id uint, requestID string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
@@ -60,11 +70,16 @@ func (m *MockURLService) Stop(id uint) error {
 	return args.Error(0)
 }
 
-func (m *MockURLService) StartWithPriority(id uint, priority int) error {
+func (m *MockURLService) StartWithPriority(id uint, priority int, requestID string) error {
 	args := m.Called(id, priority)
 	return args.Error(0)
 }
 
+func (m *MockURLService) Reprioritize(id, userID uint, priority int) error {
+	args := m.Called(id, userID, priority)
+	return args.Error(0)
+}
+
 func (m *MockURLService) Results(id uint) (*model.URLDTO, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
@@ -92,6 +107,140 @@ func (m *MockURLService) ResultsWithDetails(id uint) (*model.URL, []*model.Analy
 	return url, analysisResults, links, args.Error(3)
 }
 
+func (m *MockURLService) Merge(id, intoID, userID uint) error {
+	args := m.Called(id, intoID, userID)
+	return args.Error(0)
+}
+
+func (m *MockURLService) Validate(userID uint, urls []string) ([]model.URLValidationDTO, error) {
+	args := m.Called(userID, urls)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.URLValidationDTO), args.Error(1)
+}
+
+func (m *MockURLService) Report(id, userID uint) (*report.URLReport, error) {
+	args := m.Called(id, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*report.URLReport), args.Error(1)
+}
+
+func (m *MockURLService) ListUncrawled(userID uint, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error) {
+	args := m.Called(userID, p)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PaginatedResponse[model.URLDTO]), args.Error(1)
+}
+
+func (m *MockURLService) DeleteErrored(userID uint) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLService) CreateBulk(inputs []*model.CreateURLInputDTO) ([]uint, error) {
+	args := m.Called(inputs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockURLService) DeleteBulk(userID uint, ids []uint) (*model.BulkURLActionResultDTO, error) {
+	args := m.Called(userID, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.BulkURLActionResultDTO), args.Error(1)
+}
+
+func (m *MockURLService) StartBulk(userID uint, ids []uint) (*model.BulkURLActionResultDTO, error) {
+	args := m.Called(userID, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.BulkURLActionResultDTO), args.Error(1)
+}
+
+func (m *MockURLService) TagReport(userID uint, tag string, p repository.Pagination) (*model.PaginatedResponse[model.TagReportItemDTO], error) {
+	args := m.Called(userID, tag, p)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PaginatedResponse[model.TagReportItemDTO]), args.Error(1)
+}
+
+func (m *MockURLService) TagSummary(userID uint, tag string) (*model.TagSummaryDTO, error) {
+	args := m.Called(userID, tag)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TagSummaryDTO), args.Error(1)
+}
+
+func (m *MockURLService) RecrawlTag(userID uint, tag string, priority int) (*model.BulkURLActionResultDTO, error) {
+	args := m.Called(userID, tag, priority)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.BulkURLActionResultDTO), args.Error(1)
+}
+
+func (m *MockURLService) ListDeleted(userID uint, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error) {
+	args := m.Called(userID, p)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PaginatedResponse[model.URLDTO]), args.Error(1)
+}
+
+func (m *MockURLService) Restore(id, userID uint) error {
+	args := m.Called(id, userID)
+	return args.Error(0)
+}
+
+func (m *MockURLService) ListCursor(userID uint, f repository.URLFilter, c repository.Cursor) (*model.CursorResponse[model.URLDTO], error) {
+	args := m.Called(userID, f, c)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.CursorResponse[model.URLDTO]), args.Error(1)
+}
+
+func (m *MockURLService) Purge(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockURLService) PurgeDeleted(olderThan time.Duration) (int, error) {
+	args := m.Called(olderThan)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLService) ExportURLs(userID uint) (*model.URLExportDocument, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.URLExportDocument), args.Error(1)
+}
+
+func (m *MockURLService) ImportURLs(userID uint, doc *model.URLExportDocument) (*model.URLImportResultDTO, error) {
+	args := m.Called(userID, doc)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.URLImportResultDTO), args.Error(1)
+}
+
+func (m *MockURLService) Clone(id, userID uint, originalURL string) (uint, error) {
+	args := m.Called(id, userID, originalURL)
+	return args.Get(0).(uint), args.Error(1)
+}
+
 func (m *MockURLService) GetCrawlResults() <-chan crawler.CrawlResult {
 	args := m.Called()
 	return args.Get(0).(<-chan crawler.CrawlResult)
@@ -102,6 +251,29 @@ func (m *MockURLService) AdjustCrawlerWorkers(action string, count int) error {
 	return args.Error(0)
 }
 
+func (m *MockURLService) PauseCrawler() {
+	m.Called()
+}
+
+func (m *MockURLService) ResumeCrawler() {
+	m.Called()
+}
+
+func (m *MockURLService) CrawlerStatus() *model.CrawlerStatusDTO {
+	args := m.Called()
+	return args.Get(0).(*model.CrawlerStatusDTO)
+}
+
+func (m *MockURLService) CrawlerStats() crawler.PoolStats {
+	args := m.Called()
+	return args.Get(0).(crawler.PoolStats)
+}
+
+func (m *MockURLService) CrawlerQueue(limit int) crawler.QueueSnapshot {
+	args := m.Called(limit)
+	return args.Get(0).(crawler.QueueSnapshot)
+}
+
 func setupHandler(t *testing.T) (*gin.Engine, *MockURLService) {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -123,10 +295,10 @@ func setupHandler(t *testing.T) (*gin.Engine, *MockURLService) {
 func TestCreate(t *testing.T) {
 	r, urlService := setupHandler(t)
 
-	urlService.On("Create", &model.CreateURLInputDTO{
+	urlService.On("CreateWithCrawl", &model.CreateURLInputDTO{
 		OriginalURL: "http://example.com",
 		UserID:      uint(1),
-	}).Return(uint(1), nil)
+	}).Return(&model.URLCreatedDTO{ID: 1}, nil)
 
 	reqBody := []byte(`{"original_url":"http://example.com"}`)
 	req, _ := http.NewRequest(http.MethodPost, "/api/urls", bytes.NewBuffer(reqBody))
@@ -175,7 +347,7 @@ func TestGet(t *testing.T) {
 func TestList(t *testing.T) {
 	r, urlService := setupHandler(t)
 
-	urlService.On("List", uint(1), repository.Pagination{
+	urlService.On("List", uint(1), repository.URLFilter{}, repository.Pagination{
 		Page:     1,
 		PageSize: 10,
 	}).Return(&model.PaginatedResponse[model.URLDTO]{
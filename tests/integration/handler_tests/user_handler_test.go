@@ -51,6 +51,31 @@ func (m *MockUserService) Delete(id uint) error {
 	return args.Error(0)
 }
 
+func (m *MockUserService) DeleteAccount(id uint, password string) error {
+	args := m.Called(id, password)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RequestPasswordReset(email string) (string, error) {
+	args := m.Called(email)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserService) ResetPassword(token, newPassword string) error {
+	args := m.Called(token, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RequestEmailVerification(id uint) (string, error) {
+	args := m.Called(id)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserService) VerifyEmail(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
 func (m *MockUserService) Authenticate(email, password string) (*model.UserDTO, error) {
 	args := m.Called(email, password)
 	if args.Get(0) == nil {
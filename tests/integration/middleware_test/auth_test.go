@@ -57,6 +57,16 @@ func (m *MockAuthService) Generate(userID uint) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockAuthService) GeneratePair(userID uint) (string, string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockAuthService) Refresh(refreshToken string) (string, string, error) {
+	args := m.Called(refreshToken)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
 func (m *MockAuthService) Invalidate(tokenID string) error {
 	args := m.Called(tokenID)
 	return args.Error(0)
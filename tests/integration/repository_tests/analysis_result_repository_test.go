@@ -2,9 +2,11 @@ package repository_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
@@ -160,3 +162,231 @@ func TestAnalysisResultRepo_Integration(t *testing.T) {
 
 	utils.CleanTestData(t)
 }
+
+func TestAnalysisResultRepo_HTMLVersionCounts_Integration(t *testing.T) {
+
+	db := utils.SetupTest(t)
+
+	analysisRepo := repository.NewAnalysisResultRepo(db)
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "versionowner", Email: "versionowner@example.com", Password: "password123"}
+	other := &model.User{Username: "versionother", Email: "versionother@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+	require.NoError(t, userRepo.Create(other))
+
+	seed := func(userID uint, rawURL string, versions ...string) {
+		u := &model.URL{UserID: userID, OriginalURL: rawURL, Status: model.StatusDone}
+		require.NoError(t, urlRepo.Create(u))
+		for _, v := range versions {
+			require.NoError(t, analysisRepo.Create(&model.AnalysisResult{URLID: u.ID, HTMLVersion: v}, nil))
+		}
+	}
+
+	seed(owner.ID, "https://versions-a.com", "HTML 5")
+	seed(owner.ID, "https://versions-b.com", "HTML 5")
+	// Only the latest analysis counts: this URL was legacy but is now HTML 5.
+	seed(owner.ID, "https://versions-c.com", "html 4.01 transitional", "HTML 5")
+	seed(owner.ID, "https://versions-d.com", "html 4.01 transitional")
+	seed(owner.ID, "https://versions-e.com", "unknown")
+	seed(other.ID, "https://versions-other.com", "unknown", "unknown")
+
+	t.Run("Distribution", func(t *testing.T) {
+		counts, err := analysisRepo.HTMLVersionCounts(owner.ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, []model.HTMLVersionCountDTO{
+			{HTMLVersion: "HTML 5", Count: 3},
+			{HTMLVersion: "html 4.01 transitional", Count: 1},
+			{HTMLVersion: "unknown", Count: 1},
+		}, counts)
+	})
+
+	t.Run("No URLs", func(t *testing.T) {
+		counts, err := analysisRepo.HTMLVersionCounts(999999)
+		require.NoError(t, err)
+		assert.Empty(t, counts)
+	})
+
+	utils.CleanTestData(t)
+}
+
+func TestAnalysisResultRepo_Timings_Integration(t *testing.T) {
+
+	db := utils.SetupTest(t)
+
+	analysisRepo := repository.NewAnalysisResultRepo(db)
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "timingsowner", Email: "timingsowner@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+
+	timed := &model.URL{UserID: owner.ID, OriginalURL: "https://timings-a.com", Status: model.StatusDone}
+	untimed := &model.URL{UserID: owner.ID, OriginalURL: "https://timings-b.com", Status: model.StatusQueued}
+	require.NoError(t, urlRepo.Create(timed))
+	require.NoError(t, urlRepo.Create(untimed))
+
+	// A run from before durations were recorded must not skew the stats.
+	require.NoError(t, analysisRepo.Create(&model.AnalysisResult{URLID: timed.ID, HTMLVersion: "HTML 5"}, nil))
+	for _, ms := range []int64{300, 100, 500, 200} {
+		d := ms
+		require.NoError(t, analysisRepo.Create(&model.AnalysisResult{URLID: timed.ID, HTMLVersion: "HTML 5", CrawlDurationMs: &d}, nil))
+	}
+
+	t.Run("Stats", func(t *testing.T) {
+		timings, err := analysisRepo.Timings(timed.ID, owner.ID)
+		require.NoError(t, err)
+
+		assert.Equal(t, timed.ID, timings.URLID)
+		assert.Equal(t, 4, timings.Runs)
+		require.NotNil(t, timings.MinMs)
+		assert.Equal(t, int64(100), *timings.MinMs)
+		require.NotNil(t, timings.MaxMs)
+		assert.Equal(t, int64(500), *timings.MaxMs)
+		require.NotNil(t, timings.AvgMs)
+		assert.InDelta(t, 275.0, *timings.AvgMs, 0.001)
+		require.NotNil(t, timings.LatestMs)
+		assert.Equal(t, int64(200), *timings.LatestMs)
+	})
+
+	t.Run("No Runs", func(t *testing.T) {
+		timings, err := analysisRepo.Timings(untimed.ID, owner.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 0, timings.Runs)
+		assert.Nil(t, timings.MinMs)
+		assert.Nil(t, timings.LatestMs)
+	})
+
+	t.Run("Other User", func(t *testing.T) {
+		_, err := analysisRepo.Timings(timed.ID, owner.ID+1000)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	utils.CleanTestData(t)
+}
+
+func TestAnalysisResultRepo_LatestRawHTMLKey_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	analysisRepo := repository.NewAnalysisResultRepo(db)
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "rawowner", Email: "rawowner@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+
+	page := &model.URL{UserID: owner.ID, OriginalURL: "https://raw.example.com", Status: model.StatusDone}
+	require.NoError(t, urlRepo.Create(page))
+
+	require.NoError(t, analysisRepo.Create(&model.AnalysisResult{URLID: page.ID, HTMLVersion: "HTML 5", RawHTMLKey: "raw/a/1.html"}, nil))
+	require.NoError(t, analysisRepo.Create(&model.AnalysisResult{URLID: page.ID, HTMLVersion: "HTML 5", RawHTMLKey: "raw/a/2.html"}, nil))
+	// A later run without a stored copy must not hide the previous one.
+	require.NoError(t, analysisRepo.Create(&model.AnalysisResult{URLID: page.ID, HTMLVersion: "HTML 5"}, nil))
+
+	key, err := analysisRepo.LatestRawHTMLKey(page.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "raw/a/2.html", key)
+
+	_, err = analysisRepo.LatestRawHTMLKey(page.ID, owner.ID+1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "Other users must not see the URL's raw HTML")
+}
+
+func TestAnalysisResultRepo_LatestProvenance_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	analysisRepo := repository.NewAnalysisResultRepo(db)
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "provowner", Email: "provowner@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+
+	page := &model.URL{UserID: owner.ID, OriginalURL: "https://prov.example.com", Status: model.StatusDone}
+	require.NoError(t, urlRepo.Create(page))
+
+	_, err := analysisRepo.LatestProvenance(page.ID, owner.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "A URL without results has no provenance")
+
+	started := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, analysisRepo.Create(&model.AnalysisResult{URLID: page.ID, HTMLVersion: "HTML 5",
+		Provenance: &model.Provenance{WorkerID: 1, Attempts: 1, Analyzer: "html", RenderMode: model.RenderModeFull}}, nil))
+	runID := "4a7c1e0e-9d8b-4c55-8f0e-2b2f1a3c9d10"
+	latest := &model.AnalysisResult{URLID: page.ID, HTMLVersion: "HTML 5", RunID: &runID,
+		Provenance: &model.Provenance{
+			WorkerID:   4,
+			Attempts:   2,
+			Analyzer:   "html",
+			RenderMode: model.RenderModeHead,
+			StartedAt:  started,
+			FinishedAt: started.Add(time.Second),
+			HostWaitMs: 5,
+			AnalyzeMs:  700,
+			TotalMs:    1000,
+		}}
+	require.NoError(t, analysisRepo.Create(latest, nil))
+
+	prov, err := analysisRepo.LatestProvenance(page.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, latest.ID, prov.ResultID)
+	assert.Equal(t, page.ID, prov.URLID)
+	assert.Equal(t, runID, prov.RunID)
+	assert.Equal(t, 4, prov.WorkerID)
+	assert.Equal(t, 2, prov.Attempts)
+	assert.Equal(t, model.RenderModeHead, prov.RenderMode)
+	assert.Equal(t, int64(700), prov.AnalyzeMs)
+	assert.True(t, started.Equal(prov.StartedAt))
+
+	_, err = analysisRepo.LatestProvenance(page.ID, owner.ID+1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "Other users must not see the URL's provenance")
+}
+
+func TestAnalysisResultRepo_RecentByUser_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	analysisRepo := repository.NewAnalysisResultRepo(db)
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "recentowner", Email: "recentowner@example.com", Password: "password123"}
+	other := &model.User{Username: "recentother", Email: "recentother@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+	require.NoError(t, userRepo.Create(other))
+
+	now := time.Now().Truncate(time.Second)
+	crawl := func(userID uint, rawURL string, ages ...time.Duration) *model.URL {
+		u := &model.URL{UserID: userID, OriginalURL: rawURL, Status: model.StatusDone}
+		require.NoError(t, urlRepo.Create(u))
+		for _, age := range ages {
+			res := &model.AnalysisResult{URLID: u.ID, HTMLVersion: "HTML 5"}
+			require.NoError(t, analysisRepo.Create(res, nil))
+			require.NoError(t, db.Model(res).UpdateColumn("created_at", now.Add(-age)).Error)
+		}
+		return u
+	}
+
+	oldest := crawl(owner.ID, "https://recent.example.com/oldest", 72*time.Hour)
+	// Crawled long ago and again recently; the latest crawl counts.
+	recrawled := crawl(owner.ID, "https://recent.example.com/recrawled", 96*time.Hour, time.Hour)
+	middle := crawl(owner.ID, "https://recent.example.com/middle", 24*time.Hour)
+	crawl(owner.ID, "https://recent.example.com/never")
+	crawl(other.ID, "https://recent.example.com/other", time.Minute)
+
+	recent, err := analysisRepo.RecentByUser(owner.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, recent, 3, "Never crawled and other users' URLs are left out")
+	assert.Equal(t, recrawled.ID, recent[0].URLID)
+	assert.Equal(t, middle.ID, recent[1].URLID)
+	assert.Equal(t, oldest.ID, recent[2].URLID)
+	assert.Equal(t, "https://recent.example.com/recrawled", recent[0].OriginalURL)
+	assert.Equal(t, model.StatusDone, recent[0].Status)
+	assert.WithinDuration(t, now.Add(-time.Hour), recent[0].LastCrawledAt, time.Second)
+
+	limited, err := analysisRepo.RecentByUser(owner.ID, 2)
+	require.NoError(t, err)
+	assert.Len(t, limited, 2)
+}
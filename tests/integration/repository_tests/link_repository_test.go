@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
 	"fmt"
 
@@ -155,3 +156,317 @@ func TestLinkRepo_Integration(t *testing.T) {
 
 	utils.CleanTestData(t)
 }
+
+func TestLinkRepo_ListByUser_Integration(t *testing.T) {
+
+	db := utils.SetupTest(t)
+
+	linkRepo := repository.NewLinkRepo(db)
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	defaultPage := repository.Pagination{Page: 1, PageSize: 10}
+
+	owner := &model.User{Username: "explorer", Email: "explorer@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+	stranger := &model.User{Username: "stranger", Email: "stranger@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(stranger))
+
+	firstURL := &model.URL{UserID: owner.ID, OriginalURL: "https://first.example.com", Status: "done"}
+	require.NoError(t, urlRepo.Create(firstURL))
+	secondURL := &model.URL{UserID: owner.ID, OriginalURL: "https://second.example.com", Status: "done"}
+	require.NoError(t, urlRepo.Create(secondURL))
+	strangerURL := &model.URL{UserID: stranger.ID, OriginalURL: "https://stranger.example.com", Status: "done"}
+	require.NoError(t, urlRepo.Create(strangerURL))
+
+	require.NoError(t, db.Create(&model.AnalysisResult{URLID: firstURL.ID, HTMLVersion: "HTML 5", Title: "First Page"}).Error)
+
+	for _, l := range []*model.Link{
+		{URLID: firstURL.ID, Href: "https://first.example.com/about", IsExternal: false, StatusCode: 200},
+		{URLID: firstURL.ID, Href: "https://cdn.other.com/old/lib.js", IsExternal: true, StatusCode: 404},
+		{URLID: secondURL.ID, Href: "https://second.example.com/old/page", IsExternal: false, StatusCode: 500},
+		{URLID: secondURL.ID, Href: "https://partner.com/", IsExternal: true, StatusCode: 200},
+		{URLID: strangerURL.ID, Href: "https://stranger.example.com/old", IsExternal: false, StatusCode: 404},
+	} {
+		require.NoError(t, linkRepo.Create(l))
+	}
+
+	t.Run("Scoped To User", func(t *testing.T) {
+		links, err := linkRepo.ListByUser(owner.ID, repository.LinkFilter{}, defaultPage)
+		require.NoError(t, err)
+		assert.Len(t, links, 4)
+		for _, l := range links {
+			assert.Contains(t, []uint{firstURL.ID, secondURL.ID}, l.URLID)
+		}
+
+		count, err := linkRepo.CountByUser(owner.ID, repository.LinkFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, 4, count)
+	})
+
+	t.Run("Includes Owning URL", func(t *testing.T) {
+		links, err := linkRepo.ListByUser(owner.ID, repository.LinkFilter{}, defaultPage)
+		require.NoError(t, err)
+		for _, l := range links {
+			switch l.URLID {
+			case firstURL.ID:
+				assert.Equal(t, firstURL.OriginalURL, l.OriginalURL)
+				assert.Equal(t, "First Page", l.URLTitle)
+			case secondURL.ID:
+				assert.Equal(t, secondURL.OriginalURL, l.OriginalURL)
+				assert.Empty(t, l.URLTitle)
+			}
+		}
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		external, broken := true, true
+
+		links, err := linkRepo.ListByUser(owner.ID, repository.LinkFilter{IsExternal: &external}, defaultPage)
+		require.NoError(t, err)
+		assert.Len(t, links, 2)
+
+		links, err = linkRepo.ListByUser(owner.ID, repository.LinkFilter{Broken: &broken}, defaultPage)
+		require.NoError(t, err)
+		assert.Len(t, links, 2)
+		for _, l := range links {
+			assert.GreaterOrEqual(t, l.StatusCode, 400)
+		}
+
+		links, err = linkRepo.ListByUser(owner.ID, repository.LinkFilter{Broken: &broken, Search: "/old/"}, defaultPage)
+		require.NoError(t, err)
+		assert.Len(t, links, 2)
+
+		links, err = linkRepo.ListByUser(owner.ID, repository.LinkFilter{IsExternal: &external, Broken: &broken}, defaultPage)
+		require.NoError(t, err)
+		require.Len(t, links, 1)
+		assert.Equal(t, "https://cdn.other.com/old/lib.js", links[0].Href)
+
+		count, err := linkRepo.CountByUser(owner.ID, repository.LinkFilter{IsExternal: &external, Broken: &broken})
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("Href Pattern And Status Class", func(t *testing.T) {
+		filter := repository.LinkFilter{HrefPattern: "%/old/%", StatusClass: "4xx"}
+		links, err := linkRepo.ListByUser(owner.ID, filter, defaultPage)
+		require.NoError(t, err)
+		require.Len(t, links, 1, "the stranger's 404 link under /old is not listed")
+		assert.Equal(t, "https://cdn.other.com/old/lib.js", links[0].Href)
+
+		links, err = linkRepo.ListByUser(owner.ID, repository.LinkFilter{HrefPattern: "%/old/%", StatusClass: "5xx"}, defaultPage)
+		require.NoError(t, err)
+		require.Len(t, links, 1)
+		assert.Equal(t, "https://second.example.com/old/page", links[0].Href)
+
+		links, err = linkRepo.ListByUser(owner.ID, repository.LinkFilter{HrefPattern: "https://%.example.com/%", StatusClass: "2xx"}, defaultPage)
+		require.NoError(t, err)
+		require.Len(t, links, 1)
+		assert.Equal(t, "https://first.example.com/about", links[0].Href)
+
+		count, err := linkRepo.CountByUser(owner.ID, filter)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+
+	utils.CleanTestData(t)
+}
+
+func TestLinkRepo_StatusSummary_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	linkRepo := repository.NewLinkRepo(db)
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "summary", Email: "summary@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+	stranger := &model.User{Username: "nosy", Email: "nosy@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(stranger))
+
+	page := &model.URL{UserID: owner.ID, OriginalURL: "https://summary.example.com", Status: "done"}
+	require.NoError(t, urlRepo.Create(page))
+
+	for i, code := range []int{200, 204, 301, 404, 403, 410, 503, 0, 0} {
+		require.NoError(t, linkRepo.Create(&model.Link{
+			URLID:      page.ID,
+			Href:       fmt.Sprintf("https://summary.example.com/%d", i),
+			StatusCode: code,
+		}))
+	}
+
+	t.Run("Grouped Counts", func(t *testing.T) {
+		summary, err := linkRepo.StatusSummary(page.ID, owner.ID)
+		require.NoError(t, err)
+		assert.Equal(t, &model.LinkStatusSummaryDTO{
+			URLID:       page.ID,
+			Status2xx:   2,
+			Status3xx:   1,
+			Status4xx:   3,
+			Status5xx:   1,
+			Unreachable: 2,
+			Total:       9,
+		}, summary)
+	})
+
+	t.Run("Other User", func(t *testing.T) {
+		_, err := linkRepo.StatusSummary(page.ID, stranger.ID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestLinkRepo_DomainImpact_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	linkRepo := repository.NewLinkRepo(db)
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	alice := &model.User{Username: "impacta", Email: "impacta@example.com", Password: "password123"}
+	bob := &model.User{Username: "impactb", Email: "impactb@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(alice))
+	require.NoError(t, userRepo.Create(bob))
+
+	alicePage := &model.URL{UserID: alice.ID, OriginalURL: "https://alice.example.org", Status: "done"}
+	aliceBlog := &model.URL{UserID: alice.ID, OriginalURL: "https://blog.alice.example.org", Status: "done"}
+	bobPage := &model.URL{UserID: bob.ID, OriginalURL: "https://bob.example.org", Status: "done"}
+	for _, u := range []*model.URL{alicePage, aliceBlog, bobPage} {
+		require.NoError(t, urlRepo.Create(u))
+	}
+
+	for _, l := range []model.Link{
+		{URLID: alicePage.ID, Href: "https://cdn.shared.com/lib.js", IsExternal: true},
+		{URLID: alicePage.ID, Href: "https://img.cdn.shared.com:8443/logo.png", IsExternal: true},
+		{URLID: alicePage.ID, Href: "https://shared.com/", IsExternal: true},
+		{URLID: aliceBlog.ID, Href: "http://cdn.shared.com?v=2", IsExternal: true},
+		{URLID: bobPage.ID, Href: "https://CDN.shared.com/lib.js", IsExternal: true},
+		{URLID: bobPage.ID, Href: "https://notcdn.shared.com.evil.net/x", IsExternal: true},
+		{URLID: bobPage.ID, Href: "https://mycdn.shared.community/x", IsExternal: true},
+		{URLID: bobPage.ID, Href: "https://bob.example.org/cdn.shared.com", IsExternal: false},
+	} {
+		link := l
+		require.NoError(t, linkRepo.Create(&link))
+	}
+
+	impact, err := linkRepo.DomainImpact("cdn.shared.com")
+	require.NoError(t, err)
+	assert.Equal(t, "cdn.shared.com", impact.Domain)
+	assert.Equal(t, 4, impact.Links)
+	assert.Equal(t, 3, impact.URLs)
+	assert.Equal(t, 2, impact.Users)
+
+	urls, err := linkRepo.ListDomainImpact("cdn.shared.com", repository.Pagination{Page: 1, PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+	assert.Equal(t, model.DomainImpactURLDTO{
+		URLID: alicePage.ID, UserID: alice.ID, OriginalURL: alicePage.OriginalURL, Links: 2,
+	}, urls[0])
+	assert.Equal(t, aliceBlog.ID, urls[1].URLID)
+
+	page2, err := linkRepo.ListDomainImpact("cdn.shared.com", repository.Pagination{Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, page2, 1)
+	assert.Equal(t, bobPage.ID, page2[0].URLID)
+	assert.Equal(t, bob.ID, page2[0].UserID)
+
+	none, err := linkRepo.DomainImpact("unused.example.net")
+	require.NoError(t, err)
+	assert.Zero(t, none.Links)
+}
+
+func TestLinkRepo_TopBrokenDomains_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	linkRepo := repository.NewLinkRepo(db)
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	alice := &model.User{Username: "brokena", Email: "brokena@example.com", Password: "password123"}
+	bob := &model.User{Username: "brokenb", Email: "brokenb@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(alice))
+	require.NoError(t, userRepo.Create(bob))
+
+	alicePage := &model.URL{UserID: alice.ID, OriginalURL: "https://alice.example.org", Status: "done"}
+	bobPage := &model.URL{UserID: bob.ID, OriginalURL: "https://bob.example.org", Status: "done"}
+	require.NoError(t, urlRepo.Create(alicePage))
+	require.NoError(t, urlRepo.Create(bobPage))
+
+	for _, l := range []model.Link{
+		{URLID: alicePage.ID, Href: "https://cdn.down.com/a.js", IsExternal: true, StatusCode: 503},
+		{URLID: alicePage.ID, Href: "https://CDN.down.com:8443/b.js", IsExternal: true, StatusCode: 502},
+		{URLID: bobPage.ID, Href: "http://cdn.down.com?v=1", IsExternal: true, StatusCode: 404},
+		{URLID: alicePage.ID, Href: "https://gone.net/page", IsExternal: true, StatusCode: 410},
+		{URLID: bobPage.ID, Href: "https://gone.net/other", IsExternal: true, StatusCode: 404},
+		{URLID: bobPage.ID, Href: "https://once.org/x", IsExternal: true, StatusCode: 500},
+		{URLID: bobPage.ID, Href: "https://cdn.down.com/ok.js", IsExternal: true, StatusCode: 200},
+		{URLID: bobPage.ID, Href: "https://fine.io/", IsExternal: true, StatusCode: 301},
+		{URLID: bobPage.ID, Href: "https://bob.example.org/missing", IsExternal: false, StatusCode: 404},
+	} {
+		link := l
+		require.NoError(t, linkRepo.Create(&link))
+	}
+
+	domains, err := linkRepo.TopBrokenDomains(10)
+	require.NoError(t, err)
+	require.Len(t, domains, 3)
+	assert.Equal(t, model.BrokenDomainDTO{Domain: "cdn.down.com", BrokenLinks: 3, URLs: 2, Users: 2}, domains[0])
+	assert.Equal(t, model.BrokenDomainDTO{Domain: "gone.net", BrokenLinks: 2, URLs: 2, Users: 2}, domains[1])
+	assert.Equal(t, model.BrokenDomainDTO{Domain: "once.org", BrokenLinks: 1, URLs: 1, Users: 1}, domains[2])
+
+	top, err := linkRepo.TopBrokenDomains(1)
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	assert.Equal(t, "cdn.down.com", top[0].Domain)
+}
+
+func TestLinkRepo_LatestRuns_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	linkRepo := repository.NewLinkRepo(db)
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "runs", Email: "runs@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+	stranger := &model.User{Username: "runs-nosy", Email: "runs-nosy@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(stranger))
+
+	page := &model.URL{UserID: owner.ID, OriginalURL: "https://runs.example.com", Status: "done"}
+	require.NoError(t, urlRepo.Create(page))
+
+	t.Run("No Runs", func(t *testing.T) {
+		runs, err := linkRepo.LatestRuns(page.ID, owner.ID, 2)
+		require.NoError(t, err)
+		assert.Empty(t, runs)
+	})
+
+	first := &model.AnalysisResult{Title: "first"}
+	require.NoError(t, urlRepo.SaveResults(page.ID, first, []model.Link{
+		{Href: "https://runs.example.com/a"},
+		{Href: "https://runs.example.com/b"},
+	}))
+	second := &model.AnalysisResult{Title: "second"}
+	require.NoError(t, urlRepo.SaveResults(page.ID, second, []model.Link{
+		{Href: "https://runs.example.com/a"},
+		{Href: "https://runs.example.com/c"},
+	}))
+
+	t.Run("Two Runs", func(t *testing.T) {
+		runs, err := linkRepo.LatestRuns(page.ID, owner.ID, 2)
+		require.NoError(t, err)
+		require.Len(t, runs, 2)
+		assert.Equal(t, second.ID, runs[0].AnalysisResultID)
+		assert.ElementsMatch(t, []string{"https://runs.example.com/a", "https://runs.example.com/c"}, runs[0].Hrefs)
+		assert.Equal(t, first.ID, runs[1].AnalysisResultID)
+		assert.ElementsMatch(t, []string{"https://runs.example.com/a", "https://runs.example.com/b"}, runs[1].Hrefs)
+	})
+
+	t.Run("Other User", func(t *testing.T) {
+		_, err := linkRepo.LatestRuns(page.ID, stranger.ID, 2)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
@@ -0,0 +1,45 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+	"github.com/fuzumoe/linkTorch-api/tests/utils"
+)
+
+func TestNotificationRepo_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	userRepo := repository.NewUserRepo(db)
+	notificationRepo := repository.NewNotificationRepo(db)
+
+	user := &model.User{Username: "notify", Email: "notify@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(user))
+
+	_, err := notificationRepo.FindByUser(user.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	prefs := model.DefaultNotificationPrefs(user.ID)
+	require.NoError(t, notificationRepo.Save(prefs))
+
+	prefs.DigestFrequency = model.DigestWeekly
+	prefs.AlertChannels = []string{model.AlertChannelEmail, model.AlertChannelWebhook}
+	prefs.WebhookEnabled = true
+	require.NoError(t, notificationRepo.Save(prefs))
+
+	stored, err := notificationRepo.FindByUser(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.DigestWeekly, stored.DigestFrequency)
+	assert.Equal(t, []string{"email", "webhook"}, stored.AlertChannels)
+	assert.True(t, stored.WebhookEnabled)
+
+	var rows int64
+	require.NoError(t, db.Model(&model.NotificationPrefs{}).Where("user_id = ?", user.ID).Count(&rows).Error)
+	assert.Equal(t, int64(1), rows, "saving twice must update the existing row")
+}
@@ -0,0 +1,55 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+	"github.com/fuzumoe/linkTorch-api/tests/utils"
+)
+
+func TestStatsRepo_System_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	userRepo := repository.NewUserRepo(db)
+	statsRepo := repository.NewStatsRepo(db)
+
+	var userIDs []uint
+	for _, name := range []string{"statsa", "statsb"} {
+		u := &model.User{Username: name, Email: name + "@example.com", Password: "password123"}
+		require.NoError(t, userRepo.Create(u))
+		userIDs = append(userIDs, u.ID)
+	}
+
+	statuses := []string{model.StatusDone, model.StatusDone, model.StatusError, model.StatusQueued}
+	var urlIDs []uint
+	for i, status := range statuses {
+		u := &model.URL{
+			UserID:      userIDs[i%2],
+			OriginalURL: "https://stats.example.com/" + status,
+			Status:      status,
+		}
+		require.NoError(t, db.Create(u).Error)
+		urlIDs = append(urlIDs, u.ID)
+	}
+
+	// One recent analysis and one older than the 24h window.
+	recent := &model.AnalysisResult{URLID: urlIDs[0], HTMLVersion: "HTML 5"}
+	require.NoError(t, db.Create(recent).Error)
+	old := &model.AnalysisResult{URLID: urlIDs[1], HTMLVersion: "HTML 5"}
+	require.NoError(t, db.Create(old).Error)
+	require.NoError(t, db.Model(old).UpdateColumn("created_at", time.Now().Add(-48*time.Hour)).Error)
+
+	stats, err := statsRepo.System(time.Now().Add(-24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.TotalUsers)
+	assert.Equal(t, 4, stats.TotalURLs)
+	assert.Equal(t, 1, stats.Crawls24h)
+	assert.Equal(t, 3, stats.CrawledURLs)
+	assert.Equal(t, 1, stats.ErroredURLs)
+}
@@ -1,7 +1,9 @@
 package repository_test
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -119,7 +121,7 @@ func TestURLRepo_Integration(t *testing.T) {
 		err = urlRepo.Create(otherUserURL)
 		require.NoError(t, err, "Should create URL for other user")
 
-		urls, err := urlRepo.ListByUser(testUser.ID, defaultPage)
+		urls, err := urlRepo.ListByUser(testUser.ID, repository.URLFilter{}, defaultPage)
 		require.NoError(t, err, "Should list URLs by user")
 		assert.Len(t, urls, 2, "Should have 2 URLs for test user")
 
@@ -127,7 +129,7 @@ func TestURLRepo_Integration(t *testing.T) {
 			assert.Equal(t, testUser.ID, u.UserID, "URL should belong to test user")
 		}
 
-		otherUserURLs, err := urlRepo.ListByUser(anotherUser.ID, defaultPage)
+		otherUserURLs, err := urlRepo.ListByUser(anotherUser.ID, repository.URLFilter{}, defaultPage)
 		require.NoError(t, err, "Should list URLs for other user")
 		assert.Len(t, otherUserURLs, 1, "Should have 1 URL for other user")
 		assert.Equal(t, anotherUser.ID, otherUserURLs[0].UserID, "URL should belong to other user")
@@ -333,15 +335,15 @@ func TestURLRepo_Integration(t *testing.T) {
 
 	t.Run("CountByUser", func(t *testing.T) {
 
-		count, err := urlRepo.CountByUser(testUser.ID)
+		count, err := urlRepo.CountByUser(testUser.ID, repository.URLFilter{})
 		require.NoError(t, err, "Should count URLs without error")
 		assert.Equal(t, 4, count, "Should have 4 active URLs for testUser")
 
-		count, err = urlRepo.CountByUser(anotherUser.ID)
+		count, err = urlRepo.CountByUser(anotherUser.ID, repository.URLFilter{})
 		require.NoError(t, err, "Should count URLs without error")
 		assert.Equal(t, 1, count, "Should have 1 URL for anotherUser")
 
-		count, err = urlRepo.CountByUser(9999)
+		count, err = urlRepo.CountByUser(9999, repository.URLFilter{})
 		require.NoError(t, err, "Should not error for non-existent user")
 		assert.Equal(t, 0, count, "Should have 0 URLs for non-existent user")
 
@@ -353,10 +355,424 @@ func TestURLRepo_Integration(t *testing.T) {
 		err = urlRepo.Create(additionalURL)
 		require.NoError(t, err, "Should create additional URL")
 
-		newCount, err := urlRepo.CountByUser(testUser.ID)
+		newCount, err := urlRepo.CountByUser(testUser.ID, repository.URLFilter{})
 		require.NoError(t, err, "Should count URLs without error")
 		assert.Equal(t, 5, newCount, "Should have 5 active URLs after adding one more")
 	})
 
 	utils.CleanTestData(t)
 }
+
+func TestURLRepo_Merge_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{
+		Username: "mergeowner",
+		Email:    "mergeowner@example.com",
+		Password: "password123",
+	}
+	require.NoError(t, userRepo.Create(owner))
+
+	target := &model.URL{UserID: owner.ID, OriginalURL: "https://merge.example.com", Status: "done"}
+	duplicate := &model.URL{UserID: owner.ID, OriginalURL: "https://merge.example.com/", Status: "done"}
+	require.NoError(t, urlRepo.Create(target))
+	require.NoError(t, urlRepo.Create(duplicate))
+
+	require.NoError(t, urlRepo.SaveResults(target.ID,
+		&model.AnalysisResult{HTMLVersion: "HTML 5", Title: "Target"},
+		[]model.Link{{Href: "https://merge.example.com/a"}}))
+	require.NoError(t, urlRepo.SaveResults(duplicate.ID,
+		&model.AnalysisResult{HTMLVersion: "HTML 5", Title: "Duplicate"},
+		[]model.Link{{Href: "https://merge.example.com/b"}, {Href: "https://merge.example.com/c"}}))
+
+	err := urlRepo.Merge(duplicate.ID, target.ID)
+	require.NoError(t, err, "Should merge without error")
+
+	merged, err := urlRepo.FindByID(target.ID)
+	require.NoError(t, err)
+	assert.Len(t, merged.AnalysisResults, 2, "Target should hold both analysis histories")
+	assert.Len(t, merged.Links, 3, "Target should hold links from both URLs")
+
+	_, err = urlRepo.FindByID(duplicate.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "Merged URL should be removed")
+
+	err = urlRepo.Merge(duplicate.ID, target.ID)
+	assert.EqualError(t, err, "url not found", "Merging an already removed URL should fail")
+}
+
+func TestURLRepo_FindExisting_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "existowner", Email: "existowner@example.com", Password: "password123"}
+	other := &model.User{Username: "existother", Email: "existother@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+	require.NoError(t, userRepo.Create(other))
+
+	require.NoError(t, urlRepo.Create(&model.URL{UserID: owner.ID, OriginalURL: "https://mine.example.com", Status: "queued"}))
+	require.NoError(t, urlRepo.Create(&model.URL{UserID: other.ID, OriginalURL: "https://theirs.example.com", Status: "queued"}))
+
+	existing, err := urlRepo.FindExisting(owner.ID, []string{
+		"https://mine.example.com",
+		"https://theirs.example.com",
+		"https://new.example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://mine.example.com"}, existing)
+}
+
+func TestURLRepo_ListUncrawled_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "triage", Email: "triage@example.com", Password: "password123"}
+	other := &model.User{Username: "triageother", Email: "triageother@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+	require.NoError(t, userRepo.Create(other))
+
+	queued := &model.URL{UserID: owner.ID, OriginalURL: "https://queued.example.com", Status: model.StatusQueued}
+	failed := &model.URL{UserID: owner.ID, OriginalURL: "https://failed.example.com", Status: model.StatusError}
+	crawled := &model.URL{UserID: owner.ID, OriginalURL: "https://crawled.example.com", Status: model.StatusDone}
+	failedLater := &model.URL{UserID: owner.ID, OriginalURL: "https://flaky.example.com", Status: model.StatusError}
+	theirs := &model.URL{UserID: other.ID, OriginalURL: "https://theirs.example.com", Status: model.StatusError}
+	for _, u := range []*model.URL{queued, failed, crawled, failedLater, theirs} {
+		require.NoError(t, urlRepo.Create(u))
+	}
+	// A URL that succeeded once still counts as crawled after a later failure.
+	require.NoError(t, urlRepo.SaveResults(crawled.ID, &model.AnalysisResult{Title: "ok"}, nil))
+	require.NoError(t, urlRepo.SaveResults(failedLater.ID, &model.AnalysisResult{Title: "ok once"}, nil))
+
+	count, err := urlRepo.CountUncrawled(owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	urls, err := urlRepo.ListUncrawled(owner.ID, repository.Pagination{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+	assert.Equal(t, queued.ID, urls[0].ID)
+	assert.Equal(t, failed.ID, urls[1].ID)
+
+	page2, err := urlRepo.ListUncrawled(owner.ID, repository.Pagination{Page: 2, PageSize: 1})
+	require.NoError(t, err)
+	require.Len(t, page2, 1)
+	assert.Equal(t, failed.ID, page2[0].ID)
+}
+
+func TestURLRepo_ListByStatusCode_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "codes", Email: "codes@example.com", Password: "password123"}
+	other := &model.User{Username: "codesother", Email: "codesother@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+	require.NoError(t, userRepo.Create(other))
+
+	broken := &model.URL{UserID: owner.ID, OriginalURL: "https://broken.example.com", Status: model.StatusDone}
+	healthy := &model.URL{UserID: owner.ID, OriginalURL: "https://healthy.example.com", Status: model.StatusDone}
+	recovered := &model.URL{UserID: owner.ID, OriginalURL: "https://recovered.example.com", Status: model.StatusDone}
+	brokenLater := &model.URL{UserID: owner.ID, OriginalURL: "https://broken-later.example.com", Status: model.StatusDone}
+	uncrawled := &model.URL{UserID: owner.ID, OriginalURL: "https://uncrawled.example.com", Status: model.StatusQueued}
+	theirs := &model.URL{UserID: other.ID, OriginalURL: "https://theirs.example.com", Status: model.StatusDone}
+	for _, u := range []*model.URL{broken, healthy, recovered, brokenLater, uncrawled, theirs} {
+		require.NoError(t, urlRepo.Create(u))
+	}
+	// Only the latest result of each URL counts.
+	seed := map[*model.URL][]int{
+		broken:      {500},
+		healthy:     {200},
+		recovered:   {500, 200},
+		brokenLater: {200, 500},
+		theirs:      {500},
+	}
+	for u, codes := range seed {
+		for _, code := range codes {
+			require.NoError(t, urlRepo.SaveResults(u.ID, &model.AnalysisResult{StatusCode: code}, nil))
+		}
+	}
+
+	filter := repository.URLFilter{StatusCode: 500, Sort: "id"}
+	count, err := urlRepo.CountByUser(owner.ID, filter)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	urls, err := urlRepo.ListByUser(owner.ID, filter, repository.Pagination{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+	assert.Equal(t, broken.ID, urls[0].ID)
+	assert.Equal(t, brokenLater.ID, urls[1].ID)
+
+	page2, err := urlRepo.ListByUser(owner.ID, filter, repository.Pagination{Page: 2, PageSize: 1})
+	require.NoError(t, err)
+	require.Len(t, page2, 1)
+	assert.Equal(t, brokenLater.ID, page2[0].ID)
+
+	// Combines with the other filters.
+	urls, err = urlRepo.ListByUser(owner.ID, repository.URLFilter{StatusCode: 500, Search: "later"}, repository.Pagination{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, urls, 1)
+	assert.Equal(t, brokenLater.ID, urls[0].ID)
+
+	urls, err = urlRepo.ListByUser(owner.ID, repository.URLFilter{StatusCode: 200, Sort: "id"}, repository.Pagination{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+	assert.Equal(t, healthy.ID, urls[0].ID)
+	assert.Equal(t, recovered.ID, urls[1].ID)
+}
+
+func TestURLRepo_DeleteErrored_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "cleanup", Email: "cleanup@example.com", Password: "password123"}
+	other := &model.User{Username: "cleanupother", Email: "cleanupother@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+	require.NoError(t, userRepo.Create(other))
+
+	dead := &model.URL{UserID: owner.ID, OriginalURL: "https://dead.example.com", Status: model.StatusError}
+	gone := &model.URL{UserID: owner.ID, OriginalURL: "https://gone.example.com", Status: model.StatusError}
+	done := &model.URL{UserID: owner.ID, OriginalURL: "https://done.example.com", Status: model.StatusDone}
+	queued := &model.URL{UserID: owner.ID, OriginalURL: "https://queued.example.com", Status: model.StatusQueued}
+	theirs := &model.URL{UserID: other.ID, OriginalURL: "https://theirs.example.com", Status: model.StatusError}
+	for _, u := range []*model.URL{dead, gone, done, queued, theirs} {
+		require.NoError(t, urlRepo.Create(u))
+	}
+
+	deleted, err := urlRepo.DeleteErrored(owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	for _, u := range []*model.URL{dead, gone} {
+		_, err := urlRepo.FindByID(u.ID)
+		assert.Error(t, err, "errored URL %d should be deleted", u.ID)
+	}
+	for _, u := range []*model.URL{done, queued, theirs} {
+		_, err := urlRepo.FindByID(u.ID)
+		assert.NoError(t, err, "URL %d should remain", u.ID)
+	}
+
+	deleted, err = urlRepo.DeleteErrored(owner.ID)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+}
+
+func TestURLRepo_ListByUserCursor_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "cursor", Email: "cursor@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+	var ids []uint
+	for i := 0; i < 5; i++ {
+		u := &model.URL{UserID: owner.ID, OriginalURL: fmt.Sprintf("https://cursor%d.example.com", i), Status: model.StatusDone}
+		require.NoError(t, urlRepo.Create(u))
+		ids = append(ids, u.ID)
+	}
+	// Two URLs created in the same instant are ordered by id.
+	require.NoError(t, db.Model(&model.URL{}).Where("id IN ?", ids[1:3]).
+		Update("created_at", time.Now().Add(-time.Hour)).Error)
+
+	var seen []uint
+	cursor := repository.Cursor{Size: 2}
+	for pages := 0; ; pages++ {
+		require.Less(t, pages, 5, "the listing should end")
+		urls, next, err := urlRepo.ListByUserCursor(owner.ID, repository.URLFilter{}, cursor)
+		require.NoError(t, err)
+		for _, u := range urls {
+			seen = append(seen, u.ID)
+		}
+		if next == "" {
+			break
+		}
+		cursor.After = next
+	}
+	assert.Equal(t, []uint{ids[4], ids[3], ids[0], ids[2], ids[1]}, seen, "newest first, each URL once")
+
+	_, _, err := urlRepo.ListByUserCursor(owner.ID, repository.URLFilter{}, repository.Cursor{After: "not-a-cursor"})
+	assert.ErrorIs(t, err, repository.ErrInvalidCursor)
+	_, _, err = urlRepo.ListByUserCursor(owner.ID, repository.URLFilter{Sort: "id"}, repository.Cursor{})
+	assert.ErrorIs(t, err, repository.ErrInvalidURLFilter)
+}
+
+func TestURLRepo_Restore_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "trash", Email: "trash@example.com", Password: "password123"}
+	other := &model.User{Username: "trashother", Email: "trashother@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+	require.NoError(t, userRepo.Create(other))
+
+	kept := &model.URL{UserID: owner.ID, OriginalURL: "https://kept.example.com", Status: model.StatusDone}
+	binned := &model.URL{UserID: owner.ID, OriginalURL: "https://binned.example.com", Status: model.StatusDone}
+	theirs := &model.URL{UserID: other.ID, OriginalURL: "https://theirs-binned.example.com", Status: model.StatusDone}
+	for _, u := range []*model.URL{kept, binned, theirs} {
+		require.NoError(t, urlRepo.Create(u))
+	}
+	require.NoError(t, urlRepo.Delete(binned.ID))
+	require.NoError(t, urlRepo.Delete(theirs.ID))
+
+	count, err := urlRepo.CountDeletedByUser(owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	trash, err := urlRepo.ListDeletedByUser(owner.ID, repository.Pagination{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, binned.ID, trash[0].ID)
+
+	found, err := urlRepo.FindDeleted(binned.ID)
+	require.NoError(t, err)
+	assert.Equal(t, owner.ID, found.UserID)
+	_, err = urlRepo.FindDeleted(kept.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "live URLs are not in the trash")
+
+	require.NoError(t, urlRepo.Restore(binned.ID))
+	_, err = urlRepo.FindByID(binned.ID)
+	assert.NoError(t, err)
+	count, err = urlRepo.CountDeletedByUser(owner.ID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	assert.ErrorIs(t, urlRepo.Restore(binned.ID), gorm.ErrRecordNotFound, "already restored")
+	assert.ErrorIs(t, urlRepo.Restore(kept.ID), gorm.ErrRecordNotFound)
+}
+
+func TestURLRepo_Purge_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "purge", Email: "purge@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+
+	live := &model.URL{UserID: owner.ID, OriginalURL: "https://live-purge.example.com", Status: model.StatusDone}
+	expired := &model.URL{UserID: owner.ID, OriginalURL: "https://expired.example.com", Status: model.StatusDone}
+	recent := &model.URL{UserID: owner.ID, OriginalURL: "https://recent.example.com", Status: model.StatusDone}
+	for _, u := range []*model.URL{live, expired, recent} {
+		require.NoError(t, urlRepo.Create(u))
+		require.NoError(t, urlRepo.SaveResults(u.ID, &model.AnalysisResult{HTMLVersion: "HTML 5", RawHTMLKey: u.OriginalURL + ".html"},
+			[]model.Link{{Href: u.OriginalURL + "/a", StatusCode: 200}}))
+	}
+	require.NoError(t, urlRepo.Delete(expired.ID))
+	require.NoError(t, urlRepo.Delete(recent.ID))
+	require.NoError(t, db.Unscoped().Model(&model.URL{}).Where("id = ?", expired.ID).
+		Update("deleted_at", time.Now().Add(-48*time.Hour)).Error)
+
+	remaining := func(id uint) (urls, results, links int64) {
+		db.Unscoped().Model(&model.URL{}).Where("id = ?", id).Count(&urls)
+		db.Unscoped().Model(&model.AnalysisResult{}).Where("url_id = ?", id).Count(&results)
+		db.Unscoped().Model(&model.Link{}).Where("url_id = ?", id).Count(&links)
+		return
+	}
+
+	purged, keys, err := urlRepo.PurgeOlderThan(24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, []string{expired.OriginalURL + ".html"}, keys, "raw HTML keys are returned for the blob store")
+	u, r, l := remaining(expired.ID)
+	assert.Zero(t, u+r+l, "the expired URL is gone with its results and links")
+	u, _, _ = remaining(recent.ID)
+	assert.EqualValues(t, 1, u, "recently deleted URLs stay restorable")
+
+	keys, err = urlRepo.Purge(live.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{live.OriginalURL + ".html"}, keys)
+	u, r, l = remaining(live.ID)
+	assert.Zero(t, u+r+l)
+	_, err = urlRepo.Purge(live.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestURLRepo_ResetStuck_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "stuckowner", Email: "stuckowner@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+
+	stuck := &model.URL{UserID: owner.ID, OriginalURL: "https://stuck.example.com", Status: model.StatusRunning}
+	busy := &model.URL{UserID: owner.ID, OriginalURL: "https://busy.example.com", Status: model.StatusRunning}
+	done := &model.URL{UserID: owner.ID, OriginalURL: "https://finished.example.com", Status: model.StatusDone}
+	for _, u := range []*model.URL{stuck, busy, done} {
+		require.NoError(t, urlRepo.Create(u))
+	}
+	hourAgo := time.Now().Add(-time.Hour)
+	for _, u := range []*model.URL{stuck, done} {
+		require.NoError(t, db.Model(u).UpdateColumn("updated_at", hourAgo).Error)
+	}
+
+	ids, err := urlRepo.ResetStuck(time.Now().Add(-10*time.Minute), model.StatusQueued)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{stuck.ID}, ids)
+
+	for u, want := range map[*model.URL]string{stuck: model.StatusQueued, busy: model.StatusRunning, done: model.StatusDone} {
+		got, err := urlRepo.FindByID(u.ID)
+		require.NoError(t, err)
+		assert.Equal(t, want, got.Status, u.OriginalURL)
+	}
+}
+
+func TestURLRepo_SaveResults_SameRun_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	urlRepo := repository.NewURLRepo(db)
+	userRepo := repository.NewUserRepo(db)
+
+	owner := &model.User{Username: "retry", Email: "retry@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+	page := &model.URL{UserID: owner.ID, OriginalURL: "https://retry.example.com", Status: model.StatusRunning}
+	require.NoError(t, urlRepo.Create(page))
+
+	runID := "5f0c7a8e-6a3b-4c1d-9e2f-0a1b2c3d4e5f"
+	save := func(title string) *model.AnalysisResult {
+		res := &model.AnalysisResult{HTMLVersion: "HTML 5", Title: title, RunID: &runID}
+		require.NoError(t, urlRepo.SaveResults(page.ID, res, []model.Link{
+			{Href: "https://retry.example.com/a"},
+			{Href: "https://retry.example.com/b"},
+		}))
+		return res
+	}
+	first := save("first attempt")
+	second := save("retried")
+	assert.Equal(t, first.ID, second.ID)
+
+	var results []model.AnalysisResult
+	require.NoError(t, db.Where("url_id = ?", page.ID).Find(&results).Error)
+	require.Len(t, results, 1)
+	assert.Equal(t, "retried", results[0].Title)
+
+	var links int64
+	require.NoError(t, db.Model(&model.Link{}).Unscoped().Where("url_id = ?", page.ID).Count(&links).Error)
+	assert.Equal(t, int64(2), links)
+
+	other := &model.AnalysisResult{HTMLVersion: "HTML 5", Title: "next crawl"}
+	require.NoError(t, urlRepo.SaveResults(page.ID, other, nil))
+	assert.NotEqual(t, first.ID, other.ID)
+}
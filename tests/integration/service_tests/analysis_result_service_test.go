@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
@@ -21,7 +22,7 @@ func TestAnalysisService_Integration(t *testing.T) {
 	urlRepo := repository.NewURLRepo(db)
 	analysisRepo := repository.NewAnalysisResultRepo(db)
 
-	analysisService := service.NewAnalysisService(analysisRepo)
+	analysisService := service.NewAnalysisService(analysisRepo, nil)
 
 	testUser := &model.User{
 		Username:  "analysisUser",
@@ -159,3 +160,40 @@ func TestAnalysisService_Integration(t *testing.T) {
 		assert.True(t, foundSecond, "Second analysis result should be in the list.")
 	})
 }
+
+func TestAnalysisService_ResponseTimes_Integration(t *testing.T) {
+	db := utils.SetupTest(t)
+	defer utils.CleanTestData(t)
+
+	userRepo := repository.NewUserRepo(db)
+	urlRepo := repository.NewURLRepo(db)
+	analysisRepo := repository.NewAnalysisResultRepo(db)
+	analysisService := service.NewAnalysisService(analysisRepo, nil)
+
+	owner := &model.User{Username: "percentiles", Email: "percentiles@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(owner))
+	page := &model.URL{UserID: owner.ID, OriginalURL: "https://percentiles.example.com", Status: model.StatusDone}
+	require.NoError(t, urlRepo.Create(page))
+
+	// Eighteen quick runs and two slow outliers; an untimed run is ignored.
+	require.NoError(t, analysisRepo.Create(&model.AnalysisResult{URLID: page.ID, HTMLVersion: "HTML 5"}, nil))
+	for i := 1; i <= 20; i++ {
+		ms := int64(100 + i)
+		if i > 18 {
+			ms = int64(1000 * i)
+		}
+		require.NoError(t, analysisRepo.Create(&model.AnalysisResult{URLID: page.ID, HTMLVersion: "HTML 5", CrawlDurationMs: &ms}, nil))
+	}
+
+	rt, err := analysisService.ResponseTimes(page.ID, owner.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 20, rt.Runs)
+	assert.Equal(t, int64(110), *rt.P50Ms)
+	assert.Equal(t, int64(118), *rt.P90Ms)
+	assert.Equal(t, int64(20000), *rt.P99Ms)
+
+	stranger := &model.User{Username: "percentiles2", Email: "percentiles2@example.com", Password: "password123"}
+	require.NoError(t, userRepo.Create(stranger))
+	_, err = analysisService.ResponseTimes(page.ID, stranger.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
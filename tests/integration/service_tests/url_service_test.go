@@ -33,14 +33,14 @@ func TestURLService_Integration(t *testing.T) {
 		userRepo := repository.NewUserRepo(db)
 		urlRepo := repository.NewURLRepo(db)
 
-		htmlAnalyzer := analyzer.NewHTMLAnalyzer()
+		htmlAnalyzer := analyzer.NewHTMLAnalyzer(analyzer.Options{})
 
 		crawlerCtx, cancelCrawler = context.WithCancel(context.Background())
 		crawlerPool := crawler.New(urlRepo, htmlAnalyzer, 1, 5, 1*time.Second)
 
 		go crawlerPool.Start(crawlerCtx)
 
-		urlService = service.NewURLService(urlRepo, crawlerPool)
+		urlService = service.NewURLService(urlRepo, crawlerPool, nil)
 
 		testUser = &model.User{
 			Username:  "testuser",
@@ -94,7 +94,7 @@ func TestURLService_Integration(t *testing.T) {
 			PageSize: 10,
 		}
 
-		paginatedResult, err := urlService.List(testUser.ID, pagination)
+		paginatedResult, err := urlService.List(testUser.ID, repository.URLFilter{}, pagination)
 		require.NoError(t, err, "Should list URLs without error.")
 
 		assert.GreaterOrEqual(t, len(paginatedResult.Data), 3, "Should return at least 3 URLs.")
@@ -162,7 +162,7 @@ func TestURLService_Integration(t *testing.T) {
 		createdID, err := urlService.Create(createInput)
 		require.NoError(t, err, "Should create URL without error.")
 
-		err = urlService.Start(createdID)
+		err = urlService.Start(createdID, "")
 		require.NoError(t, err, "Should start crawling without error.")
 
 		urlDTO, err := urlService.Get(createdID)
@@ -259,7 +259,7 @@ func TestURLService_Integration(t *testing.T) {
 		})
 
 		t.Run("NonExistentURL", func(t *testing.T) {
-			err = urlService.Start(9999)
+			err = urlService.Start(9999, "")
 			assert.Error(t, err, "Starting a non-existent URL should return an error.")
 			assert.Contains(t, err.Error(), "cannot start crawling",
 				"Error message should indicate the start operation failed")
@@ -18,7 +18,7 @@ func TestUserService_Integration(t *testing.T) {
 	db := utils.SetupTest(t)
 
 	userRepo := repository.NewUserRepo(db)
-	userService := service.NewUserService(userRepo)
+	userService := service.NewUserService(userRepo, service.UserServiceOptions{})
 
 	testUsername := "testuser"
 	testEmail := "test@example.com"
@@ -62,6 +62,18 @@ func TestUserService_Integration(t *testing.T) {
 		assert.Contains(t, err.Error(), "email already in use")
 	})
 
+	t.Run("Register_DuplicateUsername", func(t *testing.T) {
+		input := &model.CreateUserInput{
+			Username: "TestUser",
+			Email:    "other@example.com",
+			Password: "anotherpassword",
+		}
+
+		user, err := userService.Register(input)
+		assert.ErrorIs(t, err, service.ErrUsernameTaken, "Usernames are compared case-insensitively by default")
+		assert.Nil(t, user)
+	})
+
 	t.Run("Authenticate_Success", func(t *testing.T) {
 		user, err := userService.Authenticate(testEmail, testPassword)
 		require.NoError(t, err)
@@ -160,6 +172,20 @@ func TestUserService_Integration(t *testing.T) {
 		})
 	})
 
+	t.Run("Update_UsernameTaken", func(t *testing.T) {
+		second, err := userRepo.FindByEmail(secondTestEmail)
+		require.NoError(t, err)
+
+		taken := testUsername
+		user, err := userService.Update(second.ID, &model.UpdateUserInput{Username: &taken})
+		assert.ErrorIs(t, err, service.ErrUsernameTaken)
+		assert.Nil(t, user)
+
+		own := secondTestUsername
+		_, err = userService.Update(second.ID, &model.UpdateUserInput{Username: &own})
+		assert.NoError(t, err, "Keeping one's own username is not a conflict")
+	})
+
 	t.Run("Delete", func(t *testing.T) {
 
 		dbUser, err := userRepo.FindByEmail(secondTestEmail)
@@ -2,6 +2,8 @@ package analyzer_test
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -13,6 +15,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/fuzumoe/linkTorch-api/internal/analyzer"
+	"github.com/fuzumoe/linkTorch-api/internal/egress"
+	"github.com/fuzumoe/linkTorch-api/internal/storage"
 )
 
 func TestHTMLAnalyzer_Analyze(t *testing.T) {
@@ -44,7 +48,7 @@ func TestHTMLAnalyzer_Analyze(t *testing.T) {
 	baseURL, err := url.Parse(ts.URL)
 	require.NoError(t, err)
 
-	ha := analyzer.NewHTMLAnalyzer()
+	ha := analyzer.NewHTMLAnalyzer(analyzer.Options{})
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -81,3 +85,878 @@ func TestHTMLAnalyzer_Analyze(t *testing.T) {
 		assert.True(t, externalFound, "External link should be present")
 	})
 }
+
+func TestHTMLAnalyzer_UnknownContentPolicy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("<title>Plain</title> see <a href=\"/other\">other</a>"))
+	}))
+	defer ts.Close()
+
+	baseURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("Skip", func(t *testing.T) {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{ContentPolicy: analyzer.ContentPolicySkip})
+		result, links, err := ha.Analyze(ctx, baseURL)
+		assert.ErrorIs(t, err, analyzer.ErrUnsupportedContent)
+		assert.Nil(t, result)
+		assert.Nil(t, links)
+	})
+
+	t.Run("Parse", func(t *testing.T) {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{ContentPolicy: analyzer.ContentPolicyParse})
+		result, links, err := ha.Analyze(ctx, baseURL)
+		require.NoError(t, err)
+		assert.Equal(t, "Plain", result.Title)
+		assert.Equal(t, "text/plain; charset=utf-8", result.ContentType)
+		assert.Len(t, links, 1)
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{ContentPolicy: analyzer.ContentPolicyMetadata})
+		result, links, err := ha.Analyze(ctx, baseURL)
+		require.NoError(t, err)
+		assert.Equal(t, "unknown", result.HTMLVersion)
+		assert.Equal(t, "text/plain; charset=utf-8", result.ContentType)
+		assert.Empty(t, result.Title)
+		assert.Empty(t, links)
+	})
+
+	t.Run("Default Is Parse", func(t *testing.T) {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{})
+		result, _, err := ha.Analyze(ctx, baseURL)
+		require.NoError(t, err)
+		assert.Equal(t, "Plain", result.Title)
+	})
+}
+
+func TestHTMLAnalyzer_EgressAllowlist(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head><title>Allowed</title></head></html>"))
+	}))
+	defer ts.Close()
+
+	baseURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("Allowed Host", func(t *testing.T) {
+		policy := egress.NewPolicy(egress.ModeAllowlist, []string{baseURL.Hostname()})
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{Egress: policy})
+		result, _, err := ha.Analyze(ctx, baseURL)
+		require.NoError(t, err)
+		assert.Equal(t, "Allowed", result.Title)
+	})
+
+	t.Run("Rejected Host", func(t *testing.T) {
+		policy := egress.NewPolicy(egress.ModeAllowlist, []string{"example.com"})
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{Egress: policy})
+		_, _, err := ha.Analyze(ctx, baseURL)
+		assert.ErrorIs(t, err, egress.ErrHostNotAllowed)
+	})
+}
+
+func TestHTMLAnalyzer_HTTPSOnly(t *testing.T) {
+	var hits int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head><title>Plain</title></head></html>"))
+	}))
+	defer ts.Close()
+
+	baseURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("Enabled", func(t *testing.T) {
+		policy := egress.NewPolicyWithHTTPSOnly(egress.ModeDenylist, nil, true)
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{Egress: policy})
+		_, _, err := ha.Analyze(ctx, baseURL)
+		assert.ErrorIs(t, err, egress.ErrHTTPSRequired)
+		assert.Equal(t, 0, hits, "an http target must not be fetched")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		policy := egress.NewPolicyWithHTTPSOnly(egress.ModeDenylist, nil, false)
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{Egress: policy})
+		result, _, err := ha.Analyze(ctx, baseURL)
+		require.NoError(t, err)
+		assert.Equal(t, "Plain", result.Title)
+	})
+}
+
+func TestHTMLAnalyzer_TitleFallback(t *testing.T) {
+	pages := map[string]string{
+		"/titled": `<html><head><title> Real Title </title><meta property="og:title" content="OG"></head><body><h1>Heading</h1></body></html>`,
+		"/docs/getting-started": `<html><head><meta property="og:title" content="Open Graph Title"></head><body><h1>  Main
+			Heading </h1></body></html>`,
+		"/no-h1": `<html><head><meta property="og:title" content="Open Graph Title"></head><body><p>text</p></body></html>`,
+		"/":      `<html><body><p>nothing</p></body></html>`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(pages[r.URL.Path]))
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	analyze := func(chain []analyzer.TitleSource, path string) (string, string) {
+		u, err := url.Parse(ts.URL + path)
+		require.NoError(t, err)
+		res, _, err := analyzer.NewHTMLAnalyzer(analyzer.Options{TitleFallback: chain}).Analyze(ctx, u)
+		require.NoError(t, err)
+		return res.Title, res.TitleSource
+	}
+	all := []analyzer.TitleSource{analyzer.TitleSourceH1, analyzer.TitleSourceOpenGraph, analyzer.TitleSourceURLPath}
+
+	cases := []struct {
+		name, path  string
+		chain       []analyzer.TitleSource
+		title, from string
+	}{
+		{"Title Element Wins", "/titled", all, "Real Title", "title"},
+		{"First H1", "/docs/getting-started", all, "Main Heading", "h1"},
+		{"Chain Order", "/docs/getting-started", []analyzer.TitleSource{analyzer.TitleSourceOpenGraph, analyzer.TitleSourceH1}, "Open Graph Title", "og_title"},
+		{"Skips Missing Source", "/no-h1", all, "Open Graph Title", "og_title"},
+		{"URL Path", "/docs/getting-started", []analyzer.TitleSource{analyzer.TitleSourceURLPath}, "getting-started", "url_path"},
+		{"Nothing Found", "/", all, "", ""},
+		{"No Fallback By Default", "/docs/getting-started", nil, "", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			title, from := analyze(tc.chain, tc.path)
+			assert.Equal(t, tc.title, title)
+			assert.Equal(t, tc.from, from)
+		})
+	}
+}
+
+func TestHTMLAnalyzer_MetaDescriptionAndLanguage(t *testing.T) {
+	pages := map[string]string{
+		"/described": `<html lang="de-AT"><head><meta name="Description" content="  A page
+			about   things "></head><body></body></html>`,
+		"/header": `<html><head><meta name="keywords" content="a,b"></head><body></body></html>`,
+		"/bare":   `<html><body></body></html>`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path != "/bare" {
+			w.Header().Set("Content-Language", "en-GB, fr")
+		}
+		_, _ = w.Write([]byte(pages[r.URL.Path]))
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cases := []struct {
+		name, path, desc, lang string
+	}{
+		{"From Document", "/described", "A page about things", "de-AT"},
+		{"Content-Language Fallback", "/header", "", "en-GB"},
+		{"Neither", "/bare", "", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.Parse(ts.URL + tc.path)
+			require.NoError(t, err)
+			res, _, err := analyzer.NewHTMLAnalyzer(analyzer.Options{}).Analyze(ctx, u)
+			require.NoError(t, err)
+			assert.Equal(t, tc.desc, res.MetaDescription)
+			assert.Equal(t, tc.lang, res.Language)
+			dto := res.ToDTO()
+			assert.Equal(t, tc.desc, dto.MetaDescription)
+			assert.Equal(t, tc.lang, dto.Language)
+		})
+	}
+}
+
+func TestHTMLAnalyzer_Images(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><body>
+			<img src="a.png" alt="Logo">
+			<img src="b.png">
+			<img src="c.png" alt="">
+			<img src="d.png" alt="   ">
+			<picture><img src="e.png" alt="Photo"></picture>
+		</body></html>`))
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	res, _, err := analyzer.NewHTMLAnalyzer(analyzer.Options{}).Analyze(ctx, u)
+	require.NoError(t, err)
+	assert.Equal(t, 5, res.ImageCount)
+	assert.Equal(t, 3, res.ImagesMissingAlt)
+	assert.Equal(t, 3, res.ToDTO().ImagesMissingAlt)
+}
+
+func TestHTMLAnalyzer_ResponseTimeAndSize(t *testing.T) {
+	page := `<html><body><a href="/slow-link">x</a></body></html>`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/slow-link" {
+			time.Sleep(300 * time.Millisecond)
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte(page))
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	res, _, err := analyzer.NewHTMLAnalyzer(analyzer.Options{}).Analyze(ctx, u)
+	require.NoError(t, err)
+	assert.Equal(t, len(page), res.PageSizeBytes)
+	assert.GreaterOrEqual(t, res.ResponseTimeMs, 50)
+	assert.Less(t, res.ResponseTimeMs, 300, "link checks are not part of the fetch")
+	dto := res.ToDTO()
+	assert.Equal(t, res.ResponseTimeMs, dto.ResponseTimeMs)
+	assert.Equal(t, res.PageSizeBytes, dto.PageSizeBytes)
+}
+
+func TestHTMLAnalyzer_CanonicalURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/article", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><link rel="canonical" href="/article/#top"></head></html>`))
+	})
+	mux.HandleFunc("/copy", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><link rel="Canonical" href="https://example.com/original"></head></html>`))
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><link rel="stylesheet" href="/s.css"></head></html>`))
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cases := []struct {
+		name, path, final, canonical string
+		mismatch                     bool
+	}{
+		{"Redirected To Canonical", "/old", ts.URL + "/article", ts.URL + "/article/#top", false},
+		{"Points Elsewhere", "/copy", ts.URL + "/copy", "https://example.com/original", true},
+		{"No Canonical", "/plain", ts.URL + "/plain", "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			u, err := url.Parse(ts.URL + tc.path)
+			require.NoError(t, err)
+			res, _, err := analyzer.NewHTMLAnalyzer(analyzer.Options{}).Analyze(ctx, u)
+			require.NoError(t, err)
+			assert.Equal(t, tc.final, res.FinalURL)
+			assert.Equal(t, tc.canonical, res.CanonicalURL)
+			assert.Equal(t, tc.mismatch, res.CanonicalMismatch)
+			assert.Equal(t, tc.mismatch, res.ToDTO().CanonicalMismatch)
+		})
+	}
+}
+
+func TestHTMLAnalyzer_LinkOccurrences(t *testing.T) {
+	var host string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`<html><body>
+			<a href="/about">a</a>
+			<a href="/about#team">b</a>
+			<a href="http://` + strings.ToUpper(host) + `/about">c</a>
+			<a href="/missing">d</a>
+			<a href="/missing">e</a>
+			<a href="https://external.invalid/x">f</a>
+		</body></html>`))
+	}))
+	defer ts.Close()
+	baseURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	host = baseURL.Host
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// No normalization rules, as with the LINK_* settings left unset: host
+	// case and fragments still do not make a link a different target.
+	ha := analyzer.NewHTMLAnalyzer(analyzer.Options{})
+	result, links, err := ha.Analyze(ctx, baseURL)
+	require.NoError(t, err)
+
+	counts := make(map[string]int, len(links))
+	for _, l := range links {
+		counts[l.Href] = l.OccurrenceCount
+	}
+	assert.Equal(t, map[string]int{
+		ts.URL + "/about":            3,
+		ts.URL + "/missing":          2,
+		"https://external.invalid/x": 1,
+	}, counts)
+	assert.Equal(t, 2, result.InternalLinkCount)
+	assert.Equal(t, 1, result.ExternalLinkCount)
+	assert.Equal(t, 1, result.BrokenLinkCount, "a repeated broken link counts once")
+}
+
+func TestDetectHTMLVersion(t *testing.T) {
+	cases := map[string]string{
+		`<!DOCTYPE html>`: analyzer.HTMLVersion5,
+		`html`:            analyzer.HTMLVersion5,
+		`<!doctype html SYSTEM "about:legacy-compat">`:                                                                              analyzer.HTMLVersion5,
+		`<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01//EN" "http://www.w3.org/TR/html4/strict.dtd">`:                                analyzer.HTMLVersion401Strict,
+		`<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN" "http://www.w3.org/TR/html4/loose.dtd">`:                    analyzer.HTMLVersion401Transitional,
+		`<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Frameset//EN" "http://www.w3.org/TR/html4/frameset.dtd">`:                     analyzer.HTMLVersion401Frameset,
+		`<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd">`:             analyzer.HTMLVersionXHTML10Strict,
+		`<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">`: analyzer.HTMLVersionXHTML10Transitional,
+		`<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Frameset//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-frameset.dtd">`:         analyzer.HTMLVersionXHTML10Frameset,
+		`<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.1//EN" "http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd">`:                         analyzer.HTMLVersionXHTML11,
+		``: analyzer.HTMLVersionUnknown,
+		`<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN">`: analyzer.HTMLVersionUnknown,
+	}
+	for doctype, want := range cases {
+		assert.Equal(t, want, analyzer.DetectHTMLVersion(doctype), doctype)
+	}
+}
+
+func TestHTMLAnalyzer_HTMLVersion(t *testing.T) {
+	pages := map[string]string{
+		"/xhtml":   `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd"><html><body><p>x</p></body></html>`,
+		"/html4":   `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01//EN"><html><body><p>x</p></body></html>`,
+		"/no-dt-5": `<html><body><article><p>x</p></article></body></html>`,
+		"/no-dt":   `<html><body><p>x</p></body></html>`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(pages[r.URL.Path]))
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for path, want := range map[string]string{
+		"/xhtml":   analyzer.HTMLVersionXHTML10Transitional,
+		"/html4":   analyzer.HTMLVersion401Strict,
+		"/no-dt-5": analyzer.HTMLVersion5,
+		"/no-dt":   analyzer.HTMLVersionUnknown,
+	} {
+		u, err := url.Parse(ts.URL + path)
+		require.NoError(t, err)
+		res, _, err := analyzer.NewHTMLAnalyzer(analyzer.Options{}).Analyze(ctx, u)
+		require.NoError(t, err)
+		assert.Equal(t, want, res.HTMLVersion, path)
+	}
+}
+
+func TestHTMLAnalyzer_BrokenLinks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><body>
+				<a href="/ok">ok</a><a href="/missing">missing</a>
+				<a href="/broken">broken</a><a href="/head-unsupported">head</a>
+			</body></html>`))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/head-unsupported":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		}
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL + "/")
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, links, err := analyzer.NewHTMLAnalyzer(analyzer.Options{LinkCheckConcurrency: 2}).Analyze(ctx, u)
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.BrokenLinkCount)
+
+	status := make(map[string]int, len(links))
+	for _, l := range links {
+		status[strings.TrimPrefix(l.Href, ts.URL)] = l.StatusCode
+	}
+	assert.Equal(t, map[string]int{
+		"/ok":               http.StatusOK,
+		"/missing":          http.StatusNotFound,
+		"/broken":           http.StatusInternalServerError,
+		"/head-unsupported": http.StatusOK,
+	}, status)
+}
+
+func TestHTMLAnalyzer_UserAgent(t *testing.T) {
+	agents := make(chan string, 4)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		agents <- r.Method + " " + r.UserAgent()
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><body><a href="/about">About</a></body></html>`))
+	}))
+	defer ts.Close()
+
+	baseURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("Configured", func(t *testing.T) {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{UserAgent: "TestAgent/2.0"})
+		_, _, err := ha.Analyze(ctx, baseURL)
+		require.NoError(t, err)
+		assert.Equal(t, "GET TestAgent/2.0", <-agents, "page fetch")
+		assert.Equal(t, "HEAD TestAgent/2.0", <-agents, "link check")
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{})
+		_, _, err := ha.Analyze(ctx, baseURL)
+		require.NoError(t, err)
+		assert.Equal(t, "GET "+analyzer.DefaultUserAgent, <-agents)
+		<-agents
+	})
+}
+
+func TestHTMLAnalyzer_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	baseURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	ha := analyzer.NewHTMLAnalyzer(analyzer.Options{Timeout: 100 * time.Millisecond})
+	start := time.Now()
+	_, _, err = ha.Analyze(context.Background(), baseURL)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second, "the client timeout should end a hanging request")
+}
+
+func TestHTMLAnalyzer_ServerError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("<html><head><title>Bad Gateway</title></head></html>"))
+	}))
+	defer ts.Close()
+
+	baseURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ha := analyzer.NewHTMLAnalyzer(analyzer.Options{})
+	_, _, err = ha.Analyze(ctx, baseURL)
+	var statusErr *analyzer.HTTPStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+}
+
+func TestHTMLAnalyzer_RedirectChain(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/hop", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/hop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/final", http.StatusFound)
+	})
+	mux.HandleFunc("/final", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head><title>Final</title></head></html>"))
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("Multi Hop", func(t *testing.T) {
+		start, err := url.Parse(ts.URL + "/start")
+		require.NoError(t, err)
+
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{})
+		result, _, err := ha.Analyze(ctx, start)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Final", result.Title)
+		require.Len(t, result.RedirectChain, 3)
+		assert.Equal(t, ts.URL+"/start", result.RedirectChain[0].URL)
+		assert.Equal(t, http.StatusMovedPermanently, result.RedirectChain[0].StatusCode)
+		assert.Equal(t, ts.URL+"/hop", result.RedirectChain[1].URL)
+		assert.Equal(t, http.StatusFound, result.RedirectChain[1].StatusCode)
+		assert.Equal(t, ts.URL+"/final", result.RedirectChain[2].URL)
+		assert.Equal(t, http.StatusOK, result.RedirectChain[2].StatusCode)
+	})
+
+	t.Run("Loop Is Capped", func(t *testing.T) {
+		loop, err := url.Parse(ts.URL + "/loop")
+		require.NoError(t, err)
+
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{MaxRedirects: 3})
+		result, _, err := ha.Analyze(ctx, loop)
+		require.NoError(t, err)
+
+		require.Len(t, result.RedirectChain, 4)
+		for _, hop := range result.RedirectChain {
+			assert.Equal(t, http.StatusFound, hop.StatusCode)
+		}
+	})
+
+	t.Run("No Redirect", func(t *testing.T) {
+		final, err := url.Parse(ts.URL + "/final")
+		require.NoError(t, err)
+
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{})
+		result, _, err := ha.Analyze(ctx, final)
+		require.NoError(t, err)
+		assert.Nil(t, result.RedirectChain)
+	})
+}
+
+func TestHTMLAnalyzer_LinkBase(t *testing.T) {
+	// The final server is addressed as "localhost" so its hostname differs
+	// from the original server's 127.0.0.1.
+	var origin *httptest.Server
+	final := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><body>
+			<a href="` + origin.URL + `/about">origin</a>
+			<a href="/docs">relative</a>
+		</body></html>`))
+	}))
+	defer final.Close()
+	finalURL := strings.Replace(final.URL, "127.0.0.1", "localhost", 1)
+
+	origin = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			http.Redirect(w, r, finalURL+"/landing", http.StatusMovedPermanently)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer origin.Close()
+
+	start, err := url.Parse(origin.URL + "/start")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	external := func(t *testing.T, base analyzer.LinkBase) map[string]bool {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{LinkBase: base})
+		_, links, err := ha.Analyze(ctx, start)
+		require.NoError(t, err)
+		got := make(map[string]bool, len(links))
+		for _, l := range links {
+			got[l.Href] = l.IsExternal
+		}
+		return got
+	}
+
+	t.Run("Original Host", func(t *testing.T) {
+		assert.Equal(t, map[string]bool{
+			origin.URL + "/about": false,
+			origin.URL + "/docs":  false,
+		}, external(t, analyzer.LinkBaseOriginal))
+	})
+
+	t.Run("Default Is Original", func(t *testing.T) {
+		assert.Equal(t, external(t, analyzer.LinkBaseOriginal), external(t, ""))
+	})
+
+	t.Run("Final Host", func(t *testing.T) {
+		assert.Equal(t, map[string]bool{
+			origin.URL + "/about": true,
+			finalURL + "/docs":    false,
+		}, external(t, analyzer.LinkBaseFinal))
+	})
+}
+
+func TestHTMLAnalyzer_InsecureTLSHosts(t *testing.T) {
+	// httptest's TLS server uses a certificate no system root trusts.
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head><title>Staging</title></head></html>"))
+	}))
+	defer ts.Close()
+
+	target, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("Verified By Default", func(t *testing.T) {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{})
+		_, _, err := ha.Analyze(ctx, target)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "certificate")
+	})
+
+	t.Run("Other Hosts Still Verified", func(t *testing.T) {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{InsecureTLSHosts: []string{"staging.internal"}})
+		_, _, err := ha.Analyze(ctx, target)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "certificate")
+	})
+
+	t.Run("Skipped For Designated Host", func(t *testing.T) {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{InsecureTLSHosts: []string{target.Hostname()}})
+		result, _, err := ha.Analyze(ctx, target)
+		require.NoError(t, err)
+		assert.Equal(t, "Staging", result.Title)
+		assert.True(t, result.TLSVerifySkipped, "Result should record that verification was skipped")
+	})
+}
+
+func TestHTMLAnalyzer_RawHTML(t *testing.T) {
+	page := "<html><head><title>Kept</title></head><body><h1>Hi</h1></body></html>"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(page))
+	}))
+	defer ts.Close()
+
+	target, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("Stored When Configured", func(t *testing.T) {
+		blobs, err := storage.NewFileStore(t.TempDir())
+		require.NoError(t, err)
+
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{RawHTML: blobs})
+		result, _, err := ha.Analyze(ctx, target)
+		require.NoError(t, err)
+		assert.Equal(t, "Kept", result.Title, "Page should still be parsed")
+		assert.Equal(t, 1, result.H1Count)
+		require.NotEmpty(t, result.RawHTMLKey)
+
+		r, err := blobs.Get(ctx, result.RawHTMLKey)
+		require.NoError(t, err)
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, page, string(data))
+	})
+
+	t.Run("Not Stored By Default", func(t *testing.T) {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{})
+		result, _, err := ha.Analyze(ctx, target)
+		require.NoError(t, err)
+		assert.Empty(t, result.RawHTMLKey)
+	})
+}
+
+func TestHTMLAnalyzer_LinkNormalization(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><body>
+			<a href="/page?id=1&utm_source=news">a</a>
+			<a href="/page?utm_medium=mail&id=1&utm_campaign=x">b</a>
+			<a href="/page?id=1#section">c</a>
+			<a href="/page/?id=1">d</a>
+			<a href="/other?id=2">e</a>
+		</body></html>`))
+	}))
+	defer ts.Close()
+
+	baseURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("No Rules", func(t *testing.T) {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{})
+		_, links, err := ha.Analyze(ctx, baseURL)
+		require.NoError(t, err)
+		assert.Len(t, links, 5)
+	})
+
+	t.Run("Stripped Params Collapse", func(t *testing.T) {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{Normalize: analyzer.NormalizeRules{
+			StripParams: []string{"utm_*"},
+		}})
+		result, links, err := ha.Analyze(ctx, baseURL)
+		require.NoError(t, err)
+
+		hrefs := make([]string, 0, len(links))
+		for _, l := range links {
+			hrefs = append(hrefs, l.Href)
+		}
+		assert.ElementsMatch(t, []string{
+			ts.URL + "/page?id=1",
+			ts.URL + "/page/?id=1",
+			ts.URL + "/other?id=2",
+		}, hrefs)
+		assert.Equal(t, 3, result.InternalLinkCount)
+	})
+
+	t.Run("All Rules", func(t *testing.T) {
+		ha := analyzer.NewHTMLAnalyzer(analyzer.Options{Normalize: analyzer.NormalizeRules{
+			StripParams:        []string{"utm_*"},
+			LowercaseHost:      true,
+			StripTrailingSlash: true,
+			StripFragment:      true,
+		}})
+		_, links, err := ha.Analyze(ctx, baseURL)
+		require.NoError(t, err)
+		require.Len(t, links, 2)
+		assert.Equal(t, ts.URL+"/page?id=1", links[0].Href)
+		assert.Equal(t, ts.URL+"/other?id=2", links[1].Href)
+	})
+}
+
+// BenchmarkHTMLAnalyzer_Analyze compares the shared, tuned transport with
+// Go's default of two idle connections per host. With only two kept alive,
+// most link checks on a busy host dial a new connection; compare allocs/op.
+func BenchmarkHTMLAnalyzer_Analyze(b *testing.B) {
+	var page strings.Builder
+	page.WriteString("<!DOCTYPE html><html><head><title>Bench</title></head><body>")
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&page, `<h2>Section %d</h2><p>%s</p><a href="/p/%d">link</a>`, i, strings.Repeat("lorem ipsum ", 50), i)
+	}
+	page.WriteString("</body></html>")
+	body := page.String()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/" {
+			io.WriteString(w, body)
+		}
+	}))
+	defer ts.Close()
+	u, err := url.Parse(ts.URL + "/")
+	require.NoError(b, err)
+
+	cases := []struct {
+		name string
+		opts analyzer.TransportOptions
+	}{
+		{"Untuned", analyzer.TransportOptions{MaxIdleConnsPerHost: 2}},
+		{"Tuned", analyzer.TransportOptions{}},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			a := analyzer.NewHTMLAnalyzer(analyzer.Options{Transport: tc.opts})
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := a.Analyze(context.Background(), u); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestHTMLAnalyzer_AnalyzeHead(t *testing.T) {
+	page := `<!DOCTYPE html><html><head><title>Up</title></head><body><h1>A</h1><a href="/x">x</a></body></html>`
+	newServer := func(allowHead bool, methods *[]string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*methods = append(*methods, r.Method)
+			if r.Method == http.MethodHead && !allowHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(page))
+		}))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("Status Without Content", func(t *testing.T) {
+		var methods []string
+		ts := newServer(true, &methods)
+		defer ts.Close()
+		u, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+
+		res, err := analyzer.NewHTMLAnalyzer(analyzer.Options{}).AnalyzeHead(ctx, u)
+		require.NoError(t, err)
+		assert.Equal(t, []string{http.MethodHead}, methods, "neither the page nor its links are fetched")
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "text/html", res.ContentType)
+		assert.Equal(t, analyzer.HTMLVersionUnknown, res.HTMLVersion)
+		assert.Empty(t, res.Title)
+		assert.Zero(t, res.H1Count)
+		assert.Zero(t, res.InternalLinkCount)
+	})
+
+	t.Run("Falls Back To GET", func(t *testing.T) {
+		var methods []string
+		ts := newServer(false, &methods)
+		defer ts.Close()
+		u, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+
+		res, err := analyzer.NewHTMLAnalyzer(analyzer.Options{}).AnalyzeHead(ctx, u)
+		require.NoError(t, err)
+		assert.Equal(t, []string{http.MethodHead, http.MethodGet}, methods)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Zero(t, res.H1Count, "the GET body is not parsed")
+	})
+
+	t.Run("Server Error", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer ts.Close()
+		u, err := url.Parse(ts.URL)
+		require.NoError(t, err)
+
+		_, err = analyzer.NewHTMLAnalyzer(analyzer.Options{}).AnalyzeHead(ctx, u)
+		var statusErr *analyzer.HTTPStatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	})
+}
@@ -2,14 +2,17 @@ package analyzer_test
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 	"unsafe"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/fuzumoe/linkTorch-api/internal/analyzer"
@@ -68,3 +71,80 @@ func TestLinkChecker_Run(t *testing.T) {
 		})
 	})
 }
+
+func TestLinkChecker_Concurrency(t *testing.T) {
+	var inFlight, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	links := make([]model.Link, 20)
+	for i := range links {
+		links[i].Href = fmt.Sprintf("%s/page/%d", ts.URL, i)
+	}
+	analyzer.NewLinkChecker(3, 2*time.Second).Run(context.Background(), links)
+
+	for _, l := range links {
+		assert.Equal(t, http.StatusOK, l.StatusCode, l.Href)
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(3), "no more than the configured number of checks may run at once")
+}
+
+func TestLinkChecker_HeadNotImplemented(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/robots.txt":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusNotImplemented)
+		default:
+			w.WriteHeader(http.StatusGone)
+		}
+	}))
+	defer ts.Close()
+
+	links := analyzer.NewLinkChecker(1, 2*time.Second).Run(context.Background(), []model.Link{{Href: ts.URL + "/old"}})
+	assert.Equal(t, http.StatusGone, links[0].StatusCode, "the GET answer should replace the unsupported HEAD")
+}
+
+func TestLinkChecker_Cancelled(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	links := make([]model.Link, 50)
+	for i := range links {
+		links[i].Href = fmt.Sprintf("%s/page/%d", ts.URL, i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		analyzer.NewLinkChecker(4, 2*time.Second).Run(ctx, links)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("link checking did not stop after cancellation")
+	}
+	assert.Zero(t, atomic.LoadInt32(&hits))
+	for _, l := range links {
+		assert.Zero(t, l.StatusCode, l.Href)
+	}
+}
@@ -33,14 +33,26 @@ type MockCrawlerPool struct{}
 
 func (m *MockCrawlerPool) Start(ctx context.Context) {
 }
-func (m *MockCrawlerPool) Shutdown()                                 {}
-func (m *MockCrawlerPool) Submit(id uint)                            {}
-func (m *MockCrawlerPool) Enqueue(id uint)                           {}
-func (m *MockCrawlerPool) EnqueueWithPriority(id uint, priority int) {}
+func (m *MockCrawlerPool) Shutdown()                                       {}
+func (m *MockCrawlerPool) Submit(id uint)                                  {}
+func (m *MockCrawlerPool) Enqueue(id uint) error                           { return nil }
+func (m *MockCrawlerPool) EnqueueWithPriority(id uint, priority int) error { return nil }
+func (m *MockCrawlerPool) EnqueueWithRequestID(id uint, priority int, requestID string) error {
+	return nil
+}
 func (m *MockCrawlerPool) GetResults() <-chan crawler.CrawlResult {
 	return make(chan crawler.CrawlResult)
 }
+func (m *MockCrawlerPool) Reprioritize(id uint, priority int) error { return nil }
+func (m *MockCrawlerPool) Snapshot(limit int) crawler.QueueSnapshot { return crawler.QueueSnapshot{} }
 func (m *MockCrawlerPool) AdjustWorkers(cmd crawler.ControlCommand) {}
+func (m *MockCrawlerPool) QueueDepth() int                          { return 0 }
+func (m *MockCrawlerPool) Workers() int                             { return 1 }
+func (m *MockCrawlerPool) Pause()                                   {}
+func (m *MockCrawlerPool) Resume()                                  {}
+func (m *MockCrawlerPool) Paused() bool                             { return false }
+func (m *MockCrawlerPool) Running() bool                            { return true }
+func (m *MockCrawlerPool) Stats() crawler.PoolStats                 { return crawler.PoolStats{} }
 
 func setupHooks(t *testing.T) {
 	gin.SetMode(gin.TestMode)
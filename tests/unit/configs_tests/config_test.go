@@ -72,4 +72,812 @@ func TestLoad(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid JWT_LIFETIME")
 	})
+
+	t.Run("JWTRefreshLifetime", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 7*24*time.Hour, cfg.JWTRefreshLifetime)
+
+		os.Setenv("JWT_REFRESH_LIFETIME", "720h")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 720*time.Hour, cfg.JWTRefreshLifetime)
+
+		os.Setenv("JWT_REFRESH_LIFETIME", "0s")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid JWT_REFRESH_LIFETIME")
+	})
+
+	t.Run("PasswordResetTTL", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 30*time.Minute, cfg.PasswordResetTTL)
+
+		os.Setenv("PASSWORD_RESET_TTL", "1h")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, time.Hour, cfg.PasswordResetTTL)
+
+		os.Setenv("PASSWORD_RESET_TTL", "-1m")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid PASSWORD_RESET_TTL")
+	})
+
+	t.Run("DevLogAuthTokens", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+		os.Setenv("GIN_MODE", "debug")
+
+		// Debug mode alone must not put tokens in the log.
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.False(t, cfg.DevLogAuthTokens)
+
+		os.Setenv("DEV_LOG_AUTH_TOKENS", "true")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.True(t, cfg.DevLogAuthTokens)
+
+		os.Setenv("DEV_LOG_AUTH_TOKENS", "maybe")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid DEV_LOG_AUTH_TOKENS")
+	})
+
+	t.Run("APIKeyExpiry", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Zero(t, cfg.APIKeyMaxLifetime, "API keys must not expire unless configured")
+		assert.False(t, cfg.APIKeyRequireExpiry)
+
+		os.Setenv("API_KEY_MAX_LIFETIME", "720h")
+		os.Setenv("API_KEY_REQUIRE_EXPIRY", "true")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 720*time.Hour, cfg.APIKeyMaxLifetime)
+		assert.True(t, cfg.APIKeyRequireExpiry)
+
+		os.Setenv("API_KEY_MAX_LIFETIME", "-1h")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid API_KEY_MAX_LIFETIME")
+
+		os.Setenv("API_KEY_MAX_LIFETIME", "720h")
+		os.Setenv("API_KEY_REQUIRE_EXPIRY", "sometimes")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid API_KEY_REQUIRE_EXPIRY")
+	})
+
+	t.Run("EmailVerification", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 48*time.Hour, cfg.EmailVerifyTTL)
+		assert.Equal(t, time.Minute, cfg.EmailVerifyResend)
+		assert.False(t, cfg.RequireVerifiedEmail, "Verification must not be required unless configured")
+
+		os.Setenv("EMAIL_VERIFICATION_TTL", "2h")
+		os.Setenv("EMAIL_VERIFICATION_RESEND_INTERVAL", "0s")
+		os.Setenv("REQUIRE_EMAIL_VERIFICATION", "true")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Hour, cfg.EmailVerifyTTL)
+		assert.Zero(t, cfg.EmailVerifyResend)
+		assert.True(t, cfg.RequireVerifiedEmail)
+
+		os.Setenv("EMAIL_VERIFICATION_TTL", "0s")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid EMAIL_VERIFICATION_TTL")
+
+		os.Setenv("EMAIL_VERIFICATION_TTL", "2h")
+		os.Setenv("EMAIL_VERIFICATION_RESEND_INTERVAL", "-1s")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid EMAIL_VERIFICATION_RESEND_INTERVAL")
+	})
+
+	t.Run("UnknownContentPolicy", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "parse", cfg.UnknownContentPolicy)
+		assert.False(t, cfg.LogHTTPBodies, "Body logging must be off unless configured")
+
+		os.Setenv("UNKNOWN_CONTENT_POLICY", "ignore")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid UNKNOWN_CONTENT_POLICY")
+	})
+
+	t.Run("EgressMode", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+		os.Setenv("CRAWL_EGRESS_MODE", "allowlist")
+		os.Setenv("CRAWL_EGRESS_HOSTS", "example.com,intranet.local")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "allowlist", cfg.EgressMode)
+		assert.Equal(t, []string{"example.com", "intranet.local"}, cfg.EgressHosts)
+		assert.Empty(t, cfg.InsecureTLSHosts, "TLS verification must stay on unless configured")
+		assert.False(t, cfg.StoreRawHTML)
+		assert.Equal(t, "fs", cfg.BlobBackend)
+
+		os.Setenv("BLOB_BACKEND", "s3")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid BLOB_BACKEND")
+		os.Unsetenv("BLOB_BACKEND")
+
+		os.Setenv("CRAWL_EGRESS_MODE", "open")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_EGRESS_MODE")
+	})
+
+	t.Run("ResultsMaxLinks", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 1000, cfg.ResultsMaxLinks)
+
+		os.Setenv("RESULTS_MAX_LINKS", "0")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, cfg.ResultsMaxLinks)
+
+		os.Setenv("RESULTS_MAX_LINKS", "-1")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid RESULTS_MAX_LINKS")
+	})
+
+	t.Run("CrawlHTTPTimeout", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 10*time.Second, cfg.CrawlHTTPTimeout)
+
+		os.Setenv("CRAWL_HTTP_TIMEOUT", "3s")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 3*time.Second, cfg.CrawlHTTPTimeout)
+
+		os.Setenv("CRAWL_HTTP_TIMEOUT", "slow")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_HTTP_TIMEOUT")
+	})
+
+	t.Run("HostConcurrency", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 4, cfg.HostConcurrency)
+
+		os.Setenv("CRAWL_MAX_PER_HOST", "0")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, cfg.HostConcurrency)
+
+		os.Setenv("CRAWL_MAX_PER_HOST", "-1")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_MAX_PER_HOST")
+	})
+
+	t.Run("AutoTagHost", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.False(t, cfg.AutoTagHost)
+
+		os.Setenv("AUTO_TAG_HOST", "true")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.True(t, cfg.AutoTagHost)
+
+		os.Setenv("AUTO_TAG_HOST", "sometimes")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid AUTO_TAG_HOST")
+	})
+
+	t.Run("CrawlOnCreate", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.False(t, cfg.CrawlOnCreate)
+
+		os.Setenv("CRAWL_ON_CREATE", "true")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.True(t, cfg.CrawlOnCreate)
+
+		os.Setenv("CRAWL_ON_CREATE", "later")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_ON_CREATE")
+	})
+
+	t.Run("Logging", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "info", cfg.LogLevel)
+		assert.Equal(t, "json", cfg.LogFormat)
+
+		os.Setenv("LOG_LEVEL", "warn")
+		os.Setenv("LOG_FORMAT", "text")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "warn", cfg.LogLevel)
+		assert.Equal(t, "text", cfg.LogFormat)
+
+		os.Setenv("LOG_FORMAT", "xml")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid LOG_FORMAT")
+
+		os.Setenv("LOG_FORMAT", "json")
+		os.Setenv("LOG_LEVEL", "verbose")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid LOG_LEVEL")
+	})
+
+	t.Run("MetricsEnabled", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.True(t, cfg.MetricsEnabled)
+
+		os.Setenv("METRICS_ENABLED", "false")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.False(t, cfg.MetricsEnabled)
+
+		os.Setenv("METRICS_ENABLED", "maybe")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid METRICS_ENABLED")
+	})
+
+	t.Run("QueueCapacity", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, cfg.QueueCapacity)
+
+		os.Setenv("CRAWL_QUEUE_CAPACITY", "200")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 200, cfg.QueueCapacity)
+
+		os.Setenv("CRAWL_QUEUE_CAPACITY", "-1")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_QUEUE_CAPACITY")
+	})
+
+	t.Run("WorkerIdleScaling", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, time.Duration(0), cfg.WorkerIdleTimeout)
+		assert.Equal(t, 1, cfg.MinCrawlers)
+
+		os.Setenv("CRAWL_WORKER_IDLE_TIMEOUT", "2m")
+		os.Setenv("MIN_CRAWLERS", "3")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 2*time.Minute, cfg.WorkerIdleTimeout)
+		assert.Equal(t, 3, cfg.MinCrawlers)
+
+		os.Setenv("CRAWL_WORKER_IDLE_TIMEOUT", "-1s")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_WORKER_IDLE_TIMEOUT")
+
+		os.Setenv("CRAWL_WORKER_IDLE_TIMEOUT", "2m")
+		os.Setenv("MIN_CRAWLERS", "0")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid MIN_CRAWLERS")
+	})
+
+	t.Run("TrashRetention", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 720*time.Hour, cfg.TrashRetention)
+		assert.Equal(t, time.Hour, cfg.TrashPurgeInterval)
+
+		os.Setenv("URL_TRASH_RETENTION", "0s")
+		os.Setenv("URL_TRASH_PURGE_INTERVAL", "10m")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Zero(t, cfg.TrashRetention)
+		assert.Equal(t, 10*time.Minute, cfg.TrashPurgeInterval)
+
+		os.Setenv("URL_TRASH_RETENTION", "-1h")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid URL_TRASH_RETENTION")
+
+		os.Setenv("URL_TRASH_RETENTION", "1h")
+		os.Setenv("URL_TRASH_PURGE_INTERVAL", "0s")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid URL_TRASH_PURGE_INTERVAL")
+	})
+
+	t.Run("ReservedWorkers", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+		os.Setenv("NUMBER_OF_CRAWLERS", "4")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, cfg.ReservedCrawlers)
+		assert.Equal(t, 8, cfg.ReservedMinPriority)
+
+		os.Setenv("CRAWL_RESERVED_WORKERS", "2")
+		os.Setenv("CRAWL_RESERVED_MIN_PRIORITY", "9")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 2, cfg.ReservedCrawlers)
+		assert.Equal(t, 9, cfg.ReservedMinPriority)
+
+		os.Setenv("CRAWL_RESERVED_WORKERS", "4")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_RESERVED_WORKERS")
+
+		os.Setenv("CRAWL_RESERVED_WORKERS", "1")
+		os.Setenv("CRAWL_RESERVED_MIN_PRIORITY", "11")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_RESERVED_MIN_PRIORITY")
+	})
+
+	t.Run("LinkCheckConcurrency", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 12, cfg.LinkCheckConcurrency)
+
+		os.Setenv("CRAWL_LINK_CHECK_CONCURRENCY", "4")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 4, cfg.LinkCheckConcurrency)
+
+		os.Setenv("CRAWL_LINK_CHECK_CONCURRENCY", "0")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_LINK_CHECK_CONCURRENCY")
+	})
+
+	t.Run("CrawlRetry", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, cfg.CrawlRetryAttempts)
+		assert.Equal(t, 2*time.Second, cfg.CrawlRetryDelay)
+
+		os.Setenv("CRAWL_RETRY_ATTEMPTS", "4")
+		os.Setenv("CRAWL_RETRY_BASE_DELAY", "500ms")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 4, cfg.CrawlRetryAttempts)
+		assert.Equal(t, 500*time.Millisecond, cfg.CrawlRetryDelay)
+
+		os.Setenv("CRAWL_RETRY_ATTEMPTS", "0")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_RETRY_ATTEMPTS")
+
+		os.Setenv("CRAWL_RETRY_ATTEMPTS", "2")
+		os.Setenv("CRAWL_RETRY_BASE_DELAY", "soon")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_RETRY_BASE_DELAY")
+	})
+
+	t.Run("Robots", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.True(t, cfg.RespectRobots)
+		assert.Equal(t, time.Hour, cfg.RobotsCacheTTL)
+
+		os.Setenv("CRAWL_RESPECT_ROBOTS", "false")
+		os.Setenv("CRAWL_ROBOTS_CACHE_TTL", "10m")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.False(t, cfg.RespectRobots)
+		assert.Equal(t, 10*time.Minute, cfg.RobotsCacheTTL)
+
+		os.Setenv("CRAWL_ROBOTS_CACHE_TTL", "forever")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_ROBOTS_CACHE_TTL")
+	})
+
+	t.Run("TitleFallback", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Empty(t, cfg.TitleFallback)
+
+		os.Setenv("TITLE_FALLBACK", "og_title, h1,url_path")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"og_title", "h1", "url_path"}, cfg.TitleFallback)
+
+		os.Setenv("TITLE_FALLBACK", "h1,meta_description")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid TITLE_FALLBACK source")
+	})
+
+	t.Run("HTTPSOnly", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.False(t, cfg.HTTPSOnly)
+
+		os.Setenv("CRAWL_HTTPS_ONLY", "true")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.True(t, cfg.HTTPSOnly)
+
+		os.Setenv("CRAWL_HTTPS_ONLY", "sometimes")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_HTTPS_ONLY")
+	})
+
+	t.Run("ExternalLinkBase", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "original", cfg.ExternalLinkBase)
+
+		os.Setenv("EXTERNAL_LINK_BASE", "redirect")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid EXTERNAL_LINK_BASE")
+	})
+
+	t.Run("UsernameMatchCase", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.False(t, cfg.UsernameMatchCase)
+
+		os.Setenv("USERNAME_CASE_SENSITIVE", "true")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.True(t, cfg.UsernameMatchCase)
+
+		os.Setenv("USERNAME_CASE_SENSITIVE", "sometimes")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid USERNAME_CASE_SENSITIVE")
+	})
+
+	t.Run("SlowCrawl", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Zero(t, cfg.SlowCrawlThreshold, "Disabled by default")
+		assert.Equal(t, 3, cfg.SlowCrawlPenalty)
+
+		os.Setenv("CRAWL_SLOW_THRESHOLD", "20s")
+		os.Setenv("CRAWL_SLOW_PRIORITY_PENALTY", "2")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 20*time.Second, cfg.SlowCrawlThreshold)
+		assert.Equal(t, 2, cfg.SlowCrawlPenalty)
+
+		os.Setenv("CRAWL_SLOW_THRESHOLD", "slow")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_SLOW_THRESHOLD")
+	})
+
+	t.Run("MaxIdleConnsPerHost", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 16, cfg.MaxIdleConnsPerHost)
+
+		os.Setenv("CRAWL_MAX_IDLE_CONNS_PER_HOST", "many")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_MAX_IDLE_CONNS_PER_HOST")
+	})
+
+	t.Run("LinkNormalization", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+		os.Setenv("LINK_STRIP_PARAMS", "utm_*, fbclid")
+		os.Setenv("LINK_STRIP_FRAGMENT", "true")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"utm_*", "fbclid"}, cfg.LinkStripParams)
+		assert.True(t, cfg.LinkStripFragment)
+		assert.False(t, cfg.LinkLowercaseHost)
+
+		os.Setenv("LINK_STRIP_PARAMS", "utm_[")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid LINK_STRIP_PARAMS pattern")
+
+		os.Setenv("LINK_STRIP_PARAMS", "")
+		os.Setenv("LINK_LOWERCASE_HOST", "sometimes")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid LINK_LOWERCASE_HOST")
+	})
+
+	t.Run("StreamTimeouts", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+		os.Setenv("SSE_HEARTBEAT_INTERVAL", "5s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 5*time.Second, cfg.SSEHeartbeat)
+		assert.Equal(t, 30*time.Minute, cfg.SSEMaxLifetime)
+		assert.Equal(t, 30*time.Second, cfg.HTTPWriteTimeout)
+
+		os.Setenv("SSE_MAX_LIFETIME", "forever")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid SSE_MAX_LIFETIME")
+	})
+
+	t.Run("StreamConnectionLimits", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 500, cfg.StreamMaxConns)
+		assert.Equal(t, 5, cfg.StreamMaxConnsUser)
+
+		os.Setenv("STREAM_MAX_CONNECTIONS", "0")
+		os.Setenv("STREAM_MAX_CONNECTIONS_PER_USER", "2")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Zero(t, cfg.StreamMaxConns)
+		assert.Equal(t, 2, cfg.StreamMaxConnsUser)
+
+		os.Setenv("STREAM_MAX_CONNECTIONS_PER_USER", "-1")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid STREAM_MAX_CONNECTIONS_PER_USER")
+
+		os.Setenv("STREAM_MAX_CONNECTIONS_PER_USER", "2")
+		os.Setenv("STREAM_MAX_CONNECTIONS", "many")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid STREAM_MAX_CONNECTIONS")
+	})
+
+	t.Run("CrawlQuota", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 100, cfg.CrawlQuotaPerUser)
+		assert.Equal(t, map[string]int{"admin": 0}, cfg.CrawlQuotaByRole)
+
+		os.Setenv("CRAWL_QUOTA_PER_USER", "10")
+		os.Setenv("CRAWL_QUOTA_ROLE_OVERRIDES", "crawler=50, user=20")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, 10, cfg.CrawlQuotaPerUser)
+		assert.Equal(t, map[string]int{"admin": 0, "crawler": 50, "user": 20}, cfg.CrawlQuotaByRole)
+
+		for _, bad := range []string{"crawler", "crawler=-1", "robot=5", "crawler=many"} {
+			os.Setenv("CRAWL_QUOTA_ROLE_OVERRIDES", bad)
+			_, err = configs.Load()
+			assert.Error(t, err, bad)
+			assert.Contains(t, err.Error(), "invalid CRAWL_QUOTA_ROLE_OVERRIDES", bad)
+		}
+
+		os.Setenv("CRAWL_QUOTA_ROLE_OVERRIDES", "")
+		os.Setenv("CRAWL_QUOTA_PER_USER", "-5")
+		_, err = configs.Load()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid CRAWL_QUOTA_PER_USER")
+	})
+
+	t.Run("StuckCrawlWatchdog", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("DB_USER", "u")
+		os.Setenv("DB_PASSWORD", "p")
+		os.Setenv("DB_NAME", "n")
+		os.Setenv("JWT_SECRET", "s")
+
+		cfg, err := configs.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, time.Minute, cfg.WatchdogInterval)
+		assert.Equal(t, 15*time.Minute, cfg.StuckCrawlAfter)
+		assert.Equal(t, "queued", cfg.StuckCrawlAction)
+
+		os.Setenv("CRAWL_WATCHDOG_INTERVAL", "0s")
+		os.Setenv("CRAWL_STUCK_AFTER", "1h")
+		os.Setenv("CRAWL_STUCK_ACTION", "error")
+		cfg, err = configs.Load()
+		assert.NoError(t, err)
+		assert.Zero(t, cfg.WatchdogInterval)
+		assert.Equal(t, time.Hour, cfg.StuckCrawlAfter)
+		assert.Equal(t, "error", cfg.StuckCrawlAction)
+
+		for key, bad := range map[string]string{
+			"CRAWL_WATCHDOG_INTERVAL": "-1m",
+			"CRAWL_STUCK_AFTER":       "0s",
+			"CRAWL_STUCK_ACTION":      "done",
+		} {
+			os.Setenv(key, bad)
+			_, err = configs.Load()
+			assert.Error(t, err, key)
+			assert.Contains(t, err.Error(), "invalid "+key)
+			os.Unsetenv(key)
+		}
+	})
 }
@@ -32,13 +32,23 @@ func (m *MockURLRepository) FindByID(id uint) (*model.URL, error) {
 	return args.Get(0).(*model.URL), args.Error(1)
 }
 
-func (m *MockURLRepository) CountByUser(userID uint) (int, error) {
-	args := m.Called(userID)
+func (m *MockURLRepository) CountByUser(userID uint, f repository.URLFilter) (int, error) {
+	args := m.Called(userID, f)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockURLRepository) ListByUser(userID uint, p repository.Pagination) ([]model.URL, error) {
-	args := m.Called(userID, p)
+func (m *MockURLRepository) CountByUserAndStatus(userID uint, statuses ...string) (int, error) {
+	args := m.Called(userID, statuses)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLRepository) ResetStuck(runningBefore time.Time, status string) ([]uint, error) {
+	args := m.Called(runningBefore, status)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockURLRepository) ListByUser(userID uint, f repository.URLFilter, p repository.Pagination) ([]model.URL, error) {
+	args := m.Called(userID, f, p)
 	return args.Get(0).([]model.URL), args.Error(1)
 }
 
@@ -75,6 +85,112 @@ func (m *MockURLRepository) ResultsWithDetails(id uint) (*model.URL, []*model.An
 	return args.Get(0).(*model.URL), args.Get(1).([]*model.AnalysisResult), args.Get(2).([]*model.Link), args.Error(3)
 }
 
+func (m *MockURLRepository) Merge(srcID, dstID uint) error {
+	args := m.Called(srcID, dstID)
+	return args.Error(0)
+}
+
+func (m *MockURLRepository) FindExisting(userID uint, urls []string) ([]string, error) {
+	args := m.Called(userID, urls)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockURLRepository) ListUncrawled(userID uint, p repository.Pagination) ([]model.URL, error) {
+	args := m.Called(userID, p)
+	return args.Get(0).([]model.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) CountUncrawled(userID uint) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLRepository) DeleteErrored(userID uint) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLRepository) CreateBatch(urls []*model.URL) error {
+	args := m.Called(urls)
+	return args.Error(0)
+}
+
+func (m *MockURLRepository) FindOwnedIDs(userID uint, ids []uint) ([]uint, error) {
+	args := m.Called(userID, ids)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockURLRepository) DeleteByIDs(ids []uint) (int, error) {
+	args := m.Called(ids)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLRepository) ListTagReport(userID uint, tag string, p repository.Pagination) ([]model.TagReportItemDTO, error) {
+	args := m.Called(userID, tag, p)
+	return args.Get(0).([]model.TagReportItemDTO), args.Error(1)
+}
+
+func (m *MockURLRepository) CountByTag(userID uint, tag string) (int, error) {
+	args := m.Called(userID, tag)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLRepository) TagSummary(userID uint, tag string) (*model.TagSummaryDTO, error) {
+	args := m.Called(userID, tag)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TagSummaryDTO), args.Error(1)
+}
+
+func (m *MockURLRepository) FindIDsByTag(userID uint, tag string) ([]uint, error) {
+	args := m.Called(userID, tag)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockURLRepository) FindDeleted(id uint) (*model.URL, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) ListDeletedByUser(userID uint, p repository.Pagination) ([]model.URL, error) {
+	args := m.Called(userID, p)
+	return args.Get(0).([]model.URL), args.Error(1)
+}
+
+func (m *MockURLRepository) CountDeletedByUser(userID uint) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLRepository) Restore(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockURLRepository) ListByUserCursor(userID uint, f repository.URLFilter, c repository.Cursor) ([]model.URL, string, error) {
+	args := m.Called(userID, f, c)
+	return args.Get(0).([]model.URL), args.String(1), args.Error(2)
+}
+
+func (m *MockURLRepository) Purge(id uint) ([]string, error) {
+	args := m.Called(id)
+	keys, _ := args.Get(0).([]string)
+	return keys, args.Error(1)
+}
+
+func (m *MockURLRepository) PurgeOlderThan(d time.Duration) (int, []string, error) {
+	args := m.Called(d)
+	keys, _ := args.Get(1).([]string)
+	return args.Int(0), keys, args.Error(2)
+}
+
 type MockAnalyzer struct {
 	mock.Mock
 }
@@ -0,0 +1,193 @@
+package crawler_test
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/crawler"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+// hostTracker records the peak number of simultaneous crawls per host.
+type hostTracker struct {
+	mu       sync.Mutex
+	inFlight map[string]int
+	peak     map[string]int
+	hold     time.Duration
+}
+
+func newHostTracker(hold time.Duration) *hostTracker {
+	return &hostTracker{inFlight: make(map[string]int), peak: make(map[string]int), hold: hold}
+}
+
+func (h *hostTracker) enter(host string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.inFlight[host]++
+	h.peak[host] = max(h.peak[host], h.inFlight[host])
+}
+
+func (h *hostTracker) exit(host string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.inFlight[host]--
+}
+
+func (h *hostTracker) peakOf(host string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.peak[host]
+}
+
+func (h *hostTracker) Analyze(ctx context.Context, u *url.URL) (*model.AnalysisResult, []model.Link, error) {
+	h.enter(u.Hostname())
+	defer h.exit(u.Hostname())
+	time.Sleep(h.hold)
+	return &model.AnalysisResult{HTMLVersion: "HTML 5"}, nil, nil
+}
+
+func TestHostLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	acquireAll := func(l *crawler.HostLimiter, tr *hostTracker, host string, n, override int, wg *sync.WaitGroup) {
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				release, err := l.Acquire(ctx, host, override)
+				if !assert.NoError(t, err) {
+					return
+				}
+				defer release()
+				tr.enter(host)
+				time.Sleep(20 * time.Millisecond)
+				tr.exit(host)
+			}()
+		}
+	}
+
+	t.Run("Caps One Host While Others Proceed", func(t *testing.T) {
+		l := crawler.NewHostLimiter(2)
+		tr := newHostTracker(0)
+		var wg sync.WaitGroup
+		acquireAll(l, tr, "busy.example", 8, 0, &wg)
+		acquireAll(l, tr, "other.example", 3, 0, &wg)
+		wg.Wait()
+
+		assert.Equal(t, 2, tr.peakOf("busy.example"))
+		assert.Equal(t, 2, tr.peakOf("other.example"), "another host has its own slots")
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		l := crawler.NewHostLimiter(4)
+		tr := newHostTracker(0)
+		var wg sync.WaitGroup
+		acquireAll(l, tr, "fragile.example", 5, 1, &wg)
+		acquireAll(l, tr, "sturdy.example", 8, 6, &wg)
+		wg.Wait()
+
+		assert.Equal(t, 1, tr.peakOf("fragile.example"))
+		assert.Equal(t, 6, tr.peakOf("sturdy.example"), "an override may also raise the cap")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		l := crawler.NewHostLimiter(0)
+		tr := newHostTracker(0)
+		var wg sync.WaitGroup
+		acquireAll(l, tr, "any.example", 5, 0, &wg)
+		wg.Wait()
+		assert.Equal(t, 5, tr.peakOf("any.example"))
+	})
+
+	t.Run("Host Names Are Case Insensitive", func(t *testing.T) {
+		l := crawler.NewHostLimiter(1)
+		release, err := l.Acquire(ctx, "Example.com", 0)
+		require.NoError(t, err)
+		defer release()
+
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = l.Acquire(waitCtx, "example.COM", 0)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Slot Freed After Cancelled Wait", func(t *testing.T) {
+		l := crawler.NewHostLimiter(1)
+		release, err := l.Acquire(ctx, "example.com", 0)
+		require.NoError(t, err)
+
+		waitCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = l.Acquire(waitCtx, "example.com", 0)
+		require.Error(t, err)
+
+		release()
+		release() // releasing twice must not free a second slot
+		next, err := l.Acquire(ctx, "example.com", 0)
+		require.NoError(t, err)
+		next()
+	})
+}
+
+// hostRepo serves URLs on two hosts: even ids on busy.example, odd ones on
+// calm.example.
+type hostRepo struct {
+	*testRepo
+	limit int
+}
+
+func (r *hostRepo) FindByID(id uint) (*model.URL, error) {
+	u, err := r.testRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	u.OriginalURL = "http://calm.example/page"
+	if id%2 == 0 {
+		u.OriginalURL = "http://busy.example/page"
+		u.HostLimit = r.limit
+	}
+	return u, nil
+}
+
+func TestWorker_HostLimit(t *testing.T) {
+	run := func(limiter *crawler.HostLimiter, busyLimit int) *hostTracker {
+		repo := &hostRepo{testRepo: newTestRepo(), limit: busyLimit}
+		tr := newHostTracker(30 * time.Millisecond)
+		tasks := make(chan uint, 16)
+		for id := uint(1); id <= 16; id++ {
+			tasks <- id
+		}
+		close(tasks)
+
+		var wg sync.WaitGroup
+		for i := 1; i <= 8; i++ {
+			w := crawler.NewWorkerWithOptions(i, context.Background(), repo, tr, 5*time.Second, nil, crawler.WorkerOptions{Hosts: limiter})
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.Run(tasks)
+			}()
+		}
+		wg.Wait()
+		return tr
+	}
+
+	t.Run("Shared Across Workers", func(t *testing.T) {
+		tr := run(crawler.NewHostLimiter(2), 0)
+		assert.LessOrEqual(t, tr.peakOf("busy.example"), 2)
+		assert.LessOrEqual(t, tr.peakOf("calm.example"), 2)
+		assert.Positive(t, tr.peakOf("calm.example"))
+	})
+
+	t.Run("Per URL Override", func(t *testing.T) {
+		tr := run(crawler.NewHostLimiter(3), 1)
+		assert.Equal(t, 1, tr.peakOf("busy.example"))
+		assert.LessOrEqual(t, tr.peakOf("calm.example"), 3)
+	})
+}
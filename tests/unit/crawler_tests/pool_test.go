@@ -1,14 +1,18 @@
 package crawler_test
 
 import (
+	"bytes"
 	"context"
+	"log"
 	"net/url"
+	"os"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
 	"github.com/fuzumoe/linkTorch-api/internal/crawler"
 	"github.com/fuzumoe/linkTorch-api/internal/model"
@@ -22,7 +26,15 @@ type mockPRepo struct {
 	saveResultsCalled bool
 }
 
-func (r *mockPRepo) CountByUser(userID uint) (int, error) {
+func (r *mockPRepo) CountByUser(userID uint, f repository.URLFilter) (int, error) {
+	panic("unimplemented")
+}
+
+func (r *mockPRepo) CountByUserAndStatus(userID uint, statuses ...string) (int, error) {
+	panic("unimplemented")
+}
+
+func (r *mockPRepo) ResetStuck(runningBefore time.Time, status string) ([]uint, error) {
 	panic("unimplemented")
 }
 
@@ -57,7 +69,7 @@ func (r *mockPRepo) SaveResults(id uint, res *model.AnalysisResult, links []mode
 
 func (r *mockPRepo) Create(u *model.URL) error { return nil }
 func (r *mockPRepo) Delete(id uint) error      { return nil }
-func (r *mockPRepo) ListByUser(userID uint, p repository.Pagination) ([]model.URL, error) {
+func (r *mockPRepo) ListByUser(userID uint, f repository.URLFilter, p repository.Pagination) ([]model.URL, error) {
 	return []model.URL{}, nil
 }
 func (r *mockPRepo) Update(u *model.URL) error { return nil }
@@ -68,6 +80,82 @@ func (r *mockPRepo) ResultsWithDetails(id uint) (*model.URL, []*model.AnalysisRe
 	return &model.URL{OriginalURL: "http://example.com/details"}, []*model.AnalysisResult{}, []*model.Link{}, nil
 }
 
+func (r *mockPRepo) Merge(srcID, dstID uint) error {
+	return nil
+}
+
+func (r *mockPRepo) FindExisting(userID uint, urls []string) ([]string, error) {
+	return nil, nil
+}
+
+func (r *mockPRepo) ListUncrawled(userID uint, p repository.Pagination) ([]model.URL, error) {
+	return nil, nil
+}
+
+func (r *mockPRepo) CountUncrawled(userID uint) (int, error) {
+	return 0, nil
+}
+
+func (r *mockPRepo) DeleteErrored(userID uint) (int, error) {
+	return 0, nil
+}
+
+func (r *mockPRepo) CreateBatch(urls []*model.URL) error {
+	return nil
+}
+
+func (r *mockPRepo) FindOwnedIDs(userID uint, ids []uint) ([]uint, error) {
+	return nil, nil
+}
+
+func (r *mockPRepo) DeleteByIDs(ids []uint) (int, error) {
+	return 0, nil
+}
+
+func (r *mockPRepo) ListTagReport(userID uint, tag string, p repository.Pagination) ([]model.TagReportItemDTO, error) {
+	return nil, nil
+}
+
+func (r *mockPRepo) CountByTag(userID uint, tag string) (int, error) {
+	return 0, nil
+}
+
+func (r *mockPRepo) TagSummary(userID uint, tag string) (*model.TagSummaryDTO, error) {
+	return nil, nil
+}
+
+func (r *mockPRepo) FindIDsByTag(userID uint, tag string) ([]uint, error) {
+	return nil, nil
+}
+
+func (r *mockPRepo) FindDeleted(id uint) (*model.URL, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *mockPRepo) ListDeletedByUser(userID uint, p repository.Pagination) ([]model.URL, error) {
+	return nil, nil
+}
+
+func (r *mockPRepo) CountDeletedByUser(userID uint) (int, error) {
+	return 0, nil
+}
+
+func (r *mockPRepo) Restore(id uint) error {
+	return nil
+}
+
+func (r *mockPRepo) ListByUserCursor(userID uint, f repository.URLFilter, c repository.Cursor) ([]model.URL, string, error) {
+	return nil, "", nil
+}
+
+func (r *mockPRepo) Purge(id uint) ([]string, error) {
+	return nil, nil
+}
+
+func (r *mockPRepo) PurgeOlderThan(d time.Duration) (int, []string, error) {
+	return 0, nil, nil
+}
+
 type mockPAnalyzer struct{}
 
 func (a *mockPAnalyzer) Analyze(ctx context.Context, u *url.URL) (*model.AnalysisResult, []model.Link, error) {
@@ -123,3 +211,555 @@ func TestPool_ProcessTasks(t *testing.T) {
 		assert.True(t, mockRepo.saveResultsCalled, "Expected SaveResults to be called")
 	})
 }
+
+func TestPool_QueueDepth(t *testing.T) {
+	// Without Start nothing drains the queues, so every enqueued ID is counted.
+	pool := crawler.New(newMockPRepo(), nil, 1, 16, time.Second)
+	assert.Equal(t, 0, pool.QueueDepth())
+
+	pool.Enqueue(1)
+	pool.EnqueueWithPriority(2, 9)
+	pool.EnqueueWithPriority(3, 1)
+	assert.Equal(t, 3, pool.QueueDepth())
+}
+
+func TestPool_Workers(t *testing.T) {
+	assert.Equal(t, 3, crawler.New(newMockPRepo(), nil, 3, 16, time.Second).Workers())
+	assert.Equal(t, 4, crawler.New(newMockPRepo(), nil, 0, 16, time.Second).Workers(), "defaults to 4 workers")
+}
+
+func TestPool_QueueFull(t *testing.T) {
+	t.Run("Capacity", func(t *testing.T) {
+		pool := crawler.NewWithOptions(newMockPRepo(), nil, 1, 64, time.Second, crawler.Options{QueueCapacity: 3})
+		require.NoError(t, pool.Enqueue(1))
+		require.NoError(t, pool.EnqueueWithPriority(2, 9))
+		require.NoError(t, pool.EnqueueWithPriority(3, 1))
+
+		assert.ErrorIs(t, pool.Enqueue(4), crawler.ErrQueueFull)
+		assert.ErrorIs(t, pool.EnqueueWithPriority(5, 9), crawler.ErrQueueFull)
+		assert.Equal(t, 3, pool.QueueDepth(), "rejected ids are not queued")
+	})
+
+	t.Run("Queue Size Without Capacity", func(t *testing.T) {
+		// A buffer of 8 holds 8 ids of any priority.
+		pool := crawler.New(newMockPRepo(), nil, 1, 8, time.Second)
+		for id := uint(1); id <= 8; id++ {
+			require.NoError(t, pool.EnqueueWithPriority(id, int(id)))
+		}
+		assert.ErrorIs(t, pool.Enqueue(9), crawler.ErrQueueFull)
+		assert.ErrorIs(t, pool.EnqueueWithPriority(10, 10), crawler.ErrQueueFull)
+	})
+}
+
+func TestPool_PriorityOrder(t *testing.T) {
+	repo := newMockPRepo()
+	pool := crawler.New(repo, &mockPAnalyzer{}, 1, 16, time.Second)
+
+	// Queued before Start, so the single worker sees them all at once.
+	require.NoError(t, pool.Enqueue(1))
+	require.NoError(t, pool.EnqueueWithPriority(2, 1))
+	require.NoError(t, pool.EnqueueWithPriority(3, 9))
+	require.NoError(t, pool.Enqueue(4))
+	require.NoError(t, pool.EnqueueWithPriority(5, 9))
+	require.NoError(t, pool.EnqueueWithPriority(6, 10))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pool.Start(ctx)
+
+	assert.Eventually(t, func() bool {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		statuses := repo.statusUpdates[2]
+		return len(statuses) > 0 && statuses[len(statuses)-1] == model.StatusDone
+	}, 2*time.Second, 10*time.Millisecond)
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	var order []uint
+	seen := map[uint]bool{}
+	for _, id := range repo.findByIDCalls {
+		if !seen[id] {
+			seen[id] = true
+			order = append(order, id)
+		}
+	}
+	assert.Equal(t, []uint{6, 3, 5, 1, 4, 2}, order, "higher priorities first, FIFO within a priority")
+}
+
+func TestPool_Reprioritize(t *testing.T) {
+	repo := newMockPRepo()
+	pool := crawler.New(repo, &mockPAnalyzer{}, 1, 16, time.Second)
+
+	// Queued before Start, so the single worker sees them all at once.
+	require.NoError(t, pool.Enqueue(1))
+	require.NoError(t, pool.Enqueue(2))
+	require.NoError(t, pool.EnqueueWithPriority(3, 9))
+	require.NoError(t, pool.Enqueue(4))
+
+	require.NoError(t, pool.Reprioritize(4, 10))
+	require.NoError(t, pool.Reprioritize(3, 1))
+	assert.ErrorIs(t, pool.Reprioritize(99, 7), crawler.ErrNotQueued)
+	assert.Equal(t, 4, pool.QueueDepth(), "nothing is queued twice")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pool.Start(ctx)
+
+	assert.Eventually(t, func() bool {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		statuses := repo.statusUpdates[3]
+		return len(statuses) > 0 && statuses[len(statuses)-1] == model.StatusDone
+	}, 2*time.Second, 10*time.Millisecond)
+
+	repo.mu.Lock()
+	var order []uint
+	seen := map[uint]bool{}
+	for _, id := range repo.findByIDCalls {
+		if !seen[id] {
+			seen[id] = true
+			order = append(order, id)
+		}
+	}
+	repo.mu.Unlock()
+	assert.Equal(t, []uint{4, 1, 2, 3}, order)
+	assert.ErrorIs(t, pool.Reprioritize(4, 1), crawler.ErrNotQueued, "a URL a worker took cannot be moved")
+}
+
+func TestPool_Snapshot(t *testing.T) {
+	pool := crawler.New(newMockPRepo(), &mockPAnalyzer{}, 1, 16, time.Second)
+	assert.Empty(t, pool.Snapshot(10).Tasks)
+
+	before := time.Now()
+	require.NoError(t, pool.Enqueue(1))
+	require.NoError(t, pool.EnqueueWithPriority(2, 1))
+	require.NoError(t, pool.EnqueueWithPriority(3, 9))
+	require.NoError(t, pool.Enqueue(4))
+	require.NoError(t, pool.Reprioritize(2, 10))
+
+	snap := pool.Snapshot(0)
+	assert.Equal(t, 4, snap.Total)
+	require.Len(t, snap.Tasks, 4)
+	var order []uint
+	for _, task := range snap.Tasks {
+		order = append(order, task.URLID)
+		assert.False(t, task.EnqueuedAt.Before(before))
+	}
+	assert.Equal(t, []uint{2, 3, 1, 4}, order, "higher priorities first, FIFO within a priority")
+	assert.Equal(t, 10, snap.Tasks[0].Priority)
+	assert.Equal(t, 4, pool.QueueDepth(), "taking a snapshot leaves the queue alone")
+
+	snap = pool.Snapshot(2)
+	assert.Equal(t, 4, snap.Total)
+	assert.Len(t, snap.Tasks, 2)
+}
+
+func TestPool_Shutdown(t *testing.T) {
+	t.Run("Idle Workers", func(t *testing.T) {
+		pool := crawler.New(newMockPRepo(), &mockPAnalyzer{}, 3, 16, time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			pool.Start(ctx)
+			close(done)
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("workers waiting on an empty queue blocked shutdown")
+		}
+		assert.ErrorIs(t, pool.Enqueue(1), crawler.ErrPoolStopped)
+	})
+
+	t.Run("Abandons Queued URLs", func(t *testing.T) {
+		repo := newMockPRepo()
+		pool := crawler.New(repo, newHostTracker(100*time.Millisecond), 1, 16, time.Second)
+		for id := uint(1); id <= 5; id++ {
+			require.NoError(t, pool.Enqueue(id))
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			pool.Start(ctx)
+			close(done)
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("shutdown did not return")
+		}
+		assert.Zero(t, pool.QueueDepth())
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		assert.Less(t, len(repo.statusUpdates), 5, "queued URLs are not crawled after shutdown")
+	})
+}
+
+func TestPool_Pause(t *testing.T) {
+	crawled := func(repo *mockPRepo, id uint) bool {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		statuses := repo.statusUpdates[id]
+		return len(statuses) > 0 && statuses[len(statuses)-1] == model.StatusDone
+	}
+
+	t.Run("Holds Queue Until Resume", func(t *testing.T) {
+		repo := newMockPRepo()
+		pool := crawler.New(repo, &mockPAnalyzer{}, 2, 16, time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pool.Start(ctx)
+
+		pool.Pause()
+		assert.True(t, pool.Paused())
+		for id := uint(1); id <= 3; id++ {
+			require.NoError(t, pool.Enqueue(id), "a paused pool still accepts URLs")
+		}
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, 3, pool.QueueDepth())
+		repo.mu.Lock()
+		assert.Empty(t, repo.findByIDCalls, "no URL is taken off the queue while paused")
+		repo.mu.Unlock()
+
+		pool.Resume()
+		assert.False(t, pool.Paused())
+		assert.Eventually(t, func() bool {
+			return crawled(repo, 1) && crawled(repo, 2) && crawled(repo, 3)
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Finishes Current Crawl", func(t *testing.T) {
+		repo := newMockPRepo()
+		pool := crawler.New(repo, newHostTracker(100*time.Millisecond), 1, 16, time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pool.Start(ctx)
+
+		require.NoError(t, pool.Enqueue(1))
+		assert.Eventually(t, func() bool {
+			repo.mu.Lock()
+			defer repo.mu.Unlock()
+			return len(repo.findByIDCalls) > 0
+		}, time.Second, 5*time.Millisecond)
+		pool.Pause()
+		require.NoError(t, pool.Enqueue(2))
+
+		assert.Eventually(t, func() bool { return crawled(repo, 1) }, time.Second, 10*time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		assert.False(t, crawled(repo, 2))
+		assert.Equal(t, 1, pool.QueueDepth())
+	})
+
+	t.Run("Shutdown While Paused", func(t *testing.T) {
+		pool := crawler.New(newMockPRepo(), &mockPAnalyzer{}, 3, 16, time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			pool.Start(ctx)
+			close(done)
+		}()
+
+		pool.Pause()
+		require.NoError(t, pool.Enqueue(1))
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("workers waiting on a paused queue blocked shutdown")
+		}
+		assert.ErrorIs(t, pool.Enqueue(2), crawler.ErrPoolStopped)
+	})
+
+	t.Run("Paused Workers Do Not Retire", func(t *testing.T) {
+		pool := crawler.NewWithOptions(newMockPRepo(), &mockPAnalyzer{}, 4, 16, time.Second, crawler.Options{
+			Idle: crawler.IdleScaling{Timeout: 20 * time.Millisecond, MinWorkers: 1},
+		})
+		pool.Pause()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pool.Start(ctx)
+
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, 4, pool.Workers())
+	})
+}
+
+func TestPool_Stats(t *testing.T) {
+	t.Run("Counts Finished Crawls", func(t *testing.T) {
+		pool := crawler.New(newMockPRepo(), &flakyAnalyzer{failures: 2}, 1, 16, time.Second)
+		for id := uint(1); id <= 5; id++ {
+			require.NoError(t, pool.Enqueue(id))
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pool.Start(ctx)
+
+		assert.Eventually(t, func() bool { return pool.Stats().Processed == 5 }, 2*time.Second, 10*time.Millisecond)
+		stats := pool.Stats()
+		assert.Equal(t, 1, stats.Workers)
+		assert.Zero(t, stats.ActiveWorkers)
+		assert.Zero(t, stats.QueueLength)
+		assert.Equal(t, int64(2), stats.Errored)
+		assert.Equal(t, int64(3), stats.ByStatus[model.StatusDone])
+		assert.Equal(t, int64(2), stats.ByStatus[model.StatusError])
+		assert.Zero(t, stats.ByStatus[model.StatusSkipped])
+	})
+
+	t.Run("Active Workers And Duration", func(t *testing.T) {
+		pool := crawler.New(newMockPRepo(), newHostTracker(100*time.Millisecond), 2, 16, time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pool.Start(ctx)
+
+		pool.Pause()
+		for id := uint(1); id <= 3; id++ {
+			require.NoError(t, pool.Enqueue(id))
+		}
+		stats := pool.Stats()
+		assert.True(t, stats.Paused)
+		assert.Equal(t, 3, stats.QueueLength)
+
+		pool.Resume()
+		assert.Eventually(t, func() bool { return pool.Stats().ActiveWorkers == 2 }, time.Second, 5*time.Millisecond)
+		assert.Eventually(t, func() bool { return pool.Stats().Processed == 3 }, 2*time.Second, 10*time.Millisecond)
+		stats = pool.Stats()
+		assert.Zero(t, stats.ActiveWorkers)
+		assert.GreaterOrEqual(t, stats.AvgDurationMs, int64(100))
+		assert.Equal(t, int64(3), stats.ByStatus[model.StatusDone])
+	})
+}
+
+func TestPool_IdleScaling(t *testing.T) {
+	t.Run("Idle Workers Retire To Minimum", func(t *testing.T) {
+		pool := crawler.NewWithOptions(newMockPRepo(), &mockPAnalyzer{}, 4, 16, time.Second, crawler.Options{
+			Idle: crawler.IdleScaling{Timeout: 50 * time.Millisecond, MinWorkers: 2},
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pool.Start(ctx)
+
+		assert.Eventually(t, func() bool { return pool.Workers() == 2 }, time.Second, 10*time.Millisecond)
+		time.Sleep(150 * time.Millisecond)
+		assert.Equal(t, 2, pool.Workers(), "never retires below the minimum")
+	})
+
+	t.Run("Busy Workers Stay", func(t *testing.T) {
+		pool := crawler.NewWithOptions(newMockPRepo(), newHostTracker(200*time.Millisecond), 2, 16, time.Second, crawler.Options{
+			Idle: crawler.IdleScaling{Timeout: 100 * time.Millisecond, MinWorkers: 1},
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pool.Start(ctx)
+
+		time.Sleep(20 * time.Millisecond)
+		require.NoError(t, pool.Enqueue(1))
+		require.NoError(t, pool.Enqueue(2))
+
+		time.Sleep(150 * time.Millisecond)
+		assert.Equal(t, 2, pool.Workers(), "workers processing a task are not idle")
+		assert.Eventually(t, func() bool { return pool.Workers() == 1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		pool := crawler.NewWithOptions(newMockPRepo(), &mockPAnalyzer{}, 3, 16, time.Second, crawler.Options{})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pool.Start(ctx)
+
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, 3, pool.Workers())
+	})
+}
+
+// recordingObserver collects the crawls reported to it.
+type recordingObserver struct {
+	mu       sync.Mutex
+	started  int
+	finished []string
+}
+
+func (o *recordingObserver) CrawlStarted() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.started++
+}
+
+func (o *recordingObserver) CrawlFinished(status string, elapsed time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.finished = append(o.finished, status)
+}
+
+func TestPool_Observer(t *testing.T) {
+	obs := &recordingObserver{}
+	pool := crawler.NewWithOptions(newMockPRepo(), &flakyAnalyzer{failures: 1}, 1, 16, time.Second, crawler.Options{Observer: obs})
+	for id := uint(1); id <= 3; id++ {
+		require.NoError(t, pool.Enqueue(id))
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pool.Start(ctx)
+
+	assert.Eventually(t, func() bool {
+		obs.mu.Lock()
+		defer obs.mu.Unlock()
+		return len(obs.finished) == 3
+	}, 2*time.Second, 10*time.Millisecond)
+
+	obs.mu.Lock()
+	defer obs.mu.Unlock()
+	assert.Equal(t, 3, obs.started)
+	assert.ElementsMatch(t, []string{model.StatusError, model.StatusDone, model.StatusDone}, obs.finished)
+}
+
+func TestPool_Logs(t *testing.T) {
+	hub := crawler.NewLogHub()
+	lines, unsubscribe := hub.Subscribe(4)
+	defer unsubscribe()
+
+	pool := crawler.NewWithOptions(newMockPRepo(), &mockPAnalyzer{}, 1, 16, time.Second, crawler.Options{Logs: hub})
+	require.NoError(t, pool.Enqueue(4))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pool.Start(ctx)
+
+	select {
+	case line := <-lines:
+		assert.Equal(t, uint(4), line.URLID)
+		assert.NotEmpty(t, line.Message)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a crawl log line")
+	}
+}
+
+func TestPool_RequestID(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	pool := crawler.New(newMockPRepo(), &mockPAnalyzer{}, 1, 16, time.Second)
+	require.NoError(t, pool.EnqueueWithRequestID(7, 5, "req-abc"))
+	require.NoError(t, pool.Enqueue(8))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pool.Start(ctx)
+
+	byURL := map[uint]crawler.CrawlResult{}
+	for len(byURL) < 2 {
+		select {
+		case res := <-pool.GetResults():
+			byURL[res.URLID] = res
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for crawl results")
+		}
+	}
+	assert.Equal(t, "req-abc", byURL[7].RequestID)
+	assert.Equal(t, "req-abc", byURL[7].Event().RequestID)
+	assert.Empty(t, byURL[8].RequestID)
+
+	cancel()
+	pool.Shutdown()
+	assert.Contains(t, buf.String(), "id=7 request_id=req-abc – analyzing")
+	assert.NotContains(t, buf.String(), "id=8 request_id=")
+}
+
+func TestPool_ReservedWorkers(t *testing.T) {
+	crawled := func(repo *mockPRepo, id uint) func() bool {
+		return func() bool {
+			repo.mu.Lock()
+			defer repo.mu.Unlock()
+			for _, got := range repo.findByIDCalls {
+				if got == id {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	t.Run("High Priority Skips Saturated Queue", func(t *testing.T) {
+		repo := newMockPRepo()
+		slow := newHostTracker(300 * time.Millisecond)
+		pool := crawler.NewWithOptions(repo, slow, 3, 32, time.Second, crawler.Options{
+			Reserve: crawler.ReservedWorkers{Workers: 1, MinPriority: 8},
+		})
+		for id := uint(1); id <= 10; id++ {
+			require.NoError(t, pool.EnqueueWithPriority(id, 1))
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pool.Start(ctx)
+
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, 2, slow.peakOf("example.com"), "the reserved worker leaves low-priority URLs alone")
+
+		require.NoError(t, pool.EnqueueWithPriority(100, 9))
+		assert.Eventually(t, crawled(repo, 100), 100*time.Millisecond, 5*time.Millisecond,
+			"a high-priority URL starts without waiting for a busy worker")
+	})
+
+	t.Run("Overflow Goes To Other Workers", func(t *testing.T) {
+		repo := newMockPRepo()
+		slow := newHostTracker(300 * time.Millisecond)
+		pool := crawler.NewWithOptions(repo, slow, 2, 32, time.Second, crawler.Options{
+			Reserve: crawler.ReservedWorkers{Workers: 1, MinPriority: 8},
+		})
+		require.NoError(t, pool.EnqueueWithPriority(1, 9))
+		require.NoError(t, pool.EnqueueWithPriority(2, 9))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pool.Start(ctx)
+
+		assert.Eventually(t, func() bool { return slow.peakOf("example.com") == 2 }, 200*time.Millisecond, 5*time.Millisecond,
+			"high-priority URLs use unreserved workers once the reserved ones are busy")
+	})
+
+	t.Run("Leaves One Unreserved Worker", func(t *testing.T) {
+		repo := newMockPRepo()
+		pool := crawler.NewWithOptions(repo, &mockPAnalyzer{}, 2, 16, time.Second, crawler.Options{
+			Reserve: crawler.ReservedWorkers{Workers: 5},
+		})
+		require.NoError(t, pool.Enqueue(1))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pool.Start(ctx)
+
+		assert.Eventually(t, crawled(repo, 1), time.Second, 10*time.Millisecond)
+		assert.Equal(t, 2, pool.Workers())
+	})
+
+	t.Run("Reserved Workers Do Not Retire", func(t *testing.T) {
+		pool := crawler.NewWithOptions(newMockPRepo(), &mockPAnalyzer{}, 4, 16, time.Second, crawler.Options{
+			Idle:    crawler.IdleScaling{Timeout: 30 * time.Millisecond, MinWorkers: 1},
+			Reserve: crawler.ReservedWorkers{Workers: 2},
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pool.Start(ctx)
+
+		assert.Eventually(t, func() bool { return pool.Workers() == 3 }, time.Second, 10*time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, 3, pool.Workers(), "two reserved workers plus the unreserved minimum")
+	})
+}
+
+func TestPool_Running(t *testing.T) {
+	pool := crawler.New(newMockPRepo(), &mockPAnalyzer{}, 1, 16, time.Second)
+	assert.False(t, pool.Running(), "not started yet")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pool.Start(ctx)
+	assert.Eventually(t, pool.Running, time.Second, 10*time.Millisecond)
+
+	pool.Shutdown()
+	assert.False(t, pool.Running())
+}
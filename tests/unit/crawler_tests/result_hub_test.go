@@ -0,0 +1,91 @@
+package crawler_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/crawler"
+)
+
+func TestResultHub(t *testing.T) {
+	recv := func(t *testing.T, ch <-chan crawler.CrawlResult) crawler.CrawlResult {
+		t.Helper()
+		select {
+		case r := <-ch:
+			return r
+		case <-time.After(time.Second):
+			t.Fatal("no result delivered")
+		}
+		return crawler.CrawlResult{}
+	}
+
+	t.Run("Fans Out", func(t *testing.T) {
+		src := make(chan crawler.CrawlResult)
+		hub := crawler.NewResultHub()
+		a, unsubA := hub.Subscribe()
+		defer unsubA()
+		b, unsubB := hub.Subscribe()
+		defer unsubB()
+		go hub.Run(src)
+
+		src <- crawler.CrawlResult{URLID: 1}
+		assert.Equal(t, uint(1), recv(t, a).URLID)
+		assert.Equal(t, uint(1), recv(t, b).URLID)
+		close(src)
+	})
+
+	t.Run("Unsubscribed Receive Nothing", func(t *testing.T) {
+		src := make(chan crawler.CrawlResult)
+		hub := crawler.NewResultHub()
+		a, unsubA := hub.Subscribe()
+		b, unsubB := hub.Subscribe()
+		defer unsubB()
+		go hub.Run(src)
+
+		unsubA()
+		unsubA() // idempotent
+		src <- crawler.CrawlResult{URLID: 2}
+		assert.Equal(t, uint(2), recv(t, b).URLID)
+		assert.Empty(t, a)
+		close(src)
+	})
+
+	t.Run("Slow Subscriber Does Not Block", func(t *testing.T) {
+		src := make(chan crawler.CrawlResult)
+		hub := crawler.NewResultHub()
+		_, unsubSlow := hub.Subscribe()
+		defer unsubSlow()
+		fast, unsubFast := hub.Subscribe()
+		defer unsubFast()
+		go hub.Run(src)
+
+		for i := uint(1); i <= 100; i++ {
+			src <- crawler.CrawlResult{URLID: i}
+			assert.Equal(t, i, recv(t, fast).URLID)
+		}
+		close(src)
+	})
+
+	t.Run("Closed With Source", func(t *testing.T) {
+		src := make(chan crawler.CrawlResult)
+		hub := crawler.NewResultHub()
+		a, unsub := hub.Subscribe()
+		defer unsub()
+		done := make(chan struct{})
+		go func() {
+			hub.Run(src)
+			close(done)
+		}()
+		close(src)
+		<-done
+
+		_, ok := <-a
+		assert.False(t, ok)
+		late, _ := hub.Subscribe()
+		_, ok = <-late
+		require.False(t, ok, "subscribing after the source closed yields a closed channel")
+	})
+}
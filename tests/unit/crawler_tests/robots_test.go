@@ -0,0 +1,185 @@
+package crawler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/crawler"
+	"github.com/fuzumoe/linkTorch-api/internal/egress"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+const robotsTxt = `# sample
+User-agent: *
+Disallow: /private/
+Allow: /private/open
+Disallow: /*.pdf$
+
+User-agent: OtherBot
+Disallow: /
+
+User-agent: linkTorch-Bot
+Disallow: /drafts
+Allow: /drafts/published
+`
+
+func robotsServer(t *testing.T, status int, body string) (*httptest.Server, *int32) {
+	t.Helper()
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/robots.txt" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(ts.Close)
+	return ts, &hits
+}
+
+func mustParse(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u
+}
+
+func TestRobotsChecker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Wildcard Group", func(t *testing.T) {
+		ts, _ := robotsServer(t, http.StatusOK, robotsTxt)
+		rc := crawler.NewRobotsChecker("SomeCrawler/1.0", time.Minute, nil)
+
+		for path, want := range map[string]bool{
+			"/":                   true,
+			"/private/page":       false,
+			"/private/open/page":  true,
+			"/files/report.pdf":   false,
+			"/files/report.pdf?x": true,
+			"/drafts":             true,
+		} {
+			allowed, err := rc.Allowed(ctx, mustParse(t, ts.URL+path))
+			require.NoError(t, err)
+			assert.Equal(t, want, allowed, path)
+		}
+	})
+
+	t.Run("Own Group Wins", func(t *testing.T) {
+		ts, _ := robotsServer(t, http.StatusOK, robotsTxt)
+		rc := crawler.NewRobotsChecker("linkTorch-Bot/1.0", time.Minute, nil)
+
+		for path, want := range map[string]bool{
+			"/drafts/one":       false,
+			"/drafts/published": true,
+			"/private/page":     true, // the "*" group no longer applies
+		} {
+			allowed, err := rc.Allowed(ctx, mustParse(t, ts.URL+path))
+			require.NoError(t, err)
+			assert.Equal(t, want, allowed, path)
+		}
+	})
+
+	t.Run("Cached Per Host", func(t *testing.T) {
+		ts, hits := robotsServer(t, http.StatusOK, robotsTxt)
+		rc := crawler.NewRobotsChecker("SomeCrawler/1.0", time.Minute, nil)
+
+		for _, path := range []string{"/a", "/b", "/private/c"} {
+			_, err := rc.Allowed(ctx, mustParse(t, ts.URL+path))
+			require.NoError(t, err)
+		}
+		assert.EqualValues(t, 1, atomic.LoadInt32(hits))
+	})
+
+	t.Run("Refetched After TTL", func(t *testing.T) {
+		ts, hits := robotsServer(t, http.StatusOK, robotsTxt)
+		rc := crawler.NewRobotsChecker("SomeCrawler/1.0", 10*time.Millisecond, nil)
+
+		_, err := rc.Allowed(ctx, mustParse(t, ts.URL+"/a"))
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+		_, err = rc.Allowed(ctx, mustParse(t, ts.URL+"/a"))
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, atomic.LoadInt32(hits))
+	})
+
+	t.Run("Missing File Allows All", func(t *testing.T) {
+		ts, _ := robotsServer(t, http.StatusNotFound, "")
+		rc := crawler.NewRobotsChecker("SomeCrawler/1.0", time.Minute, nil)
+
+		allowed, err := rc.Allowed(ctx, mustParse(t, ts.URL+"/private/page"))
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("Server Error Is Not Cached", func(t *testing.T) {
+		ts, hits := robotsServer(t, http.StatusServiceUnavailable, "")
+		rc := crawler.NewRobotsChecker("SomeCrawler/1.0", time.Minute, nil)
+
+		allowed, err := rc.Allowed(ctx, mustParse(t, ts.URL+"/a"))
+		assert.Error(t, err)
+		assert.True(t, allowed, "an unreachable robots.txt must not block the crawl")
+		_, _ = rc.Allowed(ctx, mustParse(t, ts.URL+"/a"))
+		assert.EqualValues(t, 2, atomic.LoadInt32(hits))
+	})
+
+	t.Run("Egress Rejected Host Not Fetched", func(t *testing.T) {
+		ts, hits := robotsServer(t, http.StatusOK, robotsTxt)
+		policy := egress.NewPolicy(egress.ModeAllowlist, []string{"example.com"})
+		rc := crawler.NewRobotsChecker("SomeCrawler/1.0", time.Minute, policy)
+
+		allowed, err := rc.Allowed(ctx, mustParse(t, ts.URL+"/private/page"))
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Zero(t, atomic.LoadInt32(hits))
+	})
+}
+
+// robotsRepo serves a single URL pointing at a test server.
+type robotsRepo struct {
+	*testRepo
+	target string
+}
+
+func (r *robotsRepo) FindByID(id uint) (*model.URL, error) {
+	u, err := r.testRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	u.OriginalURL = r.target
+	return u, nil
+}
+
+func TestWorker_RobotsDisallowed(t *testing.T) {
+	ts, _ := robotsServer(t, http.StatusOK, robotsTxt)
+	repo := &robotsRepo{testRepo: newTestRepo(), target: ts.URL + "/private/page"}
+	a := &flakyAnalyzer{}
+	resultsChan := make(chan crawler.CrawlResult, 1)
+	rc := crawler.NewRobotsChecker("SomeCrawler/1.0", time.Minute, nil)
+
+	worker := crawler.NewWorkerWithOptions(1, context.Background(), repo, a, time.Second, resultsChan, crawler.WorkerOptions{Robots: rc})
+	tasks := make(chan uint, 1)
+	tasks <- 12
+	close(tasks)
+	worker.Run(tasks)
+
+	assert.Zero(t, a.calls, "A disallowed page must not be fetched")
+	result := <-resultsChan
+	assert.Equal(t, model.StatusSkipped, result.Status)
+	assert.ErrorIs(t, result.Error, crawler.ErrDisallowedByRobots)
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	assert.Equal(t, model.StatusSkipped, repo.urlStatus[12])
+	assert.False(t, repo.saveResultsCalled)
+}
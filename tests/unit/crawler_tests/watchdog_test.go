@@ -0,0 +1,138 @@
+package crawler_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/crawler"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+// stuckRepo remembers since when each URL has been running and resets them
+// like the real repository does.
+type stuckRepo struct {
+	*testRepo
+	runningSince map[uint]time.Time
+}
+
+func (r *stuckRepo) ResetStuck(runningBefore time.Time, status string) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []uint
+	for id, since := range r.runningSince {
+		if r.urlStatus[id] == model.StatusRunning && since.Before(runningBefore) {
+			r.urlStatus[id] = status
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// enqueuePool records the URLs handed back to it.
+type enqueuePool struct {
+	enqueued []uint
+	err      error
+}
+
+func (p *enqueuePool) Start(ctx context.Context) {}
+func (p *enqueuePool) Enqueue(id uint) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.enqueued = append(p.enqueued, id)
+	return nil
+}
+func (p *enqueuePool) EnqueueWithPriority(id uint, priority int) error { return p.Enqueue(id) }
+func (p *enqueuePool) EnqueueWithRequestID(id uint, priority int, requestID string) error {
+	return p.Enqueue(id)
+}
+func (p *enqueuePool) Shutdown()                                {}
+func (p *enqueuePool) GetResults() <-chan crawler.CrawlResult   { return nil }
+func (p *enqueuePool) Reprioritize(id uint, priority int) error { return nil }
+func (p *enqueuePool) Snapshot(limit int) crawler.QueueSnapshot { return crawler.QueueSnapshot{} }
+func (p *enqueuePool) AdjustWorkers(cmd crawler.ControlCommand) {}
+func (p *enqueuePool) QueueDepth() int                          { return 0 }
+func (p *enqueuePool) Workers() int                             { return 1 }
+func (p *enqueuePool) Pause()                                   {}
+func (p *enqueuePool) Resume()                                  {}
+func (p *enqueuePool) Paused() bool                             { return false }
+func (p *enqueuePool) Running() bool                            { return true }
+func (p *enqueuePool) Stats() crawler.PoolStats                 { return crawler.PoolStats{} }
+
+func newStuckRepo() *stuckRepo {
+	repo := &stuckRepo{testRepo: newTestRepo(), runningSince: map[uint]time.Time{}}
+	// URL 1 has been running for an hour, URL 2 for a minute; URL 3 is done.
+	repo.urlStatus[1] = model.StatusRunning
+	repo.runningSince[1] = time.Now().Add(-time.Hour)
+	repo.urlStatus[2] = model.StatusRunning
+	repo.runningSince[2] = time.Now().Add(-time.Minute)
+	repo.urlStatus[3] = model.StatusDone
+	repo.runningSince[3] = time.Now().Add(-time.Hour)
+	return repo
+}
+
+func TestWatchdog(t *testing.T) {
+	t.Run("Requeues Stuck URLs", func(t *testing.T) {
+		repo, pool := newStuckRepo(), &enqueuePool{}
+		w := crawler.NewWatchdog(repo, pool, crawler.WatchdogOptions{StuckAfter: 10 * time.Minute})
+
+		ids, err := w.Sweep()
+		require.NoError(t, err)
+		assert.Equal(t, []uint{1}, ids)
+		assert.Equal(t, []uint{1}, pool.enqueued)
+		assert.Equal(t, model.StatusQueued, repo.urlStatus[1])
+		assert.Equal(t, model.StatusRunning, repo.urlStatus[2], "URLs within the threshold keep running")
+		assert.Equal(t, model.StatusDone, repo.urlStatus[3])
+
+		ids, err = w.Sweep()
+		require.NoError(t, err)
+		assert.Empty(t, ids, "a reset URL is not reset again")
+	})
+
+	t.Run("Marks Stuck URLs Failed", func(t *testing.T) {
+		repo, pool := newStuckRepo(), &enqueuePool{}
+		w := crawler.NewWatchdog(repo, pool, crawler.WatchdogOptions{StuckAfter: 10 * time.Minute, ResetTo: model.StatusError})
+
+		ids, err := w.Sweep()
+		require.NoError(t, err)
+		assert.Equal(t, []uint{1}, ids)
+		assert.Empty(t, pool.enqueued)
+		assert.Equal(t, model.StatusError, repo.urlStatus[1])
+	})
+
+	t.Run("Queue Full", func(t *testing.T) {
+		repo, pool := newStuckRepo(), &enqueuePool{err: crawler.ErrQueueFull}
+		w := crawler.NewWatchdog(repo, pool, crawler.WatchdogOptions{StuckAfter: 10 * time.Minute})
+
+		_, err := w.Sweep()
+		require.NoError(t, err)
+		assert.Equal(t, model.StatusError, repo.urlStatus[1], "a URL that cannot be re-queued must not stay queued")
+	})
+
+	t.Run("Runs On Ticker", func(t *testing.T) {
+		repo, pool := newStuckRepo(), &enqueuePool{}
+		w := crawler.NewWatchdog(repo, pool, crawler.WatchdogOptions{Interval: 5 * time.Millisecond, StuckAfter: 10 * time.Minute})
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			w.Run(ctx)
+			close(done)
+		}()
+
+		assert.Eventually(t, func() bool {
+			repo.mu.Lock()
+			defer repo.mu.Unlock()
+			return repo.urlStatus[1] == model.StatusQueued
+		}, time.Second, 5*time.Millisecond)
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("watchdog did not stop")
+		}
+	})
+}
@@ -3,6 +3,8 @@ package crawler_test
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"sync"
 	"testing"
@@ -10,7 +12,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
+	"github.com/fuzumoe/linkTorch-api/internal/analyzer"
 	"github.com/fuzumoe/linkTorch-api/internal/crawler"
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
@@ -24,7 +28,15 @@ type testRepo struct {
 	urlStatus         map[uint]string
 }
 
-func (r *testRepo) CountByUser(userID uint) (int, error) {
+func (r *testRepo) CountByUser(userID uint, f repository.URLFilter) (int, error) {
+	panic("unimplemented")
+}
+
+func (r *testRepo) CountByUserAndStatus(userID uint, statuses ...string) (int, error) {
+	panic("unimplemented")
+}
+
+func (r *testRepo) ResetStuck(runningBefore time.Time, status string) ([]uint, error) {
 	panic("unimplemented")
 }
 
@@ -67,7 +79,7 @@ func (r *testRepo) SaveResults(id uint, res *model.AnalysisResult, links []model
 
 func (r *testRepo) Create(u *model.URL) error { return nil }
 func (r *testRepo) Delete(id uint) error      { return nil }
-func (r *testRepo) ListByUser(userID uint, p repository.Pagination) ([]model.URL, error) {
+func (r *testRepo) ListByUser(userID uint, f repository.URLFilter, p repository.Pagination) ([]model.URL, error) {
 	return []model.URL{}, nil
 }
 func (r *testRepo) Update(u *model.URL) error { return nil }
@@ -86,6 +98,82 @@ func (r *testRepo) ResultsWithDetails(id uint) (*model.URL, []*model.AnalysisRes
 	}, []*model.AnalysisResult{}, []*model.Link{}, nil
 }
 
+func (r *testRepo) Merge(srcID, dstID uint) error {
+	return nil
+}
+
+func (r *testRepo) FindExisting(userID uint, urls []string) ([]string, error) {
+	return nil, nil
+}
+
+func (r *testRepo) ListUncrawled(userID uint, p repository.Pagination) ([]model.URL, error) {
+	return nil, nil
+}
+
+func (r *testRepo) CountUncrawled(userID uint) (int, error) {
+	return 0, nil
+}
+
+func (r *testRepo) DeleteErrored(userID uint) (int, error) {
+	return 0, nil
+}
+
+func (r *testRepo) CreateBatch(urls []*model.URL) error {
+	return nil
+}
+
+func (r *testRepo) FindOwnedIDs(userID uint, ids []uint) ([]uint, error) {
+	return nil, nil
+}
+
+func (r *testRepo) DeleteByIDs(ids []uint) (int, error) {
+	return 0, nil
+}
+
+func (r *testRepo) ListTagReport(userID uint, tag string, p repository.Pagination) ([]model.TagReportItemDTO, error) {
+	return nil, nil
+}
+
+func (r *testRepo) CountByTag(userID uint, tag string) (int, error) {
+	return 0, nil
+}
+
+func (r *testRepo) TagSummary(userID uint, tag string) (*model.TagSummaryDTO, error) {
+	return nil, nil
+}
+
+func (r *testRepo) FindIDsByTag(userID uint, tag string) ([]uint, error) {
+	return nil, nil
+}
+
+func (r *testRepo) FindDeleted(id uint) (*model.URL, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *testRepo) ListDeletedByUser(userID uint, p repository.Pagination) ([]model.URL, error) {
+	return nil, nil
+}
+
+func (r *testRepo) CountDeletedByUser(userID uint) (int, error) {
+	return 0, nil
+}
+
+func (r *testRepo) Restore(id uint) error {
+	return nil
+}
+
+func (r *testRepo) ListByUserCursor(userID uint, f repository.URLFilter, c repository.Cursor) ([]model.URL, string, error) {
+	return nil, "", nil
+}
+
+func (r *testRepo) Purge(id uint) ([]string, error) {
+	return nil, nil
+}
+
+func (r *testRepo) PurgeOlderThan(d time.Duration) (int, []string, error) {
+	return 0, nil, nil
+}
+
 type dummyAnalyzer struct {
 	shouldError bool
 }
@@ -111,6 +199,72 @@ func (a *cancelAnalyzer) Analyze(ctx context.Context, u *url.URL) (*model.Analys
 	return nil, nil, context.Canceled
 }
 
+type unsupportedAnalyzer struct{}
+
+func (a *unsupportedAnalyzer) Analyze(ctx context.Context, u *url.URL) (*model.AnalysisResult, []model.Link, error) {
+	return nil, nil, analyzer.ErrUnsupportedContent
+}
+
+type panicOnceAnalyzer struct {
+	calls int
+}
+
+func (a *panicOnceAnalyzer) Analyze(ctx context.Context, u *url.URL) (*model.AnalysisResult, []model.Link, error) {
+	a.calls++
+	if a.calls == 1 {
+		panic("malformed document")
+	}
+	return &model.AnalysisResult{HTMLVersion: "HTML 5"}, nil, nil
+}
+
+type flakyAnalyzer struct {
+	failures int
+	calls    int
+}
+
+func (a *flakyAnalyzer) Analyze(ctx context.Context, u *url.URL) (*model.AnalysisResult, []model.Link, error) {
+	a.calls++
+	if a.calls <= a.failures {
+		return nil, nil, &analyzer.HTTPStatusError{StatusCode: 503}
+	}
+	return &model.AnalysisResult{HTMLVersion: "HTML 5"}, nil, nil
+}
+
+type countingCancelAnalyzer struct {
+	calls int
+}
+
+func (a *countingCancelAnalyzer) Analyze(ctx context.Context, u *url.URL) (*model.AnalysisResult, []model.Link, error) {
+	a.calls++
+	return nil, nil, context.Canceled
+}
+
+// pageRepo serves a single URL at a fixed address and crawl method and
+// keeps what was saved for it.
+type pageRepo struct {
+	*testRepo
+	url    string
+	method string
+	saved  *model.AnalysisResult
+	links  []model.Link
+}
+
+func (r *pageRepo) FindByID(id uint) (*model.URL, error) {
+	u, err := r.testRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	u.OriginalURL, u.CrawlMethod = r.url, r.method
+	return u, nil
+}
+
+func (r *pageRepo) SaveResults(id uint, res *model.AnalysisResult, links []model.Link) error {
+	r.mu.Lock()
+	r.saved, r.links = res, links
+	r.mu.Unlock()
+	return r.testRepo.SaveResults(id, res, links)
+}
+
 func TestWorkerSuite(t *testing.T) {
 	t.Run("Process_Success", func(t *testing.T) {
 		ctx := context.Background()
@@ -256,4 +410,279 @@ func TestWorkerSuite(t *testing.T) {
 		assert.Equal(t, model.StatusStopped, statuses[len(statuses)-1], "Final status should be Stopped")
 		assert.False(t, repo.saveResultsCalled, "SaveResults should not be called when cancelled")
 	})
+
+	t.Run("Process_SkippedContent", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newTestRepo()
+		require.NoError(t, repo.UpdateStatus(5, model.StatusQueued))
+
+		resultsChan := make(chan crawler.CrawlResult, 1)
+		worker := crawler.NewWorker(1, ctx, repo, &unsupportedAnalyzer{}, 1*time.Second, resultsChan)
+		tasks := make(chan uint, 1)
+		tasks <- 5
+		close(tasks)
+		worker.Run(tasks)
+
+		result := <-resultsChan
+		assert.Equal(t, model.StatusSkipped, result.Status)
+
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		statuses := repo.statusUpdates[5]
+		require.NotEmpty(t, statuses)
+		assert.Equal(t, model.StatusSkipped, statuses[len(statuses)-1], "Final status should be Skipped")
+		assert.False(t, repo.saveResultsCalled, "SaveResults should not be called when skipped")
+	})
+
+	t.Run("Process_PublishesLogLines", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newTestRepo()
+		hub := crawler.NewLogHub()
+		lines, unsubscribe := hub.Subscribe(6)
+		defer unsubscribe()
+
+		worker := crawler.NewWorkerWithOptions(3, ctx, repo, &dummyAnalyzer{}, 1*time.Second, nil, crawler.WorkerOptions{Logs: hub})
+		tasks := make(chan uint, 1)
+		tasks <- 6
+		close(tasks)
+		worker.Run(tasks)
+
+		var messages []string
+		for len(lines) > 0 {
+			line := <-lines
+			assert.Equal(t, uint(6), line.URLID)
+			assert.Equal(t, 3, line.Worker)
+			messages = append(messages, line.Message)
+		}
+		require.NotEmpty(t, messages)
+		assert.Equal(t, "analyzing http://example.com", messages[0])
+		assert.Contains(t, messages[len(messages)-1], "done in")
+	})
+
+	t.Run("Process_RecoversFromPanic", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newTestRepo()
+		require.NoError(t, repo.UpdateStatus(7, model.StatusQueued))
+		require.NoError(t, repo.UpdateStatus(8, model.StatusQueued))
+
+		resultsChan := make(chan crawler.CrawlResult, 2)
+		worker := crawler.NewWorker(1, ctx, repo, &panicOnceAnalyzer{}, 1*time.Second, resultsChan)
+		tasks := make(chan uint, 2)
+		tasks <- 7
+		tasks <- 8
+		close(tasks)
+		assert.NotPanics(t, func() { worker.Run(tasks) })
+
+		first := <-resultsChan
+		assert.Equal(t, uint(7), first.URLID)
+		assert.Equal(t, model.StatusError, first.Status)
+		assert.ErrorContains(t, first.Error, "malformed document")
+		second := <-resultsChan
+		assert.Equal(t, uint(8), second.URLID)
+		assert.Equal(t, model.StatusDone, second.Status)
+
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		assert.Equal(t, model.StatusError, repo.urlStatus[7], "Panicking URL should be marked error")
+		assert.Equal(t, model.StatusDone, repo.urlStatus[8], "Worker should keep processing after a panic")
+	})
+
+	t.Run("Process_RetriesUntilSuccess", func(t *testing.T) {
+		repo := newTestRepo()
+		a := &flakyAnalyzer{failures: 2}
+		resultsChan := make(chan crawler.CrawlResult, 5)
+		retry := crawler.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+		worker := crawler.NewWorkerWithOptions(1, context.Background(), repo, a, time.Second, resultsChan, crawler.WorkerOptions{Retry: retry})
+		tasks := make(chan uint, 1)
+		tasks <- 9
+		close(tasks)
+		worker.Run(tasks)
+
+		assert.Equal(t, 3, a.calls)
+		require.Len(t, resultsChan, 3)
+		for attempt := 1; attempt <= 2; attempt++ {
+			r := <-resultsChan
+			assert.Equal(t, attempt, r.Attempt)
+			assert.Equal(t, model.StatusRunning, r.Status)
+			var statusErr *analyzer.HTTPStatusError
+			assert.ErrorAs(t, r.Error, &statusErr)
+		}
+		final := <-resultsChan
+		assert.Equal(t, 3, final.Attempt)
+		assert.Equal(t, model.StatusDone, final.Status)
+		assert.NoError(t, final.Error)
+		assert.True(t, repo.saveResultsCalled)
+	})
+
+	t.Run("Process_RetriesExhausted", func(t *testing.T) {
+		repo := newTestRepo()
+		a := &flakyAnalyzer{failures: 5}
+		resultsChan := make(chan crawler.CrawlResult, 5)
+		retry := crawler.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+		worker := crawler.NewWorkerWithOptions(1, context.Background(), repo, a, time.Second, resultsChan, crawler.WorkerOptions{Retry: retry})
+		tasks := make(chan uint, 1)
+		tasks <- 10
+		close(tasks)
+		worker.Run(tasks)
+
+		assert.Equal(t, 2, a.calls)
+		require.Len(t, resultsChan, 2)
+		first := <-resultsChan
+		assert.Equal(t, 1, first.Attempt)
+		final := <-resultsChan
+		assert.Equal(t, 2, final.Attempt)
+		assert.Equal(t, model.StatusError, final.Status)
+		assert.ErrorContains(t, final.Error, "status 503")
+
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		assert.Equal(t, model.StatusError, repo.urlStatus[10])
+		assert.True(t, repo.saveResultsCalled, "the final server error is kept as a result")
+	})
+
+	t.Run("Process_ServerErrorSavesStatus", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer ts.Close()
+
+		repo := &pageRepo{testRepo: newTestRepo(), url: ts.URL, method: model.CrawlMethodFull}
+		worker := crawler.NewWorker(1, context.Background(), repo, analyzer.NewHTMLAnalyzer(analyzer.Options{}), time.Second, nil)
+		tasks := make(chan uint, 1)
+		tasks <- 16
+		close(tasks)
+		worker.Run(tasks)
+
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		assert.Equal(t, model.StatusError, repo.urlStatus[16])
+		require.NotNil(t, repo.saved)
+		assert.Equal(t, http.StatusInternalServerError, repo.saved.StatusCode)
+		assert.Equal(t, analyzer.HTMLVersionUnknown, repo.saved.HTMLVersion)
+		assert.NotNil(t, repo.saved.RunID)
+		assert.NotNil(t, repo.saved.CrawlDurationMs)
+		assert.Empty(t, repo.links)
+	})
+
+	t.Run("Process_NoRetryOnCancellation", func(t *testing.T) {
+		repo := newTestRepo()
+		a := &countingCancelAnalyzer{}
+		resultsChan := make(chan crawler.CrawlResult, 5)
+		retry := crawler.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+		worker := crawler.NewWorkerWithOptions(1, context.Background(), repo, a, time.Second, resultsChan, crawler.WorkerOptions{Retry: retry})
+		tasks := make(chan uint, 1)
+		tasks <- 11
+		close(tasks)
+		worker.Run(tasks)
+
+		assert.Equal(t, 1, a.calls, "Cancelled crawls must not be retried")
+		require.Len(t, resultsChan, 1)
+		final := <-resultsChan
+		assert.Equal(t, 1, final.Attempt)
+
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+		assert.Equal(t, model.StatusStopped, repo.urlStatus[11])
+	})
+
+	t.Run("Process_CrawlMethod", func(t *testing.T) {
+		var methods []string
+		var mu sync.Mutex
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			methods = append(methods, r.Method)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><head><title>Up</title></head><body><h1>A</h1><h2>B</h2></body></html>`))
+		}))
+		defer ts.Close()
+
+		crawl := func(method string) *pageRepo {
+			methods = nil
+			repo := &pageRepo{testRepo: newTestRepo(), url: ts.URL, method: method}
+			worker := crawler.NewWorker(1, context.Background(), repo, analyzer.NewHTMLAnalyzer(analyzer.Options{}), time.Second, nil)
+			tasks := make(chan uint, 1)
+			tasks <- 12
+			close(tasks)
+			worker.Run(tasks)
+			return repo
+		}
+
+		headOnly := crawl(model.CrawlMethodHeadOnly)
+		require.NotNil(t, headOnly.saved)
+		assert.Equal(t, []string{http.MethodHead}, methods)
+		assert.Equal(t, http.StatusOK, headOnly.saved.StatusCode)
+		require.NotNil(t, headOnly.saved.CrawlDurationMs, "head-only crawls are timed")
+		assert.Zero(t, headOnly.saved.H1Count)
+		assert.Zero(t, headOnly.saved.H2Count)
+		assert.Empty(t, headOnly.saved.Title)
+		assert.Empty(t, headOnly.links)
+		assert.Equal(t, model.StatusDone, headOnly.urlStatus[12])
+
+		full := crawl(model.CrawlMethodFull)
+		require.NotNil(t, full.saved)
+		assert.Equal(t, []string{http.MethodGet}, methods)
+		assert.Equal(t, http.StatusOK, full.saved.StatusCode)
+		assert.Equal(t, 1, full.saved.H1Count)
+		assert.Equal(t, 1, full.saved.H2Count)
+		assert.Equal(t, "Up", full.saved.Title)
+	})
+
+	t.Run("Process_HeadOnlyWithoutHeadSupport", func(t *testing.T) {
+		repo := &pageRepo{testRepo: newTestRepo(), url: "http://example.com", method: model.CrawlMethodHeadOnly}
+		worker := crawler.NewWorker(1, context.Background(), repo, &dummyAnalyzer{}, time.Second, nil)
+		tasks := make(chan uint, 1)
+		tasks <- 13
+		close(tasks)
+		worker.Run(tasks)
+
+		require.NotNil(t, repo.saved)
+		assert.Equal(t, "Test Page", repo.saved.Title, "analyzers without HEAD support crawl in full")
+		assert.Len(t, repo.links, 2)
+	})
+
+	t.Run("Process_RecordsProvenance", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><head><title>Up</title></head></html>`))
+		}))
+		defer ts.Close()
+
+		repo := &pageRepo{testRepo: newTestRepo(), url: ts.URL, method: model.CrawlMethodHeadOnly}
+		worker := crawler.NewWorker(7, context.Background(), repo, analyzer.NewHTMLAnalyzer(analyzer.Options{}), time.Second, nil)
+		tasks := make(chan uint, 1)
+		tasks <- 14
+		close(tasks)
+		worker.Run(tasks)
+
+		require.NotNil(t, repo.saved)
+		prov := repo.saved.Provenance
+		require.NotNil(t, prov)
+		assert.Equal(t, 7, prov.WorkerID)
+		assert.Equal(t, 1, prov.Attempts)
+		assert.Equal(t, "html", prov.Analyzer)
+		assert.Equal(t, model.RenderModeHead, prov.RenderMode)
+		assert.False(t, prov.StartedAt.IsZero())
+		assert.False(t, prov.FinishedAt.Before(prov.StartedAt))
+		assert.Equal(t, *repo.saved.CrawlDurationMs, prov.TotalMs)
+		assert.LessOrEqual(t, prov.HostWaitMs+prov.AnalyzeMs, prov.TotalMs)
+	})
+
+	t.Run("Process_ProvenanceAfterRetries", func(t *testing.T) {
+		repo := &pageRepo{testRepo: newTestRepo(), url: "http://example.com", method: model.CrawlMethodFull}
+		retry := crawler.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+		worker := crawler.NewWorkerWithOptions(2, context.Background(), repo, &flakyAnalyzer{failures: 1}, time.Second, nil, crawler.WorkerOptions{Retry: retry})
+		tasks := make(chan uint, 1)
+		tasks <- 15
+		close(tasks)
+		worker.Run(tasks)
+
+		require.NotNil(t, repo.saved)
+		prov := repo.saved.Provenance
+		require.NotNil(t, prov)
+		assert.Equal(t, 2, prov.WorkerID)
+		assert.Equal(t, 2, prov.Attempts)
+		assert.Equal(t, "*crawler_test.flakyAnalyzer", prov.Analyzer, "unnamed analyzers are reported by type")
+		assert.Equal(t, model.RenderModeFull, prov.RenderMode)
+	})
 }
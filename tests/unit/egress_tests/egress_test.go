@@ -0,0 +1,66 @@
+package egress_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/egress"
+)
+
+func mustParse(t *testing.T, raw string) *url.URL {
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u
+}
+
+func TestPolicy_Check(t *testing.T) {
+	t.Run("Allowlist Allowed Hosts", func(t *testing.T) {
+		p := egress.NewPolicy(egress.ModeAllowlist, []string{"example.com", " Intranet.local "})
+		assert.NoError(t, p.Check(mustParse(t, "https://example.com/page")))
+		assert.NoError(t, p.Check(mustParse(t, "https://docs.example.com/")))
+		assert.NoError(t, p.Check(mustParse(t, "http://intranet.local:8080/")))
+	})
+
+	t.Run("Allowlist Rejected Hosts", func(t *testing.T) {
+		p := egress.NewPolicy(egress.ModeAllowlist, []string{"example.com"})
+		err := p.Check(mustParse(t, "https://evil.com/"))
+		assert.ErrorIs(t, err, egress.ErrHostNotAllowed)
+		assert.Contains(t, err.Error(), "not in the crawl allowlist")
+		assert.ErrorIs(t, p.Check(mustParse(t, "https://notexample.com/")), egress.ErrHostNotAllowed)
+	})
+
+	t.Run("Empty Allowlist Rejects Everything", func(t *testing.T) {
+		p := egress.NewPolicy(egress.ModeAllowlist, nil)
+		assert.ErrorIs(t, p.Check(mustParse(t, "https://example.com/")), egress.ErrHostNotAllowed)
+	})
+
+	t.Run("Denylist", func(t *testing.T) {
+		p := egress.NewPolicy(egress.ModeDenylist, []string{"blocked.com"})
+		assert.NoError(t, p.Check(mustParse(t, "https://example.com/")))
+		err := p.Check(mustParse(t, "https://api.blocked.com/"))
+		assert.ErrorIs(t, err, egress.ErrHostNotAllowed)
+		assert.Contains(t, err.Error(), "blocked by the crawl denylist")
+	})
+
+	t.Run("HTTPS Only", func(t *testing.T) {
+		p := egress.NewPolicyWithHTTPSOnly(egress.ModeDenylist, []string{"blocked.com"}, true)
+		assert.NoError(t, p.Check(mustParse(t, "https://example.com/")))
+		err := p.Check(mustParse(t, "http://example.com/"))
+		assert.ErrorIs(t, err, egress.ErrHTTPSRequired)
+		assert.Contains(t, err.Error(), "HTTPS-only mode")
+		assert.ErrorIs(t, p.Check(mustParse(t, "https://blocked.com/")), egress.ErrHostNotAllowed)
+	})
+
+	t.Run("HTTPS Only Disabled", func(t *testing.T) {
+		p := egress.NewPolicyWithHTTPSOnly(egress.ModeDenylist, nil, false)
+		assert.NoError(t, p.Check(mustParse(t, "http://example.com/")))
+	})
+
+	t.Run("Nil Policy Allows Everything", func(t *testing.T) {
+		var p *egress.Policy
+		assert.NoError(t, p.Check(mustParse(t, "https://anything.com/")))
+	})
+}
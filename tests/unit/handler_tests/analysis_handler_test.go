@@ -0,0 +1,377 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/handler"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+	"github.com/fuzumoe/linkTorch-api/internal/storage"
+)
+
+type dummyAnalysisService struct {
+	lastUserID uint
+	counts     []model.HTMLVersionCountDTO
+	timings    *model.CrawlTimingsDTO
+	times      *model.ResponseTimesDTO
+	provenance *model.ProvenanceDTO
+	rawHTML    string
+	recent     []model.RecentCrawlDTO
+	lastLimit  int
+	err        error
+}
+
+func (s *dummyAnalysisService) Record(res *model.AnalysisResult, links []model.Link) error {
+	return nil
+}
+func (s *dummyAnalysisService) List(urlID uint, p repository.Pagination) ([]*model.AnalysisResultDTO, error) {
+	return nil, nil
+}
+func (s *dummyAnalysisService) HTMLVersionDistribution(userID uint) ([]model.HTMLVersionCountDTO, error) {
+	s.lastUserID = userID
+	return s.counts, s.err
+}
+
+func (s *dummyAnalysisService) Timings(urlID, userID uint) (*model.CrawlTimingsDTO, error) {
+	s.lastUserID = userID
+	return s.timings, s.err
+}
+
+func (s *dummyAnalysisService) ResponseTimes(urlID, userID uint) (*model.ResponseTimesDTO, error) {
+	s.lastUserID = userID
+	return s.times, s.err
+}
+
+func (s *dummyAnalysisService) Provenance(urlID, userID uint) (*model.ProvenanceDTO, error) {
+	s.lastUserID = userID
+	return s.provenance, s.err
+}
+
+func (s *dummyAnalysisService) RawHTML(ctx context.Context, urlID, userID uint) (io.ReadCloser, error) {
+	s.lastUserID = userID
+	if s.err != nil {
+		return nil, s.err
+	}
+	return io.NopCloser(strings.NewReader(s.rawHTML)), nil
+}
+
+func (s *dummyAnalysisService) Recent(userID uint, limit int) ([]model.RecentCrawlDTO, error) {
+	s.lastUserID = userID
+	s.lastLimit = limit
+	return s.recent, s.err
+}
+
+func TestAnalysisHandler_HTMLVersions(t *testing.T) {
+	svc := &dummyAnalysisService{}
+	h := handler.NewAnalysisHandler(svc)
+	router := setupRouter()
+	router.GET("/api/users/me/html-versions", func(c *gin.Context) {
+		c.Set("user_id", uint(4))
+		h.HTMLVersions(c)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		svc.counts = []model.HTMLVersionCountDTO{
+			{HTMLVersion: "HTML 5", Count: 3},
+			{HTMLVersion: "unknown", Count: 1},
+		}
+		svc.err = nil
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/users/me/html-versions", nil)
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, uint(4), svc.lastUserID)
+		var resp []model.HTMLVersionCountDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, svc.counts, resp)
+	})
+
+	t.Run("Service Error", func(t *testing.T) {
+		svc.counts = nil
+		svc.err = errors.New("database error")
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/users/me/html-versions", nil)
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestAnalysisHandler_Timings(t *testing.T) {
+	svc := &dummyAnalysisService{}
+	h := handler.NewAnalysisHandler(svc)
+	router := setupRouter()
+	router.GET("/api/urls/:id/timings", func(c *gin.Context) {
+		c.Set("user_id", uint(4))
+		h.Timings(c)
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		minMs, maxMs, latest, avg := int64(120), int64(480), int64(480), 300.0
+		svc.timings = &model.CrawlTimingsDTO{URLID: 9, Runs: 2, MinMs: &minMs, MaxMs: &maxMs, AvgMs: &avg, LatestMs: &latest}
+		svc.err = nil
+
+		w := get("/api/urls/9/timings")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, uint(4), svc.lastUserID)
+		var resp model.CrawlTimingsDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, *svc.timings, resp)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		svc.timings = nil
+		svc.err = gorm.ErrRecordNotFound
+
+		w := get("/api/urls/9/timings")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		w := get("/api/urls/abc/timings")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAnalysisHandler_ResponseTimes(t *testing.T) {
+	svc := &dummyAnalysisService{}
+	h := handler.NewAnalysisHandler(svc)
+	router := setupRouter()
+	router.GET("/api/urls/:id/response-times", func(c *gin.Context) {
+		c.Set("user_id", uint(4))
+		h.ResponseTimes(c)
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		p50, p90, p99 := int64(200), int64(900), int64(1500)
+		svc.times = &model.ResponseTimesDTO{URLID: 9, Runs: 20, P50Ms: &p50, P90Ms: &p90, P99Ms: &p99}
+		svc.err = nil
+
+		w := get("/api/urls/9/response-times")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, uint(4), svc.lastUserID)
+		var resp model.ResponseTimesDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, *svc.times, resp)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		svc.times = nil
+		svc.err = gorm.ErrRecordNotFound
+
+		w := get("/api/urls/9/response-times")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		w := get("/api/urls/abc/response-times")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAnalysisHandler_Provenance(t *testing.T) {
+	svc := &dummyAnalysisService{}
+	h := handler.NewAnalysisHandler(svc)
+	router := setupRouter()
+	router.GET("/api/urls/:id/results/provenance", func(c *gin.Context) {
+		c.Set("user_id", uint(4))
+		h.Provenance(c)
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		started := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		svc.provenance = &model.ProvenanceDTO{
+			URLID:    9,
+			ResultID: 31,
+			RunID:    "run-1",
+			SavedAt:  started.Add(2 * time.Second),
+			Provenance: model.Provenance{
+				WorkerID:   3,
+				Attempts:   2,
+				Analyzer:   "html",
+				RenderMode: model.RenderModeFull,
+				StartedAt:  started,
+				FinishedAt: started.Add(1500 * time.Millisecond),
+				HostWaitMs: 20,
+				AnalyzeMs:  900,
+				TotalMs:    1500,
+			},
+		}
+		svc.err = nil
+
+		w := get("/api/urls/9/results/provenance")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, uint(4), svc.lastUserID)
+		var resp model.ProvenanceDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, *svc.provenance, resp)
+
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fields))
+		for _, key := range []string{"worker_id", "attempts", "analyzer", "render_mode", "host_wait_ms", "analyze_ms", "total_ms"} {
+			assert.Contains(t, fields, key)
+		}
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		svc.provenance = nil
+		svc.err = gorm.ErrRecordNotFound
+
+		w := get("/api/urls/9/results/provenance")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		w := get("/api/urls/abc/results/provenance")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAnalysisHandler_RawHTML(t *testing.T) {
+	svc := &dummyAnalysisService{}
+	h := handler.NewAnalysisHandler(svc)
+	router := setupRouter()
+	router.GET("/api/urls/:id/raw-html", func(c *gin.Context) {
+		c.Set("user_id", uint(4))
+		h.RawHTML(c)
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		svc.rawHTML = "<html><title>Stored</title></html>"
+		svc.err = nil
+
+		w := get("/api/urls/9/raw-html")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, uint(4), svc.lastUserID)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+		assert.Equal(t, svc.rawHTML, w.Body.String())
+	})
+
+	t.Run("No Stored Copy", func(t *testing.T) {
+		svc.err = gorm.ErrRecordNotFound
+		assert.Equal(t, http.StatusNotFound, get("/api/urls/9/raw-html").Code)
+	})
+
+	t.Run("Missing Blob", func(t *testing.T) {
+		svc.err = storage.ErrBlobNotFound
+		assert.Equal(t, http.StatusNotFound, get("/api/urls/9/raw-html").Code)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("/api/urls/abc/raw-html").Code)
+	})
+}
+
+func TestAnalysisHandler_Recent(t *testing.T) {
+	svc := &dummyAnalysisService{}
+	h := handler.NewAnalysisHandler(svc)
+	router := setupRouter()
+	router.GET("/api/users/me/recent", func(c *gin.Context) {
+		c.Set("user_id", uint(4))
+		h.Recent(c)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		crawled := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		svc.recent = []model.RecentCrawlDTO{
+			{URLID: 8, OriginalURL: "https://b.example.com", Status: model.StatusDone, LastCrawledAt: crawled},
+		}
+		svc.err = nil
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/users/me/recent?limit=5", nil)
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, uint(4), svc.lastUserID)
+		assert.Equal(t, 5, svc.lastLimit)
+		var resp []model.RecentCrawlDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, svc.recent, resp)
+	})
+
+	t.Run("Default Limit", func(t *testing.T) {
+		svc.err = nil
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/users/me/recent", nil)
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 0, svc.lastLimit, "The service picks the default")
+	})
+
+	t.Run("Invalid Limit", func(t *testing.T) {
+		for _, limit := range []string{"abc", "0", "-3"} {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(http.MethodGet, "/api/users/me/recent?limit="+limit, nil)
+			require.NoError(t, err)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, "limit=%s", limit)
+		}
+	})
+
+	t.Run("Service Error", func(t *testing.T) {
+		svc.err = errors.New("database error")
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/users/me/recent", nil)
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
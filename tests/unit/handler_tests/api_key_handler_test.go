@@ -0,0 +1,136 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/handler"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/service"
+)
+
+type dummyAPIKeyService struct {
+	keys map[uint]*model.APIKeyDTO
+}
+
+func (s *dummyAPIKeyService) Create(userID uint, input *model.CreateAPIKeyInput) (*model.CreatedAPIKeyDTO, error) {
+	if input.Name == "fail" {
+		return nil, errors.New("db down")
+	}
+	if input.ExpiresAt != nil && input.ExpiresAt.After(time.Now().Add(24*time.Hour)) {
+		return nil, fmt.Errorf("%w: keys may live at most 24h0m0s", service.ErrAPIKeyExpiry)
+	}
+	dto := model.APIKeyDTO{ID: 9, Name: input.Name, Prefix: "ltk_0123abcd", ExpiresAt: input.ExpiresAt}
+	return &model.CreatedAPIKeyDTO{APIKeyDTO: dto, Key: "ltk_0123abcdsecret"}, nil
+}
+
+func (s *dummyAPIKeyService) List(userID uint) ([]*model.APIKeyDTO, error) {
+	list := []*model.APIKeyDTO{}
+	for _, k := range s.keys {
+		list = append(list, k)
+	}
+	return list, nil
+}
+
+func (s *dummyAPIKeyService) Revoke(userID, id uint) error {
+	if _, ok := s.keys[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *dummyAPIKeyService) Authenticate(key string) (*model.UserDTO, error) {
+	return nil, errors.New("not used")
+}
+
+func TestAPIKeyHandler(t *testing.T) {
+	svc := &dummyAPIKeyService{keys: map[uint]*model.APIKeyDTO{
+		4: {ID: 4, Name: "ci", Prefix: "ltk_4444abcd"},
+	}}
+	router := setupRouter()
+	handler.NewAPIKeyHandler(svc).RegisterProtectedRoutes(router.Group("/api", func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+		c.Next()
+	}))
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Create Shows Key Once", func(t *testing.T) {
+		w := do(http.MethodPost, "/api/api-keys", `{"name":"deploy"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "deploy", resp["name"])
+		assert.Equal(t, "ltk_0123abcdsecret", resp["key"])
+	})
+
+	t.Run("Create Without Name", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/api-keys", `{}`).Code)
+	})
+
+	t.Run("Create With Expiry", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		w := do(http.MethodPost, "/api/api-keys", `{"name":"deploy","expires_at":"`+expiresAt+`"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, expiresAt, resp["expires_at"])
+		assert.Equal(t, false, resp["expired"])
+	})
+
+	t.Run("Create Beyond Max Lifetime", func(t *testing.T) {
+		expiresAt := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+		w := do(http.MethodPost, "/api/api-keys", `{"name":"deploy","expires_at":"`+expiresAt+`"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"error":"invalid API key expiry: keys may live at most 24h0m0s"}`, w.Body.String())
+	})
+
+	t.Run("Create Fails", func(t *testing.T) {
+		assert.Equal(t, http.StatusInternalServerError, do(http.MethodPost, "/api/api-keys", `{"name":"fail"}`).Code)
+	})
+
+	t.Run("List Hides Keys", func(t *testing.T) {
+		w := do(http.MethodGet, "/api/api-keys", "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp []map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp, 1)
+		assert.Equal(t, "ci", resp[0]["name"])
+		assert.NotContains(t, resp[0], "key")
+		assert.NotContains(t, resp[0], "key_hash")
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		w := do(http.MethodDelete, "/api/api-keys/4", "")
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("Revoke Unknown", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/api/api-keys/5", "").Code)
+	})
+
+	t.Run("Revoke Invalid ID", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodDelete, "/api/api-keys/abc", "").Code)
+	})
+}
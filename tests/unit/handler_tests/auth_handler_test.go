@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -44,6 +45,16 @@ func (m *MockAuthService) Generate(userID uint) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockAuthService) GeneratePair(userID uint) (string, string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockAuthService) Refresh(refreshToken string) (string, string, error) {
+	args := m.Called(refreshToken)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
 func (m *MockAuthService) Invalidate(tokenID string) error {
 	args := m.Called(tokenID)
 	return args.Error(0)
@@ -100,6 +111,31 @@ func (m *MockUserService) Delete(userID uint) error {
 	return args.Error(0)
 }
 
+func (m *MockUserService) DeleteAccount(userID uint, password string) error {
+	args := m.Called(userID, password)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RequestPasswordReset(email string) (string, error) {
+	args := m.Called(email)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserService) ResetPassword(token, newPassword string) error {
+	args := m.Called(token, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserService) RequestEmailVerification(id uint) (string, error) {
+	args := m.Called(id)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserService) VerifyEmail(token string) error {
+	args := m.Called(token)
+	return args.Error(0)
+}
+
 func (m *MockUserService) Get(userID uint) (*model.UserDTO, error) {
 	args := m.Called(userID)
 	if user, ok := args.Get(0).(*model.UserDTO); ok {
@@ -130,7 +166,7 @@ func TestLoginBasic(t *testing.T) {
 	}
 
 	userService.On("Authenticate", testEmail, testPassword).Return(userDTO, nil)
-	authService.On("Generate", uint(1)).Return("JWT-TOKEN", nil)
+	authService.On("GeneratePair", uint(1)).Return("JWT-TOKEN", "REFRESH-TOKEN", nil)
 
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -147,6 +183,7 @@ func TestLoginBasic(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.NoError(t, err)
 	assert.Equal(t, "JWT-TOKEN", resp["token"])
+	assert.Equal(t, "REFRESH-TOKEN", resp["refresh_token"])
 
 	userService.AssertExpectations(t)
 	authService.AssertExpectations(t)
@@ -166,7 +203,7 @@ func TestLoginJWT(t *testing.T) {
 	}
 
 	userService.On("Authenticate", testEmail, testPassword).Return(userDTO, nil)
-	authService.On("Generate", uint(2)).Return("JWT-TOKEN-JWT", nil)
+	authService.On("GeneratePair", uint(2)).Return("JWT-TOKEN-JWT", "REFRESH-TOKEN-JWT", nil)
 
 	payload := map[string]string{
 		"email":    testEmail,
@@ -187,10 +224,230 @@ func TestLoginJWT(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	assert.NoError(t, err)
 	assert.Equal(t, "JWT-TOKEN-JWT", resp["token"])
+	assert.Equal(t, "REFRESH-TOKEN-JWT", resp["refresh_token"])
 	userService.AssertExpectations(t)
 	authService.AssertExpectations(t)
 }
 
+func TestRefresh(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		body         string
+		setupMock    func(*MockAuthService)
+		expectedCode int
+		expectedKey  string
+	}{
+		{
+			name: "Success",
+			body: `{"refresh_token":"REFRESH-TOKEN"}`,
+			setupMock: func(m *MockAuthService) {
+				m.On("Refresh", "REFRESH-TOKEN").Return("NEW-JWT-TOKEN", "NEW-REFRESH-TOKEN", nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedKey:  "token",
+		},
+		{
+			name:         "Missing Token",
+			body:         `{}`,
+			setupMock:    func(m *MockAuthService) {},
+			expectedCode: http.StatusBadRequest,
+			expectedKey:  "error",
+		},
+		{
+			name: "Revoked Or Wrong Kind",
+			body: `{"refresh_token":"ACCESS-TOKEN"}`,
+			setupMock: func(m *MockAuthService) {
+				m.On("Refresh", "ACCESS-TOKEN").Return("", "", service.ErrTokenInvalid)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedKey:  "error",
+		},
+		{
+			name: "Expired",
+			body: `{"refresh_token":"OLD-TOKEN"}`,
+			setupMock: func(m *MockAuthService) {
+				m.On("Refresh", "OLD-TOKEN").Return("", "", service.ErrTokenExpired)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedKey:  "error",
+		},
+		{
+			name: "Reused",
+			body: `{"refresh_token":"ROTATED-TOKEN"}`,
+			setupMock: func(m *MockAuthService) {
+				m.On("Refresh", "ROTATED-TOKEN").Return("", "", service.ErrTokenReused)
+			},
+			expectedCode: http.StatusUnauthorized,
+			expectedKey:  "error",
+		},
+		{
+			name: "Blacklist Check Failed",
+			body: `{"refresh_token":"REFRESH-TOKEN"}`,
+			setupMock: func(m *MockAuthService) {
+				m.On("Refresh", "REFRESH-TOKEN").Return("", "", service.ErrBlacklistCheckFail)
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedKey:  "error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			authService := new(MockAuthService)
+			tc.setupMock(authService)
+			h := handler.NewAuthHandler(authService, new(MockUserService))
+
+			req := httptest.NewRequest(http.MethodPost, "/refresh", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			h.Refresh(c)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			var resp map[string]interface{}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Contains(t, resp, tc.expectedKey)
+			if tc.expectedCode == http.StatusOK {
+				assert.Equal(t, "NEW-JWT-TOKEN", resp["token"])
+				assert.Equal(t, "NEW-REFRESH-TOKEN", resp["refresh_token"])
+			}
+			authService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	request := func(h *handler.AuthHandler, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/password/reset/request", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = req
+		h.RequestPasswordReset(c)
+		return w
+	}
+
+	t.Run("Known And Unknown Emails Look The Same", func(t *testing.T) {
+		userService := new(MockUserService)
+		userService.On("RequestPasswordReset", "known@example.com").Return("RESET-TOKEN", nil)
+		userService.On("RequestPasswordReset", "unknown@example.com").Return("", nil)
+		sent := map[string]string{}
+		h := handler.NewAuthHandlerWithPasswordReset(new(MockAuthService), userService, func(email, token string) error {
+			sent[email] = token
+			return nil
+		})
+
+		known := request(h, `{"email":"known@example.com"}`)
+		unknown := request(h, `{"email":"unknown@example.com"}`)
+
+		assert.Equal(t, http.StatusOK, known.Code)
+		assert.Equal(t, known.Code, unknown.Code)
+		assert.Equal(t, known.Body.String(), unknown.Body.String())
+		assert.NotContains(t, known.Body.String(), "RESET-TOKEN", "the token only goes to the email owner")
+		assert.Equal(t, map[string]string{"known@example.com": "RESET-TOKEN"}, sent)
+		userService.AssertExpectations(t)
+	})
+
+	t.Run("Send Failure Is Not Reported", func(t *testing.T) {
+		userService := new(MockUserService)
+		userService.On("RequestPasswordReset", "known@example.com").Return("RESET-TOKEN", nil)
+		h := handler.NewAuthHandlerWithPasswordReset(new(MockAuthService), userService, func(email, token string) error {
+			return errors.New("smtp down")
+		})
+
+		w := request(h, `{"email":"known@example.com"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Invalid Email", func(t *testing.T) {
+		h := handler.NewAuthHandler(new(MockAuthService), new(MockUserService))
+		w := request(h, `{"email":"not-an-email"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Service Error", func(t *testing.T) {
+		userService := new(MockUserService)
+		userService.On("RequestPasswordReset", "known@example.com").Return("", errors.New("db down"))
+		h := handler.NewAuthHandler(new(MockAuthService), userService)
+
+		w := request(h, `{"email":"known@example.com"}`)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestConfirmPasswordReset(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		body         string
+		setupMock    func(*MockUserService)
+		expectedCode int
+	}{
+		{
+			name: "Success",
+			body: `{"token":"RESET-TOKEN","password":"newpassword"}`,
+			setupMock: func(m *MockUserService) {
+				m.On("ResetPassword", "RESET-TOKEN", "newpassword").Return(nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Password Too Short",
+			body:         `{"token":"RESET-TOKEN","password":"abc"}`,
+			setupMock:    func(m *MockUserService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Missing Token",
+			body:         `{"password":"newpassword"}`,
+			setupMock:    func(m *MockUserService) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "Used Or Expired Token",
+			body: `{"token":"USED-TOKEN","password":"newpassword"}`,
+			setupMock: func(m *MockUserService) {
+				m.On("ResetPassword", "USED-TOKEN", "newpassword").Return(service.ErrResetTokenInvalid)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "Service Error",
+			body: `{"token":"RESET-TOKEN","password":"newpassword"}`,
+			setupMock: func(m *MockUserService) {
+				m.On("ResetPassword", "RESET-TOKEN", "newpassword").Return(errors.New("db down"))
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			userService := new(MockUserService)
+			tc.setupMock(userService)
+			h := handler.NewAuthHandler(new(MockAuthService), userService)
+
+			req := httptest.NewRequest(http.MethodPost, "/password/reset/confirm", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = req
+
+			h.ConfirmPasswordReset(c)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			userService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestLogout(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	authService := new(MockAuthService)
@@ -0,0 +1,132 @@
+package handler_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/crawler"
+	"github.com/fuzumoe/linkTorch-api/internal/handler"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+// readSSEEvent reads one server-sent event block, up to the blank line ending it.
+func readSSEEvent(t *testing.T, r *bufio.Reader) string {
+	var b strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		if line == "\n" {
+			return b.String()
+		}
+		b.WriteString(line)
+	}
+}
+
+func TestCrawlLogHandler_Stream(t *testing.T) {
+	hub := crawler.NewLogHub()
+
+	newRouter := func(role model.UserRole, opts handler.StreamOptions) *gin.Engine {
+		h := handler.NewCrawlLogHandler(hub, opts)
+		router := setupRouter()
+		router.GET("/api/admin/urls/:id/logs", func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			c.Set("user_role", role)
+			h.Stream(c)
+		})
+		return router
+	}
+
+	openStream := func(t *testing.T, ts *httptest.Server, urlID string) (*http.Response, *bufio.Reader) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		t.Cleanup(cancel)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/admin/urls/"+urlID+"/logs", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp, bufio.NewReader(resp.Body)
+	}
+
+	t.Run("Delivers Log Lines", func(t *testing.T) {
+		ts := httptest.NewServer(newRouter(model.RoleAdmin, handler.StreamOptions{}))
+		t.Cleanup(ts.Close)
+
+		resp, reader := openStream(t, ts, "7")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "text/event-stream")
+		assert.Contains(t, readSSEEvent(t, reader), "event:subscribed")
+
+		hub.Publish(crawler.LogLine{URLID: 8, Message: "other url"})
+		hub.Publish(crawler.LogLine{URLID: 7, Worker: 2, Message: "analyzing https://example.com"})
+
+		event := readSSEEvent(t, reader)
+		assert.Contains(t, event, "event:log")
+		assert.Contains(t, event, `"url_id":7`)
+		assert.Contains(t, event, "analyzing https://example.com")
+		assert.NotContains(t, event, "other url")
+	})
+
+	t.Run("Survives Server Write Timeout", func(t *testing.T) {
+		ts := httptest.NewUnstartedServer(newRouter(model.RoleAdmin, handler.StreamOptions{
+			Heartbeat: 50 * time.Millisecond,
+		}))
+		ts.Config.WriteTimeout = 100 * time.Millisecond
+		ts.Start()
+		t.Cleanup(ts.Close)
+
+		_, reader := openStream(t, ts, "11")
+		assert.Contains(t, readSSEEvent(t, reader), "event:subscribed")
+		assert.Contains(t, readSSEEvent(t, reader), ": keep-alive")
+
+		time.Sleep(300 * time.Millisecond)
+		hub.Publish(crawler.LogLine{URLID: 11, Message: "still connected"})
+
+		var event string
+		for !strings.Contains(event, "event:log") {
+			event = readSSEEvent(t, reader)
+		}
+		assert.Contains(t, event, "still connected")
+	})
+
+	t.Run("Closes After Max Lifetime", func(t *testing.T) {
+		ts := httptest.NewServer(newRouter(model.RoleAdmin, handler.StreamOptions{
+			MaxLifetime: 100 * time.Millisecond,
+		}))
+		t.Cleanup(ts.Close)
+
+		_, reader := openStream(t, ts, "12")
+		assert.Contains(t, readSSEEvent(t, reader), "event:subscribed")
+
+		rest, err := io.ReadAll(reader)
+		require.NoError(t, err, "Stream should end cleanly")
+		assert.Empty(t, rest)
+	})
+
+	t.Run("Non Admin Forbidden", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/admin/urls/7/logs", nil)
+		require.NoError(t, err)
+		newRouter(model.RoleUser, handler.StreamOptions{}).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(http.MethodGet, "/api/admin/urls/abc/logs", nil)
+		require.NoError(t, err)
+		newRouter(model.RoleAdmin, handler.StreamOptions{}).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
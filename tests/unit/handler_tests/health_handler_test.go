@@ -88,4 +88,45 @@ func TestHealthHandler(t *testing.T) {
 	t.Run("Health Endpoint Unhealthy", func(t *testing.T) {
 		testHealthEndpoint(t, "unhealthy", false, http.StatusServiceUnavailable)
 	})
+	t.Run("Live", func(t *testing.T) {
+		router := gin.New()
+		router.GET("/health/live", handler.NewHealthHandler(&dummyHealthService{}).Live)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"status":"alive"}`, rec.Body.String())
+	})
+
+	ready := func(t *testing.T, stat *service.HealthStatus) (int, map[string]interface{}) {
+		router := gin.New()
+		router.GET("/health/ready", handler.NewHealthHandler(&dummyHealthService{response: stat}).Ready)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+		var resp map[string]interface{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return rec.Code, resp
+	}
+
+	t.Run("Ready", func(t *testing.T) {
+		code, resp := ready(t, &service.HealthStatus{Database: "healthy", Crawler: "running", Healthy: true, Checked: time.Now()})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ready", resp["status"])
+		assert.Equal(t, map[string]interface{}{"database": "healthy", "crawler": "running"}, resp["checks"])
+		assert.NotContains(t, resp, "down")
+	})
+
+	t.Run("Not Ready", func(t *testing.T) {
+		code, resp := ready(t, &service.HealthStatus{Database: "unhealthy", Crawler: "stopped", Checked: time.Now()})
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "not ready", resp["status"])
+		assert.Equal(t, []interface{}{"database", "crawler"}, resp["down"])
+	})
+
+	t.Run("Crawler Down Only", func(t *testing.T) {
+		code, resp := ready(t, &service.HealthStatus{Database: "healthy", Crawler: "stopped", Checked: time.Now()})
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, []interface{}{"crawler"}, resp["down"])
+	})
 }
@@ -0,0 +1,369 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/handler"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+)
+
+type dummyLinkService struct {
+	lastUserID uint
+	lastFilter repository.LinkFilter
+	lastPage   repository.Pagination
+	lastDomain string
+	lastLimit  int
+}
+
+func (s *dummyLinkService) Add(link *model.Link) error { return nil }
+func (s *dummyLinkService) List(urlID uint, p repository.Pagination) ([]*model.LinkDTO, error) {
+	return nil, nil
+}
+func (s *dummyLinkService) ListByURL(urlID uint, p repository.Pagination) (*model.PaginatedResponse[model.LinkDTO], error) {
+	return nil, nil
+}
+func (s *dummyLinkService) Update(link *model.Link) error { return nil }
+func (s *dummyLinkService) Delete(link *model.Link) error { return nil }
+
+func (s *dummyLinkService) ListByUser(userID uint, f repository.LinkFilter, p repository.Pagination) (*model.PaginatedResponse[model.UserLinkDTO], error) {
+	s.lastUserID = userID
+	s.lastFilter = f
+	s.lastPage = p
+	return &model.PaginatedResponse[model.UserLinkDTO]{
+		Data: []model.UserLinkDTO{{
+			LinkDTO:     model.LinkDTO{ID: 1, URLID: 3, Href: "https://example.com/broken", StatusCode: 404},
+			OriginalURL: "https://example.com",
+			URLTitle:    "Example",
+		}},
+		Pagination: model.PaginationMetaDTO{Page: p.Page, PageSize: p.PageSize, TotalItems: 1, TotalPages: 1},
+	}, nil
+}
+
+func (s *dummyLinkService) StatusSummary(urlID, userID uint) (*model.LinkStatusSummaryDTO, error) {
+	if urlID == 404 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &model.LinkStatusSummaryDTO{URLID: urlID, Status2xx: 3, Status4xx: 1, Unreachable: 2, Total: 6}, nil
+}
+
+func (s *dummyLinkService) Changes(urlID, userID uint) (*model.LinkChangesDTO, error) {
+	if urlID == 404 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	current, previous := uint(12), uint(11)
+	return &model.LinkChangesDTO{
+		URLID:         urlID,
+		CurrentRunID:  &current,
+		PreviousRunID: &previous,
+		Added:         []string{"https://new.example.com"},
+		Removed:       []string{"https://old.example.com"},
+	}, nil
+}
+
+func (s *dummyLinkService) DomainImpact(domain string, p repository.Pagination) (*model.DomainImpactDTO, error) {
+	s.lastDomain = domain
+	s.lastPage = p
+	return &model.DomainImpactDTO{
+		Domain: domain, Links: 4, URLs: 2, Users: 2,
+		AffectedURLs: model.PaginatedResponse[model.DomainImpactURLDTO]{
+			Data: []model.DomainImpactURLDTO{
+				{URLID: 1, UserID: 1, OriginalURL: "https://a.example.org", Links: 3},
+				{URLID: 2, UserID: 5, OriginalURL: "https://b.example.org", Links: 1},
+			},
+			Pagination: model.PaginationMetaDTO{Page: p.Page, PageSize: p.PageSize, TotalItems: 2, TotalPages: 1},
+		},
+	}, nil
+}
+
+func (s *dummyLinkService) TopBrokenDomains(limit int) ([]model.BrokenDomainDTO, error) {
+	s.lastLimit = limit
+	return []model.BrokenDomainDTO{
+		{Domain: "cdn.down.com", BrokenLinks: 3, URLs: 2, Users: 2},
+		{Domain: "gone.net", BrokenLinks: 1, URLs: 1, Users: 1},
+	}, nil
+}
+
+func TestLinkHandler_ListUserLinks(t *testing.T) {
+	svc := &dummyLinkService{}
+	h := handler.NewLinkHandler(svc)
+	router := setupRouter()
+	router.GET("/api/users/me/links", func(c *gin.Context) {
+		c.Set("user_id", uint(9))
+		h.ListUserLinks(c)
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/users/me/links?is_external=false&broken=true&search=old&page=2&page_size=5", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, uint(9), svc.lastUserID)
+		require.NotNil(t, svc.lastFilter.IsExternal)
+		assert.False(t, *svc.lastFilter.IsExternal)
+		require.NotNil(t, svc.lastFilter.Broken)
+		assert.True(t, *svc.lastFilter.Broken)
+		assert.Equal(t, "old", svc.lastFilter.Search)
+		assert.Equal(t, repository.Pagination{Page: 2, PageSize: 5}, svc.lastPage)
+
+		var resp model.PaginatedResponse[model.UserLinkDTO]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Data, 1)
+		assert.Equal(t, uint(3), resp.Data[0].URLID)
+		assert.Equal(t, "Example", resp.Data[0].URLTitle)
+	})
+
+	t.Run("No Filters", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/users/me/links", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, svc.lastFilter.URLID)
+		assert.Nil(t, svc.lastFilter.IsExternal)
+		assert.Nil(t, svc.lastFilter.Broken)
+		assert.Empty(t, svc.lastFilter.Search)
+	})
+
+	t.Run("URL Filter", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/users/me/links?url_id=55", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, svc.lastFilter.URLID)
+		assert.Equal(t, uint(55), *svc.lastFilter.URLID)
+
+		req, err = http.NewRequest("GET", "/api/users/me/links?url_id=abc", nil)
+		require.NoError(t, err)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Invalid Bool", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/users/me/links?broken=maybe", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Href Pattern And Status Class", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/users/me/links?href_pattern=%25%2Fold%2F%25&status_class=4xx", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "%/old/%", svc.lastFilter.HrefPattern)
+		assert.Equal(t, "4xx", svc.lastFilter.StatusClass)
+	})
+
+	t.Run("Invalid Href Pattern Or Status Class", func(t *testing.T) {
+		svc.lastUserID = 0
+		for _, query := range []string{
+			"status_class=404",
+			"href_pattern=%25%5C%25",
+			"href_pattern=" + strings.Repeat("a", repository.MaxHrefPatternLength+1),
+		} {
+			req, err := http.NewRequest("GET", "/api/users/me/links?"+query, nil)
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		}
+		assert.Zero(t, svc.lastUserID, "the service is not called for an invalid filter")
+	})
+}
+
+func TestLinkHandler_StatusSummary(t *testing.T) {
+	h := handler.NewLinkHandler(&dummyLinkService{})
+	router := setupRouter()
+	router.GET("/api/urls/:id/link-status-summary", func(c *gin.Context) {
+		c.Set("user_id", uint(9))
+		h.StatusSummary(c)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/urls/5/link-status-summary", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]int
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 5, resp["url_id"])
+		assert.Equal(t, 3, resp["2xx"])
+		assert.Equal(t, 1, resp["4xx"])
+		assert.Equal(t, 2, resp["unreachable"])
+		assert.Equal(t, 6, resp["total"])
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/urls/404/link-status-summary", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/urls/abc/link-status-summary", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestLinkHandler_Changes(t *testing.T) {
+	h := handler.NewLinkHandler(&dummyLinkService{})
+	router := setupRouter()
+	router.GET("/api/urls/:id/links/changes", func(c *gin.Context) {
+		c.Set("user_id", uint(9))
+		h.Changes(c)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/urls/5/links/changes", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp model.LinkChangesDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, uint(5), resp.URLID)
+		require.NotNil(t, resp.CurrentRunID)
+		assert.Equal(t, uint(12), *resp.CurrentRunID)
+		assert.Equal(t, []string{"https://new.example.com"}, resp.Added)
+		assert.Equal(t, []string{"https://old.example.com"}, resp.Removed)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/urls/404/links/changes", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/urls/abc/links/changes", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestLinkHandler_DomainImpact(t *testing.T) {
+	call := func(role, path string) (*httptest.ResponseRecorder, *dummyLinkService) {
+		svc := &dummyLinkService{}
+		h := handler.NewLinkHandler(svc)
+		router := setupRouter()
+		router.GET("/api/admin/link-domains/:domain/impact", func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			c.Set("user_role", role)
+			h.DomainImpact(c)
+		})
+		req, err := http.NewRequest("GET", path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w, svc
+	}
+
+	t.Run("Admin", func(t *testing.T) {
+		w, svc := call("admin", "/api/admin/link-domains/cdn.example.com/impact?page=2&page_size=5")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "cdn.example.com", svc.lastDomain)
+		assert.Equal(t, repository.Pagination{Page: 2, PageSize: 5}, svc.lastPage)
+
+		var resp model.DomainImpactDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, 4, resp.Links)
+		assert.Equal(t, 2, resp.Users)
+		assert.Len(t, resp.AffectedURLs.Data, 2)
+	})
+
+	t.Run("Not Admin", func(t *testing.T) {
+		w, svc := call("user", "/api/admin/link-domains/cdn.example.com/impact")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, svc.lastDomain)
+	})
+
+	t.Run("Invalid Domain", func(t *testing.T) {
+		w, _ := call("admin", "/api/admin/link-domains/cdn.example.com:8080/impact")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestLinkHandler_TopBrokenDomains(t *testing.T) {
+	call := func(role, path string) (*httptest.ResponseRecorder, *dummyLinkService) {
+		svc := &dummyLinkService{lastLimit: -1}
+		h := handler.NewLinkHandler(svc)
+		router := setupRouter()
+		router.GET("/api/admin/top-broken-domains", func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			c.Set("user_role", role)
+			h.TopBrokenDomains(c)
+		})
+		req, err := http.NewRequest("GET", path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w, svc
+	}
+
+	t.Run("Admin", func(t *testing.T) {
+		w, svc := call("admin", "/api/admin/top-broken-domains?limit=5")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 5, svc.lastLimit)
+
+		var resp []model.BrokenDomainDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp, 2)
+		assert.Equal(t, "cdn.down.com", resp[0].Domain)
+		assert.Equal(t, 3, resp[0].BrokenLinks)
+	})
+
+	t.Run("Default Limit", func(t *testing.T) {
+		w, svc := call("admin", "/api/admin/top-broken-domains")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 0, svc.lastLimit)
+	})
+
+	t.Run("Not Admin", func(t *testing.T) {
+		w, svc := call("user", "/api/admin/top-broken-domains")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, -1, svc.lastLimit)
+	})
+
+	t.Run("Invalid Limit", func(t *testing.T) {
+		w, svc := call("admin", "/api/admin/top-broken-domains?limit=0")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, -1, svc.lastLimit)
+	})
+}
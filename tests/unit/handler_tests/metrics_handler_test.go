@@ -0,0 +1,80 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/handler"
+	"github.com/fuzumoe/linkTorch-api/internal/metrics"
+	"github.com/fuzumoe/linkTorch-api/internal/middleware"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+func TestMetricsHandler_Snapshot(t *testing.T) {
+	m := metrics.New(func() int { return 3 })
+	h := handler.NewMetricsHandler(m)
+
+	router := setupRouter()
+	router.Use(middleware.RequestMetrics(m))
+	router.GET("/api/urls", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/admin/metrics/snapshot", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Set("user_role", model.UserRole(c.GetHeader("X-Test-Role")))
+		h.Snapshot(c)
+	})
+	get := func(path string, role model.UserRole) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Test-Role", string(role))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Admin After Activity", func(t *testing.T) {
+		get("/api/urls", model.RoleUser)
+		get("/api/urls", model.RoleUser)
+		m.CrawlStarted()
+		m.CrawlFinished(model.StatusDone, 2*time.Second)
+
+		w := get("/api/admin/metrics/snapshot", model.RoleAdmin)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Contains(t, resp, "requests")
+		assert.Contains(t, resp, "crawls")
+		assert.Equal(t, float64(3), resp["queue_depth"])
+
+		requests := resp["requests"].(map[string]any)
+		assert.Equal(t, float64(2), requests["total"])
+		routes := requests["by_route"].([]any)
+		require.Len(t, routes, 1)
+		route := routes[0].(map[string]any)
+		for _, key := range []string{"method", "route", "status", "count", "avg_seconds"} {
+			assert.Contains(t, route, key)
+		}
+		assert.Equal(t, "/api/urls", route["route"])
+		assert.Equal(t, float64(2), route["count"])
+
+		crawls := resp["crawls"].(map[string]any)
+		assert.Equal(t, float64(1), crawls["started"])
+		assert.Equal(t, float64(1), crawls["completed"])
+		assert.Equal(t, float64(0), crawls["failed"])
+		done := crawls["by_status"].(map[string]any)[model.StatusDone].(map[string]any)
+		assert.Equal(t, float64(1), done["count"])
+		assert.Equal(t, float64(2), done["avg_seconds"])
+	})
+
+	t.Run("Non Admin", func(t *testing.T) {
+		w := get("/api/admin/metrics/snapshot", model.RoleUser)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, `{"error":"admin access required"}`, w.Body.String())
+	})
+}
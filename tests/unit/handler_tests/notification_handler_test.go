@@ -0,0 +1,103 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/handler"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+type dummyNotificationService struct {
+	lastUserID uint
+	lastInput  *model.UpdateNotificationPrefsInput
+}
+
+func (s *dummyNotificationService) Get(userID uint) (*model.NotificationPrefs, error) {
+	s.lastUserID = userID
+	return model.DefaultNotificationPrefs(userID), nil
+}
+
+func (s *dummyNotificationService) Update(userID uint, input *model.UpdateNotificationPrefsInput) (*model.NotificationPrefs, error) {
+	s.lastUserID = userID
+	s.lastInput = input
+	prefs := model.DefaultNotificationPrefs(userID)
+	if input.DigestFrequency != nil {
+		prefs.DigestFrequency = *input.DigestFrequency
+	}
+	if input.AlertChannels != nil {
+		prefs.AlertChannels = *input.AlertChannels
+	}
+	if input.WebhookEnabled != nil {
+		prefs.WebhookEnabled = *input.WebhookEnabled
+	}
+	return prefs, nil
+}
+
+func TestNotificationHandler(t *testing.T) {
+	svc := &dummyNotificationService{}
+	h := handler.NewNotificationHandler(svc)
+	router := setupRouter()
+	withUser := func(next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("user_id", uint(4))
+			next(c)
+		}
+	}
+	router.GET("/api/users/me/notifications", withUser(h.Get))
+	router.PUT("/api/users/me/notifications", withUser(h.Update))
+
+	t.Run("Get Defaults", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/users/me/notifications", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, uint(4), svc.lastUserID)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "off", resp["digest_frequency"])
+		assert.Equal(t, []any{"email"}, resp["alert_channels"])
+		assert.Equal(t, false, resp["webhook_enabled"])
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		body := []byte(`{"digest_frequency":"daily","alert_channels":["webhook"],"webhook_enabled":true}`)
+		req, err := http.NewRequest("PUT", "/api/users/me/notifications", bytes.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp model.NotificationPrefs
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "daily", resp.DigestFrequency)
+		assert.Equal(t, []string{"webhook"}, resp.AlertChannels)
+		assert.True(t, resp.WebhookEnabled)
+	})
+
+	t.Run("Invalid Input", func(t *testing.T) {
+		for _, body := range []string{
+			`{"digest_frequency":"hourly"}`,
+			`{"alert_channels":["sms"]}`,
+			`not json`,
+		} {
+			req, err := http.NewRequest("PUT", "/api/users/me/notifications", bytes.NewReader([]byte(body)))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		}
+	})
+}
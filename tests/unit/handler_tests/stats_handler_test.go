@@ -0,0 +1,91 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/handler"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+type dummyStatsService struct{}
+
+func (s *dummyStatsService) System() (*model.SystemStatsDTO, error) {
+	return &model.SystemStatsDTO{
+		TotalUsers:  2,
+		TotalURLs:   5,
+		Crawls24h:   7,
+		QueueDepth:  1,
+		CrawledURLs: 4,
+		ErroredURLs: 1,
+		ErrorRate:   0.25,
+	}, nil
+}
+
+func (s *dummyStatsService) ETA() (*model.CrawlETADTO, error) {
+	seconds := int64(15)
+	return &model.CrawlETADTO{QueueDepth: 25, Workers: 5, AvgCrawlMs: 3000, Samples: 40, ETASeconds: &seconds}, nil
+}
+
+func TestStatsHandler_System(t *testing.T) {
+	h := handler.NewStatsHandler(&dummyStatsService{})
+
+	newRouter := func(role model.UserRole) *gin.Engine {
+		router := setupRouter()
+		router.GET("/api/admin/stats", func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			c.Set("user_role", role)
+			h.System(c)
+		})
+		return router
+	}
+
+	t.Run("Admin", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/admin/stats", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		newRouter(model.RoleAdmin).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, float64(2), resp["total_users"])
+		assert.Equal(t, float64(5), resp["total_urls"])
+		assert.Equal(t, float64(7), resp["crawls_last_24h"])
+		assert.Equal(t, float64(1), resp["queue_depth"])
+		assert.Equal(t, 0.25, resp["error_rate"])
+	})
+
+	t.Run("Non Admin Forbidden", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/admin/stats", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		newRouter(model.RoleUser).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+}
+
+func TestStatsHandler_ETA(t *testing.T) {
+	h := handler.NewStatsHandler(&dummyStatsService{})
+	router := setupRouter()
+	router.GET("/api/crawler/eta", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Set("user_role", model.RoleUser)
+		h.ETA(c)
+	})
+
+	req, err := http.NewRequest("GET", "/api/crawler/eta", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"queue_depth":25,"workers":5,"avg_crawl_ms":3000,"samples":40,"eta_seconds":15}`, w.Body.String())
+}
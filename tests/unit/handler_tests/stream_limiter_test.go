@@ -0,0 +1,158 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+
+	"github.com/fuzumoe/linkTorch-api/internal/crawler"
+	"github.com/fuzumoe/linkTorch-api/internal/handler"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+func TestStreamLimiter(t *testing.T) {
+	t.Run("Total Cap", func(t *testing.T) {
+		l := handler.NewStreamLimiter(2, 0)
+		r1, ok := l.Acquire(1)
+		require.True(t, ok)
+		_, ok = l.Acquire(2)
+		require.True(t, ok)
+		_, ok = l.Acquire(3)
+		assert.False(t, ok)
+		assert.Equal(t, 2, l.Open())
+
+		r1()
+		r1() // releasing twice frees one slot only
+		assert.Equal(t, 1, l.Open())
+		_, ok = l.Acquire(3)
+		assert.True(t, ok)
+	})
+
+	t.Run("Per User Cap", func(t *testing.T) {
+		l := handler.NewStreamLimiter(0, 1)
+		r1, ok := l.Acquire(1)
+		require.True(t, ok)
+		_, ok = l.Acquire(1)
+		assert.False(t, ok)
+		assert.Equal(t, 1, l.Open(), "a rejected user does not hold a total slot")
+		_, ok = l.Acquire(2)
+		assert.True(t, ok)
+
+		r1()
+		_, ok = l.Acquire(1)
+		assert.True(t, ok)
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		l := handler.NewStreamLimiter(0, 0)
+		for i := 0; i < 100; i++ {
+			_, ok := l.Acquire(1)
+			require.True(t, ok)
+		}
+	})
+
+	t.Run("Concurrent Acquire Never Exceeds Cap", func(t *testing.T) {
+		l := handler.NewStreamLimiter(10, 0)
+		var mu sync.Mutex
+		granted := 0
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func(id uint) {
+				defer wg.Done()
+				if _, ok := l.Acquire(id); ok {
+					mu.Lock()
+					granted++
+					mu.Unlock()
+				}
+			}(uint(i))
+		}
+		wg.Wait()
+		assert.Equal(t, 10, granted)
+		assert.Equal(t, 10, l.Open())
+	})
+}
+
+func TestStreamLimits_Endpoints(t *testing.T) {
+	limiter := handler.NewStreamLimiter(3, 2)
+	svc := &resultStream{results: make(chan crawler.CrawlResult)}
+	h := handler.NewURLHandlerWithOptions(svc, handler.URLHandlerOptions{
+		Stream: handler.StreamOptions{Heartbeat: 50 * time.Millisecond, Limiter: limiter},
+	})
+	router := setupRouter()
+	asUser := func(c *gin.Context) {
+		id, _ := strconv.ParseUint(c.Query("as"), 10, 64)
+		c.Set("user_id", uint(id))
+		c.Set("user_role", model.RoleUser)
+	}
+	router.GET("/api/crawler/results", func(c *gin.Context) {
+		asUser(c)
+		h.GetCrawlResults(c)
+	})
+	router.GET("/api/crawler/ws", func(c *gin.Context) {
+		asUser(c)
+		h.CrawlResultsWS(c)
+	})
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	// open starts an SSE stream for user and returns its status and a
+	// function closing it.
+	open := func(t *testing.T, user int) (int, context.CancelFunc) {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/crawler/results?as="+strconv.Itoa(user), nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+		}
+		t.Cleanup(cancel)
+		return resp.StatusCode, cancel
+	}
+	waitOpen := func(t *testing.T, n int) {
+		require.Eventually(t, func() bool { return limiter.Open() == n }, 2*time.Second, 10*time.Millisecond)
+	}
+
+	code, closeFirst := open(t, 1)
+	require.Equal(t, http.StatusOK, code)
+	code, _ = open(t, 1)
+	require.Equal(t, http.StatusOK, code)
+
+	code, _ = open(t, 1)
+	assert.Equal(t, http.StatusTooManyRequests, code, "third stream of one user")
+
+	code, _ = open(t, 2)
+	require.Equal(t, http.StatusOK, code)
+	waitOpen(t, 3)
+
+	code, _ = open(t, 3)
+	assert.Equal(t, http.StatusTooManyRequests, code, "stream over the total cap")
+
+	// WebSockets count against the same limits.
+	_, err := websocket.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/api/crawler/ws?as=3", "", ts.URL)
+	assert.Error(t, err)
+	resp, err := http.Get(ts.URL + "/api/crawler/ws?as=3")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// Disconnecting frees the slot.
+	closeFirst()
+	waitOpen(t, 2)
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/api/crawler/ws?as=3", "", ts.URL)
+	require.NoError(t, err)
+	waitOpen(t, 3)
+	require.NoError(t, ws.Close())
+	waitOpen(t, 2)
+}
@@ -1,20 +1,36 @@
 package handler_test
 
 import (
+	"bufio"
 	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
 
 	"github.com/fuzumoe/linkTorch-api/internal/crawler"
 	"github.com/fuzumoe/linkTorch-api/internal/handler"
+	"github.com/fuzumoe/linkTorch-api/internal/middleware"
 	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/report"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
+	"github.com/fuzumoe/linkTorch-api/internal/service"
 )
 
 type dummyURLService struct{}
@@ -23,6 +39,10 @@ func (s *dummyURLService) Create(in *model.CreateURLInputDTO) (uint, error) {
 	return 1, nil
 }
 
+func (s *dummyURLService) CreateWithCrawl(in *model.CreateURLInputDTO) (*model.URLCreatedDTO, error) {
+	return &model.URLCreatedDTO{ID: 1, Enqueued: in.Crawl != nil && *in.Crawl}, nil
+}
+
 func (s *dummyURLService) Get(id uint) (*model.URLDTO, error) {
 	return &model.URLDTO{
 		ID:          id,
@@ -32,7 +52,7 @@ func (s *dummyURLService) Get(id uint) (*model.URLDTO, error) {
 	}, nil
 }
 
-func (s *dummyURLService) List(userID uint, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error) {
+func (s *dummyURLService) List(userID uint, f repository.URLFilter, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error) {
 	return &model.PaginatedResponse[model.URLDTO]{
 		Data: []model.URLDTO{{
 			ID:          1,
@@ -49,6 +69,81 @@ func (s *dummyURLService) List(userID uint, p repository.Pagination) (*model.Pag
 	}, nil
 }
 
+func (s *dummyURLService) ListUncrawled(userID uint, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error) {
+	return &model.PaginatedResponse[model.URLDTO]{
+		Data: []model.URLDTO{{
+			ID:          7,
+			OriginalURL: "http://broken.example.com",
+			Status:      model.StatusError,
+			UserID:      userID,
+		}},
+		Pagination: model.PaginationMetaDTO{
+			Page:       p.Page,
+			PageSize:   p.PageSize,
+			TotalItems: 1,
+			TotalPages: 1,
+		},
+	}, nil
+}
+
+func (s *dummyURLService) DeleteErrored(userID uint) (int, error) {
+	return 2, nil
+}
+
+func (s *dummyURLService) ListDeleted(userID uint, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error) {
+	return &model.PaginatedResponse[model.URLDTO]{
+		Data:       []model.URLDTO{{ID: 8, OriginalURL: "http://deleted.example.com"}},
+		Pagination: model.PaginationMetaDTO{Page: p.Page, PageSize: p.Limit(), TotalItems: 1, TotalPages: 1},
+	}, nil
+}
+
+// ListCursor serves two pages: "" leads to "page2", which is the last one.
+func (s *dummyURLService) ListCursor(userID uint, f repository.URLFilter, c repository.Cursor) (*model.CursorResponse[model.URLDTO], error) {
+	switch c.After {
+	case "":
+		return &model.CursorResponse[model.URLDTO]{Data: []model.URLDTO{{ID: 3}, {ID: 2}}, NextCursor: "page2"}, nil
+	case "page2":
+		return &model.CursorResponse[model.URLDTO]{Data: []model.URLDTO{{ID: 1}}}, nil
+	}
+	return nil, repository.ErrInvalidCursor
+}
+
+// Purge only knows URL 8.
+func (s *dummyURLService) Purge(id uint) error {
+	if id != 8 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (s *dummyURLService) PurgeDeleted(olderThan time.Duration) (int, error) {
+	return 0, nil
+}
+
+func (s *dummyURLService) ExportURLs(userID uint) (*model.URLExportDocument, error) {
+	return &model.URLExportDocument{
+		SchemaVersion: model.URLExportSchemaVersion,
+		URLs:          []model.URLCreateRequestDTO{{OriginalURL: "https://example.com", CrawlMethod: model.CrawlMethodFull}},
+	}, nil
+}
+
+// ImportURLs refuses other schema versions like the real service and
+// otherwise imports every entry.
+func (s *dummyURLService) ImportURLs(userID uint, doc *model.URLExportDocument) (*model.URLImportResultDTO, error) {
+	if doc.SchemaVersion != model.URLExportSchemaVersion {
+		return nil, service.ErrUnsupportedSchemaVersion
+	}
+	return &model.URLImportResultDTO{Imported: len(doc.URLs)}, nil
+}
+
+// Restore only knows deleted URL 8 of user 1.
+func (s *dummyURLService) Restore(id, userID uint) error {
+	if id != 8 || userID != 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (s *dummyURLService) Update(id uint, in *model.UpdateURLInput) error {
 	return nil
 }
@@ -57,11 +152,15 @@ func (s *dummyURLService) Delete(id uint) error {
 	return nil
 }
 
-func (s *dummyURLService) Start(id uint) error {
+func (s *dummyURLService) Start(id uint, requestID string) error {
 	return nil
 }
 
-func (s *dummyURLService) StartWithPriority(id uint, priority int) error {
+func (s *dummyURLService) StartWithPriority(id uint, priority int, requestID string) error {
+	return nil
+}
+
+func (s *dummyURLService) Reprioritize(id, userID uint, priority int) error {
 	return nil
 }
 
@@ -77,6 +176,24 @@ func (s *dummyURLService) AdjustCrawlerWorkers(action string, count int) error {
 	return nil
 }
 
+func (s *dummyURLService) PauseCrawler()  {}
+func (s *dummyURLService) ResumeCrawler() {}
+
+func (s *dummyURLService) CrawlerStatus() *model.CrawlerStatusDTO {
+	return &model.CrawlerStatusDTO{QueueDepth: 3, Workers: 2}
+}
+
+func (s *dummyURLService) CrawlerStats() crawler.PoolStats {
+	return crawler.PoolStats{
+		Workers: 4, ActiveWorkers: 1, QueueLength: 3, Processed: 10, Errored: 2, AvgDurationMs: 250,
+		ByStatus: map[string]int64{model.StatusDone: 7, model.StatusError: 2, model.StatusSkipped: 1},
+	}
+}
+
+func (s *dummyURLService) CrawlerQueue(limit int) crawler.QueueSnapshot {
+	return crawler.QueueSnapshot{}
+}
+
 func (s *dummyURLService) Results(id uint) (*model.URLDTO, error) {
 	return &model.URLDTO{
 		ID:          id,
@@ -87,12 +204,93 @@ func (s *dummyURLService) Results(id uint) (*model.URLDTO, error) {
 }
 
 func (s *dummyURLService) ResultsWithDetails(id uint) (*model.URL, []*model.AnalysisResult, []*model.Link, error) {
+	links := []*model.Link{}
+	if id == 55 {
+		for i := 0; i < 5; i++ {
+			links = append(links, &model.Link{URLID: id, Href: fmt.Sprintf("http://example.com/%d", i)})
+		}
+	}
 	return &model.URL{
 		ID:          id,
 		UserID:      1,
 		OriginalURL: "http://example.com/results",
 		Status:      model.StatusDone,
-	}, []*model.AnalysisResult{}, []*model.Link{}, nil
+	}, []*model.AnalysisResult{}, links, nil
+}
+
+func (s *dummyURLService) Validate(userID uint, urls []string) ([]model.URLValidationDTO, error) {
+	out := make([]model.URLValidationDTO, len(urls))
+	for i, u := range urls {
+		out[i] = model.URLValidationDTO{Input: u, Normalized: u, Valid: true}
+	}
+	return out, nil
+}
+
+func (s *dummyURLService) Report(id, userID uint) (*report.URLReport, error) {
+	if id == 404 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &report.URLReport{
+		URL:    "http://example.com",
+		Status: model.StatusDone,
+		Analysis: &model.AnalysisResult{
+			URLID:           id,
+			HTMLVersion:     "HTML 5",
+			Title:           "Example Domain",
+			BrokenLinkCount: 1,
+		},
+		BrokenLinks: []model.Link{{URLID: id, Href: "http://example.com/missing", StatusCode: 404}},
+		BrokenTotal: 1,
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+func (s *dummyURLService) Merge(id, intoID, userID uint) error {
+	switch intoID {
+	case 404:
+		return gorm.ErrRecordNotFound
+	case 403:
+		return service.ErrURLNotOwned
+	}
+	return nil
+}
+
+func (s *dummyURLService) CreateBulk(inputs []*model.CreateURLInputDTO) ([]uint, error) {
+	ids := make([]uint, len(inputs))
+	for i := range inputs {
+		ids[i] = uint(i + 1)
+	}
+	return ids, nil
+}
+
+func (s *dummyURLService) DeleteBulk(userID uint, ids []uint) (*model.BulkURLActionResultDTO, error) {
+	return &model.BulkURLActionResultDTO{Succeeded: len(ids), Skipped: []uint{}}, nil
+}
+
+func (s *dummyURLService) StartBulk(userID uint, ids []uint) (*model.BulkURLActionResultDTO, error) {
+	return &model.BulkURLActionResultDTO{Succeeded: len(ids), Skipped: []uint{}}, nil
+}
+
+func (s *dummyURLService) TagReport(userID uint, tag string, p repository.Pagination) (*model.PaginatedResponse[model.TagReportItemDTO], error) {
+	return &model.PaginatedResponse[model.TagReportItemDTO]{Data: []model.TagReportItemDTO{}}, nil
+}
+
+func (s *dummyURLService) TagSummary(userID uint, tag string) (*model.TagSummaryDTO, error) {
+	return &model.TagSummaryDTO{Tag: tag, ByStatus: map[string]int{}}, nil
+}
+
+func (s *dummyURLService) RecrawlTag(userID uint, tag string, priority int) (*model.BulkURLActionResultDTO, error) {
+	return &model.BulkURLActionResultDTO{Skipped: []uint{}}, nil
+}
+
+func (s *dummyURLService) Clone(id, userID uint, originalURL string) (uint, error) {
+	switch id {
+	case 404:
+		return 0, gorm.ErrRecordNotFound
+	case 403:
+		return 0, service.ErrURLNotOwned
+	}
+	return 8, nil
 }
 
 func setupRouter() *gin.Engine {
@@ -113,12 +311,28 @@ func TestURLHandler(t *testing.T) {
 		c.Set("user_id", uint(1))
 		h.List(c)
 	})
+	router.GET("/api/users/me/urls/uncrawled", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		h.ListUncrawled(c)
+	})
 	router.GET("/api/urls/:id", h.Get)
 	router.PUT("/api/urls/:id", h.Update)
 	router.DELETE("/api/urls/:id", h.Delete)
 	router.PATCH("/api/urls/:id/start", h.Start)
 	router.PATCH("/api/urls/:id/stop", h.Stop)
 	router.GET("/api/urls/:id/results", h.Results)
+	router.POST("/api/urls/:id/merge", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		h.Merge(c)
+	})
+	router.GET("/api/urls/:id/report.pdf", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		h.ReportPDF(c)
+	})
+	router.POST("/api/urls/validate", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		h.Validate(c)
+	})
 
 	t.Run("Create", func(t *testing.T) {
 		input := model.URLCreateRequestDTO{
@@ -141,6 +355,31 @@ func TestURLHandler(t *testing.T) {
 		id, ok := resp["id"].(float64)
 		require.True(t, ok, "response id not a number")
 		assert.Equal(t, float64(1), id)
+		assert.Equal(t, false, resp["enqueued"])
+	})
+
+	t.Run("Create With Crawl", func(t *testing.T) {
+		send := func(query string) *httptest.ResponseRecorder {
+			req, err := http.NewRequest("POST", "/api/urls"+query, bytes.NewBufferString(`{"original_url":"http://example.com"}`))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := send("?crawl=true")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		var created model.URLCreatedDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, model.URLCreatedDTO{ID: 1, Enqueued: true}, created)
+
+		w = send("?crawl=false")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.False(t, created.Enqueued)
+
+		assert.Equal(t, http.StatusBadRequest, send("?crawl=maybe").Code)
 	})
 
 	t.Run("List", func(t *testing.T) {
@@ -166,6 +405,25 @@ func TestURLHandler(t *testing.T) {
 		assert.Equal(t, "http://example.com", response.Data[0].OriginalURL)
 	})
 
+	t.Run("List Uncrawled", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/users/me/urls/uncrawled?page=2&page_size=5", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data       []model.URLDTO          `json:"data"`
+			Pagination model.PaginationMetaDTO `json:"pagination"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Pagination.Page)
+		assert.Equal(t, 5, response.Pagination.PageSize)
+		require.Len(t, response.Data, 1)
+		assert.Equal(t, model.StatusError, response.Data[0].Status)
+		assert.Equal(t, uint(1), response.Data[0].UserID)
+	})
+
 	t.Run("Get", func(t *testing.T) {
 		req, err := http.NewRequest("GET", "/api/urls/1", nil)
 		require.NoError(t, err)
@@ -250,4 +508,1443 @@ func TestURLHandler(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, model.StatusDone, dto.URL.Status)
 	})
+
+	t.Run("Merge", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			path   string
+			status int
+		}{
+			{"Merged", "/api/urls/2/merge?into=1", http.StatusOK},
+			{"Missing Into", "/api/urls/2/merge", http.StatusBadRequest},
+			{"Target Not Found", "/api/urls/2/merge?into=404", http.StatusNotFound},
+			{"Not Owned", "/api/urls/2/merge?into=403", http.StatusForbidden},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				req, err := http.NewRequest("POST", tc.path, nil)
+				require.NoError(t, err)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				assert.Equal(t, tc.status, w.Code)
+			})
+		}
+	})
+
+	t.Run("Report PDF", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/urls/7/report.pdf", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="url-7-report.pdf"`, w.Header().Get("Content-Disposition"))
+		body := w.Body.Bytes()
+		assert.True(t, bytes.HasPrefix(body, []byte("%PDF-")))
+
+		// The page content is compressed; the title must be in it.
+		start := bytes.Index(body, []byte("stream\n")) + len("stream\n")
+		end := bytes.Index(body, []byte("\nendstream"))
+		require.Greater(t, end, start)
+		zr, err := zlib.NewReader(bytes.NewReader(body[start:end]))
+		require.NoError(t, err)
+		page, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		assert.Contains(t, string(page), "Example Domain")
+	})
+
+	t.Run("Report PDF Not Found", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/api/urls/404/report.pdf", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		body := `{"urls":["https://example.com","https://example.org"]}`
+		req, err := http.NewRequest("POST", "/api/urls/validate", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var verdicts []model.URLValidationDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &verdicts))
+		require.Len(t, verdicts, 2)
+		assert.Equal(t, "https://example.org", verdicts[1].Input)
+		assert.True(t, verdicts[1].Valid)
+	})
+
+	t.Run("Validate Empty List", func(t *testing.T) {
+		req, err := http.NewRequest("POST", "/api/urls/validate", bytes.NewBufferString(`{"urls":[]}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestURLHandler_ResultsLimit(t *testing.T) {
+	get := func(h *handler.URLHandler, path string) model.URLResultsDTO {
+		router := setupRouter()
+		router.GET("/api/urls/:id/results", h.Results)
+		req, err := http.NewRequest("GET", path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var dto model.URLResultsDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dto))
+		return dto
+	}
+
+	t.Run("Over Limit", func(t *testing.T) {
+		dto := get(handler.NewURLHandlerWithOptions(&dummyURLService{}, handler.URLHandlerOptions{MaxResultLinks: 3}), "/api/urls/55/results")
+		assert.True(t, dto.Truncated)
+		assert.Len(t, dto.Links, 3)
+		assert.Equal(t, "http://example.com/0", dto.Links[0].Href)
+		assert.Equal(t, 5, dto.LinksTotal)
+		assert.Equal(t, "/api/v1/users/me/links?url_id=55", dto.LinksURL)
+	})
+
+	t.Run("Within Limit", func(t *testing.T) {
+		dto := get(handler.NewURLHandlerWithOptions(&dummyURLService{}, handler.URLHandlerOptions{MaxResultLinks: 5}), "/api/urls/55/results")
+		assert.False(t, dto.Truncated)
+		assert.Len(t, dto.Links, 5)
+		assert.Empty(t, dto.LinksURL)
+	})
+
+	t.Run("No Limit", func(t *testing.T) {
+		dto := get(handler.NewURLHandler(&dummyURLService{}), "/api/urls/55/results")
+		assert.False(t, dto.Truncated)
+		assert.Len(t, dto.Links, 5)
+		assert.Equal(t, 5, dto.LinksTotal)
+	})
+}
+
+// cleanupRecorder remembers whose errored URLs the handler asked to delete.
+type cleanupRecorder struct {
+	dummyURLService
+	userID uint
+}
+
+func (s *cleanupRecorder) DeleteErrored(userID uint) (int, error) {
+	s.userID = userID
+	return 2, nil
+}
+
+func TestURLHandler_CleanupErrored(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	call := func(role, query string) (*httptest.ResponseRecorder, *cleanupRecorder) {
+		svc := &cleanupRecorder{}
+		h := handler.NewURLHandler(svc)
+		router := gin.New()
+		router.POST("/api/urls/cleanup-errored", func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			c.Set("user_role", role)
+			h.CleanupErrored(c)
+		})
+		req, err := http.NewRequest("POST", "/api/urls/cleanup-errored"+query, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w, svc
+	}
+
+	t.Run("Own URLs", func(t *testing.T) {
+		w, svc := call("user", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"deleted":2}`, w.Body.String())
+		assert.Equal(t, uint(1), svc.userID)
+	})
+
+	t.Run("Admin Scopes To User", func(t *testing.T) {
+		w, svc := call("admin", "?user_id=9")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, uint(9), svc.userID)
+	})
+
+	t.Run("Non Admin Cannot Scope", func(t *testing.T) {
+		w, svc := call("user", "?user_id=9")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Zero(t, svc.userID)
+	})
+
+	t.Run("Invalid User ID", func(t *testing.T) {
+		w, _ := call("admin", "?user_id=abc")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestURLHandler_Clone(t *testing.T) {
+	router := setupRouter()
+	h := handler.NewURLHandler(&dummyURLService{})
+	router.POST("/api/urls/:id/clone", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		h.Clone(c)
+	})
+	post := func(id, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/api/urls/"+id+"/clone", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	payload := `{"original_url":"https://example.com/copy"}`
+
+	t.Run("Created", func(t *testing.T) {
+		w := post("5", payload)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, `{"id":8}`, w.Body.String())
+	})
+
+	t.Run("Invalid Payload", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, post("5", `{"original_url":"not a url"}`).Code)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, post("404", payload).Code)
+	})
+
+	t.Run("Not Owned", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, post("403", payload).Code)
+	})
+}
+
+// bulkService rejects inputs on blocked.example the way an egress policy would.
+type bulkService struct {
+	dummyURLService
+	inputs []*model.CreateURLInputDTO
+}
+
+func (s *bulkService) CreateBulk(inputs []*model.CreateURLInputDTO) ([]uint, error) {
+	s.inputs = inputs
+	ids := make([]uint, len(inputs))
+	failed := map[int]error{}
+	for i, in := range inputs {
+		if strings.Contains(in.OriginalURL, "blocked.example") {
+			failed[i] = errors.New("host is blocked")
+			continue
+		}
+		ids[i] = uint(10 + i)
+	}
+	if len(failed) > 0 {
+		return ids, &service.BulkCreateError{Failed: failed}
+	}
+	return ids, nil
+}
+
+func TestURLHandler_CreateBulk(t *testing.T) {
+	svc := &bulkService{}
+	router := setupRouter()
+	h := handler.NewURLHandler(svc)
+	router.POST("/api/urls/bulk", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		h.CreateBulk(c)
+	})
+	post := func(body string) (*httptest.ResponseRecorder, []model.URLBulkCreateResultDTO) {
+		req, err := http.NewRequest("POST", "/api/urls/bulk", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var results []model.URLBulkCreateResultDTO
+		_ = json.Unmarshal(w.Body.Bytes(), &results)
+		return w, results
+	}
+
+	t.Run("Partial Success", func(t *testing.T) {
+		w, results := post(`[
+			{"original_url":"https://a.example/"},
+			{"original_url":"not a url"},
+			{"original_url":"https://blocked.example/"},
+			{"original_url":"https://a.example/"}
+		]`)
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Len(t, results, 4)
+
+		assert.Equal(t, uint(10), results[0].ID)
+		assert.Empty(t, results[0].Error)
+		assert.Equal(t, "invalid url", results[1].Error)
+		assert.Zero(t, results[1].ID)
+		assert.Equal(t, "host is blocked", results[2].Error)
+		assert.Zero(t, results[2].ID)
+		assert.True(t, results[3].Duplicate)
+		assert.Equal(t, 3, results[3].Index)
+
+		require.Len(t, svc.inputs, 3, "invalid entries never reach the service")
+		for _, in := range svc.inputs {
+			assert.Equal(t, uint(1), in.UserID)
+		}
+	})
+
+	t.Run("Nothing Created", func(t *testing.T) {
+		w, results := post(`[{"original_url":"https://blocked.example/"},{"original_url":""}]`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		require.Len(t, results, 2)
+		assert.NotEmpty(t, results[0].Error)
+		assert.NotEmpty(t, results[1].Error)
+	})
+
+	t.Run("Invalid Payload", func(t *testing.T) {
+		w, _ := post(`{"original_url":"https://a.example/"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w, _ = post(`[]`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+// bulkActionRecorder records the bulk actions it is asked to run.
+type bulkActionRecorder struct {
+	dummyURLService
+	action string
+	userID uint
+	ids    []uint
+}
+
+func (s *bulkActionRecorder) record(action string, userID uint, ids []uint) (*model.BulkURLActionResultDTO, error) {
+	s.action, s.userID, s.ids = action, userID, ids
+	if len(ids) > 0 && ids[0] == 500 {
+		return nil, errors.New("db down")
+	}
+	return &model.BulkURLActionResultDTO{Succeeded: len(ids) - 1, Skipped: ids[len(ids)-1:]}, nil
+}
+
+func (s *bulkActionRecorder) DeleteBulk(userID uint, ids []uint) (*model.BulkURLActionResultDTO, error) {
+	return s.record("delete", userID, ids)
+}
+
+func (s *bulkActionRecorder) StartBulk(userID uint, ids []uint) (*model.BulkURLActionResultDTO, error) {
+	return s.record("start", userID, ids)
+}
+
+func TestURLHandler_BulkActions(t *testing.T) {
+	svc := &bulkActionRecorder{}
+	h := handler.NewURLHandler(svc)
+	router := setupRouter()
+	api := router.Group("/api", func(c *gin.Context) { c.Set("user_id", uint(3)) })
+	h.RegisterProtectedRoutes(api)
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Delete", func(t *testing.T) {
+		w := send(http.MethodDelete, "/api/urls", `{"ids":[4,5,6]}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"succeeded":2,"skipped":[6]}`, w.Body.String())
+		assert.Equal(t, "delete", svc.action)
+		assert.Equal(t, uint(3), svc.userID)
+		assert.Equal(t, []uint{4, 5, 6}, svc.ids)
+	})
+
+	t.Run("Recrawl", func(t *testing.T) {
+		w := send(http.MethodPatch, "/api/urls/recrawl", `{"ids":[7,8]}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"succeeded":1,"skipped":[8]}`, w.Body.String())
+		assert.Equal(t, "start", svc.action)
+	})
+
+	t.Run("Invalid Payload", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodDelete, "/api/urls", `{"ids":[]}`).Code)
+		assert.Equal(t, http.StatusBadRequest, send(http.MethodPatch, "/api/urls/recrawl", `[1,2]`).Code)
+	})
+
+	t.Run("Service Error", func(t *testing.T) {
+		assert.Equal(t, http.StatusInternalServerError, send(http.MethodDelete, "/api/urls", `{"ids":[500]}`).Code)
+	})
+}
+
+// fullQueueService fails every crawl start as if the queue were full.
+type fullQueueService struct {
+	dummyURLService
+}
+
+func (s *fullQueueService) Start(id uint, requestID string) error {
+	return fmt.Errorf("cannot start: %w", crawler.ErrQueueFull)
+}
+
+func (s *fullQueueService) StartWithPriority(id uint, priority int, requestID string) error {
+	return crawler.ErrQueueFull
+}
+
+func TestURLHandler_Start_QueueFull(t *testing.T) {
+	router := setupRouter()
+	router.PATCH("/api/urls/:id/start", handler.NewURLHandler(&fullQueueService{}).Start)
+
+	for _, path := range []string{"/api/urls/1/start", "/api/urls/1/start?priority=9"} {
+		req, err := http.NewRequest(http.MethodPatch, path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, path)
+		assert.Equal(t, "30", w.Header().Get("Retry-After"), path)
+		assert.Contains(t, w.Body.String(), "crawl queue is full", path)
+	}
+}
+
+// overQuotaService fails every crawl start as if the user's crawl quota
+// were used up.
+type overQuotaService struct {
+	dummyURLService
+}
+
+func (s *overQuotaService) Start(id uint, requestID string) error {
+	return fmt.Errorf("%w: 2 of 2 crawls already queued or running", service.ErrCrawlQuotaExceeded)
+}
+
+func (s *overQuotaService) StartWithPriority(id uint, priority int, requestID string) error {
+	return s.Start(id, requestID)
+}
+
+func TestURLHandler_Start_QuotaExceeded(t *testing.T) {
+	router := setupRouter()
+	router.PATCH("/api/urls/:id/start", handler.NewURLHandler(&overQuotaService{}).Start)
+
+	for _, path := range []string{"/api/urls/1/start", "/api/urls/1/start?priority=9"} {
+		req, err := http.NewRequest(http.MethodPatch, path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code, path)
+		assert.Contains(t, w.Body.String(), "crawl quota exceeded", path)
+	}
+}
+
+// requestIDService records the request ID crawls are started with.
+type requestIDService struct {
+	dummyURLService
+	requestIDs []string
+}
+
+func (s *requestIDService) Start(id uint, requestID string) error {
+	s.requestIDs = append(s.requestIDs, requestID)
+	return nil
+}
+
+func (s *requestIDService) StartWithPriority(id uint, priority int, requestID string) error {
+	return s.Start(id, requestID)
+}
+
+func TestURLHandler_Start_RequestID(t *testing.T) {
+	svc := &requestIDService{}
+	router := setupRouter()
+	router.Use(middleware.RequestID())
+	router.PATCH("/api/urls/:id/start", handler.NewURLHandler(svc).Start)
+
+	for _, path := range []string{"/api/urls/1/start", "/api/urls/1/start?priority=9"} {
+		req, err := http.NewRequest(http.MethodPatch, path, nil)
+		require.NoError(t, err)
+		req.Header.Set(middleware.RequestIDHeader, "trace-"+path)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Code, path)
+	}
+	assert.Equal(t, []string{"trace-/api/urls/1/start", "trace-/api/urls/1/start?priority=9"}, svc.requestIDs)
+}
+
+// pausableService tracks whether the crawler is paused.
+type pausableService struct {
+	dummyURLService
+	paused bool
+}
+
+func (s *pausableService) PauseCrawler()  { s.paused = true }
+func (s *pausableService) ResumeCrawler() { s.paused = false }
+func (s *pausableService) CrawlerStatus() *model.CrawlerStatusDTO {
+	return &model.CrawlerStatusDTO{Paused: s.paused, QueueDepth: 4, Workers: 2}
+}
+
+func TestURLHandler_PauseCrawler(t *testing.T) {
+	svc := &pausableService{}
+	h := handler.NewURLHandler(svc)
+	router := setupRouter()
+	withRole := func(role string, next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			c.Set("user_role", role)
+			next(c)
+		}
+	}
+	router.PATCH("/api/crawler/pause", withRole("admin", h.PauseCrawler))
+	router.PATCH("/api/crawler/resume", withRole("admin", h.ResumeCrawler))
+	router.PATCH("/api/user/crawler/pause", withRole("user", h.PauseCrawler))
+	router.PATCH("/api/user/crawler/resume", withRole("user", h.ResumeCrawler))
+	router.GET("/api/crawler/status", withRole("user", h.CrawlerStatus))
+
+	call := func(method, path string) (int, model.CrawlerStatusDTO) {
+		req, err := http.NewRequest(method, path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		var status model.CrawlerStatusDTO
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		}
+		return w.Code, status
+	}
+
+	code, status := call(http.MethodPatch, "/api/crawler/pause")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, model.CrawlerStatusDTO{Paused: true, QueueDepth: 4, Workers: 2}, status)
+
+	code, status = call(http.MethodGet, "/api/crawler/status")
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, status.Paused)
+
+	code, _ = call(http.MethodPatch, "/api/user/crawler/resume")
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.True(t, svc.paused, "only admins can resume the crawler")
+
+	code, status = call(http.MethodPatch, "/api/crawler/resume")
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, status.Paused)
+
+	code, _ = call(http.MethodPatch, "/api/user/crawler/pause")
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.False(t, svc.paused, "only admins can pause the crawler")
+}
+
+// workerAdjustingService records the worker adjustments it is asked for.
+type workerAdjustingService struct {
+	dummyURLService
+	adjusted []string
+}
+
+func (s *workerAdjustingService) AdjustCrawlerWorkers(action string, count int) error {
+	s.adjusted = append(s.adjusted, fmt.Sprintf("%s %d", action, count))
+	return nil
+}
+
+func TestURLHandler_AdjustWorkers(t *testing.T) {
+	svc := &workerAdjustingService{}
+	h := handler.NewURLHandler(svc)
+	router := setupRouter()
+	withRole := func(role string, next gin.HandlerFunc) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			c.Set("user_role", role)
+			next(c)
+		}
+	}
+	router.PATCH("/api/crawler/workers", withRole("admin", h.AdjustWorkers))
+	router.PATCH("/api/user/crawler/workers", withRole("user", h.AdjustWorkers))
+
+	call := func(path string) int {
+		req, err := http.NewRequest(http.MethodPatch, path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, call("/api/user/crawler/workers?action=add&count=2"))
+	assert.Empty(t, svc.adjusted, "only admins can change the worker count")
+
+	assert.Equal(t, http.StatusBadRequest, call("/api/crawler/workers?action=double&count=2"))
+	assert.Equal(t, http.StatusOK, call("/api/crawler/workers?action=add&count=2"))
+	assert.Equal(t, []string{"add 2"}, svc.adjusted)
+}
+
+func TestURLHandler_CrawlerStats(t *testing.T) {
+	router := setupRouter()
+	router.GET("/api/crawler/stats", handler.NewURLHandler(&dummyURLService{}).CrawlerStats)
+
+	req, err := http.NewRequest(http.MethodGet, "/api/crawler/stats", nil)
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var stats crawler.PoolStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, 1, stats.ActiveWorkers)
+	assert.Equal(t, int64(10), stats.Processed)
+	assert.Equal(t, int64(2), stats.Errored)
+	assert.Equal(t, int64(250), stats.AvgDurationMs)
+	assert.Equal(t, int64(7), stats.ByStatus[model.StatusDone])
+	assert.Contains(t, w.Body.String(), `"queue_length":3`)
+}
+
+// queueService reads the crawl queue of an unstarted pool.
+type queueService struct {
+	dummyURLService
+	pool crawler.Pool
+}
+
+func (s *queueService) CrawlerQueue(limit int) crawler.QueueSnapshot {
+	return s.pool.Snapshot(limit)
+}
+
+func TestURLHandler_CrawlerQueue(t *testing.T) {
+	pool := crawler.New(nil, nil, 1, 16, time.Second)
+	require.NoError(t, pool.Enqueue(1))
+	require.NoError(t, pool.EnqueueWithPriority(2, 9))
+	require.NoError(t, pool.EnqueueWithPriority(3, 1))
+	require.NoError(t, pool.Enqueue(4))
+
+	h := handler.NewURLHandler(&queueService{pool: pool})
+	router := setupRouter()
+	withRole := func(role string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			c.Set("user_role", role)
+			h.CrawlerQueue(c)
+		}
+	}
+	router.GET("/api/admin/crawler/queue", withRole("admin"))
+	router.GET("/api/user/crawler/queue", withRole("user"))
+	get := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/api/admin/crawler/queue")
+	require.Equal(t, http.StatusOK, w.Code)
+	var snap crawler.QueueSnapshot
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &snap))
+	assert.Equal(t, 4, snap.Total)
+	var order []uint
+	var priorities []int
+	for _, task := range snap.Tasks {
+		order = append(order, task.URLID)
+		priorities = append(priorities, task.Priority)
+		assert.False(t, task.EnqueuedAt.IsZero())
+	}
+	assert.Equal(t, []uint{2, 1, 4, 3}, order, "listed in the order workers take them")
+	assert.Equal(t, []int{9, 5, 5, 1}, priorities)
+
+	w = get("/api/admin/crawler/queue?limit=2")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &snap))
+	assert.Equal(t, 4, snap.Total, "the total counts the URLs not listed")
+	assert.Len(t, snap.Tasks, 2)
+
+	assert.Equal(t, http.StatusBadRequest, get("/api/admin/crawler/queue?limit=0").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/api/admin/crawler/queue?limit=501").Code)
+	assert.Equal(t, http.StatusForbidden, get("/api/user/crawler/queue").Code)
+}
+
+// listRecorder records the filter of the last URL listing.
+type listRecorder struct {
+	dummyURLService
+	filter repository.URLFilter
+}
+
+func (s *listRecorder) List(userID uint, f repository.URLFilter, p repository.Pagination) (*model.PaginatedResponse[model.URLDTO], error) {
+	s.filter = f
+	return s.dummyURLService.List(userID, f, p)
+}
+
+func TestURLHandler_ListFilter(t *testing.T) {
+	svc := &listRecorder{}
+	router := setupRouter()
+	h := handler.NewURLHandler(svc)
+	router.GET("/api/urls", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		h.List(c)
+	})
+	get := func(query string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/api/urls"+query, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("No Params", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, get("").Code)
+		assert.Equal(t, repository.URLFilter{}, svc.filter)
+	})
+
+	t.Run("Status Search Sort", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, get("?status=error&search=shop&sort=-created_at").Code)
+		assert.Equal(t, repository.URLFilter{Status: model.StatusError, Search: "shop", Sort: "-created_at"}, svc.filter)
+	})
+
+	t.Run("Status Code", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, get("?status_code=500&status=done").Code)
+		assert.Equal(t, repository.URLFilter{Status: model.StatusDone, StatusCode: 500}, svc.filter)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("?sort=password").Code)
+		assert.Equal(t, http.StatusBadRequest, get("?status=finished").Code)
+		assert.Equal(t, http.StatusBadRequest, get("?status_code=abc").Code)
+		assert.Equal(t, http.StatusBadRequest, get("?status_code=999").Code)
+	})
+}
+
+// tagReportService serves a fixed two-page report for the "shop" tag.
+type tagReportService struct {
+	dummyURLService
+}
+
+func (s *tagReportService) TagReport(userID uint, tag string, p repository.Pagination) (*model.PaginatedResponse[model.TagReportItemDTO], error) {
+	if tag != "shop" || userID != 1 {
+		return &model.PaginatedResponse[model.TagReportItemDTO]{Data: []model.TagReportItemDTO{}}, nil
+	}
+	all := []model.TagReportItemDTO{
+		{URLID: 1, OriginalURL: "https://shop.example/a", Status: model.StatusDone, Title: "A", BrokenLinkCount: 2},
+		{URLID: 2, OriginalURL: "https://shop.example/b", Status: model.StatusError},
+		{URLID: 3, OriginalURL: "https://shop.example/c", Status: model.StatusQueued},
+	}
+	start := min(p.Offset(), len(all))
+	end := min(start+p.Limit(), len(all))
+	return &model.PaginatedResponse[model.TagReportItemDTO]{
+		Data:       all[start:end],
+		Pagination: model.PaginationMetaDTO{Page: p.Page, PageSize: p.Limit(), TotalItems: len(all), TotalPages: (len(all) + p.Limit() - 1) / p.Limit()},
+	}, nil
+}
+
+func TestURLHandler_TagReport(t *testing.T) {
+	router := setupRouter()
+	h := handler.NewURLHandler(&tagReportService{})
+	api := router.Group("/api", func(c *gin.Context) { c.Set("user_id", uint(1)) })
+	h.RegisterProtectedRoutes(api)
+	get := func(path string) model.PaginatedResponse[model.TagReportItemDTO] {
+		req, err := http.NewRequest("GET", path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var body model.PaginatedResponse[model.TagReportItemDTO]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	first := get("/api/tags/shop/report?page=1&page_size=2")
+	second := get("/api/tags/shop/report?page=2&page_size=2")
+	assert.Equal(t, model.PaginationMetaDTO{Page: 1, PageSize: 2, TotalItems: 3, TotalPages: 2}, first.Pagination)
+	require.Len(t, first.Data, 2)
+	require.Len(t, second.Data, 1)
+	assert.Equal(t, "A", first.Data[0].Title)
+	assert.Equal(t, 2, first.Data[0].BrokenLinkCount)
+	assert.Equal(t, []uint{1, 2, 3}, []uint{first.Data[0].URLID, first.Data[1].URLID, second.Data[0].URLID}, "every tagged URL is summarized once")
+
+	assert.Empty(t, get("/api/tags/other/report").Data)
+}
+
+// tagSummaryService rolls up the "shop" tag of user 1.
+type tagSummaryService struct {
+	dummyURLService
+}
+
+func (s *tagSummaryService) TagSummary(userID uint, tag string) (*model.TagSummaryDTO, error) {
+	if tag == "broken" {
+		return nil, errors.New("db down")
+	}
+	if tag != "shop" || userID != 1 {
+		return &model.TagSummaryDTO{Tag: tag, ByStatus: map[string]int{}}, nil
+	}
+	return &model.TagSummaryDTO{
+		Tag:         "shop",
+		TotalURLs:   3,
+		BrokenLinks: 2,
+		ByStatus:    map[string]int{model.StatusDone: 1, model.StatusError: 1, model.StatusQueued: 1},
+	}, nil
+}
+
+func TestURLHandler_TagSummary(t *testing.T) {
+	router := setupRouter()
+	h := handler.NewURLHandler(&tagSummaryService{})
+	api := router.Group("/api", func(c *gin.Context) { c.Set("user_id", uint(1)) })
+	h.RegisterProtectedRoutes(api)
+	get := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Rollup", func(t *testing.T) {
+		w := get("/api/tags/shop/summary")
+		require.Equal(t, http.StatusOK, w.Code)
+		var summary model.TagSummaryDTO
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+		assert.Equal(t, "shop", summary.Tag)
+		assert.Equal(t, 3, summary.TotalURLs)
+		assert.Equal(t, 2, summary.BrokenLinks)
+		assert.Equal(t, map[string]int{model.StatusDone: 1, model.StatusError: 1, model.StatusQueued: 1}, summary.ByStatus)
+	})
+
+	t.Run("Unknown Tag", func(t *testing.T) {
+		w := get("/api/tags/other/summary")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"tag":"other","total_urls":0,"broken_links":0,"by_status":{}}`, w.Body.String())
+	})
+
+	t.Run("Service Error", func(t *testing.T) {
+		assert.Equal(t, http.StatusInternalServerError, get("/api/tags/broken/summary").Code)
+	})
+}
+
+// tagRecrawlService records the tag recrawls it is asked for.
+type tagRecrawlService struct {
+	dummyURLService
+	userID     uint
+	tag        string
+	priorities []int
+}
+
+func (s *tagRecrawlService) RecrawlTag(userID uint, tag string, priority int) (*model.BulkURLActionResultDTO, error) {
+	s.userID, s.tag = userID, tag
+	s.priorities = append(s.priorities, priority)
+	return &model.BulkURLActionResultDTO{Succeeded: 2, Skipped: []uint{5}}, nil
+}
+
+func TestURLHandler_RecrawlTag(t *testing.T) {
+	svc := &tagRecrawlService{}
+	router := setupRouter()
+	api := router.Group("/api", func(c *gin.Context) { c.Set("user_id", uint(1)) })
+	handler.NewURLHandler(svc).RegisterProtectedRoutes(api)
+	post := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/api/tags/shop/recrawl")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"succeeded":2,"skipped":[5]}`, w.Body.String())
+	assert.Equal(t, uint(1), svc.userID)
+	assert.Equal(t, "shop", svc.tag)
+
+	assert.Equal(t, http.StatusOK, post("/api/tags/shop/recrawl?priority=9").Code)
+	assert.Equal(t, []int{0, 9}, svc.priorities, "without priority each URL is queued like a plain start")
+
+	for _, bad := range []string{"0", "11", "high"} {
+		assert.Equal(t, http.StatusBadRequest, post("/api/tags/shop/recrawl?priority="+bad).Code, bad)
+	}
+	assert.Len(t, svc.priorities, 2, "an invalid priority queues nothing")
+}
+
+func TestURLHandler_ResultsSchemaVersion(t *testing.T) {
+	router := setupRouter()
+	router.GET("/api/urls/:id/results", handler.NewURLHandler(&dummyURLService{}).Results)
+	get := func(version string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/api/urls/55/results", nil)
+		require.NoError(t, err)
+		if version != "" {
+			req.Header.Set("Accept-Version", version)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Version Present", func(t *testing.T) {
+		w := get("")
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.EqualValues(t, model.ResultsSchemaVersion, body["schema_version"])
+	})
+
+	t.Run("Current Version Accepted", func(t *testing.T) {
+		w := get(fmt.Sprint(model.ResultsSchemaVersion))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Unsupported Version", func(t *testing.T) {
+		w := get("99")
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		assert.Contains(t, w.Body.String(), "unsupported schema version")
+	})
+}
+
+// resultStream serves crawl results from a channel the test controls.
+type resultStream struct {
+	dummyURLService
+	results chan crawler.CrawlResult
+}
+
+func (s *resultStream) GetCrawlResults() <-chan crawler.CrawlResult {
+	return s.results
+}
+
+func TestURLHandler_StreamCrawlResults(t *testing.T) {
+	start := func(t *testing.T, opts handler.StreamOptions) (*resultStream, *bufio.Reader, context.CancelFunc, <-chan struct{}) {
+		svc := &resultStream{results: make(chan crawler.CrawlResult, 4)}
+		h := handler.NewURLHandlerWithOptions(svc, handler.URLHandlerOptions{Stream: opts})
+		done := make(chan struct{})
+		router := setupRouter()
+		router.GET("/api/crawler/results", func(c *gin.Context) {
+			defer close(done)
+			c.Set("user_id", uint(1))
+			c.Set("user_role", model.RoleUser)
+			h.GetCrawlResults(c)
+		})
+		ts := httptest.NewServer(router)
+		t.Cleanup(ts.Close)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		t.Cleanup(cancel)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/crawler/results", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+		return svc, bufio.NewReader(resp.Body), cancel, done
+	}
+
+	t.Run("Delivers Results", func(t *testing.T) {
+		svc, reader, _, _ := start(t, handler.StreamOptions{})
+
+		svc.results <- crawler.CrawlResult{URLID: 3, UserID: 1, URL: "https://example.com", Status: model.StatusDone, LinkCount: 4, Duration: 1500 * time.Millisecond, Attempt: 1}
+		svc.results <- crawler.CrawlResult{URLID: 9, UserID: 2, Status: model.StatusDone}
+		svc.results <- crawler.CrawlResult{URLID: 4, UserID: 1, Status: model.StatusError, Error: fmt.Errorf("dial tcp: refused"), Attempt: 2}
+
+		event := readSSEEvent(t, reader)
+		assert.Contains(t, event, "event:result")
+		assert.Contains(t, event, `"url_id":3`)
+		assert.Contains(t, event, `"status":"done"`)
+		assert.Contains(t, event, `"duration_ms":1500`)
+		assert.NotContains(t, event, `"error"`)
+
+		event = readSSEEvent(t, reader)
+		assert.Contains(t, event, `"url_id":4`, "results of other users' URLs are not streamed")
+		assert.Contains(t, event, `"status":"error"`)
+		assert.Contains(t, event, `"error":"dial tcp: refused"`)
+	})
+
+	t.Run("Stops When Client Disconnects", func(t *testing.T) {
+		_, _, cancel, done := start(t, handler.StreamOptions{})
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("handler kept streaming after the client went away")
+		}
+	})
+
+	t.Run("Ends When Results Close", func(t *testing.T) {
+		svc, reader, _, _ := start(t, handler.StreamOptions{Heartbeat: time.Hour})
+		close(svc.results)
+		rest, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Empty(t, rest)
+	})
+}
+
+func TestURLHandler_CrawlResultsWS(t *testing.T) {
+	svc := &resultStream{results: make(chan crawler.CrawlResult, 8)}
+	h := handler.NewURLHandlerWithOptions(svc, handler.URLHandlerOptions{
+		Stream: handler.StreamOptions{Heartbeat: 50 * time.Millisecond},
+	})
+	done := make(chan struct{}, 4)
+	router := setupRouter()
+	router.GET("/api/crawler/ws", func(c *gin.Context) {
+		defer func() { done <- struct{}{} }()
+		if uid := c.Query("as"); uid != "" {
+			id, _ := strconv.ParseUint(uid, 10, 64)
+			c.Set("user_id", uint(id))
+			c.Set("user_role", model.RoleUser)
+		}
+		if c.Query("admin") != "" {
+			c.Set("user_id", uint(100))
+			c.Set("user_role", model.RoleAdmin)
+		}
+		h.CrawlResultsWS(c)
+	})
+	ts := httptest.NewServer(router)
+	t.Cleanup(ts.Close)
+
+	dial := func(t *testing.T, query string) *websocket.Conn {
+		ws, err := websocket.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/api/crawler/ws?"+query, "", ts.URL)
+		require.NoError(t, err)
+		require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+		return ws
+	}
+	receive := func(t *testing.T, ws *websocket.Conn) crawler.CrawlEvent {
+		var ev crawler.CrawlEvent
+		require.NoError(t, websocket.JSON.Receive(ws, &ev))
+		return ev
+	}
+
+	t.Run("Fans Out To Owners And Admins", func(t *testing.T) {
+		alice := dial(t, "as=1")
+		defer alice.Close()
+		bob := dial(t, "as=2")
+		defer bob.Close()
+		admin := dial(t, "admin=1")
+		defer admin.Close()
+		time.Sleep(50 * time.Millisecond) // let every handler subscribe
+
+		svc.results <- crawler.CrawlResult{URLID: 10, UserID: 1, Status: model.StatusDone}
+		svc.results <- crawler.CrawlResult{URLID: 20, UserID: 2, Status: model.StatusError, Error: fmt.Errorf("boom")}
+
+		assert.Equal(t, uint(10), receive(t, alice).URLID)
+		ev := receive(t, bob)
+		assert.Equal(t, uint(20), ev.URLID)
+		assert.Equal(t, "boom", ev.Error)
+		assert.Equal(t, uint(10), receive(t, admin).URLID)
+		assert.Equal(t, uint(20), receive(t, admin).URLID)
+	})
+
+	t.Run("Removed When Socket Closes", func(t *testing.T) {
+		for len(done) > 0 {
+			<-done
+		}
+		ws := dial(t, "as=1")
+		require.NoError(t, ws.Close())
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("handler kept the subscription after the client closed the socket")
+		}
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := websocket.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/api/crawler/ws", "", ts.URL)
+		assert.Error(t, err)
+	})
+}
+
+// waitService reports a URL whose status the test changes while a client
+// waits on it. got is signalled on every lookup.
+type waitService struct {
+	resultStream
+	mu     sync.Mutex
+	status string
+	got    chan struct{}
+}
+
+func (s *waitService) Get(id uint) (*model.URLDTO, error) {
+	if id == 404 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case s.got <- struct{}{}:
+	default:
+	}
+	return &model.URLDTO{ID: id, UserID: 1, Status: s.status}, nil
+}
+
+func (s *waitService) finish(id uint, status string) {
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+	s.results <- crawler.CrawlResult{URLID: id, UserID: 1, Status: status}
+}
+
+func TestURLHandler_Wait(t *testing.T) {
+	setup := func(status string) (*waitService, func(path string, uid uint) (*httptest.ResponseRecorder, model.URLWaitDTO)) {
+		svc := &waitService{
+			resultStream: resultStream{results: make(chan crawler.CrawlResult, 4)},
+			status:       status,
+			got:          make(chan struct{}, 1),
+		}
+		h := handler.NewURLHandler(svc)
+		router := setupRouter()
+		router.GET("/api/urls/:id/wait", func(c *gin.Context) {
+			uid, _ := strconv.ParseUint(c.Query("as"), 10, 64)
+			c.Set("user_id", uint(uid))
+			c.Set("user_role", model.RoleUser)
+			h.Wait(c)
+		})
+		get := func(path string, uid uint) (*httptest.ResponseRecorder, model.URLWaitDTO) {
+			sep := "?"
+			if strings.Contains(path, "?") {
+				sep = "&"
+			}
+			req, err := http.NewRequest("GET", fmt.Sprintf("/api/urls/%s%sas=%d", path, sep, uid), nil)
+			require.NoError(t, err)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			var body model.URLWaitDTO
+			_ = json.Unmarshal(w.Body.Bytes(), &body)
+			return w, body
+		}
+		return svc, get
+	}
+
+	t.Run("Completes Before Timeout", func(t *testing.T) {
+		svc, get := setup(model.StatusRunning)
+		go func() {
+			<-svc.got
+			svc.results <- crawler.CrawlResult{URLID: 9, UserID: 1, Status: model.StatusDone}
+			svc.finish(7, model.StatusDone)
+		}()
+
+		start := time.Now()
+		w, body := get("7/wait?timeout=5s", 1)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, model.URLWaitDTO{ID: 7, Status: model.StatusDone}, body)
+		assert.Less(t, time.Since(start), 2*time.Second)
+	})
+
+	t.Run("Timeout Returns Current Status", func(t *testing.T) {
+		svc, get := setup(model.StatusRunning)
+		go func() {
+			<-svc.got
+			svc.results <- crawler.CrawlResult{URLID: 7, UserID: 1, Status: model.StatusRunning, Attempt: 1}
+		}()
+
+		w, body := get("7/wait?timeout=50ms", 1)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, model.URLWaitDTO{ID: 7, Status: model.StatusRunning, TimedOut: true}, body)
+	})
+
+	t.Run("Already Finished", func(t *testing.T) {
+		_, get := setup(model.StatusError)
+		w, body := get("7/wait?timeout=1", 1)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, model.URLWaitDTO{ID: 7, Status: model.StatusError}, body)
+	})
+
+	t.Run("Not Owned", func(t *testing.T) {
+		_, get := setup(model.StatusRunning)
+		w, _ := get("7/wait?timeout=1s", 2)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		_, get := setup(model.StatusRunning)
+		w, _ := get("404/wait", 1)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid Timeout", func(t *testing.T) {
+		_, get := setup(model.StatusRunning)
+		for _, timeout := range []string{"soon", "-5s", "0"} {
+			w, _ := get("7/wait?timeout="+timeout, 1)
+			assert.Equal(t, http.StatusBadRequest, w.Code, timeout)
+		}
+	})
+}
+
+// csvResults serves many links and two analyses for URL 9, owned by user 1.
+type csvResults struct {
+	dummyURLService
+}
+
+func (s *csvResults) ResultsWithDetails(id uint) (*model.URL, []*model.AnalysisResult, []*model.Link, error) {
+	if id != 9 {
+		return nil, nil, nil, fmt.Errorf("failed to get detailed URL results: %w", gorm.ErrRecordNotFound)
+	}
+	links := make([]*model.Link, 1200)
+	for i := range links {
+		links[i] = &model.Link{URLID: id, Href: fmt.Sprintf("http://example.com/%d", i), IsExternal: i%2 == 1, StatusCode: 200}
+	}
+	links[3].StatusCode = 404
+	return &model.URL{ID: id, UserID: 1, OriginalURL: "http://example.com"},
+		[]*model.AnalysisResult{
+			{ID: 1, InternalLinkCount: 1, ExternalLinkCount: 1, BrokenLinkCount: 1},
+			{ID: 2, InternalLinkCount: 600, ExternalLinkCount: 600, BrokenLinkCount: 1},
+		}, links, nil
+}
+
+func TestURLHandler_ResultsCSV(t *testing.T) {
+	get := func(path string, userID uint, role model.UserRole) *httptest.ResponseRecorder {
+		h := handler.NewURLHandler(&csvResults{})
+		router := setupRouter()
+		router.GET("/api/urls/:id/results.csv", func(c *gin.Context) {
+			c.Set("user_id", userID)
+			c.Set("user_role", role)
+			h.ResultsCSV(c)
+		})
+		req, err := http.NewRequest("GET", path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Owner", func(t *testing.T) {
+		w := get("/api/urls/9/results.csv", 1, model.RoleUser)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="url-9-results.csv"`, w.Header().Get("Content-Disposition"))
+
+		r := csv.NewReader(w.Body)
+		r.FieldsPerRecord = -1
+		rows, err := r.ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 1202, "header, one row per link and the summary")
+		assert.Equal(t, []string{"href", "is_external", "status_code"}, rows[0])
+		assert.Equal(t, []string{"http://example.com/0", "false", "200"}, rows[1])
+		assert.Equal(t, []string{"http://example.com/3", "true", "404"}, rows[4])
+		assert.Equal(t, []string{"summary", "600", "600", "1"}, rows[1201], "counts of the latest analysis")
+	})
+
+	t.Run("Admin", func(t *testing.T) {
+		w := get("/api/urls/9/results.csv", 2, model.RoleAdmin)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Other User", func(t *testing.T) {
+		w := get("/api/urls/9/results.csv", 2, model.RoleUser)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		w := get("/api/urls/10/results.csv", 1, model.RoleUser)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		w := get("/api/urls/abc/results.csv", 1, model.RoleUser)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Registered Next To Results", func(t *testing.T) {
+		router := setupRouter()
+		group := router.Group("/api", func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			c.Next()
+		})
+		handler.NewURLHandler(&csvResults{}).RegisterProtectedRoutes(group)
+		req, err := http.NewRequest("GET", "/api/urls/9/results.csv", nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, strings.HasPrefix(w.Body.String(), "href,is_external,status_code\n"))
+	})
+}
+
+func TestURLHandler_Trash(t *testing.T) {
+	router := setupRouter()
+	group := router.Group("/api", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Next()
+	})
+	handler.NewURLHandler(&dummyURLService{}).RegisterProtectedRoutes(group)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("List", func(t *testing.T) {
+		w := do(http.MethodGet, "/api/urls/trash?page=1&page_size=5")
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp model.PaginatedResponse[model.URLDTO]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Data, 1)
+		assert.Equal(t, uint(8), resp.Data[0].ID)
+		assert.Equal(t, 5, resp.Pagination.PageSize)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		w := do(http.MethodPost, "/api/urls/8/restore")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"message":"restored"}`, w.Body.String())
+	})
+
+	t.Run("Restore Unknown", func(t *testing.T) {
+		w := do(http.MethodPost, "/api/urls/9/restore")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Restore Invalid ID", func(t *testing.T) {
+		w := do(http.MethodPost, "/api/urls/abc/restore")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestURLHandler_Purge(t *testing.T) {
+	purge := func(role model.UserRole, path string) *httptest.ResponseRecorder {
+		router := setupRouter()
+		group := router.Group("/api", func(c *gin.Context) {
+			c.Set("user_id", uint(1))
+			c.Set("user_role", role)
+			c.Next()
+		})
+		handler.NewURLHandler(&dummyURLService{}).RegisterProtectedRoutes(group)
+		req, err := http.NewRequest(http.MethodDelete, path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := purge(model.RoleAdmin, "/api/urls/8/purge")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message":"purged"}`, w.Body.String())
+
+	assert.Equal(t, http.StatusForbidden, purge(model.RoleUser, "/api/urls/8/purge").Code)
+	assert.Equal(t, http.StatusNotFound, purge(model.RoleAdmin, "/api/urls/9/purge").Code)
+	assert.Equal(t, http.StatusBadRequest, purge(model.RoleAdmin, "/api/urls/abc/purge").Code)
+}
+
+// reprioritizingService queues URL 1, is crawling URL 2 and has no URL 3.
+type reprioritizingService struct {
+	dummyURLService
+	moved map[uint]int
+}
+
+func (s *reprioritizingService) Reprioritize(id, userID uint, priority int) error {
+	switch id {
+	case 1:
+		s.moved[id] = priority
+		return nil
+	case 2:
+		return fmt.Errorf("%w: status is %s", service.ErrURLNotQueued, model.StatusRunning)
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func TestURLHandler_Reprioritize(t *testing.T) {
+	svc := &reprioritizingService{moved: map[uint]int{}}
+	router := setupRouter()
+	group := router.Group("/api", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Next()
+	})
+	handler.NewURLHandler(svc).RegisterProtectedRoutes(group)
+	patch := func(path, body string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := patch("/api/urls/1/priority", `{"priority":9}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"queued","priority":9}`, w.Body.String())
+	assert.Equal(t, map[uint]int{1: 9}, svc.moved)
+
+	w = patch("/api/urls/2/priority", `{"priority":9}`)
+	assert.Equal(t, http.StatusConflict, w.Code, "running URLs cannot be reprioritized")
+	assert.Contains(t, w.Body.String(), "url is not queued")
+
+	assert.Equal(t, http.StatusNotFound, patch("/api/urls/3/priority", `{"priority":9}`).Code)
+	for _, body := range []string{`{"priority":0}`, `{"priority":11}`, `{}`, `{"priority":"high"}`} {
+		assert.Equal(t, http.StatusBadRequest, patch("/api/urls/1/priority", body).Code, body)
+	}
+	assert.Equal(t, map[uint]int{1: 9}, svc.moved)
+}
+
+func TestURLHandler_ListCursor(t *testing.T) {
+	router := setupRouter()
+	group := router.Group("/api", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Next()
+	})
+	handler.NewURLHandler(&dummyURLService{}).RegisterProtectedRoutes(group)
+	get := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("First Page", func(t *testing.T) {
+		w := get("/api/urls?cursor=&page_size=2")
+		require.Equal(t, http.StatusOK, w.Code)
+		var page model.CursorResponse[model.URLDTO]
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.Len(t, page.Data, 2)
+		assert.Equal(t, "page2", page.NextCursor)
+	})
+
+	t.Run("Last Page", func(t *testing.T) {
+		w := get("/api/urls?cursor=page2&page_size=2")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "next_cursor")
+		assert.NotContains(t, w.Body.String(), "pagination")
+	})
+
+	t.Run("Invalid Cursor", func(t *testing.T) {
+		w := get("/api/urls?cursor=bogus")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Sort Not Allowed", func(t *testing.T) {
+		w := get("/api/urls?cursor=&sort=id")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Offset Pagination Without Cursor", func(t *testing.T) {
+		w := get("/api/urls?page=1&page_size=2")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"pagination"`)
+	})
+}
+
+func TestURLHandler_ExportImport(t *testing.T) {
+	h := handler.NewURLHandler(&dummyURLService{})
+	router := setupRouter()
+	router.Use(func(c *gin.Context) { c.Set("user_id", uint(1)) })
+	router.GET("/api/urls/export", h.ExportURLs)
+	router.POST("/api/urls/import", h.ImportURLs)
+
+	t.Run("Export Is Versioned", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/urls/export", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "linktorch-urls-1.json")
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.EqualValues(t, model.URLExportSchemaVersion, body["schema_version"])
+		assert.Len(t, body["urls"], 1)
+	})
+
+	t.Run("Export Unsupported Version", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/urls/export", nil)
+		req.Header.Set("Accept-Version", "99")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	})
+
+	importDoc := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/api/urls/import", strings.NewReader(body)))
+		return w
+	}
+
+	t.Run("Import", func(t *testing.T) {
+		w := importDoc(fmt.Sprintf(`{"schema_version":%d,"urls":[{"original_url":"https://example.com"}]}`, model.URLExportSchemaVersion))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"imported":1,"skipped":0}`, w.Body.String())
+	})
+
+	t.Run("Import Rejects Unsupported Version", func(t *testing.T) {
+		w := importDoc(`{"schema_version":99,"urls":[{"original_url":"https://example.com"}]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "unsupported schema version")
+
+		w = importDoc(`{"urls":[{"original_url":"https://example.com"}]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "documents without a version are refused")
+	})
+
+	t.Run("Import Invalid Payload", func(t *testing.T) {
+		w := importDoc(`{`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
 }
@@ -1,10 +1,12 @@
 package handler_test
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -14,10 +16,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
 	"github.com/fuzumoe/linkTorch-api/internal/handler"
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
+	"github.com/fuzumoe/linkTorch-api/internal/service"
 )
 
 type dummyUserService struct{}
@@ -26,6 +30,9 @@ func (s *dummyUserService) Register(input *model.CreateUserInput) (*model.UserDT
 	if input.Email == "error@example.com" {
 		return nil, errors.New("service error")
 	}
+	if input.Username == "taken" {
+		return nil, service.ErrUsernameTaken
+	}
 
 	return &model.UserDTO{
 		ID:       42,
@@ -60,6 +67,9 @@ func (s *dummyUserService) Update(id uint, input *model.UpdateUserInput) (*model
 	}
 
 	if input.Username != nil {
+		if *input.Username == "taken" {
+			return nil, service.ErrUsernameTaken
+		}
 		user.Username = *input.Username
 	}
 	if input.Email != nil {
@@ -79,6 +89,34 @@ func (s *dummyUserService) Delete(id uint) error {
 	return nil
 }
 
+func (s *dummyUserService) DeleteAccount(id uint, password string) error {
+	switch {
+	case id == 404:
+		return gorm.ErrRecordNotFound
+	case password == "db-down":
+		return errors.New("db down")
+	case password != "testpassword":
+		return service.ErrWrongPassword
+	}
+	return nil
+}
+
+func (s *dummyUserService) RequestPasswordReset(email string) (string, error) {
+	return "", nil
+}
+
+func (s *dummyUserService) ResetPassword(token, newPassword string) error {
+	return nil
+}
+
+func (s *dummyUserService) RequestEmailVerification(id uint) (string, error) {
+	return "", nil
+}
+
+func (s *dummyUserService) VerifyEmail(token string) error {
+	return nil
+}
+
 func (s *dummyUserService) Authenticate(email, password string) (*model.UserDTO, error) {
 	if email == "test@example.com" && password == "testpassword" {
 		return &model.UserDTO{
@@ -233,6 +271,25 @@ func TestUserHandler(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, w.Code)
 	})
 
+	t.Run("Create_UsernameTaken", func(t *testing.T) {
+		input := model.CreateUserInput{
+			Email:    "someone@example.com",
+			Password: "password123",
+			Username: "taken",
+		}
+		jsonInput, err := json.Marshal(input)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("POST", "/api/users", bytes.NewBuffer(jsonInput))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), "username taken")
+	})
+
 	t.Run("Me", func(t *testing.T) {
 		req, err := http.NewRequest("GET", "/api/users/me", nil)
 		require.NoError(t, err)
@@ -330,6 +387,20 @@ func TestUserHandler(t *testing.T) {
 		assert.Equal(t, "user", responseData["role"])
 	})
 
+	t.Run("Update_UsernameTaken", func(t *testing.T) {
+		input := model.UpdateUserInput{Username: stringPtr("taken")}
+		jsonInput, err := json.Marshal(input)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest("PUT", "/api/users/123", bytes.NewBuffer(jsonInput))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
 	t.Run("Delete", func(t *testing.T) {
 		req, err := http.NewRequest("DELETE", "/api/users/42", nil)
 		require.NoError(t, err)
@@ -348,3 +419,273 @@ func TestUserHandler(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, w.Code)
 	})
 }
+
+type stubExportService struct {
+	err     error
+	partial bool // write part of the archive before failing
+}
+
+func (s *stubExportService) Export(userID uint, w io.Writer) error {
+	if s.partial {
+		_, _ = w.Write([]byte("PK"))
+	}
+	if s.err != nil {
+		return s.err
+	}
+	zw := zip.NewWriter(w)
+	f, _ := zw.Create(service.ExportProfileFile)
+	_ = json.NewEncoder(f).Encode(model.UserDTO{ID: userID})
+	return zw.Close()
+}
+
+func TestUserHandler_Export(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(exports service.ExportService) *gin.Engine {
+		router := setupUserRouter()
+		rg := router.Group("/api", func(c *gin.Context) {
+			c.Set("user_id", uint(123))
+			c.Next()
+		})
+		handler.NewUserHandlerWithOptions(&dummyUserService{}, handler.UserHandlerOptions{Export: exports}).RegisterProtectedRoutes(rg)
+		return router
+	}
+	get := func(router *gin.Engine) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/users/me/export", nil))
+		return w
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		w := get(newRouter(&stubExportService{}))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename="linktorch-export-123.zip"`, w.Header().Get("Content-Disposition"))
+
+		zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		require.NoError(t, err)
+		require.Len(t, zr.File, 1)
+		assert.Equal(t, service.ExportProfileFile, zr.File[0].Name)
+	})
+
+	t.Run("User Not Found", func(t *testing.T) {
+		w := get(newRouter(&stubExportService{err: gorm.ErrRecordNotFound}))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("Service Error", func(t *testing.T) {
+		w := get(newRouter(&stubExportService{err: errors.New("db down")}))
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.JSONEq(t, `{"error":"failed to export account data"}`, w.Body.String())
+	})
+
+	t.Run("Error While Streaming", func(t *testing.T) {
+		w := get(newRouter(&stubExportService{err: errors.New("db down"), partial: true}))
+
+		assert.Equal(t, http.StatusOK, w.Code, "the status is already sent")
+		assert.Equal(t, "PK", w.Body.String())
+	})
+
+	t.Run("By ID", func(t *testing.T) {
+		exportAs := func(role model.UserRole, path string) *httptest.ResponseRecorder {
+			router := setupUserRouter()
+			rg := router.Group("/api", func(c *gin.Context) {
+				c.Set("user_id", uint(123))
+				c.Set("user_role", role)
+				c.Next()
+			})
+			handler.NewUserHandlerWithOptions(&dummyUserService{}, handler.UserHandlerOptions{Export: &stubExportService{}}).RegisterProtectedRoutes(rg)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			return w
+		}
+
+		w := exportAs(model.RoleUser, "/api/users/123/export")
+		assert.Equal(t, http.StatusOK, w.Code, "owners export their own data")
+		assert.Equal(t, `attachment; filename="linktorch-export-123.zip"`, w.Header().Get("Content-Disposition"))
+
+		w = exportAs(model.RoleUser, "/api/users/7/export")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+
+		w = exportAs(model.RoleAdmin, "/api/users/7/export")
+		assert.Equal(t, http.StatusOK, w.Code, "admins export anyone's data")
+		assert.Equal(t, `attachment; filename="linktorch-export-7.zip"`, w.Header().Get("Content-Disposition"))
+
+		w = exportAs(model.RoleAdmin, "/api/users/abc/export")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Not Registered Without Export Service", func(t *testing.T) {
+		router := setupUserRouter()
+		handler.NewUserHandler(&dummyUserService{}).RegisterProtectedRoutes(router.Group("/api"))
+
+		assert.Equal(t, http.StatusNotFound, get(router).Code)
+	})
+}
+
+func TestUserHandler_DeleteAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		userID       uint
+		body         string
+		expectedCode int
+	}{
+		{name: "Deleted", userID: 123, body: `{"password":"testpassword"}`, expectedCode: http.StatusNoContent},
+		{name: "Missing Password", userID: 123, body: `{}`, expectedCode: http.StatusBadRequest},
+		{name: "Wrong Password", userID: 123, body: `{"password":"guess"}`, expectedCode: http.StatusUnauthorized},
+		{name: "User Gone", userID: 404, body: `{"password":"testpassword"}`, expectedCode: http.StatusNotFound},
+		{name: "Service Error", userID: 123, body: `{"password":"db-down"}`, expectedCode: http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := setupUserRouter()
+			rg := router.Group("/api", func(c *gin.Context) {
+				c.Set("user_id", tc.userID)
+				c.Next()
+			})
+			handler.NewUserHandler(&dummyUserService{}).RegisterProtectedRoutes(rg)
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/users/me/account", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			if tc.expectedCode == http.StatusNoContent {
+				assert.Empty(t, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestUserHandler_EmailVerification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// sent records the tokens handed to the verification sender.
+	type sent struct{ email, token string }
+	newRouter := func(m *MockUserService, delivered *[]sent) *gin.Engine {
+		h := handler.NewUserHandlerWithOptions(m, handler.UserHandlerOptions{
+			SendVerification: func(email, token string) error {
+				*delivered = append(*delivered, sent{email, token})
+				return nil
+			},
+		})
+		router := setupUserRouter()
+		h.RegisterPublicRoutes(router.Group("/api"))
+		h.RegisterProtectedRoutes(router.Group("/api", func(c *gin.Context) {
+			c.Set("user_id", uint(7))
+			c.Next()
+		}))
+		return router
+	}
+	post := func(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Create Sends Token", func(t *testing.T) {
+		m := new(MockUserService)
+		var delivered []sent
+		m.On("Register", &model.CreateUserInput{Username: "new", Email: "new@example.com", Password: "password"}).
+			Return(&model.UserDTO{ID: 7, Email: "new@example.com"}, nil)
+		m.On("RequestEmailVerification", uint(7)).Return("VERIFY-TOKEN", nil)
+
+		w := post(newRouter(m, &delivered), "/api/users", `{"username":"new","email":"new@example.com","password":"password"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, []sent{{"new@example.com", "VERIFY-TOKEN"}}, delivered)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("Create Succeeds When Token Fails", func(t *testing.T) {
+		m := new(MockUserService)
+		var delivered []sent
+		m.On("Register", &model.CreateUserInput{Username: "new", Email: "new@example.com", Password: "password"}).
+			Return(&model.UserDTO{ID: 7, Email: "new@example.com"}, nil)
+		m.On("RequestEmailVerification", uint(7)).Return("", errors.New("db down"))
+
+		w := post(newRouter(m, &delivered), "/api/users", `{"username":"new","email":"new@example.com","password":"password"}`)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, delivered)
+	})
+
+	verifyTests := []struct {
+		name         string
+		body         string
+		err          error
+		expectedCode int
+	}{
+		{name: "Verified", body: `{"token":"VERIFY-TOKEN"}`, expectedCode: http.StatusOK},
+		{name: "Missing Token", body: `{}`, expectedCode: http.StatusBadRequest},
+		{name: "Invalid Token", body: `{"token":"VERIFY-TOKEN"}`, err: service.ErrVerificationTokenInvalid, expectedCode: http.StatusBadRequest},
+		{name: "Service Error", body: `{"token":"VERIFY-TOKEN"}`, err: errors.New("db down"), expectedCode: http.StatusInternalServerError},
+	}
+	for _, tc := range verifyTests {
+		t.Run("Verify "+tc.name, func(t *testing.T) {
+			m := new(MockUserService)
+			if tc.body != `{}` {
+				m.On("VerifyEmail", "VERIFY-TOKEN").Return(tc.err)
+			}
+
+			w := post(newRouter(m, new([]sent)), "/api/verify-email", tc.body)
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			m.AssertExpectations(t)
+		})
+	}
+
+	resendTests := []struct {
+		name         string
+		err          error
+		expectedCode int
+	}{
+		{name: "Sent", expectedCode: http.StatusOK},
+		{name: "Already Verified", err: service.ErrEmailAlreadyVerified, expectedCode: http.StatusConflict},
+		{name: "Rate Limited", err: service.ErrVerificationRateLimited, expectedCode: http.StatusTooManyRequests},
+		{name: "Service Error", err: errors.New("db down"), expectedCode: http.StatusInternalServerError},
+	}
+	for _, tc := range resendTests {
+		t.Run("Resend "+tc.name, func(t *testing.T) {
+			m := new(MockUserService)
+			var delivered []sent
+			m.On("Get", uint(7)).Return(&model.UserDTO{ID: 7, Email: "user@example.com"}, nil)
+			if tc.err != nil {
+				m.On("RequestEmailVerification", uint(7)).Return("", tc.err)
+			} else {
+				m.On("RequestEmailVerification", uint(7)).Return("VERIFY-TOKEN", nil)
+			}
+
+			w := post(newRouter(m, &delivered), "/api/verify-email/resend", "")
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+			if tc.err == nil {
+				assert.Equal(t, []sent{{"user@example.com", "VERIFY-TOKEN"}}, delivered)
+			} else {
+				assert.Empty(t, delivered)
+			}
+		})
+	}
+
+	t.Run("Routes Absent Without Sender", func(t *testing.T) {
+		router := setupUserRouter()
+		h := handler.NewUserHandler(&dummyUserService{})
+		h.RegisterPublicRoutes(router.Group("/api"))
+		h.RegisterProtectedRoutes(router.Group("/api"))
+
+		assert.Equal(t, http.StatusNotFound, post(router, "/api/verify-email", `{"token":"VERIFY-TOKEN"}`).Code)
+		assert.Equal(t, http.StatusNotFound, post(router, "/api/verify-email/resend", "").Code)
+	})
+}
@@ -0,0 +1,107 @@
+package metrics_test
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/metrics"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics_Crawls(t *testing.T) {
+	m := metrics.New(func() int { return 7 })
+
+	for range 3 {
+		m.CrawlStarted()
+	}
+	m.CrawlFinished(model.StatusDone, 200*time.Millisecond)
+	m.CrawlFinished(model.StatusDone, 2*time.Second)
+	m.CrawlFinished(model.StatusError, time.Second)
+
+	out := scrape(t, m)
+	assert.Contains(t, out, "linktorch_crawls_started_total 3")
+	assert.Contains(t, out, "linktorch_crawls_completed_total 2")
+	assert.Contains(t, out, "linktorch_crawls_failed_total 1")
+	assert.Contains(t, out, `linktorch_crawl_duration_seconds_count{status="done"} 2`)
+	assert.Contains(t, out, `linktorch_crawl_duration_seconds_count{status="error"} 1`)
+	assert.Contains(t, out, "linktorch_crawl_queue_depth 7")
+	assert.Contains(t, out, "go_goroutines")
+}
+
+func TestMetrics_Requests(t *testing.T) {
+	m := metrics.New(nil)
+
+	m.ObserveRequest(http.MethodGet, "/api/v1/urls/:id", http.StatusOK, 30*time.Millisecond)
+	m.ObserveRequest(http.MethodGet, "/api/v1/urls/:id", http.StatusOK, 50*time.Millisecond)
+	m.ObserveRequest(http.MethodPost, "/api/v1/urls", http.StatusCreated, 10*time.Millisecond)
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `linktorch_http_request_duration_seconds_count{method="GET",route="/api/v1/urls/:id",status="200"} 2`)
+	assert.Contains(t, out, `linktorch_http_request_duration_seconds_count{method="POST",route="/api/v1/urls",status="201"} 1`)
+	assert.NotContains(t, out, "linktorch_crawl_queue_depth")
+}
+
+func TestMetrics_Snapshot(t *testing.T) {
+	m := metrics.New(func() int { return 4 })
+
+	m.ObserveRequest(http.MethodGet, "/api/v1/urls/:id", http.StatusOK, 30*time.Millisecond)
+	m.ObserveRequest(http.MethodGet, "/api/v1/urls/:id", http.StatusOK, 50*time.Millisecond)
+	m.ObserveRequest(http.MethodPost, "/api/v1/urls", http.StatusCreated, 10*time.Millisecond)
+	m.CrawlStarted()
+	m.CrawlStarted()
+	m.CrawlFinished(model.StatusDone, time.Second)
+	m.CrawlFinished(model.StatusError, 3*time.Second)
+
+	snap, err := m.Snapshot()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), snap.Requests.Total)
+	require.Len(t, snap.Requests.ByRoute, 2)
+	assert.Equal(t, metrics.RouteRequests{
+		Method: http.MethodGet, Route: "/api/v1/urls/:id", Status: http.StatusOK, Count: 2, AvgSeconds: 0.04,
+	}, roundAvg(snap.Requests.ByRoute[0]))
+	assert.Equal(t, http.MethodPost, snap.Requests.ByRoute[1].Method)
+	assert.Equal(t, uint64(1), snap.Requests.ByRoute[1].Count)
+
+	assert.Equal(t, uint64(2), snap.Crawls.Started)
+	assert.Equal(t, uint64(1), snap.Crawls.Completed)
+	assert.Equal(t, uint64(1), snap.Crawls.Failed)
+	assert.Equal(t, metrics.CrawlDurations{Count: 1, AvgSeconds: 1}, snap.Crawls.ByStatus[model.StatusDone])
+	assert.Equal(t, metrics.CrawlDurations{Count: 1, AvgSeconds: 3}, snap.Crawls.ByStatus[model.StatusError])
+
+	require.NotNil(t, snap.QueueDepth)
+	assert.Equal(t, 4, *snap.QueueDepth)
+}
+
+func TestMetrics_SnapshotEmpty(t *testing.T) {
+	snap, err := metrics.New(nil).Snapshot()
+	require.NoError(t, err)
+	assert.Zero(t, snap.Requests.Total)
+	assert.Empty(t, snap.Requests.ByRoute)
+	assert.Zero(t, snap.Crawls.Started)
+	assert.Empty(t, snap.Crawls.ByStatus)
+	assert.Nil(t, snap.QueueDepth)
+}
+
+// roundAvg rounds away the float error of summing durations.
+func roundAvg(r metrics.RouteRequests) metrics.RouteRequests {
+	r.AvgSeconds = math.Round(r.AvgSeconds*1000) / 1000
+	return r
+}
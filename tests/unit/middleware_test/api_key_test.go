@@ -0,0 +1,95 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/fuzumoe/linkTorch-api/internal/middleware"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/service"
+)
+
+// knownAPIKeys authenticates a fixed set of keys; the rest of
+// service.APIKeyService is not used by the middleware.
+type knownAPIKeys struct {
+	service.APIKeyService
+	users map[string]*model.UserDTO
+}
+
+func (k *knownAPIKeys) Authenticate(key string) (*model.UserDTO, error) {
+	if key == "ltk_expired" {
+		return nil, service.ErrAPIKeyExpired
+	}
+	if u, ok := k.users[key]; ok {
+		return u, nil
+	}
+	return nil, service.ErrAPIKeyInvalid
+}
+
+func TestAuthMiddleware_APIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := &knownAPIKeys{users: map[string]*model.UserDTO{
+		"ltk_good": {ID: 7, Email: "user@example.com", Role: model.RoleAdmin},
+	}}
+
+	newRouter := func(authService service.AuthService, apiKeys service.APIKeyService) *gin.Engine {
+		router := gin.New()
+		router.Use(middleware.AuthMiddlewareWithOptions(authService, middleware.AuthOptions{APIKeys: apiKeys}))
+		router.GET("/protected", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{
+				"user_id":    c.MustGet("user_id"),
+				"user_email": c.MustGet("user_email"),
+				"user_role":  c.MustGet("user_role"),
+			})
+		})
+		return router
+	}
+
+	t.Run("Valid Key", func(t *testing.T) {
+		authService := new(MockAuthService)
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("X-API-Key", "ltk_good")
+		w := httptest.NewRecorder()
+		newRouter(authService, keys).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"user_id":7,"user_email":"user@example.com","user_role":"admin"}`, w.Body.String())
+		authService.AssertNotCalled(t, "Validate", mock.Anything)
+	})
+
+	t.Run("Invalid Key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("X-API-Key", "ltk_bad")
+		req.Header.Set("Authorization", "Bearer ignored")
+		w := httptest.NewRecorder()
+		newRouter(new(MockAuthService), keys).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.JSONEq(t, `{"error":"invalid API key"}`, w.Body.String())
+	})
+
+	t.Run("Expired Key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("X-API-Key", "ltk_expired")
+		w := httptest.NewRecorder()
+		newRouter(new(MockAuthService), keys).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.JSONEq(t, `{"error":"API key expired"}`, w.Body.String())
+	})
+
+	t.Run("Keys Not Accepted Without Service", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("X-API-Key", "ltk_good")
+		w := httptest.NewRecorder()
+		newRouter(new(MockAuthService), nil).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.JSONEq(t, `{"error":"authorization header missing"}`, w.Body.String())
+	})
+}
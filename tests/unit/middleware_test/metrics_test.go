@@ -0,0 +1,49 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/middleware"
+)
+
+type observedRequest struct {
+	method string
+	route  string
+	status int
+}
+
+type fakeRequestObserver struct {
+	requests []observedRequest
+}
+
+func (o *fakeRequestObserver) ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	o.requests = append(o.requests, observedRequest{method: method, route: route, status: status})
+}
+
+func TestRequestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	obs := &fakeRequestObserver{}
+	router := gin.New()
+	router.Use(middleware.RequestMetrics(obs))
+	router.GET("/urls/:id", func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+	})
+
+	for _, path := range []string{"/urls/1", "/urls/2", "/nowhere/3"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	require.Len(t, obs.requests, 3)
+	assert.Equal(t, observedRequest{method: http.MethodGet, route: "/urls/:id", status: http.StatusAccepted}, obs.requests[0])
+	assert.Equal(t, "/urls/:id", obs.requests[1].route)
+	assert.Equal(t, observedRequest{method: http.MethodGet, route: "unmatched", status: http.StatusNotFound}, obs.requests[2])
+}
@@ -0,0 +1,57 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/middleware"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(incoming string) (header, inContext string) {
+		router := gin.New()
+		router.Use(middleware.RequestID())
+		router.GET("/ping", func(c *gin.Context) {
+			inContext = c.GetString("request_id")
+			c.Status(http.StatusNoContent)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		if incoming != "" {
+			req.Header.Set(middleware.RequestIDHeader, incoming)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Header().Get(middleware.RequestIDHeader), inContext
+	}
+
+	t.Run("Generates UUID", func(t *testing.T) {
+		header, inContext := serve("")
+		_, err := uuid.Parse(header)
+		require.NoError(t, err)
+		assert.Equal(t, header, inContext)
+
+		other, _ := serve("")
+		assert.NotEqual(t, header, other)
+	})
+
+	t.Run("Honors Incoming ID", func(t *testing.T) {
+		header, inContext := serve("trace-abc")
+		assert.Equal(t, "trace-abc", header)
+		assert.Equal(t, "trace-abc", inContext)
+	})
+
+	t.Run("Replaces Oversized ID", func(t *testing.T) {
+		header, _ := serve(strings.Repeat("x", 500))
+		_, err := uuid.Parse(header)
+		assert.NoError(t, err)
+	})
+}
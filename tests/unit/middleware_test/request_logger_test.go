@@ -0,0 +1,97 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/middleware"
+)
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(level, path string, requestID string) (*httptest.ResponseRecorder, map[string]any, string) {
+		var buf bytes.Buffer
+		router := gin.New()
+		router.Use(middleware.RequestID(), middleware.RequestLogger(middleware.NewLogger(&buf, level, "json")))
+		router.GET("/urls/:id", func(c *gin.Context) {
+			c.Set("user_id", uint(7))
+			c.JSON(http.StatusOK, gin.H{"request_id": c.GetString("request_id")})
+		})
+		router.GET("/broken", func(c *gin.Context) {
+			c.Status(http.StatusInternalServerError)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if requestID != "" {
+			req.Header.Set(middleware.RequestIDHeader, requestID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var record map[string]any
+		if buf.Len() > 0 {
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		}
+		return w, record, buf.String()
+	}
+
+	t.Run("Logs Request Fields", func(t *testing.T) {
+		w, record, _ := serve("info", "/urls/3?page=2", "")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		requestID := w.Header().Get(middleware.RequestIDHeader)
+		require.NotEmpty(t, requestID)
+		assert.Contains(t, w.Body.String(), requestID, "the handler sees the ID in the context")
+
+		assert.Equal(t, "INFO", record["level"])
+		assert.Equal(t, "request", record["msg"])
+		assert.Equal(t, "GET", record["method"])
+		assert.Equal(t, "/urls/3", record["path"])
+		assert.Equal(t, float64(200), record["status"])
+		assert.Equal(t, float64(7), record["user_id"])
+		assert.Equal(t, requestID, record["request_id"])
+		assert.Contains(t, record, "latency_ms")
+		assert.NotContains(t, record, "query")
+	})
+
+	t.Run("Propagates Incoming Request ID", func(t *testing.T) {
+		w, record, _ := serve("info", "/urls/3", "trace-abc")
+		assert.Equal(t, "trace-abc", w.Header().Get(middleware.RequestIDHeader))
+		assert.Equal(t, "trace-abc", record["request_id"])
+	})
+
+	t.Run("Server Errors At Error Level Without User", func(t *testing.T) {
+		_, record, _ := serve("info", "/broken", "")
+		assert.Equal(t, "ERROR", record["level"])
+		assert.NotContains(t, record, "user_id")
+	})
+
+	t.Run("Debug Adds Details", func(t *testing.T) {
+		_, record, _ := serve("debug", "/urls/3?page=2", "")
+		assert.Equal(t, "page=2", record["query"])
+		assert.Contains(t, record, "client_ip")
+		assert.Contains(t, record, "user_agent")
+	})
+
+	t.Run("Below Level Is Dropped", func(t *testing.T) {
+		_, _, out := serve("warn", "/urls/3", "")
+		assert.Empty(t, out)
+	})
+
+	t.Run("Text Format", func(t *testing.T) {
+		var buf bytes.Buffer
+		router := gin.New()
+		router.Use(middleware.RequestID(), middleware.RequestLogger(middleware.NewLogger(&buf, "info", "text")))
+		router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ping", nil))
+		assert.Contains(t, buf.String(), "method=GET path=/ping status=204")
+	})
+}
@@ -0,0 +1,96 @@
+package middleware_test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fuzumoe/linkTorch-api/internal/middleware"
+)
+
+func TestSlowRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	router := gin.New()
+	router.Use(middleware.SlowRequestLogger(20 * time.Millisecond))
+	router.GET("/slow/:id", func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+		time.Sleep(40 * time.Millisecond)
+		c.Status(http.StatusOK)
+	})
+	router.GET("/fast", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("Slow Request Logs Warning", func(t *testing.T) {
+		buf.Reset()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/slow/1", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		out := buf.String()
+		assert.Contains(t, out, "[WARN] slow request")
+		assert.Contains(t, out, "GET /slow/:id")
+		assert.Contains(t, out, "user_id=7")
+	})
+
+	t.Run("Fast Request Is Silent", func(t *testing.T) {
+		buf.Reset()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fast", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("Zero Threshold Disables", func(t *testing.T) {
+		buf.Reset()
+		r := gin.New()
+		r.Use(middleware.SlowRequestLogger(0))
+		r.GET("/slow", func(c *gin.Context) {
+			time.Sleep(5 * time.Millisecond)
+			c.Status(http.StatusOK)
+		})
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+		assert.Empty(t, buf.String())
+	})
+	t.Run("Long-Lived Requests Are Not Timed", func(t *testing.T) {
+		buf.Reset()
+		r := gin.New()
+		r.Use(middleware.SlowRequestLogger(20*time.Millisecond, "/urls/:id/wait"))
+		r.GET("/urls/:id/wait", func(c *gin.Context) {
+			time.Sleep(40 * time.Millisecond)
+			c.JSON(http.StatusOK, gin.H{"status": "done"})
+		})
+		r.GET("/events", func(c *gin.Context) {
+			time.Sleep(40 * time.Millisecond)
+			c.SSEvent("result", "done")
+		})
+		r.GET("/ws", func(c *gin.Context) {
+			time.Sleep(40 * time.Millisecond)
+			c.Status(http.StatusSwitchingProtocols)
+		})
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/urls/1/wait", nil))
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/events", nil))
+		ws := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		ws.Header.Set("Connection", "Upgrade")
+		ws.Header.Set("Upgrade", "websocket")
+		r.ServeHTTP(httptest.NewRecorder(), ws)
+		assert.Empty(t, buf.String())
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ws", nil))
+		assert.Contains(t, buf.String(), "GET /ws", "only upgrades are exempt")
+	})
+}
@@ -0,0 +1,71 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/fuzumoe/linkTorch-api/internal/middleware"
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/service"
+)
+
+// verifiedUsers answers Get from a fixed set of users; the rest of
+// service.UserService is not used by RequireVerifiedEmail.
+type verifiedUsers struct {
+	service.UserService
+	users map[uint]*model.UserDTO
+}
+
+func (v *verifiedUsers) Get(id uint) (*model.UserDTO, error) {
+	if u, ok := v.users[id]; ok {
+		return u, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	users := &verifiedUsers{users: map[uint]*model.UserDTO{
+		1: {ID: 1, EmailVerified: true},
+		2: {ID: 2, EmailVerified: false},
+	}}
+
+	tests := []struct {
+		name         string
+		enabled      bool
+		userID       any
+		expectedCode int
+	}{
+		{name: "Verified", enabled: true, userID: uint(1), expectedCode: http.StatusOK},
+		{name: "Unverified", enabled: true, userID: uint(2), expectedCode: http.StatusForbidden},
+		{name: "Unknown User", enabled: true, userID: uint(3), expectedCode: http.StatusUnauthorized},
+		{name: "Not Authenticated", enabled: true, expectedCode: http.StatusUnauthorized},
+		{name: "Disabled", enabled: false, userID: uint(2), expectedCode: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tc.userID != nil {
+					c.Set("user_id", tc.userID)
+				}
+				c.Next()
+			})
+			router.Use(middleware.RequireVerifiedEmail(users, tc.enabled))
+			router.GET("/urls", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/urls", nil))
+
+			assert.Equal(t, tc.expectedCode, w.Code)
+		})
+	}
+}
@@ -13,9 +13,13 @@ func TestAllModelsContainsExpectedTypes(t *testing.T) {
 	expected := []string{
 		"User",
 		"URL",
+		"URLTag",
 		"AnalysisResult",
 		"Link",
 		"BlacklistedToken",
+		"IssuedToken",
+		"NotificationPrefs",
+		"APIKey",
 	}
 
 	var actual []string
@@ -136,6 +136,7 @@ func TestURL(t *testing.T) {
 		assert.Equal(t, input.UserID, u.UserID, "UserID should match")
 		assert.Equal(t, input.OriginalURL, u.OriginalURL, "OriginalURL should match")
 		assert.Equal(t, model.StatusQueued, u.Status, "Status should default to 'queued'")
+		assert.Equal(t, model.CrawlMethodFull, u.CrawlMethod, "CrawlMethod should default to 'full'")
 		assert.NotZero(t, u.CreatedAt, "CreatedAt should be set")
 		assert.NotZero(t, u.UpdatedAt, "UpdatedAt should be set")
 	})
@@ -180,6 +181,24 @@ func TestURL(t *testing.T) {
 		assert.Equal(t, "running", input.Status)
 	})
 
+	t.Run("UpdateURL Crawl Method", func(t *testing.T) {
+		bind := func(body string) (model.UpdateURLInput, error) {
+			var input model.UpdateURLInput
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request, _ = http.NewRequest("POST", "/", bytes.NewBufferString(body))
+			ctx.Request.Header.Set("Content-Type", "application/json")
+			err := ctx.ShouldBindJSON(&input)
+			return input, err
+		}
+
+		input, err := bind(`{"crawl_method": "head_only"}`)
+		assert.NoError(t, err)
+		assert.Equal(t, model.CrawlMethodHeadOnly, input.CrawlMethod)
+
+		_, err = bind(`{"crawl_method": "ping"}`)
+		assert.Error(t, err, "Unknown crawl methods should be rejected")
+	})
+
 	t.Run("UpdateURL Invalid Input", func(t *testing.T) {
 		invalidJSON := `{"original_url": "not-a-url", "status": "invalid"}`
 		var input model.UpdateURLInput
@@ -0,0 +1,104 @@
+package report_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/report"
+)
+
+// pageCount counts page objects, excluding the /Pages tree node.
+func pageCount(pdf []byte) int {
+	return len(regexp.MustCompile(`/Type /Page\b[^s]`).FindAll(pdf, -1))
+}
+
+// contents inflates the page content streams of pdf.
+func contents(t *testing.T, pdf []byte) string {
+	t.Helper()
+	var out bytes.Buffer
+	for _, m := range regexp.MustCompile(`(?s)stream\n(.*?)\nendstream`).FindAllSubmatch(pdf, -1) {
+		r, err := zlib.NewReader(bytes.NewReader(m[1]))
+		if err != nil {
+			continue // not a compressed stream
+		}
+		_, err = io.Copy(&out, r)
+		require.NoError(t, err)
+	}
+	return out.String()
+}
+
+func TestURLReport_PDF(t *testing.T) {
+	analysis := &model.AnalysisResult{
+		ID:                3,
+		HTMLVersion:       "HTML 5",
+		Title:             "Docs (beta) \\ overview",
+		H1Count:           1,
+		H2Count:           4,
+		InternalLinkCount: 12,
+		ExternalLinkCount: 3,
+		BrokenLinkCount:   2,
+		CreatedAt:         time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("Latest Analysis", func(t *testing.T) {
+		r := &report.URLReport{
+			URL:      "https://docs.example.com",
+			Status:   model.StatusDone,
+			Analysis: analysis,
+			BrokenLinks: []model.Link{
+				{Href: "https://docs.example.com/gone", StatusCode: 404},
+				{Href: "https://docs.example.com/error", StatusCode: 500},
+			},
+			BrokenTotal: 2,
+			GeneratedAt: time.Now(),
+		}
+		pdf, err := r.PDF()
+		require.NoError(t, err)
+
+		assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+		assert.True(t, bytes.HasSuffix(bytes.TrimSpace(pdf), []byte("%%EOF")))
+		text := contents(t, pdf)
+		assert.Contains(t, text, `(Docs \(beta\) \\ overview)Tj`, "Title is escaped")
+		assert.Contains(t, text, "(https://docs.example.com/gone)Tj")
+		assert.Contains(t, text, "(500)Tj")
+		assert.Equal(t, 1, pageCount(pdf))
+	})
+
+	t.Run("Many Broken Links", func(t *testing.T) {
+		links := make([]model.Link, report.MaxBrokenLinks)
+		for i := range links {
+			links[i] = model.Link{Href: fmt.Sprintf("https://docs.example.com/%d", i), StatusCode: 404}
+		}
+		r := &report.URLReport{
+			URL:         "https://docs.example.com",
+			Status:      model.StatusDone,
+			Analysis:    analysis,
+			BrokenLinks: links,
+			BrokenTotal: report.MaxBrokenLinks + 25,
+			GeneratedAt: time.Now(),
+		}
+		pdf, err := r.PDF()
+		require.NoError(t, err)
+
+		assert.Greater(t, pageCount(pdf), 1, "Long tables continue on new pages")
+		assert.Contains(t, contents(t, pdf), "(... and 25 more)Tj")
+	})
+
+	t.Run("Never Crawled", func(t *testing.T) {
+		r := &report.URLReport{URL: "https://new.example.com", Status: model.StatusQueued, GeneratedAt: time.Now()}
+		pdf, err := r.PDF()
+		require.NoError(t, err)
+
+		assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+		assert.Contains(t, contents(t, pdf), "No analysis is available")
+	})
+}
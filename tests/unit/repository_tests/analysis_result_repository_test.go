@@ -49,22 +49,40 @@ func TestAnalysisResultRepo(t *testing.T) {
 
 		mock.ExpectBegin()
 		exec := mock.ExpectExec(regexp.QuoteMeta(
-			"INSERT INTO `analysis_results` (`url_id`,`html_version`,`title`,`h1_count`,`h2_count`,`h3_count`,`h4_count`,`h5_count`,`h6_count`,`has_login_form`,`internal_link_count`,`external_link_count`,`broken_link_count`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)",
+			"INSERT INTO `analysis_results` (`url_id`,`html_version`,`content_type`,`status_code`,`response_time_ms`,`page_size_bytes`,`title`,`title_source`,`meta_description`,`language`,`h1_count`,`h2_count`,`h3_count`,`h4_count`,`h5_count`,`h6_count`,`image_count`,`images_missing_alt`,`has_login_form`,`internal_link_count`,`external_link_count`,`broken_link_count`,`redirect_chain`,`final_url`,`canonical_url`,`canonical_mismatch`,`crawl_duration_ms`,`tls_verify_skipped`,`raw_html_key`,`run_id`,`provenance`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)",
 		))
 		exec.WithArgs(
 			testResult.URLID,
 			testResult.HTMLVersion,
+			testResult.ContentType,
+			testResult.StatusCode,
+			testResult.ResponseTimeMs,
+			testResult.PageSizeBytes,
 			testResult.Title,
+			testResult.TitleSource,
+			testResult.MetaDescription,
+			testResult.Language,
 			testResult.H1Count,
 			testResult.H2Count,
 			testResult.H3Count,
 			testResult.H4Count,
 			testResult.H5Count,
 			testResult.H6Count,
+			testResult.ImageCount,
+			testResult.ImagesMissingAlt,
 			testResult.HasLoginForm,
 			0,
 			0,
 			0,
+			nil,
+			"",
+			"",
+			false,
+			nil,
+			false,
+			"",
+			nil,
+			nil,
 			sqlmock.AnyArg(),
 			sqlmock.AnyArg(),
 			sqlmock.AnyArg(),
@@ -162,4 +180,35 @@ func TestAnalysisResultRepo(t *testing.T) {
 		assert.Equal(t, "Fourth Analysis", results[1].Title)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("ResponseTimes", func(t *testing.T) {
+		db, mock := setupAnaMockDB(t)
+		repo := repository.NewAnalysisResultRepo(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `urls`")).
+			WithArgs(uint(3), uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT CASE WHEN response_time_ms > 0 THEN response_time_ms ELSE crawl_duration_ms END FROM `analysis_results` " +
+				"WHERE (url_id = ? AND (response_time_ms > 0 OR crawl_duration_ms IS NOT NULL))",
+		)).WithArgs(uint(3)).WillReturnRows(sqlmock.NewRows([]string{"ms"}).AddRow(120).AddRow(450))
+
+		times, err := repo.ResponseTimes(3, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{120, 450}, times)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ResponseTimes_NotOwned", func(t *testing.T) {
+		db, mock := setupAnaMockDB(t)
+		repo := repository.NewAnalysisResultRepo(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `urls`")).
+			WithArgs(uint(3), uint(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		_, err := repo.ResponseTimes(3, 2)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
@@ -0,0 +1,125 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+)
+
+func TestAPIKeyRepo(t *testing.T) {
+	columns := []string{"id", "user_id", "name", "key_hash", "prefix", "last_used_at", "revoked", "created_at"}
+
+	t.Run("Create", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewAPIKeyRepo(db)
+		key := &model.APIKey{UserID: 7, Name: "ci", KeyHash: "abc", Prefix: "ltk_0123abcd"}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(
+			"INSERT INTO `api_keys` (`user_id`,`name`,`key_hash`,`prefix`,`last_used_at`,`revoked`,`expires_at`,`created_at`) VALUES (?,?,?,?,?,?,?,?)",
+		)).WithArgs(uint(7), "ci", "abc", "ltk_0123abcd", nil, false, nil, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(4, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.Create(key))
+		assert.Equal(t, uint(4), key.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindActiveByHash", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewAPIKeyRepo(db)
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `api_keys` WHERE key_hash = ? AND revoked = ? ORDER BY `api_keys`.`id` LIMIT ?",
+		)).WithArgs("abc", false, 1).WillReturnRows(
+			sqlmock.NewRows(columns).AddRow(4, 7, "ci", "abc", "ltk_0123abcd", nil, false, time.Now()),
+		)
+
+		key, err := repo.FindActiveByHash("abc")
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), key.UserID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindActiveByHash Not Found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewAPIKeyRepo(db)
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `api_keys` WHERE key_hash = ? AND revoked = ?",
+		)).WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.FindActiveByHash("abc")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListByUser", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewAPIKeyRepo(db)
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `api_keys` WHERE user_id = ? ORDER BY id DESC",
+		)).WithArgs(uint(7)).WillReturnRows(
+			sqlmock.NewRows(columns).
+				AddRow(5, 7, "deploy", "def", "ltk_4567abcd", nil, true, time.Now()).
+				AddRow(4, 7, "ci", "abc", "ltk_0123abcd", time.Now(), false, time.Now()),
+		)
+
+		keys, err := repo.ListByUser(7)
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.True(t, keys[0].Revoked)
+		assert.NotNil(t, keys[1].LastUsedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewAPIKeyRepo(db)
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `api_keys` WHERE id = ? AND user_id = ? ORDER BY `api_keys`.`id` LIMIT ?",
+		)).WithArgs(uint(4), uint(7), 1).WillReturnRows(
+			sqlmock.NewRows(columns).AddRow(4, 7, "ci", "abc", "ltk_0123abcd", nil, false, time.Now()),
+		)
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(
+			"UPDATE `api_keys` SET `revoked`=? WHERE `id` = ?",
+		)).WithArgs(true, uint(4)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.Revoke(4, 7))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Revoke Other User's Key", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewAPIKeyRepo(db)
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `api_keys` WHERE id = ? AND user_id = ?",
+		)).WithArgs(uint(4), uint(8), 1).WillReturnRows(sqlmock.NewRows(columns))
+
+		assert.ErrorIs(t, repo.Revoke(4, 8), gorm.ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("TouchLastUsed", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewAPIKeyRepo(db)
+		at := time.Now()
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(
+			"UPDATE `api_keys` SET `last_used_at`=? WHERE `id` = ?",
+		)).WithArgs(at, uint(4)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, repo.TouchLastUsed(4, at))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
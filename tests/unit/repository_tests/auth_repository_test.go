@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	mysqlerr "github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/mysql"
@@ -117,6 +118,9 @@ func TestTokenRepo(t *testing.T) {
 			sqlmock.AnyArg(),
 			sqlmock.AnyArg(),
 		).WillReturnResult(sqlmock.NewResult(0, 5))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"DELETE FROM `issued_tokens` WHERE expires_at < ?",
+		)).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 5))
 		mock.ExpectCommit()
 
 		err := repo.RemoveExpired()
@@ -135,6 +139,9 @@ func TestTokenRepo(t *testing.T) {
 			sqlmock.AnyArg(),
 			sqlmock.AnyArg(),
 		).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"DELETE FROM `issued_tokens` WHERE expires_at < ?",
+		)).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
 		mock.ExpectCommit()
 
 		err := repo.RemoveExpired()
@@ -185,4 +192,131 @@ func TestTokenRepo(t *testing.T) {
 		assert.False(t, testToken.CreatedAt.IsZero(), "CreatedAt should be set")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("AddIssued", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepo(db)
+		expiry := time.Now().Add(time.Hour)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(
+			"INSERT INTO `issued_tokens` (`jti`,`user_id`,`subject`,`expires_at`,`created_at`) VALUES (?,?,?,?,?),(?,?,?,?,?)",
+		)).WithArgs(
+			"access-1", uint(7), "access_token", expiry, sqlmock.AnyArg(),
+			"refresh-1", uint(7), "refresh_token", expiry, sqlmock.AnyArg(),
+		).WillReturnResult(sqlmock.NewResult(1, 2))
+		mock.ExpectCommit()
+
+		err := repo.AddIssued(
+			&model.IssuedToken{JTI: "access-1", UserID: 7, Subject: "access_token", ExpiresAt: expiry},
+			&model.IssuedToken{JTI: "refresh-1", UserID: 7, Subject: "refresh_token", ExpiresAt: expiry},
+		)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RotateRefresh", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepo(db)
+		oldExpiry := time.Now().Add(time.Hour)
+		next := &model.IssuedToken{JTI: "refresh-2", UserID: 7, Subject: "refresh_token", ExpiresAt: time.Now().Add(2 * time.Hour)}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(
+			"INSERT INTO `blacklisted_tokens` (`jti`,`expires_at`,`created_at`,`deleted_at`) VALUES (?,?,?,?)",
+		)).WithArgs("refresh-1", oldExpiry, sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"INSERT INTO `issued_tokens` (`jti`,`user_id`,`subject`,`expires_at`,`created_at`) VALUES (?,?,?,?,?)",
+		)).WithArgs("refresh-2", uint(7), "refresh_token", next.ExpiresAt, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.RotateRefresh("refresh-1", oldExpiry, next))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RotateRefresh Already Used", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepo(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `blacklisted_tokens`")).
+			WillReturnError(&mysqlerr.MySQLError{Number: 1062, Message: "Duplicate entry"})
+		mock.ExpectRollback()
+
+		err := repo.RotateRefresh("refresh-1", time.Now(), &model.IssuedToken{JTI: "refresh-2", UserID: 7})
+		assert.ErrorIs(t, err, repository.ErrRefreshTokenUsed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Consume", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepo(db)
+		expiry := time.Now().Add(30 * time.Minute)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(
+			"INSERT INTO `blacklisted_tokens` (`jti`,`expires_at`,`created_at`,`deleted_at`) VALUES (?,?,?,?)",
+		)).WithArgs("reset-1", expiry, sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.Consume("reset-1", expiry))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Consume Already Used", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepo(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `blacklisted_tokens`")).
+			WillReturnError(&mysqlerr.MySQLError{Number: 1062, Message: "Duplicate entry"})
+		mock.ExpectRollback()
+
+		err := repo.Consume("reset-1", time.Now())
+		assert.ErrorIs(t, err, repository.ErrTokenUsed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RevokeUserTokens", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepo(db)
+		expiry := time.Now().Add(time.Hour)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `issued_tokens` WHERE (user_id = ? AND expires_at > ?) AND subject = ?",
+		)).WithArgs(uint(7), sqlmock.AnyArg(), "refresh_token").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "jti", "user_id", "subject", "expires_at"}).
+				AddRow(1, "refresh-1", 7, "refresh_token", expiry).
+				AddRow(2, "refresh-2", 7, "refresh_token", expiry))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"INSERT INTO `blacklisted_tokens` (`jti`,`expires_at`,`created_at`,`deleted_at`) VALUES (?,?,?,?),(?,?,?,?) ON DUPLICATE KEY UPDATE `id`=`id`",
+		)).WithArgs("refresh-1", expiry, sqlmock.AnyArg(), nil, "refresh-2", expiry, sqlmock.AnyArg(), nil).
+			WillReturnResult(sqlmock.NewResult(10, 2))
+		mock.ExpectCommit()
+
+		n, err := repo.RevokeUserTokens(7, "refresh_token")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RevokeUserTokens All Subjects None Left", func(t *testing.T) {
+		db, mock := setupTokenMockDB(t)
+		repo := repository.NewTokenRepo(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `issued_tokens` WHERE user_id = ? AND expires_at > ?")).
+			WithArgs(uint(7), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "jti", "user_id", "subject", "expires_at"}))
+		mock.ExpectCommit()
+
+		n, err := repo.RevokeUserTokens(7, "")
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
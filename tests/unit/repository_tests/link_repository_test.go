@@ -2,6 +2,7 @@ package repository_test
 
 import (
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -41,12 +42,14 @@ func TestLinkRepo(t *testing.T) {
 
 		mock.ExpectBegin()
 		mock.ExpectExec(regexp.QuoteMeta(
-			"INSERT INTO `links` (`url_id`,`href`,`is_external`,`status_code`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?)",
+			"INSERT INTO `links` (`url_id`,`analysis_result_id`,`href`,`is_external`,`status_code`,`occurrence_count`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?,?,?)",
 		)).WithArgs(
 			testLink.URLID,
+			nil,
 			testLink.Href,
 			testLink.IsExternal,
 			testLink.StatusCode,
+			testLink.OccurrenceCount,
 			sqlmock.AnyArg(),
 			sqlmock.AnyArg(),
 			sqlmock.AnyArg(),
@@ -120,12 +123,14 @@ func TestLinkRepo(t *testing.T) {
 
 		mock.ExpectBegin()
 		mock.ExpectExec(regexp.QuoteMeta(
-			"UPDATE `links` SET `url_id`=?,`href`=?,`is_external`=?,`status_code`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `links`.`deleted_at` IS NULL AND `id` = ?",
+			"UPDATE `links` SET `url_id`=?,`analysis_result_id`=?,`href`=?,`is_external`=?,`status_code`=?,`occurrence_count`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `links`.`deleted_at` IS NULL AND `id` = ?",
 		)).WithArgs(
 			testLink.URLID,
+			nil,
 			testLink.Href,
 			testLink.IsExternal,
 			testLink.StatusCode,
+			testLink.OccurrenceCount,
 			testLink.CreatedAt,
 			sqlmock.AnyArg(),
 			nil,
@@ -192,4 +197,93 @@ func TestLinkRepo(t *testing.T) {
 		assert.Equal(t, 0, count)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("StatusSummary", func(t *testing.T) {
+		db, mock := setupLinkMockDB(t)
+		repo := repository.NewLinkRepo(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT count(*) FROM `urls` WHERE (id = ? AND user_id = ?) AND `urls`.`deleted_at` IS NULL",
+		)).WithArgs(42, 7).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(1))
+		mock.ExpectQuery("SELECT CASE(.|\\n)+FROM `links` WHERE url_id = \\? AND `links`.`deleted_at` IS NULL GROUP BY `class`").
+			WithArgs(42).
+			WillReturnRows(sqlmock.NewRows([]string{"class", "count"}).
+				AddRow("2xx", 4).
+				AddRow("3xx", 1).
+				AddRow("4xx", 2).
+				AddRow("unreachable", 3))
+
+		summary, err := repo.StatusSummary(42, 7)
+		require.NoError(t, err)
+		assert.Equal(t, &model.LinkStatusSummaryDTO{
+			URLID:       42,
+			Status2xx:   4,
+			Status3xx:   1,
+			Status4xx:   2,
+			Unreachable: 3,
+			Total:       10,
+		}, summary)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("StatusSummary_NotOwned", func(t *testing.T) {
+		db, mock := setupLinkMockDB(t)
+		repo := repository.NewLinkRepo(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT count(*) FROM `urls` WHERE (id = ? AND user_id = ?) AND `urls`.`deleted_at` IS NULL",
+		)).WithArgs(42, 8).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(0))
+
+		_, err := repo.StatusSummary(42, 8)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CountByUser_HrefPatternAndStatusClass", func(t *testing.T) {
+		db, mock := setupLinkMockDB(t)
+		repo := repository.NewLinkRepo(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT count(*) FROM `links` JOIN urls ON urls.id = links.url_id AND urls.deleted_at IS NULL "+
+				"WHERE urls.user_id = ? AND links.href LIKE ? AND (links.status_code BETWEEN 400 AND 499) AND `links`.`deleted_at` IS NULL",
+		)).WithArgs(7, "%/old/%").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(3))
+
+		count, err := repo.CountByUser(7, repository.LinkFilter{HrefPattern: "%/old/%", StatusClass: "4xx"})
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DomainImpact_MatchesDomainLiterally", func(t *testing.T) {
+		db, mock := setupLinkMockDB(t)
+		repo := repository.NewLinkRepo(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) AS links")).
+			WithArgs(true, "my_site%.com", `%.my\_site\%.com`).
+			WillReturnRows(sqlmock.NewRows([]string{"links", "urls", "users"}).AddRow(2, 1, 1))
+
+		impact, err := repo.DomainImpact("my_site%.com")
+		require.NoError(t, err)
+		assert.Equal(t, 2, impact.Links)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListByUser_InvalidFilter", func(t *testing.T) {
+		db, mock := setupLinkMockDB(t)
+		repo := repository.NewLinkRepo(db)
+
+		for _, f := range []repository.LinkFilter{
+			{StatusClass: "404"},
+			{StatusClass: "4xx' OR '1'='1"},
+			{HrefPattern: "%\\%"},
+			{HrefPattern: "%/old/% OR 1=1"},
+			{HrefPattern: "%" + strings.Repeat("a", repository.MaxHrefPatternLength)},
+		} {
+			_, err := repo.ListByUser(7, f, repository.Pagination{})
+			assert.ErrorIs(t, err, repository.ErrInvalidLinkFilter)
+			_, err = repo.CountByUser(7, f)
+			assert.ErrorIs(t, err, repository.ErrInvalidLinkFilter)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet(), "no query is sent for an invalid filter")
+	})
 }
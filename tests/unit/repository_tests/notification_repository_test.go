@@ -0,0 +1,66 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+)
+
+func TestNotificationRepo(t *testing.T) {
+	t.Run("FindByUser Not Found", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewNotificationRepo(db)
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `notification_prefs` WHERE user_id = ? ORDER BY `notification_prefs`.`user_id` LIMIT ?",
+		)).WithArgs(uint(3), 1).WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+
+		_, err := repo.FindByUser(3)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindByUser", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewNotificationRepo(db)
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `notification_prefs` WHERE user_id = ? ORDER BY `notification_prefs`.`user_id` LIMIT ?",
+		)).WithArgs(uint(3), 1).WillReturnRows(
+			sqlmock.NewRows([]string{"user_id", "digest_frequency", "alert_channels", "webhook_enabled"}).
+				AddRow(3, "weekly", `["email","webhook"]`, true),
+		)
+
+		prefs, err := repo.FindByUser(3)
+		require.NoError(t, err)
+		assert.Equal(t, "weekly", prefs.DigestFrequency)
+		assert.Equal(t, []string{"email", "webhook"}, prefs.AlertChannels)
+		assert.True(t, prefs.WebhookEnabled)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Save Upserts", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewNotificationRepo(db)
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(
+			"INSERT INTO `notification_prefs` (`user_id`,`digest_frequency`,`alert_channels`,`webhook_enabled`,`updated_at`) VALUES (?,?,?,?,?) ON DUPLICATE KEY UPDATE",
+		)).WithArgs(uint(3), "daily", `["webhook"]`, true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Save(&model.NotificationPrefs{
+			UserID:          3,
+			DigestFrequency: "daily",
+			AlertChannels:   []string{"webhook"},
+			WebhookEnabled:  true,
+		})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,56 @@
+package repository_test
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+)
+
+func TestStatsRepo_System(t *testing.T) {
+	db, mock := setupLinkMockDB(t)
+	repo := repository.NewStatsRepo(db)
+	since := time.Now().Add(-24 * time.Hour)
+
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT count(*) FROM `users` WHERE `users`.`deleted_at` IS NULL",
+	)).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(3))
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT count(*) FROM `analysis_results` WHERE created_at >= ? AND `analysis_results`.`deleted_at` IS NULL",
+	)).WithArgs(since).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(6))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) AS total,(.|\\n)+FROM `urls` WHERE `urls`.`deleted_at` IS NULL").
+		WithArgs(model.StatusDone, model.StatusError, model.StatusSkipped, model.StatusError).
+		WillReturnRows(sqlmock.NewRows([]string{"total", "crawled", "errored"}).AddRow(10, 8, 2))
+
+	stats, err := repo.System(since)
+	require.NoError(t, err)
+	assert.Equal(t, &model.SystemStatsDTO{
+		TotalUsers:  3,
+		TotalURLs:   10,
+		Crawls24h:   6,
+		CrawledURLs: 8,
+		ErroredURLs: 2,
+	}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStatsRepo_RecentCrawlDuration(t *testing.T) {
+	db, mock := setupLinkMockDB(t)
+	repo := repository.NewStatsRepo(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(
+		"SELECT COALESCE(AVG(crawl_duration_ms), 0) AS avg_ms, COUNT(*) AS samples FROM (SELECT `crawl_duration_ms` FROM `analysis_results` WHERE crawl_duration_ms IS NOT NULL AND `analysis_results`.`deleted_at` IS NULL ORDER BY id DESC LIMIT ?) AS recent",
+	)).WithArgs(100).WillReturnRows(sqlmock.NewRows([]string{"avg_ms", "samples"}).AddRow(1250.5, 40))
+
+	avg, samples, err := repo.RecentCrawlDuration(100)
+	require.NoError(t, err)
+	assert.Equal(t, 1250500*time.Microsecond, avg)
+	assert.Equal(t, 40, samples)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	mysqlerr "github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/mysql"
@@ -40,12 +41,14 @@ func TestURLRepo(t *testing.T) {
 
 		mock.ExpectBegin()
 		exec := mock.ExpectExec(regexp.QuoteMeta(
-			"INSERT INTO `urls` (`user_id`,`original_url`,`status`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?)",
+			"INSERT INTO `urls` (`user_id`,`original_url`,`status`,`host_limit`,`crawl_method`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?,?)",
 		))
 		exec.WithArgs(
 			testURL.UserID,
 			testURL.OriginalURL,
 			"queued",
+			0,
+			"full",
 			sqlmock.AnyArg(),
 			sqlmock.AnyArg(),
 			sqlmock.AnyArg(),
@@ -58,6 +61,52 @@ func TestURLRepo(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("CreateBatch", func(t *testing.T) {
+		insert := "INSERT INTO `urls` (`user_id`,`original_url`,`status`,`host_limit`,`crawl_method`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?,?),(?,?,?,?,?,?,?,?)"
+		batch := func() []*model.URL {
+			return []*model.URL{
+				{UserID: 42, OriginalURL: "https://a.example"},
+				{UserID: 42, OriginalURL: "https://b.example"},
+			}
+		}
+
+		t.Run("Success", func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			repo := repository.NewURLRepo(db)
+			urls := batch()
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(insert)).
+				WillReturnResult(sqlmock.NewResult(5, 2))
+			mock.ExpectCommit()
+
+			require.NoError(t, repo.CreateBatch(urls))
+			assert.Equal(t, uint(5), urls[0].ID)
+			assert.Equal(t, uint(6), urls[1].ID)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Rolled Back On Error", func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			repo := repository.NewURLRepo(db)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(insert)).
+				WillReturnError(errors.New("duplicate entry"))
+			mock.ExpectRollback()
+
+			assert.Error(t, repo.CreateBatch(batch()))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+
+		t.Run("Empty", func(t *testing.T) {
+			db, mock := setupMockDB(t)
+			repo := repository.NewURLRepo(db)
+			assert.NoError(t, repo.CreateBatch(nil))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	})
+
 	t.Run("FindByID_Success", func(t *testing.T) {
 		db, mock := setupMockDB(t)
 		repo := repository.NewURLRepo(db)
@@ -80,11 +129,15 @@ func TestURLRepo(t *testing.T) {
 		mock.ExpectQuery(regexp.QuoteMeta(
 			"SELECT * FROM `links` WHERE `links`.`url_id` = ? AND `links`.`deleted_at` IS NULL",
 		)).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `url_tags` WHERE `url_tags`.`url_id` = ?",
+		)).WithArgs(id).WillReturnRows(sqlmock.NewRows([]string{"id", "url_id", "name"}).AddRow(1, id, "u.test"))
 
 		u, err := repo.FindByID(id)
 		assert.NoError(t, err)
 		assert.Equal(t, id, u.ID)
 		assert.Equal(t, uint(42), u.UserID)
+		assert.Equal(t, []string{"u.test"}, u.ToDTO().Tags)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
@@ -119,14 +172,108 @@ func TestURLRepo(t *testing.T) {
 					time.Date(2025, 7, 10, 1, 0, 0, 0, time.UTC),
 					time.Date(2025, 7, 10, 1, 0, 0, 0, time.UTC), nil),
 		)
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `url_tags` WHERE `url_tags`.`url_id` IN (?,?)",
+		)).WithArgs(1, 2).WillReturnRows(sqlmock.NewRows([]string{"id", "url_id", "name"}))
 
-		urls, err := repo.ListByUser(userID, pagination)
+		urls, err := repo.ListByUser(userID, repository.URLFilter{}, pagination)
 		assert.NoError(t, err)
 		assert.Len(t, urls, 2)
 		assert.Equal(t, "url1", urls[0].OriginalURL)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("ListByUser_Filtered", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+		pagination := repository.Pagination{Page: 2, PageSize: 5}
+		filter := repository.URLFilter{Status: model.StatusDone, Search: "example", Sort: "-updated_at"}
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `urls` WHERE user_id = ? AND status = ? AND original_url LIKE ? AND `urls`.`deleted_at` IS NULL ORDER BY `urls`.`updated_at` DESC LIMIT ? OFFSET ?",
+		)).WithArgs(5, model.StatusDone, "%example%", 5, 5).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "original_url", "status"}))
+
+		urls, err := repo.ListByUser(5, filter, pagination)
+		assert.NoError(t, err)
+		assert.Empty(t, urls)
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT count(*) FROM `urls` WHERE user_id = ? AND status = ? AND original_url LIKE ? AND `urls`.`deleted_at` IS NULL",
+		)).WithArgs(5, model.StatusDone, "%example%").
+			WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(6))
+
+		count, err := repo.CountByUser(5, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 6, count)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListByUser_StatusCode", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+		filter := repository.URLFilter{Status: model.StatusDone, StatusCode: 500}
+
+		mock.ExpectQuery(`SELECT .*FROM `+"`urls`"+` JOIN analysis_results latest_result ON latest_result.id = \(\s*`+
+			`SELECT MAX\(ar.id\) FROM analysis_results ar\s+WHERE ar.url_id = urls.id AND ar.deleted_at IS NULL\) `+
+			regexp.QuoteMeta("WHERE user_id = ? AND status = ? AND latest_result.status_code = ? AND `urls`.`deleted_at` IS NULL LIMIT ?")).
+			WithArgs(5, model.StatusDone, 500, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "original_url", "status"}).
+				AddRow(3, 5, "https://broken.example.com", model.StatusDone))
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `url_tags` WHERE `url_tags`.`url_id` = ?",
+		)).WithArgs(3).WillReturnRows(sqlmock.NewRows([]string{"id", "url_id", "name"}))
+
+		urls, err := repo.ListByUser(5, filter, repository.Pagination{})
+		assert.NoError(t, err)
+		if assert.Len(t, urls, 1) {
+			assert.Equal(t, uint(3), urls[0].ID)
+		}
+
+		mock.ExpectQuery(`SELECT count\(\*\) FROM `+"`urls`"+` JOIN analysis_results latest_result .*`+
+			regexp.QuoteMeta("WHERE user_id = ? AND status = ? AND latest_result.status_code = ? AND `urls`.`deleted_at` IS NULL")).
+			WithArgs(5, model.StatusDone, 500).
+			WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(1))
+
+		count, err := repo.CountByUser(5, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListByUser_Ascending", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `urls` WHERE user_id = ? AND `urls`.`deleted_at` IS NULL ORDER BY `urls`.`created_at` LIMIT ?",
+		)).WithArgs(5, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err := repo.ListByUser(5, repository.URLFilter{Sort: "created_at"}, repository.Pagination{})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListByUser_InvalidFilter", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+
+		for _, f := range []repository.URLFilter{
+			{Sort: "created_at; DROP TABLE urls"},
+			{Sort: "-password"},
+			{Status: "done' OR '1'='1"},
+			{StatusCode: 99},
+			{StatusCode: 600},
+		} {
+			_, err := repo.ListByUser(5, f, repository.Pagination{})
+			assert.ErrorIs(t, err, repository.ErrInvalidURLFilter)
+			_, err = repo.CountByUser(5, f)
+			assert.ErrorIs(t, err, repository.ErrInvalidURLFilter)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet(), "no query is sent for an invalid filter")
+	})
+
 	t.Run("Update", func(t *testing.T) {
 		db, mock := setupMockDB(t)
 		repo := repository.NewURLRepo(db)
@@ -142,9 +289,9 @@ func TestURLRepo(t *testing.T) {
 
 		mock.ExpectBegin()
 		mock.ExpectExec(regexp.QuoteMeta(
-			"UPDATE `urls` SET `user_id`=?,`original_url`=?,`status`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `urls`.`deleted_at` IS NULL AND `id` = ?",
+			"UPDATE `urls` SET `user_id`=?,`original_url`=?,`status`=?,`host_limit`=?,`crawl_method`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `urls`.`deleted_at` IS NULL AND `id` = ?",
 		)).WithArgs(
-			testURL.UserID, testURL.OriginalURL, testURL.Status,
+			testURL.UserID, testURL.OriginalURL, testURL.Status, testURL.HostLimit, testURL.CrawlMethod,
 			testURL.CreatedAt, sqlmock.AnyArg(), nil, testURL.ID,
 		).WillReturnResult(sqlmock.NewResult(0, 1))
 		mock.ExpectCommit()
@@ -184,6 +331,218 @@ func TestURLRepo(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("Merge", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(
+			"UPDATE `analysis_results` SET `url_id`=?,`updated_at`=? WHERE url_id = ? AND `analysis_results`.`deleted_at` IS NULL",
+		)).WithArgs(1, sqlmock.AnyArg(), 2).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"UPDATE `links` SET `url_id`=?,`updated_at`=? WHERE url_id = ? AND `links`.`deleted_at` IS NULL",
+		)).WithArgs(1, sqlmock.AnyArg(), 2).WillReturnResult(sqlmock.NewResult(0, 5))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"UPDATE `urls` SET `deleted_at`=? WHERE `urls`.`id` = ? AND `urls`.`deleted_at` IS NULL",
+		)).WithArgs(sqlmock.AnyArg(), 2).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.Merge(2, 1)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Merge_SourceMissing_RollsBack", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `analysis_results`")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `links`")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `urls` SET `deleted_at`")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.Merge(99, 1)
+		assert.EqualError(t, err, "url not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteErrored", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(
+			"UPDATE `urls` SET `deleted_at`=? WHERE (user_id = ? AND status = ?) AND `urls`.`deleted_at` IS NULL",
+		)).WithArgs(sqlmock.AnyArg(), 3, model.StatusError).WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectCommit()
+
+		deleted, err := repo.DeleteErrored(3)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, deleted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteErrored_Error", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `urls` SET `deleted_at`")).
+			WillReturnError(errors.New("database error"))
+		mock.ExpectRollback()
+
+		deleted, err := repo.DeleteErrored(3)
+		assert.EqualError(t, err, "database error")
+		assert.Equal(t, 0, deleted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindOwnedIDs", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT `id` FROM `urls` WHERE (user_id = ? AND id IN (?,?,?)) AND `urls`.`deleted_at` IS NULL",
+		)).WithArgs(7, 1, 2, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(3))
+
+		owned, err := repo.FindOwnedIDs(7, []uint{1, 2, 3})
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{1, 3}, owned)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DeleteByIDs", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(
+			"UPDATE `urls` SET `deleted_at`=? WHERE `urls`.`id` IN (?,?) AND `urls`.`deleted_at` IS NULL",
+		)).WithArgs(sqlmock.AnyArg(), 1, 3).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		deleted, err := repo.DeleteByIDs([]uint{1, 3})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, deleted)
+
+		deleted, err = repo.DeleteByIDs(nil)
+		assert.NoError(t, err)
+		assert.Zero(t, deleted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListTagReport", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+		analyzed := time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectQuery(
+			regexp.QuoteMeta("SELECT urls.id AS url_id, urls.original_url, urls.status,")+
+				`.*FROM `+"`urls`"+` JOIN url_tags ON url_tags.url_id = urls.id AND url_tags.name = \?`+
+				`.*LEFT JOIN analysis_results ar ON ar.id = \(\s*SELECT MAX\(latest.id\)`+
+				`.*WHERE urls.user_id = \? AND `+"`urls`.`deleted_at` IS NULL"+
+				` ORDER BY urls.id LIMIT \? OFFSET \?`,
+		).WithArgs("shop", 7, 2, 2).WillReturnRows(
+			sqlmock.NewRows([]string{"url_id", "original_url", "status", "title", "broken_link_count", "analyzed_at"}).
+				AddRow(3, "https://shop.example/a", model.StatusDone, "Shop A", 2, analyzed).
+				AddRow(4, "https://shop.example/b", model.StatusQueued, "", 0, nil),
+		)
+
+		items, err := repo.ListTagReport(7, "shop", repository.Pagination{Page: 2, PageSize: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []model.TagReportItemDTO{
+			{URLID: 3, OriginalURL: "https://shop.example/a", Status: model.StatusDone, Title: "Shop A", BrokenLinkCount: 2, AnalyzedAt: &analyzed},
+			{URLID: 4, OriginalURL: "https://shop.example/b", Status: model.StatusQueued},
+		}, items)
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT count(*) FROM `urls` JOIN url_tags ON url_tags.url_id = urls.id AND url_tags.name = ? WHERE urls.user_id = ? AND `urls`.`deleted_at` IS NULL",
+		)).WithArgs("shop", 7).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(4))
+
+		count, err := repo.CountByTag(7, "shop")
+		require.NoError(t, err)
+		assert.Equal(t, 4, count)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("TagSummary", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+
+		// Five tagged URLs: three done with 2, 0 and 5 broken links, one
+		// errored with 1 and one queued that was never analyzed.
+		mock.ExpectQuery(
+			regexp.QuoteMeta("SELECT urls.status AS status, COUNT(*) AS urls,")+
+				`.*FROM `+"`urls`"+` JOIN url_tags ON url_tags.url_id = urls.id AND url_tags.name = \?`+
+				`.*LEFT JOIN analysis_results ar ON ar.id = \(\s*SELECT MAX\(latest.id\)`+
+				`.*WHERE urls.user_id = \? AND `+"`urls`.`deleted_at` IS NULL"+
+				` GROUP BY `+"`urls`.`status`",
+		).WithArgs("shop", 7).WillReturnRows(
+			sqlmock.NewRows([]string{"status", "urls", "broken_links"}).
+				AddRow(model.StatusDone, 3, 7).
+				AddRow(model.StatusError, 1, 1).
+				AddRow(model.StatusQueued, 1, 0),
+		)
+
+		summary, err := repo.TagSummary(7, "shop")
+		require.NoError(t, err)
+		assert.Equal(t, &model.TagSummaryDTO{
+			Tag:         "shop",
+			TotalURLs:   5,
+			BrokenLinks: 8,
+			ByStatus:    map[string]int{model.StatusDone: 3, model.StatusError: 1, model.StatusQueued: 1},
+		}, summary)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindIDsByTag", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+
+		// The tag join and the owner filter keep other tags and other
+		// users' URLs out.
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT `urls`.`id` FROM `urls` JOIN url_tags ON url_tags.url_id = urls.id AND url_tags.name = ? "+
+				"WHERE urls.user_id = ? AND `urls`.`deleted_at` IS NULL ORDER BY urls.id",
+		)).WithArgs("shop", 7).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(4))
+
+		ids, err := repo.FindIDsByTag(7, "shop")
+		require.NoError(t, err)
+		assert.Equal(t, []uint{3, 4}, ids)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("TagSummary_UnknownTag", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT urls.status AS status, COUNT(*) AS urls,")).
+			WithArgs("none", 7).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "urls", "broken_links"}))
+
+		summary, err := repo.TagSummary(7, "none")
+		require.NoError(t, err)
+		assert.Equal(t, &model.TagSummaryDTO{Tag: "none", ByStatus: map[string]int{}}, summary)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FindExisting", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT `original_url` FROM `urls` WHERE (user_id = ? AND original_url IN (?,?)) AND `urls`.`deleted_at` IS NULL",
+		)).WithArgs(7, "https://a.example", "https://b.example").
+			WillReturnRows(sqlmock.NewRows([]string{"original_url"}).AddRow("https://b.example"))
+
+		existing, err := repo.FindExisting(7, []string{"https://a.example", "https://b.example"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://b.example"}, existing)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("UpdateStatus", func(t *testing.T) {
 		db, mock := setupMockDB(t)
 		repo := repository.NewURLRepo(db)
@@ -201,6 +560,58 @@ func TestURLRepo(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("UpdateStatus Retries On Deadlock", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepoWithOptions(db, repository.URLRepoOptions{DeadlockRetry: repository.DeadlockRetry{Attempts: 2, Backoff: time.Millisecond}})
+		id := uint(11)
+		query := regexp.QuoteMeta(
+			"UPDATE `urls` SET `status`=?,`updated_at`=? WHERE id = ? AND `urls`.`deleted_at` IS NULL",
+		)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(query).WithArgs(model.StatusDone, sqlmock.AnyArg(), id).
+			WillReturnError(&mysqlerr.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"})
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec(query).WithArgs(model.StatusDone, sqlmock.AnyArg(), id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.UpdateStatus(id, model.StatusDone)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateStatus Gives Up After Attempts", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepoWithOptions(db, repository.URLRepoOptions{DeadlockRetry: repository.DeadlockRetry{Attempts: 1, Backoff: time.Millisecond}})
+		id := uint(12)
+		lockErr := &mysqlerr.MySQLError{Number: 1205, Message: "Lock wait timeout exceeded"}
+
+		for i := 0; i < 2; i++ {
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE `urls`").WillReturnError(lockErr)
+			mock.ExpectRollback()
+		}
+
+		err := repo.UpdateStatus(id, model.StatusDone)
+		assert.ErrorIs(t, err, lockErr)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateStatus Does Not Retry Other Errors", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepoWithOptions(db, repository.URLRepoOptions{DeadlockRetry: repository.DeadlockRetry{Attempts: 3, Backoff: time.Millisecond}})
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `urls`").WillReturnError(errors.New("connection refused"))
+		mock.ExpectRollback()
+
+		err := repo.UpdateStatus(13, model.StatusDone)
+		assert.EqualError(t, err, "connection refused")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("SaveResults", func(t *testing.T) {
 		db, mock := setupMockDB(t)
 		repo := repository.NewURLRepo(db)
@@ -223,32 +634,50 @@ func TestURLRepo(t *testing.T) {
 
 		mock.ExpectBegin()
 		exec := mock.ExpectExec(regexp.QuoteMeta(
-			"INSERT INTO `analysis_results` (`url_id`,`html_version`,`title`,`h1_count`,`h2_count`,`h3_count`,`h4_count`,`h5_count`,`h6_count`,`has_login_form`,`internal_link_count`,`external_link_count`,`broken_link_count`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)",
+			"INSERT INTO `analysis_results` (`url_id`,`html_version`,`content_type`,`status_code`,`response_time_ms`,`page_size_bytes`,`title`,`title_source`,`meta_description`,`language`,`h1_count`,`h2_count`,`h3_count`,`h4_count`,`h5_count`,`h6_count`,`image_count`,`images_missing_alt`,`has_login_form`,`internal_link_count`,`external_link_count`,`broken_link_count`,`redirect_chain`,`final_url`,`canonical_url`,`canonical_mismatch`,`crawl_duration_ms`,`tls_verify_skipped`,`raw_html_key`,`run_id`,`provenance`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)",
 		))
 		exec.WithArgs(
 			urlID,
 			analysisRes.HTMLVersion,
+			analysisRes.ContentType,
+			analysisRes.StatusCode,
+			analysisRes.ResponseTimeMs,
+			analysisRes.PageSizeBytes,
 			analysisRes.Title,
+			analysisRes.TitleSource,
+			analysisRes.MetaDescription,
+			analysisRes.Language,
 			analysisRes.H1Count,
 			analysisRes.H2Count,
 			analysisRes.H3Count,
 			analysisRes.H4Count,
 			analysisRes.H5Count,
 			analysisRes.H6Count,
+			analysisRes.ImageCount,
+			analysisRes.ImagesMissingAlt,
 			analysisRes.HasLoginForm,
 			0,
 			0,
 			0,
+			nil,
+			"",
+			"",
+			false,
+			nil,
+			false,
+			"",
+			nil,
+			nil,
 			sqlmock.AnyArg(),
 			sqlmock.AnyArg(),
 			sqlmock.AnyArg(),
 		).WillReturnResult(sqlmock.NewResult(30, 1))
 
 		mock.ExpectExec(regexp.QuoteMeta(
-			"INSERT INTO `links` (`url_id`,`href`,`is_external`,`status_code`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?),(?,?,?,?,?,?,?)",
+			"INSERT INTO `links` (`url_id`,`analysis_result_id`,`href`,`is_external`,`status_code`,`occurrence_count`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?,?,?),(?,?,?,?,?,?,?,?,?)",
 		)).WithArgs(
-			urlID, links[0].Href, false, 0, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
-			urlID, links[1].Href, false, 0, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			urlID, 30, links[0].Href, false, 0, 0, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			urlID, 30, links[1].Href, false, 0, 0, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
 		).WillReturnResult(sqlmock.NewResult(100, 2))
 		mock.ExpectCommit()
 
@@ -257,6 +686,35 @@ func TestURLRepo(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("SaveResults With Run ID", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+		urlID := uint(20)
+		runID := "0b5bd2c6-2f0e-4f55-9c8e-1f1d0c5a7e21"
+		analysisRes := &model.AnalysisResult{HTMLVersion: "HTML5", RunID: &runID}
+		links := []model.Link{{Href: "https://example.com/link1"}}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `analysis_results` .+ ON DUPLICATE KEY UPDATE").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT `id` FROM `analysis_results` WHERE run_id = ? ORDER BY `analysis_results`.`id` LIMIT ?",
+		)).WithArgs(runID, 1).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(30))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"DELETE FROM `links` WHERE analysis_result_id = ?",
+		)).WithArgs(30).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"INSERT INTO `links` (`url_id`,`analysis_result_id`,`href`,`is_external`,`status_code`,`occurrence_count`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?,?,?)",
+		)).WithArgs(urlID, 30, links[0].Href, false, 0, 0, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(101, 1))
+		mock.ExpectCommit()
+
+		err := repo.SaveResults(urlID, analysisRes, links)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(30), analysisRes.ID, "an existing run keeps its ID")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("Results", func(t *testing.T) {
 		db, mock := setupMockDB(t)
 		repo := repository.NewURLRepo(db)
@@ -309,17 +767,31 @@ func TestURLRepo(t *testing.T) {
                    'id',                  ar.id,
                    'url_id',              ar.url_id,
                    'html_version',        ar.html_version,
+                   'content_type',        ar.content_type,
+                   'status_code',         ar.status_code,
+                   'response_time_ms',    ar.response_time_ms,
+                   'page_size_bytes',     ar.page_size_bytes,
                    'title',               ar.title,
+                   'meta_description',    ar.meta_description,
+                   'language',            ar.language,
                    'h1_count',            ar.h1_count,
                    'h2_count',            ar.h2_count,
                    'h3_count',            ar.h3_count,
                    'h4_count',            ar.h4_count,
                    'h5_count',            ar.h5_count,
                    'h6_count',            ar.h6_count,
+                   'image_count',         ar.image_count,
+                   'images_missing_alt',  ar.images_missing_alt,
                    'has_login_form',      IF(ar.has_login_form = 1, CAST('true' AS JSON), CAST('false' AS JSON)),
                    'internal_link_count', ar.internal_link_count,
                    'external_link_count', ar.external_link_count,
                    'broken_link_count',   ar.broken_link_count,
+                   'redirect_chain',      ar.redirect_chain,
+                   'final_url',           ar.final_url,
+                   'canonical_url',       ar.canonical_url,
+                   'canonical_mismatch',  IF(ar.canonical_mismatch = 1, CAST('true' AS JSON), CAST('false' AS JSON)),
+                   'crawl_duration_ms',   ar.crawl_duration_ms,
+                   'tls_verify_skipped',  IF(ar.tls_verify_skipped = 1, CAST('true' AS JSON), CAST('false' AS JSON)),
                    'created_at',          DATE_FORMAT(ar.created_at, '%Y-%m-%dT%H:%i:%s.%fZ'),
                    'updated_at',          DATE_FORMAT(ar.updated_at, '%Y-%m-%dT%H:%i:%s.%fZ')
                  )
@@ -336,7 +808,8 @@ func TestURLRepo(t *testing.T) {
                    'url_id',      l.url_id,
                    'href',        l.href,
                    'is_external', IF(l.is_external = 1, CAST('true' AS JSON), CAST('false' AS JSON)),
-                   'status_code', l.status_code
+                   'status_code', l.status_code,
+                   'occurrence_count', l.occurrence_count
                  )
                )
         FROM   links l
@@ -372,7 +845,7 @@ WHERE u.id = ?`)).
 			sqlmock.NewRows([]string{"count(*)"}).AddRow(10),
 		)
 
-		count, err := repo.CountByUser(userID)
+		count, err := repo.CountByUser(userID, repository.URLFilter{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, 10, count)
@@ -389,11 +862,70 @@ WHERE u.id = ?`)).
 			"SELECT count(*) FROM `urls` WHERE user_id = ? AND `urls`.`deleted_at` IS NULL",
 		)).WithArgs(userID).WillReturnError(expectedErr)
 
-		count, err := repo.CountByUser(userID)
+		count, err := repo.CountByUser(userID, repository.URLFilter{})
 
 		assert.Error(t, err)
 		assert.Equal(t, expectedErr, err)
 		assert.Equal(t, 0, count)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("ResetStuck", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+		before := time.Date(2025, 7, 11, 12, 0, 0, 0, time.UTC)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT `id` FROM `urls` WHERE (status = ? AND updated_at < ?) AND `urls`.`deleted_at` IS NULL FOR UPDATE",
+		)).WithArgs(model.StatusRunning, before).WillReturnRows(
+			sqlmock.NewRows([]string{"id"}).AddRow(4).AddRow(9),
+		)
+		mock.ExpectExec(regexp.QuoteMeta(
+			"UPDATE `urls` SET `status`=?,`updated_at`=? WHERE id IN (?,?) AND `urls`.`deleted_at` IS NULL",
+		)).WithArgs(model.StatusQueued, sqlmock.AnyArg(), 4, 9).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		ids, err := repo.ResetStuck(before, model.StatusQueued)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{4, 9}, ids)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ResetStuck_None", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+		before := time.Date(2025, 7, 11, 12, 0, 0, 0, time.UTC)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT `id` FROM `urls` WHERE (status = ? AND updated_at < ?) AND `urls`.`deleted_at` IS NULL FOR UPDATE",
+		)).WithArgs(model.StatusRunning, before).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+
+		ids, err := repo.ResetStuck(before, model.StatusError)
+
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CountByUserAndStatus", func(t *testing.T) {
+		db, mock := setupMockDB(t)
+		repo := repository.NewURLRepo(db)
+		userID := uint(5)
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT count(*) FROM `urls` WHERE user_id = ? AND status IN (?,?) AND `urls`.`deleted_at` IS NULL",
+		)).WithArgs(userID, model.StatusQueued, model.StatusRunning).WillReturnRows(
+			sqlmock.NewRows([]string{"count(*)"}).AddRow(3),
+		)
+
+		count, err := repo.CountByUserAndStatus(userID, model.StatusQueued, model.StatusRunning)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, count)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
@@ -43,12 +43,13 @@ func TestUserRepository(t *testing.T) {
 
 		mock.ExpectBegin()
 		mock.ExpectExec(regexp.QuoteMeta(
-			"INSERT INTO `users` (`username`,`email`,`password`,`role`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?)",
+			"INSERT INTO `users` (`username`,`email`,`password`,`role`,`email_verified`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?,?)",
 		)).WithArgs(
 			user.Username,
 			user.Email,
 			user.Password,
 			user.Role,
+			false,
 			sqlmock.AnyArg(),
 			sqlmock.AnyArg(),
 			sqlmock.AnyArg(),
@@ -130,6 +131,34 @@ func TestUserRepository(t *testing.T) {
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("UsernameTaken", func(t *testing.T) {
+		db, mock := setupUserMockDB(t)
+		repo := repository.NewUserRepo(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT count(*) FROM `users` WHERE id <> ? AND LOWER(username) = LOWER(?)",
+		)).WithArgs(3, "Alice").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(1))
+
+		taken, err := repo.UsernameTaken("Alice", 3, true)
+		assert.NoError(t, err)
+		assert.True(t, taken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UsernameTaken Case Sensitive", func(t *testing.T) {
+		db, mock := setupUserMockDB(t)
+		repo := repository.NewUserRepo(db)
+
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT count(*) FROM `users` WHERE id <> ? AND username = ? COLLATE utf8mb4_bin",
+		)).WithArgs(0, "Alice").WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(0))
+
+		taken, err := repo.UsernameTaken("Alice", 0, false)
+		assert.NoError(t, err)
+		assert.False(t, taken)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("Search", func(t *testing.T) {
 		db, mock := setupUserMockDB(t)
 		repo := repository.NewUserRepo(db)
@@ -201,4 +230,101 @@ func TestUserRepository(t *testing.T) {
 		assert.Equal(t, "user not found", err.Error())
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
+
+	t.Run("SetEmailVerified", func(t *testing.T) {
+		db, mock := setupUserMockDB(t)
+		repo := repository.NewUserRepo(db)
+		userID := uint(1)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(
+			"UPDATE `users` SET `email_verified`=?,`updated_at`=? WHERE `users`.`deleted_at` IS NULL AND `id` = ?",
+		)).WithArgs(true, sqlmock.AnyArg(), userID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := repo.SetEmailVerified(userID, true)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Erase", func(t *testing.T) {
+		db, mock := setupUserMockDB(t)
+		repo := repository.NewUserRepo(db)
+		userID := uint(7)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT `raw_html_key` FROM `analysis_results` WHERE url_id IN (SELECT `id` FROM `urls` WHERE user_id = ?) AND raw_html_key <> ''",
+		)).WithArgs(userID).WillReturnRows(sqlmock.NewRows([]string{"raw_html_key"}).AddRow("1/abc.html"))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"DELETE FROM `links` WHERE url_id IN (SELECT `id` FROM `urls` WHERE user_id = ?)",
+		)).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"DELETE FROM `analysis_results` WHERE url_id IN (SELECT `id` FROM `urls` WHERE user_id = ?)",
+		)).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"DELETE FROM `url_tags` WHERE url_id IN (SELECT `id` FROM `urls` WHERE user_id = ?)",
+		)).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"DELETE FROM `urls` WHERE user_id = ?",
+		)).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"DELETE FROM `notification_prefs` WHERE user_id = ?",
+		)).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"DELETE FROM `api_keys` WHERE user_id = ?",
+		)).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta(
+			"SELECT * FROM `issued_tokens` WHERE user_id = ? AND expires_at > ?",
+		)).WithArgs(userID, sqlmock.AnyArg()).WillReturnRows(
+			sqlmock.NewRows([]string{"id", "jti", "user_id", "subject", "expires_at", "created_at"}).
+				AddRow(1, "access-jti", userID, "access_token", fixedTime.Add(time.Hour), fixedTime).
+				AddRow(2, "refresh-jti", userID, "refresh_token", fixedTime.Add(24*time.Hour), fixedTime),
+		)
+		mock.ExpectExec(regexp.QuoteMeta(
+			"INSERT INTO `blacklisted_tokens`",
+		)).WillReturnResult(sqlmock.NewResult(1, 2))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"DELETE FROM `issued_tokens` WHERE user_id = ?",
+		)).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(regexp.QuoteMeta(
+			"DELETE FROM `users` WHERE `users`.`id` = ?",
+		)).WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		erased, err := repo.Erase(userID)
+		require.NoError(t, err)
+		assert.Equal(t, &repository.AccountErasure{
+			URLs:            2,
+			AnalysisResults: 2,
+			Links:           4,
+			Tags:            3,
+			TokensRevoked:   2,
+			RawHTMLKeys:     []string{"1/abc.html"},
+		}, erased)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Erase Not Found", func(t *testing.T) {
+		db, mock := setupUserMockDB(t)
+		repo := repository.NewUserRepo(db)
+		userID := uint(999)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT `raw_html_key` FROM `analysis_results`").
+			WillReturnRows(sqlmock.NewRows([]string{"raw_html_key"}))
+		for _, table := range []string{"links", "analysis_results", "url_tags", "urls", "notification_prefs", "api_keys"} {
+			mock.ExpectExec("DELETE FROM `" + table + "`").WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		mock.ExpectQuery("SELECT \\* FROM `issued_tokens`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "jti"}))
+		mock.ExpectExec("DELETE FROM `issued_tokens`").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM `users`").WithArgs(userID).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		erased, err := repo.Erase(userID)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.Nil(t, erased)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
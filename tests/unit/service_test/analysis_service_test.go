@@ -1,16 +1,21 @@
 package service_test
 
 import (
+	"context"
 	"errors"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
 	"github.com/fuzumoe/linkTorch-api/internal/service"
+	"github.com/fuzumoe/linkTorch-api/internal/storage"
 )
 
 type MockAnalysisRepo struct {
@@ -27,10 +32,49 @@ func (m *MockAnalysisRepo) ListByURL(urlID uint, p repository.Pagination) ([]mod
 	return args.Get(0).([]model.AnalysisResult), args.Error(1)
 }
 
+func (m *MockAnalysisRepo) HTMLVersionCounts(userID uint) ([]model.HTMLVersionCountDTO, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.HTMLVersionCountDTO), args.Error(1)
+}
+
+func (m *MockAnalysisRepo) Timings(urlID, userID uint) (*model.CrawlTimingsDTO, error) {
+	args := m.Called(urlID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.CrawlTimingsDTO), args.Error(1)
+}
+
+func (m *MockAnalysisRepo) ResponseTimes(urlID, userID uint) ([]int64, error) {
+	args := m.Called(urlID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (m *MockAnalysisRepo) LatestRawHTMLKey(urlID, userID uint) (string, error) {
+	args := m.Called(urlID, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAnalysisRepo) RecentByUser(userID uint, limit int) ([]model.RecentCrawlDTO, error) {
+	args := m.Called(userID, limit)
+	return args.Get(0).([]model.RecentCrawlDTO), args.Error(1)
+}
+
+func (m *MockAnalysisRepo) LatestProvenance(urlID, userID uint) (*model.ProvenanceDTO, error) {
+	args := m.Called(urlID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ProvenanceDTO), args.Error(1)
+}
+
 func TestAnalysisService_Record(t *testing.T) {
 
 	mockRepo := new(MockAnalysisRepo)
-	svc := service.NewAnalysisService(mockRepo)
+	svc := service.NewAnalysisService(mockRepo, nil)
 
 	testResult := &model.AnalysisResult{
 		URLID:        42,
@@ -72,7 +116,7 @@ func TestAnalysisService_Record(t *testing.T) {
 func TestAnalysisService_List(t *testing.T) {
 
 	mockRepo := new(MockAnalysisRepo)
-	svc := service.NewAnalysisService(mockRepo)
+	svc := service.NewAnalysisService(mockRepo, nil)
 
 	urlID := uint(42)
 	pagination := repository.Pagination{Page: 1, PageSize: 10}
@@ -149,3 +193,207 @@ func TestAnalysisService_List(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+func TestAnalysisService_HTMLVersionDistribution(t *testing.T) {
+	mockRepo := new(MockAnalysisRepo)
+	svc := service.NewAnalysisService(mockRepo, nil)
+	userID := uint(5)
+
+	t.Run("Success", func(t *testing.T) {
+		counts := []model.HTMLVersionCountDTO{
+			{HTMLVersion: "HTML 5", Count: 4},
+			{HTMLVersion: "unknown", Count: 1},
+		}
+		mockRepo.On("HTMLVersionCounts", userID).Return(counts, nil).Once()
+
+		result, err := svc.HTMLVersionDistribution(userID)
+		require.NoError(t, err)
+		assert.Equal(t, counts, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("No Results", func(t *testing.T) {
+		mockRepo.On("HTMLVersionCounts", userID).Return([]model.HTMLVersionCountDTO(nil), nil).Once()
+
+		result, err := svc.HTMLVersionDistribution(userID)
+		require.NoError(t, err)
+		assert.NotNil(t, result, "Should return an empty slice rather than nil")
+		assert.Empty(t, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Repository Error", func(t *testing.T) {
+		expectedErr := errors.New("database error")
+		mockRepo.On("HTMLVersionCounts", userID).Return([]model.HTMLVersionCountDTO(nil), expectedErr).Once()
+
+		result, err := svc.HTMLVersionDistribution(userID)
+		assert.Equal(t, expectedErr, err)
+		assert.Nil(t, result)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestAnalysisService_Timings(t *testing.T) {
+	mockRepo := new(MockAnalysisRepo)
+	svc := service.NewAnalysisService(mockRepo, nil)
+
+	t.Run("Success", func(t *testing.T) {
+		minMs, maxMs, latest, avg := int64(100), int64(300), int64(200), 200.0
+		expected := &model.CrawlTimingsDTO{URLID: 3, Runs: 3, MinMs: &minMs, MaxMs: &maxMs, AvgMs: &avg, LatestMs: &latest}
+		mockRepo.On("Timings", uint(3), uint(1)).Return(expected, nil).Once()
+
+		timings, err := svc.Timings(3, 1)
+		require.NoError(t, err)
+		assert.Equal(t, expected, timings)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Repository Error", func(t *testing.T) {
+		expectedErr := errors.New("database error")
+		mockRepo.On("Timings", uint(3), uint(1)).Return(nil, expectedErr).Once()
+
+		timings, err := svc.Timings(3, 1)
+		assert.Equal(t, expectedErr, err)
+		assert.Nil(t, timings)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestAnalysisService_Provenance(t *testing.T) {
+	mockRepo := new(MockAnalysisRepo)
+	svc := service.NewAnalysisService(mockRepo, nil)
+
+	expected := &model.ProvenanceDTO{URLID: 3, ResultID: 7, Provenance: model.Provenance{WorkerID: 2, Attempts: 1, Analyzer: "html"}}
+	mockRepo.On("LatestProvenance", uint(3), uint(1)).Return(expected, nil).Once()
+
+	prov, err := svc.Provenance(3, 1)
+	require.NoError(t, err)
+	assert.Equal(t, expected, prov)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAnalysisService_ResponseTimes(t *testing.T) {
+	mockRepo := new(MockAnalysisRepo)
+	svc := service.NewAnalysisService(mockRepo, nil)
+
+	t.Run("Distribution", func(t *testing.T) {
+		// 100 runs taking 1..100 ms, in shuffled order.
+		durations := make([]int64, 100)
+		for i := range durations {
+			durations[i] = int64((i*37)%100 + 1)
+		}
+		mockRepo.On("ResponseTimes", uint(3), uint(1)).Return(durations, nil).Once()
+
+		rt, err := svc.ResponseTimes(3, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 100, rt.Runs)
+		assert.Equal(t, int64(50), *rt.P50Ms)
+		assert.Equal(t, int64(90), *rt.P90Ms)
+		assert.Equal(t, int64(99), *rt.P99Ms)
+	})
+
+	t.Run("Few Runs", func(t *testing.T) {
+		mockRepo.On("ResponseTimes", uint(3), uint(1)).Return([]int64{900, 100, 300}, nil).Once()
+
+		rt, err := svc.ResponseTimes(3, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 3, rt.Runs)
+		assert.Equal(t, int64(300), *rt.P50Ms)
+		assert.Equal(t, int64(900), *rt.P90Ms)
+		assert.Equal(t, int64(900), *rt.P99Ms)
+	})
+
+	t.Run("No Timed Runs", func(t *testing.T) {
+		mockRepo.On("ResponseTimes", uint(3), uint(1)).Return([]int64{}, nil).Once()
+
+		rt, err := svc.ResponseTimes(3, 1)
+		require.NoError(t, err)
+		assert.Equal(t, &model.ResponseTimesDTO{URLID: 3}, rt)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mockRepo.On("ResponseTimes", uint(3), uint(2)).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		_, err := svc.ResponseTimes(3, 2)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestAnalysisService_RawHTML(t *testing.T) {
+	ctx := context.Background()
+	blobs, err := storage.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, blobs.Put(ctx, "raw/a/1.html", strings.NewReader("<html>kept</html>")))
+
+	mockRepo := new(MockAnalysisRepo)
+	svc := service.NewAnalysisService(mockRepo, blobs)
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo.On("LatestRawHTMLKey", uint(3), uint(1)).Return("raw/a/1.html", nil).Once()
+
+		body, err := svc.RawHTML(ctx, 3, 1)
+		require.NoError(t, err)
+		defer body.Close()
+		data, err := io.ReadAll(body)
+		require.NoError(t, err)
+		assert.Equal(t, "<html>kept</html>", string(data))
+	})
+
+	t.Run("Blob Missing", func(t *testing.T) {
+		mockRepo.On("LatestRawHTMLKey", uint(3), uint(1)).Return("raw/a/gone.html", nil).Once()
+
+		_, err := svc.RawHTML(ctx, 3, 1)
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+	})
+
+	t.Run("No Key", func(t *testing.T) {
+		expectedErr := errors.New("record not found")
+		mockRepo.On("LatestRawHTMLKey", uint(4), uint(1)).Return("", expectedErr).Once()
+
+		_, err := svc.RawHTML(ctx, 4, 1)
+		assert.Equal(t, expectedErr, err)
+	})
+
+	t.Run("No Store Configured", func(t *testing.T) {
+		_, err := service.NewAnalysisService(mockRepo, nil).RawHTML(ctx, 3, 1)
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+	})
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAnalysisService_Recent(t *testing.T) {
+	mockRepo := new(MockAnalysisRepo)
+	svc := service.NewAnalysisService(mockRepo, nil)
+
+	t.Run("Limit", func(t *testing.T) {
+		cases := []struct {
+			name  string
+			limit int
+			want  int
+		}{
+			{"Default", 0, service.DefaultRecentLimit},
+			{"Within Bounds", 5, 5},
+			{"Capped", 500, service.MaxRecentLimit},
+		}
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				mockRepo.On("RecentByUser", uint(2), tc.want).Return([]model.RecentCrawlDTO(nil), nil).Once()
+
+				recent, err := svc.Recent(2, tc.limit)
+				require.NoError(t, err)
+				assert.NotNil(t, recent, "Should return an empty slice rather than nil")
+				mockRepo.AssertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("Repository Error", func(t *testing.T) {
+		expectedErr := errors.New("database error")
+		mockRepo.On("RecentByUser", uint(2), 10).Return([]model.RecentCrawlDTO(nil), expectedErr).Once()
+
+		recent, err := svc.Recent(2, 10)
+		assert.Equal(t, expectedErr, err)
+		assert.Nil(t, recent)
+	})
+}
@@ -0,0 +1,282 @@
+package service_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/service"
+)
+
+type MockAPIKeyRepo struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepo) Create(key *model.APIKey) error {
+	return m.Called(key).Error(0)
+}
+
+func (m *MockAPIKeyRepo) FindActiveByHash(hash string) (*model.APIKey, error) {
+	args := m.Called(hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) ListByUser(userID uint) ([]model.APIKey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) Revoke(id, userID uint) error {
+	return m.Called(id, userID).Error(0)
+}
+
+func (m *MockAPIKeyRepo) TouchLastUsed(id uint, at time.Time) error {
+	return m.Called(id, at).Error(0)
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAPIKeyService(t *testing.T) {
+	t.Run("Create Stores Only The Hash", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		svc := service.NewAPIKeyService(keys, new(MockUserRepo))
+
+		var stored *model.APIKey
+		keys.On("Create", mock.AnythingOfType("*model.APIKey")).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(*model.APIKey)
+			stored.ID = 4
+		}).Return(nil)
+
+		created, err := svc.Create(7, &model.CreateAPIKeyInput{Name: "ci"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(4), created.ID)
+		assert.Equal(t, "ci", created.Name)
+		assert.True(t, strings.HasPrefix(created.Key, "ltk_"))
+		assert.True(t, strings.HasPrefix(created.Key, created.Prefix))
+
+		assert.Equal(t, uint(7), stored.UserID)
+		assert.Equal(t, sha256Hex(created.Key), stored.KeyHash)
+		assert.NotContains(t, stored.KeyHash, created.Key[len(created.Prefix):])
+	})
+
+	t.Run("Keys Are Unique", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		svc := service.NewAPIKeyService(keys, new(MockUserRepo))
+		keys.On("Create", mock.Anything).Return(nil)
+
+		a, err := svc.Create(7, &model.CreateAPIKeyInput{Name: "a"})
+		require.NoError(t, err)
+		b, err := svc.Create(7, &model.CreateAPIKeyInput{Name: "b"})
+		require.NoError(t, err)
+		assert.NotEqual(t, a.Key, b.Key)
+	})
+
+	t.Run("Authenticate", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		users := new(MockUserRepo)
+		svc := service.NewAPIKeyService(keys, users)
+		plain := "ltk_" + strings.Repeat("ab", 32)
+
+		keys.On("FindActiveByHash", sha256Hex(plain)).Return(&model.APIKey{ID: 4, UserID: 7}, nil)
+		users.On("FindByID", uint(7)).Return(&model.User{ID: 7, Email: "user@example.com", Role: model.RoleAdmin}, nil)
+		keys.On("TouchLastUsed", uint(4), mock.AnythingOfType("time.Time")).Return(nil)
+
+		user, err := svc.Authenticate(plain)
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), user.ID)
+		assert.Equal(t, model.RoleAdmin, user.Role)
+		keys.AssertExpectations(t)
+	})
+
+	t.Run("Authenticate Survives Failed Touch", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		users := new(MockUserRepo)
+		svc := service.NewAPIKeyService(keys, users)
+		plain := "ltk_" + strings.Repeat("ab", 32)
+
+		keys.On("FindActiveByHash", sha256Hex(plain)).Return(&model.APIKey{ID: 4, UserID: 7}, nil)
+		users.On("FindByID", uint(7)).Return(&model.User{ID: 7}, nil)
+		keys.On("TouchLastUsed", uint(4), mock.Anything).Return(errors.New("db down"))
+
+		_, err := svc.Authenticate(plain)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Authenticate Unknown Or Revoked", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		svc := service.NewAPIKeyService(keys, new(MockUserRepo))
+		keys.On("FindActiveByHash", mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+
+		_, err := svc.Authenticate("ltk_unknown")
+		assert.ErrorIs(t, err, service.ErrAPIKeyInvalid)
+	})
+
+	t.Run("Authenticate Malformed", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		svc := service.NewAPIKeyService(keys, new(MockUserRepo))
+
+		_, err := svc.Authenticate("not-a-key")
+		assert.ErrorIs(t, err, service.ErrAPIKeyInvalid)
+		keys.AssertNotCalled(t, "FindActiveByHash", mock.Anything)
+	})
+
+	t.Run("Authenticate Deleted User", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		users := new(MockUserRepo)
+		svc := service.NewAPIKeyService(keys, users)
+		keys.On("FindActiveByHash", mock.Anything).Return(&model.APIKey{ID: 4, UserID: 7}, nil)
+		users.On("FindByID", uint(7)).Return(nil, gorm.ErrRecordNotFound)
+
+		_, err := svc.Authenticate("ltk_orphan")
+		assert.ErrorIs(t, err, service.ErrAPIKeyInvalid)
+		keys.AssertNotCalled(t, "TouchLastUsed", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Create With Expiry", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		svc := service.NewAPIKeyServiceWithOptions(keys, new(MockUserRepo), service.APIKeyServiceOptions{MaxLifetime: 30 * 24 * time.Hour})
+		var stored *model.APIKey
+		keys.On("Create", mock.AnythingOfType("*model.APIKey")).Run(func(args mock.Arguments) {
+			stored = args.Get(0).(*model.APIKey)
+		}).Return(nil)
+
+		expiresAt := time.Now().Add(24 * time.Hour)
+		created, err := svc.Create(7, &model.CreateAPIKeyInput{Name: "ci", ExpiresAt: &expiresAt})
+		require.NoError(t, err)
+		require.NotNil(t, stored.ExpiresAt)
+		assert.True(t, stored.ExpiresAt.Equal(expiresAt))
+		assert.True(t, created.ExpiresAt.Equal(expiresAt))
+		assert.False(t, created.Expired)
+	})
+
+	t.Run("Create Defaults To Max Lifetime", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		svc := service.NewAPIKeyServiceWithOptions(keys, new(MockUserRepo), service.APIKeyServiceOptions{MaxLifetime: time.Hour})
+		keys.On("Create", mock.Anything).Return(nil)
+
+		created, err := svc.Create(7, &model.CreateAPIKeyInput{Name: "ci"})
+		require.NoError(t, err)
+		require.NotNil(t, created.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *created.ExpiresAt, time.Minute)
+	})
+
+	t.Run("Create Without Limit Never Expires", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		svc := service.NewAPIKeyService(keys, new(MockUserRepo))
+		keys.On("Create", mock.Anything).Return(nil)
+
+		created, err := svc.Create(7, &model.CreateAPIKeyInput{Name: "ci"})
+		require.NoError(t, err)
+		assert.Nil(t, created.ExpiresAt)
+	})
+
+	t.Run("Create Beyond Max Lifetime", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		svc := service.NewAPIKeyServiceWithOptions(keys, new(MockUserRepo), service.APIKeyServiceOptions{MaxLifetime: 24 * time.Hour})
+
+		expiresAt := time.Now().Add(48 * time.Hour)
+		_, err := svc.Create(7, &model.CreateAPIKeyInput{Name: "ci", ExpiresAt: &expiresAt})
+		assert.ErrorIs(t, err, service.ErrAPIKeyExpiry)
+		assert.Contains(t, err.Error(), "24h0m0s")
+		keys.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Create With Past Expiry", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		svc := service.NewAPIKeyService(keys, new(MockUserRepo))
+
+		expiresAt := time.Now().Add(-time.Minute)
+		_, err := svc.Create(7, &model.CreateAPIKeyInput{Name: "ci", ExpiresAt: &expiresAt})
+		assert.ErrorIs(t, err, service.ErrAPIKeyExpiry)
+		keys.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Create Without Required Expiry", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		svc := service.NewAPIKeyServiceWithOptions(keys, new(MockUserRepo), service.APIKeyServiceOptions{MaxLifetime: time.Hour, RequireExpiry: true})
+
+		_, err := svc.Create(7, &model.CreateAPIKeyInput{Name: "ci"})
+		assert.ErrorIs(t, err, service.ErrAPIKeyExpiry)
+		keys.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Authenticate Before And After Expiry", func(t *testing.T) {
+		plain := "ltk_" + strings.Repeat("ab", 32)
+		future := time.Now().Add(time.Hour)
+		past := time.Now().Add(-time.Second)
+
+		keys := new(MockAPIKeyRepo)
+		users := new(MockUserRepo)
+		svc := service.NewAPIKeyService(keys, users)
+		keys.On("FindActiveByHash", sha256Hex(plain)).Return(&model.APIKey{ID: 4, UserID: 7, ExpiresAt: &future}, nil).Once()
+		users.On("FindByID", uint(7)).Return(&model.User{ID: 7}, nil)
+		keys.On("TouchLastUsed", uint(4), mock.Anything).Return(nil)
+
+		user, err := svc.Authenticate(plain)
+		require.NoError(t, err)
+		assert.Equal(t, uint(7), user.ID)
+
+		keys.On("FindActiveByHash", sha256Hex(plain)).Return(&model.APIKey{ID: 4, UserID: 7, ExpiresAt: &past}, nil).Once()
+		_, err = svc.Authenticate(plain)
+		assert.ErrorIs(t, err, service.ErrAPIKeyExpired)
+		keys.AssertNumberOfCalls(t, "TouchLastUsed", 1)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		svc := service.NewAPIKeyService(keys, new(MockUserRepo))
+		keys.On("ListByUser", uint(7)).Return([]model.APIKey{
+			{ID: 5, Name: "deploy", KeyHash: "secret-hash", Revoked: true},
+			{ID: 4, Name: "ci", KeyHash: "other-hash"},
+		}, nil)
+
+		list, err := svc.List(7)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, "deploy", list[0].Name)
+		assert.True(t, list[0].Revoked)
+		assert.False(t, list[1].Expired)
+	})
+
+	t.Run("List Marks Expired Keys", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		svc := service.NewAPIKeyService(keys, new(MockUserRepo))
+		past := time.Now().Add(-time.Hour)
+		future := time.Now().Add(time.Hour)
+		keys.On("ListByUser", uint(7)).Return([]model.APIKey{
+			{ID: 5, Name: "old", ExpiresAt: &past},
+			{ID: 4, Name: "ci", ExpiresAt: &future},
+		}, nil)
+
+		list, err := svc.List(7)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.True(t, list[0].Expired)
+		assert.False(t, list[1].Expired)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		keys := new(MockAPIKeyRepo)
+		svc := service.NewAPIKeyService(keys, new(MockUserRepo))
+		keys.On("Revoke", uint(4), uint(7)).Return(nil)
+		keys.On("Revoke", uint(4), uint(8)).Return(gorm.ErrRecordNotFound)
+
+		assert.NoError(t, svc.Revoke(7, 4))
+		assert.ErrorIs(t, svc.Revoke(8, 4), gorm.ErrRecordNotFound)
+	})
+}
@@ -40,11 +40,29 @@ func (m *MockUserRepository) FindByEmail(email string) (*model.User, error) {
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
+func (m *MockUserRepository) UsernameTaken(username string, excludeID uint, ignoreCase bool) (bool, error) {
+	args := m.Called(username, excludeID, ignoreCase)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockUserRepository) Delete(id uint) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) Erase(id uint) (*repository.AccountErasure, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.AccountErasure), args.Error(1)
+}
+
+func (m *MockUserRepository) SetEmailVerified(id uint, verified bool) error {
+	args := m.Called(id, verified)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) Update(id uint, u *model.User) error {
 	args := m.Called(id, u)
 	return args.Error(0)
@@ -77,6 +95,26 @@ func (m *MockTokenRepository) RemoveExpired() error {
 	return args.Error(0)
 }
 
+func (m *MockTokenRepository) AddIssued(tokens ...*model.IssuedToken) error {
+	args := m.Called(tokens)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) RotateRefresh(oldJTI string, oldExpiresAt time.Time, issued ...*model.IssuedToken) error {
+	args := m.Called(oldJTI, oldExpiresAt, issued)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) Consume(jti string, expiresAt time.Time) error {
+	args := m.Called(jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) RevokeUserTokens(userID uint, subject string) (int, error) {
+	args := m.Called(userID, subject)
+	return args.Int(0), args.Error(1)
+}
+
 func createTestUser(id uint) *model.User {
 	validHash := "$2a$10$DwPN33P/gX.yrFZ7Vw4GpuScqXd2QrQJtBSmPnxLrhS/Pv7T/Kvja"
 
@@ -134,7 +172,11 @@ func TestAuthService_Generate(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		user := createTestUser(userID)
 
+		var recorded []*model.IssuedToken
 		mockUserRepo.On("FindByID", userID).Return(user, nil).Once()
+		mockTokenRepo.On("AddIssued", mock.Anything).
+			Run(func(args mock.Arguments) { recorded = args.Get(0).([]*model.IssuedToken) }).
+			Return(nil).Once()
 
 		tokenString, err := svc.Generate(userID)
 		require.NoError(t, err)
@@ -151,11 +193,17 @@ func TestAuthService_Generate(t *testing.T) {
 		assert.Equal(t, user.Email, claims.Email)
 		assert.Equal(t, user.Role, claims.Role)
 		assert.NotEmpty(t, claims.ID)
+		assert.Equal(t, "access_token", claims.Subject)
 
 		now := time.Now().UTC()
 		assert.WithinDuration(t, now, claims.IssuedAt.Time, 2*time.Second)
 		assert.WithinDuration(t, now.Add(tokenLifetime), claims.ExpiresAt.Time, 2*time.Second)
 
+		require.Len(t, recorded, 1, "the token is recorded for its user")
+		assert.Equal(t, claims.ID, recorded[0].JTI)
+		assert.Equal(t, userID, recorded[0].UserID)
+		assert.Equal(t, "access_token", recorded[0].Subject)
+
 		mockUserRepo.AssertExpectations(t)
 	})
 
@@ -180,6 +228,7 @@ func TestAuthService_Validate(t *testing.T) {
 	userID := uint(123)
 
 	mockUserRepo.On("FindByID", userID).Return(createTestUser(userID), nil).Once()
+	mockTokenRepo.On("AddIssued", mock.Anything).Return(nil)
 	validToken, err := svc.Generate(userID)
 	require.NoError(t, err)
 
@@ -240,6 +289,50 @@ func TestAuthService_Validate(t *testing.T) {
 		assert.Nil(t, claims)
 	})
 
+	t.Run("Password Reset Token", func(t *testing.T) {
+		resetClaims := service.Claims{
+			UserID: userID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "reset-jti",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+				Subject:   "password_reset",
+			},
+		}
+		resetToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, resetClaims).SignedString([]byte(jwtSecret))
+		require.NoError(t, err)
+		mockTokenRepo.On("IsBlacklisted", "reset-jti").Return(false, nil).Once()
+
+		claims, err := svc.Validate(resetToken)
+		assert.Equal(t, service.ErrTokenInvalid, err)
+		assert.Nil(t, claims)
+	})
+
+	t.Run("Token Without Subject", func(t *testing.T) {
+		// Access tokens issued before tokens carried a subject.
+		legacyClaims := service.Claims{
+			UserID: userID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "legacy-jti",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+		legacyToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, legacyClaims).SignedString([]byte(jwtSecret))
+		require.NoError(t, err)
+		mockTokenRepo.On("IsBlacklisted", "legacy-jti").Return(false, nil).Twice()
+
+		claims, err := svc.Validate(legacyToken)
+		require.NoError(t, err)
+		assert.Equal(t, userID, claims.UserID)
+
+		// Once one access token lifetime has passed since startup, every
+		// token issued before then has expired, so none is accepted.
+		shortSvc := service.NewAuthService(mockUserRepo, mockTokenRepo, jwtSecret, time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+		claims, err = shortSvc.Validate(legacyToken)
+		assert.Equal(t, service.ErrTokenInvalid, err)
+		assert.Nil(t, claims)
+	})
+
 	t.Run("Revoked Token", func(t *testing.T) {
 		mockTokenRepo.On("IsBlacklisted", tokenID).Return(true, nil).Once()
 
@@ -260,6 +353,198 @@ func TestAuthService_Validate(t *testing.T) {
 	})
 }
 
+// parseClaims reads a token's claims without validating it against a service.
+func parseClaims(t *testing.T, tokenString, secret string) *service.Claims {
+	t.Helper()
+	claims := &service.Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	})
+	require.NoError(t, err)
+	return claims
+}
+
+func TestAuthService_GeneratePair(t *testing.T) {
+	mockUserRepo := new(MockUserRepository)
+	mockTokenRepo := new(MockTokenRepository)
+	jwtSecret := "test-secret-key"
+	svc := service.NewAuthServiceWithOptions(mockUserRepo, mockTokenRepo, jwtSecret, time.Hour, service.AuthServiceOptions{RefreshLifetime: 48 * time.Hour})
+
+	userID := uint(123)
+
+	t.Run("Success", func(t *testing.T) {
+		var recorded []*model.IssuedToken
+		mockUserRepo.On("FindByID", userID).Return(createTestUser(userID), nil).Once()
+		mockTokenRepo.On("AddIssued", mock.Anything).
+			Run(func(args mock.Arguments) { recorded = args.Get(0).([]*model.IssuedToken) }).
+			Return(nil).Once()
+
+		access, refresh, err := svc.GeneratePair(userID)
+		require.NoError(t, err)
+
+		accessClaims, refreshClaims := parseClaims(t, access, jwtSecret), parseClaims(t, refresh, jwtSecret)
+		assert.Equal(t, "access_token", accessClaims.Subject)
+		assert.Equal(t, "refresh_token", refreshClaims.Subject)
+		assert.Equal(t, userID, refreshClaims.UserID)
+		assert.NotEqual(t, accessClaims.ID, refreshClaims.ID)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), accessClaims.ExpiresAt.Time, 2*time.Second)
+		assert.WithinDuration(t, time.Now().Add(48*time.Hour), refreshClaims.ExpiresAt.Time, 2*time.Second)
+
+		require.Len(t, recorded, 2, "both tokens are recorded for their user")
+		assert.Equal(t, accessClaims.ID, recorded[0].JTI)
+		assert.Equal(t, "access_token", recorded[0].Subject)
+		assert.Equal(t, refreshClaims.ID, recorded[1].JTI)
+		assert.Equal(t, "refresh_token", recorded[1].Subject)
+		assert.Equal(t, userID, recorded[1].UserID)
+		assert.WithinDuration(t, refreshClaims.ExpiresAt.Time, recorded[1].ExpiresAt, time.Second)
+		mockUserRepo.AssertExpectations(t)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("User Not Found", func(t *testing.T) {
+		mockUserRepo.On("FindByID", userID).Return(nil, errors.New("user not found")).Once()
+
+		access, refresh, err := svc.GeneratePair(userID)
+		assert.Error(t, err)
+		assert.Empty(t, access)
+		assert.Empty(t, refresh)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Recording Fails", func(t *testing.T) {
+		mockUserRepo.On("FindByID", userID).Return(createTestUser(userID), nil).Once()
+		mockTokenRepo.On("AddIssued", mock.Anything).Return(errors.New("db error")).Once()
+
+		access, refresh, err := svc.GeneratePair(userID)
+		assert.EqualError(t, err, "db error")
+		assert.Empty(t, access)
+		assert.Empty(t, refresh)
+	})
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	jwtSecret := "test-secret-key"
+	userID := uint(123)
+
+	// newPair returns a service and a refresh token it issued.
+	newPair := func(t *testing.T) (service.AuthService, *MockUserRepository, *MockTokenRepository, string, string) {
+		mockUserRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockTokenRepository)
+		svc := service.NewAuthServiceWithOptions(mockUserRepo, mockTokenRepo, jwtSecret, time.Hour, service.AuthServiceOptions{RefreshLifetime: 48 * time.Hour})
+
+		mockUserRepo.On("FindByID", userID).Return(createTestUser(userID), nil).Once()
+		mockTokenRepo.On("AddIssued", mock.Anything).Return(nil).Once()
+		access, refresh, err := svc.GeneratePair(userID)
+		require.NoError(t, err)
+		return svc, mockUserRepo, mockTokenRepo, access, refresh
+	}
+
+	t.Run("Rotates", func(t *testing.T) {
+		svc, mockUserRepo, mockTokenRepo, _, refresh := newPair(t)
+		old := parseClaims(t, refresh, jwtSecret)
+
+		mockTokenRepo.On("IsBlacklisted", old.ID).Return(false, nil).Once()
+		mockUserRepo.On("FindByID", userID).Return(createTestUser(userID), nil).Once()
+		mockTokenRepo.On("RotateRefresh", old.ID, old.ExpiresAt.Time, mock.MatchedBy(func(issued []*model.IssuedToken) bool {
+			return len(issued) == 2 &&
+				issued[0].Subject == "access_token" && issued[1].Subject == "refresh_token" &&
+				issued[1].UserID == userID && issued[1].JTI != old.ID
+		})).Return(nil).Once()
+
+		newAccess, newRefresh, err := svc.Refresh(refresh)
+		require.NoError(t, err)
+		assert.NotEqual(t, refresh, newRefresh)
+		assert.Equal(t, "access_token", parseClaims(t, newAccess, jwtSecret).Subject)
+		assert.Equal(t, "refresh_token", parseClaims(t, newRefresh, jwtSecret).Subject)
+		mockUserRepo.AssertExpectations(t)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Reused Token Revokes All", func(t *testing.T) {
+		svc, _, mockTokenRepo, _, refresh := newPair(t)
+		old := parseClaims(t, refresh, jwtSecret)
+
+		mockTokenRepo.On("IsBlacklisted", old.ID).Return(true, nil).Once()
+		mockTokenRepo.On("RevokeUserTokens", userID, "refresh_token").Return(3, nil).Once()
+
+		newAccess, newRefresh, err := svc.Refresh(refresh)
+		assert.ErrorIs(t, err, service.ErrTokenReused)
+		assert.Empty(t, newAccess)
+		assert.Empty(t, newRefresh)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Concurrent Rotation Counts As Reuse", func(t *testing.T) {
+		svc, mockUserRepo, mockTokenRepo, _, refresh := newPair(t)
+		old := parseClaims(t, refresh, jwtSecret)
+
+		mockTokenRepo.On("IsBlacklisted", old.ID).Return(false, nil).Once()
+		mockUserRepo.On("FindByID", userID).Return(createTestUser(userID), nil).Once()
+		mockTokenRepo.On("RotateRefresh", old.ID, old.ExpiresAt.Time, mock.Anything).Return(repository.ErrRefreshTokenUsed).Once()
+		mockTokenRepo.On("RevokeUserTokens", userID, "refresh_token").Return(2, nil).Once()
+
+		_, _, err := svc.Refresh(refresh)
+		assert.ErrorIs(t, err, service.ErrTokenReused)
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Revoking Fails", func(t *testing.T) {
+		svc, _, mockTokenRepo, _, refresh := newPair(t)
+		old := parseClaims(t, refresh, jwtSecret)
+
+		mockTokenRepo.On("IsBlacklisted", old.ID).Return(true, nil).Once()
+		mockTokenRepo.On("RevokeUserTokens", userID, "refresh_token").Return(0, errors.New("db error")).Once()
+
+		_, _, err := svc.Refresh(refresh)
+		assert.ErrorIs(t, err, service.ErrTokenBlacklistFail)
+	})
+
+	t.Run("Access Token Rejected", func(t *testing.T) {
+		svc, _, mockTokenRepo, access, _ := newPair(t)
+
+		_, _, err := svc.Refresh(access)
+		assert.Equal(t, service.ErrTokenInvalid, err)
+		mockTokenRepo.AssertNotCalled(t, "RevokeUserTokens", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Refresh Token Cannot Authenticate", func(t *testing.T) {
+		svc, _, mockTokenRepo, _, refresh := newPair(t)
+		mockTokenRepo.On("IsBlacklisted", parseClaims(t, refresh, jwtSecret).ID).Return(false, nil).Once()
+
+		claims, err := svc.Validate(refresh)
+		assert.Equal(t, service.ErrTokenInvalid, err)
+		assert.Nil(t, claims)
+	})
+
+	t.Run("Invalidate Outlives Refresh Token", func(t *testing.T) {
+		svc, _, mockTokenRepo, _, refresh := newPair(t)
+		old := parseClaims(t, refresh, jwtSecret)
+
+		mockTokenRepo.On("Add", mock.MatchedBy(func(token *model.BlacklistedToken) bool {
+			return token.JTI == old.ID && !token.ExpiresAt.Before(old.ExpiresAt.Time)
+		})).Return(nil).Once()
+		require.NoError(t, svc.Invalidate(old.ID))
+		mockTokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		svc, _, _, _, _ := newPair(t)
+		expired := jwt.NewWithClaims(jwt.SigningMethodHS256, service.Claims{
+			UserID: userID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "expired-refresh",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+				Subject:   "refresh_token",
+			},
+		})
+		expiredToken, err := expired.SignedString([]byte(jwtSecret))
+		require.NoError(t, err)
+
+		_, _, err = svc.Refresh(expiredToken)
+		assert.Equal(t, service.ErrTokenExpired, err)
+	})
+}
+
 func TestAuthService_IsTokenRevoked(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockTokenRepo := new(MockTokenRepository)
@@ -0,0 +1,178 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/repository"
+	"github.com/fuzumoe/linkTorch-api/internal/service"
+)
+
+// readExport unzips an export archive into its files' contents.
+func readExport(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = b
+	}
+	return files
+}
+
+func TestExportService_Export(t *testing.T) {
+	userID := uint(7)
+	firstPage := repository.Pagination{Page: 1, PageSize: 200}
+	urlFilter := repository.URLFilter{Sort: "id"}
+
+	t.Run("Seeded User", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		urlRepo := new(MockURLRepo)
+		analysisRepo := new(MockAnalysisRepo)
+		linkRepo := new(MockLinkRepo)
+		notificationRepo := new(MockNotificationRepo)
+		svc := service.NewExportService(userRepo, urlRepo, analysisRepo, linkRepo, notificationRepo)
+
+		userRepo.On("FindByID", userID).Return(createTestUser(userID), nil)
+		notificationRepo.On("FindByUser", userID).Return(nil, gorm.ErrRecordNotFound)
+		urlRepo.On("ListByUser", userID, urlFilter, firstPage).Return([]model.URL{
+			{ID: 1, UserID: userID, OriginalURL: "https://a.example", Status: model.StatusDone,
+				Tags: []model.URLTag{{Name: "docs"}, {Name: "blog"}}},
+			{ID: 2, UserID: userID, OriginalURL: "https://b.example", Status: model.StatusQueued,
+				Tags: []model.URLTag{{Name: "docs"}}},
+		}, nil).Times(3)
+		analysisRepo.On("ListByURL", uint(1), firstPage).Return([]model.AnalysisResult{
+			{ID: 10, URLID: 1, Title: "A", BrokenLinkCount: 1},
+		}, nil)
+		analysisRepo.On("ListByURL", uint(2), firstPage).Return([]model.AnalysisResult{}, nil)
+		linkRepo.On("ListByURL", uint(1), firstPage).Return([]model.Link{
+			{ID: 100, URLID: 1, Href: "https://a.example/x", StatusCode: 404},
+			{ID: 101, URLID: 1, Href: "https://a.example/y", StatusCode: 200},
+		}, nil)
+		linkRepo.On("ListByURL", uint(2), firstPage).Return([]model.Link{}, nil)
+
+		var buf bytes.Buffer
+		require.NoError(t, svc.Export(userID, &buf))
+		files := readExport(t, buf.Bytes())
+
+		assert.ElementsMatch(t, []string{
+			service.ExportManifestFile,
+			service.ExportProfileFile,
+			service.ExportNotificationsFile,
+			service.ExportURLsFile,
+			service.ExportTagsFile,
+			service.ExportAnalysisResultsFile,
+			service.ExportLinksFile,
+		}, exportFileNames(files))
+
+		var manifest service.ExportManifest
+		require.NoError(t, json.Unmarshal(files[service.ExportManifestFile], &manifest))
+		assert.Equal(t, service.ExportSchemaVersion, manifest.SchemaVersion)
+		assert.Equal(t, userID, manifest.UserID)
+		assert.False(t, manifest.ExportedAt.IsZero())
+		assert.ElementsMatch(t, exportFileNames(files), append(manifest.Files, service.ExportManifestFile),
+			"the manifest lists every other file")
+
+		var profile model.UserDTO
+		require.NoError(t, json.Unmarshal(files[service.ExportProfileFile], &profile))
+		assert.Equal(t, userID, profile.ID)
+		assert.Equal(t, "test@example.com", profile.Email)
+		assert.NotContains(t, string(files[service.ExportProfileFile]), "$2a$", "password hashes are never exported")
+
+		var prefs model.NotificationPrefs
+		require.NoError(t, json.Unmarshal(files[service.ExportNotificationsFile], &prefs))
+		assert.Equal(t, model.DigestOff, prefs.DigestFrequency, "users without saved settings get the defaults")
+
+		var urls []model.URLDTO
+		require.NoError(t, json.Unmarshal(files[service.ExportURLsFile], &urls))
+		require.Len(t, urls, 2)
+		assert.Equal(t, []string{"docs", "blog"}, urls[0].Tags)
+
+		var tags map[string][]uint
+		require.NoError(t, json.Unmarshal(files[service.ExportTagsFile], &tags))
+		assert.Equal(t, map[string][]uint{"docs": {1, 2}, "blog": {1}}, tags)
+
+		var results []model.AnalysisResult
+		require.NoError(t, json.Unmarshal(files[service.ExportAnalysisResultsFile], &results))
+		require.Len(t, results, 1)
+		assert.Equal(t, uint(10), results[0].ID)
+		assert.Equal(t, 1, results[0].BrokenLinkCount)
+
+		var links []model.Link
+		require.NoError(t, json.Unmarshal(files[service.ExportLinksFile], &links))
+		require.Len(t, links, 2)
+		assert.Equal(t, "https://a.example/x", links[0].Href)
+
+		urlRepo.AssertExpectations(t)
+		analysisRepo.AssertExpectations(t)
+		linkRepo.AssertExpectations(t)
+	})
+
+	t.Run("User Without URLs", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		urlRepo := new(MockURLRepo)
+		notificationRepo := new(MockNotificationRepo)
+		svc := service.NewExportService(userRepo, urlRepo, new(MockAnalysisRepo), new(MockLinkRepo), notificationRepo)
+
+		userRepo.On("FindByID", userID).Return(createTestUser(userID), nil)
+		notificationRepo.On("FindByUser", userID).Return(&model.NotificationPrefs{UserID: userID, DigestFrequency: "weekly"}, nil)
+		urlRepo.On("ListByUser", userID, urlFilter, firstPage).Return([]model.URL{}, nil)
+
+		var buf bytes.Buffer
+		require.NoError(t, svc.Export(userID, &buf))
+		files := readExport(t, buf.Bytes())
+
+		assert.JSONEq(t, `[]`, string(files[service.ExportURLsFile]))
+		assert.JSONEq(t, `{}`, string(files[service.ExportTagsFile]))
+		assert.JSONEq(t, `[]`, string(files[service.ExportAnalysisResultsFile]))
+		assert.JSONEq(t, `[]`, string(files[service.ExportLinksFile]))
+		assert.Contains(t, string(files[service.ExportNotificationsFile]), `"weekly"`)
+	})
+
+	t.Run("User Not Found", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		svc := service.NewExportService(userRepo, new(MockURLRepo), new(MockAnalysisRepo), new(MockLinkRepo), new(MockNotificationRepo))
+		userRepo.On("FindByID", userID).Return(nil, gorm.ErrRecordNotFound)
+
+		var buf bytes.Buffer
+		err := svc.Export(userID, &buf)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.Zero(t, buf.Len(), "nothing is written before the user is found")
+	})
+
+	t.Run("Repository Error", func(t *testing.T) {
+		userRepo := new(MockUserRepository)
+		urlRepo := new(MockURLRepo)
+		notificationRepo := new(MockNotificationRepo)
+		svc := service.NewExportService(userRepo, urlRepo, new(MockAnalysisRepo), new(MockLinkRepo), notificationRepo)
+
+		userRepo.On("FindByID", userID).Return(createTestUser(userID), nil)
+		notificationRepo.On("FindByUser", userID).Return(nil, gorm.ErrRecordNotFound)
+		urlRepo.On("ListByUser", userID, urlFilter, firstPage).Return([]model.URL(nil), errors.New("db down"))
+
+		err := svc.Export(userID, io.Discard)
+		assert.EqualError(t, err, "db down")
+	})
+}
+
+func exportFileNames(m map[string][]byte) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
@@ -108,4 +108,38 @@ func TestHealthService(t *testing.T) {
 			t.Errorf("unfulfilled expectations: %v", err)
 		}
 	})
+	t.Run("Crawler Pool", func(t *testing.T) {
+		sqlDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		if err != nil {
+			t.Fatalf("failed to open sqlmock database: %v", err)
+		}
+		defer sqlDB.Close()
+
+		mock.ExpectPing().WillReturnError(nil)
+		mock.ExpectPing().WillReturnError(nil)
+		mock.ExpectPing().WillReturnError(nil)
+
+		gdb, err := gorm.Open(mysql.New(mysql.Config{
+			Conn:                      sqlDB,
+			SkipInitializeWithVersion: true,
+		}), &gorm.Config{})
+		if err != nil {
+			t.Fatalf("failed to open gorm db: %v", err)
+		}
+
+		status := service.NewHealthServiceWithCrawler(gdb, "TestService", &DummyCrawlerPool{}).Check()
+		if status.Crawler != "running" || !status.Healthy {
+			t.Errorf("expected a running crawler and a healthy service, got %q healthy=%v", status.Crawler, status.Healthy)
+		}
+
+		stopped := new(MockCrawlerPool)
+		stopped.On("Running").Return(false)
+		status = service.NewHealthServiceWithCrawler(gdb, "TestService", stopped).Check()
+		if status.Crawler != "stopped" || status.Healthy {
+			t.Errorf("expected a stopped crawler and an unhealthy service, got %q healthy=%v", status.Crawler, status.Healthy)
+		}
+		if status.Database != "healthy" {
+			t.Errorf("expected database 'healthy', got %s", status.Database)
+		}
+	})
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
@@ -42,6 +43,56 @@ func (m *MockLinkRepo) Delete(link *model.Link) error {
 	return args.Error(0)
 }
 
+func (m *MockLinkRepo) ListByUser(userID uint, f repository.LinkFilter, p repository.Pagination) ([]model.UserLinkDTO, error) {
+	args := m.Called(userID, f, p)
+	return args.Get(0).([]model.UserLinkDTO), args.Error(1)
+}
+
+func (m *MockLinkRepo) CountByUser(userID uint, f repository.LinkFilter) (int, error) {
+	args := m.Called(userID, f)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockLinkRepo) StatusSummary(urlID, userID uint) (*model.LinkStatusSummaryDTO, error) {
+	args := m.Called(urlID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.LinkStatusSummaryDTO), args.Error(1)
+}
+
+func (m *MockLinkRepo) LatestRuns(urlID, userID uint, n int) ([]model.LinkRun, error) {
+	args := m.Called(urlID, userID, n)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.LinkRun), args.Error(1)
+}
+
+func (m *MockLinkRepo) DomainImpact(domain string) (*model.DomainImpactDTO, error) {
+	args := m.Called(domain)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.DomainImpactDTO), args.Error(1)
+}
+
+func (m *MockLinkRepo) ListDomainImpact(domain string, p repository.Pagination) ([]model.DomainImpactURLDTO, error) {
+	args := m.Called(domain, p)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.DomainImpactURLDTO), args.Error(1)
+}
+
+func (m *MockLinkRepo) TopBrokenDomains(limit int) ([]model.BrokenDomainDTO, error) {
+	args := m.Called(limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.BrokenDomainDTO), args.Error(1)
+}
+
 func testSimpleRepoOperation(t *testing.T, testName string, operation func(repo *MockLinkRepo) error) {
 	mockRepo := new(MockLinkRepo)
 
@@ -250,6 +301,70 @@ func TestLinkService_ListByURL(t *testing.T) {
 	})
 }
 
+func TestLinkService_ListByUser(t *testing.T) {
+
+	mockRepo := new(MockLinkRepo)
+	svc := service.NewLinkService(mockRepo)
+
+	userID := uint(7)
+	external := true
+	filter := repository.LinkFilter{IsExternal: &external, Search: "docs"}
+	pagination := repository.Pagination{Page: 1, PageSize: 10}
+
+	links := []model.UserLinkDTO{
+		{
+			LinkDTO:     model.LinkDTO{ID: 1, URLID: 10, Href: "https://docs.example.com/a", IsExternal: true, StatusCode: 200},
+			OriginalURL: "https://site-one.com",
+			URLTitle:    "Site One",
+		},
+		{
+			LinkDTO:     model.LinkDTO{ID: 2, URLID: 11, Href: "https://docs.example.com/b", IsExternal: true, StatusCode: 404},
+			OriginalURL: "https://site-two.com",
+			URLTitle:    "Site Two",
+		},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo.On("ListByUser", userID, filter, pagination).Return(links, nil).Once()
+		mockRepo.On("CountByUser", userID, filter).Return(12, nil).Once()
+
+		result, err := svc.ListByUser(userID, filter, pagination)
+
+		require.NoError(t, err)
+		require.Len(t, result.Data, 2)
+		assert.Equal(t, uint(10), result.Data[0].URLID)
+		assert.Equal(t, "Site One", result.Data[0].URLTitle)
+		assert.Equal(t, uint(11), result.Data[1].URLID)
+		assert.Equal(t, "https://site-two.com", result.Data[1].OriginalURL)
+		assert.Equal(t, 12, result.Pagination.TotalItems)
+		assert.Equal(t, 2, result.Pagination.TotalPages)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Repository Error on ListByUser", func(t *testing.T) {
+		expectedErr := errors.New("database error")
+		mockRepo.On("ListByUser", userID, filter, pagination).Return([]model.UserLinkDTO{}, expectedErr).Once()
+
+		result, err := svc.ListByUser(userID, filter, pagination)
+
+		assert.Equal(t, expectedErr, err)
+		assert.Nil(t, result)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Repository Error on CountByUser", func(t *testing.T) {
+		expectedErr := errors.New("count error")
+		mockRepo.On("ListByUser", userID, filter, pagination).Return(links, nil).Once()
+		mockRepo.On("CountByUser", userID, filter).Return(0, expectedErr).Once()
+
+		result, err := svc.ListByUser(userID, filter, pagination)
+
+		assert.Equal(t, expectedErr, err)
+		assert.Nil(t, result)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
 func TestLinkService_Update(t *testing.T) {
 	testLink := &model.Link{
 		ID:         1,
@@ -279,3 +394,166 @@ func TestLinkService_Delete(t *testing.T) {
 		return svc.Delete(testLink)
 	})
 }
+
+func TestLinkService_StatusSummary(t *testing.T) {
+	mockRepo := new(MockLinkRepo)
+	svc := service.NewLinkService(mockRepo)
+
+	summary := &model.LinkStatusSummaryDTO{URLID: 4, Status2xx: 2, Status5xx: 1, Total: 3}
+	mockRepo.On("StatusSummary", uint(4), uint(7)).Return(summary, nil).Once()
+	mockRepo.On("StatusSummary", uint(5), uint(7)).Return(nil, errors.New("record not found")).Once()
+
+	got, err := svc.StatusSummary(4, 7)
+	require.NoError(t, err)
+	assert.Equal(t, summary, got)
+
+	_, err = svc.StatusSummary(5, 7)
+	assert.Error(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLinkService_Changes(t *testing.T) {
+	t.Run("Two Runs", func(t *testing.T) {
+		mockRepo := new(MockLinkRepo)
+		svc := service.NewLinkService(mockRepo)
+		mockRepo.On("LatestRuns", uint(4), uint(7), 2).Return([]model.LinkRun{
+			{AnalysisResultID: 12, Hrefs: []string{"https://c.example.com", "https://a.example.com", "https://d.example.com"}},
+			{AnalysisResultID: 11, Hrefs: []string{"https://a.example.com", "https://b.example.com"}},
+		}, nil).Once()
+
+		got, err := svc.Changes(4, 7)
+		require.NoError(t, err)
+		assert.Equal(t, uint(4), got.URLID)
+		require.NotNil(t, got.CurrentRunID)
+		require.NotNil(t, got.PreviousRunID)
+		assert.Equal(t, uint(12), *got.CurrentRunID)
+		assert.Equal(t, uint(11), *got.PreviousRunID)
+		assert.Equal(t, []string{"https://c.example.com", "https://d.example.com"}, got.Added)
+		assert.Equal(t, []string{"https://b.example.com"}, got.Removed)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Single Run", func(t *testing.T) {
+		mockRepo := new(MockLinkRepo)
+		svc := service.NewLinkService(mockRepo)
+		mockRepo.On("LatestRuns", uint(4), uint(7), 2).Return([]model.LinkRun{
+			{AnalysisResultID: 3, Hrefs: []string{"https://b.example.com", "https://a.example.com", "https://a.example.com"}},
+		}, nil).Once()
+
+		got, err := svc.Changes(4, 7)
+		require.NoError(t, err)
+		assert.Nil(t, got.PreviousRunID)
+		assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, got.Added)
+		assert.Empty(t, got.Removed)
+	})
+
+	t.Run("No Runs", func(t *testing.T) {
+		mockRepo := new(MockLinkRepo)
+		svc := service.NewLinkService(mockRepo)
+		mockRepo.On("LatestRuns", uint(4), uint(7), 2).Return([]model.LinkRun{}, nil).Once()
+
+		got, err := svc.Changes(4, 7)
+		require.NoError(t, err)
+		assert.Nil(t, got.CurrentRunID)
+		assert.Empty(t, got.Added)
+		assert.Empty(t, got.Removed)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mockRepo := new(MockLinkRepo)
+		svc := service.NewLinkService(mockRepo)
+		mockRepo.On("LatestRuns", uint(5), uint(7), 2).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		_, err := svc.Changes(5, 7)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+func TestLinkService_DomainImpact(t *testing.T) {
+	pagination := repository.Pagination{Page: 1, PageSize: 2}
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockLinkRepo)
+		svc := service.NewLinkService(mockRepo)
+		mockRepo.On("DomainImpact", "cdn.example.com").
+			Return(&model.DomainImpactDTO{Domain: "cdn.example.com", Links: 9, URLs: 3, Users: 2}, nil).Once()
+		mockRepo.On("ListDomainImpact", "cdn.example.com", pagination).Return([]model.DomainImpactURLDTO{
+			{URLID: 4, UserID: 1, OriginalURL: "https://a.example.org", Links: 5},
+			{URLID: 7, UserID: 2, OriginalURL: "https://b.example.org", Links: 3},
+		}, nil).Once()
+
+		got, err := svc.DomainImpact(" CDN.Example.com. ", pagination)
+		require.NoError(t, err)
+		assert.Equal(t, "cdn.example.com", got.Domain)
+		assert.Equal(t, 9, got.Links)
+		assert.Equal(t, 2, got.Users)
+		assert.Len(t, got.AffectedURLs.Data, 2)
+		assert.Equal(t, 3, got.AffectedURLs.Pagination.TotalItems)
+		assert.Equal(t, 2, got.AffectedURLs.Pagination.TotalPages)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("No Links", func(t *testing.T) {
+		mockRepo := new(MockLinkRepo)
+		svc := service.NewLinkService(mockRepo)
+		mockRepo.On("DomainImpact", "quiet.example.com").
+			Return(&model.DomainImpactDTO{Domain: "quiet.example.com"}, nil).Once()
+		mockRepo.On("ListDomainImpact", "quiet.example.com", pagination).Return(nil, nil).Once()
+
+		got, err := svc.DomainImpact("quiet.example.com", pagination)
+		require.NoError(t, err)
+		assert.NotNil(t, got.AffectedURLs.Data)
+		assert.Empty(t, got.AffectedURLs.Data)
+		assert.Zero(t, got.AffectedURLs.Pagination.TotalPages)
+	})
+
+	t.Run("Repository Error", func(t *testing.T) {
+		mockRepo := new(MockLinkRepo)
+		svc := service.NewLinkService(mockRepo)
+		mockRepo.On("DomainImpact", "cdn.example.com").Return(nil, errors.New("db down")).Once()
+
+		_, err := svc.DomainImpact("cdn.example.com", pagination)
+		assert.EqualError(t, err, "db down")
+	})
+}
+
+func TestLinkService_TopBrokenDomains(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockLinkRepo)
+		svc := service.NewLinkService(mockRepo)
+		want := []model.BrokenDomainDTO{
+			{Domain: "cdn.down.com", BrokenLinks: 7, URLs: 4, Users: 3},
+			{Domain: "gone.net", BrokenLinks: 2, URLs: 1, Users: 1},
+		}
+		mockRepo.On("TopBrokenDomains", 5).Return(want, nil).Once()
+
+		got, err := svc.TopBrokenDomains(5)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Limit Defaults And Caps", func(t *testing.T) {
+		mockRepo := new(MockLinkRepo)
+		svc := service.NewLinkService(mockRepo)
+		mockRepo.On("TopBrokenDomains", service.DefaultTopBrokenDomains).Return(nil, nil).Once()
+		mockRepo.On("TopBrokenDomains", service.MaxTopBrokenDomains).Return(nil, nil).Once()
+
+		got, err := svc.TopBrokenDomains(0)
+		require.NoError(t, err)
+		assert.NotNil(t, got)
+		assert.Empty(t, got)
+		_, err = svc.TopBrokenDomains(service.MaxTopBrokenDomains + 1)
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Repository Error", func(t *testing.T) {
+		mockRepo := new(MockLinkRepo)
+		svc := service.NewLinkService(mockRepo)
+		mockRepo.On("TopBrokenDomains", service.DefaultTopBrokenDomains).Return(nil, errors.New("db down")).Once()
+
+		_, err := svc.TopBrokenDomains(0)
+		assert.EqualError(t, err, "db down")
+	})
+}
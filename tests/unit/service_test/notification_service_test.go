@@ -0,0 +1,116 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/service"
+)
+
+type MockNotificationRepo struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepo) FindByUser(userID uint) (*model.NotificationPrefs, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.NotificationPrefs), args.Error(1)
+}
+
+func (m *MockNotificationRepo) Save(prefs *model.NotificationPrefs) error {
+	return m.Called(prefs).Error(0)
+}
+
+func TestNotificationService_Get(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		repo := new(MockNotificationRepo)
+		svc := service.NewNotificationService(repo)
+		repo.On("FindByUser", uint(3)).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		prefs, err := svc.Get(3)
+		require.NoError(t, err)
+		assert.Equal(t, model.DefaultNotificationPrefs(3), prefs)
+		assert.Equal(t, model.DigestOff, prefs.DigestFrequency)
+		assert.Equal(t, []string{model.AlertChannelEmail}, prefs.AlertChannels)
+		assert.False(t, prefs.WebhookEnabled)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Stored", func(t *testing.T) {
+		repo := new(MockNotificationRepo)
+		svc := service.NewNotificationService(repo)
+		stored := &model.NotificationPrefs{UserID: 3, DigestFrequency: model.DigestDaily, WebhookEnabled: true}
+		repo.On("FindByUser", uint(3)).Return(stored, nil).Once()
+
+		prefs, err := svc.Get(3)
+		require.NoError(t, err)
+		assert.Equal(t, model.DigestDaily, prefs.DigestFrequency)
+		assert.Equal(t, []string{}, prefs.AlertChannels)
+		assert.True(t, prefs.WebhookEnabled)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		repo := new(MockNotificationRepo)
+		svc := service.NewNotificationService(repo)
+		repo.On("FindByUser", uint(3)).Return(nil, errors.New("db down")).Once()
+
+		_, err := svc.Get(3)
+		assert.EqualError(t, err, "db down")
+	})
+}
+
+func TestNotificationService_Update(t *testing.T) {
+	t.Run("Partial Update Keeps Defaults", func(t *testing.T) {
+		repo := new(MockNotificationRepo)
+		svc := service.NewNotificationService(repo)
+		repo.On("FindByUser", uint(3)).Return(nil, gorm.ErrRecordNotFound).Once()
+		repo.On("Save", mock.AnythingOfType("*model.NotificationPrefs")).Return(nil).Once()
+
+		weekly := model.DigestWeekly
+		prefs, err := svc.Update(3, &model.UpdateNotificationPrefsInput{DigestFrequency: &weekly})
+		require.NoError(t, err)
+		assert.Equal(t, uint(3), prefs.UserID)
+		assert.Equal(t, model.DigestWeekly, prefs.DigestFrequency)
+		assert.Equal(t, []string{model.AlertChannelEmail}, prefs.AlertChannels)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("All Fields", func(t *testing.T) {
+		repo := new(MockNotificationRepo)
+		svc := service.NewNotificationService(repo)
+		repo.On("FindByUser", uint(3)).Return(model.DefaultNotificationPrefs(3), nil).Once()
+		repo.On("Save", mock.MatchedBy(func(p *model.NotificationPrefs) bool {
+			return p.DigestFrequency == model.DigestDaily && p.WebhookEnabled &&
+				assert.ObjectsAreEqual([]string{"webhook", "email"}, p.AlertChannels)
+		})).Return(nil).Once()
+
+		daily, enabled := model.DigestDaily, true
+		channels := []string{"webhook", "email", "webhook"}
+		_, err := svc.Update(3, &model.UpdateNotificationPrefsInput{
+			DigestFrequency: &daily,
+			AlertChannels:   &channels,
+			WebhookEnabled:  &enabled,
+		})
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Save Error", func(t *testing.T) {
+		repo := new(MockNotificationRepo)
+		svc := service.NewNotificationService(repo)
+		repo.On("FindByUser", uint(3)).Return(nil, gorm.ErrRecordNotFound).Once()
+		repo.On("Save", mock.Anything).Return(errors.New("write failed")).Once()
+
+		enabled := true
+		_, err := svc.Update(3, &model.UpdateNotificationPrefsInput{WebhookEnabled: &enabled})
+		assert.EqualError(t, err, "write failed")
+	})
+}
@@ -0,0 +1,135 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/model"
+	"github.com/fuzumoe/linkTorch-api/internal/service"
+)
+
+type MockStatsRepo struct {
+	mock.Mock
+}
+
+func (m *MockStatsRepo) System(since time.Time) (*model.SystemStatsDTO, error) {
+	args := m.Called(since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.SystemStatsDTO), args.Error(1)
+}
+
+func (m *MockStatsRepo) RecentCrawlDuration(limit int) (time.Duration, int, error) {
+	args := m.Called(limit)
+	return args.Get(0).(time.Duration), args.Int(1), args.Error(2)
+}
+
+func TestStatsService_System(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		repo := new(MockStatsRepo)
+		pool := new(MockCrawlerPool)
+		svc := service.NewStatsService(repo, pool)
+
+		last24h := mock.MatchedBy(func(since time.Time) bool {
+			return time.Since(since) >= 24*time.Hour && time.Since(since) < 25*time.Hour
+		})
+		repo.On("System", last24h).Return(&model.SystemStatsDTO{
+			TotalUsers:  3,
+			TotalURLs:   10,
+			Crawls24h:   6,
+			CrawledURLs: 8,
+			ErroredURLs: 2,
+		}, nil).Once()
+		pool.On("QueueDepth").Return(4).Once()
+
+		stats, err := svc.System()
+		require.NoError(t, err)
+		assert.Equal(t, 3, stats.TotalUsers)
+		assert.Equal(t, 10, stats.TotalURLs)
+		assert.Equal(t, 6, stats.Crawls24h)
+		assert.Equal(t, 4, stats.QueueDepth)
+		assert.InDelta(t, 0.25, stats.ErrorRate, 1e-9)
+		repo.AssertExpectations(t)
+		pool.AssertExpectations(t)
+	})
+
+	t.Run("Nothing Crawled", func(t *testing.T) {
+		repo := new(MockStatsRepo)
+		pool := new(MockCrawlerPool)
+		svc := service.NewStatsService(repo, pool)
+
+		repo.On("System", mock.Anything).Return(&model.SystemStatsDTO{TotalURLs: 2}, nil).Once()
+		pool.On("QueueDepth").Return(0).Once()
+
+		stats, err := svc.System()
+		require.NoError(t, err)
+		assert.Zero(t, stats.ErrorRate)
+	})
+
+	t.Run("Repo Error", func(t *testing.T) {
+		repo := new(MockStatsRepo)
+		svc := service.NewStatsService(repo, new(MockCrawlerPool))
+
+		repo.On("System", mock.Anything).Return(nil, errors.New("db down")).Once()
+
+		_, err := svc.System()
+		assert.EqualError(t, err, "db down")
+	})
+}
+
+func TestStatsService_ETA(t *testing.T) {
+	eta := func(depth, workers int, avg time.Duration, samples int) *model.CrawlETADTO {
+		repo := new(MockStatsRepo)
+		pool := new(MockCrawlerPool)
+		repo.On("RecentCrawlDuration", 100).Return(avg, samples, nil).Once()
+		pool.On("QueueDepth").Return(depth).Once()
+		pool.On("Workers").Return(workers).Once()
+
+		out, err := service.NewStatsService(repo, pool).ETA()
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+		pool.AssertExpectations(t)
+		return out
+	}
+
+	t.Run("Known Queue", func(t *testing.T) {
+		out := eta(25, 5, 3*time.Second, 40)
+		assert.Equal(t, 25, out.QueueDepth)
+		assert.Equal(t, 5, out.Workers)
+		assert.Equal(t, int64(3000), out.AvgCrawlMs)
+		assert.Equal(t, 40, out.Samples)
+		require.NotNil(t, out.ETASeconds)
+		assert.Equal(t, int64(15), *out.ETASeconds, "5 rounds of 3s")
+	})
+
+	t.Run("Partial Round Counts", func(t *testing.T) {
+		out := eta(11, 4, 1500*time.Millisecond, 10)
+		require.NotNil(t, out.ETASeconds)
+		assert.Equal(t, int64(5), *out.ETASeconds, "3 rounds of 1.5s, rounded up")
+	})
+
+	t.Run("Empty Queue", func(t *testing.T) {
+		out := eta(0, 4, 0, 0)
+		require.NotNil(t, out.ETASeconds)
+		assert.Zero(t, *out.ETASeconds)
+	})
+
+	t.Run("No Timed Crawls Yet", func(t *testing.T) {
+		out := eta(8, 2, 0, 0)
+		assert.Nil(t, out.ETASeconds)
+	})
+
+	t.Run("Repo Error", func(t *testing.T) {
+		repo := new(MockStatsRepo)
+		repo.On("RecentCrawlDuration", 100).Return(time.Duration(0), 0, errors.New("db down")).Once()
+
+		_, err := service.NewStatsService(repo, new(MockCrawlerPool)).ETA()
+		assert.EqualError(t, err, "db down")
+	})
+}
@@ -4,29 +4,45 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 
 	"github.com/fuzumoe/linkTorch-api/internal/crawler"
+	"github.com/fuzumoe/linkTorch-api/internal/egress"
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
 	"github.com/fuzumoe/linkTorch-api/internal/service"
+	"github.com/fuzumoe/linkTorch-api/internal/storage"
 )
 
 type DummyCrawlerPool struct{}
 
-func (d *DummyCrawlerPool) Start(ctx context.Context)                 {}
-func (d *DummyCrawlerPool) Enqueue(id uint)                           {}
-func (d *DummyCrawlerPool) EnqueueWithPriority(id uint, priority int) {}
-func (d *DummyCrawlerPool) Shutdown()                                 {}
+func (d *DummyCrawlerPool) Start(ctx context.Context)                       {}
+func (d *DummyCrawlerPool) Enqueue(id uint) error                           { return nil }
+func (d *DummyCrawlerPool) EnqueueWithPriority(id uint, priority int) error { return nil }
+func (d *DummyCrawlerPool) EnqueueWithRequestID(id uint, priority int, requestID string) error {
+	return nil
+}
+func (d *DummyCrawlerPool) Shutdown() {}
 func (d *DummyCrawlerPool) GetResults() <-chan crawler.CrawlResult {
 	return make(chan crawler.CrawlResult)
 }
+func (d *DummyCrawlerPool) Reprioritize(id uint, priority int) error { return nil }
+func (d *DummyCrawlerPool) Snapshot(limit int) crawler.QueueSnapshot { return crawler.QueueSnapshot{} }
 func (d *DummyCrawlerPool) AdjustWorkers(cmd crawler.ControlCommand) {}
+func (d *DummyCrawlerPool) QueueDepth() int                          { return 0 }
+func (d *DummyCrawlerPool) Workers() int                             { return 1 }
+func (d *DummyCrawlerPool) Pause()                                   {}
+func (d *DummyCrawlerPool) Resume()                                  {}
+func (d *DummyCrawlerPool) Paused() bool                             { return false }
+func (d *DummyCrawlerPool) Running() bool                            { return true }
+func (d *DummyCrawlerPool) Stats() crawler.PoolStats                 { return crawler.PoolStats{} }
 
 type MockCrawlerPool struct {
 	mock.Mock
@@ -35,11 +51,31 @@ type MockCrawlerPool struct {
 func (m *MockCrawlerPool) Start(ctx context.Context) {
 	m.Called(ctx)
 }
-func (m *MockCrawlerPool) Enqueue(id uint) {
-	m.Called(id)
+func (m *MockCrawlerPool) Enqueue(id uint) error {
+	if args := m.Called(id); len(args) > 0 {
+		return args.Error(0)
+	}
+	return nil
+}
+func (m *MockCrawlerPool) EnqueueWithPriority(id uint, priority int) error {
+	if args := m.Called(id, priority); len(args) > 0 {
+		return args.Error(0)
+	}
+	return nil
+}
+func (m *MockCrawlerPool) EnqueueWithRequestID(id uint, priority int, requestID string) error {
+	if args := m.Called(id, priority, requestID); len(args) > 0 {
+		return args.Error(0)
+	}
+	return nil
+}
+func (m *MockCrawlerPool) Reprioritize(id uint, priority int) error {
+	args := m.Called(id, priority)
+	return args.Error(0)
 }
-func (m *MockCrawlerPool) EnqueueWithPriority(id uint, priority int) {
-	m.Called(id, priority)
+func (m *MockCrawlerPool) Snapshot(limit int) crawler.QueueSnapshot {
+	args := m.Called(limit)
+	return args.Get(0).(crawler.QueueSnapshot)
 }
 func (m *MockCrawlerPool) Shutdown() {
 	m.Called()
@@ -51,6 +87,33 @@ func (m *MockCrawlerPool) GetResults() <-chan crawler.CrawlResult {
 func (m *MockCrawlerPool) AdjustWorkers(cmd crawler.ControlCommand) {
 	m.Called(cmd)
 }
+func (m *MockCrawlerPool) QueueDepth() int {
+	args := m.Called()
+	return args.Int(0)
+}
+func (m *MockCrawlerPool) Workers() int {
+	args := m.Called()
+	return args.Int(0)
+}
+func (m *MockCrawlerPool) Pause() {
+	m.Called()
+}
+func (m *MockCrawlerPool) Resume() {
+	m.Called()
+}
+func (m *MockCrawlerPool) Paused() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+
+func (m *MockCrawlerPool) Running() bool {
+	args := m.Called()
+	return args.Bool(0)
+}
+func (m *MockCrawlerPool) Stats() crawler.PoolStats {
+	args := m.Called()
+	return args.Get(0).(crawler.PoolStats)
+}
 
 type MockURLRepo struct {
 	mock.Mock
@@ -69,16 +132,26 @@ func (m *MockURLRepo) FindByID(id uint) (*model.URL, error) {
 	return args.Get(0).(*model.URL), args.Error(1)
 }
 
-func (m *MockURLRepo) ListByUser(userID uint, p repository.Pagination) ([]model.URL, error) {
-	args := m.Called(userID, p)
+func (m *MockURLRepo) ListByUser(userID uint, f repository.URLFilter, p repository.Pagination) ([]model.URL, error) {
+	args := m.Called(userID, f, p)
 	return args.Get(0).([]model.URL), args.Error(1)
 }
 
-func (m *MockURLRepo) CountByUser(userID uint) (int, error) {
-	args := m.Called(userID)
+func (m *MockURLRepo) CountByUser(userID uint, f repository.URLFilter) (int, error) {
+	args := m.Called(userID, f)
 	return args.Int(0), args.Error(1)
 }
 
+func (m *MockURLRepo) CountByUserAndStatus(userID uint, statuses ...string) (int, error) {
+	args := m.Called(userID, statuses)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLRepo) ResetStuck(runningBefore time.Time, status string) ([]uint, error) {
+	args := m.Called(runningBefore, status)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
 func (m *MockURLRepo) Update(url *model.URL) error {
 	args := m.Called(url)
 	return args.Error(0)
@@ -115,10 +188,116 @@ func (m *MockURLRepo) ResultsWithDetails(id uint) (*model.URL, []*model.Analysis
 	return args.Get(0).(*model.URL), args.Get(1).([]*model.AnalysisResult), args.Get(2).([]*model.Link), args.Error(3)
 }
 
+func (m *MockURLRepo) Merge(srcID, dstID uint) error {
+	args := m.Called(srcID, dstID)
+	return args.Error(0)
+}
+
+func (m *MockURLRepo) FindExisting(userID uint, urls []string) ([]string, error) {
+	args := m.Called(userID, urls)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockURLRepo) ListUncrawled(userID uint, p repository.Pagination) ([]model.URL, error) {
+	args := m.Called(userID, p)
+	return args.Get(0).([]model.URL), args.Error(1)
+}
+
+func (m *MockURLRepo) CountUncrawled(userID uint) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLRepo) DeleteErrored(userID uint) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLRepo) CreateBatch(urls []*model.URL) error {
+	args := m.Called(urls)
+	return args.Error(0)
+}
+
+func (m *MockURLRepo) FindOwnedIDs(userID uint, ids []uint) ([]uint, error) {
+	args := m.Called(userID, ids)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockURLRepo) DeleteByIDs(ids []uint) (int, error) {
+	args := m.Called(ids)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLRepo) ListTagReport(userID uint, tag string, p repository.Pagination) ([]model.TagReportItemDTO, error) {
+	args := m.Called(userID, tag, p)
+	return args.Get(0).([]model.TagReportItemDTO), args.Error(1)
+}
+
+func (m *MockURLRepo) CountByTag(userID uint, tag string) (int, error) {
+	args := m.Called(userID, tag)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLRepo) TagSummary(userID uint, tag string) (*model.TagSummaryDTO, error) {
+	args := m.Called(userID, tag)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.TagSummaryDTO), args.Error(1)
+}
+
+func (m *MockURLRepo) FindIDsByTag(userID uint, tag string) ([]uint, error) {
+	args := m.Called(userID, tag)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockURLRepo) FindDeleted(id uint) (*model.URL, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.URL), args.Error(1)
+}
+
+func (m *MockURLRepo) ListDeletedByUser(userID uint, p repository.Pagination) ([]model.URL, error) {
+	args := m.Called(userID, p)
+	return args.Get(0).([]model.URL), args.Error(1)
+}
+
+func (m *MockURLRepo) CountDeletedByUser(userID uint) (int, error) {
+	args := m.Called(userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockURLRepo) Restore(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockURLRepo) ListByUserCursor(userID uint, f repository.URLFilter, c repository.Cursor) ([]model.URL, string, error) {
+	args := m.Called(userID, f, c)
+	return args.Get(0).([]model.URL), args.String(1), args.Error(2)
+}
+
+func (m *MockURLRepo) Purge(id uint) ([]string, error) {
+	args := m.Called(id)
+	keys, _ := args.Get(0).([]string)
+	return keys, args.Error(1)
+}
+
+func (m *MockURLRepo) PurgeOlderThan(d time.Duration) (int, []string, error) {
+	args := m.Called(d)
+	keys, _ := args.Get(1).([]string)
+	return args.Int(0), keys, args.Error(2)
+}
+
 func TestURLService_Create(t *testing.T) {
 	mockRepo := new(MockURLRepo)
 	dummyPool := &DummyCrawlerPool{}
-	svc := service.NewURLService(mockRepo, dummyPool)
+	svc := service.NewURLService(mockRepo, dummyPool, nil)
 
 	input := &model.CreateURLInputDTO{
 		UserID:      1,
@@ -158,12 +337,238 @@ func TestURLService_Create(t *testing.T) {
 		assert.Equal(t, uint(0), id)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("Crawl Method", func(t *testing.T) {
+		mockRepo.
+			On("Create", mock.MatchedBy(func(u *model.URL) bool {
+				return u.CrawlMethod == model.CrawlMethodFull
+			})).
+			Return(nil).
+			Once()
+		_, err := svc.Create(input)
+		require.NoError(t, err, "URLs are fully crawled unless asked otherwise")
+
+		mockRepo.
+			On("Create", mock.MatchedBy(func(u *model.URL) bool {
+				return u.CrawlMethod == model.CrawlMethodHeadOnly
+			})).
+			Return(nil).
+			Once()
+		_, err = svc.Create(&model.CreateURLInputDTO{UserID: 1, OriginalURL: "https://example.com", CrawlMethod: model.CrawlMethodHeadOnly})
+		require.NoError(t, err)
+
+		_, err = svc.Create(&model.CreateURLInputDTO{UserID: 1, OriginalURL: "https://example.com", CrawlMethod: "ping"})
+		assert.EqualError(t, err, "invalid crawl_method value")
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestURLService_CreateWithCrawl(t *testing.T) {
+	yes, no := true, false
+	setup := func(crawlOnCreate bool) (*MockURLRepo, *MockCrawlerPool, service.URLService) {
+		mockRepo, mockPool := new(MockURLRepo), new(MockCrawlerPool)
+		mockRepo.On("Create", mock.AnythingOfType("*model.URL")).
+			Run(func(args mock.Arguments) { args.Get(0).(*model.URL).ID = 42 }).
+			Return(nil).Once()
+		svc := service.NewURLServiceWithOptions(mockRepo, mockPool, nil, service.URLServiceOptions{CrawlOnCreate: crawlOnCreate})
+		return mockRepo, mockPool, svc
+	}
+
+	t.Run("Requested", func(t *testing.T) {
+		mockRepo, mockPool, svc := setup(false)
+		mockRepo.On("UpdateStatus", uint(42), model.StatusQueued).Return(nil).Once()
+		mockPool.On("Enqueue", uint(42)).Return(nil).Once()
+
+		created, err := svc.CreateWithCrawl(&model.CreateURLInputDTO{UserID: 1, OriginalURL: "https://example.com", Crawl: &yes})
+		require.NoError(t, err)
+		assert.Equal(t, &model.URLCreatedDTO{ID: 42, Enqueued: true}, created)
+		mockRepo.AssertExpectations(t)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("Not Requested", func(t *testing.T) {
+		mockRepo, mockPool, svc := setup(false)
+
+		created, err := svc.CreateWithCrawl(&model.CreateURLInputDTO{UserID: 1, OriginalURL: "https://example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, &model.URLCreatedDTO{ID: 42}, created)
+		mockRepo.AssertExpectations(t)
+		mockPool.AssertNotCalled(t, "Enqueue", mock.Anything)
+		mockPool.AssertNotCalled(t, "EnqueueWithPriority", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Default On", func(t *testing.T) {
+		mockRepo, mockPool, svc := setup(true)
+		mockRepo.On("UpdateStatus", uint(42), model.StatusQueued).Return(nil).Once()
+		mockPool.On("Enqueue", uint(42)).Return(nil).Once()
+
+		id, err := svc.Create(&model.CreateURLInputDTO{UserID: 1, OriginalURL: "https://example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(42), id)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("Default On Opted Out", func(t *testing.T) {
+		_, mockPool, svc := setup(true)
+
+		created, err := svc.CreateWithCrawl(&model.CreateURLInputDTO{UserID: 1, OriginalURL: "https://example.com", Crawl: &no})
+		require.NoError(t, err)
+		assert.False(t, created.Enqueued)
+		mockPool.AssertNotCalled(t, "Enqueue", mock.Anything)
+	})
+
+	t.Run("Queue Full", func(t *testing.T) {
+		mockRepo, mockPool, svc := setup(false)
+		mockRepo.On("UpdateStatus", uint(42), model.StatusQueued).Return(nil).Twice()
+		mockPool.On("Enqueue", uint(42)).Return(crawler.ErrQueueFull).Once()
+
+		created, err := svc.CreateWithCrawl(&model.CreateURLInputDTO{UserID: 1, OriginalURL: "https://example.com", Crawl: &yes})
+		require.NoError(t, err, "the URL is created even though it could not be queued")
+		assert.Equal(t, &model.URLCreatedDTO{ID: 42, CrawlError: crawler.ErrQueueFull.Error()}, created)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestURLService_Create_EgressAllowlist(t *testing.T) {
+	mockRepo := new(MockURLRepo)
+	policy := egress.NewPolicy(egress.ModeAllowlist, []string{"example.com"})
+	svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, policy)
+
+	t.Run("Allowed Host", func(t *testing.T) {
+		mockRepo.On("Create", mock.AnythingOfType("*model.URL")).Return(nil).Once()
+
+		_, err := svc.Create(&model.CreateURLInputDTO{UserID: 1, OriginalURL: "https://docs.example.com/start"})
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Rejected Host", func(t *testing.T) {
+		id, err := svc.Create(&model.CreateURLInputDTO{UserID: 1, OriginalURL: "https://other.org/"})
+		assert.ErrorIs(t, err, egress.ErrHostNotAllowed)
+		assert.Contains(t, err.Error(), "not in the crawl allowlist")
+		assert.Equal(t, uint(0), id)
+		mockRepo.AssertNotCalled(t, "Create", mock.MatchedBy(func(u *model.URL) bool {
+			return u.OriginalURL == "https://other.org/"
+		}))
+	})
+}
+
+func TestURLService_Create_HostTag(t *testing.T) {
+	create := func(t *testing.T, tagHost bool) *model.URL {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLServiceWithOptions(mockRepo, &DummyCrawlerPool{}, nil, service.URLServiceOptions{TagHost: tagHost})
+
+		var created *model.URL
+		mockRepo.On("Create", mock.AnythingOfType("*model.URL")).
+			Run(func(args mock.Arguments) { created = args.Get(0).(*model.URL) }).
+			Return(nil).
+			Once()
+
+		_, err := svc.Create(&model.CreateURLInputDTO{UserID: 1, OriginalURL: "https://Docs.Example.com/start"})
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		return created
+	}
+
+	t.Run("Enabled", func(t *testing.T) {
+		u := create(t, true)
+		assert.Equal(t, []model.URLTag{{Name: "docs.example.com"}}, u.Tags)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		u := create(t, false)
+		assert.Empty(t, u.Tags)
+	})
+}
+
+func TestURLService_CreateBulk(t *testing.T) {
+	policy := egress.NewPolicy(egress.ModeDenylist, []string{"blocked.example"})
+	assignIDs := func(args mock.Arguments) {
+		for i, u := range args.Get(0).([]*model.URL) {
+			u.ID = uint(100 + i)
+		}
+	}
+
+	t.Run("Partial Success", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, policy)
+		mockRepo.On("CreateBatch", mock.MatchedBy(func(urls []*model.URL) bool {
+			return len(urls) == 3 &&
+				urls[0].OriginalURL == "https://a.example/" &&
+				urls[1].OriginalURL == "https://b.example/" &&
+				urls[2].OriginalURL == "https://a.example/" && urls[2].UserID == 2
+		})).Run(assignIDs).Return(nil).Once()
+
+		ids, err := svc.CreateBulk([]*model.CreateURLInputDTO{
+			{UserID: 1, OriginalURL: "https://a.example/"},
+			{UserID: 1, OriginalURL: "https://blocked.example/"},
+			{UserID: 1, OriginalURL: "https://b.example/"},
+			{UserID: 1, OriginalURL: "https://a.example/"},
+			{UserID: 2, OriginalURL: "https://a.example/"},
+		})
+
+		var bulkErr *service.BulkCreateError
+		require.ErrorAs(t, err, &bulkErr)
+		require.Len(t, bulkErr.Failed, 1)
+		assert.ErrorIs(t, bulkErr.Failed[1], egress.ErrHostNotAllowed)
+		assert.Equal(t, []uint{100, 0, 101, 100, 102}, ids, "a repeat shares the id of its first occurrence")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("All Valid", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, policy)
+		mockRepo.On("CreateBatch", mock.AnythingOfType("[]*model.URL")).Run(assignIDs).Return(nil).Once()
+
+		ids, err := svc.CreateBulk([]*model.CreateURLInputDTO{{UserID: 1, OriginalURL: "https://a.example/"}})
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{100}, ids)
+	})
+
+	t.Run("Repository Error", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, policy)
+		mockRepo.On("CreateBatch", mock.AnythingOfType("[]*model.URL")).Return(errors.New("database error")).Once()
+
+		ids, err := svc.CreateBulk([]*model.CreateURLInputDTO{{UserID: 1, OriginalURL: "https://a.example/"}})
+		assert.EqualError(t, err, "database error")
+		assert.Nil(t, ids)
+	})
+}
+
+func TestURLService_Create_HTTPSOnly(t *testing.T) {
+	t.Run("Enabled Rejects HTTP", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		policy := egress.NewPolicyWithHTTPSOnly(egress.ModeDenylist, nil, true)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, policy)
+
+		id, err := svc.Create(&model.CreateURLInputDTO{UserID: 1, OriginalURL: "http://example.com/"})
+		assert.ErrorIs(t, err, egress.ErrHTTPSRequired)
+		assert.Equal(t, uint(0), id)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+
+		mockRepo.On("Create", mock.AnythingOfType("*model.URL")).Return(nil).Once()
+		_, err = svc.Create(&model.CreateURLInputDTO{UserID: 1, OriginalURL: "https://example.com/"})
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Disabled Accepts HTTP", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		policy := egress.NewPolicyWithHTTPSOnly(egress.ModeDenylist, nil, false)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, policy)
+
+		mockRepo.On("Create", mock.AnythingOfType("*model.URL")).Return(nil).Once()
+		_, err := svc.Create(&model.CreateURLInputDTO{UserID: 1, OriginalURL: "http://example.com/"})
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
 }
 
 func TestURLService_Get(t *testing.T) {
 	mockRepo := new(MockURLRepo)
 	dummyPool := &DummyCrawlerPool{}
-	svc := service.NewURLService(mockRepo, dummyPool)
+	svc := service.NewURLService(mockRepo, dummyPool, nil)
 
 	urlID := uint(42)
 	testURL := &model.URL{
@@ -198,10 +603,523 @@ func TestURLService_Get(t *testing.T) {
 	})
 }
 
+func TestURLService_ListUncrawled(t *testing.T) {
+	mockRepo := new(MockURLRepo)
+	svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+	pagination := repository.Pagination{Page: 2, PageSize: 2}
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo.On("ListUncrawled", uint(1), pagination).Return([]model.URL{
+			{ID: 3, UserID: 1, OriginalURL: "https://down.example.com", Status: model.StatusError},
+			{ID: 4, UserID: 1, OriginalURL: "https://new.example.com", Status: model.StatusQueued},
+		}, nil).Once()
+		mockRepo.On("CountUncrawled", uint(1)).Return(5, nil).Once()
+
+		result, err := svc.ListUncrawled(1, pagination)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Pagination.Page)
+		assert.Equal(t, 5, result.Pagination.TotalItems)
+		assert.Equal(t, 3, result.Pagination.TotalPages)
+		require.Len(t, result.Data, 2)
+		assert.Equal(t, uint(3), result.Data[0].ID)
+		assert.Equal(t, model.StatusQueued, result.Data[1].Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Count Error", func(t *testing.T) {
+		mockRepo.On("ListUncrawled", uint(1), pagination).Return([]model.URL{}, nil).Once()
+		mockRepo.On("CountUncrawled", uint(1)).Return(0, errors.New("count failed")).Once()
+
+		_, err := svc.ListUncrawled(1, pagination)
+		assert.EqualError(t, err, "count failed")
+	})
+}
+
+func TestURLService_ListCursor(t *testing.T) {
+	mockRepo := new(MockURLRepo)
+	svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+	filter := repository.URLFilter{Status: model.StatusDone}
+	cursor := repository.Cursor{After: "abc", Size: 2}
+
+	mockRepo.On("ListByUserCursor", uint(1), filter, cursor).Return([]model.URL{
+		{ID: 5, UserID: 1, OriginalURL: "https://five.example.com", Status: model.StatusDone},
+		{ID: 4, UserID: 1, OriginalURL: "https://four.example.com", Status: model.StatusDone},
+	}, "next", nil).Once()
+	page, err := svc.ListCursor(1, filter, cursor)
+	require.NoError(t, err)
+	require.Len(t, page.Data, 2)
+	assert.Equal(t, uint(5), page.Data[0].ID)
+	assert.Equal(t, "next", page.NextCursor)
+
+	mockRepo.On("ListByUserCursor", uint(1), filter, repository.Cursor{After: "bad"}).Return([]model.URL(nil), "", repository.ErrInvalidCursor).Once()
+	_, err = svc.ListCursor(1, filter, repository.Cursor{After: "bad"})
+	assert.ErrorIs(t, err, repository.ErrInvalidCursor)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestURLService_Trash(t *testing.T) {
+	t.Run("List Deleted", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		pagination := repository.Pagination{Page: 1, PageSize: 2}
+		mockRepo.On("ListDeletedByUser", uint(1), pagination).Return([]model.URL{
+			{ID: 6, UserID: 1, OriginalURL: "https://old.example.com", Status: model.StatusDone},
+		}, nil).Once()
+		mockRepo.On("CountDeletedByUser", uint(1)).Return(3, nil).Once()
+
+		result, err := svc.ListDeleted(1, pagination)
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.Pagination.TotalItems)
+		assert.Equal(t, 2, result.Pagination.TotalPages)
+		require.Len(t, result.Data, 1)
+		assert.Equal(t, uint(6), result.Data[0].ID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		mockRepo.On("FindDeleted", uint(6)).Return(&model.URL{ID: 6, UserID: 1}, nil).Once()
+		mockRepo.On("Restore", uint(6)).Return(nil).Once()
+
+		require.NoError(t, svc.Restore(6, 1))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Restore Other User's URL", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		mockRepo.On("FindDeleted", uint(6)).Return(&model.URL{ID: 6, UserID: 2}, nil).Once()
+
+		err := svc.Restore(6, 1)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		mockRepo.AssertNotCalled(t, "Restore", mock.Anything)
+	})
+
+	t.Run("Restore Unknown URL", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		mockRepo.On("FindDeleted", uint(9)).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		assert.ErrorIs(t, svc.Restore(9, 1), gorm.ErrRecordNotFound)
+	})
+}
+
+func TestURLService_Purge(t *testing.T) {
+	mockRepo := new(MockURLRepo)
+	svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+
+	mockRepo.On("Purge", uint(6)).Return(nil, nil).Once()
+	require.NoError(t, svc.Purge(6))
+
+	mockRepo.On("Purge", uint(7)).Return(nil, gorm.ErrRecordNotFound).Once()
+	assert.ErrorIs(t, svc.Purge(7), gorm.ErrRecordNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestURLService_PurgeDeletesRawHTML(t *testing.T) {
+	blobs, err := storage.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	for _, key := range []string{"6/a.html", "9/b.html", "kept.html"} {
+		require.NoError(t, blobs.Put(context.Background(), key, strings.NewReader("<html>")))
+	}
+	mockRepo := new(MockURLRepo)
+	svc := service.NewURLServiceWithOptions(mockRepo, &DummyCrawlerPool{}, nil, service.URLServiceOptions{RawHTML: blobs})
+
+	mockRepo.On("Purge", uint(6)).Return([]string{"6/a.html", "6/already-gone.html"}, nil).Once()
+	require.NoError(t, svc.Purge(6))
+	mockRepo.On("PurgeOlderThan", time.Hour).Return(1, []string{"9/b.html"}, nil).Once()
+	n, err := svc.PurgeDeleted(time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	for _, key := range []string{"6/a.html", "9/b.html"} {
+		_, err := blobs.Get(context.Background(), key)
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound, key)
+	}
+	body, err := blobs.Get(context.Background(), "kept.html")
+	require.NoError(t, err, "bodies of other URLs stay")
+	body.Close()
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTrashPurger(t *testing.T) {
+	t.Run("Sweep", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		purger := service.NewTrashPurger(service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil), time.Hour, 48*time.Hour)
+
+		mockRepo.On("PurgeOlderThan", 48*time.Hour).Return(3, nil, nil).Once()
+		assert.Equal(t, 3, purger.Sweep())
+
+		mockRepo.On("PurgeOlderThan", 48*time.Hour).Return(0, nil, errors.New("db down")).Once()
+		assert.Zero(t, purger.Sweep())
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Run", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		purger := service.NewTrashPurger(service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil), 10*time.Millisecond, time.Hour)
+		swept := make(chan struct{}, 10)
+		mockRepo.On("PurgeOlderThan", time.Hour).Return(0, nil, nil).Run(func(mock.Arguments) { swept <- struct{}{} })
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			purger.Run(ctx)
+			close(done)
+		}()
+		select {
+		case <-swept:
+		case <-time.After(time.Second):
+			t.Fatal("no purge within a second")
+		}
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Run did not return after cancel")
+		}
+	})
+}
+
+func TestURLService_DeleteErrored(t *testing.T) {
+	mockRepo := new(MockURLRepo)
+	svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+
+	mockRepo.On("DeleteErrored", uint(4)).Return(3, nil).Once()
+	deleted, err := svc.DeleteErrored(4)
+	require.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+
+	mockRepo.On("DeleteErrored", uint(5)).Return(0, errors.New("db down")).Once()
+	_, err = svc.DeleteErrored(5)
+	assert.EqualError(t, err, "db down")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestURLService_Start_QueueFull(t *testing.T) {
+	mockRepo := new(MockURLRepo)
+	mockPool := new(MockCrawlerPool)
+	svc := service.NewURLService(mockRepo, mockPool, nil)
+
+	mockRepo.On("FindByID", uint(3)).Return(&model.URL{ID: 3, Status: model.StatusDone}, nil).Twice()
+	mockRepo.On("UpdateStatus", uint(3), model.StatusQueued).Return(nil).Twice()
+	mockPool.On("Enqueue", uint(3)).Return(crawler.ErrQueueFull).Once()
+	mockPool.On("EnqueueWithPriority", uint(3), 9).Return(crawler.ErrQueueFull).Once()
+	mockRepo.On("UpdateStatus", uint(3), model.StatusDone).Return(nil).Twice()
+
+	assert.ErrorIs(t, svc.Start(3, ""), crawler.ErrQueueFull)
+	assert.ErrorIs(t, svc.StartWithPriority(3, 9, ""), crawler.ErrQueueFull)
+	mockRepo.AssertExpectations(t)
+	mockPool.AssertExpectations(t)
+}
+
+func TestURLService_Reprioritize(t *testing.T) {
+	mockRepo := new(MockURLRepo)
+	mockPool := new(MockCrawlerPool)
+	svc := service.NewURLService(mockRepo, mockPool, nil)
+
+	mockRepo.On("FindByID", uint(1)).Return(&model.URL{ID: 1, UserID: 7, Status: model.StatusQueued}, nil).Twice()
+	mockPool.On("Reprioritize", uint(1), 9).Return(nil).Once()
+	require.NoError(t, svc.Reprioritize(1, 7, 9))
+	assert.ErrorIs(t, svc.Reprioritize(1, 8, 9), gorm.ErrRecordNotFound, "other users' URLs are not found")
+
+	for _, status := range []string{model.StatusRunning, model.StatusDone} {
+		mockRepo.On("FindByID", uint(2)).Return(&model.URL{ID: 2, UserID: 7, Status: status}, nil).Once()
+		assert.ErrorIs(t, svc.Reprioritize(2, 7, 9), service.ErrURLNotQueued, status)
+	}
+
+	// Queued in the database, but a worker took it in the meantime.
+	mockRepo.On("FindByID", uint(3)).Return(&model.URL{ID: 3, UserID: 7, Status: model.StatusQueued}, nil).Once()
+	mockPool.On("Reprioritize", uint(3), 2).Return(crawler.ErrNotQueued).Once()
+	assert.ErrorIs(t, svc.Reprioritize(3, 7, 2), service.ErrURLNotQueued)
+
+	mockRepo.AssertExpectations(t)
+	mockPool.AssertExpectations(t)
+}
+
+func TestURLService_PauseCrawler(t *testing.T) {
+	mockPool := new(MockCrawlerPool)
+	svc := service.NewURLService(new(MockURLRepo), mockPool, nil)
+
+	mockPool.On("Pause").Once()
+	mockPool.On("Resume").Once()
+	mockPool.On("Paused").Return(true).Once()
+	mockPool.On("QueueDepth").Return(7).Once()
+	mockPool.On("Workers").Return(3).Once()
+
+	svc.PauseCrawler()
+	assert.Equal(t, &model.CrawlerStatusDTO{Paused: true, QueueDepth: 7, Workers: 3}, svc.CrawlerStatus())
+	svc.ResumeCrawler()
+	mockPool.AssertExpectations(t)
+}
+
+func TestURLService_CrawlerStats(t *testing.T) {
+	mockPool := new(MockCrawlerPool)
+	svc := service.NewURLService(new(MockURLRepo), mockPool, nil)
+	want := crawler.PoolStats{Workers: 4, ActiveWorkers: 2, Processed: 9, ByStatus: map[string]int64{model.StatusDone: 9}}
+	mockPool.On("Stats").Return(want).Once()
+
+	assert.Equal(t, want, svc.CrawlerStats())
+	mockPool.AssertExpectations(t)
+}
+
+func TestURLService_CrawlQuota(t *testing.T) {
+	inFlight := []string{model.StatusQueued, model.StatusRunning}
+	quota := service.CrawlQuota{PerUser: 2, ByRole: map[model.UserRole]int{model.RoleAdmin: 0, model.RoleCrawler: 5}}
+
+	setup := func(role model.UserRole) (*MockURLRepo, *MockCrawlerPool, service.URLService) {
+		mockRepo := new(MockURLRepo)
+		mockPool := new(MockCrawlerPool)
+		mockUsers := new(MockUserRepo)
+		mockUsers.On("FindByID", uint(7)).Return(&model.User{ID: 7, Role: role}, nil)
+		svc := service.NewURLServiceWithOptions(mockRepo, mockPool, nil, service.URLServiceOptions{Quota: quota, Users: mockUsers})
+		return mockRepo, mockPool, svc
+	}
+
+	t.Run("Under Quota", func(t *testing.T) {
+		mockRepo, mockPool, svc := setup(model.RoleUser)
+		mockRepo.On("FindByID", uint(3)).Return(&model.URL{ID: 3, UserID: 7, Status: model.StatusDone}, nil).Once()
+		mockRepo.On("CountByUserAndStatus", uint(7), inFlight).Return(1, nil).Once()
+		mockRepo.On("UpdateStatus", uint(3), model.StatusQueued).Return(nil).Once()
+		mockPool.On("Enqueue", uint(3)).Return(nil).Once()
+
+		assert.NoError(t, svc.Start(3, ""))
+		mockRepo.AssertExpectations(t)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("Quota Reached", func(t *testing.T) {
+		mockRepo, mockPool, svc := setup(model.RoleUser)
+		mockRepo.On("FindByID", uint(3)).Return(&model.URL{ID: 3, UserID: 7, Status: model.StatusDone}, nil).Twice()
+		mockRepo.On("CountByUserAndStatus", uint(7), inFlight).Return(2, nil).Twice()
+
+		err := svc.Start(3, "")
+		assert.ErrorIs(t, err, service.ErrCrawlQuotaExceeded)
+		assert.Contains(t, err.Error(), "2 of 2")
+		assert.ErrorIs(t, svc.StartWithPriority(3, 9, ""), service.ErrCrawlQuotaExceeded)
+		mockRepo.AssertNotCalled(t, "UpdateStatus", uint(3), model.StatusQueued)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("Role Override", func(t *testing.T) {
+		mockRepo, mockPool, svc := setup(model.RoleCrawler)
+		mockRepo.On("FindByID", uint(3)).Return(&model.URL{ID: 3, UserID: 7, Status: model.StatusDone}, nil).Once()
+		mockRepo.On("CountByUserAndStatus", uint(7), inFlight).Return(4, nil).Once()
+		mockRepo.On("UpdateStatus", uint(3), model.StatusQueued).Return(nil).Once()
+		mockPool.On("EnqueueWithPriority", uint(3), 9).Return(nil).Once()
+
+		assert.NoError(t, svc.StartWithPriority(3, 9, ""))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Admin Unlimited", func(t *testing.T) {
+		mockRepo, mockPool, svc := setup(model.RoleAdmin)
+		mockRepo.On("FindByID", uint(3)).Return(&model.URL{ID: 3, UserID: 7, Status: model.StatusDone}, nil).Once()
+		mockRepo.On("UpdateStatus", uint(3), model.StatusQueued).Return(nil).Once()
+		mockPool.On("Enqueue", uint(3)).Return(nil).Once()
+
+		assert.NoError(t, svc.Start(3, ""))
+		mockRepo.AssertNotCalled(t, "CountByUserAndStatus", uint(7), inFlight)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Already In Flight", func(t *testing.T) {
+		mockRepo, mockPool, svc := setup(model.RoleUser)
+		mockRepo.On("FindByID", uint(3)).Return(&model.URL{ID: 3, UserID: 7, Status: model.StatusQueued}, nil).Once()
+		mockRepo.On("UpdateStatus", uint(3), model.StatusQueued).Return(nil).Once()
+		mockPool.On("Enqueue", uint(3)).Return(nil).Once()
+
+		assert.NoError(t, svc.Start(3, ""), "restarting a queued URL does not count again")
+		mockRepo.AssertNotCalled(t, "CountByUserAndStatus", uint(7), inFlight)
+	})
+}
+
+func TestURLService_List_Filter(t *testing.T) {
+	mockRepo := new(MockURLRepo)
+	svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+	filter := repository.URLFilter{Status: model.StatusDone, Sort: "-created_at"}
+	pagination := repository.Pagination{Page: 1, PageSize: 10}
+
+	mockRepo.On("ListByUser", uint(1), filter, pagination).Return([]model.URL{{ID: 3, Status: model.StatusDone}}, nil).Once()
+	mockRepo.On("CountByUser", uint(1), filter).Return(1, nil).Once()
+
+	result, err := svc.List(1, filter, pagination)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Pagination.TotalItems, "the total counts filtered URLs only")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestURLService_TagReport(t *testing.T) {
+	t.Run("Pagination", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		page := repository.Pagination{Page: 2, PageSize: 2}
+		items := []model.TagReportItemDTO{
+			{URLID: 3, Status: model.StatusDone, Title: "Shop C", BrokenLinkCount: 1},
+			{URLID: 4, Status: model.StatusQueued},
+		}
+		mockRepo.On("ListTagReport", uint(7), "shop", page).Return(items, nil).Once()
+		mockRepo.On("CountByTag", uint(7), "shop").Return(5, nil).Once()
+
+		report, err := svc.TagReport(7, "shop", page)
+		require.NoError(t, err)
+		assert.Equal(t, items, report.Data)
+		assert.Equal(t, model.PaginationMetaDTO{Page: 2, PageSize: 2, TotalItems: 5, TotalPages: 3}, report.Pagination)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown Tag", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		mockRepo.On("ListTagReport", uint(7), "none", repository.Pagination{}).Return([]model.TagReportItemDTO(nil), nil).Once()
+		mockRepo.On("CountByTag", uint(7), "none").Return(0, nil).Once()
+
+		report, err := svc.TagReport(7, "none", repository.Pagination{})
+		require.NoError(t, err)
+		assert.NotNil(t, report.Data, "an empty report lists no URLs rather than null")
+		assert.Empty(t, report.Data)
+		assert.Equal(t, 10, report.Pagination.PageSize)
+	})
+
+	t.Run("Repository Error", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		mockRepo.On("ListTagReport", uint(7), "shop", repository.Pagination{}).Return([]model.TagReportItemDTO(nil), errors.New("db down")).Once()
+
+		_, err := svc.TagReport(7, "shop", repository.Pagination{})
+		assert.EqualError(t, err, "db down")
+	})
+}
+
+func TestURLService_DeleteBulk(t *testing.T) {
+	t.Run("Skips Missing And Foreign", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		ids := []uint{1, 2, 3, 1, 4}
+		mockRepo.On("FindOwnedIDs", uint(9), ids).Return([]uint{1, 3}, nil).Once()
+		mockRepo.On("DeleteByIDs", []uint{1, 3}).Return(2, nil).Once()
+
+		summary, err := svc.DeleteBulk(9, ids)
+		require.NoError(t, err)
+		assert.Equal(t, &model.BulkURLActionResultDTO{Succeeded: 2, Skipped: []uint{2, 4}}, summary)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Lookup Error", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		mockRepo.On("FindOwnedIDs", uint(9), []uint{1}).Return([]uint(nil), errors.New("db down")).Once()
+
+		_, err := svc.DeleteBulk(9, []uint{1})
+		assert.EqualError(t, err, "db down")
+		mockRepo.AssertNotCalled(t, "DeleteByIDs", mock.Anything)
+	})
+}
+
+func TestURLService_StartBulk(t *testing.T) {
+	mockRepo := new(MockURLRepo)
+	mockPool := new(MockCrawlerPool)
+	svc := service.NewURLService(mockRepo, mockPool, nil)
+
+	mockRepo.On("FindOwnedIDs", uint(9), []uint{1, 2, 3}).Return([]uint{1, 3}, nil).Once()
+	mockRepo.On("FindByID", uint(1)).Return(&model.URL{ID: 1, UserID: 9}, nil).Once()
+	mockRepo.On("UpdateStatus", uint(1), model.StatusQueued).Return(nil).Once()
+	mockPool.On("Enqueue", uint(1)).Return().Once()
+	mockRepo.On("FindByID", uint(3)).Return(&model.URL{ID: 3, UserID: 9}, nil).Once()
+	mockRepo.On("UpdateStatus", uint(3), model.StatusQueued).Return(errors.New("deadlock")).Once()
+
+	summary, err := svc.StartBulk(9, []uint{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Succeeded)
+	assert.Equal(t, []uint{2, 3}, summary.Skipped, "a URL that could not be queued is skipped too")
+	mockRepo.AssertExpectations(t)
+	mockPool.AssertExpectations(t)
+}
+
+func TestURLService_RecrawlTag(t *testing.T) {
+	t.Run("Queues Tagged URLs", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		mockPool := new(MockCrawlerPool)
+		svc := service.NewURLService(mockRepo, mockPool, nil)
+
+		// Only the caller's URLs carrying the tag are looked up, so only
+		// those reach the pool; any other Enqueue fails the mock.
+		mockRepo.On("FindIDsByTag", uint(9), "shop").Return([]uint{1, 3}, nil).Once()
+		for _, id := range []uint{1, 3} {
+			mockRepo.On("FindByID", id).Return(&model.URL{ID: id, UserID: 9, Status: model.StatusDone}, nil).Once()
+			mockRepo.On("UpdateStatus", id, model.StatusQueued).Return(nil).Once()
+			mockPool.On("Enqueue", id).Return().Once()
+		}
+
+		summary, err := svc.RecrawlTag(9, "shop", 0)
+		require.NoError(t, err)
+		assert.Equal(t, &model.BulkURLActionResultDTO{Succeeded: 2, Skipped: []uint{}}, summary)
+		mockRepo.AssertExpectations(t)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("With Priority", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		mockPool := new(MockCrawlerPool)
+		svc := service.NewURLService(mockRepo, mockPool, nil)
+
+		mockRepo.On("FindIDsByTag", uint(9), "shop").Return([]uint{4}, nil).Once()
+		mockRepo.On("FindByID", uint(4)).Return(&model.URL{ID: 4, UserID: 9, Status: model.StatusDone}, nil).Once()
+		mockRepo.On("UpdateStatus", uint(4), model.StatusQueued).Return(nil).Once()
+		mockPool.On("EnqueueWithPriority", uint(4), 8).Return().Once()
+
+		summary, err := svc.RecrawlTag(9, "shop", 8)
+		require.NoError(t, err)
+		assert.Equal(t, 1, summary.Succeeded)
+		mockPool.AssertExpectations(t)
+	})
+
+	t.Run("Queue Full", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		mockPool := new(MockCrawlerPool)
+		svc := service.NewURLService(mockRepo, mockPool, nil)
+
+		mockRepo.On("FindIDsByTag", uint(9), "shop").Return([]uint{1, 2}, nil).Once()
+		mockRepo.On("FindByID", uint(1)).Return(&model.URL{ID: 1, UserID: 9, Status: model.StatusDone}, nil).Once()
+		mockRepo.On("UpdateStatus", uint(1), model.StatusQueued).Return(nil).Once()
+		mockPool.On("Enqueue", uint(1)).Return().Once()
+		mockRepo.On("FindByID", uint(2)).Return(&model.URL{ID: 2, UserID: 9, Status: model.StatusDone}, nil).Once()
+		mockRepo.On("UpdateStatus", uint(2), model.StatusQueued).Return(nil).Once()
+		mockPool.On("Enqueue", uint(2)).Return(crawler.ErrQueueFull).Once()
+		mockRepo.On("UpdateStatus", uint(2), model.StatusDone).Return(nil).Once()
+
+		summary, err := svc.RecrawlTag(9, "shop", 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1, summary.Succeeded)
+		assert.Equal(t, []uint{2}, summary.Skipped, "a URL that could not be queued is skipped")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown Tag", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, new(MockCrawlerPool), nil)
+		mockRepo.On("FindIDsByTag", uint(9), "none").Return([]uint(nil), nil).Once()
+
+		summary, err := svc.RecrawlTag(9, "none", 0)
+		require.NoError(t, err)
+		assert.Equal(t, &model.BulkURLActionResultDTO{Skipped: []uint{}}, summary)
+	})
+
+	t.Run("Repository Error", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, new(MockCrawlerPool), nil)
+		mockRepo.On("FindIDsByTag", uint(9), "shop").Return(nil, errors.New("db down")).Once()
+
+		_, err := svc.RecrawlTag(9, "shop", 0)
+		assert.EqualError(t, err, "db down")
+	})
+}
+
 func TestURLService_List(t *testing.T) {
 	mockRepo := new(MockURLRepo)
 	dummyPool := &DummyCrawlerPool{}
-	svc := service.NewURLService(mockRepo, dummyPool)
+	svc := service.NewURLService(mockRepo, dummyPool, nil)
 
 	userID := uint(1)
 	pagination := repository.Pagination{Page: 1, PageSize: 10}
@@ -211,10 +1129,10 @@ func TestURLService_List(t *testing.T) {
 	}
 
 	t.Run("Success", func(t *testing.T) {
-		mockRepo.On("ListByUser", userID, pagination).Return(urls, nil).Once()
-		mockRepo.On("CountByUser", userID).Return(2, nil).Once()
+		mockRepo.On("ListByUser", userID, repository.URLFilter{}, pagination).Return(urls, nil).Once()
+		mockRepo.On("CountByUser", userID, repository.URLFilter{}).Return(2, nil).Once()
 
-		result, err := svc.List(userID, pagination)
+		result, err := svc.List(userID, repository.URLFilter{}, pagination)
 		require.NoError(t, err)
 		require.NotNil(t, result)
 
@@ -238,10 +1156,10 @@ func TestURLService_List(t *testing.T) {
 	})
 
 	t.Run("Empty Results", func(t *testing.T) {
-		mockRepo.On("ListByUser", userID, pagination).Return([]model.URL{}, nil).Once()
-		mockRepo.On("CountByUser", userID).Return(0, nil).Once()
+		mockRepo.On("ListByUser", userID, repository.URLFilter{}, pagination).Return([]model.URL{}, nil).Once()
+		mockRepo.On("CountByUser", userID, repository.URLFilter{}).Return(0, nil).Once()
 
-		result, err := svc.List(userID, pagination)
+		result, err := svc.List(userID, repository.URLFilter{}, pagination)
 		require.NoError(t, err)
 		assert.Empty(t, result.Data)
 		assert.Equal(t, 0, result.Pagination.TotalItems)
@@ -251,9 +1169,9 @@ func TestURLService_List(t *testing.T) {
 
 	t.Run("Repository Error on ListByUser", func(t *testing.T) {
 		expectedErr := errors.New("database error")
-		mockRepo.On("ListByUser", userID, pagination).Return([]model.URL{}, expectedErr).Once()
+		mockRepo.On("ListByUser", userID, repository.URLFilter{}, pagination).Return([]model.URL{}, expectedErr).Once()
 
-		result, err := svc.List(userID, pagination)
+		result, err := svc.List(userID, repository.URLFilter{}, pagination)
 		assert.Error(t, err)
 		assert.Equal(t, expectedErr, err)
 		assert.Nil(t, result)
@@ -261,11 +1179,11 @@ func TestURLService_List(t *testing.T) {
 	})
 
 	t.Run("Repository Error on CountByUser", func(t *testing.T) {
-		mockRepo.On("ListByUser", userID, pagination).Return(urls, nil).Once()
+		mockRepo.On("ListByUser", userID, repository.URLFilter{}, pagination).Return(urls, nil).Once()
 		expectedErr := errors.New("count error")
-		mockRepo.On("CountByUser", userID).Return(0, expectedErr).Once()
+		mockRepo.On("CountByUser", userID, repository.URLFilter{}).Return(0, expectedErr).Once()
 
-		result, err := svc.List(userID, pagination)
+		result, err := svc.List(userID, repository.URLFilter{}, pagination)
 		assert.Error(t, err)
 		assert.Equal(t, expectedErr, err)
 		assert.Nil(t, result)
@@ -273,10 +1191,10 @@ func TestURLService_List(t *testing.T) {
 	})
 
 	t.Run("Multiple Pages", func(t *testing.T) {
-		mockRepo.On("ListByUser", userID, pagination).Return(urls, nil).Once()
-		mockRepo.On("CountByUser", userID).Return(21, nil).Once()
+		mockRepo.On("ListByUser", userID, repository.URLFilter{}, pagination).Return(urls, nil).Once()
+		mockRepo.On("CountByUser", userID, repository.URLFilter{}).Return(21, nil).Once()
 
-		result, err := svc.List(userID, pagination)
+		result, err := svc.List(userID, repository.URLFilter{}, pagination)
 		require.NoError(t, err)
 		assert.Equal(t, 21, result.Pagination.TotalItems)
 		assert.Equal(t, 3, result.Pagination.TotalPages)
@@ -287,7 +1205,7 @@ func TestURLService_List(t *testing.T) {
 func TestURLService_Update(t *testing.T) {
 	mockRepo := new(MockURLRepo)
 	dummyPool := &DummyCrawlerPool{}
-	svc := service.NewURLService(mockRepo, dummyPool)
+	svc := service.NewURLService(mockRepo, dummyPool, nil)
 	urlID := uint(42)
 
 	t.Run("Update Original URL", func(t *testing.T) {
@@ -324,6 +1242,38 @@ func TestURLService_Update(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("Update Host Limit", func(t *testing.T) {
+		existingURL := &model.URL{ID: urlID, UserID: 1, OriginalURL: "https://old-example.com", HostLimit: 3}
+		limit := 1
+
+		mockRepo.On("FindByID", urlID).Return(existingURL, nil).Once()
+		mockRepo.On("Update", mock.AnythingOfType("*model.URL")).Return(nil).Once()
+
+		require.NoError(t, svc.Update(urlID, &model.UpdateURLInput{HostLimit: &limit}))
+		assert.Equal(t, 1, existingURL.HostLimit)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Update Crawl Method", func(t *testing.T) {
+		existingURL := &model.URL{ID: urlID, UserID: 1, OriginalURL: "https://old-example.com", CrawlMethod: model.CrawlMethodFull}
+
+		mockRepo.On("FindByID", urlID).Return(existingURL, nil).Once()
+		mockRepo.On("Update", mock.AnythingOfType("*model.URL")).Return(nil).Once()
+
+		require.NoError(t, svc.Update(urlID, &model.UpdateURLInput{CrawlMethod: model.CrawlMethodHeadOnly}))
+		assert.Equal(t, model.CrawlMethodHeadOnly, existingURL.CrawlMethod)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid Crawl Method", func(t *testing.T) {
+		existingURL := &model.URL{ID: urlID, UserID: 1, OriginalURL: "https://old-example.com"}
+
+		mockRepo.On("FindByID", urlID).Return(existingURL, nil).Once()
+		err := svc.Update(urlID, &model.UpdateURLInput{CrawlMethod: "ping"})
+		assert.EqualError(t, err, "invalid crawl_method value")
+		mockRepo.AssertExpectations(t)
+	})
+
 	t.Run("Invalid Status", func(t *testing.T) {
 		existingURL := &model.URL{
 			ID:          urlID,
@@ -373,7 +1323,7 @@ func TestURLService_Update(t *testing.T) {
 func TestURLService_Delete(t *testing.T) {
 	mockRepo := new(MockURLRepo)
 	dummyPool := &DummyCrawlerPool{}
-	svc := service.NewURLService(mockRepo, dummyPool)
+	svc := service.NewURLService(mockRepo, dummyPool, nil)
 	urlID := uint(42)
 
 	t.Run("Success", func(t *testing.T) {
@@ -396,7 +1346,7 @@ func TestURLService_Delete(t *testing.T) {
 func TestURLService_Start(t *testing.T) {
 	mockRepo := new(MockURLRepo)
 	mockPool := new(MockCrawlerPool)
-	svc := service.NewURLService(mockRepo, mockPool)
+	svc := service.NewURLService(mockRepo, mockPool, nil)
 	urlID := uint(100)
 
 	t.Run("Success", func(t *testing.T) {
@@ -410,17 +1360,31 @@ func TestURLService_Start(t *testing.T) {
 		mockRepo.On("UpdateStatus", urlID, model.StatusQueued).Return(nil).Once()
 		mockPool.On("Enqueue", urlID).Return().Once()
 
-		err := svc.Start(urlID)
+		err := svc.Start(urlID, "")
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
 		mockPool.AssertExpectations(t)
 	})
 
+	t.Run("Carries Request ID", func(t *testing.T) {
+		testURL := &model.URL{ID: urlID, OriginalURL: "http://example.com", Status: model.StatusDone}
+
+		mockRepo.On("FindByID", urlID).Return(testURL, nil).Twice()
+		mockRepo.On("UpdateStatus", urlID, model.StatusQueued).Return(nil).Twice()
+		mockPool.On("EnqueueWithRequestID", urlID, service.DefaultPriority, "req-1").Return(nil).Once()
+		mockPool.On("EnqueueWithRequestID", urlID, 8, "req-2").Return(nil).Once()
+
+		require.NoError(t, svc.Start(urlID, "req-1"))
+		require.NoError(t, svc.StartWithPriority(urlID, 8, "req-2"))
+		mockRepo.AssertExpectations(t)
+		mockPool.AssertExpectations(t)
+	})
+
 	t.Run("URL Not Found", func(t *testing.T) {
 		expectedErr := errors.New("record not found")
 		mockRepo.On("FindByID", urlID).Return(nil, expectedErr).Once()
 
-		err := svc.Start(urlID)
+		err := svc.Start(urlID, "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "cannot start crawling")
 		assert.Contains(t, err.Error(), expectedErr.Error())
@@ -437,17 +1401,105 @@ func TestURLService_Start(t *testing.T) {
 		mockRepo.On("FindByID", urlID).Return(testURL, nil).Once()
 		mockRepo.On("UpdateStatus", urlID, model.StatusQueued).Return(expectedErr).Once()
 
-		err := svc.Start(urlID)
+		err := svc.Start(urlID, "")
 		assert.Error(t, err)
 		assert.Equal(t, expectedErr, err)
 		mockRepo.AssertExpectations(t)
 	})
 }
 
+func TestURLService_SlowCrawlPriority(t *testing.T) {
+	ms := func(v int64) *int64 { return &v }
+	policy := service.SlowCrawlPolicy{Threshold: 10 * time.Second, Penalty: 3}
+	slowURL := &model.URL{
+		ID: 7,
+		AnalysisResults: []model.AnalysisResult{
+			{ID: 1, CrawlDurationMs: ms(500)},
+			{ID: 2, CrawlDurationMs: ms(25_000)},
+		},
+	}
+	// Only the latest timed crawl counts.
+	recoveredURL := &model.URL{
+		ID: 8,
+		AnalysisResults: []model.AnalysisResult{
+			{ID: 1, CrawlDurationMs: ms(25_000)},
+			{ID: 2, CrawlDurationMs: ms(800)},
+		},
+	}
+
+	cases := []struct {
+		name   string
+		policy service.SlowCrawlPolicy
+		url    *model.URL
+		start  func(svc service.URLService, id uint) error
+		expect func(pool *MockCrawlerPool, id uint)
+	}{
+		{
+			name:   "Slow Default Start",
+			policy: policy,
+			url:    slowURL,
+			start:  func(svc service.URLService, id uint) error { return svc.Start(id, "") },
+			expect: func(pool *MockCrawlerPool, id uint) {
+				pool.On("EnqueueWithPriority", id, service.DefaultPriority-3).Return().Once()
+			},
+		},
+		{
+			name:   "Slow Explicit Priority",
+			policy: policy,
+			url:    slowURL,
+			start:  func(svc service.URLService, id uint) error { return svc.StartWithPriority(id, 9, "") },
+			expect: func(pool *MockCrawlerPool, id uint) {
+				pool.On("EnqueueWithPriority", id, 6).Return().Once()
+			},
+		},
+		{
+			name:   "Never Below One",
+			policy: policy,
+			url:    slowURL,
+			start:  func(svc service.URLService, id uint) error { return svc.StartWithPriority(id, 2, "") },
+			expect: func(pool *MockCrawlerPool, id uint) {
+				pool.On("EnqueueWithPriority", id, 1).Return().Once()
+			},
+		},
+		{
+			name:   "Fast Latest Crawl",
+			policy: policy,
+			url:    recoveredURL,
+			start:  func(svc service.URLService, id uint) error { return svc.Start(id, "") },
+			expect: func(pool *MockCrawlerPool, id uint) {
+				pool.On("Enqueue", id).Return().Once()
+			},
+		},
+		{
+			name:   "Disabled",
+			policy: service.SlowCrawlPolicy{},
+			url:    slowURL,
+			start:  func(svc service.URLService, id uint) error { return svc.StartWithPriority(id, 9, "") },
+			expect: func(pool *MockCrawlerPool, id uint) {
+				pool.On("EnqueueWithPriority", id, 9).Return().Once()
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockURLRepo)
+			mockPool := new(MockCrawlerPool)
+			svc := service.NewURLServiceWithOptions(mockRepo, mockPool, nil, service.URLServiceOptions{SlowCrawl: tc.policy})
+
+			mockRepo.On("FindByID", tc.url.ID).Return(tc.url, nil).Once()
+			mockRepo.On("UpdateStatus", tc.url.ID, model.StatusQueued).Return(nil).Once()
+			tc.expect(mockPool, tc.url.ID)
+
+			require.NoError(t, tc.start(svc, tc.url.ID))
+			mockPool.AssertExpectations(t)
+		})
+	}
+}
+
 func TestURLService_Stop(t *testing.T) {
 	mockRepo := new(MockURLRepo)
 	dummyPool := &DummyCrawlerPool{}
-	svc := service.NewURLService(mockRepo, dummyPool)
+	svc := service.NewURLService(mockRepo, dummyPool, nil)
 	urlID := uint(100)
 
 	t.Run("Success", func(t *testing.T) {
@@ -496,7 +1548,7 @@ func TestURLService_Stop(t *testing.T) {
 func TestURLService_Results(t *testing.T) {
 	mockRepo := new(MockURLRepo)
 	dummyPool := &DummyCrawlerPool{}
-	svc := service.NewURLService(mockRepo, dummyPool)
+	svc := service.NewURLService(mockRepo, dummyPool, nil)
 	urlID := uint(55)
 	testURL := &model.URL{
 		ID:          urlID,
@@ -518,7 +1570,7 @@ func TestURLService_Results(t *testing.T) {
 func TestURLService_ResultsWithDetails(t *testing.T) {
 	mockRepo := new(MockURLRepo)
 	dummyPool := &DummyCrawlerPool{}
-	svc := service.NewURLService(mockRepo, dummyPool)
+	svc := service.NewURLService(mockRepo, dummyPool, nil)
 	urlID := uint(77)
 
 	testURL := &model.URL{
@@ -544,6 +1596,112 @@ func TestURLService_ResultsWithDetails(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestURLService_Merge(t *testing.T) {
+	newSvc := func() (*MockURLRepo, service.URLService) {
+		mockRepo := new(MockURLRepo)
+		return mockRepo, service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+	}
+
+	t.Run("Merges Owned URLs", func(t *testing.T) {
+		mockRepo, svc := newSvc()
+		mockRepo.On("FindByID", uint(2)).Return(&model.URL{ID: 2, UserID: 7}, nil).Once()
+		mockRepo.On("FindByID", uint(1)).Return(&model.URL{ID: 1, UserID: 7}, nil).Once()
+		mockRepo.On("Merge", uint(2), uint(1)).Return(nil).Once()
+
+		require.NoError(t, svc.Merge(2, 1, 7))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Target Owned By Someone Else", func(t *testing.T) {
+		mockRepo, svc := newSvc()
+		mockRepo.On("FindByID", uint(2)).Return(&model.URL{ID: 2, UserID: 7}, nil).Once()
+		mockRepo.On("FindByID", uint(1)).Return(&model.URL{ID: 1, UserID: 8}, nil).Once()
+
+		err := svc.Merge(2, 1, 7)
+		assert.ErrorIs(t, err, service.ErrURLNotOwned)
+		mockRepo.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Source Not Found", func(t *testing.T) {
+		mockRepo, svc := newSvc()
+		mockRepo.On("FindByID", uint(2)).Return(nil, errors.New("record not found")).Once()
+
+		assert.Error(t, svc.Merge(2, 1, 7))
+		mockRepo.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Into Itself", func(t *testing.T) {
+		mockRepo, svc := newSvc()
+
+		assert.EqualError(t, svc.Merge(3, 3, 7), "cannot merge a URL into itself")
+		mockRepo.AssertNotCalled(t, "FindByID", mock.Anything)
+	})
+}
+
+func TestURLService_Clone(t *testing.T) {
+	newSvc := func() (*MockURLRepo, service.URLService) {
+		mockRepo := new(MockURLRepo)
+		return mockRepo, service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+	}
+	crawled := func() *model.URL {
+		ms := int64(1200)
+		return &model.URL{
+			ID:              2,
+			UserID:          7,
+			OriginalURL:     "https://example.com/a",
+			Status:          model.StatusDone,
+			HostLimit:       2,
+			AnalysisResults: []model.AnalysisResult{{ID: 11, URLID: 2, Title: "A", CrawlDurationMs: &ms}},
+			Links:           []model.Link{{ID: 21, URLID: 2, Href: "https://example.com/b"}},
+		}
+	}
+
+	t.Run("Copies Configuration Only", func(t *testing.T) {
+		mockRepo, svc := newSvc()
+		src := crawled()
+		mockRepo.On("FindByID", uint(2)).Return(src, nil).Once()
+		var created *model.URL
+		mockRepo.On("Create", mock.AnythingOfType("*model.URL")).Run(func(args mock.Arguments) {
+			created = args.Get(0).(*model.URL)
+			created.ID = 9
+		}).Return(nil).Once()
+
+		id, err := svc.Clone(2, 7, "https://example.com/c")
+		require.NoError(t, err)
+		assert.Equal(t, uint(9), id)
+		require.NotNil(t, created)
+
+		// Everything that is not configuration must be fresh on the clone.
+		want := *src.CloneFor(7, "https://example.com/c")
+		want.ID, want.CreatedAt, want.UpdatedAt = created.ID, created.CreatedAt, created.UpdatedAt
+		assert.Equal(t, want, *created)
+		assert.Equal(t, model.StatusQueued, created.Status)
+		assert.Equal(t, 2, created.HostLimit)
+		assert.Empty(t, created.AnalysisResults)
+		assert.Empty(t, created.Links)
+		assert.Len(t, src.AnalysisResults, 1, "the source keeps its results")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Owned By Someone Else", func(t *testing.T) {
+		mockRepo, svc := newSvc()
+		mockRepo.On("FindByID", uint(2)).Return(crawled(), nil).Once()
+
+		_, err := svc.Clone(2, 8, "https://example.com/c")
+		assert.ErrorIs(t, err, service.ErrURLNotOwned)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Source Not Found", func(t *testing.T) {
+		mockRepo, svc := newSvc()
+		mockRepo.On("FindByID", uint(2)).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		_, err := svc.Clone(2, 7, "https://example.com/c")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
 func mustParseTime(s string) time.Time {
 	parsed, err := time.Parse(time.RFC3339, s)
 	if err != nil {
@@ -551,3 +1709,168 @@ func mustParseTime(s string) time.Time {
 	}
 	return parsed
 }
+
+func TestURLService_Validate(t *testing.T) {
+	mockRepo := new(MockURLRepo)
+	policy := egress.NewPolicy(egress.ModeDenylist, []string{"blocked.example"})
+	svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, policy)
+
+	mockRepo.On("FindExisting", uint(7), []string{
+		"https://example.com/a", "https://example.com/a",
+		"https://example.com/b", "HTTPS://Example.com/b#top",
+		"https://example.com/a", "https://example.com/a",
+	}).Return([]string{"https://example.com/a"}, nil).Once()
+
+	verdicts, err := svc.Validate(7, []string{
+		"https://example.com/a",
+		"HTTPS://Example.com/b#top",
+		"ftp://example.com/file",
+		"not a url",
+		"https://blocked.example/",
+		"https://example.com/a",
+	})
+	require.NoError(t, err)
+	require.Len(t, verdicts, 6)
+
+	// Existing URL of the caller.
+	assert.True(t, verdicts[0].Valid)
+	assert.True(t, verdicts[0].Duplicate)
+
+	// New URL, normalized.
+	assert.True(t, verdicts[1].Valid)
+	assert.False(t, verdicts[1].Duplicate)
+	assert.Equal(t, "https://example.com/b", verdicts[1].Normalized)
+
+	// Invalid entries carry a reason and no normalized form.
+	assert.False(t, verdicts[2].Valid)
+	assert.Equal(t, "scheme must be http or https", verdicts[2].Reason)
+	assert.False(t, verdicts[3].Valid)
+	assert.NotEmpty(t, verdicts[3].Reason)
+	assert.Empty(t, verdicts[3].Normalized)
+	assert.False(t, verdicts[4].Valid)
+	assert.NotEmpty(t, verdicts[4].Reason)
+
+	// Repeated within the batch.
+	assert.True(t, verdicts[5].Valid)
+	assert.True(t, verdicts[5].Duplicate)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestURLService_ExportImport(t *testing.T) {
+	t.Run("Export Is Versioned", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		mockRepo.On("ListByUser", uint(7), repository.URLFilter{Sort: "id"}, repository.Pagination{Page: 1, PageSize: 200}).
+			Return([]model.URL{
+				{ID: 1, OriginalURL: "https://a.example/", CrawlMethod: model.CrawlMethodFull},
+				{ID: 2, OriginalURL: "https://b.example/", CrawlMethod: model.CrawlMethodHeadOnly},
+			}, nil).Once()
+
+		doc, err := svc.ExportURLs(7)
+		require.NoError(t, err)
+		assert.Equal(t, model.URLExportSchemaVersion, doc.SchemaVersion)
+		assert.False(t, doc.ExportedAt.IsZero())
+		assert.Equal(t, []model.URLCreateRequestDTO{
+			{OriginalURL: "https://a.example/", CrawlMethod: model.CrawlMethodFull},
+			{OriginalURL: "https://b.example/", CrawlMethod: model.CrawlMethodHeadOnly},
+		}, doc.URLs)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Import Rejects Unsupported Versions", func(t *testing.T) {
+		for _, version := range []int{0, model.URLExportSchemaVersion + 1} {
+			mockRepo := new(MockURLRepo)
+			svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+
+			_, err := svc.ImportURLs(7, &model.URLExportDocument{
+				SchemaVersion: version,
+				URLs:          []model.URLCreateRequestDTO{{OriginalURL: "https://a.example/"}},
+			})
+			assert.ErrorIs(t, err, service.ErrUnsupportedSchemaVersion, "version %d", version)
+			mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything)
+		}
+	})
+
+	t.Run("Import Skips Existing URLs", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		mockRepo.On("FindExisting", uint(7), []string{"https://a.example/", "https://b.example/", "not a url", "https://b.example/"}).
+			Return([]string{"https://a.example/"}, nil).Once()
+		mockRepo.On("CreateBatch", mock.MatchedBy(func(urls []*model.URL) bool {
+			return len(urls) == 1 && urls[0].OriginalURL == "https://b.example/" && urls[0].UserID == 7 &&
+				urls[0].CrawlMethod == model.CrawlMethodHeadOnly
+		})).Return(nil).Once()
+
+		res, err := svc.ImportURLs(7, &model.URLExportDocument{
+			SchemaVersion: model.URLExportSchemaVersion,
+			URLs: []model.URLCreateRequestDTO{
+				{OriginalURL: "https://a.example/"},
+				{OriginalURL: "https://b.example/", CrawlMethod: model.CrawlMethodHeadOnly},
+				{OriginalURL: "not a url"},
+				{OriginalURL: "https://b.example/"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, res.Imported)
+		assert.Equal(t, 2, res.Skipped)
+		require.Len(t, res.Failed, 1)
+		assert.Equal(t, 2, res.Failed[0].Index)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestURLService_Report(t *testing.T) {
+	crawled := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	u := &model.URL{
+		ID:          5,
+		UserID:      7,
+		OriginalURL: "https://report.example.com",
+		Status:      model.StatusDone,
+		AnalysisResults: []model.AnalysisResult{
+			{ID: 1, Title: "Old", CreatedAt: crawled.Add(-24 * time.Hour)},
+			{ID: 2, Title: "Latest", CreatedAt: crawled},
+		},
+		Links: []model.Link{
+			{Href: "https://report.example.com/old-broken", StatusCode: 404, CreatedAt: crawled.Add(-24 * time.Hour)},
+			{Href: "https://report.example.com/ok", StatusCode: 200, CreatedAt: crawled},
+			{Href: "https://report.example.com/gone", StatusCode: 410, CreatedAt: crawled},
+			{Href: "https://report.example.com/down", StatusCode: 503, CreatedAt: crawled},
+		},
+	}
+
+	t.Run("Latest Crawl Only", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		mockRepo.On("FindByID", uint(5)).Return(u, nil).Once()
+
+		r, err := svc.Report(5, 7)
+		require.NoError(t, err)
+		require.NotNil(t, r.Analysis)
+		assert.Equal(t, "Latest", r.Analysis.Title)
+		assert.Equal(t, 2, r.BrokenTotal)
+		require.Len(t, r.BrokenLinks, 2)
+		assert.Equal(t, "https://report.example.com/gone", r.BrokenLinks[0].Href)
+		assert.Equal(t, "https://report.example.com/down", r.BrokenLinks[1].Href)
+	})
+
+	t.Run("Other User", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		mockRepo.On("FindByID", uint(5)).Return(u, nil).Once()
+
+		_, err := svc.Report(5, 8)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("Never Crawled", func(t *testing.T) {
+		mockRepo := new(MockURLRepo)
+		svc := service.NewURLService(mockRepo, &DummyCrawlerPool{}, nil)
+		mockRepo.On("FindByID", uint(6)).Return(&model.URL{ID: 6, UserID: 7, Status: model.StatusQueued}, nil).Once()
+
+		r, err := svc.Report(6, 7)
+		require.NoError(t, err)
+		assert.Nil(t, r.Analysis)
+		assert.Empty(t, r.BrokenLinks)
+	})
+}
@@ -1,17 +1,23 @@
 package service_test
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 
 	"github.com/fuzumoe/linkTorch-api/internal/model"
 	"github.com/fuzumoe/linkTorch-api/internal/repository"
 	"github.com/fuzumoe/linkTorch-api/internal/service"
+	"github.com/fuzumoe/linkTorch-api/internal/storage"
 )
 
 type MockUserRepo struct {
@@ -49,6 +55,11 @@ func (m *MockUserRepo) FindByEmail(email string) (*model.User, error) {
 	return args.Get(0).(*model.User), args.Error(1)
 }
 
+func (m *MockUserRepo) UsernameTaken(username string, excludeID uint, ignoreCase bool) (bool, error) {
+	args := m.Called(username, excludeID, ignoreCase)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockUserRepo) ListAll(p repository.Pagination) ([]model.User, error) {
 	args := m.Called(p)
 	return args.Get(0).([]model.User), args.Error(1)
@@ -59,10 +70,23 @@ func (m *MockUserRepo) Delete(id uint) error {
 	return args.Error(0)
 }
 
+func (m *MockUserRepo) Erase(id uint) (*repository.AccountErasure, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.AccountErasure), args.Error(1)
+}
+
+func (m *MockUserRepo) SetEmailVerified(id uint, verified bool) error {
+	args := m.Called(id, verified)
+	return args.Error(0)
+}
+
 func TestUserService_Register(t *testing.T) {
 
 	mockRepo := new(MockUserRepo)
-	svc := service.NewUserService(mockRepo)
+	svc := service.NewUserService(mockRepo, service.UserServiceOptions{})
 
 	input := &model.CreateUserInput{
 		Username: "testuser",
@@ -72,6 +96,7 @@ func TestUserService_Register(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		mockRepo.On("FindByEmail", input.Email).Return(nil, errors.New("not found")).Once()
+		mockRepo.On("UsernameTaken", input.Username, uint(0), true).Return(false, nil).Once()
 		mockRepo.On("Create", mock.AnythingOfType("*model.User")).Run(func(args mock.Arguments) {
 			user := args.Get(0).(*model.User)
 			assert.NotEqual(t, input.Password, user.Password, "Password should be hashed")
@@ -111,6 +136,7 @@ func TestUserService_Register(t *testing.T) {
 	t.Run("Repository Error", func(t *testing.T) {
 
 		mockRepo.On("FindByEmail", input.Email).Return(nil, errors.New("not found")).Once()
+		mockRepo.On("UsernameTaken", input.Username, uint(0), true).Return(false, nil).Once()
 		mockRepo.On("Create", mock.AnythingOfType("*model.User")).Return(errors.New("db error")).Once()
 
 		dto, err := svc.Register(input)
@@ -122,10 +148,70 @@ func TestUserService_Register(t *testing.T) {
 	})
 }
 
+func TestUserService_UsernameTaken(t *testing.T) {
+	input := &model.CreateUserInput{
+		Username: "Taken",
+		Email:    "new@example.com",
+		Password: "password123",
+	}
+
+	t.Run("Register Rejected", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := service.NewUserService(mockRepo, service.UserServiceOptions{})
+		mockRepo.On("FindByEmail", input.Email).Return(nil, errors.New("not found")).Once()
+		mockRepo.On("UsernameTaken", "Taken", uint(0), true).Return(true, nil).Once()
+
+		dto, err := svc.Register(input)
+		assert.ErrorIs(t, err, service.ErrUsernameTaken)
+		assert.Nil(t, dto)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Case Sensitive", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := service.NewUserService(mockRepo, service.UserServiceOptions{UsernameCaseSensitive: true})
+		mockRepo.On("FindByEmail", input.Email).Return(nil, errors.New("not found")).Once()
+		mockRepo.On("UsernameTaken", "Taken", uint(0), false).Return(false, nil).Once()
+		mockRepo.On("Create", mock.AnythingOfType("*model.User")).Return(nil).Once()
+
+		_, err := svc.Register(input)
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Update Rejected", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := service.NewUserService(mockRepo, service.UserServiceOptions{})
+		mockRepo.On("FindByID", uint(3)).Return(&model.User{ID: 3, Username: "mine"}, nil).Once()
+		mockRepo.On("UsernameTaken", "Taken", uint(3), true).Return(true, nil).Once()
+
+		name := "Taken"
+		dto, err := svc.Update(3, &model.UpdateUserInput{Username: &name})
+		assert.ErrorIs(t, err, service.ErrUsernameTaken)
+		assert.Nil(t, dto)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Update Keeps Own Name", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := service.NewUserService(mockRepo, service.UserServiceOptions{})
+		mockRepo.On("FindByID", uint(3)).Return(&model.User{ID: 3, Username: "mine"}, nil).Once()
+		mockRepo.On("Update", uint(3), mock.AnythingOfType("*model.User")).Return(nil).Once()
+
+		name := "mine"
+		_, err := svc.Update(3, &model.UpdateUserInput{Username: &name})
+		require.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "UsernameTaken", mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
 func TestUserService_Authenticate(t *testing.T) {
 
 	mockRepo := new(MockUserRepo)
-	svc := service.NewUserService(mockRepo)
+	svc := service.NewUserService(mockRepo, service.UserServiceOptions{})
 
 	email := "test@example.com"
 	password := "password123"
@@ -177,7 +263,7 @@ func TestUserService_Authenticate(t *testing.T) {
 func TestUserService_Get(t *testing.T) {
 
 	mockRepo := new(MockUserRepo)
-	svc := service.NewUserService(mockRepo)
+	svc := service.NewUserService(mockRepo, service.UserServiceOptions{})
 
 	userID := uint(1)
 	user := &model.User{
@@ -214,7 +300,7 @@ func TestUserService_Get(t *testing.T) {
 
 func TestUserService_List(t *testing.T) {
 	mockRepo := new(MockUserRepo)
-	svc := service.NewUserService(mockRepo)
+	svc := service.NewUserService(mockRepo, service.UserServiceOptions{})
 
 	pagination := repository.Pagination{Page: 1, PageSize: 10}
 	users := []model.User{
@@ -276,7 +362,7 @@ func TestUserService_List(t *testing.T) {
 func TestUserService_Delete(t *testing.T) {
 
 	mockRepo := new(MockUserRepo)
-	svc := service.NewUserService(mockRepo)
+	svc := service.NewUserService(mockRepo, service.UserServiceOptions{})
 
 	userID := uint(1)
 
@@ -299,3 +385,304 @@ func TestUserService_Delete(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+func TestUserService_DeleteAccount(t *testing.T) {
+	userID := uint(1)
+	password := "password123"
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &model.User{ID: userID, Email: "gone@example.com", Password: string(hashedPassword)}
+
+	t.Run("Erases Account And Raw HTML", func(t *testing.T) {
+		blobs, err := storage.NewFileStore(t.TempDir())
+		require.NoError(t, err)
+		require.NoError(t, blobs.Put(context.Background(), "1/a.html", strings.NewReader("<html>")))
+
+		mockRepo := new(MockUserRepo)
+		svc := service.NewUserService(mockRepo, service.UserServiceOptions{RawHTML: blobs})
+		mockRepo.On("FindByID", userID).Return(user, nil).Once()
+		mockRepo.On("Erase", userID).Return(&repository.AccountErasure{
+			URLs:        1,
+			RawHTMLKeys: []string{"1/a.html", "1/already-gone.html"},
+		}, nil).Once()
+
+		require.NoError(t, svc.DeleteAccount(userID, password))
+
+		_, err = blobs.Get(context.Background(), "1/a.html")
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Wrong Password", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := service.NewUserService(mockRepo, service.UserServiceOptions{})
+		mockRepo.On("FindByID", userID).Return(user, nil).Once()
+
+		err := svc.DeleteAccount(userID, "not-it")
+		assert.ErrorIs(t, err, service.ErrWrongPassword)
+		mockRepo.AssertNotCalled(t, "Erase", mock.Anything)
+	})
+
+	t.Run("User Not Found", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := service.NewUserService(mockRepo, service.UserServiceOptions{})
+		mockRepo.On("FindByID", userID).Return(nil, gorm.ErrRecordNotFound).Once()
+
+		err := svc.DeleteAccount(userID, password)
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+
+	t.Run("Erase Fails", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := service.NewUserService(mockRepo, service.UserServiceOptions{})
+		mockRepo.On("FindByID", userID).Return(user, nil).Once()
+		mockRepo.On("Erase", userID).Return(nil, errors.New("db down")).Once()
+
+		err := svc.DeleteAccount(userID, password)
+		assert.EqualError(t, err, "db down")
+	})
+}
+
+func TestUserService_PasswordReset(t *testing.T) {
+	userID := uint(1)
+	secret := "reset-secret"
+	oldHash, err := bcrypt.GenerateFromPassword([]byte("forgotten"), bcrypt.MinCost)
+	require.NoError(t, err)
+	newUser := func() *model.User {
+		return &model.User{ID: userID, Email: "user@example.com", Password: string(oldHash)}
+	}
+	newService := func(repo *MockUserRepo, tokens *MockTokenRepository) service.UserService {
+		return service.NewUserService(repo, service.UserServiceOptions{
+			Tokens:    tokens,
+			JWTSecret: secret,
+			ResetTTL:  30 * time.Minute,
+		})
+	}
+
+	t.Run("Reset With Token", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		tokens := new(MockTokenRepository)
+		svc := newService(mockRepo, tokens)
+		user := newUser()
+
+		mockRepo.On("FindByEmail", "user@example.com").Return(user, nil).Once()
+		var issued *model.IssuedToken
+		tokens.On("AddIssued", mock.MatchedBy(func(ts []*model.IssuedToken) bool {
+			issued = ts[0]
+			return len(ts) == 1 && ts[0].UserID == userID && ts[0].Subject == "password_reset"
+		})).Return(nil).Once()
+
+		token, err := svc.RequestPasswordReset("user@example.com")
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+
+		tokens.On("Consume", issued.JTI, mock.AnythingOfType("time.Time")).Return(nil).Once()
+		mockRepo.On("FindByID", userID).Return(user, nil).Once()
+		mockRepo.On("Update", userID, mock.MatchedBy(func(u *model.User) bool {
+			return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte("brand-new")) == nil
+		})).Return(nil).Once()
+		tokens.On("RevokeUserTokens", userID, "").Return(3, nil).Once()
+
+		require.NoError(t, svc.ResetPassword(token, "brand-new"))
+		mockRepo.AssertExpectations(t)
+		tokens.AssertExpectations(t)
+	})
+
+	t.Run("Token Works Once", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		tokens := new(MockTokenRepository)
+		svc := newService(mockRepo, tokens)
+
+		mockRepo.On("FindByEmail", "user@example.com").Return(newUser(), nil).Once()
+		tokens.On("AddIssued", mock.Anything).Return(nil).Once()
+		token, err := svc.RequestPasswordReset("user@example.com")
+		require.NoError(t, err)
+
+		tokens.On("Consume", mock.Anything, mock.Anything).Return(repository.ErrTokenUsed).Once()
+		err = svc.ResetPassword(token, "brand-new")
+		assert.ErrorIs(t, err, service.ErrResetTokenInvalid)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown Email", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		tokens := new(MockTokenRepository)
+		svc := newService(mockRepo, tokens)
+		mockRepo.On("FindByEmail", "nobody@example.com").Return(nil, gorm.ErrRecordNotFound).Once()
+
+		token, err := svc.RequestPasswordReset("nobody@example.com")
+		assert.NoError(t, err)
+		assert.Empty(t, token)
+		tokens.AssertNotCalled(t, "AddIssued", mock.Anything)
+	})
+
+	t.Run("Rejects Other Tokens", func(t *testing.T) {
+		tokens := new(MockTokenRepository)
+		svc := newService(new(MockUserRepo), tokens)
+
+		access := jwt.NewWithClaims(jwt.SigningMethodHS256, service.Claims{
+			UserID: userID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "access-1",
+				Subject:   "access_token",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		})
+		accessToken, err := access.SignedString([]byte(secret))
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, svc.ResetPassword(accessToken, "brand-new"), service.ErrResetTokenInvalid)
+		assert.ErrorIs(t, svc.ResetPassword("garbage", "brand-new"), service.ErrResetTokenInvalid)
+		tokens.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Expired Token", func(t *testing.T) {
+		tokens := new(MockTokenRepository)
+		svc := newService(new(MockUserRepo), tokens)
+
+		expired := jwt.NewWithClaims(jwt.SigningMethodHS256, service.Claims{
+			UserID: userID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "reset-1",
+				Subject:   "password_reset",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+			},
+		})
+		expiredToken, err := expired.SignedString([]byte(secret))
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, svc.ResetPassword(expiredToken, "brand-new"), service.ErrResetTokenInvalid)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		svc := service.NewUserService(new(MockUserRepo), service.UserServiceOptions{})
+		_, err := svc.RequestPasswordReset("user@example.com")
+		assert.ErrorIs(t, err, service.ErrPasswordResetDisabled)
+	})
+}
+
+func TestUserService_EmailVerification(t *testing.T) {
+	userID := uint(1)
+	secret := "verify-secret"
+	newUser := func() *model.User {
+		return &model.User{ID: userID, Email: "user@example.com"}
+	}
+	newService := func(repo *MockUserRepo, resend time.Duration) service.UserService {
+		return service.NewUserService(repo, service.UserServiceOptions{
+			JWTSecret: secret,
+			EmailVerification: &service.EmailVerification{
+				TTL:            time.Hour,
+				ResendInterval: resend,
+			},
+		})
+	}
+
+	t.Run("Verify With Token", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := newService(mockRepo, time.Minute)
+
+		mockRepo.On("FindByID", userID).Return(newUser(), nil).Twice()
+		mockRepo.On("SetEmailVerified", userID, true).Return(nil).Once()
+
+		token, err := svc.RequestEmailVerification(userID)
+		require.NoError(t, err)
+		require.NotEmpty(t, token)
+
+		require.NoError(t, svc.VerifyEmail(token))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Already Verified", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := newService(mockRepo, time.Minute)
+		user := newUser()
+		user.EmailVerified = true
+		mockRepo.On("FindByID", userID).Return(user, nil).Once()
+
+		_, err := svc.RequestEmailVerification(userID)
+		assert.ErrorIs(t, err, service.ErrEmailAlreadyVerified)
+	})
+
+	t.Run("Resend Rate Limited", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := newService(mockRepo, time.Hour)
+		mockRepo.On("FindByID", userID).Return(newUser(), nil)
+		mockRepo.On("FindByID", uint(2)).Return(&model.User{ID: 2, Email: "other@example.com"}, nil)
+
+		_, err := svc.RequestEmailVerification(userID)
+		require.NoError(t, err)
+		_, err = svc.RequestEmailVerification(userID)
+		assert.ErrorIs(t, err, service.ErrVerificationRateLimited)
+
+		_, err = svc.RequestEmailVerification(2)
+		assert.NoError(t, err, "The limit applies per user")
+	})
+
+	t.Run("No Resend Limit", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := newService(mockRepo, 0)
+		mockRepo.On("FindByID", userID).Return(newUser(), nil)
+
+		_, err := svc.RequestEmailVerification(userID)
+		require.NoError(t, err)
+		_, err = svc.RequestEmailVerification(userID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Email Changed Since Issue", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := newService(mockRepo, time.Minute)
+		mockRepo.On("FindByID", userID).Return(newUser(), nil).Once()
+		token, err := svc.RequestEmailVerification(userID)
+		require.NoError(t, err)
+
+		mockRepo.On("FindByID", userID).Return(&model.User{ID: userID, Email: "new@example.com"}, nil).Once()
+		assert.ErrorIs(t, svc.VerifyEmail(token), service.ErrVerificationTokenInvalid)
+		mockRepo.AssertNotCalled(t, "SetEmailVerified", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects Other Tokens", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := newService(mockRepo, time.Minute)
+
+		reset := jwt.NewWithClaims(jwt.SigningMethodHS256, service.Claims{
+			UserID: userID,
+			Email:  "user@example.com",
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "reset-1",
+				Subject:   "password_reset",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		})
+		resetToken, err := reset.SignedString([]byte(secret))
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, svc.VerifyEmail(resetToken), service.ErrVerificationTokenInvalid)
+		assert.ErrorIs(t, svc.VerifyEmail("garbage"), service.ErrVerificationTokenInvalid)
+		mockRepo.AssertNotCalled(t, "FindByID", mock.Anything)
+	})
+
+	t.Run("Email Change Clears Verification", func(t *testing.T) {
+		mockRepo := new(MockUserRepo)
+		svc := newService(mockRepo, time.Minute)
+		user := newUser()
+		user.EmailVerified = true
+		newEmail := "new@example.com"
+
+		mockRepo.On("FindByID", userID).Return(user, nil).Once()
+		mockRepo.On("Update", userID, mock.Anything).Return(nil).Once()
+		mockRepo.On("SetEmailVerified", userID, false).Return(nil).Once()
+
+		dto, err := svc.Update(userID, &model.UpdateUserInput{Email: &newEmail})
+		require.NoError(t, err)
+		assert.False(t, dto.EmailVerified)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		svc := service.NewUserService(new(MockUserRepo), service.UserServiceOptions{})
+		_, err := svc.RequestEmailVerification(userID)
+		assert.ErrorIs(t, err, service.ErrEmailVerificationDisabled)
+		assert.ErrorIs(t, svc.VerifyEmail("token"), service.ErrEmailVerificationDisabled)
+	})
+}
@@ -0,0 +1,61 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/fuzumoe/linkTorch-api/internal/storage"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := storage.NewFileStore(filepath.Join(dir, "blobs"))
+	require.NoError(t, err)
+
+	t.Run("Round Trip", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, "raw/abc/1.html", strings.NewReader("<html>one</html>")))
+
+		r, err := store.Get(ctx, "raw/abc/1.html")
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		assert.Equal(t, "<html>one</html>", string(data))
+
+		require.NoError(t, store.Put(ctx, "raw/abc/1.html", strings.NewReader("<html>two</html>")))
+		r, err = store.Get(ctx, "raw/abc/1.html")
+		require.NoError(t, err)
+		data, _ = io.ReadAll(r)
+		r.Close()
+		assert.Equal(t, "<html>two</html>", string(data), "Put should overwrite an existing blob")
+
+		require.NoError(t, store.Delete(ctx, "raw/abc/1.html"))
+		_, err = store.Get(ctx, "raw/abc/1.html")
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+	})
+
+	t.Run("Missing Key", func(t *testing.T) {
+		_, err := store.Get(ctx, "raw/missing.html")
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+
+		err = store.Delete(ctx, "raw/missing.html")
+		assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+	})
+
+	t.Run("Invalid Keys", func(t *testing.T) {
+		for _, key := range []string{"", ".", "../outside.html", "raw/../../outside.html", "/etc/passwd"} {
+			err := store.Put(ctx, key, strings.NewReader("x"))
+			assert.ErrorIs(t, err, storage.ErrInvalidKey, "key %q", key)
+		}
+		_, err := os.Stat(filepath.Join(dir, "outside.html"))
+		assert.True(t, os.IsNotExist(err), "Nothing should be written outside the store")
+	})
+}
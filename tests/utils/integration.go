@@ -158,6 +158,7 @@ func CleanTestData(t *testing.T) {
 		&model.AnalysisResult{},
 		&model.URL{},
 		&model.BlacklistedToken{},
+		&model.NotificationPrefs{},
 	}
 
 	for _, m := range models {
@@ -33,6 +33,7 @@ func StartServer() {
 		os.Setenv("SERVER_PORT", port)
 
 		os.Setenv("PORT", port)
+		os.Setenv("METRICS_ENABLED", "false")
 
 		go func() {
 			if err := app.Run(); err != nil && err != http.ErrServerClosed {